@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"time"
+)
+
+// How often the deleted accounts past the grace period are erased.
+const accountJanitorInterval = time.Hour
+
+func runAccountJanitor(ctx context.Context, users service.User, tenants service.Tenants) {
+	ticker := time.NewTicker(accountJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// The users are erased tenant by tenant.
+			for _, tenant := range tenants.List() {
+				purged, err := users.PurgeDeleted(domain.WithTenant(ctx, tenant))
+				if err != nil {
+					logger.Error("runAccountJanitor() - PurgeDeleted",
+						logger.String("tenant", tenant.Id),
+						logger.NamedError("error", err),
+					)
+					continue
+				}
+
+				if purged > 0 {
+					logger.Info("Deleted accounts erased", logger.String("tenant", tenant.Id), logger.Int64("count", purged))
+				}
+			}
+		}
+	}
+}
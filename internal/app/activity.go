@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"time"
+)
+
+// How long the last write of the collected last online dates may take on shutdown.
+const activityFlushTimeout = 5 * time.Second
+
+func runActivityWriter(ctx context.Context, activity service.Activity, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The dates collected since the last tick aren't lost on shutdown.
+			flushCtx, cancel := context.WithTimeout(context.Background(), activityFlushTimeout)
+			flushActivity(flushCtx, activity)
+			cancel()
+			return
+		case <-ticker.C:
+			flushActivity(ctx, activity)
+		}
+	}
+}
+
+func flushActivity(ctx context.Context, activity service.Activity) {
+	if err := activity.Flush(ctx); err != nil {
+		logger.Error("runActivityWriter() - Flush",
+			logger.NamedError("error", err),
+		)
+	}
+}
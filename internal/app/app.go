@@ -8,10 +8,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"service-account/internal/audit"
 	"service-account/internal/config"
+	"service-account/internal/geoip"
+	"service-account/internal/notifier"
 	"service-account/internal/path"
 	"service-account/internal/repository"
 	"service-account/internal/service"
+	"service-account/internal/service/authn/ldap"
+	"service-account/internal/service/authn/upstream"
 	"service-account/internal/service/authz/oauth2"
 	"service-account/internal/transport/http/handler"
 	"service-account/internal/transport/http/server"
@@ -54,6 +59,23 @@ func Run() {
 	logger.ResetDefault(logger.NewTeeWithRotate(tops))
 	defer logger.Sync()
 
+	// The audit events are written to their own log besides the database.
+	auditLogger := logger.NewTeeWithRotate([]logger.TeeOption{
+		{
+			Filename: logsDir + "/audit.log",
+			Ropt: logger.RotateOptions{
+				MaxSize:    10,
+				MaxAge:     90,
+				MaxBackups: 30,
+				Compress:   true,
+			},
+			Lef: func(lvl logger.Level) bool {
+				return lvl >= logger.InfoLevel
+			},
+		},
+	})
+	defer auditLogger.Sync()
+
 	// Init config from file.
 	serviceConfig := config.NewConfig()
 	if err := serviceConfig.Init(path.ConfigFile); err != nil {
@@ -76,20 +98,100 @@ func Run() {
 
 	// Init dependencies.
 	userRepo := repository.NewUsersRepo(db)
+	roleRepo := repository.NewRolesRepo(db)
+	groupRepo := repository.NewGroupsRepo(db)
+	serviceAccountRepo := repository.NewServiceAccountsRepo(db)
+	auditRepo := repository.NewAuditRepo(db)
+	sessionRepo, closeSessionRepo := newSessionRepo(&serviceConfig.Session, db)
+	defer closeSessionRepo()
 	hasher := new(hash.HasherArgon2id)
 	depends := &service.Depends{
-		UserRepo: userRepo,
-		Hasher:   hasher,
+		UserRepo:           userRepo,
+		RoleRepo:           roleRepo,
+		GroupRepo:          groupRepo,
+		SessionRepo:        sessionRepo,
+		AuditRepo:          auditRepo,
+		Hasher:             hasher,
+		ServiceAccountRepo: serviceAccountRepo,
 	}
 
 	oa2 := oauth2.NewOAuth2Service(&serviceConfig.OAuth2)
-	userService := service.NewUserSerices(depends.UserRepo, depends.Hasher, serviceConfig)
+	// The staff sign in against the directory, the other users with the local passwords.
+	var backend service.CredentialBackend
+	if serviceConfig.LDAP.Enabled {
+		directory, err := ldap.NewDirectory(&serviceConfig.LDAP)
+		if err != nil {
+			logger.Error("Init LDAP directory", logger.NamedError("error", err))
+			return
+		}
+
+		backend = directory
+	}
+
+	userService := service.NewUserSerices(depends.UserRepo, depends.RoleRepo, depends.Hasher, backend, serviceConfig)
+	rbacService := service.NewRBACService(depends.RoleRepo)
+	sessionService, err := service.NewSessionService(depends.SessionRepo, &serviceConfig.Session)
+	if err != nil {
+		logger.Error("Init session service", logger.NamedError("error", err))
+		return
+	}
+
+	activityService := service.NewActivityService(depends.UserRepo, &serviceConfig.Account)
+	auditService := service.NewAuditService(depends.AuditRepo, audit.NewLogSink(auditLogger))
+	serviceNotifier := notifier.NewLogNotifier()
+
+	// The sign-ins are located only if the MaxMind database is set.
+	var locator service.GeoLocator
+	if serviceConfig.Device.GeoIPDatabase != "" {
+		maxMindLocator, err := geoip.NewMaxMindLocator(serviceConfig.Device.GeoIPDatabase)
+		if err != nil {
+			logger.Error("Init GeoIP database", logger.NamedError("error", err))
+			return
+		}
+		defer maxMindLocator.Close()
+
+		locator = maxMindLocator
+	}
+
+	deviceService, err := service.NewDeviceService(depends.UserRepo, auditService, serviceNotifier, locator, &serviceConfig.Device)
+	if err != nil {
+		logger.Error("Init device service", logger.NamedError("error", err))
+		return
+	}
+
+	riskService, err := service.NewRiskService(depends.UserRepo, auditService, serviceNotifier, locator, &serviceConfig.Risk)
+	if err != nil {
+		logger.Error("Init risk service", logger.NamedError("error", err))
+		return
+	}
+
+	tenantService := service.NewTenantService(serviceConfig)
+
+	identityService := service.NewIdentityService(depends.UserRepo, upstream.NewProviders(&serviceConfig.IdP), auditService, tenantService, &serviceConfig.IdP)
+
+	scimService := service.NewSCIMService(depends.UserRepo, depends.GroupRepo, depends.Hasher, oa2, sessionService, auditService, serviceConfig)
+
+	groupService := service.NewGroupService(depends.GroupRepo, rbacService, auditService)
+
+	serviceAccountService := service.NewServiceAccountService(depends.ServiceAccountRepo, depends.GroupRepo, oa2, rbacService, auditService, &serviceConfig.ServiceAccounts)
 
 	services := service.NewService(
 		serviceConfig,
 		depends,
 		oa2,
 		userService,
+		rbacService,
+		sessionService,
+		serviceNotifier,
+		auditService,
+		activityService,
+		deviceService,
+		riskService,
+		identityService,
+		scimService,
+		tenantService,
+		groupService,
+		serviceAccountService,
 	)
 
 	// Init HTTP handlers.
@@ -111,6 +213,17 @@ func Run() {
 		}
 	}()
 
+	// Delete the expired sessions, erase the deleted accounts and write the users' activity in background.
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	go runSessionJanitor(janitorCtx, sessionService)
+	go runAccountJanitor(janitorCtx, userService, tenantService)
+	activityDone := make(chan struct{})
+	go func() {
+		runActivityWriter(janitorCtx, activityService, serviceConfig.Account.ActivityFlushInterval)
+		close(activityDone)
+	}()
+
 	logger.Info("Services started")
 
 	// Graceful shutdown.
@@ -130,5 +243,9 @@ func Run() {
 		)
 	}
 
+	// Write the activity of the last requests.
+	stopJanitor()
+	<-activityDone
+
 	logger.Info("Services exited properly")
 }
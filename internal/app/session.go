@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"service-account/internal/config"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"time"
+)
+
+// How often the expired sessions are deleted from the store.
+const sessionJanitorInterval = 10 * time.Minute
+
+// newSessionRepo makes the configured session store, the returned function closes its connection.
+func newSessionRepo(sessionConfig *config.SessionConfig, db *gorm.DB) (service.SessionRepository, func()) {
+	switch sessionConfig.Store {
+	case config.SessionStorePostgres:
+		return repository.NewSessionsRepoGorm(db), func() {}
+	case config.SessionStoreRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     sessionConfig.Redis.Addr,
+			Password: sessionConfig.Redis.Password,
+			DB:       sessionConfig.Redis.DB,
+		})
+		return repository.NewSessionsRepoRedis(client), func() {
+			if err := client.Close(); err != nil {
+				logger.Error("Close redis client", logger.NamedError("error", err))
+			}
+		}
+	default:
+		return repository.NewSessionsRepoMemory(), func() {}
+	}
+}
+
+func runSessionJanitor(ctx context.Context, sessions service.Sessions) {
+	ticker := time.NewTicker(sessionJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := sessions.DeleteExpired(ctx); err != nil {
+				logger.Error("runSessionJanitor() - DeleteExpired",
+					logger.NamedError("error", err),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"service-account/internal/domain"
+	"service-account/pkg/logger"
+)
+
+// LogSink writes the audit events to the dedicated log, it's kept apart from the access and error logs
+// to be shipped and retained on its own.
+type LogSink struct {
+	logger *logger.Logger
+}
+
+func NewLogSink(logger *logger.Logger) *LogSink {
+	return &LogSink{
+		logger: logger,
+	}
+}
+
+func (s *LogSink) Write(event *domain.AuditEvent) {
+	s.logger.Info("Audit",
+		logger.Uint64("id", event.Id),
+		logger.String("action", event.Action),
+		logger.String("outcome", event.Outcome),
+		logger.Uint32p("actor_id", event.ActorId),
+		logger.Uint32p("user_id", event.UserId),
+		logger.String("reason", event.Reason),
+		logger.Any("data", event.Data),
+		logger.String("ip", event.Ip),
+		logger.String("user_agent", event.UserAgent),
+		logger.String("client_id", event.ClientId),
+		logger.String("challenge", event.Challenge),
+		logger.Time("date_created", event.DateCreated),
+	)
+}
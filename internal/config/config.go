@@ -4,17 +4,74 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/ory/viper"
+	"strings"
+	"time"
 )
 
 const (
-	defHttpAddr = "0.0.0.0"
-	defHttpPort = 3000
+	defHttpAddr               = "0.0.0.0"
+	defHttpPort               = 3000
+	defSessionStore           = SessionStoreMemory
+	defSessionIdleTimeout     = 30 * time.Minute
+	defSessionAbsoluteTimeout = 24 * time.Hour
+	defPasswordMinLength      = 8
+	defDeletionGracePeriod    = 30 * 24 * time.Hour
+	defLockoutThreshold       = 5
+	defLockoutDuration        = 15 * time.Minute
+	defActivityThrottle       = 5 * time.Minute
+	defActivityFlushInterval  = 30 * time.Second
+	defDeviceCookieMaxAge     = 365 * 24 * time.Hour
+	defRiskMediumScore        = 30
+	defRiskHighScore          = 70
+	defRiskNewDeviceScore     = 30
+	defRiskTravelScore        = 50
+	defRiskTravelSpeed        = 1000
+	defRiskDenylistScore      = 100
+	defRiskFailuresScore      = 20
+	defRiskFailuresThreshold  = 3
+	defRiskFailuresWindow     = time.Hour
+	defRiskUnusualHourScore   = 10
+	defRiskUsualHoursSignins  = 5
+	defRiskOTPTTL             = 10 * time.Minute
+	defRiskOTPMaxAttempts     = 5
+	defIdPLoginTTL            = 10 * time.Minute
+	defLDAPTimeout            = 10 * time.Second
+	defLDAPUserFilter         = "(&(objectClass=person)(|(uid={login})(mail={login})))"
+	defLDAPIdAttribute        = "entryUUID"
+	defLDAPUsernameAttribute  = "uid"
+	defLDAPEmailAttribute     = "mail"
+	defLDAPNameAttribute      = "cn"
+	defLDAPGroupAttribute     = "memberOf"
+	defBrandingProductName    = "Account"
+	defSAKeyMaxLifetime       = 365 * 24 * time.Hour
+	defSAKeyRotationGrace     = 24 * time.Hour
+	defSAKeyLastUsedThrottle  = 5 * time.Minute
+)
+
+// Session stores.
+const (
+	SessionStoreMemory   = "memory"
+	SessionStorePostgres = "postgres"
+	SessionStoreRedis    = "redis"
 )
 
 type Config struct {
-	HTTP   HTTPConfig   `mapstructure:"http"`
-	OAuth2 OAuth2Config `mapstructure:"oauth2"`
-	DB     Database     `mapstructure:"database"`
+	HTTP     HTTPConfig     `mapstructure:"http"`
+	OAuth2   OAuth2Config   `mapstructure:"oauth2"`
+	DB       Database       `mapstructure:"database"`
+	Session  SessionConfig  `mapstructure:"session"`
+	Password PasswordConfig `mapstructure:"password"`
+	Account  AccountConfig  `mapstructure:"account"`
+	Device   DeviceConfig   `mapstructure:"device"`
+	Risk     RiskConfig     `mapstructure:"risk"`
+	IdP      IdPConfig      `mapstructure:"identity_providers"`
+	LDAP     LDAPConfig     `mapstructure:"ldap"`
+	SCIM     SCIMConfig     `mapstructure:"scim"`
+	// ServiceAccounts is the API keys of the non-human accounts.
+	ServiceAccounts ServiceAccountsConfig `mapstructure:"service_accounts"`
+	// Branding is the default tenant's look.
+	Branding BrandingConfig `mapstructure:"branding"`
+	Tenants  []TenantConfig `mapstructure:"tenants" validate:"dive"`
 }
 
 type HTTPConfig struct {
@@ -30,6 +87,8 @@ type OAuth2Config struct {
 	ClientSecret string `mapstructure:"client_secret" validate:"required"`
 	HydraProto   string `mapstructure:"hydra_proto" validate:"required"`
 	RedirectAddr string `mapstructure:"redirect_addr" validate:"required"`
+	// RolesClaim emits the user's roles as the "roles" claim of the tokens.
+	RolesClaim bool `mapstructure:"roles_claim"`
 	// Init based on data.
 	HydraPublicURL           string `mapstructure:"hydra_public_host" validate:"required"`
 	HydraPublicURLPrivateLan string `mapstructure:"hydra_public_host_private_lan" validate:"required"`
@@ -47,6 +106,173 @@ type Database struct {
 	Salt string `mapstructure:"salt" validate:"required"`
 }
 
+type SessionConfig struct {
+	Store string `mapstructure:"store" validate:"oneof=memory postgres redis"`
+	// EncryptionKey is the base64 encoded 32 bytes AES-256 key, the session data is stored unencrypted if it's empty.
+	EncryptionKey string `mapstructure:"encryption_key"`
+	// IdleTimeout ends the session after the period of inactivity.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout" validate:"gt=0"`
+	// AbsoluteTimeout ends the session after the period since the sign in regardless of the activity.
+	AbsoluteTimeout time.Duration `mapstructure:"absolute_timeout" validate:"gt=0"`
+	Redis           RedisConfig   `mapstructure:"redis"`
+}
+
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+}
+
+// PasswordConfig is the password policy.
+type PasswordConfig struct {
+	MinLength int `mapstructure:"min_length" validate:"gt=0"`
+}
+
+type AccountConfig struct {
+	// DeletionGracePeriod is how long the deleted account can be restored before it's erased.
+	DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period" validate:"gt=0"`
+	// LockoutThreshold is the number of the failed sign-ins in a row that lock the user out, 0 disables the lockout.
+	LockoutThreshold int           `mapstructure:"lockout_threshold" validate:"gte=0"`
+	LockoutDuration  time.Duration `mapstructure:"lockout_duration" validate:"gt=0"`
+	// ActivityThrottle is how often the user's last online date is updated by the API requests at most.
+	ActivityThrottle time.Duration `mapstructure:"activity_throttle" validate:"gt=0"`
+	// ActivityFlushInterval is how often the collected last online dates are written.
+	ActivityFlushInterval time.Duration `mapstructure:"activity_flush_interval" validate:"gt=0"`
+}
+
+// SCIMConfig is the provisioning clients allowed to call the SCIM API with their own tokens (the client credentials grant),
+// the users' tokens are rejected even if they're granted the "scim" scope.
+type SCIMConfig struct {
+	Clients []string `mapstructure:"clients" validate:"dive,required"`
+}
+
+// ServiceAccountsConfig is the lifetime of the service accounts' API keys.
+type ServiceAccountsConfig struct {
+	// KeyMaxLifetime is how long the key is valid at most, 0 allows the keys that never expire.
+	KeyMaxLifetime time.Duration `mapstructure:"key_max_lifetime" validate:"gte=0"`
+	// KeyRotationGrace is how long the rotated key stays valid, so the services switch to the new one.
+	KeyRotationGrace time.Duration `mapstructure:"key_rotation_grace" validate:"gte=0"`
+	// KeyLastUsedThrottle is how often the key's last used date is updated by the requests at most.
+	KeyLastUsedThrottle time.Duration `mapstructure:"key_last_used_throttle" validate:"gt=0"`
+}
+
+type DeviceConfig struct {
+	// CookieKey is the base64 encoded key (32 bytes at least) signing the device cookie,
+	// the devices aren't recognized if it's empty.
+	CookieKey    string        `mapstructure:"cookie_key"`
+	CookieMaxAge time.Duration `mapstructure:"cookie_max_age" validate:"gt=0"`
+	// GeoIPDatabase is the MaxMind database file (e.g. GeoLite2-City.mmdb) locating the sign-ins,
+	// the locations are unknown if it's empty.
+	GeoIPDatabase string `mapstructure:"geoip_database"`
+}
+
+// RiskConfig is the rules of the risk-based sign-in. The score of the sign-in is the sum of the scores of its signals,
+// the signal with the score 0 is ignored. The medium risk requires the one-time code sent to the user's email,
+// the high risk rejects the sign-in.
+type RiskConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	MediumScore int  `mapstructure:"medium_score" validate:"gt=0"`
+	HighScore   int  `mapstructure:"high_score" validate:"gtfield=MediumScore"`
+	// NewDeviceScore the user signs in from the unrecognized device, it requires the device cookie key.
+	NewDeviceScore int `mapstructure:"new_device_score" validate:"gte=0"`
+	// ImpossibleTravelScore the user would travel from the last sign-in's location faster than ImpossibleTravelSpeed km/h,
+	// it requires the GeoIP database with the coordinates.
+	ImpossibleTravelScore int     `mapstructure:"impossible_travel_score" validate:"gte=0"`
+	ImpossibleTravelSpeed float64 `mapstructure:"impossible_travel_speed" validate:"gt=0"`
+	// DenylistScore the IP is in DenylistFile, the file lists the IPs and CIDRs one per line, "#" starts the comment.
+	DenylistScore int    `mapstructure:"denylist_score" validate:"gte=0"`
+	DenylistFile  string `mapstructure:"denylist_file"`
+	// RecentFailuresScore the user's sign-in failed RecentFailuresThreshold times in RecentFailuresWindow.
+	RecentFailuresScore     int           `mapstructure:"recent_failures_score" validate:"gte=0"`
+	RecentFailuresThreshold int           `mapstructure:"recent_failures_threshold" validate:"gt=0"`
+	RecentFailuresWindow    time.Duration `mapstructure:"recent_failures_window" validate:"gt=0"`
+	// UnusualHourScore none of the user's recent sign-ins were within an hour of the time of the day,
+	// it's ignored until the user signed in UsualHoursSignins times.
+	UnusualHourScore  int `mapstructure:"unusual_hour_score" validate:"gte=0"`
+	UsualHoursSignins int `mapstructure:"usual_hours_signins" validate:"gt=0"`
+	// OTPTTL is how long the one-time code is valid, OTPMaxAttempts is how many codes can be tried.
+	OTPTTL         time.Duration `mapstructure:"otp_ttl" validate:"gt=0"`
+	OTPMaxAttempts int           `mapstructure:"otp_max_attempts" validate:"gt=0"`
+}
+
+// IdPConfig is the upstream OpenID Connect providers the users sign in with.
+// The sign-in with the provider is completed within LoginTTL.
+type IdPConfig struct {
+	LoginTTL  time.Duration      `mapstructure:"login_ttl" validate:"gt=0"`
+	Providers []IdentityProvider `mapstructure:"providers" validate:"dive"`
+	// Init based on data.
+	CallbackURL string
+}
+
+// IdentityProvider is the upstream provider, it's discovered by the issuer's "/.well-known/openid-configuration".
+// The client is registered at the provider with the callback URL "<redirect_addr>/signin/idp/callback".
+type IdentityProvider struct {
+	Id           string   `mapstructure:"id" validate:"required,alphanum"`
+	Name         string   `mapstructure:"name" validate:"required"`
+	Issuer       string   `mapstructure:"issuer" validate:"required,url"`
+	ClientID     string   `mapstructure:"client_id" validate:"required"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// LDAPConfig is the directory (e.g. OpenLDAP or Active Directory) the staff signs in with instead of the local passwords.
+// The service binds as BindDN, finds the user's entry by UserFilter under BaseDN and binds as the entry with the password.
+// The logins unknown to the directory sign in with the local passwords.
+type LDAPConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// URL is "ldap://host:389" or "ldaps://host:636", StartTLS upgrades the plain connection.
+	URL      string `mapstructure:"url" validate:"required_if=Enabled true"`
+	StartTLS bool   `mapstructure:"start_tls"`
+	// CAFile is the PEM file of the directory's CA, the system's CAs are used if it's empty.
+	CAFile             string        `mapstructure:"ca_file"`
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"`
+	Timeout            time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	BindDN             string        `mapstructure:"bind_dn"`
+	BindPassword       string        `mapstructure:"bind_password"`
+	BaseDN             string        `mapstructure:"base_dn" validate:"required_if=Enabled true"`
+	// UserFilter finds the entry, "{login}" is replaced with the escaped login.
+	UserFilter string `mapstructure:"user_filter"`
+	// IdAttribute is the entry's immutable ID (e.g. entryUUID or objectGUID), the entry's DN if it's empty.
+	IdAttribute       string `mapstructure:"id_attribute"`
+	UsernameAttribute string `mapstructure:"username_attribute"`
+	EmailAttribute    string `mapstructure:"email_attribute"`
+	NameAttribute     string `mapstructure:"name_attribute"`
+	GroupAttribute    string `mapstructure:"group_attribute"`
+	// GroupRoles are assigned to the members of the groups and revoked from the others at the sign-in.
+	GroupRoles []GroupRole `mapstructure:"group_roles" validate:"dive"`
+}
+
+// GroupRole maps the directory group's DN to the role.
+type GroupRole struct {
+	Group string `mapstructure:"group" validate:"required"`
+	Role  string `mapstructure:"role" validate:"required"`
+}
+
+// TenantConfig is the product served by the service, its users are isolated from the other tenants' users.
+// The tenant of the request is resolved by the host or the "/t/<id>" path prefix, the tenant of the login
+// and consent is the one of the Hydra client. The requests and clients of no tenant are the default tenant's ones.
+type TenantConfig struct {
+	Id   string `mapstructure:"id" validate:"required,alphanum,ne=default"`
+	Name string `mapstructure:"name" validate:"required"`
+	// URL is the tenant's account pages, "<redirect_addr>/t/<id>" if it's empty.
+	URL     string   `mapstructure:"url" validate:"omitempty,url"`
+	Hosts   []string `mapstructure:"hosts" validate:"dive,hostname_port|hostname"`
+	Clients []string `mapstructure:"clients" validate:"dive,required"`
+	// Branding is the tenant's look, the tenant's name is the product name if it's empty.
+	Branding BrandingConfig `mapstructure:"branding"`
+	// PasswordMinLength overrides password.min_length if it's set.
+	PasswordMinLength int `mapstructure:"password_min_length" validate:"gte=0"`
+	// RolesClaim overrides oauth2.roles_claim if it's set.
+	RolesClaim *bool `mapstructure:"roles_claim"`
+}
+
+// BrandingConfig is the look of the sign-in, consent and account pages.
+type BrandingConfig struct {
+	ProductName  string `mapstructure:"product_name"`
+	LogoURL      string `mapstructure:"logo_url" validate:"omitempty,url"`
+	PrimaryColor string `mapstructure:"primary_color" validate:"omitempty,hexcolor"`
+}
+
 func NewConfig() *Config {
 	return &Config{}
 }
@@ -77,6 +303,41 @@ func (config *Config) Init(configPath string) error {
 func (config *Config) setDefault() {
 	viper.SetDefault("http.listen_addr", defHttpAddr)
 	viper.SetDefault("http.port", defHttpPort)
+	viper.SetDefault("session.store", defSessionStore)
+	viper.SetDefault("session.idle_timeout", defSessionIdleTimeout)
+	viper.SetDefault("session.absolute_timeout", defSessionAbsoluteTimeout)
+	viper.SetDefault("password.min_length", defPasswordMinLength)
+	viper.SetDefault("account.deletion_grace_period", defDeletionGracePeriod)
+	viper.SetDefault("account.lockout_threshold", defLockoutThreshold)
+	viper.SetDefault("account.lockout_duration", defLockoutDuration)
+	viper.SetDefault("account.activity_throttle", defActivityThrottle)
+	viper.SetDefault("account.activity_flush_interval", defActivityFlushInterval)
+	viper.SetDefault("device.cookie_max_age", defDeviceCookieMaxAge)
+	viper.SetDefault("risk.medium_score", defRiskMediumScore)
+	viper.SetDefault("risk.high_score", defRiskHighScore)
+	viper.SetDefault("risk.new_device_score", defRiskNewDeviceScore)
+	viper.SetDefault("risk.impossible_travel_score", defRiskTravelScore)
+	viper.SetDefault("risk.impossible_travel_speed", defRiskTravelSpeed)
+	viper.SetDefault("risk.denylist_score", defRiskDenylistScore)
+	viper.SetDefault("risk.recent_failures_score", defRiskFailuresScore)
+	viper.SetDefault("risk.recent_failures_threshold", defRiskFailuresThreshold)
+	viper.SetDefault("risk.recent_failures_window", defRiskFailuresWindow)
+	viper.SetDefault("risk.unusual_hour_score", defRiskUnusualHourScore)
+	viper.SetDefault("risk.usual_hours_signins", defRiskUsualHoursSignins)
+	viper.SetDefault("risk.otp_ttl", defRiskOTPTTL)
+	viper.SetDefault("risk.otp_max_attempts", defRiskOTPMaxAttempts)
+	viper.SetDefault("identity_providers.login_ttl", defIdPLoginTTL)
+	viper.SetDefault("ldap.timeout", defLDAPTimeout)
+	viper.SetDefault("ldap.user_filter", defLDAPUserFilter)
+	viper.SetDefault("ldap.id_attribute", defLDAPIdAttribute)
+	viper.SetDefault("ldap.username_attribute", defLDAPUsernameAttribute)
+	viper.SetDefault("ldap.email_attribute", defLDAPEmailAttribute)
+	viper.SetDefault("ldap.name_attribute", defLDAPNameAttribute)
+	viper.SetDefault("ldap.group_attribute", defLDAPGroupAttribute)
+	viper.SetDefault("branding.product_name", defBrandingProductName)
+	viper.SetDefault("service_accounts.key_max_lifetime", defSAKeyMaxLifetime)
+	viper.SetDefault("service_accounts.key_rotation_grace", defSAKeyRotationGrace)
+	viper.SetDefault("service_accounts.key_last_used_throttle", defSAKeyLastUsedThrottle)
 }
 
 func (config *Config) parseConfig(configPath string) error {
@@ -104,6 +365,35 @@ func (config *Config) unmarshal() error {
 		return fmt.Errorf("Initializing the configuration: Missing required attributes %w\n", err)
 	}
 
+	return config.validateTenants()
+}
+
+// validateTenants checks the tenants' ids, hosts and clients are unique, so every request and client has one tenant.
+func (config *Config) validateTenants() error {
+	ids := map[string]bool{}
+	hosts := map[string]bool{}
+	clients := map[string]bool{}
+	for _, tenant := range config.Tenants {
+		if ids[tenant.Id] {
+			return fmt.Errorf("Initializing the configuration: Duplicate tenant %q\n", tenant.Id)
+		}
+		ids[tenant.Id] = true
+
+		for _, host := range tenant.Hosts {
+			if hosts[strings.ToLower(host)] {
+				return fmt.Errorf("Initializing the configuration: Host %q of the tenant %q belongs to another tenant\n", host, tenant.Id)
+			}
+			hosts[strings.ToLower(host)] = true
+		}
+
+		for _, client := range tenant.Clients {
+			if clients[client] {
+				return fmt.Errorf("Initializing the configuration: Client %q of the tenant %q belongs to another tenant\n", client, tenant.Id)
+			}
+			clients[client] = true
+		}
+	}
+
 	return nil
 }
 
@@ -136,6 +426,10 @@ func (config *Config) getEnv() {
 		config.OAuth2.RedirectAddr = envar
 	}
 
+	if envar := viper.GetString("SERVICE_ACCOUNT_ROLES_CLAIM"); envar != "" {
+		config.OAuth2.RolesClaim = viper.GetBool("SERVICE_ACCOUNT_ROLES_CLAIM")
+	}
+
 	if envar := viper.GetString("SERVICE_ACCOUNT_DSN"); envar != "" {
 		config.DB.DSN = envar
 	}
@@ -143,6 +437,58 @@ func (config *Config) getEnv() {
 	if envar := viper.GetString("SERVICE_ACCOUNT_SALT"); envar != "" {
 		config.DB.Salt = envar
 	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_SESSION_STORE"); envar != "" {
+		config.Session.Store = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_SESSION_ENCRYPTION_KEY"); envar != "" {
+		config.Session.EncryptionKey = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_REDIS_ADDR"); envar != "" {
+		config.Session.Redis.Addr = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_REDIS_PASSWORD"); envar != "" {
+		config.Session.Redis.Password = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_DEVICE_COOKIE_KEY"); envar != "" {
+		config.Device.CookieKey = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_GEOIP_DATABASE"); envar != "" {
+		config.Device.GeoIPDatabase = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_RISK_ENABLED"); envar != "" {
+		config.Risk.Enabled = viper.GetBool("SERVICE_ACCOUNT_RISK_ENABLED")
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_RISK_DENYLIST_FILE"); envar != "" {
+		config.Risk.DenylistFile = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_LDAP_ENABLED"); envar != "" {
+		config.LDAP.Enabled = viper.GetBool("SERVICE_ACCOUNT_LDAP_ENABLED")
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_LDAP_URL"); envar != "" {
+		config.LDAP.URL = envar
+	}
+
+	if envar := viper.GetString("SERVICE_ACCOUNT_LDAP_BIND_PASSWORD"); envar != "" {
+		config.LDAP.BindPassword = envar
+	}
+
+	// The provider's client secret, e.g. SERVICE_ACCOUNT_IDP_KEYCLOAK_CLIENT_SECRET.
+	for i := range config.IdP.Providers {
+		provider := &config.IdP.Providers[i]
+		if envar := viper.GetString(fmt.Sprintf("SERVICE_ACCOUNT_IDP_%s_CLIENT_SECRET", strings.ToUpper(provider.Id))); envar != "" {
+			provider.ClientSecret = envar
+		}
+	}
 }
 
 func (config *Config) initСompositeFields() {
@@ -155,4 +501,12 @@ func (config *Config) initСompositeFields() {
 	config.OAuth2.RedirectURLCallback = fmt.Sprintf("%s/callback", config.OAuth2.RedirectURL)
 	config.OAuth2.Backend = fmt.Sprintf("%s%s", config.OAuth2.HydraPublicURLPrivateLan, "/oauth2/token")
 	config.OAuth2.Frontend = fmt.Sprintf("%s%s", config.OAuth2.HydraPublicURL, "/oauth2/auth")
+	config.IdP.CallbackURL = fmt.Sprintf("%s/signin/idp/callback", config.OAuth2.RedirectURL)
+	for i := range config.Tenants {
+		tenant := &config.Tenants[i]
+		if tenant.URL == "" {
+			tenant.URL = fmt.Sprintf("%s/t/%s", config.OAuth2.RedirectURL, tenant.Id)
+		}
+		tenant.URL = strings.TrimSuffix(tenant.URL, "/")
+	}
 }
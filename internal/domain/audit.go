@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Audited actions.
+const (
+	AuditActionSignin          = "user.signin"
+	AuditActionSignup          = "user.signup"
+	AuditActionLogout          = "user.logout"
+	AuditActionConsent         = "user.consent"
+	AuditActionPasswordChanged = "user.password_changed"
+	AuditActionUserSuspended   = "user.suspended"
+	AuditActionUserUnsuspended = "user.unsuspended"
+	AuditActionUserUnlocked    = "user.unlocked"
+	AuditActionUserLoggedOut   = "user.logged_out"
+	AuditActionRoleAssigned    = "role.assigned"
+	AuditActionRoleRevoked     = "role.revoked"
+	// The identity provider was linked to or unlinked from the account, or the password was removed.
+	AuditActionIdentityLinked   = "user.identity_linked"
+	AuditActionIdentityUnlinked = "user.identity_unlinked"
+	// The users and groups were provisioned by the HR system through SCIM.
+	AuditActionUserProvisioned   = "user.provisioned"
+	AuditActionUserUpdated       = "user.updated"
+	AuditActionUserDeprovisioned = "user.deprovisioned"
+	AuditActionGroupCreated      = "group.created"
+	AuditActionGroupUpdated      = "group.updated"
+	AuditActionGroupDeleted      = "group.deleted"
+	// The organizations' groups and their members were managed through the API.
+	AuditActionOrganizationCreated = "organization.created"
+	AuditActionGroupMemberSet      = "group.member_set"
+	AuditActionGroupMemberRemoved  = "group.member_removed"
+	// The service accounts and their API keys were managed through the API.
+	AuditActionServiceAccountCreated    = "service_account.created"
+	AuditActionServiceAccountUpdated    = "service_account.updated"
+	AuditActionServiceAccountDeleted    = "service_account.deleted"
+	AuditActionServiceAccountKeyCreated = "service_account.key_created"
+	AuditActionServiceAccountKeyRotated = "service_account.key_rotated"
+	AuditActionServiceAccountKeyRevoked = "service_account.key_revoked"
+)
+
+// Outcomes of the audited actions.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	// AuditOutcomeDenied the user or the service refused the action.
+	AuditOutcomeDenied = "denied"
+	// AuditOutcomeChallenged the action waits for the second factor, e.g. the one-time code of the risky sign-in.
+	AuditOutcomeChallenged = "challenged"
+)
+
+// AuditEvent is the append-only record of the action.
+type AuditEvent struct {
+	Id uint64 `json:"id"`
+	// TenantId is the tenant the action was done in, the tenant's admins only read its events.
+	TenantId string `json:"-"`
+	Action   string `json:"action"`
+	Outcome  string `json:"outcome"`
+	// ActorId is the user who did the action.
+	ActorId *uint32 `json:"actor_id"`
+	// UserId is the user the action was done to.
+	UserId      *uint32           `json:"user_id"`
+	Reason      string            `json:"reason"`
+	Data        map[string]string `json:"data" gorm:"serializer:json"`
+	Ip          string            `json:"ip"`
+	UserAgent   string            `json:"user_agent"`
+	ClientId    string            `json:"client_id"`
+	Challenge   string            `json:"challenge"`
+	DateCreated time.Time         `json:"date_created"`
+}
+
+// AuditQuery is the page of the audit log, the newest events first. The empty filters don't filter.
+type AuditQuery struct {
+	ActorId *uint32
+	UserId  *uint32
+	// SubjectId matches the events the user did or the ones done to the user.
+	SubjectId *uint32
+	Action    string
+	Outcome   string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	BeforeId  uint64
+}
+
+// AuditPage is the page of the audit log.
+type AuditPage struct {
+	Events []AuditEvent `json:"events"`
+	// NextCursor reads the next page, it's empty on the last page.
+	NextCursor string `json:"next_cursor"`
+}
+
+// RequestInfo is the client of the request the action was done by.
+type RequestInfo struct {
+	Ip        string
+	UserAgent string
+}
+
+// WithRequestInfo returns the copy of the context the request's client is known in.
+func WithRequestInfo(ctx context.Context, requestInfo *RequestInfo) context.Context {
+	return context.WithValue(ctx, contextKeyRequestInfo, requestInfo)
+}
+
+// RequestInfoFromContext returns the context's request client, it's nil if the context has none.
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	requestInfo, _ := ctx.Value(contextKeyRequestInfo).(*RequestInfo)
+	return requestInfo
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// UserDevice is the device the user signed in from, it's recognized by the signed device cookie.
+type UserDevice struct {
+	UserId        uint32
+	DeviceId      string
+	UserAgent     string
+	Ip            string
+	DateFirstSeen time.Time
+	DateLastSeen  time.Time
+}
+
+// LoginRecord is the sign-in of the user's login history.
+type LoginRecord struct {
+	Date    time.Time `json:"date"`
+	Outcome string    `json:"outcome"`
+	Ip      string    `json:"ip"`
+	// Location is the approximate location of the IP, it's empty if it's unknown.
+	Location string `json:"location"`
+	// Device is the browser and the OS of the user agent.
+	Device string `json:"device"`
+	// NewDevice the sign-in came from the unrecognized device.
+	NewDevice bool `json:"new_device"`
+}
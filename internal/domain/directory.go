@@ -0,0 +1,16 @@
+package domain
+
+// IdentityProviderDirectory is the provider of the identities linking the users to their directory entries.
+const IdentityProviderDirectory = "ldap"
+
+// DirectoryEntry is the user's entry in the external directory, e.g. the LDAP one.
+type DirectoryEntry struct {
+	// Id is the entry's immutable ID, it's the subject of the user's identity.
+	Id          string
+	DN          string
+	Username    string
+	Email       string
+	DisplayName string
+	// Groups are the DNs of the entry's groups.
+	Groups []string
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// EmailChange is the user's request to change the email. The new email is kept here until it's confirmed,
+// the old email can revert the change for a while after the confirmation.
+type EmailChange struct {
+	Id       uint32
+	UserId   uint32
+	OldEmail string
+	NewEmail string
+	// TokenHash is the hash of the confirmation link's token, the links' tokens aren't stored.
+	TokenHash string
+	// RevertTokenHash is the hash of the revert link's token, it's set on the confirmation.
+	RevertTokenHash   *string
+	DateCreated       time.Time
+	DateExpires       time.Time
+	DateConfirmed     *time.Time
+	DateRevertExpires *time.Time
+	DateReverted      *time.Time
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// AccountExport is everything the service holds about the user, it's the machine-readable archive of the user's data.
+type AccountExport struct {
+	DateExported time.Time                  `json:"date_exported"`
+	Profile      AccountExportProfile       `json:"profile"`
+	Roles        []string                   `json:"roles"`
+	Sessions     []AccountExportSession     `json:"sessions"`
+	Consents     []OA2ConsentGrant          `json:"consents"`
+	EmailChanges []AccountExportEmailChange `json:"email_changes"`
+	Devices      []AccountExportDevice      `json:"devices"`
+	Identities   []Identity                 `json:"identities"`
+	Groups       []AccountExportGroup       `json:"groups"`
+	// AuditEvents are the actions the user did and the ones done to the user, the newest first.
+	AuditEvents []AuditEvent `json:"audit_events"`
+}
+
+type AccountExportProfile struct {
+	Id               uint32    `json:"id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	DisplayName      string    `json:"display_name"`
+	Locale           string    `json:"locale"`
+	DateRegistration time.Time `json:"date_registration"`
+	DateLastOnline   time.Time `json:"date_last_online"`
+}
+
+// AccountExportSession is the browser session without its tokens.
+type AccountExportSession struct {
+	DateCreated  time.Time `json:"date_created"`
+	DateLastSeen time.Time `json:"date_last_seen"`
+	DateExpires  time.Time `json:"date_expires"`
+}
+
+// AccountExportDevice is the device the user signed in from without its cookie's ID.
+type AccountExportDevice struct {
+	UserAgent     string    `json:"user_agent"`
+	Ip            string    `json:"ip"`
+	DateFirstSeen time.Time `json:"date_first_seen"`
+	DateLastSeen  time.Time `json:"date_last_seen"`
+}
+
+// AccountExportGroup is the user's membership in the group.
+type AccountExportGroup struct {
+	// Organization is empty for the tenant's top-level groups.
+	Organization string `json:"organization"`
+	DisplayName  string `json:"display_name"`
+	Role         string `json:"role"`
+}
+
+// AccountExportEmailChange is the email change without its links' tokens.
+type AccountExportEmailChange struct {
+	OldEmail      string     `json:"old_email"`
+	NewEmail      string     `json:"new_email"`
+	DateCreated   time.Time  `json:"date_created"`
+	DateConfirmed *time.Time `json:"date_confirmed"`
+	DateReverted  *time.Time `json:"date_reverted"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+// The state of the login, consent and logout flows for the custom frontends.
+// RedirectTo is set when the flow was completed without the user, the frontend must follow it.
+
+type LoginFlow struct {
+	Challenge string `json:"challenge"`
+	Hint      string `json:"hint,omitempty"`
+	// Reauthenticate the remembered user has to sign in again as the subject, e.g. for prompt=login or max_age.
+	Reauthenticate bool   `json:"reauthenticate,omitempty"`
+	Subject        string `json:"subject,omitempty"`
+	// Acr is the required Authentication Context Class Reference of the sign-in.
+	Acr        string   `json:"acr,omitempty"`
+	UiLocales  []string `json:"ui_locales,omitempty"`
+	Display    string   `json:"display,omitempty"`
+	RedirectTo string   `json:"redirect_to,omitempty"`
+	// Link is the provider's identity waiting for the password of the local account with the same email.
+	Link *PendingLink `json:"link,omitempty"`
+	// Tenant is the tenant of the client, the frontend shows its branding.
+	Tenant *Tenant `json:"tenant,omitempty"`
+}
+
+type ConsentFlow struct {
+	Challenge      string                 `json:"challenge"`
+	Subject        string                 `json:"subject"`
+	RequestedScope []string               `json:"requested_scope"`
+	Client         map[string]interface{} `json:"client"`
+	RedirectTo     string                 `json:"redirect_to,omitempty"`
+	// Tenant is the tenant of the client, the frontend shows its branding.
+	Tenant *Tenant `json:"tenant,omitempty"`
+}
+
+type LogoutFlow struct {
+	Challenge   string `json:"challenge"`
+	Subject     string `json:"subject"`
+	RpInitiated bool   `json:"rp_initiated"`
+}
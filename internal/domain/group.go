@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Roles of the members within the group.
+const (
+	// GroupRoleAdmin manages the group's members.
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+// Organization owns the groups of its users, e.g. the customer's company.
+type Organization struct {
+	Id          uint32
+	TenantId    string
+	Name        string
+	DateCreated time.Time
+}
+
+// Group is the team of the users, the groups are provisioned by the HR system or managed within the organizations.
+type Group struct {
+	Id       uint32
+	TenantId string
+	// OrganizationId is nil for the tenant's top-level groups.
+	OrganizationId *uint32
+	// Organization is the organization's name read by ListUserGroups.
+	Organization string `gorm:"->"`
+	// Role is the user's role in the group read by ListUserGroups.
+	Role        string `gorm:"->"`
+	DisplayName string
+	// ExternalId is the group's id in the provisioning client.
+	ExternalId  string
+	DateCreated time.Time
+	DateUpdated time.Time
+	// Version is incremented by every update, it's used for the optimistic concurrency control.
+	Version uint32
+	// Members are stored in tb_group_members.
+	Members []GroupMember `gorm:"-"`
+}
+
+// GroupMember is the user in the group.
+type GroupMember struct {
+	GroupId uint32
+	UserId  uint32
+	// Role is GroupRoleAdmin or GroupRoleMember.
+	Role string
+	// Username is read from the user's record.
+	Username string `gorm:"->"`
+}
+
+// GroupListQuery is the page of the groups listing. The empty filters don't filter.
+type GroupListQuery struct {
+	// DisplayName is matched exactly regardless of the case.
+	DisplayName string
+	ExternalId  string
+	// OrganizationId lists the organization's groups.
+	OrganizationId *uint32
+	Offset         int
+	Limit          int
+}
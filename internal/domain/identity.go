@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+// IdentityProvider is the upstream OpenID Connect provider the users sign in with, e.g. the corporate Keycloak.
+type IdentityProvider struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Identity links the user to the upstream provider's subject.
+type Identity struct {
+	Id       uint32 `json:"id"`
+	TenantId string `json:"-"`
+	UserId   uint32 `json:"user_id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	// Email is the upstream email at the last sign-in, it's only displayed.
+	Email        string    `json:"email"`
+	DateCreated  time.Time `json:"date_created"`
+	DateLastUsed time.Time `json:"date_last_used"`
+}
+
+// UpstreamClaims are the claims of the upstream provider's verified ID token.
+type UpstreamClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// UpstreamLogin is the sign-in waiting for the upstream provider's callback, it's found by the state's hash.
+// The nonce and the PKCE code verifier are checked by the callback.
+type UpstreamLogin struct {
+	StateHash string
+	// TenantId is the tenant the sign-in was started in, the callback signs in to it.
+	TenantId  string
+	Provider  string
+	Challenge string
+	// UserId is the signed in user linking the provider to the account, the challenge is empty then.
+	UserId       *uint32
+	Nonce        string
+	CodeVerifier string
+	Remember     bool
+	DeviceId     string
+	DateCreated  time.Time
+	DateExpires  time.Time
+}
+
+// IdentityLink is the provider's identity waiting for the password of the local account with the provider's email,
+// it's found by the token's hash. The identity is linked and the login of the challenge completed once the password is entered.
+type IdentityLink struct {
+	TokenHash   string
+	Challenge   string
+	UserId      uint32
+	Provider    string
+	Subject     string
+	Email       string
+	Remember    bool
+	DeviceId    string
+	DateCreated time.Time
+	DateExpires time.Time
+}
+
+// PendingLink is the identity link shown to the user entering the local account's password.
+type PendingLink struct {
+	Token    string `json:"token"`
+	Provider string `json:"provider"`
+	Email    string `json:"email"`
+}
+
+// LoginMethods are the ways the user signs in with.
+type LoginMethods struct {
+	Password   bool       `json:"password"`
+	Identities []Identity `json:"identities"`
+	// Providers are the configured providers not linked to the user yet.
+	Providers []IdentityProvider `json:"providers"`
+}
+
+// Count returns the number of the login methods.
+func (methods *LoginMethods) Count() int {
+	if methods.Password {
+		return len(methods.Identities) + 1
+	}
+
+	return len(methods.Identities)
+}
@@ -0,0 +1,29 @@
+package domain
+
+// Notification types.
+const (
+	NotificationPasswordChanged = "password_changed"
+	// NotificationEmailChangeConfirm is sent to the new email with the confirmation link.
+	NotificationEmailChangeConfirm = "email_change_confirm"
+	// NotificationEmailChanged is sent to the old email with the revert link.
+	NotificationEmailChanged        = "email_changed"
+	NotificationEmailChangeReverted = "email_change_reverted"
+	// NotificationAccountDeleted is sent with the restore link.
+	NotificationAccountDeleted  = "account_deleted"
+	NotificationAccountRestored = "account_restored"
+	// NotificationNewDevice is sent when the user signs in from the unrecognized device.
+	NotificationNewDevice = "new_device"
+	// NotificationSigninCode is sent with the one-time code when the risky sign-in requires it.
+	NotificationSigninCode = "signin_code"
+	// NotificationVerificationCode is sent with the one-time code confirming the change of the account without the password.
+	NotificationVerificationCode = "verification_code"
+)
+
+// Notification is the message to the user about the account's security event.
+type Notification struct {
+	Type   string
+	UserId uint32
+	Email  string
+	// Data is the event's details to render in the message.
+	Data map[string]string
+}
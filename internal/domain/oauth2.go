@@ -1,6 +1,22 @@
 package domain
 
-import "time"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scopes of the account management API.
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeUsersAdmin = "users:admin"
+	// ScopeSCIM is granted to the provisioning client, e.g. the HR system.
+	ScopeSCIM = "scim"
+	// ScopeGroups adds the user's groups to the tokens as the "groups" claim.
+	ScopeGroups = "groups"
+)
 
 type OA2LoginRequest struct {
 	// Skip, if true, implies that the client has requested the same scopes from the same user previously. If true, you can skip asking the user to grant the requested scopes, and simply forward the user to the redirect URL.  This feature allows you to update / set session information.
@@ -9,6 +25,114 @@ type OA2LoginRequest struct {
 	Subject string
 	// LoginHint hints about the login identifier the End-User might use to log in (if necessary). This hint can be used by an RP if it first asks the End-User for their e-mail address (or other identifier) and then wants to pass that value as a hint to the discovered authorization service. This value MAY also be a phone number in the format specified for the phone_number Claim. The use of this parameter is optional.
 	Hint string
+	// ClientId is the OAuth 2.0 client that initiated the login.
+	ClientId       string
+	ClientData     []byte
+	RequestedScope []string
+	// AcrValues are the requested Authentication Context Class Reference values, the most preferred first.
+	AcrValues []string
+	// UiLocales are the End-User's preferred languages of the user interface, the most preferred first.
+	UiLocales []string
+	// Display is how the login page is displayed: page, popup, touch or wap.
+	Display string
+	// IdTokenHintClaims are the claims of the ID token previously issued to the client, the hint of the End-User's session.
+	IdTokenHintClaims map[string]interface{}
+	// RequestUrl is the client's original authorization request, it carries the prompt and max_age parameters.
+	RequestUrl string
+}
+
+// OIDC prompt values.
+const (
+	PromptNone  = "none"
+	PromptLogin = "login"
+)
+
+// Prompts returns the prompt values of the authorization request.
+func (r *OA2LoginRequest) Prompts() []string {
+	requestUrl, err := url.Parse(r.RequestUrl)
+	if err != nil {
+		return nil
+	}
+
+	return strings.Fields(requestUrl.Query().Get("prompt"))
+}
+
+// HasPrompt reports whether the authorization request has the prompt value.
+func (r *OA2LoginRequest) HasPrompt(prompt string) bool {
+	for _, value := range r.Prompts() {
+		if value == prompt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaxAge returns the max_age of the authorization request, the allowed time since the End-User authenticated.
+// It's false if max_age isn't set or it's malformed.
+func (r *OA2LoginRequest) MaxAge() (time.Duration, bool) {
+	requestUrl, err := url.Parse(r.RequestUrl)
+	if err != nil || !requestUrl.Query().Has("max_age") {
+		return 0, false
+	}
+
+	maxAge, err := strconv.ParseInt(requestUrl.Query().Get("max_age"), 10, 64)
+	if err != nil || maxAge < 0 {
+		return 0, false
+	}
+
+	return time.Duration(maxAge) * time.Second, true
+}
+
+// AuthTime returns the time the End-User authenticated by the ID token hint, it's false if it's unknown.
+func (r *OA2LoginRequest) AuthTime() (time.Time, bool) {
+	authTime, ok := r.IdTokenHintClaims["auth_time"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(authTime), 0), true
+}
+
+// Authentication Context Class Reference values of the sign-ins, the stronger the later.
+const (
+	// AcrRemembered the End-User is remembered by the long-lived session, it's the OIDC level 0.
+	AcrRemembered = "0"
+	// AcrPassword the End-User entered the password.
+	AcrPassword = "1"
+	// AcrMultiFactor the End-User entered the password and the one-time code sent to the email.
+	AcrMultiFactor = "2"
+)
+
+var acrLevels = []string{AcrRemembered, AcrPassword, AcrMultiFactor}
+
+// RequiredAcr returns the strongest of the requested ACR values that are supported, it's AcrRemembered if there are none.
+// The unknown values are ignored, the acr claim is voluntary.
+func (r *OA2LoginRequest) RequiredAcr() string {
+	required := AcrRemembered
+	for _, value := range r.AcrValues {
+		if AcrLevel(value) > AcrLevel(required) {
+			required = value
+		}
+	}
+
+	return required
+}
+
+// AcrLevel returns the strength of the ACR value, it's -1 if the value isn't supported.
+func AcrLevel(acr string) int {
+	for level, value := range acrLevels {
+		if value == acr {
+			return level
+		}
+	}
+
+	return -1
+}
+
+// AcrSatisfies reports whether the sign-in of the ACR value is as strong as the required one at least.
+func AcrSatisfies(acr string, required string) bool {
+	return AcrLevel(acr) >= AcrLevel(required)
 }
 
 type OA2ConsentRequest struct {
@@ -19,6 +143,15 @@ type OA2ConsentRequest struct {
 	ClientData                   []byte
 	RequestedAccessTokenAudience []string
 	RequestedScope               []string
+	ClientId                     string
+}
+
+// OA2ConsentSession sets the session data for the tokens issued by the consent request.
+type OA2ConsentSession struct {
+	// AccessToken sets session data for the access and refresh token. Keep in mind that this data will be available to anyone performing OAuth 2.0 Challenge Introspection.
+	AccessToken map[string]interface{}
+	// IdToken sets session data for the OpenID Connect ID token. Keep in mind that the session'id payloads are readable by anyone that has access to the ID Challenge.
+	IdToken map[string]interface{}
 }
 
 type Token struct {
@@ -44,10 +177,75 @@ type Token struct {
 
 	// OpenID token.
 	IdToken string `json:"id_token"`
+
+	// Subject and Sid (the login session ID) are the claims of the OpenID token.
+	Subject string `json:"-"`
+	Sid     string `json:"-"`
+}
+
+type OA2LogoutRequest struct {
+	// Subject is the user for whom the logout was request.
+	Subject string
+	// Sid is the login session ID that was requested to log out.
+	Sid string
+	// RpInitiated is set to true if the request was initiated by a Relying Party (RP), also known as an OAuth 2.0 Client.
+	RpInitiated bool
+	// ClientId is the OAuth 2.0 client that initiated the logout, it's empty if the user did.
+	ClientId string
+}
+
+// OA2LogoutToken is the verified OpenID Connect Back-Channel Logout token.
+type OA2LogoutToken struct {
+	Subject string
+	// Sid is the login session ID to log out.
+	Sid string
 }
 
 type OA2TokenIntrospection struct {
 	// Active is a boolean indicator of whether or not the presented token is currently active.  The specifics of a token's \"active\" state will vary depending on the implementation of the authorization server and the information it keeps about its tokens, but a \"true\" value return for the \"active\" property will generally indicate that a given token has been issued by this authorization server, has not been revoked by the resource owner, and is within its given time window of validity (e.g., after its issuance time and before its expiration time).
 	Active bool    `json:"active"`
 	Sub    *string `json:"sub,omitempty"`
+	// Scope is a JSON string containing a space-separated list of scopes associated with this token.
+	Scope string `json:"scope,omitempty"`
+	// ClientID is a client identifier for the OAuth 2.0 client that requested this token.
+	ClientId *string `json:"client_id,omitempty"`
+	// Audience contains a list of the token's intended audiences.
+	Aud []string `json:"aud,omitempty"`
+	// Expires at is an integer timestamp, measured in the number of seconds since January 1 1970 UTC, indicating when this token will expire.
+	Exp *int64 `json:"exp,omitempty"`
+	// TokenType is the introspected token's type, typically `Bearer`.
+	TokenType *string `json:"token_type,omitempty"`
+}
+
+// Scopes returns the list of scopes granted to the token.
+func (t *OA2TokenIntrospection) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// HasScopes reports whether all the given scopes were granted to the token.
+func (t *OA2TokenIntrospection) HasScopes(scopes ...string) bool {
+	granted := t.Scopes()
+	for _, scope := range scopes {
+		found := false
+		for _, grantedScope := range granted {
+			if grantedScope == scope {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OA2ConsentGrant is the consent the subject granted to the client.
+type OA2ConsentGrant struct {
+	ClientId    string     `json:"client_id"`
+	ClientName  string     `json:"client_name"`
+	GrantScope  []string   `json:"grant_scope"`
+	DateHandled *time.Time `json:"date_handled"`
 }
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Risk levels of the sign-in attempt.
+const (
+	RiskLevelLow = "low"
+	// RiskLevelMedium requires the one-time code sent to the user's email.
+	RiskLevelMedium = "medium"
+	// RiskLevelHigh rejects the sign-in.
+	RiskLevelHigh = "high"
+)
+
+// Risk signals of the sign-in attempt.
+const (
+	RiskReasonNewDevice        = "new_device"
+	RiskReasonImpossibleTravel = "impossible_travel"
+	RiskReasonDeniedIp         = "denied_ip"
+	RiskReasonRecentFailures   = "recent_failures"
+	RiskReasonUnusualHour      = "unusual_hour"
+)
+
+// RiskAssessment is the risk of the sign-in attempt, the score is the sum of the signals' weights.
+type RiskAssessment struct {
+	Score   int
+	Level   string
+	Reasons []string
+}
+
+// AuditData returns the assessment as the audit event's data.
+func (assessment *RiskAssessment) AuditData() map[string]string {
+	return map[string]string{
+		"risk_score":   strconv.Itoa(assessment.Score),
+		"risk_level":   assessment.Level,
+		"risk_reasons": strings.Join(assessment.Reasons, ","),
+	}
+}
+
+// StepUp is the pending sign-in waiting for the one-time code, it's bound to the login challenge.
+type StepUp struct {
+	Challenge string
+	UserId    uint32
+	// CodeHash is the SHA-256 of the one-time code, the code itself is only sent to the user.
+	CodeHash    string
+	Remember    bool
+	DeviceId    string
+	Attempts    int
+	DateCreated time.Time
+	DateExpires time.Time
+}
+
+// Location is the approximate location of the IP.
+type Location struct {
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	// HasCoordinates is false if only the names are known.
+	HasCoordinates bool
+}
+
+// String returns the names of the city and country, e.g. "Berlin, Germany".
+func (location *Location) String() string {
+	var names []string
+	for _, name := range []string{location.City, location.Country} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Roles.
+const (
+	RoleAdmin = "admin"
+)
+
+// Permissions granted to the roles.
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersList   = "users:list"
+	PermissionUsersManage = "users:manage"
+	PermissionRolesManage = "roles:manage"
+	PermissionAuditRead   = "audit:read"
+	// PermissionGroupsManage manages the organizations and all their groups, the group's admins manage its members only.
+	PermissionGroupsManage = "groups:manage"
+	// PermissionServiceAccountsManage manages all the service accounts, the owner team's admins manage their keys only.
+	PermissionServiceAccountsManage = "service_accounts:manage"
+)
+
+type UserRole struct {
+	UserId       uint32
+	Role         string
+	DateAssigned time.Time
+}
+
+// UserResource returns the policy resource name of the user record.
+func UserResource(userId uint32) string {
+	return fmt.Sprintf("users/%d", userId)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// ServiceAccountKeyPrefix starts the service accounts' API keys, it tells them apart from the Hydra tokens.
+	ServiceAccountKeyPrefix = "sa_live_"
+	// ServiceAccountClientPrefix starts the client ids of the service accounts.
+	ServiceAccountClientPrefix = "sa-"
+)
+
+// ServiceAccount is the non-human account of the service calling the API, it's owned by the team responsible for it.
+type ServiceAccount struct {
+	Id       uint32
+	TenantId string
+	// ClientId is the subject and the client id of the account's tokens, the Hydra client's id if HydraClient is set.
+	ClientId string
+	// HydraClient is set if the account gets the tokens by the client credentials grant of its Hydra client too.
+	HydraClient bool
+	Name        string
+	Description string
+	// OwnerGroupId is the owner team, its admins manage the account's keys. It's nil if the team was deleted.
+	OwnerGroupId *uint32
+	// Scope is the space-separated list of the scopes granted to the account's keys and Hydra client.
+	Scope       string
+	CreatedBy   *uint32
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// Scopes returns the list of the scopes granted to the account.
+func (a *ServiceAccount) Scopes() []string {
+	return strings.Fields(a.Scope)
+}
+
+// ServiceAccountKey is the API key of the service account, only its hash is stored.
+type ServiceAccountKey struct {
+	Id               uint32
+	TenantId         string
+	ServiceAccountId uint32
+	// Prefix is the key's first characters shown to tell the keys apart.
+	Prefix      string
+	KeyHash     string
+	DateCreated time.Time
+	// DateExpires is nil if the key never expires.
+	DateExpires  *time.Time
+	DateLastUsed *time.Time
+	DateRevoked  *time.Time
+}
+
+// Active reports whether the key authenticates the requests at the time.
+func (k *ServiceAccountKey) Active(now time.Time) bool {
+	return k.DateRevoked == nil && (k.DateExpires == nil || now.Before(*k.DateExpires))
+}
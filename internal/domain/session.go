@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Session is the server-side browser session, the browser keeps only the opaque session ID in the cookie.
+type Session struct {
+	// Id is the hash of the session ID, so the store's content can't be used to hijack the sessions.
+	Id string `json:"id"`
+	// Sid is the Hydra login session ID, it's used to end the session by the OpenID Connect logout.
+	Sid     string `json:"sid"`
+	Subject string `json:"subject"`
+	// Data is the session's tokens, encrypted if the encryption at rest is enabled.
+	Data         []byte    `json:"data"`
+	DateCreated  time.Time `json:"date_created"`
+	DateLastSeen time.Time `json:"date_last_seen"`
+	DateExpires  time.Time `json:"date_expires"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "context"
+
+// DefaultTenantId is the tenant of the requests and clients not mapped to another tenant.
+const DefaultTenantId = "default"
+
+// contextKey is the type of the request's context keys, they don't collide with the other packages' keys.
+type contextKey string
+
+const (
+	contextKeyTenant      contextKey = "tenant"
+	contextKeyRequestInfo contextKey = "request_info"
+)
+
+// Tenant is the product served by the service, its users are isolated from the other tenants' users:
+// the usernames and emails are unique within the tenant and the users sign in to the tenant's clients only.
+type Tenant struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// URL is the tenant's account pages, the links sent to the users lead to them.
+	URL      string   `json:"url"`
+	Branding Branding `json:"branding"`
+	// PasswordMinLength and RolesClaim are the tenant's overrides of the service's configuration.
+	PasswordMinLength int  `json:"password_min_length"`
+	RolesClaim        bool `json:"roles_claim"`
+}
+
+// Branding is the look of the tenant's sign-in, consent and account pages.
+type Branding struct {
+	ProductName  string `json:"product_name"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"`
+}
+
+// WithTenant returns the copy of the context the tenant's users are read and changed in.
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, contextKeyTenant, tenant)
+}
+
+// TenantFromContext returns the context's tenant, it's nil if the context has none.
+func TenantFromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(contextKeyTenant).(*Tenant)
+	return tenant
+}
+
+// TenantId returns the id of the context's tenant, the context without the tenant is the default tenant's one.
+func TenantId(ctx context.Context) string {
+	if tenant := TenantFromContext(ctx); tenant != nil {
+		return tenant.Id
+	}
+
+	return DefaultTenantId
+}
@@ -4,9 +4,102 @@ import "time"
 
 type User struct {
 	Id               uint32
+	TenantId         string
 	Username         string
 	Email            string
 	PasswordHash     []byte
 	DateRegistration time.Time
 	DateLastOnline   time.Time
+	DisplayName      string
+	Locale           string
+	// ExternalId is the user's id in the provisioning client, e.g. the HR system's employee id.
+	ExternalId string
+	// Version is incremented by every update, it's used for the optimistic concurrency control.
+	Version uint32
+	// DateDeleted is set while the deleted user can be restored.
+	DateDeleted *time.Time
+	// DateSuspended is set while the user is suspended by an administrator,
+	// DateSuspendedUntil is nil if the suspension doesn't expire.
+	DateSuspended      *time.Time
+	DateSuspendedUntil *time.Time
+	SuspensionReason   string
+	// FailedSignins is the number of the failed sign-ins since the last successful one or lockout.
+	FailedSignins   int
+	DateLockedUntil *time.Time
+}
+
+// User statuses.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
+func (user *User) Status() string {
+	if user.DateDeleted != nil {
+		return UserStatusDeleted
+	}
+
+	if user.IsSuspended(time.Now()) {
+		return UserStatusSuspended
+	}
+
+	return UserStatusActive
+}
+
+// IsSuspended reports whether the user's suspension is in effect at the time.
+func (user *User) IsSuspended(now time.Time) bool {
+	return user.DateSuspended != nil && (user.DateSuspendedUntil == nil || user.DateSuspendedUntil.After(now))
+}
+
+// IsLocked reports whether the user is locked out after too many failed sign-ins at the time.
+func (user *User) IsLocked(now time.Time) bool {
+	return user.DateLockedUntil != nil && user.DateLockedUntil.After(now)
+}
+
+// HasPassword reports whether the user can sign in with the password, the user registered by the identity provider has none.
+func (user *User) HasPassword() bool {
+	return len(user.PasswordHash) > 0
+}
+
+// User listing sort fields.
+const (
+	UserSortId               = "id"
+	UserSortUsername         = "username"
+	UserSortEmail            = "email"
+	UserSortDateRegistration = "date_registration"
+	UserSortDateLastOnline   = "date_last_online"
+)
+
+// UserListQuery is the page of the users listing. The empty filters don't filter.
+type UserListQuery struct {
+	// Username, Email and ExternalId are matched exactly, the username and email regardless of the case.
+	Username       string
+	Email          string
+	ExternalId     string
+	EmailPrefix    string
+	UsernamePrefix string
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time
+	// LastOnlineBefore finds the inactive users.
+	LastOnlineBefore *time.Time
+	Status           string
+	// Existing skips the deleted users.
+	Existing bool
+	Sort     string
+	Desc     bool
+	Limit    int
+	// After is the last user of the previous page, only its id and sort field are used.
+	After *User
+	// Offset skips the users instead of After, the SCIM clients page by the index.
+	Offset int
+}
+
+// UserPage is the page of the users listing.
+type UserPage struct {
+	Users []User
+	// Total is the number of the users matching the filters on all pages.
+	Total int64
+	// NextCursor reads the next page, it's empty on the last page.
+	NextCursor string
 }
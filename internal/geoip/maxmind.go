@@ -0,0 +1,72 @@
+package geoip
+
+import (
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+	"service-account/internal/domain"
+)
+
+// MaxMindLocator locates the IPs by the local MaxMind database, e.g. GeoLite2-City or GeoLite2-Country.
+type MaxMindLocator struct {
+	reader *maxminddb.Reader
+}
+
+// record is the part of the City and Country databases' record that is used.
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	// Location is missing in the Country databases.
+	Location *struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func NewMaxMindLocator(filename string) (*MaxMindLocator, error) {
+	reader, err := maxminddb.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MaxMindLocator{
+		reader: reader,
+	}, nil
+}
+
+// Locate returns the English names of the IP's city and country and its coordinates if they are known.
+// It's nil if the IP isn't found, e.g. it's a private one.
+func (l *MaxMindLocator) Locate(ip string) *domain.Location {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return nil
+	}
+
+	var found record
+	if err := l.reader.Lookup(parsedIp, &found); err != nil {
+		return nil
+	}
+
+	location := &domain.Location{
+		City:    found.City.Names["en"],
+		Country: found.Country.Names["en"],
+	}
+	if found.Location != nil {
+		location.Latitude = found.Location.Latitude
+		location.Longitude = found.Location.Longitude
+		location.HasCoordinates = true
+	}
+
+	if location.City == "" && location.Country == "" && !location.HasCoordinates {
+		return nil
+	}
+
+	return location
+}
+
+func (l *MaxMindLocator) Close() error {
+	return l.reader.Close()
+}
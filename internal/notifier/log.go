@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+	"service-account/internal/domain"
+	"service-account/pkg/logger"
+)
+
+// LogNotifier writes the notifications to the log instead of delivering them to the users.
+// It's the default until a delivery channel (e.g. email) is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	logger.Info("Notification",
+		logger.String("type", notification.Type),
+		logger.Uint32("user_id", notification.UserId),
+		logger.Any("data", notification.Data),
+	)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+	List(ctx context.Context, query *domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+type AuditRepositoryGorm struct {
+	db *gorm.DB
+}
+
+var _ AuditRepository = &AuditRepositoryGorm{}
+
+func NewAuditRepo(db *gorm.DB) *AuditRepositoryGorm {
+	return &AuditRepositoryGorm{db}
+}
+
+// Create appends the event to the audit log of the context's tenant.
+func (r *AuditRepositoryGorm) Create(ctx context.Context, event *domain.AuditEvent) error {
+	event.TenantId = domain.TenantId(ctx)
+	return r.db.WithContext(ctx).Table("tb_audit_log").Create(event).Error
+}
+
+// List returns the page of the context's tenant's events matching the query, the newest events first.
+// The pages are read by the keyset pagination on the id.
+func (r *AuditRepositoryGorm) List(ctx context.Context, query *domain.AuditQuery) ([]domain.AuditEvent, error) {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_audit_log")
+
+	if query.ActorId != nil {
+		db = db.Where("actor_id = ?", *query.ActorId)
+	}
+
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+
+	if query.SubjectId != nil {
+		db = db.Where("actor_id = ? OR user_id = ?", *query.SubjectId, *query.SubjectId)
+	}
+
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+
+	if query.Outcome != "" {
+		db = db.Where("outcome = ?", query.Outcome)
+	}
+
+	if query.From != nil {
+		db = db.Where("date_created >= ?", *query.From)
+	}
+
+	if query.To != nil {
+		db = db.Where("date_created < ?", *query.To)
+	}
+
+	if query.BeforeId != 0 {
+		db = db.Where("id < ?", query.BeforeId)
+	}
+
+	var events []domain.AuditEvent
+	if err := db.Order("id DESC").Limit(query.Limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestAudit_List(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_audit_log" WHERE tenant_id = $1 AND user_id = $2 AND action = $3 AND id < $4 ORDER BY id DESC LIMIT 3`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewAuditRepo(gormDB)
+	userId := uint32(1)
+
+	mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+		WithArgs("acme", userId, domain.AuditActionSignin, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "outcome"}).
+			AddRow(9, domain.AuditActionSignin, domain.AuditOutcomeFailure).
+			AddRow(4, domain.AuditActionSignin, domain.AuditOutcomeSuccess))
+
+	events, err := r.List(domain.WithTenant(context.Background(), &domain.Tenant{Id: "acme"}), &domain.AuditQuery{
+		UserId:   &userId,
+		Action:   domain.AuditActionSignin,
+		Limit:    3,
+		BeforeId: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.AuditEvent{
+		{Id: 9, Action: domain.AuditActionSignin, Outcome: domain.AuditOutcomeFailure},
+		{Id: 4, Action: domain.AuditActionSignin, Outcome: domain.AuditOutcomeSuccess},
+	}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAudit_ListSubject(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_audit_log" WHERE tenant_id = $1 AND (actor_id = $2 OR user_id = $3) ORDER BY id DESC LIMIT 3`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewAuditRepo(gormDB)
+	userId := uint32(1)
+
+	mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+		WithArgs("default", userId, userId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "outcome"}).
+			AddRow(9, domain.AuditActionPasswordChanged, domain.AuditOutcomeSuccess).
+			AddRow(4, domain.AuditActionUserSuspended, domain.AuditOutcomeSuccess))
+
+	events, err := r.List(context.Background(), &domain.AuditQuery{
+		SubjectId: &userId,
+		Limit:     3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.AuditEvent{
+		{Id: 9, Action: domain.AuditActionPasswordChanged, Outcome: domain.AuditOutcomeSuccess},
+		{Id: 4, Action: domain.AuditActionUserSuspended, Outcome: domain.AuditOutcomeSuccess},
+	}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAudit_Create(t *testing.T) {
+	const sqlInsert = `INSERT INTO "tb_audit_log" ("tenant_id","action","outcome","actor_id","user_id","reason","data","ip","user_agent","client_id","challenge","date_created") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING "id"`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewAuditRepo(gormDB)
+	userId := uint32(1)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(sqlInsert)).
+		WithArgs("acme", domain.AuditActionSignin, domain.AuditOutcomeSuccess, nil, userId, "", `{"device_id":"d-1"}`, "", "", "web", "", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectCommit()
+
+	event := &domain.AuditEvent{
+		Action:      domain.AuditActionSignin,
+		Outcome:     domain.AuditOutcomeSuccess,
+		UserId:      &userId,
+		Data:        map[string]string{"device_id": "d-1"},
+		ClientId:    "web",
+		DateCreated: now,
+	}
+	err = r.Create(domain.WithTenant(context.Background(), &domain.Tenant{Id: "acme"}), event)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9), event.Id)
+	assert.Equal(t, "acme", event.TenantId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
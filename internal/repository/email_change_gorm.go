@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service-account/internal/domain"
+	"time"
+)
+
+// CreateEmailChange stores the user's pending email change, the former pending changes are dropped.
+func (r *UserRepositoryGorm) CreateEmailChange(ctx context.Context, emailChange *domain.EmailChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_email_changes").Where("user_id = ? AND date_confirmed IS NULL", emailChange.UserId).Delete(&domain.EmailChange{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Table("tb_email_changes").Create(emailChange).Error
+	})
+}
+
+// ConfirmEmailChange swaps the user's email for the pending one and stores the revert token.
+// The swap is rejected if the user's email was changed since the request.
+func (r *UserRepositoryGorm) ConfirmEmailChange(ctx context.Context, tokenHash string, revertTokenHash string, revertExpires time.Time) (*domain.EmailChange, error) {
+	emailChange := new(domain.EmailChange)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_email_changes").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND date_confirmed IS NULL AND date_expires > ?", tokenHash, time.Now()).
+			Take(emailChange)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if err := r.swapEmail(ctx, tx, emailChange.UserId, emailChange.OldEmail, emailChange.NewEmail); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		emailChange.RevertTokenHash = &revertTokenHash
+		emailChange.DateConfirmed = &now
+		emailChange.DateRevertExpires = &revertExpires
+
+		return tx.Table("tb_email_changes").Where("id = ?", emailChange.Id).Updates(map[string]interface{}{
+			"revert_token_hash":   emailChange.RevertTokenHash,
+			"date_confirmed":      emailChange.DateConfirmed,
+			"date_revert_expires": emailChange.DateRevertExpires,
+		}).Error
+	})
+	if err != nil {
+		return nil, r.txError(err)
+	}
+
+	return emailChange, nil
+}
+
+// RevertEmailChange swaps the user's email back for the old one.
+// The swap is rejected if the user's email was changed since the confirmation.
+func (r *UserRepositoryGorm) RevertEmailChange(ctx context.Context, revertTokenHash string) (*domain.EmailChange, error) {
+	emailChange := new(domain.EmailChange)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_email_changes").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("revert_token_hash = ? AND date_reverted IS NULL AND date_revert_expires > ?", revertTokenHash, time.Now()).
+			Take(emailChange)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if err := r.swapEmail(ctx, tx, emailChange.UserId, emailChange.NewEmail, emailChange.OldEmail); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		emailChange.DateReverted = &now
+
+		return tx.Table("tb_email_changes").Where("id = ?", emailChange.Id).Update("date_reverted", emailChange.DateReverted).Error
+	})
+	if err != nil {
+		return nil, r.txError(err)
+	}
+
+	return emailChange, nil
+}
+
+// ListEmailChanges returns the user's email changes, the oldest first.
+func (r *UserRepositoryGorm) ListEmailChanges(ctx context.Context, userId uint32) ([]domain.EmailChange, error) {
+	var emailChanges []domain.EmailChange
+	db := r.db.WithContext(ctx).Table("tb_email_changes").Where("user_id = ?", userId).Order("date_created").Find(&emailChanges)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return emailChanges, nil
+}
+
+func (r *UserRepositoryGorm) swapEmail(ctx context.Context, tx *gorm.DB, userId uint32, fromEmail string, toEmail string) error {
+	db := tenantTable(ctx, tx, "tb_users").Where("id = ? AND email = ?", userId, fromEmail).Updates(map[string]interface{}{
+		"email":   toEmail,
+		"version": gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordVersionConflict
+	}
+
+	return nil
+}
+
+// txError maps the errors of the transactions to the repository errors.
+func (r *UserRepositoryGorm) txError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+
+	// The email is taken by another user.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgErrCodeUniqueViolation {
+		return ErrRecordAlreadyExist
+	}
+
+	return err
+}
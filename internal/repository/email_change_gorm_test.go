@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUser_ConfirmEmailChange(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_email_changes"`
+	const sqlUpdateUser = `UPDATE "tb_users" SET`
+	const sqlUpdateChange = `UPDATE "tb_email_changes" SET`
+	changeColumns := []string{"id", "user_id", "old_email", "new_email", "token_hash", "date_created", "date_expires"}
+
+	tests := []struct {
+		name          string
+		expectedQuery func(mock sqlmock.Sqlmock)
+		expectedErr   error
+	}{
+		{
+			name: "Confirm email change",
+			expectedQuery: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+					WillReturnRows(sqlmock.NewRows(changeColumns).
+						AddRow(1, 2, "old@mail.com", "new@mail.com", "hash", time.Now(), time.Now().Add(time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdateUser)).
+					WithArgs("new@mail.com", "default", 2, "old@mail.com").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdateChange)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Link is invalid or expired",
+			expectedQuery: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+					WillReturnRows(sqlmock.NewRows(changeColumns))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrRecordNotFound,
+		},
+		{
+			name: "Email already taken",
+			expectedQuery: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+					WillReturnRows(sqlmock.NewRows(changeColumns).
+						AddRow(1, 2, "old@mail.com", "new@mail.com", "hash", time.Now(), time.Now().Add(time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdateUser)).
+					WillReturnError(&pgconn.PgError{Code: pgErrCodeUniqueViolation, ConstraintName: "tb_users_email"})
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrRecordAlreadyExist,
+		},
+		{
+			name: "Email was changed since the request",
+			expectedQuery: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+					WillReturnRows(sqlmock.NewRows(changeColumns).
+						AddRow(1, 2, "old@mail.com", "new@mail.com", "hash", time.Now(), time.Now().Add(time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdateUser)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrRecordVersionConflict,
+		},
+	}
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Expected behavior.
+			tt.expectedQuery(mock)
+
+			// Call test function.
+			r := NewUsersRepo(gormDB)
+			emailChange, err := r.ConfirmEmailChange(context.Background(), "hash", "revert-hash", time.Now().Add(time.Hour))
+			assert.Equal(t, tt.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, "revert-hash", *emailChange.RevertTokenHash)
+				assert.NotNil(t, emailChange.DateConfirmed)
+			}
+
+			// We make sure that all expectations were met.
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
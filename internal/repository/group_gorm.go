@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service-account/internal/domain"
+	"time"
+)
+
+type GroupRepository interface {
+	CreateGroup(ctx context.Context, group *domain.Group) error
+	GetGroup(ctx context.Context, id uint32) (*domain.Group, error)
+	ListGroups(ctx context.Context, query *domain.GroupListQuery) ([]domain.Group, int64, error)
+	UpdateGroup(ctx context.Context, group *domain.Group) error
+	DeleteGroup(ctx context.Context, id uint32) error
+	ListUserGroups(ctx context.Context, userIds []uint32) (map[uint32][]domain.Group, error)
+	SetGroupMember(ctx context.Context, member *domain.GroupMember, dateUpdated time.Time) error
+	DeleteGroupMember(ctx context.Context, groupId uint32, userId uint32, dateUpdated time.Time) error
+	CreateOrganization(ctx context.Context, organization *domain.Organization) error
+	GetOrganization(ctx context.Context, id uint32) (*domain.Organization, error)
+	ListOrganizations(ctx context.Context) ([]domain.Organization, error)
+}
+
+type GroupRepositoryGorm struct {
+	db *gorm.DB
+}
+
+var _ GroupRepository = &GroupRepositoryGorm{}
+
+func NewGroupsRepo(db *gorm.DB) *GroupRepositoryGorm {
+	return &GroupRepositoryGorm{db}
+}
+
+// userGroup is the group of the user read by ListUserGroups.
+type userGroup struct {
+	UserId         uint32
+	Id             uint32
+	OrganizationId *uint32
+	Organization   string
+	DisplayName    string
+	Role           string
+}
+
+// CreateGroup creates the group of the context's tenant with its members. It's ErrRecordAlreadyExist if the name
+// or the external id is taken in the tenant and ErrRecordNotFound if the member isn't the tenant's user.
+func (r *GroupRepositoryGorm) CreateGroup(ctx context.Context, group *domain.Group) error {
+	group.TenantId = domain.TenantId(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("tb_groups").Create(group).Error; err != nil {
+			return err
+		}
+
+		return createGroupMembers(ctx, tx, group)
+	})
+
+	return groupError(err)
+}
+
+// GetGroup returns the group with its members, the deleted users aren't members.
+func (r *GroupRepositoryGorm) GetGroup(ctx context.Context, id uint32) (*domain.Group, error) {
+	group := new(domain.Group)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_groups").Where("id = ?", id).Take(group)
+	if db.Error != nil {
+		return nil, groupError(db.Error)
+	}
+
+	groups := []domain.Group{*group}
+	if err := r.loadMembers(ctx, groups); err != nil {
+		return nil, err
+	}
+
+	return &groups[0], nil
+}
+
+// ListGroups returns the page of the groups with their members and the total number of the matching groups.
+func (r *GroupRepositoryGorm) ListGroups(ctx context.Context, query *domain.GroupListQuery) ([]domain.Group, int64, error) {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_groups")
+	if query.DisplayName != "" {
+		db = db.Where("lower(display_name) = lower(?)", query.DisplayName)
+	}
+
+	if query.ExternalId != "" {
+		db = db.Where("external_id = ?", query.ExternalId)
+	}
+
+	if query.OrganizationId != nil {
+		db = db.Where("organization_id = ?", *query.OrganizationId)
+	}
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+
+	var groups []domain.Group
+	if err := db.Order("id").Limit(query.Limit).Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.loadMembers(ctx, groups); err != nil {
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+// UpdateGroup replaces the group's name, external id and members if the group's version wasn't changed since it was read,
+// the version is incremented. The remaining members keep their roles. It's ErrRecordNotFound if the group doesn't exist
+// or the member isn't the tenant's user.
+func (r *GroupRepositoryGorm) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tenantTable(ctx, tx, "tb_groups").Where("id = ? AND version = ?", group.Id, group.Version).Updates(map[string]interface{}{
+			"display_name": group.DisplayName,
+			"external_id":  group.ExternalId,
+			"date_updated": group.DateUpdated,
+			"version":      gorm.Expr("version + 1"),
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if db.RowsAffected == 0 {
+			var count int64
+			if err := tenantTable(ctx, tx, "tb_groups").Where("id = ?", group.Id).Count(&count).Error; err != nil {
+				return err
+			}
+
+			if count == 0 {
+				return ErrRecordNotFound
+			}
+
+			return ErrRecordVersionConflict
+		}
+
+		removed := tx.Table("tb_group_members").Where("group_id = ?", group.Id)
+		if len(group.Members) > 0 {
+			userIds := make([]uint32, 0, len(group.Members))
+			for _, member := range group.Members {
+				userIds = append(userIds, member.UserId)
+			}
+
+			removed = removed.Where("user_id NOT IN ?", userIds)
+		}
+
+		if err := removed.Delete(&domain.GroupMember{}).Error; err != nil {
+			return err
+		}
+
+		return createGroupMembers(ctx, tx, group)
+	})
+	if err != nil {
+		return groupError(err)
+	}
+
+	group.Version++
+
+	return nil
+}
+
+// DeleteGroup deletes the group with its memberships.
+func (r *GroupRepositoryGorm) DeleteGroup(ctx context.Context, id uint32) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_groups").Where("id = ?", id).Delete(&domain.Group{})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ListUserGroups returns the groups of the users by the user id with the organizations' names, the groups have no members.
+func (r *GroupRepositoryGorm) ListUserGroups(ctx context.Context, userIds []uint32) (map[uint32][]domain.Group, error) {
+	groups := map[uint32][]domain.Group{}
+	if len(userIds) == 0 {
+		return groups, nil
+	}
+
+	var rows []userGroup
+	db := r.db.WithContext(ctx).Table("tb_group_members AS gm").
+		Select("gm.user_id, g.id, g.organization_id, o.name AS organization, g.display_name, gm.role").
+		Joins("JOIN tb_groups AS g ON g.id = gm.group_id").
+		Joins("LEFT JOIN tb_organizations AS o ON o.id = g.organization_id").
+		Where("gm.user_id IN ? AND g.tenant_id = ?", userIds, domain.TenantId(ctx)).
+		Order("o.name NULLS FIRST, g.display_name").
+		Scan(&rows)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	for _, row := range rows {
+		groups[row.UserId] = append(groups[row.UserId], domain.Group{
+			Id:             row.Id,
+			OrganizationId: row.OrganizationId,
+			Organization:   row.Organization,
+			DisplayName:    row.DisplayName,
+			Role:           row.Role,
+		})
+	}
+
+	return groups, nil
+}
+
+// loadMembers reads the members of the groups with one query.
+func (r *GroupRepositoryGorm) loadMembers(ctx context.Context, groups []domain.Group) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	groupIds := make([]uint32, 0, len(groups))
+	for _, group := range groups {
+		groupIds = append(groupIds, group.Id)
+	}
+
+	var members []domain.GroupMember
+	db := r.db.WithContext(ctx).Table("tb_group_members AS gm").
+		Select("gm.group_id, gm.user_id, gm.role, u.username").
+		Joins("JOIN tb_users AS u ON u.id = gm.user_id").
+		Where("gm.group_id IN ? AND u.date_deleted IS NULL", groupIds).
+		Order("gm.user_id").
+		Scan(&members)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	for i := range groups {
+		for _, member := range members {
+			if member.GroupId == groups[i].Id {
+				groups[i].Members = append(groups[i].Members, member)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createGroupMembers adds the group's members, the existing members are left as they are. The members are GroupRoleMember
+// unless the role is set. It's ErrRecordNotFound if the member isn't the user of the context's tenant.
+func createGroupMembers(ctx context.Context, tx *gorm.DB, group *domain.Group) error {
+	if len(group.Members) == 0 {
+		return nil
+	}
+
+	userIds := make([]uint32, 0, len(group.Members))
+	for i := range group.Members {
+		group.Members[i].GroupId = group.Id
+		if group.Members[i].Role == "" {
+			group.Members[i].Role = domain.GroupRoleMember
+		}
+
+		userIds = append(userIds, group.Members[i].UserId)
+	}
+
+	var users int64
+	if err := tenantTable(ctx, tx, "tb_users").Where("id IN ?", userIds).Count(&users).Error; err != nil {
+		return err
+	}
+
+	if users != int64(len(userIds)) {
+		return ErrRecordNotFound
+	}
+
+	return tx.Table("tb_group_members").Clauses(clause.OnConflict{DoNothing: true}).Create(&group.Members).Error
+}
+
+// SetGroupMember adds the user to the group or changes the member's role, the group's version is incremented.
+// It's ErrRecordNotFound if the group or the user isn't the tenant's one and ErrLastGroupAdmin if the only admin becomes the member.
+func (r *GroupRepositoryGorm) SetGroupMember(ctx context.Context, member *domain.GroupMember, dateUpdated time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := lockGroupMember(ctx, tx, member.GroupId, member.UserId, dateUpdated)
+		if err != nil {
+			return err
+		}
+
+		if role == domain.GroupRoleAdmin && member.Role != domain.GroupRoleAdmin {
+			if err = checkOtherGroupAdmins(tx, member.GroupId, member.UserId); err != nil {
+				return err
+			}
+		}
+
+		if role == "" {
+			var users int64
+			if err = tenantTable(ctx, tx, "tb_users").Where("id = ?", member.UserId).Count(&users).Error; err != nil {
+				return err
+			}
+
+			if users == 0 {
+				return ErrRecordNotFound
+			}
+		}
+
+		return tx.Table("tb_group_members").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "group_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role"}),
+		}).Create(member).Error
+	})
+
+	return groupError(err)
+}
+
+// DeleteGroupMember removes the user from the group, the group's version is incremented. It's ErrRecordNotFound
+// if the user isn't the group's member and ErrLastGroupAdmin if the user is the group's only admin.
+func (r *GroupRepositoryGorm) DeleteGroupMember(ctx context.Context, groupId uint32, userId uint32, dateUpdated time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := lockGroupMember(ctx, tx, groupId, userId, dateUpdated)
+		if err != nil {
+			return err
+		}
+
+		switch role {
+		case "":
+			return ErrRecordNotFound
+		case domain.GroupRoleAdmin:
+			if err = checkOtherGroupAdmins(tx, groupId, userId); err != nil {
+				return err
+			}
+		}
+
+		return tx.Table("tb_group_members").Where("group_id = ? AND user_id = ?", groupId, userId).Delete(&domain.GroupMember{}).Error
+	})
+
+	return groupError(err)
+}
+
+// lockGroupMember increments the version of the tenant's group, which locks the group's members until the transaction ends,
+// and returns the user's role in the group. The role is empty if the user isn't the member.
+func lockGroupMember(ctx context.Context, tx *gorm.DB, groupId uint32, userId uint32, dateUpdated time.Time) (string, error) {
+	db := tenantTable(ctx, tx, "tb_groups").Where("id = ?", groupId).Updates(map[string]interface{}{
+		"date_updated": dateUpdated,
+		"version":      gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return "", db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return "", ErrRecordNotFound
+	}
+
+	var roles []string
+	if err := tx.Table("tb_group_members").Where("group_id = ? AND user_id = ?", groupId, userId).Pluck("role", &roles).Error; err != nil {
+		return "", err
+	}
+
+	if len(roles) == 0 {
+		return "", nil
+	}
+
+	return roles[0], nil
+}
+
+// checkOtherGroupAdmins returns ErrLastGroupAdmin if the user is the group's only admin.
+func checkOtherGroupAdmins(tx *gorm.DB, groupId uint32, userId uint32) error {
+	var admins int64
+	db := tx.Table("tb_group_members").Where("group_id = ? AND user_id <> ? AND role = ?", groupId, userId, domain.GroupRoleAdmin).Count(&admins)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if admins == 0 {
+		return ErrLastGroupAdmin
+	}
+
+	return nil
+}
+
+// groupError maps the taken name or external id and the missing member.
+func groupError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgErrCodeUniqueViolation:
+			return ErrRecordAlreadyExist
+		case pgErrCodeForeignKeyViolation:
+			return ErrRecordNotFound
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	const sqlInsertGroup = `INSERT INTO "tb_groups" ("tenant_id","organization_id","display_name","external_id","date_created","date_updated","version") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`
+	const sqlCountUsers = `SELECT count(*) FROM "tb_users" WHERE tenant_id = $1 AND id IN ($2,$3)`
+	const sqlInsertMembers = `INSERT INTO "tb_group_members" ("group_id","user_id","role") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT DO NOTHING`
+	const sqlSelectGroup = `SELECT * FROM "tb_groups" WHERE tenant_id = $1 AND id = $2 LIMIT 1`
+	const sqlSelectMembers = `SELECT gm.group_id, gm.user_id, gm.role, u.username FROM tb_group_members AS gm JOIN tb_users AS u ON u.id = gm.user_id WHERE gm.group_id IN ($1) AND u.date_deleted IS NULL ORDER BY gm.user_id`
+	const sqlUpdateGroup = `UPDATE "tb_groups" SET`
+	const sqlCountGroup = `SELECT count(*) FROM "tb_groups" WHERE tenant_id = $1 AND id = $2`
+	const sqlDeleteGroup = `DELETE FROM "tb_groups" WHERE tenant_id = $1 AND id = $2`
+	const sqlLockGroup = `UPDATE "tb_groups" SET "date_updated"=$1,"version"=version + 1 WHERE tenant_id = $2 AND id = $3`
+	const sqlMemberRole = `SELECT "role" FROM "tb_group_members" WHERE group_id = $1 AND user_id = $2`
+	const sqlCountAdmins = `SELECT count(*) FROM "tb_group_members" WHERE group_id = $1 AND user_id <> $2 AND role = $3`
+	const sqlUpsertMember = `INSERT INTO "tb_group_members" ("group_id","user_id","role") VALUES ($1,$2,$3) ON CONFLICT ("group_id","user_id") DO UPDATE SET "role"="excluded"."role"`
+	const sqlDeleteMember = `DELETE FROM "tb_group_members" WHERE group_id = $1 AND user_id = $2`
+	const sqlUserGroups = `SELECT gm.user_id, g.id, g.organization_id, o.name AS organization, g.display_name, gm.role FROM tb_group_members AS gm JOIN tb_groups AS g ON g.id = gm.group_id LEFT JOIN tb_organizations AS o ON o.id = g.organization_id WHERE gm.user_id IN ($1,$2) AND g.tenant_id = $3 ORDER BY o.name NULLS FIRST, g.display_name`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewGroupsRepo(gormDB)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Create group with members", func(t *testing.T) {
+		group := &domain.Group{
+			DisplayName: "Engineering",
+			DateCreated: now,
+			DateUpdated: now,
+			Version:     1,
+			Members:     []domain.GroupMember{{UserId: 1}, {UserId: 2}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertGroup)).
+			WithArgs("default", nil, "Engineering", "", now, now, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountUsers)).
+			WithArgs("default", 1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertMembers)).
+			WithArgs(7, 1, "member", 7, 2, "member").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := r.CreateGroup(ctx, group)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(7), group.Id)
+		assert.Equal(t, uint32(7), group.Members[1].GroupId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Member of another tenant", func(t *testing.T) {
+		group := &domain.Group{
+			DisplayName: "Engineering",
+			DateCreated: now,
+			DateUpdated: now,
+			Version:     1,
+			Members:     []domain.GroupMember{{UserId: 1}, {UserId: 2}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertGroup)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountUsers)).
+			WithArgs("default", 1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := r.CreateGroup(ctx, group)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Member not found", func(t *testing.T) {
+		group := &domain.Group{
+			DisplayName: "Engineering",
+			DateCreated: now,
+			DateUpdated: now,
+			Version:     1,
+			Members:     []domain.GroupMember{{UserId: 1}, {UserId: 2}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertGroup)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountUsers)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertMembers)).
+			WillReturnError(&pgconn.PgError{Code: pgErrCodeForeignKeyViolation})
+		mock.ExpectRollback()
+
+		err := r.CreateGroup(ctx, group)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Group name taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertGroup)).
+			WillReturnError(&pgconn.PgError{Code: pgErrCodeUniqueViolation, ConstraintName: "tb_groups_display_name"})
+		mock.ExpectRollback()
+
+		err := r.CreateGroup(ctx, &domain.Group{DisplayName: "Engineering", DateCreated: now, DateUpdated: now, Version: 1})
+		assert.Equal(t, ErrRecordAlreadyExist, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get group", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectGroup)).
+			WithArgs("default", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "display_name", "version"}).AddRow(7, "Engineering", 2))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectMembers)).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"group_id", "user_id", "role", "username"}).AddRow(7, 1, "admin", "alice"))
+
+		group, err := r.GetGroup(ctx, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, "Engineering", group.DisplayName)
+		assert.Equal(t, []domain.GroupMember{{GroupId: 7, UserId: 1, Role: "admin", Username: "alice"}}, group.Members)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Group not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectGroup)).
+			WithArgs("default", 8).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		group, err := r.GetGroup(ctx, 8)
+		assert.Nil(t, group)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Group was changed", func(t *testing.T) {
+		group := &domain.Group{Id: 7, DisplayName: "Platform", DateUpdated: now, Version: 1}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdateGroup)).
+			WithArgs(now, "Platform", "", "default", 7, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountGroup)).
+			WithArgs("default", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := r.UpdateGroup(ctx, group)
+		assert.Equal(t, ErrRecordVersionConflict, err)
+		assert.Equal(t, uint32(1), group.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete group not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlDeleteGroup)).
+			WithArgs("default", 8).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := r.DeleteGroup(ctx, 8)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List users' groups", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlUserGroups)).
+			WithArgs(1, 2, "default").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "organization_id", "organization", "display_name", "role"}).
+				AddRow(1, 7, nil, nil, "Engineering", "member").
+				AddRow(2, 7, nil, nil, "Engineering", "admin").
+				AddRow(1, 9, 3, "Acme", "Platform", "member"))
+
+		groups, err := r.ListUserGroups(ctx, []uint32{1, 2})
+		assert.NoError(t, err)
+		organizationId := uint32(3)
+		assert.Equal(t, []domain.Group{{Id: 7, DisplayName: "Engineering", Role: "member"}, {Id: 9, OrganizationId: &organizationId, Organization: "Acme", DisplayName: "Platform", Role: "member"}}, groups[1])
+		assert.Equal(t, []domain.Group{{Id: 7, DisplayName: "Engineering", Role: "admin"}}, groups[2])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Add group member", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlLockGroup)).
+			WithArgs(now, "default", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlMemberRole)).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "tb_users" WHERE tenant_id = $1 AND id = $2`)).
+			WithArgs("default", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpsertMember)).
+			WithArgs(7, 2, "admin").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := r.SetGroupMember(ctx, &domain.GroupMember{GroupId: 7, UserId: 2, Role: domain.GroupRoleAdmin}, now)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Last admin isn't demoted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlLockGroup)).
+			WithArgs(now, "default", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlMemberRole)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("admin"))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountAdmins)).
+			WithArgs(7, 1, "admin").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := r.SetGroupMember(ctx, &domain.GroupMember{GroupId: 7, UserId: 1, Role: domain.GroupRoleMember}, now)
+		assert.Equal(t, ErrLastGroupAdmin, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Admin leaves group with another admin", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlLockGroup)).
+			WithArgs(now, "default", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlMemberRole)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("admin"))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCountAdmins)).
+			WithArgs(7, 1, "admin").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(sqlDeleteMember)).
+			WithArgs(7, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := r.DeleteGroupMember(ctx, 7, 1, now)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Member of another tenant's group isn't removed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlLockGroup)).
+			WithArgs(now, "default", 8).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := r.DeleteGroupMember(ctx, 8, 1, now)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
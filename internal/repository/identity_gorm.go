@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service-account/internal/domain"
+	"time"
+)
+
+// CreateUserWithIdentity registers the user signed in with the upstream provider and links the identity to the user.
+// It's ErrRecordAlreadyExist if the username, the email or the identity is taken in the context's tenant.
+func (r *UserRepositoryGorm) CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.Identity) error {
+	user.TenantId = domain.TenantId(ctx)
+	identity.TenantId = user.TenantId
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("tb_users").Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserId = user.Id
+
+		return tx.Table("tb_user_identities").Create(identity).Error
+	})
+	if err != nil {
+		return r.txError(err)
+	}
+
+	return nil
+}
+
+// GetIdentity finds the identity of the context's tenant by the provider's subject.
+func (r *UserRepositoryGorm) GetIdentity(ctx context.Context, provider string, subject string) (*domain.Identity, error) {
+	identity := new(domain.Identity)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_user_identities").Where("provider = ? AND subject = ?", provider, subject).Take(identity)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, db.Error
+	}
+
+	return identity, nil
+}
+
+// TouchIdentity updates the identity's email and last used date at the sign-in.
+func (r *UserRepositoryGorm) TouchIdentity(ctx context.Context, id uint32, email string, lastUsed time.Time) error {
+	return tenantTable(ctx, r.db.WithContext(ctx), "tb_user_identities").Where("id = ?", id).Updates(map[string]interface{}{
+		"email":          email,
+		"date_last_used": lastUsed,
+	}).Error
+}
+
+// CreateUpstreamLogin stores the sign-in of the context's tenant waiting for the provider's callback,
+// the expired ones are dropped on the way.
+func (r *UserRepositoryGorm) CreateUpstreamLogin(ctx context.Context, login *domain.UpstreamLogin) error {
+	login.TenantId = domain.TenantId(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_upstream_logins").Where("date_expires < ?", time.Now()).Delete(&domain.UpstreamLogin{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Table("tb_upstream_logins").Create(login).Error
+	})
+}
+
+// TakeUpstreamLogin returns and deletes the sign-in of the state's hash, the state is used once.
+// It's ErrRecordNotFound if there is none. The state finds the sign-in of any tenant, the callback restores its tenant.
+func (r *UserRepositoryGorm) TakeUpstreamLogin(ctx context.Context, stateHash string) (*domain.UpstreamLogin, error) {
+	login := new(domain.UpstreamLogin)
+	db := r.db.WithContext(ctx).Raw(`DELETE FROM tb_upstream_logins WHERE state_hash = ? RETURNING *`, stateHash).Scan(login)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return login, nil
+}
+
+// ListIdentities returns the user's identities, the oldest first.
+func (r *UserRepositoryGorm) ListIdentities(ctx context.Context, userId uint32) ([]domain.Identity, error) {
+	var identities []domain.Identity
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_user_identities").Where("user_id = ?", userId).Order("id").Find(&identities)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return identities, nil
+}
+
+// CreateIdentity links the identity to the existing user.
+// It's ErrRecordAlreadyExist if the provider's subject is linked already in the context's tenant.
+func (r *UserRepositoryGorm) CreateIdentity(ctx context.Context, identity *domain.Identity) error {
+	identity.TenantId = domain.TenantId(ctx)
+	if err := r.db.WithContext(ctx).Table("tb_user_identities").Create(identity).Error; err != nil {
+		return r.txError(err)
+	}
+
+	return nil
+}
+
+// DeleteIdentity unlinks the user's identity. It's ErrRecordNotFound if the user has no such identity
+// and ErrLastLoginMethod if the user has no password and no other identity.
+func (r *UserRepositoryGorm) DeleteIdentity(ctx context.Context, userId uint32, id uint32) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := r.lockUser(ctx, tx, userId)
+		if err != nil {
+			return err
+		}
+
+		var identities int64
+		if err = tenantTable(ctx, tx, "tb_user_identities").Where("user_id = ? AND id <> ?", userId, id).Count(&identities).Error; err != nil {
+			return err
+		}
+
+		if identities == 0 && !user.HasPassword() {
+			return ErrLastLoginMethod
+		}
+
+		db := tenantTable(ctx, tx, "tb_user_identities").Where("id = ? AND user_id = ?", id, userId).Delete(&domain.Identity{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if db.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return r.txError(err)
+	}
+
+	return nil
+}
+
+// RemovePasswordHash removes the user's password, the version is incremented.
+// It's ErrLastLoginMethod if the user has no identity.
+func (r *UserRepositoryGorm) RemovePasswordHash(ctx context.Context, userId uint32) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := r.lockUser(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		var identities int64
+		if err := tenantTable(ctx, tx, "tb_user_identities").Where("user_id = ?", userId).Count(&identities).Error; err != nil {
+			return err
+		}
+
+		if identities == 0 {
+			return ErrLastLoginMethod
+		}
+
+		return tenantTable(ctx, tx, "tb_users").Where("id = ?", userId).Updates(map[string]interface{}{
+			"password_hash": []byte{},
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+	})
+	if err != nil {
+		return r.txError(err)
+	}
+
+	return nil
+}
+
+// lockUser locks the user's row till the end of the transaction, so the login methods are removed one at a time.
+func (r *UserRepositoryGorm) lockUser(ctx context.Context, tx *gorm.DB, userId uint32) (*domain.User, error) {
+	user := new(domain.User)
+	db := tenantTable(ctx, tx, "tb_users").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userId).Take(user)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return user, nil
+}
+
+// CreateIdentityLink stores the identity waiting for the account's password, the expired ones are dropped on the way.
+func (r *UserRepositoryGorm) CreateIdentityLink(ctx context.Context, link *domain.IdentityLink) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_identity_links").Where("date_expires < ?", time.Now()).Delete(&domain.IdentityLink{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Table("tb_identity_links").Create(link).Error
+	})
+}
+
+// GetIdentityLink finds the identity link by the token's hash, it's ErrRecordNotFound if there is none.
+func (r *UserRepositoryGorm) GetIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error) {
+	link := new(domain.IdentityLink)
+	db := r.db.WithContext(ctx).Table("tb_identity_links").Where("token_hash = ?", tokenHash).Take(link)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, db.Error
+	}
+
+	return link, nil
+}
+
+// TakeIdentityLink returns and deletes the identity link of the token's hash, the token is used once.
+// It's ErrRecordNotFound if there is none.
+func (r *UserRepositoryGorm) TakeIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error) {
+	link := new(domain.IdentityLink)
+	db := r.db.WithContext(ctx).Raw(`DELETE FROM tb_identity_links WHERE token_hash = ? RETURNING *`, tokenHash).Scan(link)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return link, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+)
+
+func TestUser_Identity(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_user_identities" WHERE tenant_id = $1 AND (provider = $2 AND subject = $3)`
+	const sqlTake = `DELETE FROM tb_upstream_logins WHERE state_hash = $1 RETURNING *`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Get identity", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("default", "keycloak", "upstream-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "provider", "subject"}).AddRow(3, 1, "keycloak", "upstream-1"))
+
+		identity, err := r.GetIdentity(ctx, "keycloak", "upstream-1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), identity.UserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Identity not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("default", "keycloak", "upstream-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		identity, err := r.GetIdentity(ctx, "keycloak", "upstream-2")
+		assert.Nil(t, identity)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Take upstream login", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlTake)).
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows([]string{"state_hash", "provider", "challenge"}).AddRow("hash", "keycloak", "challenge"))
+
+		login, err := r.TakeUpstreamLogin(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, "challenge", login.Challenge)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Upstream login already taken", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlTake)).
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows([]string{"state_hash"}))
+
+		login, err := r.TakeUpstreamLogin(ctx, "hash")
+		assert.Nil(t, login)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Last identity isn't deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tb_users" WHERE tenant_id = $1 AND id = $2 LIMIT 1 FOR UPDATE`)).
+			WithArgs("default", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash"}).AddRow(1, []byte{}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "tb_user_identities" WHERE tenant_id = $1 AND (user_id = $2 AND id <> $3)`)).
+			WithArgs("default", 1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := r.DeleteIdentity(ctx, 1, 3)
+		assert.Equal(t, ErrLastLoginMethod, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Identity is deleted with the password left", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tb_users" WHERE tenant_id = $1 AND id = $2 LIMIT 1 FOR UPDATE`)).
+			WithArgs("default", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash"}).AddRow(1, []byte("hash")))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "tb_user_identities" WHERE tenant_id = $1 AND (user_id = $2 AND id <> $3)`)).
+			WithArgs("default", 1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "tb_user_identities" WHERE tenant_id = $1 AND (id = $2 AND user_id = $3)`)).
+			WithArgs("default", 3, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := r.DeleteIdentity(ctx, 1, 3)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Password isn't removed without identities", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tb_users" WHERE tenant_id = $1 AND id = $2 LIMIT 1 FOR UPDATE`)).
+			WithArgs("default", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash"}).AddRow(1, []byte("hash")))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "tb_user_identities" WHERE tenant_id = $1 AND user_id = $2`)).
+			WithArgs("default", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := r.RemovePasswordHash(ctx, 1)
+		assert.Equal(t, ErrLastLoginMethod, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Take identity link", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM tb_identity_links WHERE token_hash = $1 RETURNING *`)).
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows([]string{"token_hash", "user_id", "provider"}).AddRow("hash", 1, "keycloak"))
+
+		link, err := r.TakeIdentityLink(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), link.UserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"service-account/internal/domain"
+)
+
+// CreateOrganization creates the organization of the context's tenant, it's ErrRecordAlreadyExist if the name is taken in the tenant.
+func (r *GroupRepositoryGorm) CreateOrganization(ctx context.Context, organization *domain.Organization) error {
+	organization.TenantId = domain.TenantId(ctx)
+	err := r.db.WithContext(ctx).Table("tb_organizations").Create(organization).Error
+
+	return groupError(err)
+}
+
+func (r *GroupRepositoryGorm) GetOrganization(ctx context.Context, id uint32) (*domain.Organization, error) {
+	organization := new(domain.Organization)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_organizations").Where("id = ?", id).Take(organization)
+	if db.Error != nil {
+		return nil, groupError(db.Error)
+	}
+
+	return organization, nil
+}
+
+// ListOrganizations returns the tenant's organizations ordered by the name.
+func (r *GroupRepositoryGorm) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	var organizations []domain.Organization
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_organizations").Order("lower(name)").Find(&organizations)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return organizations, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service-account/internal/domain"
+)
+
+const (
+	// PostgreSQL error codes.
+	// SRC: https://www.postgresql.org/docs/current/errcodes-appendix.html
+	pgErrCodeForeignKeyViolation = "23503"
+	pgErrCodeUniqueViolation     = "23505"
+)
+
+type RoleRepository interface {
+	GetUserRoles(ctx context.Context, userId uint32) ([]string, error)
+	GetUserPermissions(ctx context.Context, userId uint32) ([]string, error)
+	AssignRole(ctx context.Context, userRole *domain.UserRole) error
+	RevokeRole(ctx context.Context, userId uint32, role string) error
+}
+
+// RoleRepositoryGorm reads and changes the roles of the context's tenant users only.
+type RoleRepositoryGorm struct {
+	db *gorm.DB
+}
+
+var _ RoleRepository = &RoleRepositoryGorm{}
+
+func NewRolesRepo(db *gorm.DB) *RoleRepositoryGorm {
+	return &RoleRepositoryGorm{db}
+}
+
+func (r *RoleRepositoryGorm) GetUserRoles(ctx context.Context, userId uint32) ([]string, error) {
+	var roles []string
+	db := r.db.WithContext(ctx).Table("tb_user_roles AS ur").
+		Joins("JOIN tb_users AS u ON u.id = ur.user_id").
+		Where("ur.user_id = ? AND u.tenant_id = ?", userId, domain.TenantId(ctx)).
+		Order("ur.role").
+		Pluck("ur.role", &roles)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepositoryGorm) GetUserPermissions(ctx context.Context, userId uint32) ([]string, error) {
+	var permissions []string
+	db := r.db.WithContext(ctx).Table("tb_user_roles AS ur").
+		Joins("JOIN tb_role_permissions AS rp ON rp.role = ur.role").
+		Joins("JOIN tb_users AS u ON u.id = ur.user_id").
+		Where("ur.user_id = ? AND u.tenant_id = ?", userId, domain.TenantId(ctx)).
+		Distinct().
+		Pluck("rp.permission", &permissions)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return permissions, nil
+}
+
+// AssignRole assigns the role to the user, assigning an already assigned role is not an error.
+func (r *RoleRepositoryGorm) AssignRole(ctx context.Context, userRole *domain.UserRole) error {
+	// The user of another tenant doesn't exist in the context's tenant.
+	var users int64
+	if err := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ?", userRole.UserId).Count(&users).Error; err != nil {
+		return err
+	}
+
+	if users == 0 {
+		return ErrRecordNotFound
+	}
+
+	db := r.db.WithContext(ctx).Table("tb_user_roles").Clauses(clause.OnConflict{DoNothing: true}).Create(userRole)
+	if db.Error != nil {
+		// The user or the role doesn't exist.
+		var pgErr *pgconn.PgError
+		if errors.As(db.Error, &pgErr) && pgErr.Code == pgErrCodeForeignKeyViolation {
+			return ErrRecordNotFound
+		}
+
+		return db.Error
+	}
+
+	return nil
+}
+
+func (r *RoleRepositoryGorm) RevokeRole(ctx context.Context, userId uint32, role string) error {
+	tenantUsers := tenantTable(ctx, r.db, "tb_users").Select("id")
+	db := r.db.WithContext(ctx).Table("tb_user_roles").Where("user_id = ? AND role = ? AND user_id IN (?)", userId, role, tenantUsers).Delete(&domain.UserRole{})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
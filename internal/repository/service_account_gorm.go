@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+	"time"
+)
+
+type ServiceAccountRepository interface {
+	CreateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error
+	GetServiceAccount(ctx context.Context, id uint32) (*domain.ServiceAccount, error)
+	GetServiceAccountByClientId(ctx context.Context, clientId string) (*domain.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]domain.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error
+	DeleteServiceAccount(ctx context.Context, id uint32) error
+	CreateServiceAccountKey(ctx context.Context, key *domain.ServiceAccountKey) error
+	GetServiceAccountKey(ctx context.Context, keyHash string) (*domain.ServiceAccountKey, error)
+	ListServiceAccountKeys(ctx context.Context, serviceAccountId uint32) ([]domain.ServiceAccountKey, error)
+	RotateServiceAccountKey(ctx context.Context, keyId uint32, key *domain.ServiceAccountKey, dateExpires time.Time) error
+	RevokeServiceAccountKey(ctx context.Context, serviceAccountId uint32, keyId uint32, dateRevoked time.Time) error
+	TouchServiceAccountKey(ctx context.Context, id uint32, lastUsed time.Time) error
+}
+
+type ServiceAccountRepositoryGorm struct {
+	db *gorm.DB
+}
+
+var _ ServiceAccountRepository = &ServiceAccountRepositoryGorm{}
+
+func NewServiceAccountsRepo(db *gorm.DB) *ServiceAccountRepositoryGorm {
+	return &ServiceAccountRepositoryGorm{db}
+}
+
+// CreateServiceAccount creates the service account of the context's tenant.
+// It's ErrRecordAlreadyExist if the name is taken in the tenant or the client id is taken.
+func (r *ServiceAccountRepositoryGorm) CreateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error {
+	account.TenantId = domain.TenantId(ctx)
+	err := r.db.WithContext(ctx).Table("tb_service_accounts").Create(account).Error
+
+	return serviceAccountError(err)
+}
+
+func (r *ServiceAccountRepositoryGorm) GetServiceAccount(ctx context.Context, id uint32) (*domain.ServiceAccount, error) {
+	return r.getServiceAccount(ctx, "id = ?", id)
+}
+
+// GetServiceAccountByClientId finds the service account of the context's tenant by its tokens' client id.
+func (r *ServiceAccountRepositoryGorm) GetServiceAccountByClientId(ctx context.Context, clientId string) (*domain.ServiceAccount, error) {
+	return r.getServiceAccount(ctx, "client_id = ?", clientId)
+}
+
+// ListServiceAccounts returns the tenant's service accounts ordered by the name.
+func (r *ServiceAccountRepositoryGorm) ListServiceAccounts(ctx context.Context) ([]domain.ServiceAccount, error) {
+	var accounts []domain.ServiceAccount
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_accounts").Order("lower(name)").Find(&accounts)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return accounts, nil
+}
+
+// UpdateServiceAccount updates the account's name, description, owner team and scope.
+func (r *ServiceAccountRepositoryGorm) UpdateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_accounts").Where("id = ?", account.Id).Updates(map[string]interface{}{
+		"name":           account.Name,
+		"description":    account.Description,
+		"owner_group_id": account.OwnerGroupId,
+		"scope":          account.Scope,
+		"date_updated":   account.DateUpdated,
+	})
+	if db.Error != nil {
+		return serviceAccountError(db.Error)
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteServiceAccount deletes the service account with its keys.
+func (r *ServiceAccountRepositoryGorm) DeleteServiceAccount(ctx context.Context, id uint32) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_accounts").Where("id = ?", id).Delete(&domain.ServiceAccount{})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ServiceAccountRepositoryGorm) CreateServiceAccountKey(ctx context.Context, key *domain.ServiceAccountKey) error {
+	key.TenantId = domain.TenantId(ctx)
+	err := r.db.WithContext(ctx).Table("tb_service_account_keys").Create(key).Error
+
+	return serviceAccountError(err)
+}
+
+// GetServiceAccountKey finds the key of the context's tenant by the key's hash, the revoked and expired ones too.
+func (r *ServiceAccountRepositoryGorm) GetServiceAccountKey(ctx context.Context, keyHash string) (*domain.ServiceAccountKey, error) {
+	key := new(domain.ServiceAccountKey)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_account_keys").Where("key_hash = ?", keyHash).Take(key)
+	if db.Error != nil {
+		return nil, serviceAccountError(db.Error)
+	}
+
+	return key, nil
+}
+
+// ListServiceAccountKeys returns the account's keys, the newest first.
+func (r *ServiceAccountRepositoryGorm) ListServiceAccountKeys(ctx context.Context, serviceAccountId uint32) ([]domain.ServiceAccountKey, error) {
+	var keys []domain.ServiceAccountKey
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_account_keys").Where("service_account_id = ?", serviceAccountId).Order("id DESC").Find(&keys)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return keys, nil
+}
+
+// RotateServiceAccountKey creates the new key of the account and expires its active key at dateExpires,
+// unless it expires earlier. It's ErrRecordNotFound if the account has no such active key.
+func (r *ServiceAccountRepositoryGorm) RotateServiceAccountKey(ctx context.Context, keyId uint32, key *domain.ServiceAccountKey, dateExpires time.Time) error {
+	key.TenantId = domain.TenantId(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tenantTable(ctx, tx, "tb_service_account_keys").
+			Where("id = ? AND service_account_id = ? AND date_revoked IS NULL AND (date_expires IS NULL OR date_expires > ?)", keyId, key.ServiceAccountId, key.DateCreated).
+			Update("date_expires", gorm.Expr("LEAST(COALESCE(date_expires, ?), ?)", dateExpires, dateExpires))
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if db.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		return tx.Table("tb_service_account_keys").Create(key).Error
+	})
+
+	return serviceAccountError(err)
+}
+
+// RevokeServiceAccountKey revokes the account's key. It's ErrRecordNotFound if the account has no such key
+// or it's revoked already.
+func (r *ServiceAccountRepositoryGorm) RevokeServiceAccountKey(ctx context.Context, serviceAccountId uint32, keyId uint32, dateRevoked time.Time) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_account_keys").
+		Where("id = ? AND service_account_id = ? AND date_revoked IS NULL", keyId, serviceAccountId).
+		Update("date_revoked", dateRevoked)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// TouchServiceAccountKey updates the key's last used date.
+func (r *ServiceAccountRepositoryGorm) TouchServiceAccountKey(ctx context.Context, id uint32, lastUsed time.Time) error {
+	return tenantTable(ctx, r.db.WithContext(ctx), "tb_service_account_keys").Where("id = ?", id).Update("date_last_used", lastUsed).Error
+}
+
+func (r *ServiceAccountRepositoryGorm) getServiceAccount(ctx context.Context, query string, arg interface{}) (*domain.ServiceAccount, error) {
+	account := new(domain.ServiceAccount)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_service_accounts").Where(query, arg).Take(account)
+	if db.Error != nil {
+		return nil, serviceAccountError(db.Error)
+	}
+
+	return account, nil
+}
+
+// serviceAccountError maps the missing record, the taken name or client id and the missing owner team.
+func serviceAccountError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgErrCodeUniqueViolation:
+			return ErrRecordAlreadyExist
+		case pgErrCodeForeignKeyViolation:
+			return ErrRecordNotFound
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestServiceAccount(t *testing.T) {
+	const sqlInsertAccount = `INSERT INTO "tb_service_accounts" ("tenant_id","client_id","hydra_client","name","description","owner_group_id","scope","created_by","date_created","date_updated") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "id"`
+	const sqlSelectKey = `SELECT * FROM "tb_service_account_keys" WHERE tenant_id = $1 AND key_hash = $2 LIMIT 1`
+	const sqlExpireKey = `UPDATE "tb_service_account_keys" SET "date_expires"=LEAST(COALESCE(date_expires, $1), $2) WHERE tenant_id = $3 AND (id = $4 AND service_account_id = $5 AND date_revoked IS NULL AND (date_expires IS NULL OR date_expires > $6))`
+	const sqlInsertKey = `INSERT INTO "tb_service_account_keys" ("tenant_id","service_account_id","prefix","key_hash","date_created","date_expires","date_last_used","date_revoked") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`
+	const sqlRevokeKey = `UPDATE "tb_service_account_keys" SET "date_revoked"=$1 WHERE tenant_id = $2 AND (id = $3 AND service_account_id = $4 AND date_revoked IS NULL)`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewServiceAccountsRepo(gormDB)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Create service account of tenant", func(t *testing.T) {
+		groupId := uint32(7)
+		account := &domain.ServiceAccount{
+			ClientId:     "sa-1f2e",
+			Name:         "Billing",
+			OwnerGroupId: &groupId,
+			Scope:        "scim",
+			DateCreated:  now,
+			DateUpdated:  now,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertAccount)).
+			WithArgs("acme", "sa-1f2e", false, "Billing", "", 7, "scim", nil, now, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectCommit()
+
+		err := r.CreateServiceAccount(domain.WithTenant(ctx, &domain.Tenant{Id: "acme"}), account)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(3), account.Id)
+		assert.Equal(t, "acme", account.TenantId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key of another tenant not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectKey)).
+			WithArgs("default", "hash").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := r.GetServiceAccountKey(ctx, "hash")
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rotate key", func(t *testing.T) {
+		graceEnd := now.Add(24 * time.Hour)
+		key := &domain.ServiceAccountKey{ServiceAccountId: 3, Prefix: "sa_live_AbCd", KeyHash: "hash", DateCreated: now}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlExpireKey)).
+			WithArgs(graceEnd, graceEnd, "default", 5, 3, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlInsertKey)).
+			WithArgs("default", 3, "sa_live_AbCd", "hash", now, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+		mock.ExpectCommit()
+
+		err := r.RotateServiceAccountKey(ctx, 5, key, graceEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(6), key.Id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoked key isn't rotated", func(t *testing.T) {
+		graceEnd := now.Add(24 * time.Hour)
+		key := &domain.ServiceAccountKey{ServiceAccountId: 3, Prefix: "sa_live_AbCd", KeyHash: "hash", DateCreated: now}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlExpireKey)).
+			WithArgs(graceEnd, graceEnd, "default", 5, 3, now).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := r.RotateServiceAccountKey(ctx, 5, key, graceEnd)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke key", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlRevokeKey)).
+			WithArgs(now, "default", 5, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := r.RevokeServiceAccountKey(ctx, 3, 5, now)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key of another account isn't revoked", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlRevokeKey)).
+			WithArgs(now, "default", 5, 4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := r.RevokeServiceAccountKey(ctx, 4, 5, now)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"service-account/internal/domain"
+)
+
+// SessionRepository is the server-side store of the browser sessions.
+// The expired sessions (DateExpires) are never returned.
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	Get(ctx context.Context, id string) (*domain.Session, error)
+	Update(ctx context.Context, session *domain.Session) error
+	Delete(ctx context.Context, id string) error
+	DeleteBySid(ctx context.Context, sid string) error
+	ListBySubject(ctx context.Context, subject string) ([]domain.Session, error)
+	// DeleteBySubject deletes all the subject's sessions except the session exceptId.
+	DeleteBySubject(ctx context.Context, subject string, exceptId string) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+	"time"
+)
+
+type SessionRepositoryGorm struct {
+	db *gorm.DB
+}
+
+var _ SessionRepository = &SessionRepositoryGorm{}
+
+func NewSessionsRepoGorm(db *gorm.DB) *SessionRepositoryGorm {
+	return &SessionRepositoryGorm{db}
+}
+
+func (r *SessionRepositoryGorm) Create(ctx context.Context, session *domain.Session) error {
+	db := r.db.WithContext(ctx).Table("tb_sessions").Create(session)
+	if db.Error != nil {
+		return ErrRecordAlreadyExist
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryGorm) Get(ctx context.Context, id string) (*domain.Session, error) {
+	session := new(domain.Session)
+	db := r.db.WithContext(ctx).Table("tb_sessions").Where("id = ? AND date_expires > ?", id, time.Now()).Take(session)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, db.Error
+	}
+
+	return session, nil
+}
+
+// Update writes the session's data and activity, the expiry slides with the idle timeout.
+func (r *SessionRepositoryGorm) Update(ctx context.Context, session *domain.Session) error {
+	db := r.db.WithContext(ctx).Table("tb_sessions").Where("id = ?", session.Id).Updates(map[string]interface{}{
+		"sid":            session.Sid,
+		"data":           session.Data,
+		"date_last_seen": session.DateLastSeen,
+		"date_expires":   session.DateExpires,
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryGorm) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Table("tb_sessions").Where("id = ?", id).Delete(&domain.Session{}).Error
+}
+
+func (r *SessionRepositoryGorm) DeleteBySid(ctx context.Context, sid string) error {
+	return r.db.WithContext(ctx).Table("tb_sessions").Where("sid = ?", sid).Delete(&domain.Session{}).Error
+}
+
+func (r *SessionRepositoryGorm) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	var sessions []domain.Session
+	db := r.db.WithContext(ctx).Table("tb_sessions").Where("subject = ? AND date_expires > ?", subject, time.Now()).Order("date_created").Find(&sessions)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepositoryGorm) DeleteBySubject(ctx context.Context, subject string, exceptId string) error {
+	return r.db.WithContext(ctx).Table("tb_sessions").Where("subject = ? AND id <> ?", subject, exceptId).Delete(&domain.Session{}).Error
+}
+
+func (r *SessionRepositoryGorm) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Table("tb_sessions").Where("date_expires <= ?", time.Now()).Delete(&domain.Session{}).Error
+}
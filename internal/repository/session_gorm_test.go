@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestSessionGorm_Update(t *testing.T) {
+	const sqlUpdate = `UPDATE "tb_sessions" SET "data"=$1,"date_expires"=$2,"date_last_seen"=$3,"sid"=$4 WHERE id = $5`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewSessionsRepoGorm(gormDB)
+	now := time.Now()
+	session := &domain.Session{
+		Id:           "a",
+		Sid:          "sid1",
+		Data:         []byte(`{"access_token":"access"}`),
+		DateLastSeen: now,
+		DateExpires:  now.Add(30 * time.Minute),
+	}
+
+	t.Run("Idle timeout slides", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WithArgs(session.Data, session.DateExpires, now, "sid1", "a").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := r.Update(context.Background(), session)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Session not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WithArgs(session.Data, session.DateExpires, now, "sid1", "a").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := r.Update(context.Background(), session)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
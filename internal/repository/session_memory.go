@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"service-account/internal/domain"
+	"sync"
+	"time"
+)
+
+// SessionRepositoryMemory keeps the sessions in the process memory, the sessions are lost on restart.
+// It's suitable for a single instance of the service.
+type SessionRepositoryMemory struct {
+	mu       sync.RWMutex
+	sessions map[string]domain.Session
+}
+
+var _ SessionRepository = &SessionRepositoryMemory{}
+
+func NewSessionsRepoMemory() *SessionRepositoryMemory {
+	return &SessionRepositoryMemory{
+		sessions: make(map[string]domain.Session),
+	}
+}
+
+func (r *SessionRepositoryMemory) Create(ctx context.Context, session *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.Id]; exists {
+		return ErrRecordAlreadyExist
+	}
+
+	r.sessions[session.Id] = *session
+	return nil
+}
+
+func (r *SessionRepositoryMemory) Get(ctx context.Context, id string) (*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[id]
+	if !exists || !session.DateExpires.After(time.Now()) {
+		return nil, ErrRecordNotFound
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepositoryMemory) Update(ctx context.Context, session *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.Id]; !exists {
+		return ErrRecordNotFound
+	}
+
+	r.sessions[session.Id] = *session
+	return nil
+}
+
+func (r *SessionRepositoryMemory) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *SessionRepositoryMemory) DeleteBySid(ctx context.Context, sid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.Sid == sid {
+			delete(r.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryMemory) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var sessions []domain.Session
+	for _, session := range r.sessions {
+		if session.Subject == subject && session.DateExpires.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepositoryMemory) DeleteBySubject(ctx context.Context, subject string, exceptId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.Subject == subject && id != exceptId {
+			delete(r.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryMemory) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.sessions {
+		if !session.DateExpires.After(now) {
+			delete(r.sessions, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"service-account/internal/domain"
+	"time"
+)
+
+const (
+	redisKeySession        = "session:"
+	redisKeySessionSid     = "session_sid:"
+	redisKeySessionSubject = "session_subject:"
+)
+
+// SessionRepositoryRedis keeps the sessions in Redis, the sessions expire with the keys' TTL.
+// The sessions of a Hydra login session and of a subject are indexed by the sets of the session IDs.
+type SessionRepositoryRedis struct {
+	client redis.UniversalClient
+}
+
+var _ SessionRepository = &SessionRepositoryRedis{}
+
+func NewSessionsRepoRedis(client redis.UniversalClient) *SessionRepositoryRedis {
+	return &SessionRepositoryRedis{client}
+}
+
+func (r *SessionRepositoryRedis) Create(ctx context.Context, session *domain.Session) error {
+	ttl := time.Until(session.DateExpires)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	created, err := r.client.SetNX(ctx, redisKeySession+session.Id, data, ttl).Result()
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		return ErrRecordAlreadyExist
+	}
+
+	return r.index(ctx, session, ttl)
+}
+
+func (r *SessionRepositoryRedis) Get(ctx context.Context, id string) (*domain.Session, error) {
+	data, err := r.client.Get(ctx, redisKeySession+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	session := new(domain.Session)
+	if err = json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *SessionRepositoryRedis) Update(ctx context.Context, session *domain.Session) error {
+	ttl := time.Until(session.DateExpires)
+	if ttl <= 0 {
+		return ErrRecordNotFound
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	updated, err := r.client.SetXX(ctx, redisKeySession+session.Id, data, ttl).Result()
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return ErrRecordNotFound
+	}
+
+	return r.index(ctx, session, ttl)
+}
+
+func (r *SessionRepositoryRedis) Delete(ctx context.Context, id string) error {
+	session, err := r.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, redisKeySession+id)
+	if session.Sid != "" {
+		pipe.SRem(ctx, redisKeySessionSid+session.Sid, id)
+	}
+	if session.Subject != "" {
+		pipe.SRem(ctx, redisKeySessionSubject+session.Subject, id)
+	}
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *SessionRepositoryRedis) DeleteBySid(ctx context.Context, sid string) error {
+	ids, err := r.client.SMembers(ctx, redisKeySessionSid+sid).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{redisKeySessionSid + sid}
+	for _, id := range ids {
+		keys = append(keys, redisKeySession+id)
+	}
+
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func (r *SessionRepositoryRedis) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	ids, err := r.client.SMembers(ctx, redisKeySessionSubject+subject).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []domain.Session
+	for _, id := range ids {
+		session, err := r.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				// The index may refer to the expired session.
+				continue
+			}
+
+			return nil, err
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+// DeleteBySubject deletes the subject's sessions one by one, so the Hydra login session indexes stay consistent.
+func (r *SessionRepositoryRedis) DeleteBySubject(ctx context.Context, subject string, exceptId string) error {
+	ids, err := r.client.SMembers(ctx, redisKeySessionSubject+subject).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if id == exceptId {
+			continue
+		}
+
+		if err = r.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		// The index may refer to the expired session.
+		if err = r.client.SRem(ctx, redisKeySessionSubject+subject, id).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpired does nothing, Redis expires the sessions by itself.
+func (r *SessionRepositoryRedis) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func (r *SessionRepositoryRedis) index(ctx context.Context, session *domain.Session, ttl time.Duration) error {
+	if session.Sid != "" {
+		if err := r.addToIndex(ctx, redisKeySessionSid+session.Sid, session.Id, ttl); err != nil {
+			return err
+		}
+	}
+
+	if session.Subject != "" {
+		return r.addToIndex(ctx, redisKeySessionSubject+session.Subject, session.Id, ttl)
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryRedis) addToIndex(ctx context.Context, key string, id string, ttl time.Duration) error {
+	if err := r.client.SAdd(ctx, key, id).Err(); err != nil {
+		return err
+	}
+
+	// The index lives as long as the longest of its sessions.
+	indexTtl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	if indexTtl < ttl {
+		return r.client.Expire(ctx, key, ttl).Err()
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestSessionRepository(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	defer redisClient.Close()
+
+	repositories := map[string]SessionRepository{
+		"memory": NewSessionsRepoMemory(),
+		"redis":  NewSessionsRepoRedis(redisClient),
+	}
+
+	for name, repo := range repositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+			newSession := func(id string, sid string) *domain.Session {
+				return &domain.Session{
+					Id:           id,
+					Sid:          sid,
+					Subject:      "1",
+					Data:         []byte(`{"access_token":"access"}`),
+					DateCreated:  now,
+					DateLastSeen: now,
+					DateExpires:  now.Add(time.Hour),
+				}
+			}
+
+			// Create and get.
+			session := newSession("a", "sid1")
+			assert.NoError(t, repo.Create(ctx, session))
+			assert.ErrorIs(t, repo.Create(ctx, session), ErrRecordAlreadyExist)
+			got, err := repo.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.Equal(t, session, got)
+
+			// Update.
+			session.Data = []byte(`{"access_token":"access2"}`)
+			assert.NoError(t, repo.Update(ctx, session))
+			got, err = repo.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.Equal(t, session.Data, got.Data)
+			assert.ErrorIs(t, repo.Update(ctx, newSession("unknown", "")), ErrRecordNotFound)
+
+			// Delete by the Hydra login session.
+			assert.NoError(t, repo.Create(ctx, newSession("b", "sid1")))
+			assert.NoError(t, repo.Create(ctx, newSession("c", "sid2")))
+			assert.NoError(t, repo.DeleteBySid(ctx, "sid1"))
+			_, err = repo.Get(ctx, "a")
+			assert.ErrorIs(t, err, ErrRecordNotFound)
+			_, err = repo.Get(ctx, "b")
+			assert.ErrorIs(t, err, ErrRecordNotFound)
+			_, err = repo.Get(ctx, "c")
+			assert.NoError(t, err)
+
+			// Delete by the subject except the current session.
+			other := newSession("e", "sid3")
+			other.Subject = "2"
+			assert.NoError(t, repo.Create(ctx, newSession("b", "sid3")))
+			assert.NoError(t, repo.Create(ctx, other))
+			listed, err := repo.ListBySubject(ctx, "1")
+			assert.NoError(t, err)
+			assert.Len(t, listed, 2)
+			assert.NoError(t, repo.DeleteBySubject(ctx, "1", "c"))
+			_, err = repo.Get(ctx, "b")
+			assert.ErrorIs(t, err, ErrRecordNotFound)
+			_, err = repo.Get(ctx, "c")
+			assert.NoError(t, err)
+			_, err = repo.Get(ctx, "e")
+			assert.NoError(t, err)
+
+			// Delete.
+			assert.NoError(t, repo.Delete(ctx, "c"))
+			_, err = repo.Get(ctx, "c")
+			assert.ErrorIs(t, err, ErrRecordNotFound)
+
+			// Expiry.
+			expired := newSession("d", "")
+			expired.DateExpires = now.Add(-time.Second)
+			assert.NoError(t, repo.Create(ctx, expired))
+			_, err = repo.Get(ctx, "d")
+			assert.ErrorIs(t, err, ErrRecordNotFound)
+			assert.NoError(t, repo.DeleteExpired(ctx))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service-account/internal/domain"
+	"time"
+)
+
+// CreateStepUp stores the sign-in waiting for the one-time code, it replaces the former one of the challenge.
+// The expired step-ups are dropped on the way.
+func (r *UserRepositoryGorm) CreateStepUp(ctx context.Context, stepUp *domain.StepUp) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("tb_login_step_ups").Where("date_expires < ?", time.Now()).Delete(&domain.StepUp{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Table("tb_login_step_ups").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "challenge"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "code_hash", "remember", "device_id", "attempts", "date_created", "date_expires"}),
+		}).Create(stepUp).Error
+	})
+}
+
+// GetStepUp returns the challenge's step-up, it's ErrRecordNotFound if there is none.
+func (r *UserRepositoryGorm) GetStepUp(ctx context.Context, challenge string) (*domain.StepUp, error) {
+	stepUp := new(domain.StepUp)
+	db := r.db.WithContext(ctx).Table("tb_login_step_ups").Where("challenge = ?", challenge).Take(stepUp)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, db.Error
+	}
+
+	return stepUp, nil
+}
+
+// CountStepUpAttempt counts the attempt to enter the challenge's code, returns the number of the attempts so far.
+func (r *UserRepositoryGorm) CountStepUpAttempt(ctx context.Context, challenge string) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).Raw(`UPDATE tb_login_step_ups SET attempts = attempts + 1 WHERE challenge = ? RETURNING attempts`, challenge).
+		Row().Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+func (r *UserRepositoryGorm) DeleteStepUp(ctx context.Context, challenge string) error {
+	return r.db.WithContext(ctx).Table("tb_login_step_ups").Where("challenge = ?", challenge).Delete(&domain.StepUp{}).Error
+}
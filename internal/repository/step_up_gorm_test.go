@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+)
+
+func TestUser_StepUp(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_login_step_ups" WHERE challenge = $1`
+	const sqlAttempt = `UPDATE tb_login_step_ups SET attempts = attempts + 1 WHERE challenge = $1 RETURNING attempts`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Step-up not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("challenge").
+			WillReturnRows(sqlmock.NewRows([]string{"challenge"}))
+
+		stepUp, err := r.GetStepUp(ctx, "challenge")
+		assert.Nil(t, stepUp)
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Count attempt", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlAttempt)).
+			WithArgs("challenge").
+			WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(2))
+
+		attempts, err := r.CountStepUpAttempt(ctx, "challenge")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Count attempt of missing step-up", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlAttempt)).
+			WithArgs("challenge").
+			WillReturnRows(sqlmock.NewRows([]string{"attempts"}))
+
+		_, err := r.CountStepUpAttempt(ctx, "challenge")
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+)
+
+// tenantTable returns the query of the table's rows of the context's tenant, the rows of the other tenants
+// are never read or changed.
+func tenantTable(ctx context.Context, db *gorm.DB, table string) *gorm.DB {
+	return db.Table(table).Where("tenant_id = ?", domain.TenantId(ctx))
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"service-account/internal/domain"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UpdateLastOnline sets the last online dates of the context's tenant users in one query, the later dates aren't overwritten.
+func (r *UserRepositoryGorm) UpdateLastOnline(ctx context.Context, lastOnline map[uint32]time.Time) error {
+	if len(lastOnline) == 0 {
+		return nil
+	}
+
+	ids := make([]uint32, 0, len(lastOnline))
+	for id := range lastOnline {
+		ids = append(ids, id)
+	}
+
+	// The rows are locked in the same order by the concurrent updates.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	values := make([]string, 0, len(ids))
+	args := make([]interface{}, 0, 2*len(ids))
+	for _, id := range ids {
+		values = append(values, "(?::integer, ?::timestamptz)")
+		args = append(args, id, lastOnline[id])
+	}
+	args = append(args, domain.TenantId(ctx))
+
+	return r.db.WithContext(ctx).Exec(
+		`UPDATE tb_users AS u SET date_last_online = v.date_last_online FROM (VALUES `+strings.Join(values, ", ")+
+			`) AS v (id, date_last_online) WHERE u.id = v.id AND u.tenant_id = ? AND u.date_last_online < v.date_last_online`,
+		args...,
+	).Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUser_UpdateLastOnline(t *testing.T) {
+	const sqlUpdate = `UPDATE tb_users AS u SET date_last_online = v.date_last_online FROM (VALUES ($1::integer, $2::timestamptz), ($3::integer, $4::timestamptz)) AS v (id, date_last_online) WHERE u.id = v.id AND u.tenant_id = $5 AND u.date_last_online < v.date_last_online`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Update users in one query", func(t *testing.T) {
+		first, second := time.Now(), time.Now().Add(time.Second)
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WithArgs(1, first, 7, second, "default").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, r.UpdateLastOnline(ctx, map[uint32]time.Time{7: second, 1: first}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing to update", func(t *testing.T) {
+		assert.NoError(t, r.UpdateLastOnline(ctx, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+	"time"
+)
+
+// SoftDelete marks the user deleted, the deleted users aren't found until they're restored.
+// The restore token's hash is kept to restore the user by the link.
+func (r *UserRepositoryGorm) SoftDelete(ctx context.Context, id uint32, restoreTokenHash string) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND date_deleted IS NULL", id).Updates(map[string]interface{}{
+		"date_deleted":       time.Now(),
+		"restore_token_hash": restoreTokenHash,
+		"version":            gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Restore unmarks the user deleted after deletedAfter by the restore token's hash.
+func (r *UserRepositoryGorm) Restore(ctx context.Context, restoreTokenHash string, deletedAfter time.Time) (*domain.User, error) {
+	user := new(domain.User)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tenantTable(ctx, tx, "tb_users").Where("restore_token_hash = ? AND date_deleted > ?", restoreTokenHash, deletedAfter).Take(user)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tenantTable(ctx, tx, "tb_users").Where("id = ?", user.Id).Updates(map[string]interface{}{
+			"date_deleted":       nil,
+			"restore_token_hash": nil,
+			"version":            gorm.Expr("version + 1"),
+		}).Error
+	})
+	if err != nil {
+		return nil, r.txError(err)
+	}
+
+	user.Version++
+
+	return user, nil
+}
+
+// PurgeDeleted erases the users deleted before deletedBefore with their data, returns the number of the erased users.
+func (r *UserRepositoryGorm) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("date_deleted <= ?", deletedBefore).Delete(&domain.User{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+
+	return db.RowsAffected, nil
+}
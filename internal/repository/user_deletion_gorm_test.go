@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUser_SoftDeleteAndRestore(t *testing.T) {
+	const sqlUpdate = `UPDATE "tb_users" SET`
+	const sqlSelect = `SELECT * FROM "tb_users"`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Soft delete user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.SoftDelete(ctx, 1, "hash"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Soft delete deleted user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.Equal(t, ErrRecordNotFound, r.SoftDelete(ctx, 1, "hash"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Restore user", func(t *testing.T) {
+		deletedAfter := time.Now().Add(-time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("default", "hash", deletedAfter).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "version"}).AddRow(1, "test@mail.com", 2))
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		user, err := r.Restore(ctx, "hash", deletedAfter)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(3), user.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Restore link expired", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := r.Restore(ctx, "hash", time.Now())
+		assert.Equal(t, ErrRecordNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
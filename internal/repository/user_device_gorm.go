@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"service-account/internal/domain"
+)
+
+// SaveDevice remembers the user's device or updates its last seen date, returns true if the device is new.
+func (r *UserRepositoryGorm) SaveDevice(ctx context.Context, device *domain.UserDevice) (bool, error) {
+	// The inserted row has no previous version, xmax is 0.
+	var created bool
+	err := r.db.WithContext(ctx).Raw(`INSERT INTO tb_user_devices (user_id, device_id, user_agent, ip, date_first_seen, date_last_seen)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id, device_id) DO UPDATE
+SET user_agent = EXCLUDED.user_agent, ip = EXCLUDED.ip, date_last_seen = EXCLUDED.date_last_seen
+RETURNING xmax = 0`,
+		device.UserId, device.DeviceId, device.UserAgent, device.Ip, device.DateFirstSeen, device.DateLastSeen,
+	).Row().Scan(&created)
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// HasDevices reports whether any device of the user is known.
+func (r *UserRepositoryGorm) HasDevices(ctx context.Context, userId uint32) (bool, error) {
+	var exists bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (SELECT 1 FROM tb_user_devices WHERE user_id = ?)`, userId).
+		Row().Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// HasDevice reports whether the user's device is known.
+func (r *UserRepositoryGorm) HasDevice(ctx context.Context, userId uint32, deviceId string) (bool, error) {
+	var exists bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (SELECT 1 FROM tb_user_devices WHERE user_id = ? AND device_id = ?)`, userId, deviceId).
+		Row().Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// ListDevices returns the user's known devices, the last seen first.
+func (r *UserRepositoryGorm) ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error) {
+	var devices []domain.UserDevice
+	err := r.db.WithContext(ctx).Table("tb_user_devices").
+		Where("user_id = ?", userId).
+		Order("date_last_seen DESC").
+		Find(&devices).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestUser_SaveDevice(t *testing.T) {
+	const sqlUpsert = `INSERT INTO tb_user_devices (user_id, device_id, user_agent, ip, date_first_seen, date_last_seen)`
+	const sqlExists = `SELECT EXISTS (SELECT 1 FROM tb_user_devices WHERE user_id = $1)`
+	const sqlList = `SELECT * FROM "tb_user_devices" WHERE user_id = $1 ORDER BY date_last_seen DESC`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Save new device", func(t *testing.T) {
+		now := time.Now()
+		device := &domain.UserDevice{UserId: 1, DeviceId: "device", UserAgent: "agent", Ip: "192.0.2.1", DateFirstSeen: now, DateLastSeen: now}
+		mock.ExpectQuery(regexp.QuoteMeta(sqlUpsert)).
+			WithArgs(1, "device", "agent", "192.0.2.1", now, now).
+			WillReturnRows(sqlmock.NewRows([]string{"created"}).AddRow(true))
+
+		created, err := r.SaveDevice(ctx, device)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("User has devices", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlExists)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		exists, err := r.HasDevices(ctx, 1)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List devices", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlList)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "device_id", "user_agent", "ip"}).
+				AddRow(1, "phone", "agent", "192.0.2.1").
+				AddRow(1, "laptop", "agent", "192.0.2.2"))
+
+		devices, err := r.ListDevices(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.UserDevice{
+			{UserId: 1, DeviceId: "phone", UserAgent: "agent", Ip: "192.0.2.1"},
+			{UserId: 1, DeviceId: "laptop", UserAgent: "agent", Ip: "192.0.2.2"},
+		}, devices)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -3,19 +3,67 @@ package repository
 import (
 	"context"
 	"errors"
+	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
 	"service-account/internal/domain"
+	"time"
 )
 
 var (
 	ErrRecordNotFound     = errors.New("Record not found")
 	ErrRecordAlreadyExist = errors.New("Record already exist")
+	// ErrUsernameAlreadyExist, ErrEmailAlreadyExist and ErrExternalIdAlreadyExist the user's unique column is taken
+	// in the tenant.
+	ErrUsernameAlreadyExist   = errors.New("Username already exist")
+	ErrEmailAlreadyExist      = errors.New("Email already exist")
+	ErrExternalIdAlreadyExist = errors.New("External id already exist")
+	// ErrRecordVersionConflict the record was changed since it was read.
+	ErrRecordVersionConflict = errors.New("Record version conflict")
+	// ErrLastLoginMethod the user's only password or identity can't be removed.
+	ErrLastLoginMethod = errors.New("Last login method")
+	// ErrLastGroupAdmin the group's only admin can't leave the group or become its member.
+	ErrLastGroupAdmin = errors.New("Last group admin")
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetUserById(ctx context.Context, id uint32) (*domain.User, error)
+	Update(ctx context.Context, user *domain.User) error
+	UpdatePasswordHash(ctx context.Context, id uint32, passwordHash []byte) error
+	CreateEmailChange(ctx context.Context, emailChange *domain.EmailChange) error
+	ConfirmEmailChange(ctx context.Context, tokenHash string, revertTokenHash string, revertExpires time.Time) (*domain.EmailChange, error)
+	RevertEmailChange(ctx context.Context, revertTokenHash string) (*domain.EmailChange, error)
+	ListEmailChanges(ctx context.Context, userId uint32) ([]domain.EmailChange, error)
+	SoftDelete(ctx context.Context, id uint32, restoreTokenHash string) error
+	Restore(ctx context.Context, restoreTokenHash string, deletedAfter time.Time) (*domain.User, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	List(ctx context.Context, query *domain.UserListQuery) ([]domain.User, int64, error)
+	Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error
+	Unsuspend(ctx context.Context, id uint32) error
+	RecordFailedSignin(ctx context.Context, id uint32, threshold int, lockUntil time.Time) error
+	Unlock(ctx context.Context, id uint32) error
+	UpdateLastOnline(ctx context.Context, lastOnline map[uint32]time.Time) error
+	SaveDevice(ctx context.Context, device *domain.UserDevice) (bool, error)
+	HasDevices(ctx context.Context, userId uint32) (bool, error)
+	HasDevice(ctx context.Context, userId uint32, deviceId string) (bool, error)
+	CreateStepUp(ctx context.Context, stepUp *domain.StepUp) error
+	GetStepUp(ctx context.Context, challenge string) (*domain.StepUp, error)
+	CountStepUpAttempt(ctx context.Context, challenge string) (int, error)
+	DeleteStepUp(ctx context.Context, challenge string) error
+	CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.Identity) error
+	GetIdentity(ctx context.Context, provider string, subject string) (*domain.Identity, error)
+	TouchIdentity(ctx context.Context, id uint32, email string, lastUsed time.Time) error
+	CreateUpstreamLogin(ctx context.Context, login *domain.UpstreamLogin) error
+	TakeUpstreamLogin(ctx context.Context, stateHash string) (*domain.UpstreamLogin, error)
+	ListIdentities(ctx context.Context, userId uint32) ([]domain.Identity, error)
+	CreateIdentity(ctx context.Context, identity *domain.Identity) error
+	DeleteIdentity(ctx context.Context, userId uint32, id uint32) error
+	RemovePasswordHash(ctx context.Context, userId uint32) error
+	CreateIdentityLink(ctx context.Context, link *domain.IdentityLink) error
+	GetIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error)
+	TakeIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error)
 }
 
 type UserRepositoryGorm struct {
@@ -28,8 +76,9 @@ func NewUsersRepo(db *gorm.DB) *UserRepositoryGorm {
 	return &UserRepositoryGorm{db}
 }
 
-// Create user.
+// Create user of the context's tenant.
 func (r *UserRepositoryGorm) Create(ctx context.Context, user *domain.User) error {
+	user.TenantId = domain.TenantId(ctx)
 	db := r.db.WithContext(ctx).Table("tb_users").Create(user)
 	if db.Error != nil {
 		return ErrRecordAlreadyExist
@@ -40,9 +89,39 @@ func (r *UserRepositoryGorm) Create(ctx context.Context, user *domain.User) erro
 	return nil
 }
 
+// userUniqueError maps the unique violation of the users' table to the error of the taken column.
+func userUniqueError(pgErr *pgconn.PgError) error {
+	switch pgErr.ConstraintName {
+	case "tb_users_username":
+		return ErrUsernameAlreadyExist
+	case "tb_users_email":
+		return ErrEmailAlreadyExist
+	case "tb_users_external_id":
+		return ErrExternalIdAlreadyExist
+	}
+
+	return ErrRecordAlreadyExist
+}
+
+// GetUserByEmail finds the user by the email regardless of the case.
 func (r *UserRepositoryGorm) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	user := new(domain.User)
-	db := r.db.WithContext(ctx).Table("tb_users").Where("email = ?", email).Take(user)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("lower(email) = lower(?) AND date_deleted IS NULL", email).Take(user)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, db.Error
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername finds the user by the username regardless of the case.
+func (r *UserRepositoryGorm) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	user := new(domain.User)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("lower(username) = lower(?) AND date_deleted IS NULL", username).Take(user)
 	if db.Error != nil {
 		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrRecordNotFound
@@ -56,7 +135,7 @@ func (r *UserRepositoryGorm) GetUserByEmail(ctx context.Context, email string) (
 
 func (r *UserRepositoryGorm) GetUserById(ctx context.Context, id uint32) (*domain.User, error) {
 	user := new(domain.User)
-	db := r.db.WithContext(ctx).Table("tb_users").Where("id = ?", id).Take(user)
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND date_deleted IS NULL", id).Take(user)
 	if db.Error != nil {
 		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrRecordNotFound
@@ -67,3 +146,58 @@ func (r *UserRepositoryGorm) GetUserById(ctx context.Context, id uint32) (*domai
 
 	return user, nil
 }
+
+// Update the user's profile if the user's version wasn't changed since it was read, the version is incremented.
+// The email is changed by the provisioning client only, the users confirm their changes.
+func (r *UserRepositoryGorm) Update(ctx context.Context, user *domain.User) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND version = ?", user.Id, user.Version).Updates(map[string]interface{}{
+		"username":     user.Username,
+		"email":        user.Email,
+		"display_name": user.DisplayName,
+		"locale":       user.Locale,
+		"external_id":  user.ExternalId,
+		"version":      gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(db.Error, &pgErr) && pgErr.Code == pgErrCodeUniqueViolation {
+			return userUniqueError(pgErr)
+		}
+
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		var count int64
+		if err := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ?", user.Id).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrRecordNotFound
+		}
+
+		return ErrRecordVersionConflict
+	}
+
+	user.Version++
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the user's password hash, the version is incremented.
+func (r *UserRepositoryGorm) UpdatePasswordHash(ctx context.Context, id uint32, passwordHash []byte) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"version":       gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -62,11 +63,22 @@ func TestUser_Create(t *testing.T) {
 					mock.ExpectQuery(
 						regexp.QuoteMeta(sqlRequest)).
 						WithArgs(
+							domain.DefaultTenantId,
 							tt.args.user.Username,
 							tt.args.user.Email,
 							tt.args.user.PasswordHash,
 							tt.args.user.DateRegistration,
 							tt.args.user.DateLastOnline,
+							tt.args.user.DisplayName,
+							tt.args.user.Locale,
+							tt.args.user.ExternalId,
+							tt.args.user.Version,
+							tt.args.user.DateDeleted,
+							tt.args.user.DateSuspended,
+							tt.args.user.DateSuspendedUntil,
+							tt.args.user.SuspensionReason,
+							tt.args.user.FailedSignins,
+							tt.args.user.DateLockedUntil,
 							tt.args.user.Id,
 						).
 						WillReturnRows(sqlmock.NewRows([]string{"id"}).
@@ -103,11 +115,22 @@ func TestUser_Create(t *testing.T) {
 					mock.ExpectQuery(
 						regexp.QuoteMeta(sqlRequest)).
 						WithArgs(
+							domain.DefaultTenantId,
 							tt.args.user.Username,
 							tt.args.user.Email,
 							tt.args.user.PasswordHash,
 							tt.args.user.DateRegistration,
 							tt.args.user.DateLastOnline,
+							tt.args.user.DisplayName,
+							tt.args.user.Locale,
+							tt.args.user.ExternalId,
+							tt.args.user.Version,
+							tt.args.user.DateDeleted,
+							tt.args.user.DateSuspended,
+							tt.args.user.DateSuspendedUntil,
+							tt.args.user.SuspensionReason,
+							tt.args.user.FailedSignins,
+							tt.args.user.DateLockedUntil,
 							tt.args.user.Id,
 						).
 						WillReturnError(ErrRecordAlreadyExist)
@@ -167,3 +190,253 @@ func TestUser_Create(t *testing.T) {
 		})
 	}
 }
+
+type TestTableUpdate struct {
+	name            string
+	user            *domain.User
+	expectedQuery   func(mock sqlmock.Sqlmock, user *domain.User)
+	expectedErr     error
+	expectedVersion uint32
+}
+
+func TestUser_Update(t *testing.T) {
+	const sqlUpdate = `UPDATE "tb_users" SET`
+	const sqlCount = `SELECT count(*) FROM "tb_users"`
+
+	tests := []TestTableUpdate{
+		{
+			name: "Update user",
+			user: &domain.User{Id: 1, Username: "test", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WithArgs(user.DisplayName, user.Email, user.ExternalId, user.Locale, user.Username, domain.DefaultTenantId, user.Id, user.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedVersion: 2,
+		},
+		{
+			name: "Username already exist",
+			user: &domain.User{Id: 1, Username: "test", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WillReturnError(&pgconn.PgError{Code: pgErrCodeUniqueViolation, ConstraintName: "tb_users_username"})
+				mock.ExpectRollback()
+			},
+			expectedErr:     ErrUsernameAlreadyExist,
+			expectedVersion: 1,
+		},
+		{
+			name: "Email already exist",
+			user: &domain.User{Id: 1, Username: "test", Email: "test@mail.com", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WillReturnError(&pgconn.PgError{Code: pgErrCodeUniqueViolation, ConstraintName: "tb_users_email"})
+				mock.ExpectRollback()
+			},
+			expectedErr:     ErrEmailAlreadyExist,
+			expectedVersion: 1,
+		},
+		{
+			name: "External id already exist",
+			user: &domain.User{Id: 1, Username: "test", ExternalId: "ext-1", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WillReturnError(&pgconn.PgError{Code: pgErrCodeUniqueViolation, ConstraintName: "tb_users_external_id"})
+				mock.ExpectRollback()
+			},
+			expectedErr:     ErrExternalIdAlreadyExist,
+			expectedVersion: 1,
+		},
+		{
+			name: "User was changed",
+			user: &domain.User{Id: 1, Username: "test", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlCount)).
+					WithArgs(domain.DefaultTenantId, user.Id).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			expectedErr:     ErrRecordVersionConflict,
+			expectedVersion: 1,
+		},
+		{
+			name: "User not found",
+			user: &domain.User{Id: 1, Username: "test", Version: 1},
+			expectedQuery: func(mock sqlmock.Sqlmock, user *domain.User) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(sqlCount)).
+					WithArgs(domain.DefaultTenantId, user.Id).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			expectedErr:     ErrRecordNotFound,
+			expectedVersion: 1,
+		},
+	}
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Expected behavior.
+			tt.expectedQuery(mock, tt.user)
+
+			// Call test function.
+			r := NewUsersRepo(gormDB)
+			err = r.Update(context.Background(), tt.user)
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedVersion, tt.user.Version)
+
+			// We make sure that all expectations were met.
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUser_UpdatePasswordHash(t *testing.T) {
+	const sqlUpdate = `UPDATE "tb_users" SET`
+	passwordHash := []byte("hash")
+
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		expectedErr  error
+	}{
+		{
+			name:         "Update password hash",
+			rowsAffected: 1,
+		},
+		{
+			name:         "User not found",
+			rowsAffected: 0,
+			expectedErr:  ErrRecordNotFound,
+		},
+	}
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Expected behavior.
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+				WithArgs(passwordHash, domain.DefaultTenantId, 1).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			mock.ExpectCommit()
+
+			// Call test function.
+			r := NewUsersRepo(gormDB)
+			err = r.UpdatePasswordHash(context.Background(), 1, passwordHash)
+			assert.Equal(t, tt.expectedErr, err)
+
+			// We make sure that all expectations were met.
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUser_GetUserByUsername(t *testing.T) {
+	const sqlSelect = `SELECT * FROM "tb_users" WHERE tenant_id = $1 AND (lower(username) = lower($2) AND date_deleted IS NULL)`
+
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectedErr error
+	}{
+		{
+			name: "Username in other case",
+			rows: sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "alice"),
+		},
+		{
+			name:        "User not found",
+			rows:        sqlmock.NewRows([]string{"id", "username"}),
+			expectedErr: ErrRecordNotFound,
+		},
+	}
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Expected behavior.
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(domain.DefaultTenantId, "Alice").
+				WillReturnRows(tt.rows)
+
+			// Call test function.
+			r := NewUsersRepo(gormDB)
+			user, err := r.GetUserByUsername(context.Background(), "Alice")
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, uint32(1), user.Id)
+			}
+
+			// We make sure that all expectations were met.
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"service-account/internal/domain"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List returns the page of the users matching the query and the total number of the matching users.
+// The pages are read by the keyset pagination, the total doesn't depend on the page.
+func (r *UserRepositoryGorm) List(ctx context.Context, query *domain.UserListQuery) ([]domain.User, int64, error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = domain.UserSortId
+	}
+
+	// The sort field is put into the query, only the known fields are accepted.
+	if _, ok := userSortValue(&domain.User{}, sort); !ok {
+		return nil, 0, fmt.Errorf("unknown users sort field %q", sort)
+	}
+
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users")
+	db = filterUsers(db, query)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, cmp := "ASC", ">"
+	if query.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if query.After != nil {
+		if sort == domain.UserSortId {
+			db = db.Where(fmt.Sprintf("id %s ?", cmp), query.After.Id)
+		} else {
+			after, _ := userSortValue(query.After, sort)
+			db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", sort, cmp), after, query.After.Id)
+		}
+	}
+
+	if sort != domain.UserSortId {
+		db = db.Order(fmt.Sprintf("%s %s", sort, order))
+	}
+
+	if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+
+	var users []domain.User
+	if err := db.Order(fmt.Sprintf("id %s", order)).Limit(query.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func filterUsers(db *gorm.DB, query *domain.UserListQuery) *gorm.DB {
+	if query.Username != "" {
+		db = db.Where("lower(username) = lower(?)", query.Username)
+	}
+
+	if query.Email != "" {
+		db = db.Where("lower(email) = lower(?)", query.Email)
+	}
+
+	if query.ExternalId != "" {
+		db = db.Where("external_id = ?", query.ExternalId)
+	}
+
+	if query.EmailPrefix != "" {
+		db = db.Where("email LIKE ?", likePrefix(query.EmailPrefix))
+	}
+
+	if query.UsernamePrefix != "" {
+		db = db.Where("username LIKE ?", likePrefix(query.UsernamePrefix))
+	}
+
+	if query.RegisteredFrom != nil {
+		db = db.Where("date_registration >= ?", *query.RegisteredFrom)
+	}
+
+	if query.RegisteredTo != nil {
+		db = db.Where("date_registration < ?", *query.RegisteredTo)
+	}
+
+	if query.LastOnlineBefore != nil {
+		db = db.Where("date_last_online < ?", *query.LastOnlineBefore)
+	}
+
+	if query.Existing {
+		db = db.Where("date_deleted IS NULL")
+	}
+
+	switch query.Status {
+	case domain.UserStatusActive:
+		db = db.Where("date_deleted IS NULL AND (date_suspended IS NULL OR date_suspended_until <= now())")
+	case domain.UserStatusSuspended:
+		db = db.Where("date_deleted IS NULL AND date_suspended IS NOT NULL AND (date_suspended_until IS NULL OR date_suspended_until > now())")
+	case domain.UserStatusDeleted:
+		db = db.Where("date_deleted IS NOT NULL")
+	}
+
+	return db
+}
+
+// userSortValue returns the user's value of the sort field, false if the field is unknown.
+func userSortValue(user *domain.User, sort string) (interface{}, bool) {
+	switch sort {
+	case domain.UserSortId:
+		return user.Id, true
+	case domain.UserSortUsername:
+		return user.Username, true
+	case domain.UserSortEmail:
+		return user.Email, true
+	case domain.UserSortDateRegistration:
+		return user.DateRegistration, true
+	case domain.UserSortDateLastOnline:
+		return user.DateLastOnline, true
+	default:
+		return nil, false
+	}
+}
+
+// likePrefix escapes the LIKE wildcards of the prefix.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
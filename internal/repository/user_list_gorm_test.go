@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"service-account/internal/domain"
+	"testing"
+)
+
+func TestUser_List(t *testing.T) {
+	const sqlCount = `SELECT count(*) FROM "tb_users" WHERE tenant_id = $1 AND email LIKE $2 AND (date_deleted IS NULL AND (date_suspended IS NULL OR date_suspended_until <= now()))`
+	const sqlSelect = `SELECT * FROM "tb_users" WHERE tenant_id = $1 AND email LIKE $2 AND (date_deleted IS NULL AND (date_suspended IS NULL OR date_suspended_until <= now())) AND (username, id) < ($3, $4) ORDER BY username DESC,id DESC LIMIT 3`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("List users page", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCount)).
+			WithArgs("default", `a\_b%`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("default", `a\_b%`, "test", 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(2, "tes").AddRow(9, "te"))
+
+		users, total, err := r.List(ctx, &domain.UserListQuery{
+			EmailPrefix: "a_b",
+			Status:      domain.UserStatusActive,
+			Sort:        domain.UserSortUsername,
+			Desc:        true,
+			Limit:       3,
+			After:       &domain.User{Id: 5, Username: "test"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), total)
+		assert.Equal(t, []domain.User{{Id: 2, Username: "tes"}, {Id: 9, Username: "te"}}, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List users by index", func(t *testing.T) {
+		const sqlCount = `SELECT count(*) FROM "tb_users" WHERE tenant_id = $1 AND lower(username) = lower($2) AND date_deleted IS NULL`
+		const sqlSelect = `SELECT * FROM "tb_users" WHERE tenant_id = $1 AND lower(username) = lower($2) AND date_deleted IS NULL ORDER BY id ASC LIMIT 2 OFFSET 4`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sqlCount)).
+			WithArgs("default", "Alice").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+			WithArgs("default", "Alice").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}))
+
+		users, total, err := r.List(ctx, &domain.UserListQuery{
+			Username: "Alice",
+			Existing: true,
+			Limit:    2,
+			Offset:   4,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, 0, len(users))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown sort field", func(t *testing.T) {
+		_, _, err := r.List(ctx, &domain.UserListQuery{Sort: "password_hash", Limit: 3})
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
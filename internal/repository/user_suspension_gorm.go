@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Suspend suspends the user until the date or indefinitely if it's nil, the previous suspension is replaced.
+func (r *UserRepositoryGorm) Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND date_deleted IS NULL", id).Updates(map[string]interface{}{
+		"date_suspended":       time.Now(),
+		"date_suspended_until": until,
+		"suspension_reason":    reason,
+		"version":              gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Unsuspend lifts the user's suspension.
+func (r *UserRepositoryGorm) Unsuspend(ctx context.Context, id uint32) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND date_deleted IS NULL", id).Updates(map[string]interface{}{
+		"date_suspended":       nil,
+		"date_suspended_until": nil,
+		"suspension_reason":    "",
+		"version":              gorm.Expr("version + 1"),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RecordFailedSignin counts the user's failed sign-in, the user is locked out until lockUntil
+// when the count reaches the threshold and the count starts over.
+func (r *UserRepositoryGorm) RecordFailedSignin(ctx context.Context, id uint32, threshold int, lockUntil time.Time) error {
+	// The SET expressions read the row's values before the update.
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_signins":    gorm.Expr("CASE WHEN failed_signins + 1 >= ? THEN 0 ELSE failed_signins + 1 END", threshold),
+		"date_locked_until": gorm.Expr("CASE WHEN failed_signins + 1 >= ? THEN ? ELSE date_locked_until END", threshold, lockUntil),
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Unlock lifts the user's lockout and resets the failed sign-ins count.
+func (r *UserRepositoryGorm) Unlock(ctx context.Context, id uint32) error {
+	db := tenantTable(ctx, r.db.WithContext(ctx), "tb_users").Where("id = ? AND date_deleted IS NULL", id).Updates(map[string]interface{}{
+		"failed_signins":    0,
+		"date_locked_until": nil,
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
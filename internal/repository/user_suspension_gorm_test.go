@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUser_SuspensionAndLockout(t *testing.T) {
+	const sqlUpdate = `UPDATE "tb_users" SET`
+
+	// Init mockDB mock.
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	gormDB, err := gorm.Open(
+		postgres.New(
+			postgres.Config{
+				Conn: mockDB,
+			}),
+		&gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a gorm database connection", err)
+		return
+	}
+
+	r := NewUsersRepo(gormDB)
+	ctx := context.Background()
+
+	t.Run("Suspend user", func(t *testing.T) {
+		until := time.Now().Add(time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WithArgs(sqlmock.AnyArg(), &until, "spam", "default", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.Suspend(ctx, 1, "spam", &until))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unsuspend deleted user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.Equal(t, ErrRecordNotFound, r.Unsuspend(ctx, 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Record failed sign-in", func(t *testing.T) {
+		lockUntil := time.Now().Add(time.Minute)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tb_users" SET "date_locked_until"=CASE WHEN failed_signins + 1 >= $1 THEN $2 ELSE date_locked_until END,"failed_signins"=CASE WHEN failed_signins + 1 >= $3 THEN 0 ELSE failed_signins + 1 END WHERE tenant_id = $4 AND id = $5`)).
+			WithArgs(5, lockUntil, 5, "default", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.RecordFailedSignin(ctx, 1, 5, lockUntil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unlock user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
+			WithArgs(nil, 0, "default", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.Unlock(ctx, 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,480 @@
+package service
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/url"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/pkg/convert_to"
+	"strconv"
+	"time"
+)
+
+// The pages of the links sent to the user.
+const (
+	PathEmailChangeConfirm = "/account/email/confirm"
+	PathEmailChangeRevert  = "/account/email/revert"
+	PathAccountRestore     = "/account/restore"
+	// The account page the provider's callback returns to after linking the identity.
+	PathAccountIdentities = "/account/identities"
+)
+
+// ErrNotificationFailed the account was changed but the user wasn't notified.
+var ErrNotificationFailed = errors.New("Notification failed")
+
+type ChangePasswordInput struct {
+	CurrentPassword string
+	NewPassword     string
+	// RevokeOtherSessions signs the user out everywhere except the current browser session.
+	RevokeOtherSessions bool
+	// SessionId is the current browser session, it's empty for the API clients.
+	SessionId string
+	// Code is the one-time code sent by SendVerificationCode, the user without the password sets one with it.
+	Code string
+}
+
+// DeleteAccountInput the user without the password confirms the deletion with the one-time code instead.
+type DeleteAccountInput struct {
+	Password string
+	// Code is the one-time code sent by SendVerificationCode.
+	Code string
+}
+
+// EmailChangeInput the user without the password confirms the change with the one-time code instead.
+type EmailChangeInput struct {
+	Password string
+	NewEmail string
+	// Code is the one-time code sent by SendVerificationCode.
+	Code string
+}
+
+// AccountService handles the account's security changes, it's shared by the HTML and JSON handlers.
+type AccountService struct {
+	config   *config.Config
+	oa2      OAuth2
+	user     User
+	rbac     RBAC
+	sessions Sessions
+	notifier Notifier
+	audit    Audit
+	devices  Devices
+	identity Identity
+	groups   Groups
+	risk     Risk
+}
+
+func NewAccountService(config *config.Config, oa2 OAuth2, userService User, rbacService RBAC, sessionService Sessions, notifier Notifier, auditService Audit, deviceService Devices, identityService Identity, groupService Groups, riskService Risk) *AccountService {
+	return &AccountService{
+		config:   config,
+		oa2:      oa2,
+		user:     userService,
+		rbac:     rbacService,
+		sessions: sessionService,
+		notifier: notifier,
+		audit:    auditService,
+		devices:  deviceService,
+		identity: identityService,
+		groups:   groupService,
+		risk:     riskService,
+	}
+}
+
+// SignUp registers the user, the registration is audited.
+func (s *AccountService) SignUp(ctx context.Context, input *UserSignUpInput) error {
+	user, err := s.user.SignUp(ctx, input)
+	if err != nil {
+		if auditErr := recordAudit(ctx, s.audit, &domain.AuditEvent{
+			Action:  domain.AuditActionSignup,
+			Outcome: domain.AuditOutcomeFailure,
+			Reason:  err.Error(),
+			Data:    map[string]string{"email": input.Email},
+		}); auditErr != nil {
+			return auditErr
+		}
+
+		return err
+	}
+
+	return recordAudit(ctx, s.audit, &domain.AuditEvent{
+		Action:  domain.AuditActionSignup,
+		ActorId: &user.Id,
+		UserId:  &user.Id,
+	})
+}
+
+// ChangePassword changes the user's password and notifies the user. The other sessions are ended on request:
+// the Hydra login sessions, the tokens issued to the other clients and the other browser sessions.
+// The password is changed even if ErrNotificationFailed is returned.
+func (s *AccountService) ChangePassword(ctx context.Context, userId uint32, input *ChangePasswordInput) error {
+	var user *domain.User
+	verified, err := s.checkVerificationCode(ctx, userId, input.Code)
+	if err == nil {
+		user, err = s.user.ChangePassword(ctx, userId, &UserChangePasswordInput{
+			CurrentPassword: input.CurrentPassword,
+			NewPassword:     input.NewPassword,
+			Verified:        verified,
+		})
+	}
+
+	event := &domain.AuditEvent{
+		Action:  domain.AuditActionPasswordChanged,
+		ActorId: &userId,
+		UserId:  &userId,
+	}
+	if err != nil {
+		event.Outcome = domain.AuditOutcomeFailure
+		event.Reason = err.Error()
+	} else {
+		event.Data = map[string]string{"sessions_revoked": strconv.FormatBool(input.RevokeOtherSessions)}
+	}
+
+	if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+		return auditErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if input.RevokeOtherSessions {
+		if err = s.revokeOtherSessions(ctx, convert_to.ToString(userId), input.SessionId); err != nil {
+			return err
+		}
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationPasswordChanged,
+		UserId: user.Id,
+		Email:  user.Email,
+		Data: map[string]string{
+			"sessions_revoked": strconv.FormatBool(input.RevokeOtherSessions),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// RequestEmailChange sends the confirmation link to the new email, the email is changed when the link is followed.
+func (s *AccountService) RequestEmailChange(ctx context.Context, userId uint32, input *EmailChangeInput) error {
+	verified, err := s.checkVerificationCode(ctx, userId, input.Code)
+	if err != nil {
+		return err
+	}
+
+	emailChange, token, err := s.user.RequestEmailChange(ctx, userId, &UserEmailChangeInput{
+		Password: input.Password,
+		NewEmail: input.NewEmail,
+		Verified: verified,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationEmailChangeConfirm,
+		UserId: userId,
+		Email:  emailChange.NewEmail,
+		Data: map[string]string{
+			"url":          s.linkURL(ctx, PathEmailChangeConfirm, token),
+			"date_expires": emailChange.DateExpires.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange changes the email and sends the revert link to the old email.
+// The email is changed even if ErrNotificationFailed is returned.
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, token string) error {
+	emailChange, revertToken, err := s.user.ConfirmEmailChange(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationEmailChanged,
+		UserId: emailChange.UserId,
+		Email:  emailChange.OldEmail,
+		Data: map[string]string{
+			"new_email":    emailChange.NewEmail,
+			"url":          s.linkURL(ctx, PathEmailChangeRevert, revertToken),
+			"date_expires": emailChange.DateRevertExpires.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// RevertEmailChange changes the email back to the old one. The change may be the account's takeover,
+// so all the user's sessions are ended.
+// The email is reverted even if ErrNotificationFailed is returned.
+func (s *AccountService) RevertEmailChange(ctx context.Context, revertToken string) error {
+	emailChange, err := s.user.RevertEmailChange(ctx, revertToken)
+	if err != nil {
+		return err
+	}
+
+	if err = s.revokeOtherSessions(ctx, convert_to.ToString(emailChange.UserId), ""); err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationEmailChangeReverted,
+		UserId: emailChange.UserId,
+		Email:  emailChange.OldEmail,
+		Data: map[string]string{
+			"new_email": emailChange.NewEmail,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// DeleteAccount deletes the account after re-verifying the password or the one-time code and ends all the user's sessions,
+// returns the date the account is erased at. The restore link is sent to the user's email.
+// The account is deleted even if ErrNotificationFailed is returned.
+func (s *AccountService) DeleteAccount(ctx context.Context, userId uint32, input *DeleteAccountInput) (time.Time, error) {
+	verified, err := s.checkVerificationCode(ctx, userId, input.Code)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	user, restoreToken, err := s.user.Delete(ctx, userId, &UserDeleteInput{
+		Password: input.Password,
+		Verified: verified,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	datePurge := time.Now().Add(s.config.Account.DeletionGracePeriod)
+	if err = signOutEverywhere(ctx, s.oa2, s.sessions, convert_to.ToString(userId)); err != nil {
+		return time.Time{}, err
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationAccountDeleted,
+		UserId: user.Id,
+		Email:  user.Email,
+		Data: map[string]string{
+			"url":        s.linkURL(ctx, PathAccountRestore, restoreToken),
+			"date_purge": datePurge.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return datePurge, errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return datePurge, nil
+}
+
+// RestoreAccount restores the deleted account within the grace period, the user signs in again.
+// The account is restored even if ErrNotificationFailed is returned.
+func (s *AccountService) RestoreAccount(ctx context.Context, restoreToken string) error {
+	user, err := s.user.Restore(ctx, restoreToken)
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationAccountRestored,
+		UserId: user.Id,
+		Email:  user.Email,
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// Export collects everything the service holds about the user.
+func (s *AccountService) Export(ctx context.Context, userId uint32) (*domain.AccountExport, error) {
+	user, err := s.user.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &domain.AccountExport{
+		DateExported: time.Now(),
+		Profile: domain.AccountExportProfile{
+			Id:               user.Id,
+			Username:         user.Username,
+			Email:            user.Email,
+			DisplayName:      user.DisplayName,
+			Locale:           user.Locale,
+			DateRegistration: user.DateRegistration,
+			DateLastOnline:   user.DateLastOnline,
+		},
+		Sessions:     []domain.AccountExportSession{},
+		EmailChanges: []domain.AccountExportEmailChange{},
+		Devices:      []domain.AccountExportDevice{},
+		Groups:       []domain.AccountExportGroup{},
+		AuditEvents:  []domain.AuditEvent{},
+	}
+
+	if export.Roles, err = s.rbac.GetUserRoles(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	if export.Roles == nil {
+		export.Roles = []string{}
+	}
+
+	subject := convert_to.ToString(userId)
+	sessions, err := s.sessions.ListBySubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, domain.AccountExportSession{
+			DateCreated:  session.DateCreated,
+			DateLastSeen: session.DateLastSeen,
+			DateExpires:  session.DateExpires,
+		})
+	}
+
+	if export.Consents, err = s.oa2.ListConsentGrants(ctx, subject); err != nil {
+		return nil, err
+	}
+
+	emailChanges, err := s.user.ListEmailChanges(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, emailChange := range emailChanges {
+		export.EmailChanges = append(export.EmailChanges, domain.AccountExportEmailChange{
+			OldEmail:      emailChange.OldEmail,
+			NewEmail:      emailChange.NewEmail,
+			DateCreated:   emailChange.DateCreated,
+			DateConfirmed: emailChange.DateConfirmed,
+			DateReverted:  emailChange.DateReverted,
+		})
+	}
+
+	devices, err := s.devices.ListDevices(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		export.Devices = append(export.Devices, domain.AccountExportDevice{
+			UserAgent:     device.UserAgent,
+			Ip:            device.Ip,
+			DateFirstSeen: device.DateFirstSeen,
+			DateLastSeen:  device.DateLastSeen,
+		})
+	}
+
+	loginMethods, err := s.identity.LoginMethods(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Identities = loginMethods.Identities
+	if export.Identities == nil {
+		export.Identities = []domain.Identity{}
+	}
+
+	groups, err := s.groups.ListUserGroups(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		export.Groups = append(export.Groups, domain.AccountExportGroup{
+			Organization: group.Organization,
+			DisplayName:  group.DisplayName,
+			Role:         group.Role,
+		})
+	}
+
+	// The whole audit trail of the user is read page by page.
+	auditInput := &AuditListInput{SubjectId: &userId, Limit: auditListMaxLimit}
+	for {
+		page, err := s.audit.List(ctx, auditInput)
+		if err != nil {
+			return nil, err
+		}
+
+		export.AuditEvents = append(export.AuditEvents, page.Events...)
+		if page.NextCursor == "" {
+			break
+		}
+
+		auditInput.Cursor = page.NextCursor
+	}
+
+	return export, nil
+}
+
+// SendVerificationCode sends the one-time code to the user's email, the user without the password confirms
+// the account's changes with it.
+func (s *AccountService) SendVerificationCode(ctx context.Context, userId uint32) error {
+	user, err := s.user.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return s.risk.SendVerificationCode(ctx, user)
+}
+
+// checkVerificationCode checks the one-time code confirming the change, it's false if the code isn't given.
+func (s *AccountService) checkVerificationCode(ctx context.Context, userId uint32, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+
+	if err := s.risk.CheckVerificationCode(ctx, userId, code); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// linkURL is the public URL of the page of the context's tenant with the link's token.
+func (s *AccountService) linkURL(ctx context.Context, path string, token string) string {
+	return tenantURL(ctx, &s.config.OAuth2) + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *AccountService) revokeOtherSessions(ctx context.Context, subject string, sessionId string) error {
+	if err := s.oa2.RevokeLoginSessions(ctx, subject); err != nil {
+		return err
+	}
+
+	// The tokens of the service's own client are kept in the browser sessions, they're ended below.
+	if err := s.oa2.RevokeConsentSessions(ctx, subject, true); err != nil {
+		return err
+	}
+
+	return s.sessions.DeleteBySubject(ctx, subject, sessionId)
+}
+
+// signOutEverywhere ends all the subject's sessions: the Hydra login sessions, the consents with the tokens
+// of all the clients including the service's own one and the browser sessions.
+func signOutEverywhere(ctx context.Context, oa2 OAuth2, sessions Sessions, subject string) error {
+	if err := oa2.RevokeLoginSessions(ctx, subject); err != nil {
+		return err
+	}
+
+	if err := oa2.RevokeConsentSessions(ctx, subject, false); err != nil {
+		return err
+	}
+
+	return sessions.DeleteBySubject(ctx, subject, "")
+}
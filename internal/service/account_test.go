@@ -0,0 +1,575 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+type mockBehaviorUserRepo func(mockRepo *mock_service.MockUserRepository)
+
+type TestTableUserChangePassword struct {
+	name                 string
+	input                *service.UserChangePasswordInput
+	mockBehaviorUserRepo mockBehaviorUserRepo
+	expectedErr          error
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	// The service changes the user, each case gets a copy.
+	newUser := func() *domain.User {
+		return &domain.User{Id: 1, Username: "alice", Email: "alice.smith@mail.com", PasswordHash: []byte("current-password"), Version: 1}
+	}
+
+	testTable := []TestTableUserChangePassword{
+		{
+			name:  "OK",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "new-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+				mockRepo.EXPECT().UpdatePasswordHash(gomock.Any(), uint32(1), []byte("new-password")).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, current password is incorrect",
+			input: &service.UserChangePasswordInput{CurrentPassword: "wrong-password", NewPassword: "new-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+				mockRepo.EXPECT().RecordFailedSignin(gomock.Any(), uint32(1), 5, gomock.Any()).Return(nil)
+			},
+			expectedErr: service.ErrPasswordIncorrect,
+		},
+		{
+			name:  "BAD, locked user's current password isn't checked",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "new-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				user := newUser()
+				lockedUntil := time.Now().Add(time.Minute)
+				user.DateLockedUntil = &lockedUntil
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(user, nil)
+			},
+			expectedErr: service.ErrUserLocked,
+		},
+		{
+			name:  "OK, failed sign-ins are reset",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "new-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				user := newUser()
+				user.FailedSignins = 2
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(user, nil)
+				mockRepo.EXPECT().UpdatePasswordHash(gomock.Any(), uint32(1), []byte("new-password")).Return(nil)
+				mockRepo.EXPECT().Unlock(gomock.Any(), uint32(1)).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, new password is the current one",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "current-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+			},
+			expectedErr: service.ErrPasswordPolicy,
+		},
+		{
+			name:  "BAD, new password is too short",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "short"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+			},
+			expectedErr: service.ErrPasswordPolicy,
+		},
+		{
+			name:  "BAD, new password contains the username",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "my-Alice-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+			},
+			expectedErr: service.ErrPasswordPolicy,
+		},
+		{
+			name:  "BAD, new password contains the email",
+			input: &service.UserChangePasswordInput{CurrentPassword: "current-password", NewPassword: "alice.smith1234"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(newUser(), nil)
+			},
+			expectedErr: service.ErrPasswordPolicy,
+		},
+		{
+			name:  "OK, user without password sets one verified by the code",
+			input: &service.UserChangePasswordInput{NewPassword: "new-password", Verified: true},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice", PasswordHash: []byte{}}, nil)
+				mockRepo.EXPECT().UpdatePasswordHash(gomock.Any(), uint32(1), []byte("new-password")).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, user without password isn't verified",
+			input: &service.UserChangePasswordInput{NewPassword: "new-password"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice", PasswordHash: []byte{}}, nil)
+			},
+			expectedErr: service.ErrVerificationRequired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			// The hash is the password itself.
+			mockHasher := mock_service.NewMockHasher(ctrl)
+			mockHasher.EXPECT().Hash(gomock.Any(), gomock.Any()).DoAndReturn(func(password string, salt []byte) []byte {
+				return []byte(password)
+			}).AnyTimes()
+			serviceConfig := &config.Config{
+				Password: config.PasswordConfig{MinLength: 8},
+				Account:  config.AccountConfig{LockoutThreshold: 5, LockoutDuration: time.Minute},
+			}
+			userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, nil, serviceConfig)
+
+			//// Act
+			_, err := userService.ChangePassword(context.Background(), 1, testCase.input)
+
+			//// Assert
+			assert.Equal(t, errors.Is(err, testCase.expectedErr), true)
+		})
+	}
+}
+
+func TestUserService_Delete(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+
+	testTable := []struct {
+		name        string
+		user        *domain.User
+		input       *service.UserDeleteInput
+		expectedErr error
+	}{
+		{
+			name:  "OK",
+			user:  &domain.User{Id: 1, PasswordHash: []byte("password")},
+			input: &service.UserDeleteInput{Password: "password"},
+		},
+		{
+			name:        "BAD, password is incorrect",
+			user:        &domain.User{Id: 1, PasswordHash: []byte("password")},
+			input:       &service.UserDeleteInput{Password: "wrong"},
+			expectedErr: service.ErrPasswordIncorrect,
+		},
+		{
+			name:        "BAD, locked user's password isn't checked",
+			user:        &domain.User{Id: 1, PasswordHash: []byte("password"), DateLockedUntil: &lockedUntil},
+			input:       &service.UserDeleteInput{Password: "password"},
+			expectedErr: service.ErrUserLocked,
+		},
+		{
+			name:  "OK, user without password verified by the code",
+			user:  &domain.User{Id: 1, PasswordHash: []byte{}},
+			input: &service.UserDeleteInput{Verified: true},
+		},
+		{
+			name:        "BAD, user without password isn't verified",
+			user:        &domain.User{Id: 1, PasswordHash: []byte{}},
+			input:       &service.UserDeleteInput{Password: ""},
+			expectedErr: service.ErrVerificationRequired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			mockUserRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(testCase.user, nil)
+			if testCase.expectedErr == nil {
+				mockUserRepo.EXPECT().SoftDelete(gomock.Any(), uint32(1), gomock.Any()).Return(nil)
+			}
+			// The incorrect password is counted as the failed sign-in.
+			if testCase.expectedErr == service.ErrPasswordIncorrect {
+				mockUserRepo.EXPECT().RecordFailedSignin(gomock.Any(), uint32(1), 5, gomock.Any()).Return(nil)
+			}
+			// The hash is the password itself.
+			mockHasher := mock_service.NewMockHasher(ctrl)
+			mockHasher.EXPECT().Hash(gomock.Any(), gomock.Any()).DoAndReturn(func(password string, salt []byte) []byte {
+				return []byte(password)
+			}).AnyTimes()
+			serviceConfig := &config.Config{Account: config.AccountConfig{LockoutThreshold: 5, LockoutDuration: time.Minute}}
+			userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, nil, serviceConfig)
+
+			//// Act
+			_, restoreToken, err := userService.Delete(context.Background(), 1, testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, restoreToken != "", testCase.expectedErr == nil)
+		})
+	}
+}
+
+type TestTableAccountChangePassword struct {
+	name                 string
+	input                *service.ChangePasswordInput
+	mockBehaviorOAuth2   func(mockOAuth2 *mock_service.MockOAuth2)
+	mockBehaviorUser     func(mockUser *mock_service.MockUser)
+	mockBehaviorSessions func(mockSessions *mock_service.MockSessions)
+	mockBehaviorNotifier func(mockNotifier *mock_service.MockNotifier)
+	mockBehaviorRisk     func(mockRisk *mock_service.MockRisk)
+	expectedErr          error
+}
+
+func TestAccountService_ChangePassword(t *testing.T) {
+	user := &domain.User{Id: 1, Email: "alice@mail.com"}
+
+	testTable := []TestTableAccountChangePassword{
+		{
+			name:  "OK, revoke other sessions",
+			input: &service.ChangePasswordInput{CurrentPassword: "current", NewPassword: "new-password", RevokeOtherSessions: true, SessionId: "current-session"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().RevokeLoginSessions(gomock.Any(), "1").Return(nil)
+				mockOAuth2.EXPECT().RevokeConsentSessions(gomock.Any(), "1", true).Return(nil)
+			},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().ChangePassword(gomock.Any(), uint32(1), gomock.Any()).Return(user, nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().DeleteBySubject(gomock.Any(), "1", "current-session").Return(nil)
+			},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:               "OK, keep other sessions",
+			input:              &service.ChangePasswordInput{CurrentPassword: "current", NewPassword: "new-password"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().ChangePassword(gomock.Any(), uint32(1), gomock.Any()).Return(user, nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:               "BAD, current password is incorrect",
+			input:              &service.ChangePasswordInput{CurrentPassword: "wrong", NewPassword: "new-password", RevokeOtherSessions: true},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().ChangePassword(gomock.Any(), uint32(1), gomock.Any()).Return(nil, service.ErrPasswordIncorrect)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {},
+			expectedErr:          service.ErrPasswordIncorrect,
+		},
+		{
+			name:               "BAD, notification failed",
+			input:              &service.ChangePasswordInput{CurrentPassword: "current", NewPassword: "new-password"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().ChangePassword(gomock.Any(), uint32(1), gomock.Any()).Return(user, nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(errors.New("Test error"))
+			},
+			expectedErr: service.ErrNotificationFailed,
+		},
+		{
+			name:               "OK, set password verified by the code",
+			input:              &service.ChangePasswordInput{NewPassword: "new-password", Code: "123456"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().ChangePassword(gomock.Any(), uint32(1), &service.UserChangePasswordInput{NewPassword: "new-password", Verified: true}).Return(user, nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			mockBehaviorRisk: func(mockRisk *mock_service.MockRisk) {
+				mockRisk.EXPECT().CheckVerificationCode(gomock.Any(), uint32(1), "123456").Return(nil)
+			},
+		},
+		{
+			name:                 "BAD, code is incorrect",
+			input:                &service.ChangePasswordInput{NewPassword: "new-password", Code: "000000"},
+			mockBehaviorOAuth2:   func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser:     func(mockUser *mock_service.MockUser) {},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {},
+			mockBehaviorRisk: func(mockRisk *mock_service.MockRisk) {
+				mockRisk.EXPECT().CheckVerificationCode(gomock.Any(), uint32(1), "000000").Return(service.ErrStepUpCodeIncorrect)
+			},
+			expectedErr: service.ErrStepUpCodeIncorrect,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockUser := mock_service.NewMockUser(ctrl)
+			testCase.mockBehaviorUser(mockUser)
+			mockSessions := mock_service.NewMockSessions(ctrl)
+			testCase.mockBehaviorSessions(mockSessions)
+			mockNotifier := mock_service.NewMockNotifier(ctrl)
+			testCase.mockBehaviorNotifier(mockNotifier)
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			if testCase.mockBehaviorRisk != nil {
+				testCase.mockBehaviorRisk(mockRisk)
+			}
+			// Both the changes and the failures are audited.
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+			account := service.NewAccountService(&config.Config{}, mockOAuth2, mockUser, nil, mockSessions, mockNotifier, mockAudit, nil, nil, nil, mockRisk)
+
+			//// Act
+			err := account.ChangePassword(context.Background(), 1, testCase.input)
+
+			//// Assert
+			assert.Equal(t, errors.Is(err, testCase.expectedErr), true)
+		})
+	}
+}
+
+func TestAccountService_EmailChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+	mockUser := mock_service.NewMockUser(ctrl)
+	mockSessions := mock_service.NewMockSessions(ctrl)
+	mockNotifier := mock_service.NewMockNotifier(ctrl)
+	serviceConfig := &config.Config{OAuth2: config.OAuth2Config{RedirectURL: "https://account.localhost"}}
+	account := service.NewAccountService(serviceConfig, mockOAuth2, mockUser, nil, mockSessions, mockNotifier, nil, nil, nil, nil, nil)
+
+	dateExpires := time.Now()
+	emailChange := &domain.EmailChange{UserId: 1, OldEmail: "old@mail.com", NewEmail: "new@mail.com", DateExpires: dateExpires, DateRevertExpires: &dateExpires}
+	var notifications []*domain.Notification
+	mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification *domain.Notification) error {
+		notifications = append(notifications, notification)
+		return nil
+	}).Times(3)
+
+	mockUser.EXPECT().RequestEmailChange(gomock.Any(), uint32(1), gomock.Any()).Return(emailChange, "confirm-token", nil)
+	mockUser.EXPECT().ConfirmEmailChange(gomock.Any(), "confirm-token").Return(emailChange, "revert-token", nil)
+	mockUser.EXPECT().RevertEmailChange(gomock.Any(), "revert-token").Return(emailChange, nil)
+	// The revert ends all the user's sessions.
+	mockOAuth2.EXPECT().RevokeLoginSessions(gomock.Any(), "1").Return(nil)
+	mockOAuth2.EXPECT().RevokeConsentSessions(gomock.Any(), "1", true).Return(nil)
+	mockSessions.EXPECT().DeleteBySubject(gomock.Any(), "1", "").Return(nil)
+
+	//// Act
+	errRequest := account.RequestEmailChange(context.Background(), 1, &service.EmailChangeInput{Password: "password", NewEmail: "new@mail.com"})
+	errConfirm := account.ConfirmEmailChange(context.Background(), "confirm-token")
+	errRevert := account.RevertEmailChange(context.Background(), "revert-token")
+
+	//// Assert
+	assert.Equal(t, errRequest, nil)
+	assert.Equal(t, errConfirm, nil)
+	assert.Equal(t, errRevert, nil)
+	// The new email confirms the change.
+	assert.Equal(t, notifications[0].Type, domain.NotificationEmailChangeConfirm)
+	assert.Equal(t, notifications[0].Email, "new@mail.com")
+	assert.Equal(t, notifications[0].Data["url"], "https://account.localhost"+service.PathEmailChangeConfirm+"?token=confirm-token")
+	// The old email can revert the change.
+	assert.Equal(t, notifications[1].Type, domain.NotificationEmailChanged)
+	assert.Equal(t, notifications[1].Email, "old@mail.com")
+	assert.Equal(t, notifications[1].Data["url"], "https://account.localhost"+service.PathEmailChangeRevert+"?token=revert-token")
+	assert.Equal(t, notifications[2].Type, domain.NotificationEmailChangeReverted)
+	assert.Equal(t, notifications[2].Email, "old@mail.com")
+}
+
+func TestUserService_RequestEmailChange(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		input                *service.UserEmailChangeInput
+		mockBehaviorUserRepo mockBehaviorUserRepo
+		expectedErr          error
+	}{
+		{
+			name:  "OK",
+			input: &service.UserEmailChangeInput{Password: "password", NewEmail: "new@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte("password")}, nil)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "new@mail.com").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().CreateEmailChange(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, password is incorrect",
+			input: &service.UserEmailChangeInput{Password: "wrong", NewEmail: "new@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte("password")}, nil)
+				mockRepo.EXPECT().RecordFailedSignin(gomock.Any(), uint32(1), 5, gomock.Any()).Return(nil)
+			},
+			expectedErr: service.ErrPasswordIncorrect,
+		},
+		{
+			name:  "BAD, locked user's password isn't checked",
+			input: &service.UserEmailChangeInput{Password: "password", NewEmail: "new@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				lockedUntil := time.Now().Add(time.Minute)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte("password"), DateLockedUntil: &lockedUntil}, nil)
+			},
+			expectedErr: service.ErrUserLocked,
+		},
+		{
+			name:  "OK, user without password verified by the code",
+			input: &service.UserEmailChangeInput{NewEmail: "new@mail.com", Verified: true},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte{}}, nil)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "new@mail.com").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().CreateEmailChange(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, user without password isn't verified",
+			input: &service.UserEmailChangeInput{NewEmail: "new@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte{}}, nil)
+			},
+			expectedErr: service.ErrVerificationRequired,
+		},
+		{
+			name:  "BAD, email is taken",
+			input: &service.UserEmailChangeInput{Password: "password", NewEmail: "new@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "old@mail.com", PasswordHash: []byte("password")}, nil)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "new@mail.com").Return(&domain.User{Id: 2}, nil)
+			},
+			expectedErr: service.ErrEmailTaken,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			mockHasher := mock_service.NewMockHasher(ctrl)
+			mockHasher.EXPECT().Hash(gomock.Any(), gomock.Any()).DoAndReturn(func(password string, salt []byte) []byte {
+				return []byte(password)
+			}).AnyTimes()
+			serviceConfig := &config.Config{Account: config.AccountConfig{LockoutThreshold: 5, LockoutDuration: time.Minute}}
+			userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, nil, serviceConfig)
+
+			//// Act
+			emailChange, token, err := userService.RequestEmailChange(context.Background(), 1, testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if err == nil {
+				assert.Equal(t, emailChange.NewEmail, "new@mail.com")
+				// Only the token's hash is stored.
+				assert.NotEqual(t, emailChange.TokenHash, token)
+			}
+		})
+	}
+}
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+	mockUser := mock_service.NewMockUser(ctrl)
+	mockSessions := mock_service.NewMockSessions(ctrl)
+	mockNotifier := mock_service.NewMockNotifier(ctrl)
+	serviceConfig := &config.Config{Account: config.AccountConfig{DeletionGracePeriod: time.Hour}}
+	account := service.NewAccountService(serviceConfig, mockOAuth2, mockUser, nil, mockSessions, mockNotifier, nil, nil, nil, nil, nil)
+
+	mockUser.EXPECT().Delete(gomock.Any(), uint32(1), &service.UserDeleteInput{Password: "password"}).Return(&domain.User{Id: 1, Email: "alice@mail.com"}, "restore-token", nil)
+	// All the user's sessions and consents are revoked.
+	mockOAuth2.EXPECT().RevokeLoginSessions(gomock.Any(), "1").Return(nil)
+	mockOAuth2.EXPECT().RevokeConsentSessions(gomock.Any(), "1", false).Return(nil)
+	mockSessions.EXPECT().DeleteBySubject(gomock.Any(), "1", "").Return(nil)
+	var notification *domain.Notification
+	mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, n *domain.Notification) error {
+		notification = n
+		return nil
+	})
+
+	//// Act
+	datePurge, err := account.DeleteAccount(context.Background(), 1, &service.DeleteAccountInput{Password: "password"})
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, datePurge.After(time.Now().Add(59*time.Minute)), true)
+	assert.Equal(t, notification.Type, domain.NotificationAccountDeleted)
+	assert.Equal(t, notification.Data["url"], service.PathAccountRestore+"?token=restore-token")
+}
+
+func TestAccountService_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+	mockUser := mock_service.NewMockUser(ctrl)
+	mockRBAC := mock_service.NewMockRBAC(ctrl)
+	mockSessions := mock_service.NewMockSessions(ctrl)
+	mockAudit := mock_service.NewMockAudit(ctrl)
+	mockDevices := mock_service.NewMockDevices(ctrl)
+	mockIdentity := mock_service.NewMockIdentity(ctrl)
+	mockGroups := mock_service.NewMockGroups(ctrl)
+	account := service.NewAccountService(&config.Config{}, mockOAuth2, mockUser, mockRBAC, mockSessions, nil, mockAudit, mockDevices, mockIdentity, mockGroups, nil)
+
+	now := time.Now()
+	mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice", PasswordHash: []byte("hash")}, nil)
+	mockUser.EXPECT().ListEmailChanges(gomock.Any(), uint32(1)).Return([]domain.EmailChange{{OldEmail: "old@mail.com", NewEmail: "new@mail.com", TokenHash: "hash"}}, nil)
+	mockRBAC.EXPECT().GetUserRoles(gomock.Any(), uint32(1)).Return(nil, nil)
+	mockSessions.EXPECT().ListBySubject(gomock.Any(), "1").Return([]domain.Session{{Id: "hash", Data: []byte("tokens"), DateCreated: now}}, nil)
+	mockOAuth2.EXPECT().ListConsentGrants(gomock.Any(), "1").Return([]domain.OA2ConsentGrant{{ClientId: "client"}}, nil)
+	mockDevices.EXPECT().ListDevices(gomock.Any(), uint32(1)).Return([]domain.UserDevice{{UserId: 1, DeviceId: "device", UserAgent: "agent", Ip: "192.0.2.1", DateLastSeen: now}}, nil)
+	mockIdentity.EXPECT().LoginMethods(gomock.Any(), uint32(1)).Return(&domain.LoginMethods{
+		Password:   true,
+		Identities: []domain.Identity{{Id: 5, UserId: 1, Provider: "google", Subject: "upstream"}},
+		Providers:  []domain.IdentityProvider{},
+	}, nil)
+	mockGroups.EXPECT().ListUserGroups(gomock.Any(), uint32(1)).Return([]domain.Group{{Id: 7, Organization: "Acme", DisplayName: "Platform", Role: domain.GroupRoleAdmin}}, nil)
+	// The audit trail is read until the last page.
+	subjectId := uint32(1)
+	gomock.InOrder(
+		mockAudit.EXPECT().List(gomock.Any(), &service.AuditListInput{SubjectId: &subjectId, Limit: 200}).
+			Return(&domain.AuditPage{Events: []domain.AuditEvent{{Id: 9, Action: domain.AuditActionPasswordChanged}}, NextCursor: "cursor"}, nil),
+		mockAudit.EXPECT().List(gomock.Any(), &service.AuditListInput{SubjectId: &subjectId, Limit: 200, Cursor: "cursor"}).
+			Return(&domain.AuditPage{Events: []domain.AuditEvent{{Id: 4, Action: domain.AuditActionSignin}}}, nil),
+	)
+
+	//// Act
+	export, err := account.Export(context.Background(), 1)
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, export.Profile.Username, "alice")
+	assert.Equal(t, export.Roles, []string{})
+	assert.Equal(t, export.Sessions, []domain.AccountExportSession{{DateCreated: now}})
+	assert.Equal(t, export.Consents[0].ClientId, "client")
+	assert.Equal(t, export.EmailChanges[0].NewEmail, "new@mail.com")
+	assert.Equal(t, export.Devices, []domain.AccountExportDevice{{UserAgent: "agent", Ip: "192.0.2.1", DateLastSeen: now}})
+	assert.Equal(t, export.Identities, []domain.Identity{{Id: 5, UserId: 1, Provider: "google", Subject: "upstream"}})
+	assert.Equal(t, export.Groups, []domain.AccountExportGroup{{Organization: "Acme", DisplayName: "Platform", Role: domain.GroupRoleAdmin}})
+	assert.Equal(t, export.AuditEvents, []domain.AuditEvent{{Id: 9, Action: domain.AuditActionPasswordChanged}, {Id: 4, Action: domain.AuditActionSignin}})
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"sync"
+	"time"
+)
+
+// ActivityService collects the users' last online dates of the API requests and writes them in batches,
+// so the requests don't wait for the database. Every user's date is collected once per the throttle at most.
+// The dates are written to the users of the requests' tenants.
+type ActivityService struct {
+	repo     UserRepository
+	throttle time.Duration
+
+	mu sync.Mutex
+	// touched is when the users' dates were collected last.
+	touched map[uint32]time.Time
+	// pending are the dates to write, tenants are the tenants of their users.
+	pending map[uint32]time.Time
+	tenants map[uint32]*domain.Tenant
+}
+
+func NewActivityService(userRepo UserRepository, config *config.AccountConfig) *ActivityService {
+	return &ActivityService{
+		repo:     userRepo,
+		throttle: config.ActivityThrottle,
+		touched:  make(map[uint32]time.Time),
+		pending:  make(map[uint32]time.Time),
+		tenants:  make(map[uint32]*domain.Tenant),
+	}
+}
+
+// Touch collects the last online date of the user of the context's tenant, it doesn't block on the database.
+func (s *ActivityService) Touch(ctx context.Context, userId uint32) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if touched, ok := s.touched[userId]; ok && now.Sub(touched) < s.throttle {
+		return
+	}
+
+	s.touched[userId] = now
+	s.pending[userId] = now
+	s.tenants[userId] = domain.TenantFromContext(ctx)
+}
+
+// Flush writes the collected dates, the dates are collected again if the write failed.
+func (s *ActivityService) Flush(ctx context.Context) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	pending, tenants := s.pending, s.tenants
+	s.pending = make(map[uint32]time.Time)
+	s.tenants = make(map[uint32]*domain.Tenant)
+	// The expired throttles are forgotten, so the map doesn't grow with all the users ever seen.
+	for userId, touched := range s.touched {
+		if now.Sub(touched) >= s.throttle {
+			delete(s.touched, userId)
+		}
+	}
+	s.mu.Unlock()
+
+	// Every tenant's dates are written by one query.
+	batches := make(map[string]map[uint32]time.Time)
+	batchTenants := make(map[string]*domain.Tenant)
+	for userId, lastOnline := range pending {
+		tenantId := domain.DefaultTenantId
+		if tenant := tenants[userId]; tenant != nil {
+			tenantId = tenant.Id
+		}
+
+		if batches[tenantId] == nil {
+			batches[tenantId] = make(map[uint32]time.Time)
+			batchTenants[tenantId] = tenants[userId]
+		}
+		batches[tenantId][userId] = lastOnline
+	}
+
+	var err error
+	for tenantId, batch := range batches {
+		batchErr := s.repo.UpdateLastOnline(domain.WithTenant(ctx, batchTenants[tenantId]), batch)
+		if batchErr == nil {
+			continue
+		}
+
+		err = batchErr
+		s.mu.Lock()
+		for userId, lastOnline := range batch {
+			if _, ok := s.pending[userId]; !ok {
+				s.pending[userId] = lastOnline
+				s.tenants[userId] = tenants[userId]
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return err
+}
@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestActivityService_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	var written []map[uint32]time.Time
+	mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+	gomock.InOrder(
+		mockUserRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(errors.New("Test error")),
+		mockUserRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, lastOnline map[uint32]time.Time) error {
+				written = append(written, lastOnline)
+				return nil
+			}),
+	)
+	activity := service.NewActivityService(mockUserRepo, &config.AccountConfig{ActivityThrottle: time.Hour})
+	ctx := context.Background()
+
+	//// Act
+	activity.Touch(ctx, 1)
+	activity.Touch(ctx, 2)
+	// Throttled.
+	activity.Touch(ctx, 1)
+	failedErr := activity.Flush(ctx)
+	// Collected again after the failed write.
+	retriedErr := activity.Flush(ctx)
+	// Still throttled after the write.
+	activity.Touch(ctx, 2)
+	emptyErr := activity.Flush(ctx)
+
+	//// Assert
+	assert.NotEqual(t, failedErr, nil)
+	assert.Equal(t, retriedErr, nil)
+	assert.Equal(t, emptyErr, nil)
+	assert.Equal(t, len(written), 1)
+	assert.Equal(t, len(written[0]), 2)
+}
+
+func TestActivityService_Flush_tenants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	written := map[string]map[uint32]time.Time{}
+	mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+	mockUserRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, lastOnline map[uint32]time.Time) error {
+			written[domain.TenantId(ctx)] = lastOnline
+			return nil
+		})
+	activity := service.NewActivityService(mockUserRepo, &config.AccountConfig{ActivityThrottle: time.Hour})
+	acme := domain.WithTenant(context.Background(), &domain.Tenant{Id: "acme"})
+
+	//// Act
+	activity.Touch(context.Background(), 1)
+	activity.Touch(acme, 2)
+	activity.Touch(acme, 3)
+	err := activity.Flush(context.Background())
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(written[domain.DefaultTenantId]), 1)
+	assert.Equal(t, len(written["acme"]), 2)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"service-account/internal/domain"
+	"service-account/pkg/convert_to"
+	"time"
+)
+
+// AdminService handles the administrators' actions on the users' accounts, every action is audited.
+type AdminService struct {
+	oa2      OAuth2
+	user     User
+	rbac     RBAC
+	sessions Sessions
+	audit    Audit
+}
+
+func NewAdminService(oa2 OAuth2, userService User, rbacService RBAC, sessionService Sessions, auditService Audit) *AdminService {
+	return &AdminService{
+		oa2:      oa2,
+		user:     userService,
+		rbac:     rbacService,
+		sessions: sessionService,
+		audit:    auditService,
+	}
+}
+
+// SuspendUser suspends the user until the date or indefinitely if it's nil and ends all the user's sessions.
+func (s *AdminService) SuspendUser(ctx context.Context, actorId uint32, userId uint32, reason string, until *time.Time) error {
+	if err := s.user.Suspend(ctx, userId, reason, until); err != nil {
+		return err
+	}
+
+	event := s.event(domain.AuditActionUserSuspended, actorId, userId, reason)
+	if until != nil {
+		event.Data = map[string]string{"date_suspended_until": until.Format(time.RFC3339)}
+	}
+
+	if err := s.record(ctx, event); err != nil {
+		return err
+	}
+
+	return signOutEverywhere(ctx, s.oa2, s.sessions, convert_to.ToString(userId))
+}
+
+// UnsuspendUser lifts the user's suspension.
+func (s *AdminService) UnsuspendUser(ctx context.Context, actorId uint32, userId uint32, reason string) error {
+	if err := s.user.Unsuspend(ctx, userId); err != nil {
+		return err
+	}
+
+	return s.record(ctx, s.event(domain.AuditActionUserUnsuspended, actorId, userId, reason))
+}
+
+// UnlockUser lifts the user's lockout after the failed sign-ins.
+func (s *AdminService) UnlockUser(ctx context.Context, actorId uint32, userId uint32, reason string) error {
+	if err := s.user.Unlock(ctx, userId); err != nil {
+		return err
+	}
+
+	return s.record(ctx, s.event(domain.AuditActionUserUnlocked, actorId, userId, reason))
+}
+
+// LogoutUser ends all the user's sessions: the Hydra login sessions, the tokens and the browser sessions.
+func (s *AdminService) LogoutUser(ctx context.Context, actorId uint32, userId uint32, reason string) error {
+	if _, err := s.user.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := signOutEverywhere(ctx, s.oa2, s.sessions, convert_to.ToString(userId)); err != nil {
+		return err
+	}
+
+	return s.record(ctx, s.event(domain.AuditActionUserLoggedOut, actorId, userId, reason))
+}
+
+// AssignRole assigns the role to the user.
+func (s *AdminService) AssignRole(ctx context.Context, actorId uint32, userId uint32, role string) error {
+	if err := s.rbac.AssignRole(ctx, userId, role); err != nil {
+		return err
+	}
+
+	event := s.event(domain.AuditActionRoleAssigned, actorId, userId, "")
+	event.Data = map[string]string{"role": role}
+
+	return s.record(ctx, event)
+}
+
+// RevokeRole revokes the role from the user.
+func (s *AdminService) RevokeRole(ctx context.Context, actorId uint32, userId uint32, role string) error {
+	if err := s.rbac.RevokeRole(ctx, userId, role); err != nil {
+		return err
+	}
+
+	event := s.event(domain.AuditActionRoleRevoked, actorId, userId, "")
+	event.Data = map[string]string{"role": role}
+
+	return s.record(ctx, event)
+}
+
+func (s *AdminService) event(action string, actorId uint32, userId uint32, reason string) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		Action:  action,
+		ActorId: &actorId,
+		UserId:  &userId,
+		Reason:  reason,
+	}
+}
+
+func (s *AdminService) record(ctx context.Context, event *domain.AuditEvent) error {
+	return recordAudit(ctx, s.audit, event)
+}
@@ -0,0 +1,352 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+type TestTableAdminSuspendUser struct {
+	name                 string
+	until                *time.Time
+	mockBehaviorOAuth2   func(mockOAuth2 *mock_service.MockOAuth2)
+	mockBehaviorUser     func(mockUser *mock_service.MockUser)
+	mockBehaviorSessions func(mockSessions *mock_service.MockSessions)
+	mockBehaviorAudit    func(mockAudit *mock_service.MockAudit)
+	expectedErr          error
+}
+
+func TestAdminService_SuspendUser(t *testing.T) {
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testTable := []TestTableAdminSuspendUser{
+		{
+			name:  "OK, suspend and sign out",
+			until: &until,
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().RevokeLoginSessions(gomock.Any(), "2").Return(nil)
+				mockOAuth2.EXPECT().RevokeConsentSessions(gomock.Any(), "2", false).Return(nil)
+			},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().Suspend(gomock.Any(), uint32(2), "spam", &until).Return(nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().DeleteBySubject(gomock.Any(), "2", "").Return(nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				actorId, userId := uint32(1), uint32(2)
+				mockAudit.EXPECT().Record(gomock.Any(), &domain.AuditEvent{
+					Action:  domain.AuditActionUserSuspended,
+					ActorId: &actorId,
+					UserId:  &userId,
+					Reason:  "spam",
+					Data:    map[string]string{"date_suspended_until": "2030-01-02T03:04:05Z"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:               "BAD, user not found",
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().Suspend(gomock.Any(), uint32(2), "spam", nil).Return(service.ErrUserNotFound)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit:    func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:          service.ErrUserNotFound,
+		},
+		{
+			name:               "BAD, audit failed, the sessions are kept",
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().Suspend(gomock.Any(), uint32(2), "spam", nil).Return(nil)
+			},
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("Test error"))
+			},
+			expectedErr: errors.New("audit: Test error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockUser := mock_service.NewMockUser(ctrl)
+			testCase.mockBehaviorUser(mockUser)
+			mockSessions := mock_service.NewMockSessions(ctrl)
+			testCase.mockBehaviorSessions(mockSessions)
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			testCase.mockBehaviorAudit(mockAudit)
+			adminService := service.NewAdminService(mockOAuth2, mockUser, nil, mockSessions, mockAudit)
+
+			//// Act
+			err := adminService.SuspendUser(context.Background(), 1, 2, "spam", testCase.until)
+
+			//// Assert
+			if testCase.expectedErr == nil {
+				assert.Equal(t, err, nil)
+			} else {
+				assert.Equal(t, err.Error(), testCase.expectedErr.Error())
+			}
+		})
+	}
+}
+
+type TestTableUserSignIn struct {
+	name                 string
+	user                 *domain.User
+	password             string
+	mockBehaviorUserRepo mockBehaviorUserRepo
+	expectedErr          error
+}
+
+func TestUserService_SignIn(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testTable := []TestTableUserSignIn{
+		{
+			name:     "OK",
+			user:     &domain.User{Id: 1, PasswordHash: []byte("password")},
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "OK, failed sign-ins are reset",
+			user:     &domain.User{Id: 1, PasswordHash: []byte("password"), FailedSignins: 2, DateLockedUntil: &past},
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().Unlock(gomock.Any(), uint32(1)).Return(nil)
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "BAD, failed sign-in is counted",
+			user:     &domain.User{Id: 1, PasswordHash: []byte("password")},
+			password: "wrong",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().RecordFailedSignin(gomock.Any(), uint32(1), 5, gomock.Any()).Return(nil)
+			},
+			expectedErr: service.ErrPasswordIncorrect,
+		},
+		{
+			name:                 "BAD, locked out",
+			user:                 &domain.User{Id: 1, PasswordHash: []byte("password"), DateLockedUntil: &future},
+			password:             "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {},
+			expectedErr:          service.ErrUserLocked,
+		},
+		{
+			name:                 "BAD, suspended",
+			user:                 &domain.User{Id: 1, PasswordHash: []byte("password"), DateSuspended: &past, DateSuspendedUntil: &future},
+			password:             "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {},
+			expectedErr:          service.ErrUserSuspended,
+		},
+		{
+			name:     "OK, suspension expired",
+			user:     &domain.User{Id: 1, PasswordHash: []byte("password"), DateSuspended: &past, DateSuspendedUntil: &past},
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			mockUserRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(testCase.user, nil)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			// The hash is the password itself.
+			mockHasher := mock_service.NewMockHasher(ctrl)
+			mockHasher.EXPECT().Hash(gomock.Any(), gomock.Any()).DoAndReturn(func(password string, salt []byte) []byte {
+				return []byte(password)
+			})
+			serviceConfig := &config.Config{Account: config.AccountConfig{LockoutThreshold: 5, LockoutDuration: time.Minute}}
+			userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, nil, serviceConfig)
+
+			//// Act
+			_, err := userService.SignIn(context.Background(), &service.UserSignInInput{Login: "alice@mail.com", Password: testCase.password})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+type TestTableUserSignInDirectory struct {
+	name                 string
+	password             string
+	mockBehaviorUserRepo mockBehaviorUserRepo
+	mockBehaviorRoleRepo func(mockRepo *mock_service.MockRoleRepository)
+	expectedUser         *domain.User
+	expectedErr          error
+}
+
+func TestUserService_SignInDirectory(t *testing.T) {
+	entry := &domain.DirectoryEntry{
+		Id:          "entry-1",
+		DN:          "uid=alice,ou=people,dc=example,dc=com",
+		Username:    "Alice",
+		Email:       "Alice@Mail.com",
+		DisplayName: "Alice Liddell",
+		Groups:      []string{"CN=Admins,OU=Groups,DC=example,DC=com"},
+	}
+
+	testTable := []TestTableUserSignInDirectory{
+		{
+			name:     "OK, provisioned at the first sign-in",
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), domain.IdentityProviderDirectory, "entry-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "Alice@mail.com").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().CreateUserWithIdentity(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User, identity *domain.Identity) error {
+					assert.Equal(t, user.Username, "Alice")
+					assert.Equal(t, user.Email, "Alice@mail.com")
+					assert.Equal(t, user.HasPassword(), false)
+					assert.Equal(t, identity.Provider, domain.IdentityProviderDirectory)
+					assert.Equal(t, identity.Subject, "entry-1")
+					user.Id = 2
+					return nil
+				})
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserRoles(gomock.Any(), uint32(2)).Return([]string{}, nil)
+				mockRepo.EXPECT().AssignRole(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, userRole *domain.UserRole) error {
+					assert.Equal(t, userRole.UserId, uint32(2))
+					assert.Equal(t, userRole.Role, "admin")
+					return nil
+				})
+			},
+			expectedUser: &domain.User{Id: 2, Username: "Alice"},
+		},
+		{
+			name:     "OK, linked user's roles are synced",
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), domain.IdentityProviderDirectory, "entry-1").Return(&domain.Identity{Id: 3, UserId: 1}, nil)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice"}, nil)
+				mockRepo.EXPECT().TouchIdentity(gomock.Any(), uint32(3), "Alice@Mail.com", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserRoles(gomock.Any(), uint32(1)).Return([]string{"admin", "support"}, nil)
+				// The support role left the groups, the other roles aren't touched.
+				mockRepo.EXPECT().RevokeRole(gomock.Any(), uint32(1), "support").Return(nil)
+			},
+			expectedUser: &domain.User{Id: 1, Username: "alice"},
+		},
+		{
+			name:     "BAD, existing account isn't linked by the email",
+			password: "password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), domain.IdentityProviderDirectory, "entry-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "Alice@mail.com").Return(&domain.User{Id: 1, Username: "alice"}, nil)
+			},
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {},
+			expectedErr:          service.ErrDirectoryEmailTaken,
+		},
+		{
+			name:     "BAD, wrong password is counted",
+			password: "wrong",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), domain.IdentityProviderDirectory, "entry-1").Return(&domain.Identity{Id: 3, UserId: 1}, nil)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice"}, nil)
+				mockRepo.EXPECT().TouchIdentity(gomock.Any(), uint32(3), "Alice@Mail.com", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().RecordFailedSignin(gomock.Any(), uint32(1), 5, gomock.Any()).Return(nil)
+			},
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {},
+			expectedUser:         &domain.User{Id: 1, Username: "alice"},
+			expectedErr:          service.ErrPasswordIncorrect,
+		},
+		{
+			name:     "BAD, wrong password isn't provisioned",
+			password: "wrong",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), domain.IdentityProviderDirectory, "entry-1").Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {},
+			expectedErr:          service.ErrPasswordIncorrect,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			mockRoleRepo := mock_service.NewMockRoleRepository(ctrl)
+			testCase.mockBehaviorRoleRepo(mockRoleRepo)
+			mockBackend := mock_service.NewMockCredentialBackend(ctrl)
+			mockBackend.EXPECT().Find(gomock.Any(), "alice").Return(entry, nil)
+			mockBackend.EXPECT().Verify(gomock.Any(), entry, testCase.password).Return(testCase.password == "password", nil)
+			serviceConfig := &config.Config{
+				Account: config.AccountConfig{LockoutThreshold: 5, LockoutDuration: time.Minute},
+				LDAP: config.LDAPConfig{GroupRoles: []config.GroupRole{
+					{Group: "cn=admins,ou=groups,dc=example,dc=com", Role: "admin"},
+					{Group: "cn=support,ou=groups,dc=example,dc=com", Role: "support"},
+				}},
+			}
+			userService := service.NewUserSerices(mockUserRepo, mockRoleRepo, nil, mockBackend, serviceConfig)
+
+			//// Act
+			user, err := userService.SignIn(context.Background(), &service.UserSignInInput{Login: " alice ", Password: testCase.password})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedUser == nil {
+				assert.Equal(t, user, nil)
+			} else {
+				assert.Equal(t, user.Id, testCase.expectedUser.Id)
+				assert.Equal(t, user.Username, testCase.expectedUser.Username)
+			}
+		})
+	}
+}
+
+func TestUserService_SignInDirectoryFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	// The login unknown to the directory signs in with the local password.
+	mockBackend := mock_service.NewMockCredentialBackend(ctrl)
+	mockBackend.EXPECT().Find(gomock.Any(), "alice@mail.com").Return(nil, nil)
+	mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+	mockUserRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(&domain.User{Id: 1, PasswordHash: []byte("password")}, nil)
+	mockUserRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+	mockHasher := mock_service.NewMockHasher(ctrl)
+	mockHasher.EXPECT().Hash("password", gomock.Any()).Return([]byte("password"))
+	userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, mockBackend, &config.Config{})
+
+	//// Act
+	user, err := userService.SignIn(context.Background(), &service.UserSignInInput{Login: "alice@mail.com", Password: "password"})
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Id, uint32(1))
+}
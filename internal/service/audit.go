@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"service-account/internal/domain"
+	"time"
+)
+
+const (
+	// The audit log's page size if the limit isn't set.
+	auditListDefaultLimit = 50
+	// The largest audit log's page.
+	auditListMaxLimit = 200
+)
+
+// AuditListInput the empty filters don't filter.
+type AuditListInput struct {
+	ActorId *uint32
+	UserId  *uint32
+	// SubjectId lists the events the user did or the ones done to the user.
+	SubjectId *uint32
+	Action    string
+	Outcome   string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Cursor    string
+}
+
+// auditListCursor is the id of the page's last event.
+type auditListCursor struct {
+	Id uint64 `json:"i"`
+}
+
+// AuditService writes the audit trail of the actions to the database and to the audit sink.
+type AuditService struct {
+	repo AuditRepository
+	sink AuditSink
+}
+
+func NewAuditService(auditRepo AuditRepository, sink AuditSink) *AuditService {
+	return &AuditService{
+		repo: auditRepo,
+		sink: sink,
+	}
+}
+
+// Record appends the event to the audit trail. The client's IP and user agent are taken from the context
+// unless the event has them. The event is written to the sink even if it isn't saved to the database.
+func (s *AuditService) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event.DateCreated.IsZero() {
+		event.DateCreated = time.Now()
+	}
+
+	if event.Outcome == "" {
+		event.Outcome = domain.AuditOutcomeSuccess
+	}
+
+	if requestInfo := domain.RequestInfoFromContext(ctx); requestInfo != nil {
+		if event.Ip == "" {
+			event.Ip = requestInfo.Ip
+		}
+
+		if event.UserAgent == "" {
+			event.UserAgent = requestInfo.UserAgent
+		}
+	}
+
+	err := s.repo.Create(ctx, event)
+	s.sink.Write(event)
+
+	return err
+}
+
+// List returns the page of the audit log, the newest events first. The next page is read by the returned cursor.
+func (s *AuditService) List(ctx context.Context, input *AuditListInput) (*domain.AuditPage, error) {
+	query := &domain.AuditQuery{
+		ActorId:   input.ActorId,
+		UserId:    input.UserId,
+		SubjectId: input.SubjectId,
+		Action:    input.Action,
+		Outcome:   input.Outcome,
+		From:      input.From,
+		To:        input.To,
+		Limit:     input.Limit,
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = auditListDefaultLimit
+	} else if query.Limit > auditListMaxLimit {
+		query.Limit = auditListMaxLimit
+	}
+
+	if input.Cursor != "" {
+		beforeId, err := decodeAuditListCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		query.BeforeId = beforeId
+	}
+
+	// One more event tells whether there is the next page.
+	limit := query.Limit
+	query.Limit++
+	events, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.AuditPage{
+		Events: events,
+	}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = encodeAuditListCursor(page.Events[limit-1].Id)
+	}
+
+	if page.Events == nil {
+		page.Events = []domain.AuditEvent{}
+	}
+
+	return page, nil
+}
+
+func encodeAuditListCursor(id uint64) string {
+	data, _ := json.Marshal(&auditListCursor{Id: id})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeAuditListCursor(cursor string) (uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	var decoded auditListCursor
+	if err = json.Unmarshal(data, &decoded); err != nil || decoded.Id == 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return decoded.Id, nil
+}
+
+// recordAudit records the event, the error is wrapped to tell it from the action's errors.
+func recordAudit(ctx context.Context, audit Audit, event *domain.AuditEvent) error {
+	if err := audit.Record(ctx, event); err != nil {
+		return errors.Wrap(err, "audit")
+	}
+
+	return nil
+}
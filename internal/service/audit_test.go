@@ -0,0 +1,325 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	var sunk []*domain.AuditEvent
+	mockAuditRepo := mock_service.NewMockAuditRepository(ctrl)
+	mockAuditRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("Test error"))
+	mockAuditSink := mock_service.NewMockAuditSink(ctrl)
+	mockAuditSink.EXPECT().Write(gomock.Any()).Do(func(event *domain.AuditEvent) {
+		sunk = append(sunk, event)
+	})
+	audit := service.NewAuditService(mockAuditRepo, mockAuditSink)
+	ctx := domain.WithRequestInfo(context.Background(), &domain.RequestInfo{
+		Ip:        "192.0.2.1",
+		UserAgent: "test-agent",
+	})
+
+	//// Act
+	err := audit.Record(ctx, &domain.AuditEvent{Action: domain.AuditActionSignin})
+
+	//// Assert
+	assert.Equal(t, err.Error(), "Test error")
+	// The event isn't lost if the database is down.
+	assert.Equal(t, len(sunk), 1)
+	assert.Equal(t, sunk[0].Outcome, domain.AuditOutcomeSuccess)
+	assert.Equal(t, sunk[0].Ip, "192.0.2.1")
+	assert.Equal(t, sunk[0].UserAgent, "test-agent")
+	assert.Equal(t, sunk[0].DateCreated.IsZero(), false)
+}
+
+func TestAuditService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	var queries []domain.AuditQuery
+	mockAuditRepo := mock_service.NewMockAuditRepository(ctrl)
+	mockAuditRepo.EXPECT().List(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query *domain.AuditQuery) ([]domain.AuditEvent, error) {
+			queries = append(queries, *query)
+			if query.BeforeId == 0 {
+				return []domain.AuditEvent{{Id: 9}, {Id: 7}, {Id: 4}}, nil
+			}
+
+			return []domain.AuditEvent{{Id: 2}}, nil
+		}).Times(2)
+	audit := service.NewAuditService(mockAuditRepo, nil)
+
+	//// Act
+	first, errFirst := audit.List(context.Background(), &service.AuditListInput{Limit: 2})
+	second, errSecond := audit.List(context.Background(), &service.AuditListInput{Limit: 2, Cursor: first.NextCursor})
+	_, errCursor := audit.List(context.Background(), &service.AuditListInput{Cursor: "bad"})
+
+	//// Assert
+	assert.Equal(t, errFirst, nil)
+	assert.Equal(t, errSecond, nil)
+	assert.Equal(t, errCursor, service.ErrInvalidCursor)
+	// One more event tells whether there is the next page.
+	assert.Equal(t, queries[0].Limit, 3)
+	assert.Equal(t, first.Events, []domain.AuditEvent{{Id: 9}, {Id: 7}})
+	assert.Equal(t, queries[1].BeforeId, uint64(7))
+	assert.Equal(t, second.Events, []domain.AuditEvent{{Id: 2}})
+	assert.Equal(t, second.NextCursor, "")
+}
+
+func TestFlowService_SubmitLogin(t *testing.T) {
+	testTable := []struct {
+		name             string
+		input            *service.LoginSubmitInput
+		mockBehaviorUser func(mockUser *mock_service.MockUser)
+		assessment       *domain.RiskAssessment
+		expectedEvent    *domain.AuditEvent
+	}{
+		{
+			name:  "OK, signed in",
+			input: &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com", Password: "foobar", DeviceId: "device"},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, nil)
+			},
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				ActorId:   uint32p(1),
+				UserId:    uint32p(1),
+				Data:      map[string]string{"device": "Firefox on Linux", "new_device": "true", "acr": "1"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:  "BAD, password is incorrect",
+			input: &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com", Password: "wrong", DeviceId: "device"},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, service.ErrPasswordIncorrect)
+			},
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				Outcome:   domain.AuditOutcomeFailure,
+				UserId:    uint32p(1),
+				Reason:    service.ErrPasswordIncorrect.Error(),
+				Data:      map[string]string{"device": "Firefox on Linux", "login": "foo@bar.com"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:  "OK, low risk",
+			input: &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com", Password: "foobar", DeviceId: "device"},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, nil)
+			},
+			assessment: &domain.RiskAssessment{Score: 10, Level: domain.RiskLevelLow, Reasons: []string{domain.RiskReasonUnusualHour}},
+			expectedEvent: &domain.AuditEvent{
+				Action:  domain.AuditActionSignin,
+				ActorId: uint32p(1),
+				UserId:  uint32p(1),
+				Data: map[string]string{"device": "Firefox on Linux", "new_device": "true", "acr": "1",
+					"risk_score": "10", "risk_level": "low", "risk_reasons": "unusual_hour"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:  "DENIED, high risk",
+			input: &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com", Password: "foobar", DeviceId: "device"},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, nil)
+			},
+			assessment: &domain.RiskAssessment{Score: 130, Level: domain.RiskLevelHigh, Reasons: []string{domain.RiskReasonDeniedIp, domain.RiskReasonNewDevice}},
+			expectedEvent: &domain.AuditEvent{
+				Action:  domain.AuditActionSignin,
+				Outcome: domain.AuditOutcomeDenied,
+				ActorId: uint32p(1),
+				UserId:  uint32p(1),
+				Reason:  "The sign-in was blocked as too risky",
+				Data: map[string]string{"device": "Firefox on Linux",
+					"risk_score": "130", "risk_level": "high", "risk_reasons": "denied_ip,new_device"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:  "CHALLENGED, medium risk",
+			input: &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com", Password: "foobar", DeviceId: "device"},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, nil)
+			},
+			assessment: &domain.RiskAssessment{Score: 30, Level: domain.RiskLevelMedium, Reasons: []string{domain.RiskReasonNewDevice}},
+			expectedEvent: &domain.AuditEvent{
+				Action:  domain.AuditActionSignin,
+				Outcome: domain.AuditOutcomeChallenged,
+				ActorId: uint32p(1),
+				UserId:  uint32p(1),
+				Reason:  service.ErrStepUpRequired.Error(),
+				Data: map[string]string{"device": "Firefox on Linux", "step_up": "email_code",
+					"risk_score": "30", "risk_level": "medium", "risk_reasons": "new_device"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:             "OK, denied",
+			input:            &service.LoginSubmitInput{},
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {},
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				Outcome:   domain.AuditOutcomeDenied,
+				Reason:    "The resource owner denied the request",
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+			mockOAuth2.EXPECT().AcceptLoginRequest(gomock.Any(), "challenge", "1", false, gomock.Any(), domain.AcrPassword).Return("redirectTo", nil).AnyTimes()
+			mockOAuth2.EXPECT().RejectLoginRequest(gomock.Any(), "challenge", gomock.Any(), gomock.Any()).Return("redirectTo", nil).AnyTimes()
+			mockUser := mock_service.NewMockUser(ctrl)
+			testCase.mockBehaviorUser(mockUser)
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			})
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "device").DoAndReturn(func(ctx context.Context, deviceId string) map[string]string {
+				return map[string]string{"device": "Firefox on Linux"}
+			}).AnyTimes()
+			mockDevices.EXPECT().Recognize(gomock.Any(), gomock.Any(), "device", gomock.Any()).Return(true, nil).AnyTimes()
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			mockRisk.EXPECT().Assess(gomock.Any(), gomock.Any(), "device").Return(testCase.assessment, nil).AnyTimes()
+			mockRisk.EXPECT().StartStepUp(gomock.Any(), "challenge", gomock.Any(), false, "device").Return(nil).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, mockDevices, mockRisk, nil, service.NewTenantService(&config.Config{}), nil)
+
+			//// Act
+			_, _ = flow.SubmitLogin(context.Background(), "challenge", testCase.input)
+
+			//// Assert
+			assert.Equal(t, recorded, testCase.expectedEvent)
+		})
+	}
+}
+
+func TestFlowService_SubmitLoginCode(t *testing.T) {
+	testTable := []struct {
+		name             string
+		verifyErr        error
+		mockBehaviorUser func(mockUser *mock_service.MockUser)
+		expectedRedirect string
+		expectedErr      error
+		expectedEvent    *domain.AuditEvent
+	}{
+		{
+			name: "OK, code accepted",
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1}, nil)
+			},
+			expectedRedirect: "redirectTo",
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				ActorId:   uint32p(1),
+				UserId:    uint32p(1),
+				Data:      map[string]string{"device": "Firefox on Linux", "new_device": "true", "step_up": "email_code", "acr": "2"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name:             "BAD, code is incorrect",
+			verifyErr:        service.ErrStepUpCodeIncorrect,
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {},
+			expectedErr:      service.ErrStepUpCodeIncorrect,
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				Outcome:   domain.AuditOutcomeFailure,
+				UserId:    uint32p(1),
+				Reason:    service.ErrStepUpCodeIncorrect.Error(),
+				Data:      map[string]string{"device": "Firefox on Linux", "step_up": "email_code"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+		{
+			name: "BAD, user was suspended since the code was sent",
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				suspendedAt := time.Now()
+				mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, DateSuspended: &suspendedAt}, nil)
+			},
+			expectedErr: service.ErrUserSuspended,
+			expectedEvent: &domain.AuditEvent{
+				Action:    domain.AuditActionSignin,
+				Outcome:   domain.AuditOutcomeDenied,
+				UserId:    uint32p(1),
+				Reason:    service.ErrUserSuspended.Error(),
+				Data:      map[string]string{"device": "Firefox on Linux", "step_up": "email_code"},
+				ClientId:  "client",
+				Challenge: "challenge",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+			mockOAuth2.EXPECT().AcceptLoginRequest(gomock.Any(), "challenge", "1", true, gomock.Any(), domain.AcrMultiFactor).Return("redirectTo", nil).AnyTimes()
+			mockUser := mock_service.NewMockUser(ctrl)
+			testCase.mockBehaviorUser(mockUser)
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			})
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "device").DoAndReturn(func(ctx context.Context, deviceId string) map[string]string {
+				return map[string]string{"device": "Firefox on Linux"}
+			})
+			mockDevices.EXPECT().Recognize(gomock.Any(), gomock.Any(), "device", gomock.Any()).Return(true, nil).AnyTimes()
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			mockRisk.EXPECT().VerifyStepUp(gomock.Any(), "challenge", "123456").
+				Return(&domain.StepUp{Challenge: "challenge", UserId: 1, Remember: true, DeviceId: "device"}, testCase.verifyErr)
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, mockDevices, mockRisk, nil, service.NewTenantService(&config.Config{}), nil)
+
+			//// Act
+			redirectTo, err := flow.SubmitLoginCode(context.Background(), "challenge", "123456")
+
+			//// Assert
+			assert.Equal(t, redirectTo, testCase.expectedRedirect)
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, recorded, testCase.expectedEvent)
+		})
+	}
+}
+
+func uint32p(v uint32) *uint32 {
+	return &v
+}
@@ -0,0 +1,192 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	goldap "github.com/go-ldap/ldap/v3"
+	"net"
+	"net/url"
+	"os"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"strings"
+	"unicode/utf8"
+)
+
+// The placeholder of the login in the user filter.
+const filterLogin = "{login}"
+
+var (
+	// ErrAmbiguousLogin the filter finds several entries of the login.
+	ErrAmbiguousLogin = errors.New("Directory has several entries of the login")
+	ErrNoEntryId      = errors.New("Directory entry has no ID")
+	ErrInvalidCA      = errors.New("Directory's CA file has no certificates")
+)
+
+// Directory checks the credentials against the LDAP directory, e.g. OpenLDAP or Active Directory.
+// Every check opens its own connection, the plain one is upgraded by StartTLS if it's configured.
+type Directory struct {
+	config    *config.LDAPConfig
+	tlsConfig *tls.Config
+}
+
+func NewDirectory(config *config.LDAPConfig) (*Directory, error) {
+	serverUrl, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         serverUrl.Hostname(),
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, ErrInvalidCA
+		}
+	}
+
+	return &Directory{
+		config:    config,
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+// Find binds as the service and searches the entry of the login, it's nil if the directory doesn't know the login.
+func (d *Directory) Find(ctx context.Context, login string) (*domain.DirectoryEntry, error) {
+	if login == "" {
+		return nil, nil
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// The directory is searched anonymously without the service's DN.
+	if d.config.BindDN != "" {
+		if err = conn.Bind(d.config.BindDN, d.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("bind as the service: %w", err)
+		}
+	}
+
+	attributes := []string{}
+	for _, attribute := range []string{d.config.IdAttribute, d.config.UsernameAttribute, d.config.EmailAttribute, d.config.NameAttribute, d.config.GroupAttribute} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	// The size limit 2 is enough to see the login is ambiguous.
+	result, err := conn.Search(goldap.NewSearchRequest(
+		d.config.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, int(d.config.Timeout.Seconds()), false,
+		strings.ReplaceAll(d.config.UserFilter, filterLogin, goldap.EscapeFilter(login)),
+		attributes,
+		nil,
+	))
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrAmbiguousLogin
+		}
+
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+		return d.directoryEntry(result.Entries[0])
+	default:
+		return nil, ErrAmbiguousLogin
+	}
+}
+
+// Verify binds as the entry with the password.
+func (d *Directory) Verify(ctx context.Context, entry *domain.DirectoryEntry, password string) (bool, error) {
+	// The bind without the password is the unauthenticated one, the directories accept it.
+	if password == "" {
+		return false, nil
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *Directory) connect() (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(d.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: d.config.Timeout}),
+		goldap.DialWithTLSConfig(d.tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(d.config.Timeout)
+	if d.config.StartTLS && strings.HasPrefix(strings.ToLower(d.config.URL), "ldap://") {
+		if err = conn.StartTLS(d.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("start TLS: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// directoryEntry maps the LDAP entry's attributes, the binary ID (e.g. objectGUID) is hex encoded.
+func (d *Directory) directoryEntry(entry *goldap.Entry) (*domain.DirectoryEntry, error) {
+	id := entry.DN
+	if d.config.IdAttribute != "" {
+		raw := entry.GetRawAttributeValue(d.config.IdAttribute)
+		if utf8.Valid(raw) {
+			id = string(raw)
+		} else {
+			id = hex.EncodeToString(raw)
+		}
+	}
+
+	if id == "" {
+		return nil, ErrNoEntryId
+	}
+
+	directoryEntry := &domain.DirectoryEntry{
+		Id:          id,
+		DN:          entry.DN,
+		Username:    entry.GetAttributeValue(d.config.UsernameAttribute),
+		Email:       entry.GetAttributeValue(d.config.EmailAttribute),
+		DisplayName: entry.GetAttributeValue(d.config.NameAttribute),
+	}
+	if d.config.GroupAttribute != "" {
+		directoryEntry.Groups = entry.GetAttributeValues(d.config.GroupAttribute)
+	}
+
+	return directoryEntry, nil
+}
@@ -0,0 +1,366 @@
+package ldap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testBindDN       = "cn=service-account,ou=services,dc=example,dc=com"
+	testBindPassword = "service-secret"
+	testBaseDN       = "ou=people,dc=example,dc=com"
+	testAliceDN      = "uid=alice,ou=people,dc=example,dc=com"
+	testAdminsDN     = "cn=admins,ou=groups,dc=example,dc=com"
+	// The OID of the StartTLS extended operation.
+	oidStartTLS = "1.3.6.1.4.1.1466.20037"
+)
+
+// fakeEntry is the entry of the fake directory.
+type fakeEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// fakeDirectory is the in-process LDAP server answering the bind, search and StartTLS requests.
+// The binds are accepted only over TLS, the search requires the service's bind.
+type fakeDirectory struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	entries   []fakeEntry
+}
+
+func newFakeDirectory(t *testing.T) (*fakeDirectory, string) {
+	tlsConfig, caFile := newTestCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when listening", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	d := &fakeDirectory{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		entries: []fakeEntry{
+			{dn: testBindDN, password: testBindPassword},
+			{
+				dn:       testAliceDN,
+				password: "alice-secret",
+				attributes: map[string][]string{
+					"entryUUID": {"5b1f6a2e-1c9d-4f7e-9a51-3c2d8e0f4a11"},
+					"uid":       {"alice"},
+					"mail":      {"Alice@Example.com"},
+					"cn":        {"Alice Liddell"},
+					"memberOf":  {testAdminsDN, "cn=staff,ou=groups,dc=example,dc=com"},
+				},
+			},
+		},
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go d.serve(conn)
+		}
+	}()
+
+	return d, caFile
+}
+
+func (d *fakeDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var reader io.ReadWriter = conn
+	secure := false
+	bound := ""
+	for {
+		packet, err := ber.ReadPacket(reader)
+		if err != nil {
+			return
+		}
+
+		messageId := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			dn := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+			switch {
+			case !secure:
+				d.respond(reader, messageId, goldap.ApplicationBindResponse, goldap.LDAPResultConfidentialityRequired)
+			case d.authenticate(dn, password):
+				bound = dn
+				d.respond(reader, messageId, goldap.ApplicationBindResponse, goldap.LDAPResultSuccess)
+			default:
+				d.respond(reader, messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials)
+			}
+		case goldap.ApplicationExtendedRequest:
+			if request.Children[0].Data.String() != oidStartTLS || secure {
+				d.respond(reader, messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultProtocolError)
+				continue
+			}
+
+			d.respond(reader, messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultSuccess)
+			tlsConn := tls.Server(conn, d.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+
+			reader = tlsConn
+			secure = true
+		case goldap.ApplicationSearchRequest:
+			if bound != testBindDN {
+				d.respond(reader, messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights)
+				continue
+			}
+
+			filter, err := goldap.DecompileFilter(request.Children[6])
+			if err != nil {
+				d.respond(reader, messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultProtocolError)
+				continue
+			}
+
+			d.search(reader, messageId, request.Children[0].Value.(string), filter)
+		case goldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (d *fakeDirectory) authenticate(dn string, password string) bool {
+	for _, entry := range d.entries {
+		if entry.dn == dn && entry.password == password {
+			return true
+		}
+	}
+
+	return false
+}
+
+// search matches the entries whose uid or mail is compared by the filter.
+func (d *fakeDirectory) search(writer io.Writer, messageId int64, baseDN string, filter string) {
+	for _, entry := range d.entries {
+		if !strings.HasSuffix(entry.dn, baseDN) {
+			continue
+		}
+
+		matched := false
+		for _, attribute := range []string{"uid", "mail"} {
+			for _, value := range entry.attributes[attribute] {
+				matched = matched || strings.Contains(filter, "("+attribute+"="+value+")")
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "Object Name"))
+		attributes := ber.NewSequence("Attributes")
+		for name, values := range entry.attributes {
+			attribute := ber.NewSequence("Attribute")
+			attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+			for _, value := range values {
+				set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+			}
+			attribute.AppendChild(set)
+			attributes.AppendChild(attribute)
+		}
+		result.AppendChild(attributes)
+		d.write(writer, messageId, result)
+	}
+
+	d.respond(writer, messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess)
+}
+
+func (d *fakeDirectory) respond(writer io.Writer, messageId int64, tag ber.Tag, resultCode uint16) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	d.write(writer, messageId, response)
+}
+
+func (d *fakeDirectory) write(writer io.Writer, messageId int64, response *ber.Packet) {
+	packet := ber.NewSequence("LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "Message ID"))
+	packet.AppendChild(response)
+	_, _ = writer.Write(packet.Bytes())
+}
+
+// newTestCertificate returns the server's TLS config with the self-signed certificate of 127.0.0.1 and its PEM file.
+func newTestCertificate(t *testing.T) (*tls.Config, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when generating the key", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when creating the certificate", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("an error '%s' was not expected when writing the certificate", err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, caFile
+}
+
+func newTestConfig(addr string, caFile string) *config.LDAPConfig {
+	return &config.LDAPConfig{
+		Enabled:           true,
+		URL:               "ldap://" + addr,
+		StartTLS:          true,
+		CAFile:            caFile,
+		Timeout:           5 * time.Second,
+		BindDN:            testBindDN,
+		BindPassword:      testBindPassword,
+		BaseDN:            testBaseDN,
+		UserFilter:        "(&(objectClass=person)(|(uid={login})(mail={login})))",
+		IdAttribute:       "entryUUID",
+		UsernameAttribute: "uid",
+		EmailAttribute:    "mail",
+		NameAttribute:     "cn",
+		GroupAttribute:    "memberOf",
+	}
+}
+
+func TestDirectory_Find(t *testing.T) {
+	fake, caFile := newFakeDirectory(t)
+	directory, err := NewDirectory(newTestConfig(fake.listener.Addr().String(), caFile))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when creating the directory", err)
+	}
+
+	expected := &domain.DirectoryEntry{
+		Id:          "5b1f6a2e-1c9d-4f7e-9a51-3c2d8e0f4a11",
+		DN:          testAliceDN,
+		Username:    "alice",
+		Email:       "Alice@Example.com",
+		DisplayName: "Alice Liddell",
+		Groups:      []string{testAdminsDN, "cn=staff,ou=groups,dc=example,dc=com"},
+	}
+
+	t.Run("By username", func(t *testing.T) {
+		entry, err := directory.Find(context.Background(), "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entry)
+	})
+
+	t.Run("By email", func(t *testing.T) {
+		entry, err := directory.Find(context.Background(), "Alice@Example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entry)
+	})
+
+	t.Run("Unknown login", func(t *testing.T) {
+		entry, err := directory.Find(context.Background(), "bob")
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("Filter injection", func(t *testing.T) {
+		// The escaped "*" matches nothing.
+		entry, err := directory.Find(context.Background(), "*")
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+}
+
+func TestDirectory_Verify(t *testing.T) {
+	fake, caFile := newFakeDirectory(t)
+	directory, err := NewDirectory(newTestConfig(fake.listener.Addr().String(), caFile))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when creating the directory", err)
+	}
+
+	entry := &domain.DirectoryEntry{DN: testAliceDN}
+
+	ok, err := directory.Verify(context.Background(), entry, "alice-secret")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = directory.Verify(context.Background(), entry, "wrong")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// The unauthenticated bind isn't tried.
+	ok, err = directory.Verify(context.Background(), entry, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDirectory_Failures(t *testing.T) {
+	fake, caFile := newFakeDirectory(t)
+
+	t.Run("Plain connection", func(t *testing.T) {
+		ldapConfig := newTestConfig(fake.listener.Addr().String(), caFile)
+		ldapConfig.StartTLS = false
+		directory, err := NewDirectory(ldapConfig)
+		assert.NoError(t, err)
+
+		_, err = directory.Find(context.Background(), "alice")
+		var ldapErr *goldap.Error
+		assert.True(t, errors.As(err, &ldapErr))
+		assert.Equal(t, uint16(goldap.LDAPResultConfidentialityRequired), ldapErr.ResultCode)
+	})
+
+	t.Run("Untrusted certificate", func(t *testing.T) {
+		directory, err := NewDirectory(newTestConfig(fake.listener.Addr().String(), ""))
+		assert.NoError(t, err)
+
+		_, err = directory.Find(context.Background(), "alice")
+		assert.Error(t, err)
+	})
+
+	t.Run("Wrong service password", func(t *testing.T) {
+		ldapConfig := newTestConfig(fake.listener.Addr().String(), caFile)
+		ldapConfig.BindPassword = "wrong"
+		directory, err := NewDirectory(ldapConfig)
+		assert.NoError(t, err)
+
+		_, err = directory.Find(context.Background(), "alice")
+		var ldapErr *goldap.Error
+		assert.True(t, errors.As(err, &ldapErr))
+		assert.Equal(t, uint16(goldap.LDAPResultInvalidCredentials), ldapErr.ResultCode)
+	})
+}
@@ -0,0 +1,154 @@
+package upstream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"net/http"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"sync"
+	"time"
+)
+
+// The timeout of the requests to the providers.
+const providerTimeout = 10 * time.Second
+
+var (
+	ErrProviderNotFound = errors.New("Identity provider isn't configured")
+	ErrNonceMismatch    = errors.New("ID token's nonce doesn't match")
+	ErrNoIdToken        = errors.New("Token response has no ID token")
+)
+
+// Providers are the upstream OpenID Connect providers, the authorization code flow is protected by PKCE.
+// The provider is discovered at the first sign-in with it, so the service starts while the provider is down.
+type Providers struct {
+	list      []domain.IdentityProvider
+	providers map[string]*provider
+	// client's context makes the requests to the providers.
+	client context.Context
+}
+
+type provider struct {
+	config      config.IdentityProvider
+	callbackURL string
+
+	mu       sync.Mutex
+	verifier *oidc.IDTokenVerifier
+	oauth2   *oauth2.Config
+}
+
+func NewProviders(config *config.IdPConfig) *Providers {
+	p := &Providers{
+		providers: make(map[string]*provider, len(config.Providers)),
+		// The key set keeps the context to refresh the keys, it mustn't be canceled.
+		client: oidc.ClientContext(context.Background(), &http.Client{Timeout: providerTimeout}),
+	}
+
+	for _, providerConfig := range config.Providers {
+		p.list = append(p.list, domain.IdentityProvider{Id: providerConfig.Id, Name: providerConfig.Name})
+		p.providers[providerConfig.Id] = &provider{config: providerConfig, callbackURL: config.CallbackURL}
+	}
+
+	return p
+}
+
+// List returns the providers in the order of the configuration.
+func (p *Providers) List() []domain.IdentityProvider {
+	return p.list
+}
+
+// AuthCodeURL returns the provider's authorization URL, the code verifier is sent as the S256 code challenge.
+func (p *Providers) AuthCodeURL(ctx context.Context, providerId string, state string, nonce string, codeVerifier string) (string, error) {
+	oauth2Config, _, err := p.discover(providerId)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	return oauth2Config.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange exchanges the authorization code for the tokens and returns the claims of the verified ID token.
+func (p *Providers) Exchange(ctx context.Context, providerId string, code string, codeVerifier string, nonce string) (*domain.UpstreamClaims, error) {
+	oauth2Config, verifier, err := p.discover(providerId)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(p.requestContext(ctx), code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrNoIdToken
+	}
+
+	idToken, err := verifier.Verify(p.requestContext(ctx), rawIdToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	claims := new(domain.UpstreamClaims)
+	if err = idToken.Claims(claims); err != nil {
+		return nil, err
+	}
+
+	claims.Subject = idToken.Subject
+
+	return claims, nil
+}
+
+// discover returns the provider's OAuth2 config and ID token verifier, the provider is discovered once.
+func (p *Providers) discover(providerId string) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	provider, ok := p.providers[providerId]
+	if !ok {
+		return nil, nil, ErrProviderNotFound
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.oauth2 == nil {
+		discovered, err := oidc.NewProvider(p.client, provider.config.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discover the identity provider %q: %w", providerId, err)
+		}
+
+		scopes := provider.config.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+
+		provider.oauth2 = &oauth2.Config{
+			ClientID:     provider.config.ClientID,
+			ClientSecret: provider.config.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  provider.callbackURL,
+			Scopes:       scopes,
+		}
+		provider.verifier = discovered.Verifier(&oidc.Config{ClientID: provider.config.ClientID})
+	}
+
+	return provider.oauth2, provider.verifier, nil
+}
+
+// requestContext is the request's context making the requests with the providers' client.
+func (p *Providers) requestContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client.Value(oauth2.HTTPClient))
+}
@@ -0,0 +1,262 @@
+package upstream
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientId     = "service-account"
+	testClientSecret = "secret"
+	testCallbackURL  = "https://account.example.com/signin/idp/callback"
+)
+
+// fakeProvider is the local OpenID Connect provider issuing the ID tokens signed with its RSA key.
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	mu sync.Mutex
+	// codes are the issued authorization codes and their requests.
+	codes map[string]url.Values
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when generating the key", err)
+	}
+
+	p := &fakeProvider{
+		key:    key,
+		codes:  map[string]url.Values{},
+		claims: map[string]interface{}{"sub": "upstream-1", "email": "alice@mail.com", "email_verified": true, "preferred_username": "alice"},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// authorize signs the user in at the authorization URL and returns the code of the callback.
+func (p *fakeProvider) authorize(t *testing.T, authCodeUrl string) string {
+	parsed, err := url.Parse(authCodeUrl)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when parsing the authorization URL", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := "code-" + parsed.Query().Get("state")
+	p.codes[code] = parsed.Query()
+
+	return code
+}
+
+// token checks the code and the PKCE code verifier and issues the ID token with the authorization request's nonce.
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientId, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+
+	p.mu.Lock()
+	request, found := p.codes[r.PostFormValue("code")]
+	p.mu.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !found || clientId != testClientId || clientSecret != testClientSecret ||
+		request.Get("code_challenge_method") != "S256" ||
+		request.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(verifierHash[:]) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   testClientId,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": request.Get("nonce"),
+	}
+	for key, value := range p.claims {
+		claims[key] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.sign(claims),
+	})
+}
+
+// sign returns the RS256 JWT of the claims.
+func (p *fakeProvider) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hash[:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestProviders(issuer string) *Providers {
+	return NewProviders(&config.IdPConfig{
+		CallbackURL: testCallbackURL,
+		Providers: []config.IdentityProvider{{
+			Id:           "keycloak",
+			Name:         "Corporate account",
+			Issuer:       issuer,
+			ClientID:     testClientId,
+			ClientSecret: testClientSecret,
+		}},
+	})
+}
+
+func TestProviders_AuthCodeURL(t *testing.T) {
+	fake := newFakeProvider(t)
+	providers := newTestProviders(fake.server.URL)
+
+	authCodeUrl, err := providers.AuthCodeURL(context.Background(), "keycloak", "state", "nonce", "verifier")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authCodeUrl)
+	assert.NoError(t, err)
+	verifierHash := sha256.Sum256([]byte("verifier"))
+	assert.Equal(t, fake.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "state", parsed.Query().Get("state"))
+	assert.Equal(t, "nonce", parsed.Query().Get("nonce"))
+	assert.Equal(t, testCallbackURL, parsed.Query().Get("redirect_uri"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(verifierHash[:]), parsed.Query().Get("code_challenge"))
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, []domain.IdentityProvider{{Id: "keycloak", Name: "Corporate account"}}, providers.List())
+}
+
+func TestProviders_Exchange(t *testing.T) {
+	tests := []struct {
+		name           string
+		providerId     string
+		codeVerifier   string
+		nonce          string
+		expectedClaims *domain.UpstreamClaims
+		expectedErr    error
+		expectedErrMsg string
+	}{
+		{
+			name:         "OK",
+			providerId:   "keycloak",
+			codeVerifier: "verifier",
+			nonce:        "nonce",
+			expectedClaims: &domain.UpstreamClaims{
+				Subject:           "upstream-1",
+				Email:             "alice@mail.com",
+				EmailVerified:     true,
+				PreferredUsername: "alice",
+			},
+		},
+		{
+			name:           "BAD, code verifier mismatch",
+			providerId:     "keycloak",
+			codeVerifier:   "another verifier",
+			nonce:          "nonce",
+			expectedErrMsg: "invalid_grant",
+		},
+		{
+			name:         "BAD, nonce mismatch",
+			providerId:   "keycloak",
+			codeVerifier: "verifier",
+			nonce:        "another nonce",
+			expectedErr:  ErrNonceMismatch,
+		},
+		{
+			name:        "BAD, unknown provider",
+			providerId:  "github",
+			expectedErr: ErrProviderNotFound,
+		},
+	}
+
+	fake := newFakeProvider(t)
+	providers := newTestProviders(fake.server.URL)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The user signs in at the provider.
+			authCodeUrl, err := providers.AuthCodeURL(context.Background(), "keycloak", tt.name, "nonce", "verifier")
+			assert.NoError(t, err)
+			code := fake.authorize(t, authCodeUrl)
+
+			// Call test function.
+			claims, err := providers.Exchange(context.Background(), tt.providerId, code, tt.codeVerifier, tt.nonce)
+			switch {
+			case tt.expectedErr != nil:
+				assert.ErrorIs(t, err, tt.expectedErr)
+			case tt.expectedErrMsg != "":
+				assert.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.expectedErrMsg))
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedClaims, claims)
+		})
+	}
+}
+
+func TestProviders_DiscoveryFailure(t *testing.T) {
+	fake := newFakeProvider(t)
+	// The provider is down at the first sign-in and back at the next one.
+	providers := newTestProviders(fake.server.URL + "/down")
+
+	_, err := providers.AuthCodeURL(context.Background(), "keycloak", "state", "nonce", "verifier")
+	assert.Error(t, err)
+
+	providers.providers["keycloak"].config.Issuer = fake.server.URL
+	_, err = providers.AuthCodeURL(context.Background(), "keycloak", "state", "nonce", "verifier")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,52 @@
+package oauth2
+
+import (
+	client "github.com/ory/hydra-client-go"
+	"golang.org/x/net/context"
+)
+
+// CreateServiceClient creates the Hydra client of the service account getting the tokens by the client credentials grant
+// and returns its secret, the secret is returned only once.
+func (h *OAuth2Service) CreateServiceClient(context context.Context, clientId string, name string, scope string) (string, error) {
+	oAuth2Client := client.NewOAuth2Client()
+	oAuth2Client.SetClientId(clientId)
+	oAuth2Client.SetClientName(name)
+	oAuth2Client.SetScope(scope)
+	oAuth2Client.SetGrantTypes([]string{"client_credentials"})
+	oAuth2Client.SetResponseTypes([]string{"token"})
+	oAuth2Client.SetTokenEndpointAuthMethod("client_secret_basic")
+
+	request := h.hydra.AdminApi.CreateOAuth2Client(context)
+	request = request.OAuth2Client(*oAuth2Client)
+	createdClient, _, err := request.Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return "", err
+	}
+
+	return createdClient.GetClientSecret(), nil
+}
+
+// UpdateServiceClient changes the name and the scope of the service account's Hydra client, the secret is kept.
+func (h *OAuth2Service) UpdateServiceClient(context context.Context, clientId string, name string, scope string) error {
+	oAuth2Client, _, err := h.hydra.AdminApi.GetOAuth2Client(context, clientId).Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return err
+	}
+
+	oAuth2Client.SetClientName(name)
+	oAuth2Client.SetScope(scope)
+
+	request := h.hydra.AdminApi.UpdateOAuth2Client(context, clientId)
+	request = request.OAuth2Client(*oAuth2Client)
+	_, _, err = request.Execute()
+
+	return err
+}
+
+// DeleteServiceClient deletes the service account's Hydra client, its tokens aren't active anymore.
+func (h *OAuth2Service) DeleteServiceClient(context context.Context, clientId string) error {
+	_, err := h.hydra.AdminApi.DeleteOAuth2Client(context, clientId).Execute()
+	return err
+}
@@ -25,11 +25,12 @@ func (h *OAuth2Service) GetConsentRequest(context context.Context, challenge str
 			ClientData:                   clientData,
 			RequestedAccessTokenAudience: consentRequest.GetRequestedAccessTokenAudience(),
 			RequestedScope:               consentRequest.GetRequestedScope(),
+			ClientId:                     consentRequest.Client.GetClientId(),
 		},
 		nil
 }
 
-func (h *OAuth2Service) AcceptConsentRequest(context context.Context, challenge string, grantScope []string, grantAccessTokenAudience []string, remember bool, rememberFor int64) (string, error) {
+func (h *OAuth2Service) AcceptConsentRequest(context context.Context, challenge string, grantScope []string, grantAccessTokenAudience []string, session *domain.OA2ConsentSession, remember bool, rememberFor int64) (string, error) {
 	//// The session allows us to set session data for id and access tokens
 	//let session: ConsentRequestSession = {
 	//	// This data will be available when introspecting the token. Try to avoid sensitive information here,
@@ -86,7 +87,16 @@ func (h *OAuth2Service) AcceptConsentRequest(context context.Context, challenge
 		}
 	*/
 	consentSession := client.ConsentRequestSession{}
-	// TODO: do better value for acceptConsentRequest.SetSession()
+	if session != nil {
+		if session.AccessToken != nil {
+			consentSession.AccessToken = session.AccessToken
+		}
+
+		if session.IdToken != nil {
+			consentSession.IdToken = session.IdToken
+		}
+	}
+
 	var acceptConsentRequest client.AcceptConsentRequest
 	acceptConsentRequest.SetSession(consentSession)
 	acceptConsentRequest.SetGrantScope(grantScope)
@@ -100,7 +110,7 @@ func (h *OAuth2Service) AcceptConsentRequest(context context.Context, challenge
 	completedRequest, _, err := requestAcceptConsent.Execute()
 	if err != nil {
 		// Error request to hydra OAuth admin API.
-		return "", nil
+		return "", err
 	}
 
 	return completedRequest.RedirectTo, nil
@@ -15,7 +15,12 @@ func (h *OAuth2Service) IntrospectOAuth2Token(context context.Context, accessTok
 	}
 
 	return &domain.OA2TokenIntrospection{
-		Active: tokenIntrospection.GetActive(),
-		Sub:    tokenIntrospection.Sub,
+		Active:    tokenIntrospection.GetActive(),
+		Sub:       tokenIntrospection.Sub,
+		Scope:     tokenIntrospection.GetScope(),
+		ClientId:  tokenIntrospection.ClientId,
+		Aud:       tokenIntrospection.GetAud(),
+		Exp:       tokenIntrospection.Exp,
+		TokenType: tokenIntrospection.TokenType,
 	}, nil
 }
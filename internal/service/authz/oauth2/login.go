@@ -22,26 +22,42 @@ func (h *OAuth2Service) GetLoginRequest(context context.Context, challenge strin
 		return nil, errGetLogin
 	}
 
-	// Get hint.
+	// Get hint and the rest of the OpenID Connect context.
 	oidcContext := loginRequestResponseData.GetOidcContext()
 	var hint string
 	if hintPtr, ok := oidcContext.GetLoginHintOk(); ok {
 		hint = *hintPtr
 	}
 
+	idTokenHintClaims := make(map[string]interface{}, len(oidcContext.GetIdTokenHintClaims()))
+	for claim, value := range oidcContext.GetIdTokenHintClaims() {
+		idTokenHintClaims[claim] = value
+	}
+
+	clientData, _ := loginRequestResponseData.Client.MarshalJSON()
+
 	return &domain.OA2LoginRequest{
-			Skip:    loginRequestResponseData.GetSkip(),
-			Subject: loginRequestResponseData.GetSubject(),
-			Hint:    hint,
+			Skip:              loginRequestResponseData.GetSkip(),
+			Subject:           loginRequestResponseData.GetSubject(),
+			Hint:              hint,
+			ClientId:          loginRequestResponseData.Client.GetClientId(),
+			ClientData:        clientData,
+			RequestedScope:    loginRequestResponseData.GetRequestedScope(),
+			AcrValues:         oidcContext.GetAcrValues(),
+			UiLocales:         oidcContext.GetUiLocales(),
+			Display:           oidcContext.GetDisplay(),
+			IdTokenHintClaims: idTokenHintClaims,
+			RequestUrl:        loginRequestResponseData.GetRequestUrl(),
 		},
 		nil
 }
 
-func (h *OAuth2Service) AcceptLoginRequest(context context.Context, challenge string, subject string, remember bool, rememberFor int64) (string, error) {
+func (h *OAuth2Service) AcceptLoginRequest(context context.Context, challenge string, subject string, remember bool, rememberFor int64, acr string) (string, error) {
 	var acceptLoginRequest client.AcceptLoginRequest
 	acceptLoginRequest.SetSubject(subject)
 	acceptLoginRequest.SetRemember(remember)
 	acceptLoginRequest.SetRememberFor(rememberFor)
+	acceptLoginRequest.SetAcr(acr)
 
 	// Sets which "level" (e.g. 2-factor authentication) of authentication the user has. The value is really arbitrary
 	// and optional. In the context of OpenID Connect, a value of 0 indicates the lowest authorization level.
@@ -58,7 +74,6 @@ func (h *OAuth2Service) AcceptLoginRequest(context context.Context, challenge st
 	//	oidcConformityMaybeFakeAcr(loginRequest, '0')
 	//	acceptLoginRequest.SetAcr()
 
-	// TODO:  acceptLoginRequest.SetAcr()
 	// acr - sets the Authentication AuthorizationContext Class Reference value for this authentication session. You can use it to express that, for example, a user authenticated using two factor authentication.
 	// SRC: https://www.ory.sh/docs/hydra/concepts/login
 
@@ -1,6 +1,27 @@
 package oauth2
 
-import "golang.org/x/net/context"
+import (
+	"golang.org/x/net/context"
+	"service-account/internal/domain"
+)
+
+func (h *OAuth2Service) GetLogoutRequest(context context.Context, challenge string) (*domain.OA2LogoutRequest, error) {
+	request := h.hydra.AdminApi.GetLogoutRequest(context)
+	request = request.LogoutChallenge(challenge)
+	logoutRequest, _, err := request.Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return nil, err
+	}
+
+	return &domain.OA2LogoutRequest{
+			Subject:     logoutRequest.GetSubject(),
+			Sid:         logoutRequest.GetSid(),
+			RpInitiated: logoutRequest.GetRpInitiated(),
+			ClientId:    logoutRequest.Client.GetClientId(),
+		},
+		nil
+}
 
 func (h *OAuth2Service) RejectLogoutRequest(context context.Context, challenge string) error {
 	request := h.hydra.AdminApi.RejectLogoutRequest(context)
@@ -20,7 +41,7 @@ func (h *OAuth2Service) AcceptLogoutRequest(context context.Context, challenge s
 	completedRequest, _, errAcceptLogout := requestAcceptLogout.Execute()
 	if errAcceptLogout != nil {
 		// Error request to hydra OAuth admin API.
-		return "", nil
+		return "", errAcceptLogout
 	}
 
 	return completedRequest.RedirectTo, nil
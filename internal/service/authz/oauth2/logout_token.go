@@ -0,0 +1,80 @@
+package oauth2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/net/context"
+	"service-account/internal/domain"
+	"strings"
+)
+
+// Back-Channel Logout event of the logout token.
+// SRC: https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+const eventBackchannelLogout = "http://schemas.openid.net/event/backchannel-logout"
+
+var ErrInvalidLogoutToken = errors.New("The logout token is invalid")
+
+// VerifyLogoutToken verifies the signature and the claims of the OpenID Connect Back-Channel Logout token
+// and returns the login session to end.
+func (h *OAuth2Service) VerifyLogoutToken(ctx context.Context, logoutToken string) (*domain.OA2LogoutToken, error) {
+	// The logout token may have no "exp" claim and the issuer is compared without the trailing slash.
+	verifier := oidc.NewVerifier(h.issuer, h.keySet, &oidc.Config{
+		ClientID:        h.confOAuth2.ClientID,
+		SkipExpiryCheck: true,
+		SkipIssuerCheck: true,
+	})
+
+	token, err := verifier.Verify(ctx, logoutToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(token.Issuer, "/") != strings.TrimSuffix(h.issuer, "/") {
+		return nil, ErrInvalidLogoutToken
+	}
+
+	var claims struct {
+		Sid    string                     `json:"sid"`
+		Nonce  *string                    `json:"nonce"`
+		Events map[string]json.RawMessage `json:"events"`
+	}
+	if err = token.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	// The logout token must have the logout event, the session ID and mustn't have the nonce.
+	if _, ok := claims.Events[eventBackchannelLogout]; !ok || claims.Nonce != nil || claims.Sid == "" {
+		return nil, ErrInvalidLogoutToken
+	}
+
+	return &domain.OA2LogoutToken{
+		Subject: token.Subject,
+		Sid:     claims.Sid,
+	}, nil
+}
+
+// parseIdTokenClaims returns the subject and the login session ID of the ID token.
+// The token isn't verified, it's only used for the tokens received from the token endpoint directly.
+func parseIdTokenClaims(idToken string) (string, string) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return "", ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ""
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+		Sid     string `json:"sid"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return "", ""
+	}
+
+	return claims.Subject, claims.Sid
+}
@@ -2,6 +2,7 @@ package oauth2
 
 import (
 	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
 	client "github.com/ory/hydra-client-go"
 	"github.com/ory/x/randx"
 	"golang.org/x/net/context"
@@ -15,6 +16,8 @@ import (
 type OAuth2Service struct {
 	hydra      *client.APIClient
 	confOAuth2 oauth2.Config
+	issuer     string
+	keySet     oidc.KeySet // Hydra's keys to verify the logout tokens.
 
 	AuthCodeUrl       string
 	AuthState         []rune // I think it's should be generated on client app side.
@@ -23,7 +26,7 @@ type OAuth2Service struct {
 
 func NewOAuth2Service(config *config.OAuth2Config) *OAuth2Service {
 	// Init OAuth config.
-	scopes := []string{"openid", "offline"}
+	scopes := []string{"openid", "offline", domain.ScopeUsersRead, domain.ScopeUsersWrite}
 
 	configuration := client.NewConfiguration()
 	configuration.Servers = []client.ServerConfiguration{
@@ -46,6 +49,8 @@ func NewOAuth2Service(config *config.OAuth2Config) *OAuth2Service {
 	handlerOA2 := &OAuth2Service{
 		hydra:      client.NewAPIClient(configuration),
 		confOAuth2: confOAuth2,
+		issuer:     config.HydraPublicURL,
+		keySet:     oidc.NewRemoteKeySet(context.Background(), fmt.Sprintf("%s%s", config.HydraPublicURLPrivateLan, "/.well-known/jwks.json")),
 	}
 
 	// Init auth code url.
@@ -69,6 +74,7 @@ func (h *OAuth2Service) TokenExchange(ctx context.Context, code string) (*domain
 	// Retrive OpenID token.
 	idt := token.Extra("id_token")
 	IdToken := idt.(string)
+	subject, sid := parseIdTokenClaims(IdToken)
 
 	return &domain.Token{
 		AccessToken:  token.AccessToken,
@@ -76,6 +82,8 @@ func (h *OAuth2Service) TokenExchange(ctx context.Context, code string) (*domain
 		RefreshToken: token.RefreshToken,
 		Expiry:       token.Expiry,
 		IdToken:      IdToken,
+		Subject:      subject,
+		Sid:          sid,
 	}, nil
 }
 
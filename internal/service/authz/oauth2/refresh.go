@@ -0,0 +1,54 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"service-account/internal/domain"
+)
+
+// ErrInvalidGrant the refresh token is invalid, expired, revoked or was already used.
+var ErrInvalidGrant = errors.New("The refresh token is invalid")
+
+func (h *OAuth2Service) RefreshToken(ctx context.Context, token *domain.Token) (*domain.Token, error) {
+	// The token source refreshes the token when the access token is not valid,
+	// so we pass only the refresh token to force the refresh.
+	tokenSource := h.confOAuth2.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: token.RefreshToken,
+	})
+
+	refreshedToken, err := tokenSource.Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			// Hydra rotates the refresh tokens, reusing one revokes the whole token chain.
+			// SRC: https://www.rfc-editor.org/rfc/rfc6749#section-5.2
+			var errorResponse struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(retrieveErr.Body, &errorResponse) == nil && errorResponse.Error == "invalid_grant" {
+				return nil, ErrInvalidGrant
+			}
+		}
+
+		return nil, err
+	}
+
+	// The ID token is issued again only if the "openid" scope was granted.
+	idToken, subject, sid := token.IdToken, token.Subject, token.Sid
+	if idt, ok := refreshedToken.Extra("id_token").(string); ok && idt != "" {
+		idToken = idt
+		subject, sid = parseIdTokenClaims(idt)
+	}
+
+	return &domain.Token{
+		AccessToken:  refreshedToken.AccessToken,
+		TokenType:    refreshedToken.TokenType,
+		RefreshToken: refreshedToken.RefreshToken,
+		Expiry:       refreshedToken.Expiry,
+		IdToken:      idToken,
+		Subject:      subject,
+		Sid:          sid,
+	}, nil
+}
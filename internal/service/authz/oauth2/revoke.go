@@ -0,0 +1,83 @@
+package oauth2
+
+import (
+	"golang.org/x/net/context"
+	"service-account/internal/domain"
+)
+
+// RevokeLoginSessions revokes the subject's Hydra login sessions, so the subject has to sign in again.
+func (h *OAuth2Service) RevokeLoginSessions(context context.Context, subject string) error {
+	request := h.hydra.AdminApi.RevokeAuthenticationSession(context)
+	request = request.Subject(subject)
+	_, err := request.Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return err
+	}
+
+	return nil
+}
+
+// RevokeConsentSessions revokes the subject's consents and the access and refresh tokens issued by them.
+// The consent of the service's own client is kept if exceptOwnClient is set, so the current session stays alive.
+func (h *OAuth2Service) RevokeConsentSessions(context context.Context, subject string, exceptOwnClient bool) error {
+	if !exceptOwnClient {
+		request := h.hydra.AdminApi.RevokeConsentSessions(context)
+		request = request.Subject(subject).All(true)
+		_, err := request.Execute()
+		return err
+	}
+
+	requestList := h.hydra.AdminApi.ListSubjectConsentSessions(context)
+	requestList = requestList.Subject(subject)
+	consentSessions, _, err := requestList.Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return err
+	}
+
+	revoked := map[string]bool{h.confOAuth2.ClientID: true}
+	for _, consentSession := range consentSessions {
+		consentRequest := consentSession.GetConsentRequest()
+		consentClient := consentRequest.GetClient()
+		clientId := consentClient.GetClientId()
+		if revoked[clientId] {
+			continue
+		}
+
+		request := h.hydra.AdminApi.RevokeConsentSessions(context)
+		request = request.Subject(subject).Client(clientId)
+		if _, err = request.Execute(); err != nil {
+			return err
+		}
+
+		revoked[clientId] = true
+	}
+
+	return nil
+}
+
+// ListConsentGrants returns the consents the subject granted to the clients.
+func (h *OAuth2Service) ListConsentGrants(context context.Context, subject string) ([]domain.OA2ConsentGrant, error) {
+	request := h.hydra.AdminApi.ListSubjectConsentSessions(context)
+	request = request.Subject(subject)
+	consentSessions, _, err := request.Execute()
+	if err != nil {
+		// Error request to hydra OAuth admin API.
+		return nil, err
+	}
+
+	grants := make([]domain.OA2ConsentGrant, 0, len(consentSessions))
+	for _, consentSession := range consentSessions {
+		consentRequest := consentSession.GetConsentRequest()
+		consentClient := consentRequest.GetClient()
+		grants = append(grants, domain.OA2ConsentGrant{
+			ClientId:    consentClient.GetClientId(),
+			ClientName:  consentClient.GetClientName(),
+			GrantScope:  consentSession.GetGrantScope(),
+			DateHandled: consentSession.HandledAt,
+		})
+	}
+
+	return grants, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"strings"
+	"time"
+)
+
+const (
+	// Length of the random device ID in bytes.
+	deviceIdLength = 16
+	// The shortest device cookie key in bytes.
+	deviceCookieKeyMinLength = 32
+	// The number of the sign-ins in the login history.
+	loginHistoryLimit = 20
+)
+
+// ErrDeviceCookieKey the device cookie key is malformed.
+var ErrDeviceCookieKey = errors.New("Device cookie key must be the base64 encoded 32 bytes at least")
+
+// DeviceService recognizes the devices the users sign in from and keeps the users' login history.
+// The device is identified by the long-lived device cookie signed with the HMAC-SHA256.
+type DeviceService struct {
+	repo     UserRepository
+	audit    Audit
+	notifier Notifier
+	locator  GeoLocator // nil if the locations are unknown.
+	key      []byte     // nil if the devices aren't recognized.
+}
+
+func NewDeviceService(userRepo UserRepository, auditService Audit, notifier Notifier, locator GeoLocator, config *config.DeviceConfig) (*DeviceService, error) {
+	s := &DeviceService{
+		repo:     userRepo,
+		audit:    auditService,
+		notifier: notifier,
+		locator:  locator,
+	}
+
+	if config.CookieKey != "" {
+		key, err := base64.StdEncoding.DecodeString(config.CookieKey)
+		if err != nil || len(key) < deviceCookieKeyMinLength {
+			return nil, ErrDeviceCookieKey
+		}
+
+		s.key = key
+	}
+
+	return s, nil
+}
+
+// Identify verifies the device cookie, returns the device's ID and the cookie to set.
+// The new device is issued if the cookie is missing or forged. Both are empty if the devices aren't recognized.
+func (s *DeviceService) Identify(cookie string) (string, string, error) {
+	if s.key == nil {
+		return "", "", nil
+	}
+
+	if deviceId, signature, ok := strings.Cut(cookie, "."); ok && hmac.Equal([]byte(signature), []byte(s.sign(deviceId))) {
+		return deviceId, cookie, nil
+	}
+
+	rawId := make([]byte, deviceIdLength)
+	if _, err := rand.Read(rawId); err != nil {
+		return "", "", err
+	}
+
+	deviceId := base64.RawURLEncoding.EncodeToString(rawId)
+
+	return deviceId, deviceId + "." + s.sign(deviceId), nil
+}
+
+// Describe returns the details of the request's device for the audit log:
+// the device ID, the browser and the OS of the user agent and the approximate location of the IP.
+func (s *DeviceService) Describe(ctx context.Context, deviceId string) map[string]string {
+	details := map[string]string{}
+	if deviceId != "" {
+		details["device_id"] = deviceId
+	}
+
+	if requestInfo := domain.RequestInfoFromContext(ctx); requestInfo != nil {
+		details["device"] = describeUserAgent(requestInfo.UserAgent)
+		if s.locator != nil {
+			if location := s.locator.Locate(requestInfo.Ip); location != nil && location.String() != "" {
+				details["location"] = location.String()
+			}
+		}
+	}
+
+	return details
+}
+
+// Recognize remembers the user's device, returns true if the device is new. The user is notified about the new device
+// unless it's the user's first one. The device is remembered even if ErrNotificationFailed is returned.
+// The unidentified device (the request without the device cookie) isn't remembered, it's new for the user with the known devices.
+func (s *DeviceService) Recognize(ctx context.Context, user *domain.User, deviceId string, details map[string]string) (bool, error) {
+	if s.key == nil {
+		return false, nil
+	}
+
+	hasDevices, err := s.repo.HasDevices(ctx, user.Id)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	device := &domain.UserDevice{
+		UserId:        user.Id,
+		DeviceId:      deviceId,
+		DateFirstSeen: now,
+		DateLastSeen:  now,
+	}
+	if requestInfo := domain.RequestInfoFromContext(ctx); requestInfo != nil {
+		device.UserAgent = requestInfo.UserAgent
+		device.Ip = requestInfo.Ip
+	}
+
+	if deviceId == "" {
+		if !hasDevices {
+			return false, nil
+		}
+
+		return true, s.notifyNewDevice(ctx, user, device, details)
+	}
+
+	created, err := s.repo.SaveDevice(ctx, device)
+	if err != nil {
+		return false, err
+	}
+
+	if !created || !hasDevices {
+		return created, nil
+	}
+
+	return true, s.notifyNewDevice(ctx, user, device, details)
+}
+
+// notifyNewDevice sends the user the email about the sign-in from the new device.
+func (s *DeviceService) notifyNewDevice(ctx context.Context, user *domain.User, device *domain.UserDevice, details map[string]string) error {
+	err := s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationNewDevice,
+		UserId: user.Id,
+		Email:  user.Email,
+		Data: map[string]string{
+			"device":   details["device"],
+			"location": details["location"],
+			"ip":       device.Ip,
+			"date":     device.DateFirstSeen.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ErrNotificationFailed, err.Error())
+	}
+
+	return nil
+}
+
+// LoginHistory returns the user's recent sign-ins, the newest first.
+func (s *DeviceService) LoginHistory(ctx context.Context, userId uint32) ([]domain.LoginRecord, error) {
+	page, err := s.audit.List(ctx, &AuditListInput{
+		UserId: &userId,
+		Action: domain.AuditActionSignin,
+		Limit:  loginHistoryLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]domain.LoginRecord, 0, len(page.Events))
+	for _, event := range page.Events {
+		logins = append(logins, domain.LoginRecord{
+			Date:      event.DateCreated,
+			Outcome:   event.Outcome,
+			Ip:        event.Ip,
+			Location:  event.Data["location"],
+			Device:    event.Data["device"],
+			NewDevice: event.Data["new_device"] == "true",
+		})
+	}
+
+	return logins, nil
+}
+
+// ListDevices returns the user's known devices, the last seen first.
+func (s *DeviceService) ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error) {
+	devices, err := s.repo.ListDevices(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if devices == nil {
+		devices = []domain.UserDevice{}
+	}
+
+	return devices, nil
+}
+
+func (s *DeviceService) sign(deviceId string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(deviceId))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// The user agent's tokens of the browsers and the OSes, the more specific ones go first.
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentSystems = [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeUserAgent returns the browser and the OS of the user agent, e.g. "Firefox on Linux".
+func describeUserAgent(userAgent string) string {
+	browser, system := "Unknown browser", "unknown OS"
+	for _, token := range userAgentBrowsers {
+		if strings.Contains(userAgent, token[0]) {
+			browser = token[1]
+			break
+		}
+	}
+
+	for _, token := range userAgentSystems {
+		if strings.Contains(userAgent, token[0]) {
+			system = token[1]
+			break
+		}
+	}
+
+	return browser + " on " + system
+}
@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"strings"
+	"testing"
+)
+
+var testDeviceConfig = &config.DeviceConfig{
+	CookieKey: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+}
+
+func TestDeviceService_Identify(t *testing.T) {
+	//// Arrange
+	devices, errInit := service.NewDeviceService(nil, nil, nil, nil, testDeviceConfig)
+	disabled, _ := service.NewDeviceService(nil, nil, nil, nil, &config.DeviceConfig{})
+	_, errKey := service.NewDeviceService(nil, nil, nil, nil, &config.DeviceConfig{CookieKey: "c2hvcnQ="})
+
+	//// Act
+	deviceId, cookie, errNew := devices.Identify("")
+	knownId, knownCookie, _ := devices.Identify(cookie)
+	forgedId, _, _ := devices.Identify(deviceId + ".forged")
+	disabledId, disabledCookie, _ := disabled.Identify(cookie)
+
+	//// Assert
+	assert.Equal(t, errInit, nil)
+	assert.Equal(t, errNew, nil)
+	assert.Equal(t, errKey, service.ErrDeviceCookieKey)
+	assert.Equal(t, strings.HasPrefix(cookie, deviceId+"."), true)
+	// The signed cookie is recognized.
+	assert.Equal(t, knownId, deviceId)
+	assert.Equal(t, knownCookie, cookie)
+	// The forged cookie is replaced by the new device.
+	assert.NotEqual(t, forgedId, deviceId)
+	assert.Equal(t, disabledId, "")
+	assert.Equal(t, disabledCookie, "")
+}
+
+func TestDeviceService_Recognize(t *testing.T) {
+	testTable := []struct {
+		name string
+		// unidentified is the sign-in without the device cookie.
+		unidentified         bool
+		hasDevices           bool
+		created              bool
+		mockBehaviorNotifier func(mockNotifier *mock_service.MockNotifier)
+		expectedNew          bool
+		expectedErr          error
+	}{
+		{
+			name:       "OK, new device is notified about",
+			hasDevices: true,
+			created:    true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification *domain.Notification) error {
+					assert.Equal(t, notification.Type, domain.NotificationNewDevice)
+					assert.Equal(t, notification.Data["device"], "Firefox on Linux")
+					assert.Equal(t, notification.Data["ip"], "192.0.2.1")
+					return nil
+				})
+			},
+			expectedNew: true,
+		},
+		{
+			name:         "OK, unidentified device is notified about",
+			unidentified: true,
+			hasDevices:   true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification *domain.Notification) error {
+					assert.Equal(t, notification.Type, domain.NotificationNewDevice)
+					assert.Equal(t, notification.Data["device"], "Firefox on Linux")
+					return nil
+				})
+			},
+			expectedNew: true,
+		},
+		{
+			name:                 "OK, unidentified first device isn't notified about",
+			unidentified:         true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {},
+		},
+		{
+			name:                 "OK, known device",
+			hasDevices:           true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {},
+		},
+		{
+			name:                 "OK, first device isn't notified about",
+			created:              true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {},
+			expectedNew:          true,
+		},
+		{
+			name:       "BAD, notification failed",
+			hasDevices: true,
+			created:    true,
+			mockBehaviorNotifier: func(mockNotifier *mock_service.MockNotifier) {
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(errors.New("Test error"))
+			},
+			expectedNew: true,
+			expectedErr: service.ErrNotificationFailed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			mockUserRepo.EXPECT().HasDevices(gomock.Any(), uint32(1)).Return(testCase.hasDevices, nil)
+			deviceId := "device"
+			if testCase.unidentified {
+				deviceId = ""
+			} else {
+				mockUserRepo.EXPECT().SaveDevice(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, device *domain.UserDevice) (bool, error) {
+					assert.Equal(t, device.DeviceId, "device")
+					assert.Equal(t, device.UserAgent, "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0")
+					return testCase.created, nil
+				})
+			}
+			mockNotifier := mock_service.NewMockNotifier(ctrl)
+			testCase.mockBehaviorNotifier(mockNotifier)
+			devices, _ := service.NewDeviceService(mockUserRepo, nil, mockNotifier, nil, testDeviceConfig)
+			ctx := domain.WithRequestInfo(context.Background(), &domain.RequestInfo{
+				Ip:        "192.0.2.1",
+				UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0",
+			})
+
+			//// Act
+			details := devices.Describe(ctx, deviceId)
+			isNew, err := devices.Recognize(ctx, &domain.User{Id: 1, Email: "alice@mail.com"}, deviceId, details)
+
+			//// Assert
+			expectedDetails := map[string]string{"device": "Firefox on Linux"}
+			if deviceId != "" {
+				expectedDetails["device_id"] = deviceId
+			}
+			assert.Equal(t, details, expectedDetails)
+			assert.Equal(t, isNew, testCase.expectedNew)
+			assert.Equal(t, errors.Is(err, testCase.expectedErr), true)
+		})
+	}
+}
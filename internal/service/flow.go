@@ -0,0 +1,843 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/pkg/convert_to"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// How long Hydra remembers the login and consent, in seconds.
+	flowRememberFor = 3600
+	// The error of the denied login and consent requests.
+	flowErrAccessDenied     = "access_denied"
+	flowErrAccessDeniedDesc = "The resource owner denied the request"
+	flowErrRiskDesc         = "The sign-in was blocked as too risky"
+	// The error of the login the client asked not to prompt for (prompt=none), but the user has to sign in.
+	flowErrLoginRequired     = "login_required"
+	flowErrLoginRequiredDesc = "The End-User has to sign in"
+	// The second factor of the risky sign-in in the audit log.
+	flowStepUpEmailCode = "email_code"
+)
+
+// ErrLoginSubjectMismatch the remembered user signed in again as another user.
+var ErrLoginSubjectMismatch = errors.New("Sign in to the account that is already signed in")
+
+// ErrConsentScopeNotRequested the user granted the scope the client didn't request.
+var ErrConsentScopeNotRequested = errors.New("The granted scope wasn't requested by the client")
+
+type LoginSubmitInput struct {
+	// Accept is false if the user denied the login.
+	Accept bool
+	// Login is the user's username or email.
+	Login    string
+	Password string
+	Remember bool
+	// DeviceId is the device identified by the device cookie, it's empty if the devices aren't recognized.
+	DeviceId string
+}
+
+type UpstreamLoginStartInput struct {
+	ProviderId string
+	Remember   bool
+	DeviceId   string
+}
+
+type UpstreamLoginSubmitInput struct {
+	// State and Code are the provider's callback parameters, Error is set instead of Code if the sign-in failed.
+	State string
+	Code  string
+	Error string
+}
+
+type IdentityLinkSubmitInput struct {
+	// Token is the identity link's one, Password is the one of the local account the identity is linked to.
+	Token    string
+	Password string
+}
+
+type ConsentSubmitInput struct {
+	// Accept is false if the user denied the access.
+	Accept     bool
+	GrantScope []string
+	Remember   bool
+}
+
+// FlowService handles the Hydra login, consent and logout flows, it's shared by the HTML and JSON handlers.
+// The sign-ins, consents and logouts are audited. The login and consent only find and accept the users
+// of the tenant of the Hydra client.
+type FlowService struct {
+	config   *config.Config
+	oa2      OAuth2
+	user     User
+	rbac     RBAC
+	audit    Audit
+	devices  Devices
+	risk     Risk
+	identity Identity
+	tenants  Tenants
+	groups   Groups
+}
+
+func NewFlowService(config *config.Config, oa2 OAuth2, userService User, rbacService RBAC, auditService Audit, deviceService Devices, riskService Risk, identityService Identity, tenantService Tenants, groupService Groups) *FlowService {
+	return &FlowService{
+		config:   config,
+		oa2:      oa2,
+		user:     userService,
+		rbac:     rbacService,
+		audit:    auditService,
+		devices:  deviceService,
+		risk:     riskService,
+		identity: identityService,
+		tenants:  tenantService,
+		groups:   groupService,
+	}
+}
+
+// GetLoginFlow returns the login flow state, the login is accepted at once if Hydra already authenticated the user
+// unless the client's prompt, max_age or acr_values require the user to sign in again.
+// The login is rejected if the user has to sign in, but the client asked not to prompt for it.
+func (s *FlowService) GetLoginFlow(ctx context.Context, challenge string) (*domain.LoginFlow, error) {
+	loginRequest, err := s.oa2.GetLoginRequest(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, tenant := s.clientTenant(ctx, loginRequest.ClientId)
+	flow := &domain.LoginFlow{
+		Challenge: challenge,
+		UiLocales: loginRequest.UiLocales,
+		Display:   loginRequest.Display,
+		Tenant:    tenant,
+	}
+	// The login_hint prefills the username or email, the unusable hint is dropped.
+	if hint, err := NormalizeLogin(loginRequest.Hint); err == nil {
+		flow.Hint = hint
+	}
+	if acr := loginRequest.RequiredAcr(); acr != domain.AcrRemembered {
+		flow.Acr = acr
+	}
+
+	if loginRequest.Skip && mustReauthenticate(loginRequest, time.Now()) {
+		flow.Reauthenticate = true
+		flow.Subject = loginRequest.Subject
+	}
+
+	if (!loginRequest.Skip || flow.Reauthenticate) && loginRequest.HasPrompt(domain.PromptNone) {
+		if flow.RedirectTo, err = s.oa2.RejectLoginRequest(ctx, challenge, flowErrLoginRequired, flowErrLoginRequiredDesc); err != nil {
+			return nil, err
+		}
+
+		event := &domain.AuditEvent{
+			Action:    domain.AuditActionSignin,
+			UserId:    subjectUserId(loginRequest.Subject),
+			Outcome:   domain.AuditOutcomeDenied,
+			Reason:    flowErrLoginRequiredDesc,
+			ClientId:  loginRequest.ClientId,
+			Challenge: challenge,
+			Data:      map[string]string{"prompt": domain.PromptNone},
+		}
+		if err = recordAudit(ctx, s.audit, event); err != nil {
+			return nil, err
+		}
+
+		return flow, nil
+	}
+
+	// If hydra was already able to authenticate the user, skip will be true, and we do not need to re-authenticate
+	// the user.
+	if loginRequest.Skip && !flow.Reauthenticate {
+		event := &domain.AuditEvent{
+			Action:    domain.AuditActionSignin,
+			ClientId:  loginRequest.ClientId,
+			Challenge: challenge,
+			Data:      s.devices.Describe(ctx, ""),
+		}
+		event.Data["remembered"] = "true"
+
+		// The remembered user may have been suspended or deleted since.
+		userId, err := s.checkSubject(ctx, loginRequest.Subject)
+		if err != nil {
+			if !errors.Is(err, ErrUserSuspended) && !errors.Is(err, ErrUserNotFound) {
+				return nil, err
+			}
+
+			event.UserId = subjectUserId(loginRequest.Subject)
+			event.Outcome = domain.AuditOutcomeDenied
+			event.Reason = err.Error()
+			if flow.RedirectTo, err = s.oa2.RejectLoginRequest(ctx, challenge, flowErrAccessDenied, event.Reason); err != nil {
+				return nil, err
+			}
+
+			if err = recordAudit(ctx, s.audit, event); err != nil {
+				return nil, err
+			}
+
+			return flow, nil
+		}
+
+		if flow.RedirectTo, err = s.oa2.AcceptLoginRequest(ctx, challenge, loginRequest.Subject, true, flowRememberFor, domain.AcrRemembered); err != nil {
+			return nil, err
+		}
+
+		event.ActorId = &userId
+		event.UserId = &userId
+		event.Data["acr"] = domain.AcrRemembered
+		if err = recordAudit(ctx, s.audit, event); err != nil {
+			return nil, err
+		}
+
+		// The remembered login is the user's sign-in too.
+		if err = s.user.UpdateLastOnline(ctx, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	return flow, nil
+}
+
+// SubmitLogin checks the user's credentials and the sign-in's risk and completes the login, returns the URL to redirect the user to.
+// The high risk login is rejected. The medium risk one and the one requiring the multi-factor ACR wait for the one-time code
+// sent to the user, ErrStepUpRequired is returned. The remembered user signing in again must be the same user.
+// The user is notified about the sign-in from the new device, the login is completed even if ErrNotificationFailed is returned.
+func (s *FlowService) SubmitLogin(ctx context.Context, challenge string, input *LoginSubmitInput) (string, error) {
+	// Check the login request is still valid.
+	loginRequest, err := s.oa2.GetLoginRequest(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, _ = s.clientTenant(ctx, loginRequest.ClientId)
+	event := &domain.AuditEvent{
+		Action:    domain.AuditActionSignin,
+		ClientId:  loginRequest.ClientId,
+		Challenge: challenge,
+	}
+
+	if !input.Accept {
+		redirectTo, err := s.oa2.RejectLoginRequest(ctx, challenge, flowErrAccessDenied, flowErrAccessDeniedDesc)
+		if err != nil {
+			return "", err
+		}
+
+		event.Outcome = domain.AuditOutcomeDenied
+		event.Reason = flowErrAccessDeniedDesc
+
+		return redirectTo, recordAudit(ctx, s.audit, event)
+	}
+
+	event.Data = s.devices.Describe(ctx, input.DeviceId)
+	user, err := s.user.SignIn(ctx, &UserSignInInput{
+		Login:    input.Login,
+		Password: input.Password,
+	})
+	if err != nil {
+		// The failure is in the user's login history unless the login is unknown.
+		if user != nil {
+			event.UserId = &user.Id
+		}
+
+		event.Outcome = domain.AuditOutcomeFailure
+		event.Reason = err.Error()
+		event.Data["login"] = input.Login
+		if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+			return "", auditErr
+		}
+
+		return "", err
+	}
+
+	return s.completeLogin(ctx, challenge, loginRequest, user, input.Remember, input.DeviceId, event)
+}
+
+// completeLogin checks the signed in user's sign-in and completes the login, returns the URL to redirect the user to.
+func (s *FlowService) completeLogin(ctx context.Context, challenge string, loginRequest *domain.OA2LoginRequest, user *domain.User, remember bool, deviceId string, event *domain.AuditEvent) (string, error) {
+	// The remembered user is asked to sign in again by prompt, max_age or acr_values, Hydra rejects another subject.
+	if loginRequest.Skip && loginRequest.Subject != convert_to.ToString(user.Id) {
+		event.UserId = &user.Id
+		event.Outcome = domain.AuditOutcomeFailure
+		event.Reason = ErrLoginSubjectMismatch.Error()
+		if err := recordAudit(ctx, s.audit, event); err != nil {
+			return "", err
+		}
+
+		return "", ErrLoginSubjectMismatch
+	}
+
+	assessment, err := s.risk.Assess(ctx, user, deviceId)
+	if err != nil {
+		return "", err
+	}
+
+	stepUp := !domain.AcrSatisfies(domain.AcrPassword, loginRequest.RequiredAcr())
+	if stepUp {
+		event.Data["acr_values"] = strings.Join(loginRequest.AcrValues, " ")
+	}
+
+	if assessment != nil {
+		for key, value := range assessment.AuditData() {
+			event.Data[key] = value
+		}
+
+		if assessment.Level == domain.RiskLevelHigh {
+			redirectTo, err := s.oa2.RejectLoginRequest(ctx, challenge, flowErrAccessDenied, flowErrRiskDesc)
+			if err != nil {
+				return "", err
+			}
+
+			event.ActorId = &user.Id
+			event.UserId = &user.Id
+			event.Outcome = domain.AuditOutcomeDenied
+			event.Reason = flowErrRiskDesc
+
+			return redirectTo, recordAudit(ctx, s.audit, event)
+		}
+
+		stepUp = stepUp || assessment.Level == domain.RiskLevelMedium
+	}
+
+	if stepUp {
+		if err = s.risk.StartStepUp(ctx, challenge, user, remember, deviceId); err != nil {
+			return "", err
+		}
+
+		event.ActorId = &user.Id
+		event.UserId = &user.Id
+		event.Outcome = domain.AuditOutcomeChallenged
+		event.Reason = ErrStepUpRequired.Error()
+		event.Data["step_up"] = flowStepUpEmailCode
+		if err = recordAudit(ctx, s.audit, event); err != nil {
+			return "", err
+		}
+
+		return "", ErrStepUpRequired
+	}
+
+	return s.acceptLogin(ctx, challenge, user, remember, deviceId, domain.AcrPassword, event)
+}
+
+// StartUpstreamLogin starts the login with the upstream identity provider, returns the provider's authorization URL
+// to redirect the user to and the state to keep in the user's browser.
+func (s *FlowService) StartUpstreamLogin(ctx context.Context, challenge string, input *UpstreamLoginStartInput) (string, string, error) {
+	// Check the login request is still valid.
+	loginRequest, err := s.oa2.GetLoginRequest(ctx, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The provider's callback signs in to the client's tenant.
+	ctx, _ = s.clientTenant(ctx, loginRequest.ClientId)
+
+	return s.identity.StartLogin(ctx, input.ProviderId, challenge, input.Remember, input.DeviceId)
+}
+
+// SubmitUpstreamLogin completes the login with the provider's callback like SubmitLogin does with the password.
+// The flow has the login's challenge even with the errors unless the callback is unknown, so the user signs in again.
+// The identity with the email of the existing account waits for its password, ErrIdentityLinkRequired is returned
+// with the flow's link. The linking callback returns to the account page.
+func (s *FlowService) SubmitUpstreamLogin(ctx context.Context, input *UpstreamLoginSubmitInput) (*domain.LoginFlow, error) {
+	result, err := s.identity.FinishLogin(ctx, input.State, input.Code, input.Error)
+	if result == nil {
+		return nil, err
+	}
+
+	login, user := result.Login, result.User
+	if login.UserId != nil {
+		if err != nil {
+			return nil, err
+		}
+
+		return &domain.LoginFlow{RedirectTo: PathAccountIdentities}, nil
+	}
+
+	flow := &domain.LoginFlow{Challenge: login.Challenge, Link: result.Link}
+	loginRequest, requestErr := s.oa2.GetLoginRequest(ctx, login.Challenge)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	ctx, flow.Tenant = s.clientTenant(ctx, loginRequest.ClientId)
+
+	event := &domain.AuditEvent{
+		Action:    domain.AuditActionSignin,
+		ClientId:  loginRequest.ClientId,
+		Challenge: login.Challenge,
+		Data:      s.devices.Describe(ctx, login.DeviceId),
+	}
+	event.Data["provider"] = login.Provider
+
+	if err == nil && user.IsSuspended(time.Now()) {
+		event.UserId = &user.Id
+		err = ErrUserSuspended
+	}
+
+	if err != nil {
+		event.Outcome = domain.AuditOutcomeFailure
+		if errors.Is(err, ErrIdentityLinkRequired) {
+			event.Outcome = domain.AuditOutcomeChallenged
+		}
+
+		event.Reason = err.Error()
+		if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+			return nil, auditErr
+		}
+
+		return flow, err
+	}
+
+	flow.RedirectTo, err = s.completeLogin(ctx, login.Challenge, loginRequest, user, login.Remember, login.DeviceId, event)
+
+	return flow, err
+}
+
+// SubmitIdentityLink checks the password of the account the identity waits for, links the identity
+// and completes the login like SubmitUpstreamLogin does. The flow has the login's challenge and the link even with the errors
+// unless the link is expired, so the user enters the password again.
+func (s *FlowService) SubmitIdentityLink(ctx context.Context, input *IdentityLinkSubmitInput) (*domain.LoginFlow, error) {
+	link, err := s.identity.GetLink(ctx, input.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	flow := &domain.LoginFlow{
+		Challenge: link.Challenge,
+		Link: &domain.PendingLink{
+			Token:    input.Token,
+			Provider: link.Provider,
+			Email:    link.Email,
+		},
+	}
+	loginRequest, err := s.oa2.GetLoginRequest(ctx, link.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, flow.Tenant = s.clientTenant(ctx, loginRequest.ClientId)
+
+	event := &domain.AuditEvent{
+		Action:    domain.AuditActionSignin,
+		ClientId:  loginRequest.ClientId,
+		Challenge: link.Challenge,
+		UserId:    &link.UserId,
+		Data:      s.devices.Describe(ctx, link.DeviceId),
+	}
+	event.Data["provider"] = link.Provider
+
+	user, err := s.user.GetUserById(ctx, link.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The password proves the ownership of the account with the provider's email.
+	user, err = s.user.SignIn(ctx, &UserSignInInput{
+		Login:    user.Email,
+		Password: input.Password,
+	})
+	if err == nil {
+		_, err = s.identity.ConfirmLink(ctx, input.Token, user.Id)
+	}
+
+	if err != nil {
+		event.Outcome = domain.AuditOutcomeFailure
+		event.Reason = err.Error()
+		if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+			return nil, auditErr
+		}
+
+		return flow, err
+	}
+
+	flow.Link = nil
+	flow.RedirectTo, err = s.completeLogin(ctx, link.Challenge, loginRequest, user, link.Remember, link.DeviceId, event)
+
+	return flow, err
+}
+
+// SubmitLoginCode checks the one-time code of the risky sign-in and completes the login, returns the URL to redirect the user to.
+// ErrStepUpExpired is returned if the user has to sign in again.
+func (s *FlowService) SubmitLoginCode(ctx context.Context, challenge string, code string) (string, error) {
+	// Check the login request is still valid.
+	loginRequest, err := s.oa2.GetLoginRequest(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, _ = s.clientTenant(ctx, loginRequest.ClientId)
+	event := &domain.AuditEvent{
+		Action:    domain.AuditActionSignin,
+		ClientId:  loginRequest.ClientId,
+		Challenge: challenge,
+	}
+
+	stepUp, err := s.risk.VerifyStepUp(ctx, challenge, code)
+	if err != nil {
+		if stepUp == nil {
+			return "", err
+		}
+
+		event.UserId = &stepUp.UserId
+		event.Outcome = domain.AuditOutcomeFailure
+		event.Reason = err.Error()
+		event.Data = s.devices.Describe(ctx, stepUp.DeviceId)
+		event.Data["step_up"] = flowStepUpEmailCode
+		if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+			return "", auditErr
+		}
+
+		return "", err
+	}
+
+	// The user may have been suspended or deleted since the code was sent.
+	user, err := s.user.GetUserById(ctx, stepUp.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	event.Data = s.devices.Describe(ctx, stepUp.DeviceId)
+	event.Data["step_up"] = flowStepUpEmailCode
+	if user.IsSuspended(time.Now()) {
+		event.UserId = &user.Id
+		event.Outcome = domain.AuditOutcomeDenied
+		event.Reason = ErrUserSuspended.Error()
+		if err = recordAudit(ctx, s.audit, event); err != nil {
+			return "", err
+		}
+
+		return "", ErrUserSuspended
+	}
+
+	return s.acceptLogin(ctx, challenge, user, stepUp.Remember, stepUp.DeviceId, domain.AcrMultiFactor, event)
+}
+
+// acceptLogin completes the user's login at the ACR level, remembers the device and audits the successful sign-in.
+func (s *FlowService) acceptLogin(ctx context.Context, challenge string, user *domain.User, remember bool, deviceId string, acr string, event *domain.AuditEvent) (string, error) {
+	redirectTo, err := s.oa2.AcceptLoginRequest(ctx, challenge, convert_to.ToString(user.Id), remember, flowRememberFor, acr)
+	if err != nil {
+		return "", err
+	}
+
+	newDevice, recognizeErr := s.devices.Recognize(ctx, user, deviceId, event.Data)
+	if recognizeErr != nil && !errors.Is(recognizeErr, ErrNotificationFailed) {
+		return "", recognizeErr
+	}
+
+	event.ActorId = &user.Id
+	event.UserId = &user.Id
+	event.Data["new_device"] = strconv.FormatBool(newDevice)
+	event.Data["acr"] = acr
+	if err = recordAudit(ctx, s.audit, event); err != nil {
+		return "", err
+	}
+
+	return redirectTo, recognizeErr
+}
+
+// GetConsentFlow returns the consent flow state, the consent is accepted at once if the user already granted it.
+// The consent of the user who isn't the active user of the client's tenant is rejected.
+func (s *FlowService) GetConsentFlow(ctx context.Context, challenge string) (*domain.ConsentFlow, error) {
+	consentRequest, err := s.oa2.GetConsentRequest(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, tenant := s.clientTenant(ctx, consentRequest.ClientId)
+	flow := &domain.ConsentFlow{
+		Challenge:      challenge,
+		Subject:        consentRequest.Subject,
+		RequestedScope: consentRequest.RequestedScope,
+		Tenant:         tenant,
+	}
+	// The client's data is only displayed.
+	_ = json.Unmarshal(consentRequest.ClientData, &flow.Client)
+
+	if flow.RedirectTo, err = s.checkConsentSubject(ctx, challenge, consentRequest); err != nil || flow.RedirectTo != "" {
+		return flow, err
+	}
+
+	if consentRequest.Skip {
+		// We can grant all scopes that have been requested - hydra already checked for us that no additional scopes
+		// are requested accidentally.
+		session, err := s.consentSession(ctx, consentRequest.Subject, consentRequest.RequestedScope)
+		if err != nil {
+			return nil, err
+		}
+
+		flow.RedirectTo, err = s.oa2.AcceptConsentRequest(ctx, challenge, consentRequest.RequestedScope, consentRequest.RequestedAccessTokenAudience, session, true, flowRememberFor)
+		if err != nil {
+			return nil, err
+		}
+
+		event := s.consentEvent(challenge, consentRequest, consentRequest.RequestedScope)
+		event.Data["remembered"] = "true"
+		if err = recordAudit(ctx, s.audit, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return flow, nil
+}
+
+// SubmitConsent completes the consent, returns the URL to redirect the user to.
+func (s *FlowService) SubmitConsent(ctx context.Context, challenge string, input *ConsentSubmitInput) (string, error) {
+	consentRequest, err := s.oa2.GetConsentRequest(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, _ = s.clientTenant(ctx, consentRequest.ClientId)
+	if !input.Accept {
+		redirectTo, err := s.oa2.RejectConsentRequest(ctx, challenge, flowErrAccessDenied, flowErrAccessDeniedDesc)
+		if err != nil {
+			return "", err
+		}
+
+		event := s.consentEvent(challenge, consentRequest, nil)
+		event.Outcome = domain.AuditOutcomeDenied
+		event.Reason = flowErrAccessDeniedDesc
+
+		return redirectTo, recordAudit(ctx, s.audit, event)
+	}
+
+	if redirectTo, err := s.checkConsentSubject(ctx, challenge, consentRequest); err != nil || redirectTo != "" {
+		return redirectTo, err
+	}
+
+	if !requestedScopes(consentRequest.RequestedScope, input.GrantScope) {
+		return "", ErrConsentScopeNotRequested
+	}
+
+	session, err := s.consentSession(ctx, consentRequest.Subject, input.GrantScope)
+	if err != nil {
+		return "", err
+	}
+
+	redirectTo, err := s.oa2.AcceptConsentRequest(ctx, challenge, input.GrantScope, consentRequest.RequestedAccessTokenAudience, session, input.Remember, flowRememberFor)
+	if err != nil {
+		return "", err
+	}
+
+	return redirectTo, recordAudit(ctx, s.audit, s.consentEvent(challenge, consentRequest, input.GrantScope))
+}
+
+// requestedScopes reports whether all the granted scopes are the requested ones, the user grants a part of them at most.
+func requestedScopes(requestedScope []string, grantScope []string) bool {
+	requested := make(map[string]bool, len(requestedScope))
+	for _, scope := range requestedScope {
+		requested[scope] = true
+	}
+
+	for _, scope := range grantScope {
+		if !requested[scope] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *FlowService) GetLogoutFlow(ctx context.Context, challenge string) (*domain.LogoutFlow, error) {
+	logoutRequest, err := s.oa2.GetLogoutRequest(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LogoutFlow{
+		Challenge:   challenge,
+		Subject:     logoutRequest.Subject,
+		RpInitiated: logoutRequest.RpInitiated,
+	}, nil
+}
+
+// SubmitLogout completes the logout, returns the URL to redirect the user to.
+// The URL is empty if the user denied the logout.
+func (s *FlowService) SubmitLogout(ctx context.Context, challenge string, accept bool) (string, error) {
+	logoutRequest, err := s.oa2.GetLogoutRequest(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	userId := subjectUserId(logoutRequest.Subject)
+	event := &domain.AuditEvent{
+		Action:    domain.AuditActionLogout,
+		ActorId:   userId,
+		UserId:    userId,
+		ClientId:  logoutRequest.ClientId,
+		Challenge: challenge,
+	}
+
+	var redirectTo string
+	if accept {
+		if redirectTo, err = s.oa2.AcceptLogoutRequest(ctx, challenge); err != nil {
+			return "", err
+		}
+	} else {
+		if err = s.oa2.RejectLogoutRequest(ctx, challenge); err != nil {
+			return "", err
+		}
+
+		event.Outcome = domain.AuditOutcomeDenied
+	}
+
+	return redirectTo, recordAudit(ctx, s.audit, event)
+}
+
+// mustReauthenticate reports whether the remembered user has to sign in again: the client asked for it by prompt=login,
+// the user authenticated longer than max_age ago or the remembered sign-in is weaker than the required ACR.
+func mustReauthenticate(loginRequest *domain.OA2LoginRequest, now time.Time) bool {
+	if loginRequest.HasPrompt(domain.PromptLogin) {
+		return true
+	}
+
+	if maxAge, ok := loginRequest.MaxAge(); ok {
+		if maxAge == 0 {
+			return true
+		}
+
+		// Hydra checks max_age against its own session too, the ID token hint tells the time if it's given.
+		if authTime, ok := loginRequest.AuthTime(); ok && now.Sub(authTime) > maxAge {
+			return true
+		}
+	}
+
+	return !domain.AcrSatisfies(domain.AcrRemembered, loginRequest.RequiredAcr())
+}
+
+// checkSubject checks the subject's user still exists and isn't suspended, returns the user's id.
+func (s *FlowService) checkSubject(ctx context.Context, subject string) (uint32, error) {
+	userId, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	user, err := s.user.GetUserById(ctx, uint32(userId))
+	if err != nil {
+		return 0, err
+	}
+
+	if user.IsSuspended(time.Now()) {
+		return 0, ErrUserSuspended
+	}
+
+	return user.Id, nil
+}
+
+// clientTenant returns the copy of the context with the tenant of the Hydra client and the tenant,
+// the users of the other tenants aren't found in it.
+func (s *FlowService) clientTenant(ctx context.Context, clientId string) (context.Context, *domain.Tenant) {
+	tenant := s.tenants.ClientTenant(clientId)
+
+	return domain.WithTenant(ctx, tenant), tenant
+}
+
+// checkConsentSubject rejects the consent if the subject isn't the active user of the context's tenant,
+// e.g. Hydra remembers the user signed in to another tenant's client. Returns the URL to redirect the user to,
+// it's empty if the consent can go on.
+func (s *FlowService) checkConsentSubject(ctx context.Context, challenge string, consentRequest *domain.OA2ConsentRequest) (string, error) {
+	_, err := s.checkSubject(ctx, consentRequest.Subject)
+	if err == nil {
+		return "", nil
+	}
+
+	if !errors.Is(err, ErrUserSuspended) && !errors.Is(err, ErrUserNotFound) {
+		return "", err
+	}
+
+	redirectTo, rejectErr := s.oa2.RejectConsentRequest(ctx, challenge, flowErrAccessDenied, err.Error())
+	if rejectErr != nil {
+		return "", rejectErr
+	}
+
+	event := s.consentEvent(challenge, consentRequest, nil)
+	event.Outcome = domain.AuditOutcomeDenied
+	event.Reason = err.Error()
+
+	return redirectTo, recordAudit(ctx, s.audit, event)
+}
+
+// consentEvent returns the audit event of the consent granting the scope.
+func (s *FlowService) consentEvent(challenge string, consentRequest *domain.OA2ConsentRequest, grantScope []string) *domain.AuditEvent {
+	userId := subjectUserId(consentRequest.Subject)
+
+	return &domain.AuditEvent{
+		Action:    domain.AuditActionConsent,
+		ActorId:   userId,
+		UserId:    userId,
+		ClientId:  consentRequest.ClientId,
+		Challenge: challenge,
+		Data:      map[string]string{"scope": strings.Join(grantScope, " ")},
+	}
+}
+
+// subjectUserId returns the id of the subject's user, it's nil if the subject isn't a user's id.
+func subjectUserId(subject string) *uint32 {
+	userId, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return nil
+	}
+
+	id := uint32(userId)
+
+	return &id
+}
+
+// consentSession returns the session data for the tokens issued to the subject: the roles if the tenant's tokens
+// have the roles claim and the groups if the "groups" scope is granted.
+func (s *FlowService) consentSession(ctx context.Context, subject string, grantScope []string) (*domain.OA2ConsentSession, error) {
+	session := &domain.OA2ConsentSession{}
+	withRoles := rolesClaim(ctx, &s.config.OAuth2)
+	withGroups := hasScope(grantScope, domain.ScopeGroups)
+	if !withRoles && !withGroups {
+		return session, nil
+	}
+
+	userId, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if withRoles {
+		roles, err := s.rbac.GetUserRoles(ctx, uint32(userId))
+		if err != nil {
+			return nil, err
+		}
+
+		if roles == nil {
+			roles = []string{}
+		}
+
+		claims["roles"] = roles
+	}
+
+	if withGroups {
+		groups, err := s.groups.ClaimGroups(ctx, uint32(userId))
+		if err != nil {
+			return nil, err
+		}
+
+		claims["groups"] = groups
+	}
+
+	// The claims are available when introspecting the access token and in the ID token.
+	session.AccessToken = claims
+	session.IdToken = claims
+
+	return session, nil
+}
+
+// hasScope reports whether the scope is granted.
+func hasScope(grantScope []string, scope string) bool {
+	for _, granted := range grantScope {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,499 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestFlowService_GetLoginFlow(t *testing.T) {
+	const authUrl = "http://127.0.0.1:4444/oauth2/auth?client_id=client"
+
+	tenants := service.NewTenantService(&config.Config{})
+	tenant, _ := tenants.Get(domain.DefaultTenantId)
+
+	testTable := []struct {
+		name               string
+		loginRequest       *domain.OA2LoginRequest
+		mockBehaviorOAuth2 func(mockOAuth2 *mock_service.MockOAuth2)
+		expectedFlow       *domain.LoginFlow
+	}{
+		{
+			name:         "OK, remembered user",
+			loginRequest: &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().AcceptLoginRequest(gomock.Any(), "challenge", "1", true, gomock.Any(), domain.AcrRemembered).Return("redirectTo", nil)
+			},
+			expectedFlow: &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, RedirectTo: "redirectTo"},
+		},
+		{
+			name:               "OK, prompt=login",
+			loginRequest:       &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl + "&prompt=login+consent"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Reauthenticate: true, Subject: "1"},
+		},
+		{
+			name:               "OK, max_age=0",
+			loginRequest:       &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl + "&max_age=0"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Reauthenticate: true, Subject: "1"},
+		},
+		{
+			name: "OK, max_age is exceeded",
+			loginRequest: &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl + "&max_age=300",
+				IdTokenHintClaims: map[string]interface{}{"auth_time": float64(time.Now().Add(-time.Hour).Unix())}},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Reauthenticate: true, Subject: "1"},
+		},
+		{
+			name:               "OK, multi-factor ACR is required",
+			loginRequest:       &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl, AcrValues: []string{"urn:unknown", domain.AcrMultiFactor}},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Reauthenticate: true, Subject: "1", Acr: domain.AcrMultiFactor},
+		},
+		{
+			name:               "OK, not remembered user",
+			loginRequest:       &domain.OA2LoginRequest{RequestUrl: authUrl, UiLocales: []string{"de", "en"}, Display: "popup"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, UiLocales: []string{"de", "en"}, Display: "popup"},
+		},
+		{
+			name:         "DENIED, prompt=none requires login",
+			loginRequest: &domain.OA2LoginRequest{Skip: true, Subject: "1", RequestUrl: authUrl + "&prompt=none&max_age=0"},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().RejectLoginRequest(gomock.Any(), "challenge", "login_required", gomock.Any()).Return("redirectTo", nil)
+			},
+			expectedFlow: &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Reauthenticate: true, Subject: "1", RedirectTo: "redirectTo"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(testCase.loginRequest, nil)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockUser := mock_service.NewMockUser(ctrl)
+			mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1}, nil).AnyTimes()
+			mockUser.EXPECT().UpdateLastOnline(gomock.Any(), uint32(1)).Return(nil).AnyTimes()
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "").Return(map[string]string{}).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, mockDevices, nil, nil, tenants, nil)
+
+			//// Act
+			loginFlow, err := flow.GetLoginFlow(context.Background(), "challenge")
+
+			//// Assert
+			assert.Equal(t, err, nil)
+			assert.Equal(t, loginFlow, testCase.expectedFlow)
+		})
+	}
+}
+
+func TestFlowService_SubmitLoginReauthentication(t *testing.T) {
+	testTable := []struct {
+		name         string
+		loginRequest *domain.OA2LoginRequest
+		expectedErr  error
+	}{
+		{
+			name:         "BAD, another user signed in",
+			loginRequest: &domain.OA2LoginRequest{Skip: true, Subject: "2", RequestUrl: "http://127.0.0.1:4444/oauth2/auth?prompt=login"},
+			expectedErr:  service.ErrLoginSubjectMismatch,
+		},
+		{
+			name:         "CHALLENGED, multi-factor ACR is required",
+			loginRequest: &domain.OA2LoginRequest{AcrValues: []string{domain.AcrMultiFactor}},
+			expectedErr:  service.ErrStepUpRequired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(testCase.loginRequest, nil)
+			mockUser := mock_service.NewMockUser(ctrl)
+			mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, nil)
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			})
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "").Return(map[string]string{})
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			mockRisk.EXPECT().Assess(gomock.Any(), gomock.Any(), "").Return(nil, nil).AnyTimes()
+			mockRisk.EXPECT().StartStepUp(gomock.Any(), "challenge", gomock.Any(), false, "").Return(nil).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, mockDevices, mockRisk, nil, service.NewTenantService(&config.Config{}), nil)
+
+			//// Act
+			_, err := flow.SubmitLogin(context.Background(), "challenge", &service.LoginSubmitInput{Accept: true, Login: "foo@bar.com"})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, recorded.Reason, testCase.expectedErr.Error())
+			assert.Equal(t, *recorded.UserId, uint32(1))
+		})
+	}
+}
+
+func TestFlowService_SubmitUpstreamLogin(t *testing.T) {
+	upstreamLogin := &domain.UpstreamLogin{Provider: "keycloak", Challenge: "challenge", Remember: true, DeviceId: "device"}
+	userId := uint32(1)
+	pendingLink := &domain.PendingLink{Token: "token", Provider: "keycloak", Email: "alice@mail.com"}
+
+	tenants := service.NewTenantService(&config.Config{})
+	tenant, _ := tenants.Get(domain.DefaultTenantId)
+
+	testTable := []struct {
+		name                 string
+		result               *service.UpstreamLoginResult
+		identityErr          error
+		mockBehaviorOAuth2   func(mockOAuth2 *mock_service.MockOAuth2)
+		expectedFlow         *domain.LoginFlow
+		expectedErr          error
+		expectedAuditOutcome string
+	}{
+		{
+			name:   "OK",
+			result: &service.UpstreamLoginResult{Login: upstreamLogin, User: &domain.User{Id: 1}},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+				mockOAuth2.EXPECT().AcceptLoginRequest(gomock.Any(), "challenge", "1", true, int64(3600), domain.AcrPassword).Return("redirectTo", nil)
+			},
+			expectedFlow:         &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, RedirectTo: "redirectTo"},
+			expectedAuditOutcome: "",
+		},
+		{
+			name:        "OK, email of local account waits for password",
+			result:      &service.UpstreamLoginResult{Login: upstreamLogin, Link: pendingLink},
+			identityErr: service.ErrIdentityLinkRequired,
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+			},
+			expectedFlow:         &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Link: pendingLink},
+			expectedErr:          service.ErrIdentityLinkRequired,
+			expectedAuditOutcome: domain.AuditOutcomeChallenged,
+		},
+		{
+			name:               "OK, identity linked to signed in user",
+			result:             &service.UpstreamLoginResult{Login: &domain.UpstreamLogin{Provider: "keycloak", UserId: &userId}, User: &domain.User{Id: 1}},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedFlow:       &domain.LoginFlow{RedirectTo: service.PathAccountIdentities},
+		},
+		{
+			name:        "BAD, email of local account without password",
+			result:      &service.UpstreamLoginResult{Login: upstreamLogin},
+			identityErr: service.ErrIdentityEmailTaken,
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+			},
+			expectedFlow:         &domain.LoginFlow{Challenge: "challenge", Tenant: tenant},
+			expectedErr:          service.ErrIdentityEmailTaken,
+			expectedAuditOutcome: domain.AuditOutcomeFailure,
+		},
+		{
+			name:               "BAD, unknown state",
+			identityErr:        service.ErrUpstreamLoginExpired,
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedErr:        service.ErrUpstreamLoginExpired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockIdentity := mock_service.NewMockIdentity(ctrl)
+			mockIdentity.EXPECT().FinishLogin(gomock.Any(), "state", "code", "").Return(testCase.result, testCase.identityErr)
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			}).AnyTimes()
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "device").Return(map[string]string{}).AnyTimes()
+			mockDevices.EXPECT().Recognize(gomock.Any(), gomock.Any(), "device", gomock.Any()).Return(false, nil).AnyTimes()
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			mockRisk.EXPECT().Assess(gomock.Any(), gomock.Any(), "device").Return(nil, nil).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, nil, nil, mockAudit, mockDevices, mockRisk, mockIdentity, tenants, nil)
+
+			//// Act
+			loginFlow, err := flow.SubmitUpstreamLogin(context.Background(), &service.UpstreamLoginSubmitInput{State: "state", Code: "code"})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, loginFlow, testCase.expectedFlow)
+			if testCase.result != nil && testCase.result.Login.UserId == nil {
+				assert.Equal(t, recorded.Outcome, testCase.expectedAuditOutcome)
+				assert.Equal(t, recorded.Data["provider"], "keycloak")
+			}
+		})
+	}
+}
+
+func TestFlowService_SubmitIdentityLink(t *testing.T) {
+	link := &domain.IdentityLink{Challenge: "challenge", UserId: 1, Provider: "keycloak", Email: "alice@mail.com", Remember: true, DeviceId: "device"}
+	pendingLink := &domain.PendingLink{Token: "token", Provider: "keycloak", Email: "alice@mail.com"}
+
+	tenants := service.NewTenantService(&config.Config{})
+	tenant, _ := tenants.Get(domain.DefaultTenantId)
+
+	testTable := []struct {
+		name                 string
+		mockBehaviorUser     func(mockUser *mock_service.MockUser)
+		mockBehaviorIdentity func(mockIdentity *mock_service.MockIdentity)
+		mockBehaviorOAuth2   func(mockOAuth2 *mock_service.MockOAuth2)
+		expectedFlow         *domain.LoginFlow
+		expectedErr          error
+		expectedAuditOutcome string
+	}{
+		{
+			name: "OK",
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "alice@mail.com"}, nil)
+				mockUser.EXPECT().SignIn(gomock.Any(), &service.UserSignInInput{Login: "alice@mail.com", Password: "password"}).
+					Return(&domain.User{Id: 1, Email: "alice@mail.com"}, nil)
+			},
+			mockBehaviorIdentity: func(mockIdentity *mock_service.MockIdentity) {
+				mockIdentity.EXPECT().GetLink(gomock.Any(), "token").Return(link, nil)
+				mockIdentity.EXPECT().ConfirmLink(gomock.Any(), "token", uint32(1)).Return(link, nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+				mockOAuth2.EXPECT().AcceptLoginRequest(gomock.Any(), "challenge", "1", true, int64(3600), domain.AcrPassword).Return("redirectTo", nil)
+			},
+			expectedFlow:         &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, RedirectTo: "redirectTo"},
+			expectedAuditOutcome: "",
+		},
+		{
+			name: "BAD, wrong password",
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+				mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Email: "alice@mail.com"}, nil)
+				mockUser.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&domain.User{Id: 1}, service.ErrPasswordIncorrect)
+			},
+			mockBehaviorIdentity: func(mockIdentity *mock_service.MockIdentity) {
+				mockIdentity.EXPECT().GetLink(gomock.Any(), "token").Return(link, nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().GetLoginRequest(gomock.Any(), "challenge").Return(&domain.OA2LoginRequest{ClientId: "client"}, nil)
+			},
+			expectedFlow:         &domain.LoginFlow{Challenge: "challenge", Tenant: tenant, Link: pendingLink},
+			expectedErr:          service.ErrPasswordIncorrect,
+			expectedAuditOutcome: domain.AuditOutcomeFailure,
+		},
+		{
+			name:             "BAD, expired link",
+			mockBehaviorUser: func(mockUser *mock_service.MockUser) {},
+			mockBehaviorIdentity: func(mockIdentity *mock_service.MockIdentity) {
+				mockIdentity.EXPECT().GetLink(gomock.Any(), "token").Return(nil, service.ErrIdentityLinkExpired)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			expectedErr:        service.ErrIdentityLinkExpired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockUser := mock_service.NewMockUser(ctrl)
+			testCase.mockBehaviorUser(mockUser)
+			mockIdentity := mock_service.NewMockIdentity(ctrl)
+			testCase.mockBehaviorIdentity(mockIdentity)
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			}).AnyTimes()
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Describe(gomock.Any(), "device").Return(map[string]string{}).AnyTimes()
+			mockDevices.EXPECT().Recognize(gomock.Any(), gomock.Any(), "device", gomock.Any()).Return(false, nil).AnyTimes()
+			mockRisk := mock_service.NewMockRisk(ctrl)
+			mockRisk.EXPECT().Assess(gomock.Any(), gomock.Any(), "device").Return(nil, nil).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, mockDevices, mockRisk, mockIdentity, tenants, nil)
+
+			//// Act
+			loginFlow, err := flow.SubmitIdentityLink(context.Background(), &service.IdentityLinkSubmitInput{Token: "token", Password: "password"})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, loginFlow, testCase.expectedFlow)
+			if testCase.expectedFlow != nil {
+				assert.Equal(t, recorded.Outcome, testCase.expectedAuditOutcome)
+				assert.Equal(t, recorded.Data["provider"], "keycloak")
+			}
+		})
+	}
+}
+
+func TestFlowService_GetConsentFlow(t *testing.T) {
+	tenants := service.NewTenantService(&config.Config{Tenants: []config.TenantConfig{{Id: "acme", Clients: []string{"acme-web"}}}})
+	acme, _ := tenants.Get("acme")
+
+	testTable := []struct {
+		name                 string
+		userErr              error
+		mockBehaviorOAuth2   func(mockOAuth2 *mock_service.MockOAuth2)
+		expectedFlow         *domain.ConsentFlow
+		expectedAuditOutcome string
+	}{
+		{
+			name: "OK, user of the client's tenant",
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedFlow: &domain.ConsentFlow{Challenge: "challenge", Subject: "1", Tenant: acme},
+		},
+		{
+			name:    "DENIED, user of another tenant",
+			userErr: service.ErrUserNotFound,
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().RejectConsentRequest(gomock.Any(), "challenge", "access_denied", service.ErrUserNotFound.Error()).Return("rejectedTo", nil)
+			},
+			expectedFlow:         &domain.ConsentFlow{Challenge: "challenge", Subject: "1", Tenant: acme, RedirectTo: "rejectedTo"},
+			expectedAuditOutcome: domain.AuditOutcomeDenied,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetConsentRequest(gomock.Any(), "challenge").Return(&domain.OA2ConsentRequest{Subject: "1", ClientId: "acme-web"}, nil)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			var userTenant string
+			mockUser := mock_service.NewMockUser(ctrl)
+			mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).DoAndReturn(func(ctx context.Context, id uint32) (*domain.User, error) {
+				userTenant = domain.TenantId(ctx)
+				if testCase.userErr != nil {
+					return nil, testCase.userErr
+				}
+
+				return &domain.User{Id: id, TenantId: userTenant}, nil
+			})
+			var recorded *domain.AuditEvent
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			}).AnyTimes()
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, nil, nil, nil, tenants, nil)
+
+			//// Act
+			consentFlow, err := flow.GetConsentFlow(context.Background(), "challenge")
+
+			//// Assert
+			assert.Equal(t, err, nil)
+			assert.Equal(t, consentFlow, testCase.expectedFlow)
+			assert.Equal(t, userTenant, "acme")
+			if testCase.expectedAuditOutcome != "" {
+				assert.Equal(t, recorded.Outcome, testCase.expectedAuditOutcome)
+			}
+		})
+	}
+}
+
+func TestFlowService_SubmitConsent(t *testing.T) {
+	testTable := []struct {
+		name                string
+		grantScope          []string
+		mockBehaviorGroups  func(mockGroups *mock_service.MockGroups)
+		expectedAccessToken map[string]interface{}
+		expectedErr         error
+	}{
+		{
+			name:       "OK, groups claim with groups scope",
+			grantScope: []string{"openid", domain.ScopeGroups},
+			mockBehaviorGroups: func(mockGroups *mock_service.MockGroups) {
+				mockGroups.EXPECT().ClaimGroups(gomock.Any(), uint32(1)).Return([]string{"Staff", "Acme/Team"}, nil)
+			},
+			expectedAccessToken: map[string]interface{}{"groups": []string{"Staff", "Acme/Team"}},
+		},
+		{
+			name:       "OK, no claims without groups scope",
+			grantScope: []string{"openid"},
+			mockBehaviorGroups: func(mockGroups *mock_service.MockGroups) {
+				// Nothing
+			},
+		},
+		{
+			name:       "BAD, scope not requested by client",
+			grantScope: []string{"openid", domain.ScopeUsersAdmin},
+			mockBehaviorGroups: func(mockGroups *mock_service.MockGroups) {
+				// Nothing
+			},
+			expectedErr: service.ErrConsentScopeNotRequested,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			var session *domain.OA2ConsentSession
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().GetConsentRequest(gomock.Any(), "challenge").Return(&domain.OA2ConsentRequest{
+				Subject:        "1",
+				ClientId:       "web",
+				RequestedScope: []string{"openid", "offline", domain.ScopeGroups},
+			}, nil)
+			mockUser := mock_service.NewMockUser(ctrl)
+			mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, TenantId: domain.DefaultTenantId}, nil)
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			if testCase.expectedErr == nil {
+				mockOAuth2.EXPECT().AcceptConsentRequest(gomock.Any(), "challenge", testCase.grantScope, gomock.Any(), gomock.Any(), false, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, challenge string, grantScope []string, audience []string, consentSession *domain.OA2ConsentSession, remember bool, rememberFor int64) (string, error) {
+						session = consentSession
+						return "acceptedTo", nil
+					})
+				mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			mockGroups := mock_service.NewMockGroups(ctrl)
+			testCase.mockBehaviorGroups(mockGroups)
+			flow := service.NewFlowService(&config.Config{}, mockOAuth2, mockUser, nil, mockAudit, nil, nil, nil, service.NewTenantService(&config.Config{}), mockGroups)
+
+			//// Act
+			redirectTo, err := flow.SubmitConsent(context.Background(), "challenge", &service.ConsentSubmitInput{Accept: true, GrantScope: testCase.grantScope})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedErr == nil {
+				assert.Equal(t, redirectTo, "acceptedTo")
+				assert.Equal(t, session.AccessToken, testCase.expectedAccessToken)
+				assert.Equal(t, session.IdToken, testCase.expectedAccessToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/pkg/convert_to"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The longest organization name.
+const organizationNameMaxLength = 255
+
+var (
+	ErrOrganizationNotFound     = errors.New("Organization not found")
+	ErrOrganizationAlreadyExist = errors.New("Organization already exist")
+	ErrOrganizationNameInvalid  = errors.New("Organization name must be 1 to 255 characters long")
+	ErrGroupRoleInvalid         = errors.New("Group role must be admin or member")
+	// ErrGroupAccessDenied the user is neither the group's admin nor the groups' manager.
+	ErrGroupAccessDenied = errors.New("Only the group's admins manage its members")
+	// ErrLastGroupAdmin the group's only admin can't leave the group or become its member.
+	ErrLastGroupAdmin = errors.New("Group must keep at least one admin")
+)
+
+// GroupService manages the organizations, their groups and the groups' members, every change is audited.
+// The users with the "groups:manage" permission manage all the groups, the group's admins manage its members
+// and the members read the group.
+type GroupService struct {
+	groups GroupRepository
+	rbac   RBAC
+	audit  Audit
+}
+
+func NewGroupService(groups GroupRepository, rbacService RBAC, auditService Audit) *GroupService {
+	return &GroupService{
+		groups: groups,
+		rbac:   rbacService,
+		audit:  auditService,
+	}
+}
+
+// CreateOrganization creates the organization of the context's tenant.
+func (s *GroupService) CreateOrganization(ctx context.Context, actorId uint32, name string) (*domain.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > organizationNameMaxLength {
+		return nil, ErrOrganizationNameInvalid
+	}
+
+	organization := &domain.Organization{
+		Name:        name,
+		DateCreated: time.Now(),
+	}
+	if err := s.groups.CreateOrganization(ctx, organization); err != nil {
+		if errors.Is(err, repository.ErrRecordAlreadyExist) {
+			return nil, ErrOrganizationAlreadyExist
+		}
+
+		return nil, err
+	}
+
+	event := &domain.AuditEvent{
+		Action:  domain.AuditActionOrganizationCreated,
+		ActorId: &actorId,
+		Data: map[string]string{
+			"organization_id": convert_to.ToString(organization.Id),
+			"name":            organization.Name,
+		},
+	}
+	if err := recordAudit(ctx, s.audit, event); err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (s *GroupService) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	return s.groups.ListOrganizations(ctx)
+}
+
+// CreateGroup creates the organization's group without members, the members are added by SetMember.
+func (s *GroupService) CreateGroup(ctx context.Context, actorId uint32, organizationId uint32, displayName string) (*domain.Group, error) {
+	if _, err := s.getOrganization(ctx, organizationId); err != nil {
+		return nil, err
+	}
+
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" || utf8.RuneCountInString(displayName) > groupNameMaxLength {
+		return nil, ErrGroupNameInvalid
+	}
+
+	now := time.Now()
+	group := &domain.Group{
+		OrganizationId: &organizationId,
+		DisplayName:    displayName,
+		DateCreated:    now,
+		DateUpdated:    now,
+		Version:        1,
+	}
+	if err := s.groups.CreateGroup(ctx, group); err != nil {
+		return nil, groupError(err)
+	}
+
+	event := s.groupEvent(domain.AuditActionGroupCreated, actorId, group.Id)
+	event.Data["organization_id"] = convert_to.ToString(organizationId)
+	event.Data["display_name"] = group.DisplayName
+	if err := recordAudit(ctx, s.audit, event); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// ListGroups returns the organization's groups with their members.
+func (s *GroupService) ListGroups(ctx context.Context, organizationId uint32) ([]domain.Group, error) {
+	if _, err := s.getOrganization(ctx, organizationId); err != nil {
+		return nil, err
+	}
+
+	// All the organization's groups.
+	groups, _, err := s.groups.ListGroups(ctx, &domain.GroupListQuery{OrganizationId: &organizationId, Limit: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetGroup returns the group with its members to the group's members and the groups' managers.
+func (s *GroupService) GetGroup(ctx context.Context, actorId uint32, groupId uint32) (*domain.Group, error) {
+	return s.authorize(ctx, actorId, groupId)
+}
+
+// DeleteGroup deletes the group, the members aren't deleted.
+func (s *GroupService) DeleteGroup(ctx context.Context, actorId uint32, groupId uint32) error {
+	if err := s.groups.DeleteGroup(ctx, groupId); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrGroupNotFound
+		}
+
+		return err
+	}
+
+	return recordAudit(ctx, s.audit, s.groupEvent(domain.AuditActionGroupDeleted, actorId, groupId))
+}
+
+// SetMember adds the user to the group or changes the member's role, it's done by the group's admins and the groups' managers.
+func (s *GroupService) SetMember(ctx context.Context, actorId uint32, groupId uint32, userId uint32, role string) error {
+	if role != domain.GroupRoleAdmin && role != domain.GroupRoleMember {
+		return ErrGroupRoleInvalid
+	}
+
+	if _, err := s.authorize(ctx, actorId, groupId, domain.GroupRoleAdmin); err != nil {
+		return err
+	}
+
+	member := &domain.GroupMember{GroupId: groupId, UserId: userId, Role: role}
+	if err := s.groups.SetGroupMember(ctx, member, time.Now()); err != nil {
+		return memberError(err)
+	}
+
+	event := s.groupEvent(domain.AuditActionGroupMemberSet, actorId, groupId)
+	event.UserId = &userId
+	event.Data["role"] = role
+
+	return recordAudit(ctx, s.audit, event)
+}
+
+// RemoveMember removes the user from the group, it's done by the group's admins and the groups' managers,
+// the members leave the group themselves.
+func (s *GroupService) RemoveMember(ctx context.Context, actorId uint32, groupId uint32, userId uint32) error {
+	if actorId != userId {
+		if _, err := s.authorize(ctx, actorId, groupId, domain.GroupRoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	if err := s.groups.DeleteGroupMember(ctx, groupId, userId, time.Now()); err != nil {
+		return memberError(err)
+	}
+
+	event := s.groupEvent(domain.AuditActionGroupMemberRemoved, actorId, groupId)
+	event.UserId = &userId
+
+	return recordAudit(ctx, s.audit, event)
+}
+
+// ClaimGroups returns the names of the user's groups for the "groups" claim of the tokens,
+// the organization's group is named "<organization>/<group>".
+func (s *GroupService) ClaimGroups(ctx context.Context, userId uint32) ([]string, error) {
+	groups, err := s.groups.ListUserGroups(ctx, []uint32{userId})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(groups[userId]))
+	for _, group := range groups[userId] {
+		if group.Organization != "" {
+			names = append(names, group.Organization+"/"+group.DisplayName)
+		} else {
+			names = append(names, group.DisplayName)
+		}
+	}
+
+	return names, nil
+}
+
+// ListUserGroups returns the user's groups with the user's role in each of them.
+func (s *GroupService) ListUserGroups(ctx context.Context, userId uint32) ([]domain.Group, error) {
+	groups, err := s.groups.ListUserGroups(ctx, []uint32{userId})
+	if err != nil {
+		return nil, err
+	}
+
+	if groups[userId] == nil {
+		return []domain.Group{}, nil
+	}
+
+	return groups[userId], nil
+}
+
+// authorize returns the group with its members if the actor is the group's member with one of the roles,
+// any member if no roles are given, or manages the groups.
+func (s *GroupService) authorize(ctx context.Context, actorId uint32, groupId uint32, roles ...string) (*domain.Group, error) {
+	group, err := s.groups.GetGroup(ctx, groupId)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+
+		return nil, err
+	}
+
+	for _, member := range group.Members {
+		if member.UserId != actorId {
+			continue
+		}
+
+		if len(roles) == 0 {
+			return group, nil
+		}
+
+		for _, role := range roles {
+			if member.Role == role {
+				return group, nil
+			}
+		}
+	}
+
+	allowed, err := s.rbac.Can(ctx, actorId, domain.PermissionGroupsManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		return nil, ErrGroupAccessDenied
+	}
+
+	return group, nil
+}
+
+func (s *GroupService) getOrganization(ctx context.Context, id uint32) (*domain.Organization, error) {
+	organization, err := s.groups.GetOrganization(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (s *GroupService) groupEvent(action string, actorId uint32, groupId uint32) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		Action:  action,
+		ActorId: &actorId,
+		Data:    map[string]string{"group_id": convert_to.ToString(groupId)},
+	}
+}
+
+// memberError maps the missing group or member and the group's last admin.
+func memberError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrLastGroupAdmin):
+		return ErrLastGroupAdmin
+	case errors.Is(err, repository.ErrRecordNotFound):
+		return ErrGroupMemberNotFound
+	}
+
+	return err
+}
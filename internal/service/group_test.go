@@ -0,0 +1,278 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+type TestTableGroup struct {
+	name                  string
+	actorId               uint32
+	userId                uint32
+	role                  string
+	mockBehaviorGroupRepo func(mockGroupRepo *mock_service.MockGroupRepository)
+	mockBehaviorRBAC      func(mockRBAC *mock_service.MockRBAC)
+	mockBehaviorAudit     func(mockAudit *mock_service.MockAudit)
+	expectedErr           error
+}
+
+func newGroupService(ctrl *gomock.Controller, testCase *TestTableGroup) *service.GroupService {
+	mockGroupRepo := mock_service.NewMockGroupRepository(ctrl)
+	testCase.mockBehaviorGroupRepo(mockGroupRepo)
+	mockRBAC := mock_service.NewMockRBAC(ctrl)
+	testCase.mockBehaviorRBAC(mockRBAC)
+	mockAudit := mock_service.NewMockAudit(ctrl)
+	testCase.mockBehaviorAudit(mockAudit)
+
+	return service.NewGroupService(mockGroupRepo, mockRBAC, mockAudit)
+}
+
+// teamGroup is the group 7 administered by the user 1 with the member 2.
+func teamGroup() *domain.Group {
+	return &domain.Group{Id: 7, DisplayName: "Team", Members: []domain.GroupMember{
+		{GroupId: 7, UserId: 1, Role: domain.GroupRoleAdmin},
+		{GroupId: 7, UserId: 2, Role: domain.GroupRoleMember},
+	}}
+}
+
+func memberEvent(action string, actorId uint32, userId uint32, role string) *domain.AuditEvent {
+	event := &domain.AuditEvent{Action: action, ActorId: &actorId, UserId: &userId, Data: map[string]string{"group_id": "7"}}
+	if role != "" {
+		event.Data["role"] = role
+	}
+
+	return event
+}
+
+func TestGroupService_SetMember(t *testing.T) {
+	testTable := []TestTableGroup{
+		{
+			name:    "OK, group admin adds member",
+			actorId: 1,
+			userId:  3,
+			role:    domain.GroupRoleMember,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+				mockGroupRepo.EXPECT().SetGroupMember(gomock.Any(), &domain.GroupMember{GroupId: 7, UserId: 3, Role: domain.GroupRoleMember}, gomock.Any()).Return(nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), memberEvent(domain.AuditActionGroupMemberSet, 1, 3, domain.GroupRoleMember)).Return(nil)
+			},
+		},
+		{
+			name:    "OK, groups manager promotes member",
+			actorId: 9,
+			userId:  2,
+			role:    domain.GroupRoleAdmin,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+				mockGroupRepo.EXPECT().SetGroupMember(gomock.Any(), &domain.GroupMember{GroupId: 7, UserId: 2, Role: domain.GroupRoleAdmin}, gomock.Any()).Return(nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {
+				mockRBAC.EXPECT().Can(gomock.Any(), uint32(9), domain.PermissionGroupsManage, "").Return(true, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), memberEvent(domain.AuditActionGroupMemberSet, 9, 2, domain.GroupRoleAdmin)).Return(nil)
+			},
+		},
+		{
+			name:    "BAD, member doesn't manage members",
+			actorId: 2,
+			userId:  3,
+			role:    domain.GroupRoleMember,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {
+				mockRBAC.EXPECT().Can(gomock.Any(), uint32(2), domain.PermissionGroupsManage, "").Return(false, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupAccessDenied,
+		},
+		{
+			name:    "BAD, last admin isn't demoted",
+			actorId: 1,
+			userId:  1,
+			role:    domain.GroupRoleMember,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+				mockGroupRepo.EXPECT().SetGroupMember(gomock.Any(), gomock.Any(), gomock.Any()).Return(repository.ErrLastGroupAdmin)
+			},
+			mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrLastGroupAdmin,
+		},
+		{
+			name:                  "BAD, role is invalid",
+			actorId:               1,
+			userId:                3,
+			role:                  "owner",
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {},
+			mockBehaviorRBAC:      func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit:     func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:           service.ErrGroupRoleInvalid,
+		},
+		{
+			name:    "BAD, group not found",
+			actorId: 1,
+			userId:  3,
+			role:    domain.GroupRoleMember,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			groupService := newGroupService(ctrl, &testCase)
+
+			//// Act
+			err := groupService.SetMember(context.Background(), testCase.actorId, 7, testCase.userId, testCase.role)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestGroupService_RemoveMember(t *testing.T) {
+	testTable := []TestTableGroup{
+		{
+			name:    "OK, member leaves group",
+			actorId: 2,
+			userId:  2,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().DeleteGroupMember(gomock.Any(), uint32(7), uint32(2), gomock.Any()).Return(nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), memberEvent(domain.AuditActionGroupMemberRemoved, 2, 2, "")).Return(nil)
+			},
+		},
+		{
+			name:    "OK, group admin removes member",
+			actorId: 1,
+			userId:  2,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+				mockGroupRepo.EXPECT().DeleteGroupMember(gomock.Any(), uint32(7), uint32(2), gomock.Any()).Return(nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), memberEvent(domain.AuditActionGroupMemberRemoved, 1, 2, "")).Return(nil)
+			},
+		},
+		{
+			name:    "BAD, member doesn't remove other member",
+			actorId: 2,
+			userId:  1,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {
+				mockRBAC.EXPECT().Can(gomock.Any(), uint32(2), domain.PermissionGroupsManage, "").Return(false, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupAccessDenied,
+		},
+		{
+			name:    "BAD, last admin doesn't leave group",
+			actorId: 1,
+			userId:  1,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().DeleteGroupMember(gomock.Any(), uint32(7), uint32(1), gomock.Any()).Return(repository.ErrLastGroupAdmin)
+			},
+			mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrLastGroupAdmin,
+		},
+		{
+			name:    "BAD, user isn't member",
+			actorId: 3,
+			userId:  3,
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().DeleteGroupMember(gomock.Any(), uint32(7), uint32(3), gomock.Any()).Return(repository.ErrRecordNotFound)
+			},
+			mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupMemberNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			groupService := newGroupService(ctrl, &testCase)
+
+			//// Act
+			err := groupService.RemoveMember(context.Background(), testCase.actorId, 7, testCase.userId)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestGroupService_ClaimGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	testCase := TestTableGroup{
+		mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+			mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{2}).Return(map[uint32][]domain.Group{
+				2: {{Id: 3, DisplayName: "Staff"}, {Id: 7, DisplayName: "Team", Organization: "Acme"}},
+			}, nil)
+		},
+		mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+		mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+	}
+	groupService := newGroupService(ctrl, &testCase)
+
+	//// Act
+	groups, err := groupService.ClaimGroups(context.Background(), 2)
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, groups, []string{"Staff", "Acme/Team"})
+}
+
+func TestGroupService_ListUserGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	testCase := TestTableGroup{
+		mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+			mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{2}).Return(map[uint32][]domain.Group{}, nil)
+		},
+		mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+		mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+	}
+	groupService := newGroupService(ctrl, &testCase)
+
+	//// Act
+	groups, err := groupService.ListUserGroups(context.Background(), 2)
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, groups, []domain.Group{})
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The longest username and email, it's the columns' size.
+const identifierMaxLength = 255
+
+var (
+	ErrEmailInvalid = errors.New("Email is invalid")
+	// ErrUsernameInvalid the username is empty, too long, has "@", spaces or invisible characters,
+	// or mixes the letters of the scripts that look alike, e.g. the Latin "a" and the Cyrillic "а".
+	ErrUsernameInvalid = errors.New("Username has disallowed or confusable characters")
+)
+
+// The scripts of the letters that look alike, the username's letters are from one of them.
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek}
+
+// NormalizeEmail returns the email in NFKC with the lowercased domain. The local part is kept as is,
+// the emails differing in case only are the same user anyway.
+func NormalizeEmail(email string) (string, error) {
+	email = norm.NFKC.String(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 || utf8.RuneCountInString(email) > identifierMaxLength || strings.IndexFunc(email, isDisallowedRune) >= 0 {
+		return "", ErrEmailInvalid
+	}
+
+	return email[:at] + "@" + strings.ToLower(email[at+1:]), nil
+}
+
+// NormalizeUsername returns the username in NFKC. The username can't look like an email or another username.
+func NormalizeUsername(username string) (string, error) {
+	username = norm.NFKC.String(strings.TrimSpace(username))
+	if username == "" || utf8.RuneCountInString(username) > identifierMaxLength || strings.Contains(username, "@") ||
+		strings.IndexFunc(username, isDisallowedRune) >= 0 || isMixedScript(username) {
+		return "", ErrUsernameInvalid
+	}
+
+	return username, nil
+}
+
+// IsEmailLogin reports whether the sign-in's login is the email, it's the username otherwise.
+func IsEmailLogin(login string) bool {
+	return strings.Contains(login, "@")
+}
+
+// NormalizeLogin normalizes the sign-in's login as the email or the username.
+func NormalizeLogin(login string) (string, error) {
+	if IsEmailLogin(login) {
+		return NormalizeEmail(login)
+	}
+
+	return NormalizeUsername(login)
+}
+
+// isDisallowedRune reports whether the rune is the space, the control or the invisible formatting character.
+func isDisallowedRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || r == utf8.RuneError
+}
+
+// isMixedScript reports whether the letters are from more than one of the confusable scripts.
+func isMixedScript(s string) bool {
+	var found *unicode.RangeTable
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		for _, script := range confusableScripts {
+			if !unicode.Is(script, r) {
+				continue
+			}
+
+			if found != nil && found != script {
+				return true
+			}
+
+			found = script
+		}
+	}
+
+	return false
+}
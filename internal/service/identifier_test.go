@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	testTable := []struct {
+		name          string
+		email         string
+		expectedEmail string
+		expectedErr   error
+	}{
+		{
+			name:          "OK, domain is lowercased",
+			email:         " Alice@Mail.COM ",
+			expectedEmail: "Alice@mail.com",
+		},
+		{
+			name:          "OK, fullwidth characters",
+			email:         "ａlice@mail.com",
+			expectedEmail: "alice@mail.com",
+		},
+		{
+			name:        "BAD, no domain",
+			email:       "alice@",
+			expectedErr: service.ErrEmailInvalid,
+		},
+		{
+			name:        "BAD, zero width space",
+			email:       "ali​ce@mail.com",
+			expectedErr: service.ErrEmailInvalid,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Act
+			email, err := service.NormalizeEmail(testCase.email)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, email, testCase.expectedEmail)
+		})
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	testTable := []struct {
+		name             string
+		username         string
+		expectedUsername string
+		expectedErr      error
+	}{
+		{
+			name:             "OK, latin",
+			username:         " Alice ",
+			expectedUsername: "Alice",
+		},
+		{
+			name:             "OK, cyrillic",
+			username:         "алиса_1",
+			expectedUsername: "алиса_1",
+		},
+		{
+			name:             "OK, ligature",
+			username:         "ﬁona",
+			expectedUsername: "fiona",
+		},
+		{
+			name:        "BAD, mixed latin and cyrillic",
+			username:    "аlice",
+			expectedErr: service.ErrUsernameInvalid,
+		},
+		{
+			name:        "BAD, looks like email",
+			username:    "alice@mail.com",
+			expectedErr: service.ErrUsernameInvalid,
+		},
+		{
+			name:        "BAD, space",
+			username:    "al ice",
+			expectedErr: service.ErrUsernameInvalid,
+		},
+		{
+			name:        "BAD, empty",
+			username:    "  ",
+			expectedErr: service.ErrUsernameInvalid,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Act
+			username, err := service.NormalizeUsername(testCase.username)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, username, testCase.expectedUsername)
+		})
+	}
+}
+
+func TestUserService_SignInLogin(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		login                string
+		mockBehaviorUserRepo func(mockRepo *mock_service.MockUserRepository)
+		expectedErr          error
+	}{
+		{
+			name:  "OK, email",
+			login: "alice@Mail.com",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(&domain.User{Id: 1, PasswordHash: []byte("password")}, nil)
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "OK, username",
+			login: "Alice",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserByUsername(gomock.Any(), "Alice").Return(&domain.User{Id: 1, PasswordHash: []byte("password")}, nil)
+				mockRepo.EXPECT().UpdateLastOnline(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:                 "BAD, confusable username",
+			login:                "аlice",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {},
+			expectedErr:          service.ErrUserNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			// The hash is the password itself.
+			mockHasher := mock_service.NewMockHasher(ctrl)
+			mockHasher.EXPECT().Hash(gomock.Any(), gomock.Any()).DoAndReturn(func(password string, salt []byte) []byte {
+				return []byte(password)
+			}).AnyTimes()
+			userService := service.NewUserSerices(mockUserRepo, nil, mockHasher, nil, &config.Config{})
+
+			//// Act
+			_, err := userService.SignIn(context.Background(), &service.UserSignInInput{Login: testCase.login, Password: "password"})
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
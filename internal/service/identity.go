@@ -0,0 +1,561 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/pkg/errors"
+	"math/big"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// The number of the usernames tried for the user registered by the upstream provider.
+	identityUsernameAttempts = 5
+	// The upper bound of the random suffix of the taken username.
+	identityUsernameSuffixMax = 10000
+	// The username of the user whose upstream username and email are unusable.
+	identityDefaultUsername = "user"
+)
+
+var (
+	ErrIdentityProviderNotFound = errors.New("Identity provider isn't configured")
+	// ErrUpstreamLoginExpired the provider's callback is unknown, used or too late, the user has to sign in again.
+	ErrUpstreamLoginExpired = errors.New("Sign-in with the identity provider is expired, sign in again")
+	// ErrUpstreamLoginFailed the provider denied the sign-in or its response is invalid.
+	ErrUpstreamLoginFailed = errors.New("Sign-in with the identity provider failed")
+	// ErrIdentityEmailUnverified the provider didn't verify the email, the user can't be registered with it.
+	ErrIdentityEmailUnverified = errors.New("Identity provider didn't verify your email")
+	// ErrIdentityEmailTaken the email belongs to the local account without the password, the owner links the provider
+	// on the account page.
+	ErrIdentityEmailTaken = errors.New("Account with your email already exists, sign in to it and link the identity provider on the account page")
+	// ErrIdentityLinkRequired the email belongs to the local account, its password proves the ownership before the identity is linked.
+	ErrIdentityLinkRequired = errors.New("Account with your email already exists, enter its password to link the identity provider")
+	// ErrIdentityLinkExpired the identity waiting for the account's password is unknown, used or too late.
+	ErrIdentityLinkExpired = errors.New("Linking of the identity provider is expired, sign in again")
+	ErrIdentityLinked      = errors.New("The identity is linked to another account")
+	ErrIdentityNotFound    = errors.New("The identity isn't linked to your account")
+	// ErrLastLoginMethod the user would have no password and no identity left to sign in with.
+	ErrLastLoginMethod = errors.New("The only sign-in method of your account can't be removed")
+)
+
+// UpstreamLoginResult is the completed sign-in with the provider.
+type UpstreamLoginResult struct {
+	Login *domain.UpstreamLogin
+	User  *domain.User
+	// Link is the identity waiting for the password of the local account with the provider's email, see ErrIdentityLinkRequired.
+	Link *domain.PendingLink
+}
+
+// IdentityService signs the users in with the upstream OpenID Connect providers. The provider's subject is mapped
+// to the local user through the identities, the new user is registered at the first sign-in.
+// The identity is linked to the existing account by its signed in user or the owner entering the account's password.
+// The provider's subject has the identity in every tenant, the callback signs in to the tenant the sign-in was started in.
+type IdentityService struct {
+	repo      UserRepository
+	providers IdentityProviders
+	audit     Audit
+	tenants   Tenants
+	config    *config.IdPConfig
+}
+
+func NewIdentityService(userRepo UserRepository, providers IdentityProviders, auditService Audit, tenantService Tenants, config *config.IdPConfig) *IdentityService {
+	return &IdentityService{
+		repo:      userRepo,
+		providers: providers,
+		audit:     auditService,
+		tenants:   tenantService,
+		config:    config,
+	}
+}
+
+// Providers returns the configured providers.
+func (s *IdentityService) Providers() []domain.IdentityProvider {
+	return s.providers.List()
+}
+
+// StartLogin starts the sign-in of the challenge with the provider, returns the provider's authorization URL
+// and the state binding the callback to the browser.
+func (s *IdentityService) StartLogin(ctx context.Context, providerId string, challenge string, remember bool, deviceId string) (string, string, error) {
+	return s.startLogin(ctx, &domain.UpstreamLogin{
+		Provider:  providerId,
+		Challenge: challenge,
+		Remember:  remember,
+		DeviceId:  deviceId,
+	})
+}
+
+// StartLink starts linking the provider to the signed in user's account, returns the provider's authorization URL
+// and the state binding the callback to the browser.
+func (s *IdentityService) StartLink(ctx context.Context, userId uint32, providerId string) (string, string, error) {
+	return s.startLogin(ctx, &domain.UpstreamLogin{
+		Provider: providerId,
+		UserId:   &userId,
+	})
+}
+
+func (s *IdentityService) startLogin(ctx context.Context, login *domain.UpstreamLogin) (string, string, error) {
+	if !s.hasProvider(login.Provider) {
+		return "", "", ErrIdentityProviderNotFound
+	}
+
+	state, stateHash, err := newLinkToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, _, err := newLinkToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	codeVerifier, _, err := newLinkToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authCodeUrl, err := s.providers.AuthCodeURL(ctx, login.Provider, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	login.StateHash = stateHash
+	login.Nonce = nonce
+	login.CodeVerifier = codeVerifier
+	login.DateCreated = now
+	login.DateExpires = now.Add(s.config.LoginTTL)
+	if err = s.repo.CreateUpstreamLogin(ctx, login); err != nil {
+		return "", "", err
+	}
+
+	return authCodeUrl, state, nil
+}
+
+// FinishLogin completes the sign-in of the provider's callback and returns the local user.
+// The upstream error is the callback's "error" parameter. The result has the sign-in with the errors too
+// unless it's unknown, so the user signs in again with its challenge. The linking sign-in links the identity
+// to its user's account instead.
+func (s *IdentityService) FinishLogin(ctx context.Context, state string, code string, upstreamErr string) (*UpstreamLoginResult, error) {
+	login, err := s.repo.TakeUpstreamLogin(ctx, hashLinkToken(state))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrUpstreamLoginExpired
+		}
+
+		return nil, err
+	}
+
+	// The tenant may have been removed from the configuration since.
+	tenant, ok := s.tenants.Get(login.TenantId)
+	if !ok {
+		return nil, ErrUpstreamLoginExpired
+	}
+
+	ctx = domain.WithTenant(ctx, tenant)
+	result := &UpstreamLoginResult{Login: login}
+	if login.DateExpires.Before(time.Now()) {
+		return result, ErrUpstreamLoginExpired
+	}
+
+	if upstreamErr != "" {
+		return result, fmt.Errorf("%w: %s", ErrUpstreamLoginFailed, upstreamErr)
+	}
+
+	claims, err := s.providers.Exchange(ctx, login.Provider, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrUpstreamLoginFailed, err)
+	}
+
+	if login.UserId != nil {
+		result.User, err = s.link(ctx, *login.UserId, login.Provider, claims)
+	} else {
+		result.User, result.Link, err = s.resolveUser(ctx, login, claims)
+	}
+
+	return result, err
+}
+
+// resolveUser returns the user of the provider's subject, the new user is registered if the subject is unknown.
+// The identity with the email of the existing account waits for the account's password.
+func (s *IdentityService) resolveUser(ctx context.Context, login *domain.UpstreamLogin, claims *domain.UpstreamClaims) (*domain.User, *domain.PendingLink, error) {
+	identity, err := s.repo.GetIdentity(ctx, login.Provider, claims.Subject)
+	if err != nil {
+		if !errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+
+		if !claims.EmailVerified {
+			return nil, nil, ErrIdentityEmailUnverified
+		}
+
+		email, err := NormalizeEmail(claims.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The email alone doesn't prove the ownership of the account, it would be taken over by the provider's user.
+		owner, err := s.repo.GetUserByEmail(ctx, email)
+		if err == nil {
+			link, err := s.createLink(ctx, login, owner, claims.Subject, email)
+			return nil, link, err
+		}
+
+		if !errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+
+		user, err := s.register(ctx, login.Provider, claims, email)
+		return user, nil, err
+	}
+
+	// The deleted user isn't registered again.
+	user, err := s.repo.GetUserById(ctx, identity.UserId)
+	if err != nil {
+		return nil, nil, userError(err)
+	}
+
+	if err = s.repo.TouchIdentity(ctx, identity.Id, claims.Email, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	return user, nil, nil
+}
+
+// createLink stores the identity waiting for the password of the owner's account, ErrIdentityLinkRequired is returned
+// with the link. The owner without the password links the provider on the account page.
+func (s *IdentityService) createLink(ctx context.Context, login *domain.UpstreamLogin, owner *domain.User, subject string, email string) (*domain.PendingLink, error) {
+	if !owner.HasPassword() {
+		return nil, ErrIdentityEmailTaken
+	}
+
+	token, tokenHash, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.repo.CreateIdentityLink(ctx, &domain.IdentityLink{
+		TokenHash:   tokenHash,
+		Challenge:   login.Challenge,
+		UserId:      owner.Id,
+		Provider:    login.Provider,
+		Subject:     subject,
+		Email:       email,
+		Remember:    login.Remember,
+		DeviceId:    login.DeviceId,
+		DateCreated: now,
+		DateExpires: now.Add(s.config.LoginTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PendingLink{
+		Token:    token,
+		Provider: login.Provider,
+		Email:    email,
+	}, ErrIdentityLinkRequired
+}
+
+// GetLink returns the identity waiting for the account's password.
+func (s *IdentityService) GetLink(ctx context.Context, token string) (*domain.IdentityLink, error) {
+	link, err := s.repo.GetIdentityLink(ctx, hashLinkToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrIdentityLinkExpired
+		}
+
+		return nil, err
+	}
+
+	if link.DateExpires.Before(time.Now()) {
+		return nil, ErrIdentityLinkExpired
+	}
+
+	return link, nil
+}
+
+// ConfirmLink links the waiting identity to the account once its user entered the password.
+func (s *IdentityService) ConfirmLink(ctx context.Context, token string, userId uint32) (*domain.IdentityLink, error) {
+	link, err := s.repo.TakeIdentityLink(ctx, hashLinkToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrIdentityLinkExpired
+		}
+
+		return nil, err
+	}
+
+	if link.UserId != userId || link.DateExpires.Before(time.Now()) {
+		return nil, ErrIdentityLinkExpired
+	}
+
+	now := time.Now()
+	err = s.createIdentity(ctx, &domain.Identity{
+		UserId:       userId,
+		Provider:     link.Provider,
+		Subject:      link.Subject,
+		Email:        link.Email,
+		DateCreated:  now,
+		DateLastUsed: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// link links the provider's subject to the signed in user's account, the subject of another user isn't moved.
+func (s *IdentityService) link(ctx context.Context, userId uint32, providerId string, claims *domain.UpstreamClaims) (*domain.User, error) {
+	user, err := s.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	identity, err := s.repo.GetIdentity(ctx, providerId, claims.Subject)
+	if err == nil {
+		if identity.UserId != userId {
+			return nil, s.recordLinkFailure(ctx, userId, providerId, ErrIdentityLinked)
+		}
+
+		// Linked already.
+		return user, s.repo.TouchIdentity(ctx, identity.Id, claims.Email, time.Now())
+	}
+
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.createIdentity(ctx, &domain.Identity{
+		UserId:       userId,
+		Provider:     providerId,
+		Subject:      claims.Subject,
+		Email:        claims.Email,
+		DateCreated:  now,
+		DateLastUsed: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createIdentity links the identity to its user and records it in the audit log.
+func (s *IdentityService) createIdentity(ctx context.Context, identity *domain.Identity) error {
+	if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+		if errors.Is(err, repository.ErrRecordAlreadyExist) {
+			return s.recordLinkFailure(ctx, identity.UserId, identity.Provider, ErrIdentityLinked)
+		}
+
+		return err
+	}
+
+	return recordAudit(ctx, s.audit, &domain.AuditEvent{
+		Action:  domain.AuditActionIdentityLinked,
+		ActorId: &identity.UserId,
+		UserId:  &identity.UserId,
+		Data:    map[string]string{"provider": identity.Provider},
+	})
+}
+
+// recordLinkFailure records the failed link in the audit log and returns the failure.
+func (s *IdentityService) recordLinkFailure(ctx context.Context, userId uint32, providerId string, failure error) error {
+	err := recordAudit(ctx, s.audit, &domain.AuditEvent{
+		Action:  domain.AuditActionIdentityLinked,
+		Outcome: domain.AuditOutcomeFailure,
+		ActorId: &userId,
+		UserId:  &userId,
+		Reason:  failure.Error(),
+		Data:    map[string]string{"provider": providerId},
+	})
+	if err != nil {
+		return err
+	}
+
+	return failure
+}
+
+// LoginMethods returns the user's password and identities, and the providers the user can link.
+func (s *IdentityService) LoginMethods(ctx context.Context, userId uint32) (*domain.LoginMethods, error) {
+	user, err := s.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	identities, err := s.repo.ListIdentities(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := &domain.LoginMethods{
+		Password:   user.HasPassword(),
+		Identities: identities,
+		Providers:  []domain.IdentityProvider{},
+	}
+	for _, provider := range s.providers.List() {
+		linked := false
+		for _, identity := range identities {
+			linked = linked || identity.Provider == provider.Id
+		}
+
+		if !linked {
+			methods.Providers = append(methods.Providers, provider)
+		}
+	}
+
+	return methods, nil
+}
+
+// Unlink unlinks the user's identity unless it's the user's last login method.
+func (s *IdentityService) Unlink(ctx context.Context, userId uint32, identityId uint32) error {
+	identities, err := s.repo.ListIdentities(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	var identity *domain.Identity
+	for i := range identities {
+		if identities[i].Id == identityId {
+			identity = &identities[i]
+		}
+	}
+
+	if identity == nil {
+		return ErrIdentityNotFound
+	}
+
+	err = s.repo.DeleteIdentity(ctx, userId, identityId)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return ErrIdentityNotFound
+	}
+
+	return s.removeLoginMethod(ctx, userId, map[string]string{"provider": identity.Provider}, err)
+}
+
+// RemovePassword removes the user's password unless the user has no identity to sign in with.
+func (s *IdentityService) RemovePassword(ctx context.Context, userId uint32) error {
+	return s.removeLoginMethod(ctx, userId, map[string]string{"method": "password"},
+		userError(s.repo.RemovePasswordHash(ctx, userId)))
+}
+
+// removeLoginMethod records the removal in the audit log, the refused removal of the last login method too.
+func (s *IdentityService) removeLoginMethod(ctx context.Context, userId uint32, data map[string]string, err error) error {
+	if errors.Is(err, repository.ErrLastLoginMethod) {
+		err = ErrLastLoginMethod
+	} else if err != nil {
+		return err
+	}
+
+	event := &domain.AuditEvent{
+		Action:  domain.AuditActionIdentityUnlinked,
+		ActorId: &userId,
+		UserId:  &userId,
+		Data:    data,
+	}
+	if err != nil {
+		event.Outcome = domain.AuditOutcomeDenied
+		event.Reason = err.Error()
+	}
+
+	if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil {
+		return auditErr
+	}
+
+	return err
+}
+
+// register registers the user with the provider's verified email and links the identity to the user.
+// The user has no password, the username is the upstream one or the email's name made unique.
+func (s *IdentityService) register(ctx context.Context, providerId string, claims *domain.UpstreamClaims, email string) (*domain.User, error) {
+	username, err := s.availableUsername(ctx, claims.PreferredUsername, email)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		Username:         username,
+		Email:            email,
+		PasswordHash:     []byte{},
+		DateRegistration: now,
+		DateLastOnline:   now,
+		DisplayName:      claims.Name,
+		Version:          1,
+	}
+	identity := &domain.Identity{
+		Provider:     providerId,
+		Subject:      claims.Subject,
+		Email:        email,
+		DateCreated:  now,
+		DateLastUsed: now,
+	}
+	if err = s.repo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		if errors.Is(err, repository.ErrRecordAlreadyExist) {
+			return nil, ErrUserAlreadyExist
+		}
+
+		return nil, err
+	}
+
+	return user, recordAudit(ctx, s.audit, &domain.AuditEvent{
+		Action:  domain.AuditActionSignup,
+		ActorId: &user.Id,
+		UserId:  &user.Id,
+		Data:    map[string]string{"provider": providerId},
+	})
+}
+
+// availableUsername returns the first free username of the upstream username, the email's name and the default one.
+// The taken username gets the random suffix.
+func (s *IdentityService) availableUsername(ctx context.Context, preferredUsername string, email string) (string, error) {
+	base, err := NormalizeUsername(preferredUsername)
+	if err != nil {
+		if base, err = NormalizeUsername(email[:strings.LastIndex(email, "@")]); err != nil {
+			base = identityDefaultUsername
+		}
+	}
+
+	// The suffix fits the column.
+	if runes := []rune(base); len(runes) > identifierMaxLength-len("-9999") {
+		base = string(runes[:identifierMaxLength-len("-9999")])
+	}
+
+	username := base
+	for i := 0; i < identityUsernameAttempts; i++ {
+		_, err = s.repo.GetUserByUsername(ctx, username)
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return username, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(identityUsernameSuffixMax))
+		if err != nil {
+			return "", err
+		}
+
+		username = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return "", ErrUsernameTaken
+}
+
+func (s *IdentityService) hasProvider(providerId string) bool {
+	for _, provider := range s.providers.List() {
+		if provider.Id == providerId {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,340 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testIdentityProviders = []domain.IdentityProvider{{Id: "keycloak", Name: "Corporate account"}}
+
+func TestIdentityService_StartLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+	mockProviders := mock_service.NewMockIdentityProviders(ctrl)
+	mockProviders.EXPECT().List().Return(testIdentityProviders).AnyTimes()
+	var authState string
+	mockProviders.EXPECT().AuthCodeURL(gomock.Any(), "keycloak", gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, providerId string, state string, nonce string, codeVerifier string) (string, error) {
+			authState = state
+			return "https://keycloak/authorize?state=" + state, nil
+		})
+	mockUserRepo.EXPECT().CreateUpstreamLogin(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, login *domain.UpstreamLogin) error {
+		// The state isn't stored, the nonce and the code verifier are.
+		assert.Equal(t, login.StateHash != authState && len(login.StateHash) == 64, true)
+		assert.Equal(t, login.Challenge, "challenge")
+		assert.Equal(t, login.Nonce != "" && login.CodeVerifier != "", true)
+		assert.Equal(t, login.DateExpires.Sub(login.DateCreated), 10*time.Minute)
+		return nil
+	})
+	identityService := service.NewIdentityService(mockUserRepo, mockProviders, nil, service.NewTenantService(&config.Config{}), &config.IdPConfig{LoginTTL: 10 * time.Minute})
+
+	//// Act
+	authCodeUrl, state, err := identityService.StartLogin(context.Background(), "keycloak", "challenge", true, "device")
+	_, _, unknownErr := identityService.StartLogin(context.Background(), "github", "challenge", true, "device")
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, state, authState)
+	assert.Equal(t, authCodeUrl, "https://keycloak/authorize?state="+state)
+	assert.Equal(t, unknownErr, service.ErrIdentityProviderNotFound)
+}
+
+func TestIdentityService_FinishLogin(t *testing.T) {
+	expires := time.Now().Add(time.Minute)
+	upstreamLogin := &domain.UpstreamLogin{TenantId: domain.DefaultTenantId, Provider: "keycloak", Challenge: "challenge", Nonce: "nonce", CodeVerifier: "verifier", DateExpires: expires}
+	linkUserId := uint32(1)
+	linkLogin := &domain.UpstreamLogin{TenantId: domain.DefaultTenantId, Provider: "keycloak", UserId: &linkUserId, Nonce: "nonce", CodeVerifier: "verifier", DateExpires: expires}
+	claims := &domain.UpstreamClaims{Subject: "upstream-1", Email: "alice@Mail.com", EmailVerified: true, PreferredUsername: "alice", Name: "Alice"}
+
+	testTable := []struct {
+		name                  string
+		upstreamErr           string
+		mockBehaviorUserRepo  func(mockRepo *mock_service.MockUserRepository)
+		mockBehaviorProviders func(mockProviders *mock_service.MockIdentityProviders)
+		mockBehaviorAudit     func(mockAudit *mock_service.MockAudit)
+		expectedUser          *domain.User
+		expectedLogin         bool
+		expectedLink          bool
+		expectedErr           error
+	}{
+		{
+			name: "OK, linked identity",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(&domain.Identity{Id: 3, UserId: 1}, nil)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice"}, nil)
+				mockRepo.EXPECT().TouchIdentity(gomock.Any(), uint32(3), "alice@Mail.com", gomock.Any()).Return(nil)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedUser:      &domain.User{Id: 1, Username: "alice"},
+			expectedLogin:     true,
+		},
+		{
+			name: "OK, new user with taken username",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(nil, repository.ErrRecordNotFound)
+				gomock.InOrder(
+					mockRepo.EXPECT().GetUserByUsername(gomock.Any(), "alice").Return(&domain.User{Id: 2}, nil),
+					mockRepo.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Return(nil, repository.ErrRecordNotFound),
+				)
+				mockRepo.EXPECT().CreateUserWithIdentity(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, user *domain.User, identity *domain.Identity) error {
+						assert.Equal(t, strings.HasPrefix(user.Username, "alice-"), true)
+						assert.Equal(t, user.Email, "alice@mail.com")
+						assert.Equal(t, len(user.PasswordHash), 0)
+						// The version starts at 1 like the other ways of the registration.
+						assert.Equal(t, user.Version, uint32(1))
+						assert.Equal(t, identity.Subject, "upstream-1")
+						user.Id = 5
+						user.Username = "alice-1"
+						return nil
+					})
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				userId := uint32(5)
+				mockAudit.EXPECT().Record(gomock.Any(), &domain.AuditEvent{
+					Action:  domain.AuditActionSignup,
+					ActorId: &userId,
+					UserId:  &userId,
+					Data:    map[string]string{"provider": "keycloak"},
+				}).Return(nil)
+			},
+			expectedLogin: true,
+		},
+		{
+			name: "OK, email of local account waits for password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(&domain.User{Id: 2, PasswordHash: []byte("hash")}, nil)
+				mockRepo.EXPECT().CreateIdentityLink(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, link *domain.IdentityLink) error {
+					// The identity isn't linked before the password is entered.
+					assert.Equal(t, link.UserId, uint32(2))
+					assert.Equal(t, link.Challenge, "challenge")
+					assert.Equal(t, link.Subject, "upstream-1")
+					assert.Equal(t, len(link.TokenHash), 64)
+					return nil
+				})
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedLogin:     true,
+			expectedLink:      true,
+			expectedErr:       service.ErrIdentityLinkRequired,
+		},
+		{
+			name: "BAD, email of local account without password",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "alice@mail.com").Return(&domain.User{Id: 2, PasswordHash: []byte{}}, nil)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedLogin:     true,
+			expectedErr:       service.ErrIdentityEmailTaken,
+		},
+		{
+			name: "OK, identity linked to signed in user",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(linkLogin, nil)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice"}, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(nil, repository.ErrRecordNotFound)
+				mockRepo.EXPECT().CreateIdentity(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, identity *domain.Identity) error {
+					assert.Equal(t, identity.UserId, uint32(1))
+					assert.Equal(t, identity.Subject, "upstream-1")
+					return nil
+				})
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), &domain.AuditEvent{
+					Action:  domain.AuditActionIdentityLinked,
+					ActorId: &linkUserId,
+					UserId:  &linkUserId,
+					Data:    map[string]string{"provider": "keycloak"},
+				}).Return(nil)
+			},
+			expectedUser:  &domain.User{Id: 1, Username: "alice"},
+			expectedLogin: true,
+		},
+		{
+			name: "BAD, identity of another account isn't linked",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(linkLogin, nil)
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, Username: "alice"}, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(&domain.Identity{Id: 3, UserId: 2}, nil)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").Return(claims, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+					assert.Equal(t, event.Outcome, domain.AuditOutcomeFailure)
+					return nil
+				})
+			},
+			expectedLogin: true,
+			expectedErr:   service.ErrIdentityLinked,
+		},
+		{
+			name: "BAD, unverified email",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+				mockRepo.EXPECT().GetIdentity(gomock.Any(), "keycloak", "upstream-1").Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {
+				mockProviders.EXPECT().Exchange(gomock.Any(), "keycloak", "code", "verifier", "nonce").
+					Return(&domain.UpstreamClaims{Subject: "upstream-1", Email: "alice@mail.com"}, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedLogin:     true,
+			expectedErr:       service.ErrIdentityEmailUnverified,
+		},
+		{
+			name:        "BAD, provider's error",
+			upstreamErr: "access_denied",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(upstreamLogin, nil)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {},
+			mockBehaviorAudit:     func(mockAudit *mock_service.MockAudit) {},
+			expectedLogin:         true,
+			expectedErr:           service.ErrUpstreamLoginFailed,
+		},
+		{
+			name: "BAD, unknown state",
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().TakeUpstreamLogin(gomock.Any(), gomock.Any()).Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorProviders: func(mockProviders *mock_service.MockIdentityProviders) {},
+			mockBehaviorAudit:     func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:           service.ErrUpstreamLoginExpired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			mockProviders := mock_service.NewMockIdentityProviders(ctrl)
+			testCase.mockBehaviorProviders(mockProviders)
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			testCase.mockBehaviorAudit(mockAudit)
+			identityService := service.NewIdentityService(mockUserRepo, mockProviders, mockAudit, service.NewTenantService(&config.Config{}), &config.IdPConfig{LoginTTL: 10 * time.Minute})
+
+			//// Act
+			result, err := identityService.FinishLogin(context.Background(), "state", "code", testCase.upstreamErr)
+
+			//// Assert
+			assert.Equal(t, errors.Is(err, testCase.expectedErr), true)
+			assert.Equal(t, result != nil, testCase.expectedLogin)
+			if result != nil {
+				assert.Equal(t, result.Link != nil, testCase.expectedLink)
+			}
+			if testCase.expectedUser != nil {
+				assert.Equal(t, result.User, testCase.expectedUser)
+			}
+		})
+	}
+}
+
+func TestIdentityService_Unlink(t *testing.T) {
+	identities := []domain.Identity{{Id: 3, UserId: 1, Provider: "keycloak"}}
+
+	testTable := []struct {
+		name                 string
+		identityId           uint32
+		mockBehaviorUserRepo func(mockRepo *mock_service.MockUserRepository)
+		expectedOutcome      string
+		expectedErr          error
+	}{
+		{
+			name:       "OK",
+			identityId: 3,
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().ListIdentities(gomock.Any(), uint32(1)).Return(identities, nil)
+				mockRepo.EXPECT().DeleteIdentity(gomock.Any(), uint32(1), uint32(3)).Return(nil)
+			},
+			expectedOutcome: "",
+		},
+		{
+			name:       "BAD, last login method",
+			identityId: 3,
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().ListIdentities(gomock.Any(), uint32(1)).Return(identities, nil)
+				mockRepo.EXPECT().DeleteIdentity(gomock.Any(), uint32(1), uint32(3)).Return(repository.ErrLastLoginMethod)
+			},
+			expectedOutcome: domain.AuditOutcomeDenied,
+			expectedErr:     service.ErrLastLoginMethod,
+		},
+		{
+			name:       "BAD, identity of another account",
+			identityId: 4,
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().ListIdentities(gomock.Any(), uint32(1)).Return(identities, nil)
+			},
+			expectedErr: service.ErrIdentityNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			testCase.mockBehaviorUserRepo(mockUserRepo)
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			var recorded *domain.AuditEvent
+			mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *domain.AuditEvent) error {
+				recorded = event
+				return nil
+			}).MaxTimes(1)
+			identityService := service.NewIdentityService(mockUserRepo, nil, mockAudit, service.NewTenantService(&config.Config{}), &config.IdPConfig{})
+
+			//// Act
+			err := identityService.Unlink(context.Background(), 1, testCase.identityId)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedErr != service.ErrIdentityNotFound {
+				assert.Equal(t, recorded.Action, domain.AuditActionIdentityUnlinked)
+				assert.Equal(t, recorded.Outcome, testCase.expectedOutcome)
+				assert.Equal(t, recorded.Data["provider"], "keycloak")
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 	domain "service-account/internal/domain"
 	service "service-account/internal/service"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	context "golang.org/x/net/context"
@@ -73,313 +74,3769 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ConfirmEmailChange mocks base method.
+func (m *MockUserRepository) ConfirmEmailChange(ctx context.Context, tokenHash, revertTokenHash string, revertExpires time.Time) (*domain.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, tokenHash, revertTokenHash, revertExpires)
+	ret0, _ := ret[0].(*domain.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserRepositoryMockRecorder) ConfirmEmailChange(ctx, tokenHash, revertTokenHash, revertExpires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserRepository)(nil).ConfirmEmailChange), ctx, tokenHash, revertTokenHash, revertExpires)
+}
+
+// CountStepUpAttempt mocks base method.
+func (m *MockUserRepository) CountStepUpAttempt(ctx context.Context, challenge string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountStepUpAttempt", ctx, challenge)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountStepUpAttempt indicates an expected call of CountStepUpAttempt.
+func (mr *MockUserRepositoryMockRecorder) CountStepUpAttempt(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountStepUpAttempt", reflect.TypeOf((*MockUserRepository)(nil).CountStepUpAttempt), ctx, challenge)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, user)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Create", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
+}
+
+// CreateEmailChange mocks base method.
+func (m *MockUserRepository) CreateEmailChange(ctx context.Context, emailChange *domain.EmailChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailChange", ctx, emailChange)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailChange indicates an expected call of CreateEmailChange.
+func (mr *MockUserRepositoryMockRecorder) CreateEmailChange(ctx, emailChange interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailChange", reflect.TypeOf((*MockUserRepository)(nil).CreateEmailChange), ctx, emailChange)
+}
+
+// CreateIdentity mocks base method.
+func (m *MockUserRepository) CreateIdentity(ctx context.Context, identity *domain.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockUserRepositoryMockRecorder) CreateIdentity(ctx, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockUserRepository)(nil).CreateIdentity), ctx, identity)
+}
+
+// CreateIdentityLink mocks base method.
+func (m *MockUserRepository) CreateIdentityLink(ctx context.Context, link *domain.IdentityLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentityLink", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentityLink indicates an expected call of CreateIdentityLink.
+func (mr *MockUserRepositoryMockRecorder) CreateIdentityLink(ctx, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentityLink", reflect.TypeOf((*MockUserRepository)(nil).CreateIdentityLink), ctx, link)
+}
+
+// CreateStepUp mocks base method.
+func (m *MockUserRepository) CreateStepUp(ctx context.Context, stepUp *domain.StepUp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStepUp", ctx, stepUp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateStepUp indicates an expected call of CreateStepUp.
+func (mr *MockUserRepositoryMockRecorder) CreateStepUp(ctx, stepUp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStepUp", reflect.TypeOf((*MockUserRepository)(nil).CreateStepUp), ctx, stepUp)
+}
+
+// CreateUpstreamLogin mocks base method.
+func (m *MockUserRepository) CreateUpstreamLogin(ctx context.Context, login *domain.UpstreamLogin) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpstreamLogin", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUpstreamLogin indicates an expected call of CreateUpstreamLogin.
+func (mr *MockUserRepositoryMockRecorder) CreateUpstreamLogin(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpstreamLogin", reflect.TypeOf((*MockUserRepository)(nil).CreateUpstreamLogin), ctx, login)
+}
+
+// CreateUserWithIdentity mocks base method.
+func (m *MockUserRepository) CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserWithIdentity", ctx, user, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserWithIdentity indicates an expected call of CreateUserWithIdentity.
+func (mr *MockUserRepositoryMockRecorder) CreateUserWithIdentity(ctx, user, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserWithIdentity", reflect.TypeOf((*MockUserRepository)(nil).CreateUserWithIdentity), ctx, user, identity)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockUserRepository) DeleteIdentity(ctx context.Context, userId, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockUserRepositoryMockRecorder) DeleteIdentity(ctx, userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockUserRepository)(nil).DeleteIdentity), ctx, userId, id)
+}
+
+// DeleteStepUp mocks base method.
+func (m *MockUserRepository) DeleteStepUp(ctx context.Context, challenge string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStepUp", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStepUp indicates an expected call of DeleteStepUp.
+func (mr *MockUserRepositoryMockRecorder) DeleteStepUp(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStepUp", reflect.TypeOf((*MockUserRepository)(nil).DeleteStepUp), ctx, challenge)
+}
+
+// GetIdentity mocks base method.
+func (m *MockUserRepository) GetIdentity(ctx context.Context, provider, subject string) (*domain.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(*domain.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockUserRepositoryMockRecorder) GetIdentity(ctx, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockUserRepository)(nil).GetIdentity), ctx, provider, subject)
+}
+
+// GetIdentityLink mocks base method.
+func (m *MockUserRepository) GetIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentityLink", ctx, tokenHash)
+	ret0, _ := ret[0].(*domain.IdentityLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentityLink indicates an expected call of GetIdentityLink.
+func (mr *MockUserRepositoryMockRecorder) GetIdentityLink(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentityLink", reflect.TypeOf((*MockUserRepository)(nil).GetIdentityLink), ctx, tokenHash)
+}
+
+// GetStepUp mocks base method.
+func (m *MockUserRepository) GetStepUp(ctx context.Context, challenge string) (*domain.StepUp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStepUp", ctx, challenge)
+	ret0, _ := ret[0].(*domain.StepUp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStepUp indicates an expected call of GetStepUp.
+func (mr *MockUserRepositoryMockRecorder) GetStepUp(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStepUp", reflect.TypeOf((*MockUserRepository)(nil).GetStepUp), ctx, challenge)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserRepositoryMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserById mocks base method.
+func (m *MockUserRepository) GetUserById(ctx context.Context, id uint32) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockUserRepositoryMockRecorder) GetUserById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepository)(nil).GetUserById), ctx, id)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockUserRepositoryMockRecorder) GetUserByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockUserRepository)(nil).GetUserByUsername), ctx, username)
+}
+
+// HasDevice mocks base method.
+func (m *MockUserRepository) HasDevice(ctx context.Context, userId uint32, deviceId string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasDevice", ctx, userId, deviceId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasDevice indicates an expected call of HasDevice.
+func (mr *MockUserRepositoryMockRecorder) HasDevice(ctx, userId, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDevice", reflect.TypeOf((*MockUserRepository)(nil).HasDevice), ctx, userId, deviceId)
+}
+
+// HasDevices mocks base method.
+func (m *MockUserRepository) HasDevices(ctx context.Context, userId uint32) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasDevices", ctx, userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasDevices indicates an expected call of HasDevices.
+func (mr *MockUserRepositoryMockRecorder) HasDevices(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDevices", reflect.TypeOf((*MockUserRepository)(nil).HasDevices), ctx, userId)
+}
+
+// List mocks base method.
+func (m *MockUserRepository) List(ctx context.Context, query *domain.UserListQuery) ([]domain.User, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, query)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserRepositoryMockRecorder) List(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, query)
+}
+
+// ListDevices mocks base method.
+func (m *MockUserRepository) ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userId)
+	ret0, _ := ret[0].([]domain.UserDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockUserRepositoryMockRecorder) ListDevices(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockUserRepository)(nil).ListDevices), ctx, userId)
+}
+
+// ListEmailChanges mocks base method.
+func (m *MockUserRepository) ListEmailChanges(ctx context.Context, userId uint32) ([]domain.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEmailChanges", ctx, userId)
+	ret0, _ := ret[0].([]domain.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEmailChanges indicates an expected call of ListEmailChanges.
+func (mr *MockUserRepositoryMockRecorder) ListEmailChanges(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEmailChanges", reflect.TypeOf((*MockUserRepository)(nil).ListEmailChanges), ctx, userId)
+}
+
+// ListIdentities mocks base method.
+func (m *MockUserRepository) ListIdentities(ctx context.Context, userId uint32) ([]domain.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", ctx, userId)
+	ret0, _ := ret[0].([]domain.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockUserRepositoryMockRecorder) ListIdentities(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockUserRepository)(nil).ListIdentities), ctx, userId)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockUserRepositoryMockRecorder) PurgeDeleted(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockUserRepository)(nil).PurgeDeleted), ctx, deletedBefore)
+}
+
+// RecordFailedSignin mocks base method.
+func (m *MockUserRepository) RecordFailedSignin(ctx context.Context, id uint32, threshold int, lockUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailedSignin", ctx, id, threshold, lockUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailedSignin indicates an expected call of RecordFailedSignin.
+func (mr *MockUserRepositoryMockRecorder) RecordFailedSignin(ctx, id, threshold, lockUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailedSignin", reflect.TypeOf((*MockUserRepository)(nil).RecordFailedSignin), ctx, id, threshold, lockUntil)
+}
+
+// RemovePasswordHash mocks base method.
+func (m *MockUserRepository) RemovePasswordHash(ctx context.Context, userId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePasswordHash", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePasswordHash indicates an expected call of RemovePasswordHash.
+func (mr *MockUserRepositoryMockRecorder) RemovePasswordHash(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePasswordHash", reflect.TypeOf((*MockUserRepository)(nil).RemovePasswordHash), ctx, userId)
+}
+
+// Restore mocks base method.
+func (m *MockUserRepository) Restore(ctx context.Context, restoreTokenHash string, deletedAfter time.Time) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, restoreTokenHash, deletedAfter)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserRepositoryMockRecorder) Restore(ctx, restoreTokenHash, deletedAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserRepository)(nil).Restore), ctx, restoreTokenHash, deletedAfter)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockUserRepository) RevertEmailChange(ctx context.Context, revertTokenHash string) (*domain.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", ctx, revertTokenHash)
+	ret0, _ := ret[0].(*domain.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockUserRepositoryMockRecorder) RevertEmailChange(ctx, revertTokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockUserRepository)(nil).RevertEmailChange), ctx, revertTokenHash)
+}
+
+// SaveDevice mocks base method.
+func (m *MockUserRepository) SaveDevice(ctx context.Context, device *domain.UserDevice) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDevice", ctx, device)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveDevice indicates an expected call of SaveDevice.
+func (mr *MockUserRepositoryMockRecorder) SaveDevice(ctx, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDevice", reflect.TypeOf((*MockUserRepository)(nil).SaveDevice), ctx, device)
+}
+
+// SoftDelete mocks base method.
+func (m *MockUserRepository) SoftDelete(ctx context.Context, id uint32, restoreTokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", ctx, id, restoreTokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockUserRepositoryMockRecorder) SoftDelete(ctx, id, restoreTokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockUserRepository)(nil).SoftDelete), ctx, id, restoreTokenHash)
+}
+
+// Suspend mocks base method.
+func (m *MockUserRepository) Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suspend", ctx, id, reason, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Suspend indicates an expected call of Suspend.
+func (mr *MockUserRepositoryMockRecorder) Suspend(ctx, id, reason, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspend", reflect.TypeOf((*MockUserRepository)(nil).Suspend), ctx, id, reason, until)
+}
+
+// TakeIdentityLink mocks base method.
+func (m *MockUserRepository) TakeIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeIdentityLink", ctx, tokenHash)
+	ret0, _ := ret[0].(*domain.IdentityLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeIdentityLink indicates an expected call of TakeIdentityLink.
+func (mr *MockUserRepositoryMockRecorder) TakeIdentityLink(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeIdentityLink", reflect.TypeOf((*MockUserRepository)(nil).TakeIdentityLink), ctx, tokenHash)
+}
+
+// TakeUpstreamLogin mocks base method.
+func (m *MockUserRepository) TakeUpstreamLogin(ctx context.Context, stateHash string) (*domain.UpstreamLogin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeUpstreamLogin", ctx, stateHash)
+	ret0, _ := ret[0].(*domain.UpstreamLogin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeUpstreamLogin indicates an expected call of TakeUpstreamLogin.
+func (mr *MockUserRepositoryMockRecorder) TakeUpstreamLogin(ctx, stateHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeUpstreamLogin", reflect.TypeOf((*MockUserRepository)(nil).TakeUpstreamLogin), ctx, stateHash)
+}
+
+// TouchIdentity mocks base method.
+func (m *MockUserRepository) TouchIdentity(ctx context.Context, id uint32, email string, lastUsed time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchIdentity", ctx, id, email, lastUsed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchIdentity indicates an expected call of TouchIdentity.
+func (mr *MockUserRepositoryMockRecorder) TouchIdentity(ctx, id, email, lastUsed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchIdentity", reflect.TypeOf((*MockUserRepository)(nil).TouchIdentity), ctx, id, email, lastUsed)
+}
+
+// Unlock mocks base method.
+func (m *MockUserRepository) Unlock(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockUserRepositoryMockRecorder) Unlock(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockUserRepository)(nil).Unlock), ctx, id)
+}
+
+// Unsuspend mocks base method.
+func (m *MockUserRepository) Unsuspend(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsuspend", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsuspend indicates an expected call of Unsuspend.
+func (mr *MockUserRepositoryMockRecorder) Unsuspend(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsuspend", reflect.TypeOf((*MockUserRepository)(nil).Unsuspend), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserRepositoryMockRecorder) Update(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, user)
+}
+
+// UpdateLastOnline mocks base method.
+func (m *MockUserRepository) UpdateLastOnline(ctx context.Context, lastOnline map[uint32]time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastOnline", ctx, lastOnline)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastOnline indicates an expected call of UpdateLastOnline.
+func (mr *MockUserRepositoryMockRecorder) UpdateLastOnline(ctx, lastOnline interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastOnline", reflect.TypeOf((*MockUserRepository)(nil).UpdateLastOnline), ctx, lastOnline)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, id uint32, passwordHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockUserRepositoryMockRecorder) UpdatePasswordHash(ctx, id, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockUserRepository)(nil).UpdatePasswordHash), ctx, id, passwordHash)
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuditRepositoryMockRecorder) Create(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuditRepository)(nil).Create), ctx, event)
+}
+
+// List mocks base method.
+func (m *MockAuditRepository) List(ctx context.Context, query *domain.AuditQuery) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, query)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAuditRepositoryMockRecorder) List(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAuditRepository)(nil).List), ctx, query)
+}
+
+// MockAuditSink is a mock of AuditSink interface.
+type MockAuditSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditSinkMockRecorder
+}
+
+// MockAuditSinkMockRecorder is the mock recorder for MockAuditSink.
+type MockAuditSinkMockRecorder struct {
+	mock *MockAuditSink
+}
+
+// NewMockAuditSink creates a new mock instance.
+func NewMockAuditSink(ctrl *gomock.Controller) *MockAuditSink {
+	mock := &MockAuditSink{ctrl: ctrl}
+	mock.recorder = &MockAuditSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditSink) EXPECT() *MockAuditSinkMockRecorder {
+	return m.recorder
+}
+
+// Write mocks base method.
+func (m *MockAuditSink) Write(event *domain.AuditEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Write", event)
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockAuditSinkMockRecorder) Write(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockAuditSink)(nil).Write), event)
+}
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method.
+func (m *MockNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", ctx, notification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockNotifierMockRecorder) Notify(ctx, notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockNotifier)(nil).Notify), ctx, notification)
+}
+
+// MockGeoLocator is a mock of GeoLocator interface.
+type MockGeoLocator struct {
+	ctrl     *gomock.Controller
+	recorder *MockGeoLocatorMockRecorder
+}
+
+// MockGeoLocatorMockRecorder is the mock recorder for MockGeoLocator.
+type MockGeoLocatorMockRecorder struct {
+	mock *MockGeoLocator
+}
+
+// NewMockGeoLocator creates a new mock instance.
+func NewMockGeoLocator(ctrl *gomock.Controller) *MockGeoLocator {
+	mock := &MockGeoLocator{ctrl: ctrl}
+	mock.recorder = &MockGeoLocatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGeoLocator) EXPECT() *MockGeoLocatorMockRecorder {
+	return m.recorder
+}
+
+// Locate mocks base method.
+func (m *MockGeoLocator) Locate(ip string) *domain.Location {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Locate", ip)
+	ret0, _ := ret[0].(*domain.Location)
+	return ret0
+}
+
+// Locate indicates an expected call of Locate.
+func (mr *MockGeoLocatorMockRecorder) Locate(ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Locate", reflect.TypeOf((*MockGeoLocator)(nil).Locate), ip)
+}
+
+// MockCredentialBackend is a mock of CredentialBackend interface.
+type MockCredentialBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockCredentialBackendMockRecorder
+}
+
+// MockCredentialBackendMockRecorder is the mock recorder for MockCredentialBackend.
+type MockCredentialBackendMockRecorder struct {
+	mock *MockCredentialBackend
+}
+
+// NewMockCredentialBackend creates a new mock instance.
+func NewMockCredentialBackend(ctrl *gomock.Controller) *MockCredentialBackend {
+	mock := &MockCredentialBackend{ctrl: ctrl}
+	mock.recorder = &MockCredentialBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCredentialBackend) EXPECT() *MockCredentialBackendMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockCredentialBackend) Find(ctx context.Context, login string) (*domain.DirectoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, login)
+	ret0, _ := ret[0].(*domain.DirectoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockCredentialBackendMockRecorder) Find(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockCredentialBackend)(nil).Find), ctx, login)
+}
+
+// Verify mocks base method.
+func (m *MockCredentialBackend) Verify(ctx context.Context, entry *domain.DirectoryEntry, password string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, entry, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockCredentialBackendMockRecorder) Verify(ctx, entry, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCredentialBackend)(nil).Verify), ctx, entry, password)
+}
+
+// MockIdentityProviders is a mock of IdentityProviders interface.
+type MockIdentityProviders struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityProvidersMockRecorder
+}
+
+// MockIdentityProvidersMockRecorder is the mock recorder for MockIdentityProviders.
+type MockIdentityProvidersMockRecorder struct {
+	mock *MockIdentityProviders
+}
+
+// NewMockIdentityProviders creates a new mock instance.
+func NewMockIdentityProviders(ctrl *gomock.Controller) *MockIdentityProviders {
+	mock := &MockIdentityProviders{ctrl: ctrl}
+	mock.recorder = &MockIdentityProvidersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityProviders) EXPECT() *MockIdentityProvidersMockRecorder {
+	return m.recorder
+}
+
+// AuthCodeURL mocks base method.
+func (m *MockIdentityProviders) AuthCodeURL(ctx context.Context, providerId, state, nonce, codeVerifier string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthCodeURL", ctx, providerId, state, nonce, codeVerifier)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthCodeURL indicates an expected call of AuthCodeURL.
+func (mr *MockIdentityProvidersMockRecorder) AuthCodeURL(ctx, providerId, state, nonce, codeVerifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthCodeURL", reflect.TypeOf((*MockIdentityProviders)(nil).AuthCodeURL), ctx, providerId, state, nonce, codeVerifier)
+}
+
+// Exchange mocks base method.
+func (m *MockIdentityProviders) Exchange(ctx context.Context, providerId, code, codeVerifier, nonce string) (*domain.UpstreamClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, providerId, code, codeVerifier, nonce)
+	ret0, _ := ret[0].(*domain.UpstreamClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockIdentityProvidersMockRecorder) Exchange(ctx, providerId, code, codeVerifier, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockIdentityProviders)(nil).Exchange), ctx, providerId, code, codeVerifier, nonce)
+}
+
+// List mocks base method.
+func (m *MockIdentityProviders) List() []domain.IdentityProvider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]domain.IdentityProvider)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockIdentityProvidersMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIdentityProviders)(nil).List))
+}
+
+// MockRoleRepository is a mock of RoleRepository interface.
+type MockRoleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepositoryMockRecorder
+}
+
+// MockRoleRepositoryMockRecorder is the mock recorder for MockRoleRepository.
+type MockRoleRepositoryMockRecorder struct {
+	mock *MockRoleRepository
+}
+
+// NewMockRoleRepository creates a new mock instance.
+func NewMockRoleRepository(ctrl *gomock.Controller) *MockRoleRepository {
+	mock := &MockRoleRepository{ctrl: ctrl}
+	mock.recorder = &MockRoleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepository) EXPECT() *MockRoleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockRoleRepository) AssignRole(ctx context.Context, userRole *domain.UserRole) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, userRole)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRoleRepositoryMockRecorder) AssignRole(ctx, userRole interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRoleRepository)(nil).AssignRole), ctx, userRole)
+}
+
+// GetUserPermissions mocks base method.
+func (m *MockRoleRepository) GetUserPermissions(ctx context.Context, userId uint32) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPermissions", ctx, userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPermissions indicates an expected call of GetUserPermissions.
+func (mr *MockRoleRepositoryMockRecorder) GetUserPermissions(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPermissions", reflect.TypeOf((*MockRoleRepository)(nil).GetUserPermissions), ctx, userId)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRoleRepository) GetUserRoles(ctx context.Context, userId uint32) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRoleRepositoryMockRecorder) GetUserRoles(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRoleRepository)(nil).GetUserRoles), ctx, userId)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRoleRepository) RevokeRole(ctx context.Context, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRoleRepositoryMockRecorder) RevokeRole(ctx, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRoleRepository)(nil).RevokeRole), ctx, userId, role)
+}
+
+// MockGroupRepository is a mock of GroupRepository interface.
+type MockGroupRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupRepositoryMockRecorder
+}
+
+// MockGroupRepositoryMockRecorder is the mock recorder for MockGroupRepository.
+type MockGroupRepositoryMockRecorder struct {
+	mock *MockGroupRepository
+}
+
+// NewMockGroupRepository creates a new mock instance.
+func NewMockGroupRepository(ctrl *gomock.Controller) *MockGroupRepository {
+	mock := &MockGroupRepository{ctrl: ctrl}
+	mock.recorder = &MockGroupRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupRepository) EXPECT() *MockGroupRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateGroup mocks base method.
+func (m *MockGroupRepository) CreateGroup(ctx context.Context, group *domain.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockGroupRepositoryMockRecorder) CreateGroup(ctx, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockGroupRepository)(nil).CreateGroup), ctx, group)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockGroupRepository) CreateOrganization(ctx context.Context, organization *domain.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, organization)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockGroupRepositoryMockRecorder) CreateOrganization(ctx, organization interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockGroupRepository)(nil).CreateOrganization), ctx, organization)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockGroupRepository) DeleteGroup(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockGroupRepositoryMockRecorder) DeleteGroup(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupRepository)(nil).DeleteGroup), ctx, id)
+}
+
+// DeleteGroupMember mocks base method.
+func (m *MockGroupRepository) DeleteGroupMember(ctx context.Context, groupId, userId uint32, dateUpdated time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupMember", ctx, groupId, userId, dateUpdated)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroupMember indicates an expected call of DeleteGroupMember.
+func (mr *MockGroupRepositoryMockRecorder) DeleteGroupMember(ctx, groupId, userId, dateUpdated interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupMember", reflect.TypeOf((*MockGroupRepository)(nil).DeleteGroupMember), ctx, groupId, userId, dateUpdated)
+}
+
+// GetGroup mocks base method.
+func (m *MockGroupRepository) GetGroup(ctx context.Context, id uint32) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, id)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupRepositoryMockRecorder) GetGroup(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroupRepository)(nil).GetGroup), ctx, id)
+}
+
+// GetOrganization mocks base method.
+func (m *MockGroupRepository) GetOrganization(ctx context.Context, id uint32) (*domain.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, id)
+	ret0, _ := ret[0].(*domain.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockGroupRepositoryMockRecorder) GetOrganization(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockGroupRepository)(nil).GetOrganization), ctx, id)
+}
+
+// ListGroups mocks base method.
+func (m *MockGroupRepository) ListGroups(ctx context.Context, query *domain.GroupListQuery) ([]domain.Group, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, query)
+	ret0, _ := ret[0].([]domain.Group)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockGroupRepositoryMockRecorder) ListGroups(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGroupRepository)(nil).ListGroups), ctx, query)
+}
+
+// ListOrganizations mocks base method.
+func (m *MockGroupRepository) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizations", ctx)
+	ret0, _ := ret[0].([]domain.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizations indicates an expected call of ListOrganizations.
+func (mr *MockGroupRepositoryMockRecorder) ListOrganizations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizations", reflect.TypeOf((*MockGroupRepository)(nil).ListOrganizations), ctx)
+}
+
+// ListUserGroups mocks base method.
+func (m *MockGroupRepository) ListUserGroups(ctx context.Context, userIds []uint32) (map[uint32][]domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserGroups", ctx, userIds)
+	ret0, _ := ret[0].(map[uint32][]domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserGroups indicates an expected call of ListUserGroups.
+func (mr *MockGroupRepositoryMockRecorder) ListUserGroups(ctx, userIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserGroups", reflect.TypeOf((*MockGroupRepository)(nil).ListUserGroups), ctx, userIds)
+}
+
+// SetGroupMember mocks base method.
+func (m *MockGroupRepository) SetGroupMember(ctx context.Context, member *domain.GroupMember, dateUpdated time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGroupMember", ctx, member, dateUpdated)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroupMember indicates an expected call of SetGroupMember.
+func (mr *MockGroupRepositoryMockRecorder) SetGroupMember(ctx, member, dateUpdated interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGroupMember", reflect.TypeOf((*MockGroupRepository)(nil).SetGroupMember), ctx, member, dateUpdated)
+}
+
+// UpdateGroup mocks base method.
+func (m *MockGroupRepository) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroup", ctx, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroup indicates an expected call of UpdateGroup.
+func (mr *MockGroupRepositoryMockRecorder) UpdateGroup(ctx, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupRepository)(nil).UpdateGroup), ctx, group)
+}
+
+// MockServiceAccountRepository is a mock of ServiceAccountRepository interface.
+type MockServiceAccountRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountRepositoryMockRecorder
+}
+
+// MockServiceAccountRepositoryMockRecorder is the mock recorder for MockServiceAccountRepository.
+type MockServiceAccountRepositoryMockRecorder struct {
+	mock *MockServiceAccountRepository
+}
+
+// NewMockServiceAccountRepository creates a new mock instance.
+func NewMockServiceAccountRepository(ctrl *gomock.Controller) *MockServiceAccountRepository {
+	mock := &MockServiceAccountRepository{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountRepository) EXPECT() *MockServiceAccountRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockServiceAccountRepository) CreateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockServiceAccountRepositoryMockRecorder) CreateServiceAccount(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockServiceAccountRepository)(nil).CreateServiceAccount), ctx, account)
+}
+
+// CreateServiceAccountKey mocks base method.
+func (m *MockServiceAccountRepository) CreateServiceAccountKey(ctx context.Context, key *domain.ServiceAccountKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccountKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateServiceAccountKey indicates an expected call of CreateServiceAccountKey.
+func (mr *MockServiceAccountRepositoryMockRecorder) CreateServiceAccountKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccountKey", reflect.TypeOf((*MockServiceAccountRepository)(nil).CreateServiceAccountKey), ctx, key)
+}
+
+// DeleteServiceAccount mocks base method.
+func (m *MockServiceAccountRepository) DeleteServiceAccount(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceAccount", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceAccount indicates an expected call of DeleteServiceAccount.
+func (mr *MockServiceAccountRepositoryMockRecorder) DeleteServiceAccount(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceAccount", reflect.TypeOf((*MockServiceAccountRepository)(nil).DeleteServiceAccount), ctx, id)
+}
+
+// GetServiceAccount mocks base method.
+func (m *MockServiceAccountRepository) GetServiceAccount(ctx context.Context, id uint32) (*domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccount", ctx, id)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccount indicates an expected call of GetServiceAccount.
+func (mr *MockServiceAccountRepositoryMockRecorder) GetServiceAccount(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccount", reflect.TypeOf((*MockServiceAccountRepository)(nil).GetServiceAccount), ctx, id)
+}
+
+// GetServiceAccountByClientId mocks base method.
+func (m *MockServiceAccountRepository) GetServiceAccountByClientId(ctx context.Context, clientId string) (*domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccountByClientId", ctx, clientId)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccountByClientId indicates an expected call of GetServiceAccountByClientId.
+func (mr *MockServiceAccountRepositoryMockRecorder) GetServiceAccountByClientId(ctx, clientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccountByClientId", reflect.TypeOf((*MockServiceAccountRepository)(nil).GetServiceAccountByClientId), ctx, clientId)
+}
+
+// GetServiceAccountKey mocks base method.
+func (m *MockServiceAccountRepository) GetServiceAccountKey(ctx context.Context, keyHash string) (*domain.ServiceAccountKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccountKey", ctx, keyHash)
+	ret0, _ := ret[0].(*domain.ServiceAccountKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccountKey indicates an expected call of GetServiceAccountKey.
+func (mr *MockServiceAccountRepositoryMockRecorder) GetServiceAccountKey(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccountKey", reflect.TypeOf((*MockServiceAccountRepository)(nil).GetServiceAccountKey), ctx, keyHash)
+}
+
+// ListServiceAccountKeys mocks base method.
+func (m *MockServiceAccountRepository) ListServiceAccountKeys(ctx context.Context, serviceAccountId uint32) ([]domain.ServiceAccountKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccountKeys", ctx, serviceAccountId)
+	ret0, _ := ret[0].([]domain.ServiceAccountKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccountKeys indicates an expected call of ListServiceAccountKeys.
+func (mr *MockServiceAccountRepositoryMockRecorder) ListServiceAccountKeys(ctx, serviceAccountId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccountKeys", reflect.TypeOf((*MockServiceAccountRepository)(nil).ListServiceAccountKeys), ctx, serviceAccountId)
+}
+
+// ListServiceAccounts mocks base method.
+func (m *MockServiceAccountRepository) ListServiceAccounts(ctx context.Context) ([]domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccounts", ctx)
+	ret0, _ := ret[0].([]domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccounts indicates an expected call of ListServiceAccounts.
+func (mr *MockServiceAccountRepositoryMockRecorder) ListServiceAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccounts", reflect.TypeOf((*MockServiceAccountRepository)(nil).ListServiceAccounts), ctx)
+}
+
+// RevokeServiceAccountKey mocks base method.
+func (m *MockServiceAccountRepository) RevokeServiceAccountKey(ctx context.Context, serviceAccountId, keyId uint32, dateRevoked time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeServiceAccountKey", ctx, serviceAccountId, keyId, dateRevoked)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeServiceAccountKey indicates an expected call of RevokeServiceAccountKey.
+func (mr *MockServiceAccountRepositoryMockRecorder) RevokeServiceAccountKey(ctx, serviceAccountId, keyId, dateRevoked interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeServiceAccountKey", reflect.TypeOf((*MockServiceAccountRepository)(nil).RevokeServiceAccountKey), ctx, serviceAccountId, keyId, dateRevoked)
+}
+
+// RotateServiceAccountKey mocks base method.
+func (m *MockServiceAccountRepository) RotateServiceAccountKey(ctx context.Context, keyId uint32, key *domain.ServiceAccountKey, dateExpires time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateServiceAccountKey", ctx, keyId, key, dateExpires)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateServiceAccountKey indicates an expected call of RotateServiceAccountKey.
+func (mr *MockServiceAccountRepositoryMockRecorder) RotateServiceAccountKey(ctx, keyId, key, dateExpires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateServiceAccountKey", reflect.TypeOf((*MockServiceAccountRepository)(nil).RotateServiceAccountKey), ctx, keyId, key, dateExpires)
+}
+
+// TouchServiceAccountKey mocks base method.
+func (m *MockServiceAccountRepository) TouchServiceAccountKey(ctx context.Context, id uint32, lastUsed time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchServiceAccountKey", ctx, id, lastUsed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchServiceAccountKey indicates an expected call of TouchServiceAccountKey.
+func (mr *MockServiceAccountRepositoryMockRecorder) TouchServiceAccountKey(ctx, id, lastUsed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchServiceAccountKey", reflect.TypeOf((*MockServiceAccountRepository)(nil).TouchServiceAccountKey), ctx, id, lastUsed)
+}
+
+// UpdateServiceAccount mocks base method.
+func (m *MockServiceAccountRepository) UpdateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateServiceAccount", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateServiceAccount indicates an expected call of UpdateServiceAccount.
+func (mr *MockServiceAccountRepositoryMockRecorder) UpdateServiceAccount(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServiceAccount", reflect.TypeOf((*MockServiceAccountRepository)(nil).UpdateServiceAccount), ctx, account)
+}
+
+// MockSessionRepository is a mock of SessionRepository interface.
+type MockSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryMockRecorder
+}
+
+// MockSessionRepositoryMockRecorder is the mock recorder for MockSessionRepository.
+type MockSessionRepositoryMockRecorder struct {
+	mock *MockSessionRepository
+}
+
+// NewMockSessionRepository creates a new mock instance.
+func NewMockSessionRepository(ctrl *gomock.Controller) *MockSessionRepository {
+	mock := &MockSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepository) EXPECT() *MockSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionRepositoryMockRecorder) Create(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessionRepository)(nil).Create), ctx, session)
+}
+
+// Delete mocks base method.
+func (m *MockSessionRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessionRepository)(nil).Delete), ctx, id)
+}
+
+// DeleteBySid mocks base method.
+func (m *MockSessionRepository) DeleteBySid(ctx context.Context, sid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBySid", ctx, sid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBySid indicates an expected call of DeleteBySid.
+func (mr *MockSessionRepositoryMockRecorder) DeleteBySid(ctx, sid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBySid", reflect.TypeOf((*MockSessionRepository)(nil).DeleteBySid), ctx, sid)
+}
+
+// DeleteBySubject mocks base method.
+func (m *MockSessionRepository) DeleteBySubject(ctx context.Context, subject, exceptId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBySubject", ctx, subject, exceptId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBySubject indicates an expected call of DeleteBySubject.
+func (mr *MockSessionRepositoryMockRecorder) DeleteBySubject(ctx, subject, exceptId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBySubject", reflect.TypeOf((*MockSessionRepository)(nil).DeleteBySubject), ctx, subject, exceptId)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockSessionRepository) DeleteExpired(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockSessionRepositoryMockRecorder) DeleteExpired(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockSessionRepository)(nil).DeleteExpired), ctx)
+}
+
+// Get mocks base method.
+func (m *MockSessionRepository) Get(ctx context.Context, id string) (*domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSessionRepositoryMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSessionRepository)(nil).Get), ctx, id)
+}
+
+// ListBySubject mocks base method.
+func (m *MockSessionRepository) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySubject", ctx, subject)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySubject indicates an expected call of ListBySubject.
+func (mr *MockSessionRepositoryMockRecorder) ListBySubject(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubject", reflect.TypeOf((*MockSessionRepository)(nil).ListBySubject), ctx, subject)
+}
+
+// Update mocks base method.
+func (m *MockSessionRepository) Update(ctx context.Context, session *domain.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSessionRepositoryMockRecorder) Update(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSessionRepository)(nil).Update), ctx, session)
+}
+
+// MockOAuth2 is a mock of OAuth2 interface.
+type MockOAuth2 struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2MockRecorder
+}
+
+// MockOAuth2MockRecorder is the mock recorder for MockOAuth2.
+type MockOAuth2MockRecorder struct {
+	mock *MockOAuth2
+}
+
+// NewMockOAuth2 creates a new mock instance.
+func NewMockOAuth2(ctrl *gomock.Controller) *MockOAuth2 {
+	mock := &MockOAuth2{ctrl: ctrl}
+	mock.recorder = &MockOAuth2MockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2) EXPECT() *MockOAuth2MockRecorder {
+	return m.recorder
+}
+
+// AcceptConsentRequest mocks base method.
+func (m *MockOAuth2) AcceptConsentRequest(context context.Context, challenge string, grantScope, grantAccessTokenAudience []string, session *domain.OA2ConsentSession, remember bool, rememberFor int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptConsentRequest", context, challenge, grantScope, grantAccessTokenAudience, session, remember, rememberFor)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptConsentRequest indicates an expected call of AcceptConsentRequest.
+func (mr *MockOAuth2MockRecorder) AcceptConsentRequest(context, challenge, grantScope, grantAccessTokenAudience, session, remember, rememberFor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptConsentRequest), context, challenge, grantScope, grantAccessTokenAudience, session, remember, rememberFor)
+}
+
+// AcceptLoginRequest mocks base method.
+func (m *MockOAuth2) AcceptLoginRequest(context context.Context, challenge, subject string, remember bool, rememberFor int64, acr string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptLoginRequest", context, challenge, subject, remember, rememberFor, acr)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptLoginRequest indicates an expected call of AcceptLoginRequest.
+func (mr *MockOAuth2MockRecorder) AcceptLoginRequest(context, challenge, subject, remember, rememberFor, acr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptLoginRequest), context, challenge, subject, remember, rememberFor, acr)
+}
+
+// AcceptLogoutRequest mocks base method.
+func (m *MockOAuth2) AcceptLogoutRequest(context context.Context, challenge string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptLogoutRequest", context, challenge)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptLogoutRequest indicates an expected call of AcceptLogoutRequest.
+func (mr *MockOAuth2MockRecorder) AcceptLogoutRequest(context, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptLogoutRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptLogoutRequest), context, challenge)
+}
+
+// CreateServiceClient mocks base method.
+func (m *MockOAuth2) CreateServiceClient(context context.Context, clientId, name, scope string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceClient", context, clientId, name, scope)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceClient indicates an expected call of CreateServiceClient.
+func (mr *MockOAuth2MockRecorder) CreateServiceClient(context, clientId, name, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceClient", reflect.TypeOf((*MockOAuth2)(nil).CreateServiceClient), context, clientId, name, scope)
+}
+
+// DeleteServiceClient mocks base method.
+func (m *MockOAuth2) DeleteServiceClient(context context.Context, clientId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceClient", context, clientId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceClient indicates an expected call of DeleteServiceClient.
+func (mr *MockOAuth2MockRecorder) DeleteServiceClient(context, clientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceClient", reflect.TypeOf((*MockOAuth2)(nil).DeleteServiceClient), context, clientId)
+}
+
+// GenerateLogoutURL mocks base method.
+func (m *MockOAuth2) GenerateLogoutURL(idTokenHint, state, postLogoutRedirectUri string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateLogoutURL", idTokenHint, state, postLogoutRedirectUri)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GenerateLogoutURL indicates an expected call of GenerateLogoutURL.
+func (mr *MockOAuth2MockRecorder) GenerateLogoutURL(idTokenHint, state, postLogoutRedirectUri interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateLogoutURL", reflect.TypeOf((*MockOAuth2)(nil).GenerateLogoutURL), idTokenHint, state, postLogoutRedirectUri)
+}
+
+// GetAuthCodeUrl mocks base method.
+func (m *MockOAuth2) GetAuthCodeUrl() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthCodeUrl")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetAuthCodeUrl indicates an expected call of GetAuthCodeUrl.
+func (mr *MockOAuth2MockRecorder) GetAuthCodeUrl() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthCodeUrl", reflect.TypeOf((*MockOAuth2)(nil).GetAuthCodeUrl))
+}
+
+// GetConsentRequest mocks base method.
+func (m *MockOAuth2) GetConsentRequest(context context.Context, challenge string) (*domain.OA2ConsentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConsentRequest", context, challenge)
+	ret0, _ := ret[0].(*domain.OA2ConsentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConsentRequest indicates an expected call of GetConsentRequest.
+func (mr *MockOAuth2MockRecorder) GetConsentRequest(context, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).GetConsentRequest), context, challenge)
+}
+
+// GetLoginRequest mocks base method.
+func (m *MockOAuth2) GetLoginRequest(context context.Context, challenge string) (*domain.OA2LoginRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginRequest", context, challenge)
+	ret0, _ := ret[0].(*domain.OA2LoginRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginRequest indicates an expected call of GetLoginRequest.
+func (mr *MockOAuth2MockRecorder) GetLoginRequest(context, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).GetLoginRequest), context, challenge)
+}
+
+// GetLogoutRequest mocks base method.
+func (m *MockOAuth2) GetLogoutRequest(context context.Context, challenge string) (*domain.OA2LogoutRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogoutRequest", context, challenge)
+	ret0, _ := ret[0].(*domain.OA2LogoutRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogoutRequest indicates an expected call of GetLogoutRequest.
+func (mr *MockOAuth2MockRecorder) GetLogoutRequest(context, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogoutRequest", reflect.TypeOf((*MockOAuth2)(nil).GetLogoutRequest), context, challenge)
+}
+
+// IntrospectOAuth2Token mocks base method.
+func (m *MockOAuth2) IntrospectOAuth2Token(context context.Context, accessToken string) (*domain.OA2TokenIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IntrospectOAuth2Token", context, accessToken)
+	ret0, _ := ret[0].(*domain.OA2TokenIntrospection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IntrospectOAuth2Token indicates an expected call of IntrospectOAuth2Token.
+func (mr *MockOAuth2MockRecorder) IntrospectOAuth2Token(context, accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectOAuth2Token", reflect.TypeOf((*MockOAuth2)(nil).IntrospectOAuth2Token), context, accessToken)
+}
+
+// ListConsentGrants mocks base method.
+func (m *MockOAuth2) ListConsentGrants(context context.Context, subject string) ([]domain.OA2ConsentGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConsentGrants", context, subject)
+	ret0, _ := ret[0].([]domain.OA2ConsentGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConsentGrants indicates an expected call of ListConsentGrants.
+func (mr *MockOAuth2MockRecorder) ListConsentGrants(context, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConsentGrants", reflect.TypeOf((*MockOAuth2)(nil).ListConsentGrants), context, subject)
+}
+
+// RefreshToken mocks base method.
+func (m *MockOAuth2) RefreshToken(ctx context.Context, token *domain.Token) (*domain.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, token)
+	ret0, _ := ret[0].(*domain.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockOAuth2MockRecorder) RefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockOAuth2)(nil).RefreshToken), ctx, token)
+}
+
+// RejectConsentRequest mocks base method.
+func (m *MockOAuth2) RejectConsentRequest(context context.Context, challenge, errStr, errDescStr string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectConsentRequest", context, challenge, errStr, errDescStr)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectConsentRequest indicates an expected call of RejectConsentRequest.
+func (mr *MockOAuth2MockRecorder) RejectConsentRequest(context, challenge, errStr, errDescStr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectConsentRequest), context, challenge, errStr, errDescStr)
+}
+
+// RejectLoginRequest mocks base method.
+func (m *MockOAuth2) RejectLoginRequest(context context.Context, challenge, errStr, errDescStr string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectLoginRequest", context, challenge, errStr, errDescStr)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectLoginRequest indicates an expected call of RejectLoginRequest.
+func (mr *MockOAuth2MockRecorder) RejectLoginRequest(context, challenge, errStr, errDescStr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectLoginRequest), context, challenge, errStr, errDescStr)
+}
+
+// RejectLogoutRequest mocks base method.
+func (m *MockOAuth2) RejectLogoutRequest(context context.Context, challenge string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectLogoutRequest", context, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectLogoutRequest indicates an expected call of RejectLogoutRequest.
+func (mr *MockOAuth2MockRecorder) RejectLogoutRequest(context, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectLogoutRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectLogoutRequest), context, challenge)
+}
+
+// RevokeConsentSessions mocks base method.
+func (m *MockOAuth2) RevokeConsentSessions(context context.Context, subject string, exceptOwnClient bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeConsentSessions", context, subject, exceptOwnClient)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeConsentSessions indicates an expected call of RevokeConsentSessions.
+func (mr *MockOAuth2MockRecorder) RevokeConsentSessions(context, subject, exceptOwnClient interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeConsentSessions", reflect.TypeOf((*MockOAuth2)(nil).RevokeConsentSessions), context, subject, exceptOwnClient)
+}
+
+// RevokeLoginSessions mocks base method.
+func (m *MockOAuth2) RevokeLoginSessions(context context.Context, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeLoginSessions", context, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeLoginSessions indicates an expected call of RevokeLoginSessions.
+func (mr *MockOAuth2MockRecorder) RevokeLoginSessions(context, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeLoginSessions", reflect.TypeOf((*MockOAuth2)(nil).RevokeLoginSessions), context, subject)
+}
+
+// TokenExchange mocks base method.
+func (m *MockOAuth2) TokenExchange(ctx context.Context, code string) (*domain.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TokenExchange", ctx, code)
+	ret0, _ := ret[0].(*domain.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TokenExchange indicates an expected call of TokenExchange.
+func (mr *MockOAuth2MockRecorder) TokenExchange(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenExchange", reflect.TypeOf((*MockOAuth2)(nil).TokenExchange), ctx, code)
+}
+
+// UpdateServiceClient mocks base method.
+func (m *MockOAuth2) UpdateServiceClient(context context.Context, clientId, name, scope string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateServiceClient", context, clientId, name, scope)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateServiceClient indicates an expected call of UpdateServiceClient.
+func (mr *MockOAuth2MockRecorder) UpdateServiceClient(context, clientId, name, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServiceClient", reflect.TypeOf((*MockOAuth2)(nil).UpdateServiceClient), context, clientId, name, scope)
+}
+
+// VerifyLogoutToken mocks base method.
+func (m *MockOAuth2) VerifyLogoutToken(ctx context.Context, logoutToken string) (*domain.OA2LogoutToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyLogoutToken", ctx, logoutToken)
+	ret0, _ := ret[0].(*domain.OA2LogoutToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyLogoutToken indicates an expected call of VerifyLogoutToken.
+func (mr *MockOAuth2MockRecorder) VerifyLogoutToken(ctx, logoutToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyLogoutToken", reflect.TypeOf((*MockOAuth2)(nil).VerifyLogoutToken), ctx, logoutToken)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockUser) ChangePassword(ctx context.Context, id uint32, inputUserData *service.UserChangePasswordInput) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, id, inputUserData)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserMockRecorder) ChangePassword(ctx, id, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUser)(nil).ChangePassword), ctx, id, inputUserData)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockUser) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, token)
+	ret0, _ := ret[0].(*domain.EmailChange)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserMockRecorder) ConfirmEmailChange(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUser)(nil).ConfirmEmailChange), ctx, token)
+}
+
+// Delete mocks base method.
+func (m *MockUser) Delete(ctx context.Context, id uint32, inputUserData *service.UserDeleteInput) (*domain.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, inputUserData)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserMockRecorder) Delete(ctx, id, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUser)(nil).Delete), ctx, id, inputUserData)
+}
+
+// GetUserById mocks base method.
+func (m *MockUser) GetUserById(ctx context.Context, id uint32) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockUserMockRecorder) GetUserById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUser)(nil).GetUserById), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockUser) List(ctx context.Context, input *service.UserListInput) (*domain.UserPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, input)
+	ret0, _ := ret[0].(*domain.UserPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserMockRecorder) List(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUser)(nil).List), ctx, input)
+}
+
+// ListEmailChanges mocks base method.
+func (m *MockUser) ListEmailChanges(ctx context.Context, id uint32) ([]domain.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEmailChanges", ctx, id)
+	ret0, _ := ret[0].([]domain.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEmailChanges indicates an expected call of ListEmailChanges.
+func (mr *MockUserMockRecorder) ListEmailChanges(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEmailChanges", reflect.TypeOf((*MockUser)(nil).ListEmailChanges), ctx, id)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockUser) PurgeDeleted(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockUserMockRecorder) PurgeDeleted(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockUser)(nil).PurgeDeleted), ctx)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockUser) RequestEmailChange(ctx context.Context, id uint32, inputUserData *service.UserEmailChangeInput) (*domain.EmailChange, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, id, inputUserData)
+	ret0, _ := ret[0].(*domain.EmailChange)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockUserMockRecorder) RequestEmailChange(ctx, id, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockUser)(nil).RequestEmailChange), ctx, id, inputUserData)
+}
+
+// Restore mocks base method.
+func (m *MockUser) Restore(ctx context.Context, restoreToken string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, restoreToken)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserMockRecorder) Restore(ctx, restoreToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUser)(nil).Restore), ctx, restoreToken)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockUser) RevertEmailChange(ctx context.Context, revertToken string) (*domain.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", ctx, revertToken)
+	ret0, _ := ret[0].(*domain.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockUserMockRecorder) RevertEmailChange(ctx, revertToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockUser)(nil).RevertEmailChange), ctx, revertToken)
+}
+
+// SignIn mocks base method.
+func (m *MockUser) SignIn(ctx context.Context, inputUserData *service.UserSignInInput) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignIn", ctx, inputUserData)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignIn indicates an expected call of SignIn.
+func (mr *MockUserMockRecorder) SignIn(ctx, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockUser)(nil).SignIn), ctx, inputUserData)
+}
+
+// SignUp mocks base method.
+func (m *MockUser) SignUp(ctx context.Context, inputUserData *service.UserSignUpInput) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignUp", ctx, inputUserData)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignUp indicates an expected call of SignUp.
+func (mr *MockUserMockRecorder) SignUp(ctx, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockUser)(nil).SignUp), ctx, inputUserData)
+}
+
+// Suspend mocks base method.
+func (m *MockUser) Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suspend", ctx, id, reason, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Suspend indicates an expected call of Suspend.
+func (mr *MockUserMockRecorder) Suspend(ctx, id, reason, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspend", reflect.TypeOf((*MockUser)(nil).Suspend), ctx, id, reason, until)
+}
+
+// Unlock mocks base method.
+func (m *MockUser) Unlock(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockUserMockRecorder) Unlock(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockUser)(nil).Unlock), ctx, id)
+}
+
+// Unsuspend mocks base method.
+func (m *MockUser) Unsuspend(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsuspend", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsuspend indicates an expected call of Unsuspend.
+func (mr *MockUserMockRecorder) Unsuspend(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsuspend", reflect.TypeOf((*MockUser)(nil).Unsuspend), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockUser) Update(ctx context.Context, id, version uint32, inputUserData *service.UserUpdateInput) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, version, inputUserData)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserMockRecorder) Update(ctx, id, version, inputUserData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUser)(nil).Update), ctx, id, version, inputUserData)
+}
+
+// UpdateLastOnline mocks base method.
+func (m *MockUser) UpdateLastOnline(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastOnline", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastOnline indicates an expected call of UpdateLastOnline.
+func (mr *MockUserMockRecorder) UpdateLastOnline(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastOnline", reflect.TypeOf((*MockUser)(nil).UpdateLastOnline), ctx, id)
+}
+
+// MockRBAC is a mock of RBAC interface.
+type MockRBAC struct {
+	ctrl     *gomock.Controller
+	recorder *MockRBACMockRecorder
+}
+
+// MockRBACMockRecorder is the mock recorder for MockRBAC.
+type MockRBACMockRecorder struct {
+	mock *MockRBAC
+}
+
+// NewMockRBAC creates a new mock instance.
+func NewMockRBAC(ctrl *gomock.Controller) *MockRBAC {
+	mock := &MockRBAC{ctrl: ctrl}
+	mock.recorder = &MockRBACMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRBAC) EXPECT() *MockRBACMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockRBAC) AssignRole(ctx context.Context, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRBACMockRecorder) AssignRole(ctx, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRBAC)(nil).AssignRole), ctx, userId, role)
+}
+
+// Can mocks base method.
+func (m *MockRBAC) Can(ctx context.Context, subject uint32, permission, resource string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Can", ctx, subject, permission, resource)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Can indicates an expected call of Can.
+func (mr *MockRBACMockRecorder) Can(ctx, subject, permission, resource interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Can", reflect.TypeOf((*MockRBAC)(nil).Can), ctx, subject, permission, resource)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRBAC) GetUserRoles(ctx context.Context, userId uint32) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRBACMockRecorder) GetUserRoles(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRBAC)(nil).GetUserRoles), ctx, userId)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRBAC) RevokeRole(ctx context.Context, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRBACMockRecorder) RevokeRole(ctx, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRBAC)(nil).RevokeRole), ctx, userId, role)
+}
+
+// MockSessions is a mock of Sessions interface.
+type MockSessions struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsMockRecorder
+}
+
+// MockSessionsMockRecorder is the mock recorder for MockSessions.
+type MockSessionsMockRecorder struct {
+	mock *MockSessions
+}
+
+// NewMockSessions creates a new mock instance.
+func NewMockSessions(ctrl *gomock.Controller) *MockSessions {
+	mock := &MockSessions{ctrl: ctrl}
+	mock.recorder = &MockSessionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessions) EXPECT() *MockSessionsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSessions) Create(ctx context.Context, token *domain.Token) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionsMockRecorder) Create(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessions)(nil).Create), ctx, token)
+}
+
+// Delete mocks base method.
+func (m *MockSessions) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionsMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessions)(nil).Delete), ctx, id)
+}
+
+// DeleteBySid mocks base method.
+func (m *MockSessions) DeleteBySid(ctx context.Context, sid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBySid", ctx, sid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBySid indicates an expected call of DeleteBySid.
+func (mr *MockSessionsMockRecorder) DeleteBySid(ctx, sid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBySid", reflect.TypeOf((*MockSessions)(nil).DeleteBySid), ctx, sid)
+}
+
+// DeleteBySubject mocks base method.
+func (m *MockSessions) DeleteBySubject(ctx context.Context, subject, exceptId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBySubject", ctx, subject, exceptId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBySubject indicates an expected call of DeleteBySubject.
+func (mr *MockSessionsMockRecorder) DeleteBySubject(ctx, subject, exceptId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBySubject", reflect.TypeOf((*MockSessions)(nil).DeleteBySubject), ctx, subject, exceptId)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockSessions) DeleteExpired(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockSessionsMockRecorder) DeleteExpired(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockSessions)(nil).DeleteExpired), ctx)
+}
+
+// Get mocks base method.
+func (m *MockSessions) Get(ctx context.Context, id string) (*domain.Session, *domain.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.Session)
+	ret1, _ := ret[1].(*domain.Token)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSessionsMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSessions)(nil).Get), ctx, id)
+}
+
+// ListBySubject mocks base method.
+func (m *MockSessions) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySubject", ctx, subject)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySubject indicates an expected call of ListBySubject.
+func (mr *MockSessionsMockRecorder) ListBySubject(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubject", reflect.TypeOf((*MockSessions)(nil).ListBySubject), ctx, subject)
+}
+
+// UpdateTokens mocks base method.
+func (m *MockSessions) UpdateTokens(ctx context.Context, id string, token *domain.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokens", ctx, id, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTokens indicates an expected call of UpdateTokens.
+func (mr *MockSessionsMockRecorder) UpdateTokens(ctx, id, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokens", reflect.TypeOf((*MockSessions)(nil).UpdateTokens), ctx, id, token)
+}
+
+// MockFlow is a mock of Flow interface.
+type MockFlow struct {
+	ctrl     *gomock.Controller
+	recorder *MockFlowMockRecorder
+}
+
+// MockFlowMockRecorder is the mock recorder for MockFlow.
+type MockFlowMockRecorder struct {
+	mock *MockFlow
+}
+
+// NewMockFlow creates a new mock instance.
+func NewMockFlow(ctrl *gomock.Controller) *MockFlow {
+	mock := &MockFlow{ctrl: ctrl}
+	mock.recorder = &MockFlowMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFlow) EXPECT() *MockFlowMockRecorder {
+	return m.recorder
+}
+
+// GetConsentFlow mocks base method.
+func (m *MockFlow) GetConsentFlow(ctx context.Context, challenge string) (*domain.ConsentFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConsentFlow", ctx, challenge)
+	ret0, _ := ret[0].(*domain.ConsentFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConsentFlow indicates an expected call of GetConsentFlow.
+func (mr *MockFlowMockRecorder) GetConsentFlow(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsentFlow", reflect.TypeOf((*MockFlow)(nil).GetConsentFlow), ctx, challenge)
+}
+
+// GetLoginFlow mocks base method.
+func (m *MockFlow) GetLoginFlow(ctx context.Context, challenge string) (*domain.LoginFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFlow", ctx, challenge)
+	ret0, _ := ret[0].(*domain.LoginFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFlow indicates an expected call of GetLoginFlow.
+func (mr *MockFlowMockRecorder) GetLoginFlow(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFlow", reflect.TypeOf((*MockFlow)(nil).GetLoginFlow), ctx, challenge)
+}
+
+// GetLogoutFlow mocks base method.
+func (m *MockFlow) GetLogoutFlow(ctx context.Context, challenge string) (*domain.LogoutFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogoutFlow", ctx, challenge)
+	ret0, _ := ret[0].(*domain.LogoutFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogoutFlow indicates an expected call of GetLogoutFlow.
+func (mr *MockFlowMockRecorder) GetLogoutFlow(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogoutFlow", reflect.TypeOf((*MockFlow)(nil).GetLogoutFlow), ctx, challenge)
+}
+
+// StartUpstreamLogin mocks base method.
+func (m *MockFlow) StartUpstreamLogin(ctx context.Context, challenge string, input *service.UpstreamLoginStartInput) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartUpstreamLogin", ctx, challenge, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartUpstreamLogin indicates an expected call of StartUpstreamLogin.
+func (mr *MockFlowMockRecorder) StartUpstreamLogin(ctx, challenge, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartUpstreamLogin", reflect.TypeOf((*MockFlow)(nil).StartUpstreamLogin), ctx, challenge, input)
+}
+
+// SubmitConsent mocks base method.
+func (m *MockFlow) SubmitConsent(ctx context.Context, challenge string, input *service.ConsentSubmitInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitConsent", ctx, challenge, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitConsent indicates an expected call of SubmitConsent.
+func (mr *MockFlowMockRecorder) SubmitConsent(ctx, challenge, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitConsent", reflect.TypeOf((*MockFlow)(nil).SubmitConsent), ctx, challenge, input)
+}
+
+// SubmitIdentityLink mocks base method.
+func (m *MockFlow) SubmitIdentityLink(ctx context.Context, input *service.IdentityLinkSubmitInput) (*domain.LoginFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitIdentityLink", ctx, input)
+	ret0, _ := ret[0].(*domain.LoginFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitIdentityLink indicates an expected call of SubmitIdentityLink.
+func (mr *MockFlowMockRecorder) SubmitIdentityLink(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitIdentityLink", reflect.TypeOf((*MockFlow)(nil).SubmitIdentityLink), ctx, input)
+}
+
+// SubmitLogin mocks base method.
+func (m *MockFlow) SubmitLogin(ctx context.Context, challenge string, input *service.LoginSubmitInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitLogin", ctx, challenge, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitLogin indicates an expected call of SubmitLogin.
+func (mr *MockFlowMockRecorder) SubmitLogin(ctx, challenge, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitLogin", reflect.TypeOf((*MockFlow)(nil).SubmitLogin), ctx, challenge, input)
+}
+
+// SubmitLoginCode mocks base method.
+func (m *MockFlow) SubmitLoginCode(ctx context.Context, challenge, code string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitLoginCode", ctx, challenge, code)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitLoginCode indicates an expected call of SubmitLoginCode.
+func (mr *MockFlowMockRecorder) SubmitLoginCode(ctx, challenge, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitLoginCode", reflect.TypeOf((*MockFlow)(nil).SubmitLoginCode), ctx, challenge, code)
+}
+
+// SubmitLogout mocks base method.
+func (m *MockFlow) SubmitLogout(ctx context.Context, challenge string, accept bool) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitLogout", ctx, challenge, accept)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitLogout indicates an expected call of SubmitLogout.
+func (mr *MockFlowMockRecorder) SubmitLogout(ctx, challenge, accept interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitLogout", reflect.TypeOf((*MockFlow)(nil).SubmitLogout), ctx, challenge, accept)
+}
+
+// SubmitUpstreamLogin mocks base method.
+func (m *MockFlow) SubmitUpstreamLogin(ctx context.Context, input *service.UpstreamLoginSubmitInput) (*domain.LoginFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitUpstreamLogin", ctx, input)
+	ret0, _ := ret[0].(*domain.LoginFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitUpstreamLogin indicates an expected call of SubmitUpstreamLogin.
+func (mr *MockFlowMockRecorder) SubmitUpstreamLogin(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitUpstreamLogin", reflect.TypeOf((*MockFlow)(nil).SubmitUpstreamLogin), ctx, input)
+}
+
+// MockAccount is a mock of Account interface.
+type MockAccount struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountMockRecorder
+}
+
+// MockAccountMockRecorder is the mock recorder for MockAccount.
+type MockAccountMockRecorder struct {
+	mock *MockAccount
+}
+
+// NewMockAccount creates a new mock instance.
+func NewMockAccount(ctrl *gomock.Controller) *MockAccount {
+	mock := &MockAccount{ctrl: ctrl}
+	mock.recorder = &MockAccountMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccount) EXPECT() *MockAccountMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockAccount) ChangePassword(ctx context.Context, userId uint32, input *service.ChangePasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAccountMockRecorder) ChangePassword(ctx, userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAccount)(nil).ChangePassword), ctx, userId, input)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockAccount) ConfirmEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockAccountMockRecorder) ConfirmEmailChange(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockAccount)(nil).ConfirmEmailChange), ctx, token)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAccount) DeleteAccount(ctx context.Context, userId uint32, input *service.DeleteAccountInput) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, userId, input)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAccountMockRecorder) DeleteAccount(ctx, userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccount)(nil).DeleteAccount), ctx, userId, input)
+}
+
+// Export mocks base method.
+func (m *MockAccount) Export(ctx context.Context, userId uint32) (*domain.AccountExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, userId)
+	ret0, _ := ret[0].(*domain.AccountExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockAccountMockRecorder) Export(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockAccount)(nil).Export), ctx, userId)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockAccount) RequestEmailChange(ctx context.Context, userId uint32, input *service.EmailChangeInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockAccountMockRecorder) RequestEmailChange(ctx, userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockAccount)(nil).RequestEmailChange), ctx, userId, input)
+}
+
+// RestoreAccount mocks base method.
+func (m *MockAccount) RestoreAccount(ctx context.Context, restoreToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreAccount", ctx, restoreToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreAccount indicates an expected call of RestoreAccount.
+func (mr *MockAccountMockRecorder) RestoreAccount(ctx, restoreToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreAccount", reflect.TypeOf((*MockAccount)(nil).RestoreAccount), ctx, restoreToken)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockAccount) RevertEmailChange(ctx context.Context, revertToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", ctx, revertToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockAccountMockRecorder) RevertEmailChange(ctx, revertToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockAccount)(nil).RevertEmailChange), ctx, revertToken)
+}
+
+// SendVerificationCode mocks base method.
+func (m *MockAccount) SendVerificationCode(ctx context.Context, userId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationCode", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationCode indicates an expected call of SendVerificationCode.
+func (mr *MockAccountMockRecorder) SendVerificationCode(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationCode", reflect.TypeOf((*MockAccount)(nil).SendVerificationCode), ctx, userId)
+}
+
+// SignUp mocks base method.
+func (m *MockAccount) SignUp(ctx context.Context, input *service.UserSignUpInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignUp", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignUp indicates an expected call of SignUp.
+func (mr *MockAccountMockRecorder) SignUp(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockAccount)(nil).SignUp), ctx, input)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockAudit) List(ctx context.Context, input *service.AuditListInput) (*domain.AuditPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, input)
+	ret0, _ := ret[0].(*domain.AuditPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAuditMockRecorder) List(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAudit)(nil).List), ctx, input)
+}
+
+// Record mocks base method.
+func (m *MockAudit) Record(ctx context.Context, event *domain.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditMockRecorder) Record(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAudit)(nil).Record), ctx, event)
+}
+
+// MockAdmin is a mock of Admin interface.
+type MockAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminMockRecorder
+}
+
+// MockAdminMockRecorder is the mock recorder for MockAdmin.
+type MockAdminMockRecorder struct {
+	mock *MockAdmin
+}
+
+// NewMockAdmin creates a new mock instance.
+func NewMockAdmin(ctrl *gomock.Controller) *MockAdmin {
+	mock := &MockAdmin{ctrl: ctrl}
+	mock.recorder = &MockAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdmin) EXPECT() *MockAdminMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockAdmin) AssignRole(ctx context.Context, actorId, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, actorId, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockAdminMockRecorder) AssignRole(ctx, actorId, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockAdmin)(nil).AssignRole), ctx, actorId, userId, role)
+}
+
+// LogoutUser mocks base method.
+func (m *MockAdmin) LogoutUser(ctx context.Context, actorId, userId uint32, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutUser", ctx, actorId, userId, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutUser indicates an expected call of LogoutUser.
+func (mr *MockAdminMockRecorder) LogoutUser(ctx, actorId, userId, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutUser", reflect.TypeOf((*MockAdmin)(nil).LogoutUser), ctx, actorId, userId, reason)
+}
+
+// RevokeRole mocks base method.
+func (m *MockAdmin) RevokeRole(ctx context.Context, actorId, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, actorId, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockAdminMockRecorder) RevokeRole(ctx, actorId, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockAdmin)(nil).RevokeRole), ctx, actorId, userId, role)
+}
+
+// SuspendUser mocks base method.
+func (m *MockAdmin) SuspendUser(ctx context.Context, actorId, userId uint32, reason string, until *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, actorId, userId, reason, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockAdminMockRecorder) SuspendUser(ctx, actorId, userId, reason, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockAdmin)(nil).SuspendUser), ctx, actorId, userId, reason, until)
+}
+
+// UnlockUser mocks base method.
+func (m *MockAdmin) UnlockUser(ctx context.Context, actorId, userId uint32, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, actorId, userId, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockAdminMockRecorder) UnlockUser(ctx, actorId, userId, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockAdmin)(nil).UnlockUser), ctx, actorId, userId, reason)
+}
+
+// UnsuspendUser mocks base method.
+func (m *MockAdmin) UnsuspendUser(ctx context.Context, actorId, userId uint32, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsuspendUser", ctx, actorId, userId, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsuspendUser indicates an expected call of UnsuspendUser.
+func (mr *MockAdminMockRecorder) UnsuspendUser(ctx, actorId, userId, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsuspendUser", reflect.TypeOf((*MockAdmin)(nil).UnsuspendUser), ctx, actorId, userId, reason)
+}
+
+// MockDevices is a mock of Devices interface.
+type MockDevices struct {
+	ctrl     *gomock.Controller
+	recorder *MockDevicesMockRecorder
+}
+
+// MockDevicesMockRecorder is the mock recorder for MockDevices.
+type MockDevicesMockRecorder struct {
+	mock *MockDevices
+}
+
+// NewMockDevices creates a new mock instance.
+func NewMockDevices(ctrl *gomock.Controller) *MockDevices {
+	mock := &MockDevices{ctrl: ctrl}
+	mock.recorder = &MockDevicesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDevices) EXPECT() *MockDevicesMockRecorder {
+	return m.recorder
+}
+
+// Describe mocks base method.
+func (m *MockDevices) Describe(ctx context.Context, deviceId string) map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Describe", ctx, deviceId)
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// Describe indicates an expected call of Describe.
+func (mr *MockDevicesMockRecorder) Describe(ctx, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Describe", reflect.TypeOf((*MockDevices)(nil).Describe), ctx, deviceId)
+}
+
+// Identify mocks base method.
+func (m *MockDevices) Identify(cookie string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Identify", cookie)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Identify indicates an expected call of Identify.
+func (mr *MockDevicesMockRecorder) Identify(cookie interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identify", reflect.TypeOf((*MockDevices)(nil).Identify), cookie)
+}
+
+// ListDevices mocks base method.
+func (m *MockDevices) ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userId)
+	ret0, _ := ret[0].([]domain.UserDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockDevicesMockRecorder) ListDevices(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockDevices)(nil).ListDevices), ctx, userId)
+}
+
+// LoginHistory mocks base method.
+func (m *MockDevices) LoginHistory(ctx context.Context, userId uint32) ([]domain.LoginRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginHistory", ctx, userId)
+	ret0, _ := ret[0].([]domain.LoginRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginHistory indicates an expected call of LoginHistory.
+func (mr *MockDevicesMockRecorder) LoginHistory(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginHistory", reflect.TypeOf((*MockDevices)(nil).LoginHistory), ctx, userId)
+}
+
+// Recognize mocks base method.
+func (m *MockDevices) Recognize(ctx context.Context, user *domain.User, deviceId string, details map[string]string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recognize", ctx, user, deviceId, details)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recognize indicates an expected call of Recognize.
+func (mr *MockDevicesMockRecorder) Recognize(ctx, user, deviceId, details interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recognize", reflect.TypeOf((*MockDevices)(nil).Recognize), ctx, user, deviceId, details)
+}
+
+// MockRisk is a mock of Risk interface.
+type MockRisk struct {
+	ctrl     *gomock.Controller
+	recorder *MockRiskMockRecorder
+}
+
+// MockRiskMockRecorder is the mock recorder for MockRisk.
+type MockRiskMockRecorder struct {
+	mock *MockRisk
+}
+
+// NewMockRisk creates a new mock instance.
+func NewMockRisk(ctrl *gomock.Controller) *MockRisk {
+	mock := &MockRisk{ctrl: ctrl}
+	mock.recorder = &MockRiskMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRisk) EXPECT() *MockRiskMockRecorder {
+	return m.recorder
+}
+
+// Assess mocks base method.
+func (m *MockRisk) Assess(ctx context.Context, user *domain.User, deviceId string) (*domain.RiskAssessment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assess", ctx, user, deviceId)
+	ret0, _ := ret[0].(*domain.RiskAssessment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Assess indicates an expected call of Assess.
+func (mr *MockRiskMockRecorder) Assess(ctx, user, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assess", reflect.TypeOf((*MockRisk)(nil).Assess), ctx, user, deviceId)
+}
+
+// CheckVerificationCode mocks base method.
+func (m *MockRisk) CheckVerificationCode(ctx context.Context, userId uint32, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckVerificationCode", ctx, userId, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckVerificationCode indicates an expected call of CheckVerificationCode.
+func (mr *MockRiskMockRecorder) CheckVerificationCode(ctx, userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckVerificationCode", reflect.TypeOf((*MockRisk)(nil).CheckVerificationCode), ctx, userId, code)
+}
+
+// SendVerificationCode mocks base method.
+func (m *MockRisk) SendVerificationCode(ctx context.Context, user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationCode", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationCode indicates an expected call of SendVerificationCode.
+func (mr *MockRiskMockRecorder) SendVerificationCode(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationCode", reflect.TypeOf((*MockRisk)(nil).SendVerificationCode), ctx, user)
+}
+
+// StartStepUp mocks base method.
+func (m *MockRisk) StartStepUp(ctx context.Context, challenge string, user *domain.User, remember bool, deviceId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartStepUp", ctx, challenge, user, remember, deviceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartStepUp indicates an expected call of StartStepUp.
+func (mr *MockRiskMockRecorder) StartStepUp(ctx, challenge, user, remember, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartStepUp", reflect.TypeOf((*MockRisk)(nil).StartStepUp), ctx, challenge, user, remember, deviceId)
+}
+
+// VerifyStepUp mocks base method.
+func (m *MockRisk) VerifyStepUp(ctx context.Context, challenge, code string) (*domain.StepUp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyStepUp", ctx, challenge, code)
+	ret0, _ := ret[0].(*domain.StepUp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyStepUp indicates an expected call of VerifyStepUp.
+func (mr *MockRiskMockRecorder) VerifyStepUp(ctx, challenge, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyStepUp", reflect.TypeOf((*MockRisk)(nil).VerifyStepUp), ctx, challenge, code)
+}
+
+// MockIdentity is a mock of Identity interface.
+type MockIdentity struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityMockRecorder
+}
+
+// MockIdentityMockRecorder is the mock recorder for MockIdentity.
+type MockIdentityMockRecorder struct {
+	mock *MockIdentity
+}
+
+// NewMockIdentity creates a new mock instance.
+func NewMockIdentity(ctrl *gomock.Controller) *MockIdentity {
+	mock := &MockIdentity{ctrl: ctrl}
+	mock.recorder = &MockIdentityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentity) EXPECT() *MockIdentityMockRecorder {
+	return m.recorder
+}
+
+// ConfirmLink mocks base method.
+func (m *MockIdentity) ConfirmLink(ctx context.Context, token string, userId uint32) (*domain.IdentityLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmLink", ctx, token, userId)
+	ret0, _ := ret[0].(*domain.IdentityLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmLink indicates an expected call of ConfirmLink.
+func (mr *MockIdentityMockRecorder) ConfirmLink(ctx, token, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmLink", reflect.TypeOf((*MockIdentity)(nil).ConfirmLink), ctx, token, userId)
+}
+
+// FinishLogin mocks base method.
+func (m *MockIdentity) FinishLogin(ctx context.Context, state, code, upstreamErr string) (*service.UpstreamLoginResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", ctx, state, code, upstreamErr)
+	ret0, _ := ret[0].(*service.UpstreamLoginResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockIdentityMockRecorder) FinishLogin(ctx, state, code, upstreamErr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockIdentity)(nil).FinishLogin), ctx, state, code, upstreamErr)
+}
+
+// GetLink mocks base method.
+func (m *MockIdentity) GetLink(ctx context.Context, token string) (*domain.IdentityLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLink", ctx, token)
+	ret0, _ := ret[0].(*domain.IdentityLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLink indicates an expected call of GetLink.
+func (mr *MockIdentityMockRecorder) GetLink(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLink", reflect.TypeOf((*MockIdentity)(nil).GetLink), ctx, token)
+}
+
+// LoginMethods mocks base method.
+func (m *MockIdentity) LoginMethods(ctx context.Context, userId uint32) (*domain.LoginMethods, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMethods", ctx, userId)
+	ret0, _ := ret[0].(*domain.LoginMethods)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMethods indicates an expected call of LoginMethods.
+func (mr *MockIdentityMockRecorder) LoginMethods(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMethods", reflect.TypeOf((*MockIdentity)(nil).LoginMethods), ctx, userId)
+}
+
+// Providers mocks base method.
+func (m *MockIdentity) Providers() []domain.IdentityProvider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Providers")
+	ret0, _ := ret[0].([]domain.IdentityProvider)
+	return ret0
+}
+
+// Providers indicates an expected call of Providers.
+func (mr *MockIdentityMockRecorder) Providers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Providers", reflect.TypeOf((*MockIdentity)(nil).Providers))
+}
+
+// RemovePassword mocks base method.
+func (m *MockIdentity) RemovePassword(ctx context.Context, userId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePassword", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePassword indicates an expected call of RemovePassword.
+func (mr *MockIdentityMockRecorder) RemovePassword(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePassword", reflect.TypeOf((*MockIdentity)(nil).RemovePassword), ctx, userId)
+}
+
+// StartLink mocks base method.
+func (m *MockIdentity) StartLink(ctx context.Context, userId uint32, providerId string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartLink", ctx, userId, providerId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartLink indicates an expected call of StartLink.
+func (mr *MockIdentityMockRecorder) StartLink(ctx, userId, providerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLink", reflect.TypeOf((*MockIdentity)(nil).StartLink), ctx, userId, providerId)
+}
+
+// StartLogin mocks base method.
+func (m *MockIdentity) StartLogin(ctx context.Context, providerId, challenge string, remember bool, deviceId string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartLogin", ctx, providerId, challenge, remember, deviceId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartLogin indicates an expected call of StartLogin.
+func (mr *MockIdentityMockRecorder) StartLogin(ctx, providerId, challenge, remember, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLogin", reflect.TypeOf((*MockIdentity)(nil).StartLogin), ctx, providerId, challenge, remember, deviceId)
+}
+
+// Unlink mocks base method.
+func (m *MockIdentity) Unlink(ctx context.Context, userId, identityId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlink", ctx, userId, identityId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlink indicates an expected call of Unlink.
+func (mr *MockIdentityMockRecorder) Unlink(ctx, userId, identityId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlink", reflect.TypeOf((*MockIdentity)(nil).Unlink), ctx, userId, identityId)
+}
+
+// MockSCIM is a mock of SCIM interface.
+type MockSCIM struct {
+	ctrl     *gomock.Controller
+	recorder *MockSCIMMockRecorder
+}
+
+// MockSCIMMockRecorder is the mock recorder for MockSCIM.
+type MockSCIMMockRecorder struct {
+	mock *MockSCIM
+}
+
+// NewMockSCIM creates a new mock instance.
+func NewMockSCIM(ctrl *gomock.Controller) *MockSCIM {
+	mock := &MockSCIM{ctrl: ctrl}
+	mock.recorder = &MockSCIMMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSCIM) EXPECT() *MockSCIMMockRecorder {
+	return m.recorder
+}
+
+// CreateGroup mocks base method.
+func (m *MockSCIM) CreateGroup(ctx context.Context, clientId string, input *service.SCIMGroupInput) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, clientId, input)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockSCIMMockRecorder) CreateGroup(ctx, clientId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockSCIM)(nil).CreateGroup), ctx, clientId, input)
+}
+
+// CreateUser mocks base method.
+func (m *MockSCIM) CreateUser(ctx context.Context, clientId string, input *service.SCIMUserInput) (*service.SCIMUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, clientId, input)
+	ret0, _ := ret[0].(*service.SCIMUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockSCIMMockRecorder) CreateUser(ctx, clientId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockSCIM)(nil).CreateUser), ctx, clientId, input)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockSCIM) DeleteGroup(ctx context.Context, clientId string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, clientId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockSCIMMockRecorder) DeleteGroup(ctx, clientId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockSCIM)(nil).DeleteGroup), ctx, clientId, id)
+}
+
+// DeleteUser mocks base method.
+func (m *MockSCIM) DeleteUser(ctx context.Context, clientId string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, clientId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockSCIMMockRecorder) DeleteUser(ctx, clientId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockSCIM)(nil).DeleteUser), ctx, clientId, id)
+}
+
+// GetGroup mocks base method.
+func (m *MockSCIM) GetGroup(ctx context.Context, id uint32) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, id)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockSCIMMockRecorder) GetGroup(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockSCIM)(nil).GetGroup), ctx, id)
+}
+
+// GetUser mocks base method.
+func (m *MockSCIM) GetUser(ctx context.Context, id uint32) (*service.SCIMUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, id)
+	ret0, _ := ret[0].(*service.SCIMUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockSCIMMockRecorder) GetUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockSCIM)(nil).GetUser), ctx, id)
+}
+
+// ListGroups mocks base method.
+func (m *MockSCIM) ListGroups(ctx context.Context, input *service.SCIMGroupListInput) (*service.SCIMGroupPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, input)
+	ret0, _ := ret[0].(*service.SCIMGroupPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockSCIMMockRecorder) ListGroups(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockSCIM)(nil).ListGroups), ctx, input)
+}
+
+// ListUsers mocks base method.
+func (m *MockSCIM) ListUsers(ctx context.Context, input *service.SCIMUserListInput) (*service.SCIMUserPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, input)
+	ret0, _ := ret[0].(*service.SCIMUserPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockSCIMMockRecorder) ListUsers(ctx, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockSCIM)(nil).ListUsers), ctx, input)
 }
 
-// GetUserByEmail mocks base method.
-func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+// ReplaceGroup mocks base method.
+func (m *MockSCIM) ReplaceGroup(ctx context.Context, clientId string, id, version uint32, input *service.SCIMGroupInput) (*domain.Group, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "ReplaceGroup", ctx, clientId, id, version, input)
+	ret0, _ := ret[0].(*domain.Group)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+// ReplaceGroup indicates an expected call of ReplaceGroup.
+func (mr *MockSCIMMockRecorder) ReplaceGroup(ctx, clientId, id, version, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceGroup", reflect.TypeOf((*MockSCIM)(nil).ReplaceGroup), ctx, clientId, id, version, input)
 }
 
-// GetUserById mocks base method.
-func (m *MockUserRepository) GetUserById(ctx context.Context, id uint32) (*domain.User, error) {
+// ReplaceUser mocks base method.
+func (m *MockSCIM) ReplaceUser(ctx context.Context, clientId string, id, version uint32, input *service.SCIMUserInput) (*service.SCIMUser, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "ReplaceUser", ctx, clientId, id, version, input)
+	ret0, _ := ret[0].(*service.SCIMUser)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserRepositoryMockRecorder) GetUserById(ctx, id interface{}) *gomock.Call {
+// ReplaceUser indicates an expected call of ReplaceUser.
+func (mr *MockSCIMMockRecorder) ReplaceUser(ctx, clientId, id, version, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepository)(nil).GetUserById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUser", reflect.TypeOf((*MockSCIM)(nil).ReplaceUser), ctx, clientId, id, version, input)
 }
 
-// MockOAuth2 is a mock of OAuth2 interface.
-type MockOAuth2 struct {
+// MockGroups is a mock of Groups interface.
+type MockGroups struct {
 	ctrl     *gomock.Controller
-	recorder *MockOAuth2MockRecorder
+	recorder *MockGroupsMockRecorder
 }
 
-// MockOAuth2MockRecorder is the mock recorder for MockOAuth2.
-type MockOAuth2MockRecorder struct {
-	mock *MockOAuth2
+// MockGroupsMockRecorder is the mock recorder for MockGroups.
+type MockGroupsMockRecorder struct {
+	mock *MockGroups
 }
 
-// NewMockOAuth2 creates a new mock instance.
-func NewMockOAuth2(ctrl *gomock.Controller) *MockOAuth2 {
-	mock := &MockOAuth2{ctrl: ctrl}
-	mock.recorder = &MockOAuth2MockRecorder{mock}
+// NewMockGroups creates a new mock instance.
+func NewMockGroups(ctrl *gomock.Controller) *MockGroups {
+	mock := &MockGroups{ctrl: ctrl}
+	mock.recorder = &MockGroupsMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockOAuth2) EXPECT() *MockOAuth2MockRecorder {
+func (m *MockGroups) EXPECT() *MockGroupsMockRecorder {
 	return m.recorder
 }
 
-// AcceptConsentRequest mocks base method.
-func (m *MockOAuth2) AcceptConsentRequest(context context.Context, challenge string, grantScope, grantAccessTokenAudience []string, remember bool, rememberFor int64) (string, error) {
+// ClaimGroups mocks base method.
+func (m *MockGroups) ClaimGroups(ctx context.Context, userId uint32) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AcceptConsentRequest", context, challenge, grantScope, grantAccessTokenAudience, remember, rememberFor)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "ClaimGroups", ctx, userId)
+	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AcceptConsentRequest indicates an expected call of AcceptConsentRequest.
-func (mr *MockOAuth2MockRecorder) AcceptConsentRequest(context, challenge, grantScope, grantAccessTokenAudience, remember, rememberFor interface{}) *gomock.Call {
+// ClaimGroups indicates an expected call of ClaimGroups.
+func (mr *MockGroupsMockRecorder) ClaimGroups(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptConsentRequest), context, challenge, grantScope, grantAccessTokenAudience, remember, rememberFor)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimGroups", reflect.TypeOf((*MockGroups)(nil).ClaimGroups), ctx, userId)
 }
 
-// AcceptLoginRequest mocks base method.
-func (m *MockOAuth2) AcceptLoginRequest(context context.Context, challenge, subject string, remember bool, rememberFor int64) (string, error) {
+// CreateGroup mocks base method.
+func (m *MockGroups) CreateGroup(ctx context.Context, actorId, organizationId uint32, displayName string) (*domain.Group, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AcceptLoginRequest", context, challenge, subject, remember, rememberFor)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, actorId, organizationId, displayName)
+	ret0, _ := ret[0].(*domain.Group)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AcceptLoginRequest indicates an expected call of AcceptLoginRequest.
-func (mr *MockOAuth2MockRecorder) AcceptLoginRequest(context, challenge, subject, remember, rememberFor interface{}) *gomock.Call {
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockGroupsMockRecorder) CreateGroup(ctx, actorId, organizationId, displayName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptLoginRequest), context, challenge, subject, remember, rememberFor)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockGroups)(nil).CreateGroup), ctx, actorId, organizationId, displayName)
 }
 
-// AcceptLogoutRequest mocks base method.
-func (m *MockOAuth2) AcceptLogoutRequest(context context.Context, challenge string) (string, error) {
+// CreateOrganization mocks base method.
+func (m *MockGroups) CreateOrganization(ctx context.Context, actorId uint32, name string) (*domain.Organization, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AcceptLogoutRequest", context, challenge)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, actorId, name)
+	ret0, _ := ret[0].(*domain.Organization)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AcceptLogoutRequest indicates an expected call of AcceptLogoutRequest.
-func (mr *MockOAuth2MockRecorder) AcceptLogoutRequest(context, challenge interface{}) *gomock.Call {
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockGroupsMockRecorder) CreateOrganization(ctx, actorId, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptLogoutRequest", reflect.TypeOf((*MockOAuth2)(nil).AcceptLogoutRequest), context, challenge)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockGroups)(nil).CreateOrganization), ctx, actorId, name)
 }
 
-// GenerateLogoutURL mocks base method.
-func (m *MockOAuth2) GenerateLogoutURL(idTokenHint, state, postLogoutRedirectUri string) string {
+// DeleteGroup mocks base method.
+func (m *MockGroups) DeleteGroup(ctx context.Context, actorId, groupId uint32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateLogoutURL", idTokenHint, state, postLogoutRedirectUri)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, actorId, groupId)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// GenerateLogoutURL indicates an expected call of GenerateLogoutURL.
-func (mr *MockOAuth2MockRecorder) GenerateLogoutURL(idTokenHint, state, postLogoutRedirectUri interface{}) *gomock.Call {
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockGroupsMockRecorder) DeleteGroup(ctx, actorId, groupId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateLogoutURL", reflect.TypeOf((*MockOAuth2)(nil).GenerateLogoutURL), idTokenHint, state, postLogoutRedirectUri)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroups)(nil).DeleteGroup), ctx, actorId, groupId)
 }
 
-// GetAuthCodeUrl mocks base method.
-func (m *MockOAuth2) GetAuthCodeUrl() string {
+// GetGroup mocks base method.
+func (m *MockGroups) GetGroup(ctx context.Context, actorId, groupId uint32) (*domain.Group, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAuthCodeUrl")
-	ret0, _ := ret[0].(string)
-	return ret0
+	ret := m.ctrl.Call(m, "GetGroup", ctx, actorId, groupId)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// GetAuthCodeUrl indicates an expected call of GetAuthCodeUrl.
-func (mr *MockOAuth2MockRecorder) GetAuthCodeUrl() *gomock.Call {
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupsMockRecorder) GetGroup(ctx, actorId, groupId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthCodeUrl", reflect.TypeOf((*MockOAuth2)(nil).GetAuthCodeUrl))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroups)(nil).GetGroup), ctx, actorId, groupId)
 }
 
-// GetConsentRequest mocks base method.
-func (m *MockOAuth2) GetConsentRequest(context context.Context, challenge string) (*domain.OA2ConsentRequest, error) {
+// ListGroups mocks base method.
+func (m *MockGroups) ListGroups(ctx context.Context, organizationId uint32) ([]domain.Group, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetConsentRequest", context, challenge)
-	ret0, _ := ret[0].(*domain.OA2ConsentRequest)
+	ret := m.ctrl.Call(m, "ListGroups", ctx, organizationId)
+	ret0, _ := ret[0].([]domain.Group)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetConsentRequest indicates an expected call of GetConsentRequest.
-func (mr *MockOAuth2MockRecorder) GetConsentRequest(context, challenge interface{}) *gomock.Call {
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockGroupsMockRecorder) ListGroups(ctx, organizationId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).GetConsentRequest), context, challenge)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGroups)(nil).ListGroups), ctx, organizationId)
 }
 
-// GetLoginRequest mocks base method.
-func (m *MockOAuth2) GetLoginRequest(context context.Context, challenge string) (*domain.OA2LoginRequest, error) {
+// ListOrganizations mocks base method.
+func (m *MockGroups) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLoginRequest", context, challenge)
-	ret0, _ := ret[0].(*domain.OA2LoginRequest)
+	ret := m.ctrl.Call(m, "ListOrganizations", ctx)
+	ret0, _ := ret[0].([]domain.Organization)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetLoginRequest indicates an expected call of GetLoginRequest.
-func (mr *MockOAuth2MockRecorder) GetLoginRequest(context, challenge interface{}) *gomock.Call {
+// ListOrganizations indicates an expected call of ListOrganizations.
+func (mr *MockGroupsMockRecorder) ListOrganizations(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).GetLoginRequest), context, challenge)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizations", reflect.TypeOf((*MockGroups)(nil).ListOrganizations), ctx)
 }
 
-// IntrospectOAuth2Token mocks base method.
-func (m *MockOAuth2) IntrospectOAuth2Token(context context.Context, accessToken string) (*domain.OA2TokenIntrospection, error) {
+// ListUserGroups mocks base method.
+func (m *MockGroups) ListUserGroups(ctx context.Context, userId uint32) ([]domain.Group, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IntrospectOAuth2Token", context, accessToken)
+	ret := m.ctrl.Call(m, "ListUserGroups", ctx, userId)
+	ret0, _ := ret[0].([]domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserGroups indicates an expected call of ListUserGroups.
+func (mr *MockGroupsMockRecorder) ListUserGroups(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserGroups", reflect.TypeOf((*MockGroups)(nil).ListUserGroups), ctx, userId)
+}
+
+// RemoveMember mocks base method.
+func (m *MockGroups) RemoveMember(ctx context.Context, actorId, groupId, userId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, actorId, groupId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockGroupsMockRecorder) RemoveMember(ctx, actorId, groupId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockGroups)(nil).RemoveMember), ctx, actorId, groupId, userId)
+}
+
+// SetMember mocks base method.
+func (m *MockGroups) SetMember(ctx context.Context, actorId, groupId, userId uint32, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMember", ctx, actorId, groupId, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMember indicates an expected call of SetMember.
+func (mr *MockGroupsMockRecorder) SetMember(ctx, actorId, groupId, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMember", reflect.TypeOf((*MockGroups)(nil).SetMember), ctx, actorId, groupId, userId, role)
+}
+
+// MockServiceAccounts is a mock of ServiceAccounts interface.
+type MockServiceAccounts struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountsMockRecorder
+}
+
+// MockServiceAccountsMockRecorder is the mock recorder for MockServiceAccounts.
+type MockServiceAccountsMockRecorder struct {
+	mock *MockServiceAccounts
+}
+
+// NewMockServiceAccounts creates a new mock instance.
+func NewMockServiceAccounts(ctrl *gomock.Controller) *MockServiceAccounts {
+	mock := &MockServiceAccounts{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccounts) EXPECT() *MockServiceAccountsMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockServiceAccounts) Authenticate(ctx context.Context, apiKey string) (*domain.OA2TokenIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, apiKey)
 	ret0, _ := ret[0].(*domain.OA2TokenIntrospection)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IntrospectOAuth2Token indicates an expected call of IntrospectOAuth2Token.
-func (mr *MockOAuth2MockRecorder) IntrospectOAuth2Token(context, accessToken interface{}) *gomock.Call {
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockServiceAccountsMockRecorder) Authenticate(ctx, apiKey interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectOAuth2Token", reflect.TypeOf((*MockOAuth2)(nil).IntrospectOAuth2Token), context, accessToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockServiceAccounts)(nil).Authenticate), ctx, apiKey)
 }
 
-// RejectConsentRequest mocks base method.
-func (m *MockOAuth2) RejectConsentRequest(context context.Context, challenge, errStr, errDescStr string) (string, error) {
+// ClientAccount mocks base method.
+func (m *MockServiceAccounts) ClientAccount(ctx context.Context, clientId string) (*domain.ServiceAccount, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RejectConsentRequest", context, challenge, errStr, errDescStr)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "ClientAccount", ctx, clientId)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// RejectConsentRequest indicates an expected call of RejectConsentRequest.
-func (mr *MockOAuth2MockRecorder) RejectConsentRequest(context, challenge, errStr, errDescStr interface{}) *gomock.Call {
+// ClientAccount indicates an expected call of ClientAccount.
+func (mr *MockServiceAccountsMockRecorder) ClientAccount(ctx, clientId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectConsentRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectConsentRequest), context, challenge, errStr, errDescStr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientAccount", reflect.TypeOf((*MockServiceAccounts)(nil).ClientAccount), ctx, clientId)
 }
 
-// RejectLoginRequest mocks base method.
-func (m *MockOAuth2) RejectLoginRequest(context context.Context, challenge, errStr, errDescStr string) (string, error) {
+// Create mocks base method.
+func (m *MockServiceAccounts) Create(ctx context.Context, actorId uint32, input *service.ServiceAccountInput) (*domain.ServiceAccount, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RejectLoginRequest", context, challenge, errStr, errDescStr)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Create", ctx, actorId, input)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockServiceAccountsMockRecorder) Create(ctx, actorId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockServiceAccounts)(nil).Create), ctx, actorId, input)
+}
+
+// CreateKey mocks base method.
+func (m *MockServiceAccounts) CreateKey(ctx context.Context, actorId, id uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", ctx, actorId, id, dateExpires)
+	ret0, _ := ret[0].(*domain.ServiceAccountKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *MockServiceAccountsMockRecorder) CreateKey(ctx, actorId, id, dateExpires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockServiceAccounts)(nil).CreateKey), ctx, actorId, id, dateExpires)
+}
+
+// Delete mocks base method.
+func (m *MockServiceAccounts) Delete(ctx context.Context, actorId, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, actorId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockServiceAccountsMockRecorder) Delete(ctx, actorId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockServiceAccounts)(nil).Delete), ctx, actorId, id)
+}
+
+// Get mocks base method.
+func (m *MockServiceAccounts) Get(ctx context.Context, actorId, id uint32) (*domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, actorId, id)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// RejectLoginRequest indicates an expected call of RejectLoginRequest.
-func (mr *MockOAuth2MockRecorder) RejectLoginRequest(context, challenge, errStr, errDescStr interface{}) *gomock.Call {
+// Get indicates an expected call of Get.
+func (mr *MockServiceAccountsMockRecorder) Get(ctx, actorId, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectLoginRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectLoginRequest), context, challenge, errStr, errDescStr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockServiceAccounts)(nil).Get), ctx, actorId, id)
 }
 
-// RejectLogoutRequest mocks base method.
-func (m *MockOAuth2) RejectLogoutRequest(context context.Context, challenge string) error {
+// List mocks base method.
+func (m *MockServiceAccounts) List(ctx context.Context) ([]domain.ServiceAccount, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RejectLogoutRequest", context, challenge)
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockServiceAccountsMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockServiceAccounts)(nil).List), ctx)
+}
+
+// ListKeys mocks base method.
+func (m *MockServiceAccounts) ListKeys(ctx context.Context, actorId, id uint32) ([]domain.ServiceAccountKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", ctx, actorId, id)
+	ret0, _ := ret[0].([]domain.ServiceAccountKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockServiceAccountsMockRecorder) ListKeys(ctx, actorId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockServiceAccounts)(nil).ListKeys), ctx, actorId, id)
+}
+
+// RevokeKey mocks base method.
+func (m *MockServiceAccounts) RevokeKey(ctx context.Context, actorId, id, keyId uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, actorId, id, keyId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RejectLogoutRequest indicates an expected call of RejectLogoutRequest.
-func (mr *MockOAuth2MockRecorder) RejectLogoutRequest(context, challenge interface{}) *gomock.Call {
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockServiceAccountsMockRecorder) RevokeKey(ctx, actorId, id, keyId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectLogoutRequest", reflect.TypeOf((*MockOAuth2)(nil).RejectLogoutRequest), context, challenge)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockServiceAccounts)(nil).RevokeKey), ctx, actorId, id, keyId)
 }
 
-// TokenExchange mocks base method.
-func (m *MockOAuth2) TokenExchange(ctx context.Context, code string) (*domain.Token, error) {
+// RotateKey mocks base method.
+func (m *MockServiceAccounts) RotateKey(ctx context.Context, actorId, id, keyId uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TokenExchange", ctx, code)
-	ret0, _ := ret[0].(*domain.Token)
+	ret := m.ctrl.Call(m, "RotateKey", ctx, actorId, id, keyId, dateExpires)
+	ret0, _ := ret[0].(*domain.ServiceAccountKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RotateKey indicates an expected call of RotateKey.
+func (mr *MockServiceAccountsMockRecorder) RotateKey(ctx, actorId, id, keyId, dateExpires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKey", reflect.TypeOf((*MockServiceAccounts)(nil).RotateKey), ctx, actorId, id, keyId, dateExpires)
+}
+
+// Update mocks base method.
+func (m *MockServiceAccounts) Update(ctx context.Context, actorId, id uint32, input *service.ServiceAccountInput) (*domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, actorId, id, input)
+	ret0, _ := ret[0].(*domain.ServiceAccount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// TokenExchange indicates an expected call of TokenExchange.
-func (mr *MockOAuth2MockRecorder) TokenExchange(ctx, code interface{}) *gomock.Call {
+// Update indicates an expected call of Update.
+func (mr *MockServiceAccountsMockRecorder) Update(ctx, actorId, id, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenExchange", reflect.TypeOf((*MockOAuth2)(nil).TokenExchange), ctx, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockServiceAccounts)(nil).Update), ctx, actorId, id, input)
 }
 
-// MockUser is a mock of User interface.
-type MockUser struct {
+// MockTenants is a mock of Tenants interface.
+type MockTenants struct {
 	ctrl     *gomock.Controller
-	recorder *MockUserMockRecorder
+	recorder *MockTenantsMockRecorder
 }
 
-// MockUserMockRecorder is the mock recorder for MockUser.
-type MockUserMockRecorder struct {
-	mock *MockUser
+// MockTenantsMockRecorder is the mock recorder for MockTenants.
+type MockTenantsMockRecorder struct {
+	mock *MockTenants
 }
 
-// NewMockUser creates a new mock instance.
-func NewMockUser(ctrl *gomock.Controller) *MockUser {
-	mock := &MockUser{ctrl: ctrl}
-	mock.recorder = &MockUserMockRecorder{mock}
+// NewMockTenants creates a new mock instance.
+func NewMockTenants(ctrl *gomock.Controller) *MockTenants {
+	mock := &MockTenants{ctrl: ctrl}
+	mock.recorder = &MockTenantsMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUser) EXPECT() *MockUserMockRecorder {
+func (m *MockTenants) EXPECT() *MockTenantsMockRecorder {
 	return m.recorder
 }
 
-// GetUserById mocks base method.
-func (m *MockUser) GetUserById(ctx context.Context, id uint32) (*domain.User, error) {
+// ClientTenant mocks base method.
+func (m *MockTenants) ClientTenant(clientId string) *domain.Tenant {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
-	ret0, _ := ret[0].(*domain.User)
-	ret1, _ := ret[1].(error)
+	ret := m.ctrl.Call(m, "ClientTenant", clientId)
+	ret0, _ := ret[0].(*domain.Tenant)
+	return ret0
+}
+
+// ClientTenant indicates an expected call of ClientTenant.
+func (mr *MockTenantsMockRecorder) ClientTenant(clientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientTenant", reflect.TypeOf((*MockTenants)(nil).ClientTenant), clientId)
+}
+
+// Get mocks base method.
+func (m *MockTenants) Get(tenantId string) (*domain.Tenant, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", tenantId)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(bool)
 	return ret0, ret1
 }
 
-// GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserMockRecorder) GetUserById(ctx, id interface{}) *gomock.Call {
+// Get indicates an expected call of Get.
+func (mr *MockTenantsMockRecorder) Get(tenantId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUser)(nil).GetUserById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTenants)(nil).Get), tenantId)
 }
 
-// SignIn mocks base method.
-func (m *MockUser) SignIn(ctx context.Context, inputUserData *service.UserSignInInput) (*domain.User, error) {
+// List mocks base method.
+func (m *MockTenants) List() []*domain.Tenant {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignIn", ctx, inputUserData)
-	ret0, _ := ret[0].(*domain.User)
-	ret1, _ := ret[1].(error)
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*domain.Tenant)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockTenantsMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTenants)(nil).List))
+}
+
+// Resolve mocks base method.
+func (m *MockTenants) Resolve(host, tenantId string) (*domain.Tenant, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", host, tenantId)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(bool)
 	return ret0, ret1
 }
 
-// SignIn indicates an expected call of SignIn.
-func (mr *MockUserMockRecorder) SignIn(ctx, inputUserData interface{}) *gomock.Call {
+// Resolve indicates an expected call of Resolve.
+func (mr *MockTenantsMockRecorder) Resolve(host, tenantId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockUser)(nil).SignIn), ctx, inputUserData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockTenants)(nil).Resolve), host, tenantId)
 }
 
-// SignUp mocks base method.
-func (m *MockUser) SignUp(ctx context.Context, inputUserData *service.UserSignUpInput) error {
+// MockActivity is a mock of Activity interface.
+type MockActivity struct {
+	ctrl     *gomock.Controller
+	recorder *MockActivityMockRecorder
+}
+
+// MockActivityMockRecorder is the mock recorder for MockActivity.
+type MockActivityMockRecorder struct {
+	mock *MockActivity
+}
+
+// NewMockActivity creates a new mock instance.
+func NewMockActivity(ctrl *gomock.Controller) *MockActivity {
+	mock := &MockActivity{ctrl: ctrl}
+	mock.recorder = &MockActivityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActivity) EXPECT() *MockActivityMockRecorder {
+	return m.recorder
+}
+
+// Flush mocks base method.
+func (m *MockActivity) Flush(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignUp", ctx, inputUserData)
+	ret := m.ctrl.Call(m, "Flush", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SignUp indicates an expected call of SignUp.
-func (mr *MockUserMockRecorder) SignUp(ctx, inputUserData interface{}) *gomock.Call {
+// Flush indicates an expected call of Flush.
+func (mr *MockActivityMockRecorder) Flush(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockUser)(nil).SignUp), ctx, inputUserData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockActivity)(nil).Flush), ctx)
+}
+
+// Touch mocks base method.
+func (m *MockActivity) Touch(ctx context.Context, userId uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Touch", ctx, userId)
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockActivityMockRecorder) Touch(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockActivity)(nil).Touch), ctx, userId)
 }
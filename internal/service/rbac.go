@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"time"
+)
+
+var (
+	ErrRoleNotFound    = errors.New("Role or user not found")
+	ErrRoleNotAssigned = errors.New("Role is not assigned to the user")
+)
+
+// Permissions which the users have on their own records.
+var ownerPermissions = map[string]bool{
+	domain.PermissionUsersRead:  true,
+	domain.PermissionUsersWrite: true,
+}
+
+type RBACService struct {
+	repo RoleRepository
+}
+
+func NewRBACService(roleRepo RoleRepository) *RBACService {
+	return &RBACService{
+		repo: roleRepo,
+	}
+}
+
+// Can decides whether the subject has the permission on the resource.
+// The users have the owner permissions on their own records, any other access is granted by the roles.
+func (s *RBACService) Can(ctx context.Context, subject uint32, permission string, resource string) (bool, error) {
+	if resource != "" && resource == domain.UserResource(subject) && ownerPermissions[permission] {
+		return true, nil
+	}
+
+	permissions, err := s.repo.GetUserPermissions(ctx, subject)
+	if err != nil {
+		return false, err
+	}
+
+	for _, granted := range permissions {
+		if granted == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *RBACService) GetUserRoles(ctx context.Context, userId uint32) ([]string, error) {
+	return s.repo.GetUserRoles(ctx, userId)
+}
+
+func (s *RBACService) AssignRole(ctx context.Context, userId uint32, role string) error {
+	userRole := &domain.UserRole{
+		UserId:       userId,
+		Role:         role,
+		DateAssigned: time.Now(),
+	}
+
+	if err := s.repo.AssignRole(ctx, userRole); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *RBACService) RevokeRole(ctx context.Context, userId uint32, role string) error {
+	if err := s.repo.RevokeRole(ctx, userId, role); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrRoleNotAssigned
+		}
+
+		return err
+	}
+
+	return nil
+}
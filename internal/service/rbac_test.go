@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+type mockBehaviorRoleRepo func(mockRepo *mock_service.MockRoleRepository)
+
+type TestTableCan struct {
+	name                 string
+	subject              uint32
+	permission           string
+	resource             string
+	mockBehaviorRoleRepo mockBehaviorRoleRepo
+	expectedAllowed      bool
+	expectedErr          bool
+}
+
+func TestRBACService_Can(t *testing.T) {
+	testTable := []TestTableCan{
+		{
+			name:       "OK, owner reads own record",
+			subject:    1,
+			permission: domain.PermissionUsersRead,
+			resource:   domain.UserResource(1),
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				// Nothing
+			},
+			expectedAllowed: true,
+		},
+		{
+			name:       "OK, admin reads other user record",
+			subject:    1,
+			permission: domain.PermissionUsersRead,
+			resource:   domain.UserResource(2),
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserPermissions(gomock.Any(), uint32(1)).
+					Return([]string{domain.PermissionUsersRead, domain.PermissionRolesManage}, nil)
+			},
+			expectedAllowed: true,
+		},
+		{
+			name:       "BAD, user reads other user record",
+			subject:    1,
+			permission: domain.PermissionUsersRead,
+			resource:   domain.UserResource(2),
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserPermissions(gomock.Any(), uint32(1)).Return(nil, nil)
+			},
+			expectedAllowed: false,
+		},
+		{
+			name:       "BAD, owner manages own roles",
+			subject:    1,
+			permission: domain.PermissionRolesManage,
+			resource:   domain.UserResource(1),
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserPermissions(gomock.Any(), uint32(1)).Return([]string{}, nil)
+			},
+			expectedAllowed: false,
+		},
+		{
+			name:       "BAD, GetUserPermissions error",
+			subject:    1,
+			permission: domain.PermissionRolesManage,
+			mockBehaviorRoleRepo: func(mockRepo *mock_service.MockRoleRepository) {
+				mockRepo.EXPECT().GetUserPermissions(gomock.Any(), uint32(1)).Return(nil, errors.New("Test error"))
+			},
+			expectedAllowed: false,
+			expectedErr:     true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockRoleRepo := mock_service.NewMockRoleRepository(ctrl)
+			testCase.mockBehaviorRoleRepo(mockRoleRepo)
+			rbac := service.NewRBACService(mockRoleRepo)
+
+			//// Act
+			allowed, err := rbac.Can(context.Background(), testCase.subject, testCase.permission, testCase.resource)
+
+			//// Assert
+			assert.Equal(t, allowed, testCase.expectedAllowed)
+			assert.Equal(t, err != nil, testCase.expectedErr)
+		})
+	}
+}
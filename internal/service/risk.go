@@ -0,0 +1,444 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"fmt"
+	"github.com/pkg/errors"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/pkg/convert_to"
+	"strings"
+	"time"
+)
+
+const (
+	// The number of the user's recent sign-ins the usual hours and the last location are learned from.
+	riskHistoryLimit = 20
+	// The shorter distances in km are within the GeoIP accuracy, they are never an impossible travel.
+	riskTravelMinDistance = 300
+	// The travel time of the sign-ins closer in time is rounded up to it.
+	riskTravelMinDuration = time.Minute
+	// The usual hour is within the hours of the user's recent sign-ins.
+	riskUsualHourRange = 1
+	// The Earth's mean radius in km.
+	earthRadius = 6371
+	// The number of the digits of the one-time code.
+	stepUpCodeDigits = 6
+)
+
+var (
+	// ErrStepUpRequired the risky sign-in requires the one-time code sent to the user's email.
+	ErrStepUpRequired      = errors.New("Sign-in requires the one-time code sent to your email")
+	ErrStepUpCodeIncorrect = errors.New("One-time code is incorrect")
+	// ErrStepUpExpired the code expired or was tried too many times, the user has to sign in again.
+	ErrStepUpExpired = errors.New("One-time code is expired, sign in again")
+	// ErrVerificationRequired the user without the password confirms the account's change with the one-time code
+	// sent to the email.
+	ErrVerificationRequired = errors.New("Confirm the change with the one-time code sent to your email")
+	// ErrVerificationExpired the code expired or was tried too many times, the user requests a new one.
+	ErrVerificationExpired = errors.New("One-time code is expired, request a new one")
+	// ErrRiskDenylist the IP denylist file is malformed.
+	ErrRiskDenylist = errors.New("Risk denylist must list the IPs and CIDRs one per line")
+)
+
+// RiskService scores the sign-ins by the configured signals: the new device, the impossible travel from the last
+// sign-in's location, the denied IP, the recent failed sign-ins and the unusual hour.
+// The medium risk sign-in is stepped up with the one-time code sent to the user's email.
+type RiskService struct {
+	repo     UserRepository
+	audit    Audit
+	notifier Notifier
+	locator  GeoLocator // nil if the locations are unknown.
+	config   *config.RiskConfig
+	denylist []*net.IPNet
+}
+
+func NewRiskService(userRepo UserRepository, auditService Audit, notifier Notifier, locator GeoLocator, config *config.RiskConfig) (*RiskService, error) {
+	s := &RiskService{
+		repo:     userRepo,
+		audit:    auditService,
+		notifier: notifier,
+		locator:  locator,
+		config:   config,
+	}
+
+	if config.Enabled && config.DenylistFile != "" {
+		denylist, err := readDenylist(config.DenylistFile)
+		if err != nil {
+			return nil, err
+		}
+
+		s.denylist = denylist
+	}
+
+	return s, nil
+}
+
+// Assess scores the user's sign-in from the request's IP and the device, it's nil if the risk-based sign-in is disabled.
+func (s *RiskService) Assess(ctx context.Context, user *domain.User, deviceId string) (*domain.RiskAssessment, error) {
+	if !s.config.Enabled {
+		return nil, nil
+	}
+
+	var ip string
+	if requestInfo := domain.RequestInfoFromContext(ctx); requestInfo != nil {
+		ip = requestInfo.Ip
+	}
+
+	now := time.Now()
+	assessment := &domain.RiskAssessment{Reasons: []string{}}
+	add := func(reason string, score int) {
+		if score > 0 {
+			assessment.Score += score
+			assessment.Reasons = append(assessment.Reasons, reason)
+		}
+	}
+
+	if s.config.DenylistScore > 0 && s.isDenied(ip) {
+		add(domain.RiskReasonDeniedIp, s.config.DenylistScore)
+	}
+
+	if s.config.NewDeviceScore > 0 {
+		newDevice, err := s.isNewDevice(ctx, user.Id, deviceId)
+		if err != nil {
+			return nil, err
+		}
+
+		if newDevice {
+			add(domain.RiskReasonNewDevice, s.config.NewDeviceScore)
+		}
+	}
+
+	if s.config.RecentFailuresScore > 0 {
+		from := now.Add(-s.config.RecentFailuresWindow)
+		failures, err := s.audit.List(ctx, &AuditListInput{
+			UserId:  &user.Id,
+			Action:  domain.AuditActionSignin,
+			Outcome: domain.AuditOutcomeFailure,
+			From:    &from,
+			Limit:   s.config.RecentFailuresThreshold,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(failures.Events) >= s.config.RecentFailuresThreshold {
+			add(domain.RiskReasonRecentFailures, s.config.RecentFailuresScore)
+		}
+	}
+
+	if s.config.ImpossibleTravelScore > 0 || s.config.UnusualHourScore > 0 {
+		signins, err := s.audit.List(ctx, &AuditListInput{
+			UserId:  &user.Id,
+			Action:  domain.AuditActionSignin,
+			Outcome: domain.AuditOutcomeSuccess,
+			Limit:   riskHistoryLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(signins.Events) > 0 && s.isImpossibleTravel(&signins.Events[0], ip, now) {
+			add(domain.RiskReasonImpossibleTravel, s.config.ImpossibleTravelScore)
+		}
+
+		if len(signins.Events) >= s.config.UsualHoursSignins && isUnusualHour(signins.Events, now) {
+			add(domain.RiskReasonUnusualHour, s.config.UnusualHourScore)
+		}
+	}
+
+	switch {
+	case assessment.Score >= s.config.HighScore:
+		assessment.Level = domain.RiskLevelHigh
+	case assessment.Score >= s.config.MediumScore:
+		assessment.Level = domain.RiskLevelMedium
+	default:
+		assessment.Level = domain.RiskLevelLow
+	}
+
+	return assessment, nil
+}
+
+// StartStepUp sends the one-time code to the user's email, the sign-in of the challenge waits for it.
+func (s *RiskService) StartStepUp(ctx context.Context, challenge string, user *domain.User, remember bool, deviceId string) error {
+	code, err := newStepUpCode()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stepUp := &domain.StepUp{
+		Challenge:   challenge,
+		UserId:      user.Id,
+		CodeHash:    hashStepUpCode(challenge, code),
+		Remember:    remember,
+		DeviceId:    deviceId,
+		DateCreated: now,
+		DateExpires: now.Add(s.config.OTPTTL),
+	}
+	if err = s.repo.CreateStepUp(ctx, stepUp); err != nil {
+		return err
+	}
+
+	// The sign-in can't be completed without the code, the notification failure isn't ignored.
+	return s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationSigninCode,
+		UserId: user.Id,
+		Email:  user.Email,
+		Data: map[string]string{
+			"code":         code,
+			"date_expires": stepUp.DateExpires.Format(time.RFC3339),
+		},
+	})
+}
+
+// VerifyStepUp checks the challenge's one-time code, returns the sign-in waiting for it.
+// The step-up is returned with ErrStepUpCodeIncorrect and ErrStepUpExpired too if it's found.
+func (s *RiskService) VerifyStepUp(ctx context.Context, challenge string, code string) (*domain.StepUp, error) {
+	stepUp, err := s.repo.GetStepUp(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrStepUpExpired
+		}
+
+		return nil, err
+	}
+
+	if !stepUp.DateExpires.After(time.Now()) {
+		return stepUp, s.dropStepUp(ctx, challenge)
+	}
+
+	attempts, err := s.repo.CountStepUpAttempt(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return stepUp, ErrStepUpExpired
+		}
+
+		return nil, err
+	}
+
+	if attempts > s.config.OTPMaxAttempts {
+		return stepUp, s.dropStepUp(ctx, challenge)
+	}
+
+	if !hmac.Equal([]byte(hashStepUpCode(challenge, strings.TrimSpace(code))), []byte(stepUp.CodeHash)) {
+		return stepUp, ErrStepUpCodeIncorrect
+	}
+
+	// The code is used once.
+	if err = s.repo.DeleteStepUp(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return stepUp, nil
+}
+
+// SendVerificationCode sends the one-time code confirming the change of the user's account, e.g. setting the password
+// of the user without one. The code replaces the user's former one.
+func (s *RiskService) SendVerificationCode(ctx context.Context, user *domain.User) error {
+	code, err := newStepUpCode()
+	if err != nil {
+		return err
+	}
+
+	challenge := verificationChallenge(user.Id)
+	now := time.Now()
+	stepUp := &domain.StepUp{
+		Challenge:   challenge,
+		UserId:      user.Id,
+		CodeHash:    hashStepUpCode(challenge, code),
+		DateCreated: now,
+		DateExpires: now.Add(s.config.OTPTTL),
+	}
+	if err = s.repo.CreateStepUp(ctx, stepUp); err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, &domain.Notification{
+		Type:   domain.NotificationVerificationCode,
+		UserId: user.Id,
+		Email:  user.Email,
+		Data: map[string]string{
+			"code":         code,
+			"date_expires": stepUp.DateExpires.Format(time.RFC3339),
+		},
+	})
+}
+
+// CheckVerificationCode checks the user's one-time code sent by SendVerificationCode, the code is used once.
+func (s *RiskService) CheckVerificationCode(ctx context.Context, userId uint32, code string) error {
+	_, err := s.VerifyStepUp(ctx, verificationChallenge(userId), code)
+	if errors.Is(err, ErrStepUpExpired) {
+		return ErrVerificationExpired
+	}
+
+	return err
+}
+
+// dropStepUp deletes the step-up that can't be completed, returns ErrStepUpExpired.
+func (s *RiskService) dropStepUp(ctx context.Context, challenge string) error {
+	if err := s.repo.DeleteStepUp(ctx, challenge); err != nil {
+		return err
+	}
+
+	return ErrStepUpExpired
+}
+
+func (s *RiskService) isDenied(ip string) bool {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return false
+	}
+
+	for _, network := range s.denylist {
+		if network.Contains(parsedIp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isNewDevice reports whether the device is unknown while the user has known ones, the user's first device isn't new.
+// The unidentified device (the request without the device cookie) is unknown too.
+func (s *RiskService) isNewDevice(ctx context.Context, userId uint32, deviceId string) (bool, error) {
+	hasDevices, err := s.repo.HasDevices(ctx, userId)
+	if err != nil || !hasDevices {
+		return false, err
+	}
+
+	if deviceId == "" {
+		return true, nil
+	}
+
+	known, err := s.repo.HasDevice(ctx, userId, deviceId)
+	if err != nil {
+		return false, err
+	}
+
+	return !known, nil
+}
+
+// isImpossibleTravel reports whether the user would travel from the last sign-in's location faster than the limit.
+func (s *RiskService) isImpossibleTravel(last *domain.AuditEvent, ip string, now time.Time) bool {
+	if s.locator == nil || last.Ip == "" || last.Ip == ip {
+		return false
+	}
+
+	from, to := s.locator.Locate(last.Ip), s.locator.Locate(ip)
+	if from == nil || to == nil || !from.HasCoordinates || !to.HasCoordinates {
+		return false
+	}
+
+	distance := greatCircleDistance(from, to)
+	if distance < riskTravelMinDistance {
+		return false
+	}
+
+	duration := now.Sub(last.DateCreated)
+	if duration < riskTravelMinDuration {
+		duration = riskTravelMinDuration
+	}
+
+	return distance/duration.Hours() > s.config.ImpossibleTravelSpeed
+}
+
+// isUnusualHour reports whether none of the sign-ins were within the usual hour range of the time of the day, in UTC.
+func isUnusualHour(signins []domain.AuditEvent, now time.Time) bool {
+	hour := now.UTC().Hour()
+	for _, signin := range signins {
+		diff := signin.DateCreated.UTC().Hour() - hour
+		if diff < 0 {
+			diff = -diff
+		}
+
+		// The hours wrap around the midnight.
+		if diff <= riskUsualHourRange || 24-diff <= riskUsualHourRange {
+			return false
+		}
+	}
+
+	return true
+}
+
+// greatCircleDistance returns the distance between the locations in km by the haversine formula.
+func greatCircleDistance(from *domain.Location, to *domain.Location) float64 {
+	lat1, lat2 := from.Latitude*math.Pi/180, to.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// newStepUpCode returns the random one-time code of stepUpCodeDigits digits.
+func newStepUpCode() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(stepUpCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", stepUpCodeDigits, n), nil
+}
+
+// verificationChallenge is the key of the user's verification code among the sign-ins' step-ups,
+// Hydra's login challenges never contain the colon.
+func verificationChallenge(userId uint32) string {
+	return "verification:" + convert_to.ToString(userId)
+}
+
+// hashStepUpCode hashes the one-time code with its challenge, so the store keeps no usable codes.
+func hashStepUpCode(challenge string, code string) string {
+	return hashLinkToken(challenge + ":" + code)
+}
+
+// readDenylist reads the IPs and CIDRs one per line, the IPs are the single address networks.
+func readDenylist(filename string) ([]*net.IPNet, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var denylist []*net.IPNet
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry, _, _ := strings.Cut(scanner.Text(), "#")
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.Wrapf(ErrRiskDenylist, "line %d", line)
+			}
+
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.Wrapf(ErrRiskDenylist, "line %d", line)
+		}
+
+		denylist = append(denylist, network)
+	}
+
+	return denylist, scanner.Err()
+}
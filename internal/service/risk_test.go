@@ -0,0 +1,344 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"os"
+	"path/filepath"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+func testRiskConfig() *config.RiskConfig {
+	return &config.RiskConfig{
+		Enabled:                 true,
+		MediumScore:             30,
+		HighScore:               70,
+		NewDeviceScore:          30,
+		ImpossibleTravelScore:   50,
+		ImpossibleTravelSpeed:   1000,
+		DenylistScore:           100,
+		RecentFailuresScore:     20,
+		RecentFailuresThreshold: 3,
+		RecentFailuresWindow:    time.Hour,
+		UnusualHourScore:        10,
+		UsualHoursSignins:       5,
+		OTPTTL:                  10 * time.Minute,
+		OTPMaxAttempts:          5,
+	}
+}
+
+func TestRiskService_Assess(t *testing.T) {
+	now := time.Now()
+	testTable := []struct {
+		name        string
+		ip          string
+		knownDevice bool
+		// unidentifiedDevice is the sign-in without the device cookie.
+		unidentifiedDevice bool
+		failures           int
+		signins            []domain.AuditEvent
+		expectedAssessment *domain.RiskAssessment
+	}{
+		{
+			name:               "OK, known device",
+			ip:                 "192.0.2.1",
+			knownDevice:        true,
+			expectedAssessment: &domain.RiskAssessment{Score: 0, Level: domain.RiskLevelLow, Reasons: []string{}},
+		},
+		{
+			name:               "MEDIUM, new device",
+			ip:                 "192.0.2.1",
+			expectedAssessment: &domain.RiskAssessment{Score: 30, Level: domain.RiskLevelMedium, Reasons: []string{domain.RiskReasonNewDevice}},
+		},
+		{
+			name:               "MEDIUM, unidentified device",
+			ip:                 "192.0.2.1",
+			unidentifiedDevice: true,
+			expectedAssessment: &domain.RiskAssessment{Score: 30, Level: domain.RiskLevelMedium, Reasons: []string{domain.RiskReasonNewDevice}},
+		},
+		{
+			name:               "HIGH, denied IP",
+			ip:                 "203.0.113.7",
+			knownDevice:        true,
+			expectedAssessment: &domain.RiskAssessment{Score: 100, Level: domain.RiskLevelHigh, Reasons: []string{domain.RiskReasonDeniedIp}},
+		},
+		{
+			name:               "LOW, recent failures",
+			ip:                 "192.0.2.1",
+			knownDevice:        true,
+			failures:           3,
+			expectedAssessment: &domain.RiskAssessment{Score: 20, Level: domain.RiskLevelLow, Reasons: []string{domain.RiskReasonRecentFailures}},
+		},
+		{
+			name:        "MEDIUM, impossible travel",
+			ip:          "192.0.2.1",
+			knownDevice: true,
+			signins:     []domain.AuditEvent{{Ip: "198.51.100.1", DateCreated: now.Add(-time.Hour)}},
+			expectedAssessment: &domain.RiskAssessment{Score: 50, Level: domain.RiskLevelMedium,
+				Reasons: []string{domain.RiskReasonImpossibleTravel}},
+		},
+		{
+			name:        "OK, possible travel",
+			ip:          "192.0.2.1",
+			knownDevice: true,
+			signins:     []domain.AuditEvent{{Ip: "198.51.100.1", DateCreated: now.Add(-12 * time.Hour)}},
+			expectedAssessment: &domain.RiskAssessment{Score: 0, Level: domain.RiskLevelLow,
+				Reasons: []string{}},
+		},
+		{
+			name:        "LOW, unusual hour",
+			ip:          "192.0.2.1",
+			knownDevice: true,
+			signins: []domain.AuditEvent{
+				{Ip: "192.0.2.1", DateCreated: now.Add(-12 * time.Hour)},
+				{Ip: "192.0.2.1", DateCreated: now.Add(-36 * time.Hour)},
+				{Ip: "192.0.2.1", DateCreated: now.Add(-60 * time.Hour)},
+				{Ip: "192.0.2.1", DateCreated: now.Add(-84 * time.Hour)},
+				{Ip: "192.0.2.1", DateCreated: now.Add(-108 * time.Hour)},
+			},
+			expectedAssessment: &domain.RiskAssessment{Score: 10, Level: domain.RiskLevelLow, Reasons: []string{domain.RiskReasonUnusualHour}},
+		},
+	}
+
+	denylistFile := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(denylistFile, []byte("# Test networks\n203.0.113.0/24\n2001:db8::1 # single IP\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			deviceId := "device"
+			if testCase.unidentifiedDevice {
+				deviceId = ""
+			}
+			mockUserRepo.EXPECT().HasDevices(gomock.Any(), uint32(1)).Return(true, nil)
+			if deviceId != "" {
+				mockUserRepo.EXPECT().HasDevice(gomock.Any(), uint32(1), deviceId).Return(testCase.knownDevice, nil)
+			}
+			mockAudit := mock_service.NewMockAudit(ctrl)
+			mockAudit.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, input *service.AuditListInput) (*domain.AuditPage, error) {
+				if input.Outcome == domain.AuditOutcomeFailure {
+					return &domain.AuditPage{Events: make([]domain.AuditEvent, testCase.failures)}, nil
+				}
+
+				return &domain.AuditPage{Events: testCase.signins}, nil
+			}).Times(2)
+			mockLocator := mock_service.NewMockGeoLocator(ctrl)
+			mockLocator.EXPECT().Locate("192.0.2.1").Return(&domain.Location{City: "New York", Latitude: 40.71, Longitude: -74.01, HasCoordinates: true}).AnyTimes()
+			mockLocator.EXPECT().Locate("198.51.100.1").Return(&domain.Location{City: "Berlin", Latitude: 52.52, Longitude: 13.40, HasCoordinates: true}).AnyTimes()
+			riskConfig := testRiskConfig()
+			riskConfig.DenylistFile = denylistFile
+			risk, errInit := service.NewRiskService(mockUserRepo, mockAudit, nil, mockLocator, riskConfig)
+			ctx := domain.WithRequestInfo(context.Background(), &domain.RequestInfo{Ip: testCase.ip})
+
+			//// Act
+			assessment, err := risk.Assess(ctx, &domain.User{Id: 1}, deviceId)
+
+			//// Assert
+			assert.Equal(t, errInit, nil)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, assessment, testCase.expectedAssessment)
+		})
+	}
+}
+
+func TestRiskService_AssessDisabled(t *testing.T) {
+	//// Arrange
+	risk, _ := service.NewRiskService(nil, nil, nil, nil, &config.RiskConfig{DenylistFile: "missing.txt"})
+
+	//// Act
+	assessment, err := risk.Assess(context.Background(), &domain.User{Id: 1}, "device")
+
+	//// Assert
+	assert.Equal(t, err, nil)
+	assert.Equal(t, assessment, (*domain.RiskAssessment)(nil))
+}
+
+func TestRiskService_NewDenylist(t *testing.T) {
+	//// Arrange
+	denylistFile := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(denylistFile, []byte("192.0.2.1\nnot an ip\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	riskConfig := testRiskConfig()
+	riskConfig.DenylistFile = denylistFile
+
+	//// Act
+	_, err := service.NewRiskService(nil, nil, nil, nil, riskConfig)
+
+	//// Assert
+	assert.Equal(t, err.Error(), "line 2: "+service.ErrRiskDenylist.Error())
+}
+
+func TestRiskService_StepUp(t *testing.T) {
+	testTable := []struct {
+		name          string
+		wrongCode     bool
+		expired       bool
+		attempts      int
+		expectedErr   error
+		expectedUser  uint32
+		expectDeleted bool
+	}{
+		{
+			name:          "OK, code is correct",
+			attempts:      1,
+			expectedUser:  1,
+			expectDeleted: true,
+		},
+		{
+			name:         "BAD, code is incorrect",
+			wrongCode:    true,
+			attempts:     1,
+			expectedErr:  service.ErrStepUpCodeIncorrect,
+			expectedUser: 1,
+		},
+		{
+			name:          "BAD, too many attempts",
+			attempts:      6,
+			expectedErr:   service.ErrStepUpExpired,
+			expectedUser:  1,
+			expectDeleted: true,
+		},
+		{
+			name:          "BAD, code is expired",
+			expired:       true,
+			expectedErr:   service.ErrStepUpExpired,
+			expectedUser:  1,
+			expectDeleted: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			var stored *domain.StepUp
+			var code string
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			mockUserRepo.EXPECT().CreateStepUp(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, stepUp *domain.StepUp) error {
+				stored = stepUp
+				return nil
+			})
+			mockUserRepo.EXPECT().GetStepUp(gomock.Any(), "challenge").DoAndReturn(func(ctx context.Context, challenge string) (*domain.StepUp, error) {
+				if testCase.expired {
+					stored.DateExpires = time.Now().Add(-time.Second)
+				}
+				return stored, nil
+			})
+			mockUserRepo.EXPECT().CountStepUpAttempt(gomock.Any(), "challenge").Return(testCase.attempts, nil).AnyTimes()
+			if testCase.expectDeleted {
+				mockUserRepo.EXPECT().DeleteStepUp(gomock.Any(), "challenge").Return(nil)
+			}
+			mockNotifier := mock_service.NewMockNotifier(ctrl)
+			mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification *domain.Notification) error {
+				assert.Equal(t, notification.Type, domain.NotificationSigninCode)
+				assert.Equal(t, notification.Email, "alice@mail.com")
+				code = notification.Data["code"]
+				return nil
+			})
+			risk, _ := service.NewRiskService(mockUserRepo, nil, mockNotifier, nil, testRiskConfig())
+
+			//// Act
+			errStart := risk.StartStepUp(context.Background(), "challenge", &domain.User{Id: 1, Email: "alice@mail.com"}, true, "device")
+			if testCase.wrongCode {
+				code = "abcdef"
+			}
+			stepUp, err := risk.VerifyStepUp(context.Background(), "challenge", code)
+
+			//// Assert
+			assert.Equal(t, errStart, nil)
+			assert.Equal(t, len(code), 6)
+			// The store keeps no usable codes.
+			assert.NotEqual(t, stored.CodeHash, code)
+			assert.Equal(t, err, testCase.expectedErr)
+			assert.Equal(t, stepUp.UserId, testCase.expectedUser)
+			assert.Equal(t, stepUp.Remember, true)
+		})
+	}
+}
+
+func TestRiskService_VerificationCode(t *testing.T) {
+	testTable := []struct {
+		name          string
+		wrongCode     bool
+		attempts      int
+		expectedErr   error
+		expectDeleted bool
+	}{
+		{
+			name:          "OK, code is correct",
+			attempts:      1,
+			expectDeleted: true,
+		},
+		{
+			name:        "BAD, code is incorrect",
+			wrongCode:   true,
+			attempts:    1,
+			expectedErr: service.ErrStepUpCodeIncorrect,
+		},
+		{
+			name:          "BAD, too many attempts",
+			attempts:      6,
+			expectedErr:   service.ErrVerificationExpired,
+			expectDeleted: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			var stored *domain.StepUp
+			var code string
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			mockUserRepo.EXPECT().CreateStepUp(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, stepUp *domain.StepUp) error {
+				stored = stepUp
+				return nil
+			})
+			mockUserRepo.EXPECT().GetStepUp(gomock.Any(), "verification:1").DoAndReturn(func(ctx context.Context, challenge string) (*domain.StepUp, error) {
+				return stored, nil
+			})
+			mockUserRepo.EXPECT().CountStepUpAttempt(gomock.Any(), "verification:1").Return(testCase.attempts, nil)
+			if testCase.expectDeleted {
+				mockUserRepo.EXPECT().DeleteStepUp(gomock.Any(), "verification:1").Return(nil)
+			}
+			mockNotifier := mock_service.NewMockNotifier(ctrl)
+			mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification *domain.Notification) error {
+				assert.Equal(t, notification.Type, domain.NotificationVerificationCode)
+				assert.Equal(t, notification.Email, "alice@mail.com")
+				code = notification.Data["code"]
+				return nil
+			})
+			risk, _ := service.NewRiskService(mockUserRepo, nil, mockNotifier, nil, testRiskConfig())
+
+			//// Act
+			errSend := risk.SendVerificationCode(context.Background(), &domain.User{Id: 1, Email: "alice@mail.com"})
+			if testCase.wrongCode {
+				code = "abcdef"
+			}
+			err := risk.CheckVerificationCode(context.Background(), 1, code)
+
+			//// Assert
+			assert.Equal(t, errSend, nil)
+			assert.Equal(t, stored.UserId, uint32(1))
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
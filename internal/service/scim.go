@@ -0,0 +1,539 @@
+package service
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/pkg/convert_to"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// The SCIM listing's page size if the count isn't set.
+	scimListDefaultCount = 100
+	// The largest SCIM listing's page.
+	scimListMaxCount = 200
+	// The reason of the user's deactivation by the provisioning client.
+	scimSuspensionReason = "Deactivated by the provisioning client"
+	// The longest group name.
+	groupNameMaxLength = 255
+)
+
+var (
+	ErrGroupNotFound     = errors.New("Group not found")
+	ErrGroupAlreadyExist = errors.New("Group already exist")
+	// ErrGroupVersionConflict the group was changed since the client read it.
+	ErrGroupVersionConflict = errors.New("Group was changed by another request")
+	ErrGroupNameInvalid     = errors.New("Group name must be 1 to 255 characters long")
+	// ErrGroupMemberNotFound the group's member isn't the existing user.
+	ErrGroupMemberNotFound = errors.New("Group member not found")
+)
+
+// SCIMUserInput is the user's state set by the provisioning client, the empty password isn't changed.
+type SCIMUserInput struct {
+	Username    string
+	Email       string
+	DisplayName string
+	Locale      string
+	ExternalId  string
+	Password    string
+	// Active is false for the deactivated user, the user is suspended. The omitted (nil) one doesn't change the user's state,
+	// the new user is active.
+	Active *bool
+}
+
+// SCIMGroupInput is the group's state set by the provisioning client.
+type SCIMGroupInput struct {
+	DisplayName string
+	ExternalId  string
+	MemberIds   []uint32
+}
+
+// SCIMUserListInput the empty filters don't filter.
+type SCIMUserListInput struct {
+	Username       string
+	Email          string
+	ExternalId     string
+	UsernamePrefix string
+	EmailPrefix    string
+	// StartIndex is the 1-based index of the page's first user.
+	StartIndex int
+	// Count is the page size, it's the default one if it's nil. The zero count returns the total only.
+	Count *int
+}
+
+// SCIMGroupListInput the empty filters don't filter.
+type SCIMGroupListInput struct {
+	DisplayName string
+	ExternalId  string
+	// StartIndex is the 1-based index of the page's first group.
+	StartIndex int
+	// Count is the page size, it's the default one if it's nil. The zero count returns the total only.
+	Count *int
+}
+
+// SCIMUser is the provisioned user with the user's groups, the groups have no members.
+type SCIMUser struct {
+	User   *domain.User
+	Groups []domain.Group
+}
+
+// SCIMUserPage is the page of the provisioned users.
+type SCIMUserPage struct {
+	Users []SCIMUser
+	// Total is the number of the users matching the filters on all pages.
+	Total      int64
+	StartIndex int
+}
+
+// SCIMGroupPage is the page of the provisioned groups.
+type SCIMGroupPage struct {
+	Groups []domain.Group
+	// Total is the number of the groups matching the filters on all pages.
+	Total      int64
+	StartIndex int
+}
+
+// SCIMService provisions the users and groups by the HR system, every change is audited with the client's id.
+// The deactivated users are suspended and the deleted ones are soft-deleted, so they are erased after the grace period.
+type SCIMService struct {
+	repo     UserRepository
+	groups   GroupRepository
+	hasher   Hasher
+	oa2      OAuth2
+	sessions Sessions
+	audit    Audit
+	config   *config.Config
+}
+
+func NewSCIMService(repo UserRepository, groups GroupRepository, hasher Hasher, oa2 OAuth2, sessionService Sessions, auditService Audit, config *config.Config) *SCIMService {
+	return &SCIMService{
+		repo:     repo,
+		groups:   groups,
+		hasher:   hasher,
+		oa2:      oa2,
+		sessions: sessionService,
+		audit:    auditService,
+		config:   config,
+	}
+}
+
+// ListUsers returns the page of the users, the deleted users aren't listed.
+func (s *SCIMService) ListUsers(ctx context.Context, input *SCIMUserListInput) (*SCIMUserPage, error) {
+	offset, limit, totalOnly := scimPage(input.StartIndex, input.Count)
+	users, total, err := s.repo.List(ctx, &domain.UserListQuery{
+		Username:       input.Username,
+		Email:          input.Email,
+		ExternalId:     input.ExternalId,
+		UsernamePrefix: input.UsernamePrefix,
+		EmailPrefix:    input.EmailPrefix,
+		Existing:       true,
+		Sort:           domain.UserSortId,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SCIMUserPage{
+		Total:      total,
+		StartIndex: offset + 1,
+	}
+	if totalOnly || len(users) == 0 {
+		return page, nil
+	}
+
+	userIds := make([]uint32, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.Id)
+	}
+
+	groups, err := s.groups.ListUserGroups(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		page.Users = append(page.Users, SCIMUser{User: &users[i], Groups: groups[users[i].Id]})
+	}
+
+	return page, nil
+}
+
+// GetUser returns the user with the user's groups.
+func (s *SCIMService) GetUser(ctx context.Context, id uint32) (*SCIMUser, error) {
+	user, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return s.withGroups(ctx, user)
+}
+
+// CreateUser registers the user, the user without the password signs in with the identity provider or the directory.
+// The inactive user is suspended at once.
+func (s *SCIMService) CreateUser(ctx context.Context, clientId string, input *SCIMUserInput) (*SCIMUser, error) {
+	now := time.Now()
+	user := &domain.User{
+		PasswordHash:     []byte{},
+		DateRegistration: now,
+		DateLastOnline:   now,
+		Version:          1,
+	}
+	if err := s.applyUserInput(ctx, user, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, provisionError(err)
+	}
+
+	if err := s.record(ctx, s.event(domain.AuditActionUserProvisioned, clientId, user.Id)); err != nil {
+		return nil, err
+	}
+
+	if err := s.setActive(ctx, clientId, user, input.Active, now); err != nil {
+		return nil, err
+	}
+
+	return &SCIMUser{User: user}, nil
+}
+
+// ReplaceUser replaces the user's state. The update is rejected if the version isn't zero and the user's version differs.
+// The deactivated user is suspended and signed out everywhere, the activated one is unsuspended if the provisioning client
+// suspended it.
+func (s *SCIMService) ReplaceUser(ctx context.Context, clientId string, id uint32, version uint32, input *SCIMUserInput) (*SCIMUser, error) {
+	user, err := s.repo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	if version != 0 && user.Version != version {
+		return nil, ErrUserVersionConflict
+	}
+
+	if err = s.applyUserInput(ctx, user, input); err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.Update(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, repository.ErrUsernameAlreadyExist), errors.Is(err, repository.ErrEmailAlreadyExist),
+			errors.Is(err, repository.ErrExternalIdAlreadyExist), errors.Is(err, repository.ErrRecordAlreadyExist):
+			return nil, ErrUserAlreadyExist
+		case errors.Is(err, repository.ErrRecordVersionConflict):
+			return nil, ErrUserVersionConflict
+		}
+
+		return nil, err
+	}
+
+	if input.Password != "" {
+		if err = s.repo.UpdatePasswordHash(ctx, id, user.PasswordHash); err != nil {
+			return nil, userError(err)
+		}
+
+		user.Version++
+	}
+
+	if err = s.record(ctx, s.event(domain.AuditActionUserUpdated, clientId, user.Id)); err != nil {
+		return nil, err
+	}
+
+	if err = s.setActive(ctx, clientId, user, input.Active, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.withGroups(ctx, user)
+}
+
+// DeleteUser deletes the user and ends all the user's sessions. The user is erased after the grace period,
+// nobody gets the restore link.
+func (s *SCIMService) DeleteUser(ctx context.Context, clientId string, id uint32) error {
+	_, restoreTokenHash, err := newLinkToken()
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.SoftDelete(ctx, id, restoreTokenHash); err != nil {
+		return userError(err)
+	}
+
+	if err = s.record(ctx, s.event(domain.AuditActionUserDeprovisioned, clientId, id)); err != nil {
+		return err
+	}
+
+	return signOutEverywhere(ctx, s.oa2, s.sessions, convert_to.ToString(id))
+}
+
+// ListGroups returns the page of the groups with their members.
+func (s *SCIMService) ListGroups(ctx context.Context, input *SCIMGroupListInput) (*SCIMGroupPage, error) {
+	offset, limit, totalOnly := scimPage(input.StartIndex, input.Count)
+	groups, total, err := s.groups.ListGroups(ctx, &domain.GroupListQuery{
+		DisplayName: input.DisplayName,
+		ExternalId:  input.ExternalId,
+		Offset:      offset,
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SCIMGroupPage{
+		Total:      total,
+		StartIndex: offset + 1,
+	}
+	if !totalOnly {
+		page.Groups = groups
+	}
+
+	return page, nil
+}
+
+// GetGroup returns the group with its members.
+func (s *SCIMService) GetGroup(ctx context.Context, id uint32) (*domain.Group, error) {
+	group, err := s.groups.GetGroup(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// CreateGroup creates the group with the members.
+func (s *SCIMService) CreateGroup(ctx context.Context, clientId string, input *SCIMGroupInput) (*domain.Group, error) {
+	now := time.Now()
+	group := &domain.Group{
+		DateCreated: now,
+		DateUpdated: now,
+		Version:     1,
+	}
+	if err := applyGroupInput(group, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.groups.CreateGroup(ctx, group); err != nil {
+		return nil, groupError(err)
+	}
+
+	if err := s.record(ctx, s.groupEvent(domain.AuditActionGroupCreated, clientId, group)); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, group.Id)
+}
+
+// ReplaceGroup replaces the group's name and members. The update is rejected if the version isn't zero
+// and the group's version differs.
+func (s *SCIMService) ReplaceGroup(ctx context.Context, clientId string, id uint32, version uint32, input *SCIMGroupInput) (*domain.Group, error) {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && group.Version != version {
+		return nil, ErrGroupVersionConflict
+	}
+
+	if err = applyGroupInput(group, input); err != nil {
+		return nil, err
+	}
+
+	group.DateUpdated = time.Now()
+	if err = s.groups.UpdateGroup(ctx, group); err != nil {
+		if errors.Is(err, repository.ErrRecordVersionConflict) {
+			return nil, ErrGroupVersionConflict
+		}
+
+		return nil, groupError(err)
+	}
+
+	if err = s.record(ctx, s.groupEvent(domain.AuditActionGroupUpdated, clientId, group)); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, group.Id)
+}
+
+// DeleteGroup deletes the group, the members aren't deleted.
+func (s *SCIMService) DeleteGroup(ctx context.Context, clientId string, id uint32) error {
+	if err := s.groups.DeleteGroup(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrGroupNotFound
+		}
+
+		return err
+	}
+
+	return s.record(ctx, s.groupEvent(domain.AuditActionGroupDeleted, clientId, &domain.Group{Id: id}))
+}
+
+// applyUserInput sets the user's fields, the username and email are normalized and the password is hashed.
+func (s *SCIMService) applyUserInput(ctx context.Context, user *domain.User, input *SCIMUserInput) error {
+	username, err := NormalizeUsername(input.Username)
+	if err != nil {
+		return err
+	}
+
+	email, err := NormalizeEmail(input.Email)
+	if err != nil {
+		return err
+	}
+
+	if input.Password != "" {
+		if err = checkPasswordPolicy(passwordPolicy(ctx, &s.config.Password), input.Password, username, email); err != nil {
+			return err
+		}
+
+		user.PasswordHash = s.hasher.Hash(input.Password, []byte(s.config.DB.Salt))
+	}
+
+	user.Username = username
+	user.Email = email
+	user.DisplayName = strings.TrimSpace(input.DisplayName)
+	user.Locale = strings.TrimSpace(input.Locale)
+	user.ExternalId = strings.TrimSpace(input.ExternalId)
+
+	return nil
+}
+
+// setActive suspends the deactivated user and signs the user out everywhere, or unsuspends the activated one.
+// Only the suspension by the provisioning client is lifted, the admins' suspensions and the lockouts stay.
+// The omitted state doesn't change the user.
+func (s *SCIMService) setActive(ctx context.Context, clientId string, user *domain.User, active *bool, now time.Time) error {
+	if active == nil {
+		return nil
+	}
+
+	switch {
+	case !*active && !user.IsSuspended(now):
+		if err := s.repo.Suspend(ctx, user.Id, scimSuspensionReason, nil); err != nil {
+			return userError(err)
+		}
+
+		user.DateSuspended, user.DateSuspendedUntil, user.SuspensionReason = &now, nil, scimSuspensionReason
+		user.Version++
+
+		event := s.event(domain.AuditActionUserSuspended, clientId, user.Id)
+		event.Reason = scimSuspensionReason
+		if err := s.record(ctx, event); err != nil {
+			return err
+		}
+
+		return signOutEverywhere(ctx, s.oa2, s.sessions, convert_to.ToString(user.Id))
+	case *active && user.IsSuspended(now) && user.SuspensionReason == scimSuspensionReason:
+		if err := s.repo.Unsuspend(ctx, user.Id); err != nil {
+			return userError(err)
+		}
+
+		user.DateSuspended, user.DateSuspendedUntil, user.SuspensionReason = nil, nil, ""
+		user.Version++
+
+		return s.record(ctx, s.event(domain.AuditActionUserUnsuspended, clientId, user.Id))
+	}
+
+	return nil
+}
+
+func (s *SCIMService) withGroups(ctx context.Context, user *domain.User) (*SCIMUser, error) {
+	groups, err := s.groups.ListUserGroups(ctx, []uint32{user.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SCIMUser{User: user, Groups: groups[user.Id]}, nil
+}
+
+func (s *SCIMService) event(action string, clientId string, userId uint32) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		Action:   action,
+		UserId:   &userId,
+		ClientId: clientId,
+	}
+}
+
+func (s *SCIMService) groupEvent(action string, clientId string, group *domain.Group) *domain.AuditEvent {
+	event := &domain.AuditEvent{
+		Action:   action,
+		ClientId: clientId,
+		Data:     map[string]string{"group_id": convert_to.ToString(group.Id)},
+	}
+
+	if group.DisplayName != "" {
+		event.Data["display_name"] = group.DisplayName
+	}
+
+	return event
+}
+
+func (s *SCIMService) record(ctx context.Context, event *domain.AuditEvent) error {
+	return recordAudit(ctx, s.audit, event)
+}
+
+// applyGroupInput sets the group's name, external id and members, the repeated members are dropped.
+func applyGroupInput(group *domain.Group, input *SCIMGroupInput) error {
+	displayName := strings.TrimSpace(input.DisplayName)
+	if displayName == "" || utf8.RuneCountInString(displayName) > groupNameMaxLength {
+		return ErrGroupNameInvalid
+	}
+
+	group.DisplayName = displayName
+	group.ExternalId = strings.TrimSpace(input.ExternalId)
+	group.Members = nil
+
+	added := map[uint32]bool{}
+	for _, userId := range input.MemberIds {
+		if !added[userId] {
+			added[userId] = true
+			group.Members = append(group.Members, domain.GroupMember{UserId: userId})
+		}
+	}
+
+	return nil
+}
+
+// scimPage returns the offset and limit of the page. The zero count still reads one record, it's dropped.
+func scimPage(startIndex int, count *int) (int, int, bool) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	limit := scimListDefaultCount
+	if count != nil {
+		limit = *count
+	}
+
+	if limit <= 0 {
+		return startIndex - 1, 1, true
+	}
+
+	if limit > scimListMaxCount {
+		limit = scimListMaxCount
+	}
+
+	return startIndex - 1, limit, false
+}
+
+// groupError maps the taken name or external id and the missing member.
+func groupError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRecordAlreadyExist):
+		return ErrGroupAlreadyExist
+	case errors.Is(err, repository.ErrRecordNotFound):
+		// The group was read or created, so the missing record is the member.
+		return ErrGroupMemberNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,368 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+type TestTableSCIM struct {
+	name                  string
+	input                 *service.SCIMUserInput
+	mockBehaviorUserRepo  mockBehaviorUserRepo
+	mockBehaviorGroupRepo func(mockGroupRepo *mock_service.MockGroupRepository)
+	mockBehaviorSignOut   func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions)
+	mockBehaviorAudit     func(mockAudit *mock_service.MockAudit)
+	// expectedVersion and expectedSuspensionReason are the replaced user's ones.
+	expectedVersion          uint32
+	expectedSuspensionReason string
+	expectedErr              error
+}
+
+// signedOut expects the user to be signed out everywhere.
+func signedOut(subject string) func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {
+	return func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {
+		mockOAuth2.EXPECT().RevokeLoginSessions(gomock.Any(), subject).Return(nil)
+		mockOAuth2.EXPECT().RevokeConsentSessions(gomock.Any(), subject, false).Return(nil)
+		mockSessions.EXPECT().DeleteBySubject(gomock.Any(), subject, "").Return(nil)
+	}
+}
+
+func scimEvent(action string, reason string) *domain.AuditEvent {
+	userId := uint32(5)
+	return &domain.AuditEvent{Action: action, UserId: &userId, ClientId: "hr", Reason: reason}
+}
+
+func TestSCIMService_CreateUser(t *testing.T) {
+	active, inactive := true, false
+	testTable := []TestTableSCIM{
+		{
+			name:  "OK, provision active user",
+			input: &service.SCIMUserInput{Username: "alice", Email: "Alice@Mail.com", DisplayName: " Alice ", ExternalId: "e-5", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					assert.Equal(t, user.Username, "alice")
+					assert.Equal(t, user.Email, "Alice@mail.com")
+					assert.Equal(t, user.DisplayName, "Alice")
+					assert.Equal(t, user.ExternalId, "e-5")
+					assert.Equal(t, user.PasswordHash, []byte{})
+					user.Id = 5
+					return nil
+				})
+			},
+			mockBehaviorSignOut: func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserProvisioned, "")).Return(nil)
+			},
+		},
+		{
+			name:  "OK, provision inactive user suspended",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &inactive},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					user.Id = 5
+					return nil
+				})
+				mockRepo.EXPECT().Suspend(gomock.Any(), uint32(5), "Deactivated by the provisioning client", nil).Return(nil)
+			},
+			mockBehaviorSignOut: signedOut("5"),
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserProvisioned, "")).Return(nil)
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserSuspended, "Deactivated by the provisioning client")).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, user already exist",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrRecordAlreadyExist)
+			},
+			mockBehaviorSignOut: func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit:   func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:         service.ErrUserAlreadyExist,
+		},
+		{
+			name:                 "BAD, email is invalid",
+			input:                &service.SCIMUserInput{Username: "alice", Email: "alice", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {},
+			mockBehaviorSignOut:  func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit:    func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:          service.ErrEmailInvalid,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			scimService := newSCIMService(ctrl, &testCase)
+
+			//// Act
+			_, err := scimService.CreateUser(context.Background(), "hr", testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func TestSCIMService_ReplaceUser(t *testing.T) {
+	active, inactive := true, false
+	suspendedUser := func(reason string) *domain.User {
+		suspended := time.Now().Add(-time.Hour)
+		return &domain.User{Id: 5, Username: "alice", Email: "alice@mail.com", Version: 2, DateSuspended: &suspended, SuspensionReason: reason}
+	}
+	testTable := []TestTableSCIM{
+		{
+			name:  "OK, deactivate user signed out",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &inactive},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(&domain.User{Id: 5, Username: "alice", Email: "alice@mail.com", Version: 2}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					user.Version++
+					return nil
+				})
+				mockRepo.EXPECT().Suspend(gomock.Any(), uint32(5), "Deactivated by the provisioning client", nil).Return(nil)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{5}).Return(map[uint32][]domain.Group{}, nil)
+			},
+			mockBehaviorSignOut: signedOut("5"),
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserUpdated, "")).Return(nil)
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserSuspended, "Deactivated by the provisioning client")).Return(nil)
+			},
+			expectedVersion:          4,
+			expectedSuspensionReason: "Deactivated by the provisioning client",
+		},
+		{
+			name:  "OK, activate user deactivated by client",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(suspendedUser("Deactivated by the provisioning client"), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					user.Version++
+					return nil
+				})
+				mockRepo.EXPECT().Unsuspend(gomock.Any(), uint32(5)).Return(nil)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{5}).Return(map[uint32][]domain.Group{}, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserUpdated, "")).Return(nil)
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserUnsuspended, "")).Return(nil)
+			},
+			expectedVersion: 4,
+		},
+		{
+			name:  "OK, activate user doesn't lift admin's suspension",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(suspendedUser("Spam"), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					user.Version++
+					return nil
+				})
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{5}).Return(map[uint32][]domain.Group{}, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserUpdated, "")).Return(nil)
+			},
+			expectedVersion:          3,
+			expectedSuspensionReason: "Spam",
+		},
+		{
+			name:  "OK, omitted active keeps suspension",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com"},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(suspendedUser("Deactivated by the provisioning client"), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *domain.User) error {
+					user.Version++
+					return nil
+				})
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().ListUserGroups(gomock.Any(), []uint32{5}).Return(map[uint32][]domain.Group{}, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserUpdated, "")).Return(nil)
+			},
+			expectedVersion:          3,
+			expectedSuspensionReason: "Deactivated by the provisioning client",
+		},
+		{
+			name:  "BAD, user was changed",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(&domain.User{Id: 5, Version: 3}, nil)
+			},
+			mockBehaviorSignOut: func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit:   func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:         service.ErrUserVersionConflict,
+		},
+		{
+			name:  "BAD, user not found",
+			input: &service.SCIMUserInput{Username: "alice", Email: "alice@mail.com", Active: &active},
+			mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+				mockRepo.EXPECT().GetUserById(gomock.Any(), uint32(5)).Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorSignOut: func(mockOAuth2 *mock_service.MockOAuth2, mockSessions *mock_service.MockSessions) {},
+			mockBehaviorAudit:   func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:         service.ErrUserNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			scimService := newSCIMService(ctrl, &testCase)
+
+			//// Act
+			user, err := scimService.ReplaceUser(context.Background(), "hr", 5, 2, testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if err == nil {
+				assert.Equal(t, user.User.Version, testCase.expectedVersion)
+				assert.Equal(t, user.User.SuspensionReason, testCase.expectedSuspensionReason)
+			}
+		})
+	}
+}
+
+func TestSCIMService_DeleteUser(t *testing.T) {
+	testCase := TestTableSCIM{
+		mockBehaviorUserRepo: func(mockRepo *mock_service.MockUserRepository) {
+			mockRepo.EXPECT().SoftDelete(gomock.Any(), uint32(5), gomock.Any()).Return(nil)
+		},
+		mockBehaviorSignOut: signedOut("5"),
+		mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+			mockAudit.EXPECT().Record(gomock.Any(), scimEvent(domain.AuditActionUserDeprovisioned, "")).Return(nil)
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	scimService := newSCIMService(ctrl, &testCase)
+
+	//// Act
+	err := scimService.DeleteUser(context.Background(), "hr", 5)
+
+	//// Assert
+	assert.Equal(t, err, nil)
+}
+
+func TestSCIMService_CreateGroup(t *testing.T) {
+	testTable := []struct {
+		name                  string
+		input                 *service.SCIMGroupInput
+		mockBehaviorGroupRepo func(mockGroupRepo *mock_service.MockGroupRepository)
+		mockBehaviorAudit     func(mockAudit *mock_service.MockAudit)
+		expectedErr           error
+	}{
+		{
+			name:  "OK, create group without repeated members",
+			input: &service.SCIMGroupInput{DisplayName: " Engineering ", MemberIds: []uint32{5, 6, 5}},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, group *domain.Group) error {
+					assert.Equal(t, group.DisplayName, "Engineering")
+					assert.Equal(t, group.Members, []domain.GroupMember{{UserId: 5}, {UserId: 6}})
+					group.Id = 3
+					return nil
+				})
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(3)).Return(&domain.Group{Id: 3, DisplayName: "Engineering"}, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), &domain.AuditEvent{
+					Action:   domain.AuditActionGroupCreated,
+					ClientId: "hr",
+					Data:     map[string]string{"group_id": "3", "display_name": "Engineering"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:  "BAD, member not found",
+			input: &service.SCIMGroupInput{DisplayName: "Engineering", MemberIds: []uint32{9}},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).Return(repository.ErrRecordNotFound)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupMemberNotFound,
+		},
+		{
+			name:  "BAD, name taken",
+			input: &service.SCIMGroupInput{DisplayName: "Engineering"},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).Return(repository.ErrRecordAlreadyExist)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrGroupAlreadyExist,
+		},
+		{
+			name:                  "BAD, name is empty",
+			input:                 &service.SCIMGroupInput{DisplayName: " "},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {},
+			mockBehaviorAudit:     func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:           service.ErrGroupNameInvalid,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			scimService := newSCIMService(ctrl, &TestTableSCIM{
+				mockBehaviorGroupRepo: testCase.mockBehaviorGroupRepo,
+				mockBehaviorAudit:     testCase.mockBehaviorAudit,
+			})
+
+			//// Act
+			_, err := scimService.CreateGroup(context.Background(), "hr", testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+func newSCIMService(ctrl *gomock.Controller, testCase *TestTableSCIM) *service.SCIMService {
+	mockRepo := mock_service.NewMockUserRepository(ctrl)
+	if testCase.mockBehaviorUserRepo != nil {
+		testCase.mockBehaviorUserRepo(mockRepo)
+	}
+
+	mockGroupRepo := mock_service.NewMockGroupRepository(ctrl)
+	if testCase.mockBehaviorGroupRepo != nil {
+		testCase.mockBehaviorGroupRepo(mockGroupRepo)
+	}
+
+	mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+	mockSessions := mock_service.NewMockSessions(ctrl)
+	if testCase.mockBehaviorSignOut != nil {
+		testCase.mockBehaviorSignOut(mockOAuth2, mockSessions)
+	}
+
+	mockAudit := mock_service.NewMockAudit(ctrl)
+	testCase.mockBehaviorAudit(mockAudit)
+
+	return service.NewSCIMService(mockRepo, mockGroupRepo, nil, mockOAuth2, mockSessions, mockAudit, &config.Config{})
+}
@@ -4,6 +4,7 @@ import (
 	"golang.org/x/net/context"
 	"service-account/internal/config"
 	"service-account/internal/domain"
+	"time"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
@@ -15,40 +16,339 @@ type Hasher interface {
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetUserById(ctx context.Context, id uint32) (*domain.User, error)
+	Update(ctx context.Context, user *domain.User) error
+	UpdatePasswordHash(ctx context.Context, id uint32, passwordHash []byte) error
+	CreateEmailChange(ctx context.Context, emailChange *domain.EmailChange) error
+	ConfirmEmailChange(ctx context.Context, tokenHash string, revertTokenHash string, revertExpires time.Time) (*domain.EmailChange, error)
+	RevertEmailChange(ctx context.Context, revertTokenHash string) (*domain.EmailChange, error)
+	ListEmailChanges(ctx context.Context, userId uint32) ([]domain.EmailChange, error)
+	SoftDelete(ctx context.Context, id uint32, restoreTokenHash string) error
+	Restore(ctx context.Context, restoreTokenHash string, deletedAfter time.Time) (*domain.User, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	List(ctx context.Context, query *domain.UserListQuery) ([]domain.User, int64, error)
+	Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error
+	Unsuspend(ctx context.Context, id uint32) error
+	RecordFailedSignin(ctx context.Context, id uint32, threshold int, lockUntil time.Time) error
+	Unlock(ctx context.Context, id uint32) error
+	UpdateLastOnline(ctx context.Context, lastOnline map[uint32]time.Time) error
+	SaveDevice(ctx context.Context, device *domain.UserDevice) (bool, error)
+	HasDevices(ctx context.Context, userId uint32) (bool, error)
+	HasDevice(ctx context.Context, userId uint32, deviceId string) (bool, error)
+	ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error)
+	CreateStepUp(ctx context.Context, stepUp *domain.StepUp) error
+	GetStepUp(ctx context.Context, challenge string) (*domain.StepUp, error)
+	CountStepUpAttempt(ctx context.Context, challenge string) (int, error)
+	DeleteStepUp(ctx context.Context, challenge string) error
+	CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.Identity) error
+	GetIdentity(ctx context.Context, provider string, subject string) (*domain.Identity, error)
+	TouchIdentity(ctx context.Context, id uint32, email string, lastUsed time.Time) error
+	CreateUpstreamLogin(ctx context.Context, login *domain.UpstreamLogin) error
+	TakeUpstreamLogin(ctx context.Context, stateHash string) (*domain.UpstreamLogin, error)
+	ListIdentities(ctx context.Context, userId uint32) ([]domain.Identity, error)
+	CreateIdentity(ctx context.Context, identity *domain.Identity) error
+	DeleteIdentity(ctx context.Context, userId uint32, id uint32) error
+	RemovePasswordHash(ctx context.Context, userId uint32) error
+	CreateIdentityLink(ctx context.Context, link *domain.IdentityLink) error
+	GetIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error)
+	TakeIdentityLink(ctx context.Context, tokenHash string) (*domain.IdentityLink, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+	List(ctx context.Context, query *domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+// AuditSink receives a copy of every audit event, e.g. the dedicated audit log file.
+type AuditSink interface {
+	Write(event *domain.AuditEvent)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, notification *domain.Notification) error
+}
+
+// GeoLocator returns the approximate location of the IP, it's nil if it's unknown.
+type GeoLocator interface {
+	Locate(ip string) *domain.Location
+}
+
+// CredentialBackend checks the credentials against the external directory instead of the local password hashes.
+type CredentialBackend interface {
+	// Find returns the entry of the login, it's nil if the directory doesn't know the login.
+	Find(ctx context.Context, login string) (*domain.DirectoryEntry, error)
+	// Verify reports whether the password is the entry's one.
+	Verify(ctx context.Context, entry *domain.DirectoryEntry, password string) (bool, error)
+}
+
+// IdentityProviders are the upstream OpenID Connect providers the users sign in with.
+type IdentityProviders interface {
+	List() []domain.IdentityProvider
+	AuthCodeURL(ctx context.Context, providerId string, state string, nonce string, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, providerId string, code string, codeVerifier string, nonce string) (*domain.UpstreamClaims, error)
+}
+
+type RoleRepository interface {
+	GetUserRoles(ctx context.Context, userId uint32) ([]string, error)
+	GetUserPermissions(ctx context.Context, userId uint32) ([]string, error)
+	AssignRole(ctx context.Context, userRole *domain.UserRole) error
+	RevokeRole(ctx context.Context, userId uint32, role string) error
+}
+
+type GroupRepository interface {
+	CreateGroup(ctx context.Context, group *domain.Group) error
+	GetGroup(ctx context.Context, id uint32) (*domain.Group, error)
+	ListGroups(ctx context.Context, query *domain.GroupListQuery) ([]domain.Group, int64, error)
+	UpdateGroup(ctx context.Context, group *domain.Group) error
+	DeleteGroup(ctx context.Context, id uint32) error
+	ListUserGroups(ctx context.Context, userIds []uint32) (map[uint32][]domain.Group, error)
+	SetGroupMember(ctx context.Context, member *domain.GroupMember, dateUpdated time.Time) error
+	DeleteGroupMember(ctx context.Context, groupId uint32, userId uint32, dateUpdated time.Time) error
+	CreateOrganization(ctx context.Context, organization *domain.Organization) error
+	GetOrganization(ctx context.Context, id uint32) (*domain.Organization, error)
+	ListOrganizations(ctx context.Context) ([]domain.Organization, error)
+}
+
+type ServiceAccountRepository interface {
+	CreateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error
+	GetServiceAccount(ctx context.Context, id uint32) (*domain.ServiceAccount, error)
+	GetServiceAccountByClientId(ctx context.Context, clientId string) (*domain.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]domain.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, account *domain.ServiceAccount) error
+	DeleteServiceAccount(ctx context.Context, id uint32) error
+	CreateServiceAccountKey(ctx context.Context, key *domain.ServiceAccountKey) error
+	GetServiceAccountKey(ctx context.Context, keyHash string) (*domain.ServiceAccountKey, error)
+	ListServiceAccountKeys(ctx context.Context, serviceAccountId uint32) ([]domain.ServiceAccountKey, error)
+	RotateServiceAccountKey(ctx context.Context, keyId uint32, key *domain.ServiceAccountKey, dateExpires time.Time) error
+	RevokeServiceAccountKey(ctx context.Context, serviceAccountId uint32, keyId uint32, dateRevoked time.Time) error
+	TouchServiceAccountKey(ctx context.Context, id uint32, lastUsed time.Time) error
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	Get(ctx context.Context, id string) (*domain.Session, error)
+	Update(ctx context.Context, session *domain.Session) error
+	Delete(ctx context.Context, id string) error
+	DeleteBySid(ctx context.Context, sid string) error
+	ListBySubject(ctx context.Context, subject string) ([]domain.Session, error)
+	DeleteBySubject(ctx context.Context, subject string, exceptId string) error
+	DeleteExpired(ctx context.Context) error
 }
 
 // Dependencies of services.
 type Depends struct {
-	UserRepo UserRepository
-	Hasher   Hasher
+	UserRepo           UserRepository
+	RoleRepo           RoleRepository
+	GroupRepo          GroupRepository
+	SessionRepo        SessionRepository
+	AuditRepo          AuditRepository
+	Hasher             Hasher
+	ServiceAccountRepo ServiceAccountRepository
 }
 
 type OAuth2 interface {
 	TokenExchange(ctx context.Context, code string) (*domain.Token, error)
+	RefreshToken(ctx context.Context, token *domain.Token) (*domain.Token, error)
+	VerifyLogoutToken(ctx context.Context, logoutToken string) (*domain.OA2LogoutToken, error)
 	GetLoginRequest(context context.Context, challenge string) (*domain.OA2LoginRequest, error)
-	AcceptLoginRequest(context context.Context, challenge string, subject string, remember bool, rememberFor int64) (string, error)
+	AcceptLoginRequest(context context.Context, challenge string, subject string, remember bool, rememberFor int64, acr string) (string, error)
 	RejectLoginRequest(context context.Context, challenge string, errStr string, errDescStr string) (string, error)
 	GetConsentRequest(context context.Context, challenge string) (*domain.OA2ConsentRequest, error)
-	AcceptConsentRequest(context context.Context, challenge string, grantScope []string, grantAccessTokenAudience []string, remember bool, rememberFor int64) (string, error)
+	AcceptConsentRequest(context context.Context, challenge string, grantScope []string, grantAccessTokenAudience []string, session *domain.OA2ConsentSession, remember bool, rememberFor int64) (string, error)
 	RejectConsentRequest(context context.Context, challenge string, errStr string, errDescStr string) (string, error)
 	RejectLogoutRequest(context context.Context, challenge string) error
 	AcceptLogoutRequest(context context.Context, challenge string) (string, error)
+	GetLogoutRequest(context context.Context, challenge string) (*domain.OA2LogoutRequest, error)
 	IntrospectOAuth2Token(context context.Context, accessToken string) (*domain.OA2TokenIntrospection, error)
 	GenerateLogoutURL(idTokenHint string, state string, postLogoutRedirectUri string) string
 	GetAuthCodeUrl() string
+	RevokeLoginSessions(context context.Context, subject string) error
+	RevokeConsentSessions(context context.Context, subject string, exceptOwnClient bool) error
+	ListConsentGrants(context context.Context, subject string) ([]domain.OA2ConsentGrant, error)
+	CreateServiceClient(context context.Context, clientId string, name string, scope string) (string, error)
+	UpdateServiceClient(context context.Context, clientId string, name string, scope string) error
+	DeleteServiceClient(context context.Context, clientId string) error
 }
 
 type User interface {
-	SignUp(ctx context.Context, inputUserData *UserSignUpInput) error
+	SignUp(ctx context.Context, inputUserData *UserSignUpInput) (*domain.User, error)
 	SignIn(ctx context.Context, inputUserData *UserSignInInput) (*domain.User, error)
 	GetUserById(ctx context.Context, id uint32) (*domain.User, error)
+	Update(ctx context.Context, id uint32, version uint32, inputUserData *UserUpdateInput) (*domain.User, error)
+	ChangePassword(ctx context.Context, id uint32, inputUserData *UserChangePasswordInput) (*domain.User, error)
+	RequestEmailChange(ctx context.Context, id uint32, inputUserData *UserEmailChangeInput) (*domain.EmailChange, string, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, string, error)
+	RevertEmailChange(ctx context.Context, revertToken string) (*domain.EmailChange, error)
+	ListEmailChanges(ctx context.Context, id uint32) ([]domain.EmailChange, error)
+	Delete(ctx context.Context, id uint32, inputUserData *UserDeleteInput) (*domain.User, string, error)
+	Restore(ctx context.Context, restoreToken string) (*domain.User, error)
+	PurgeDeleted(ctx context.Context) (int64, error)
+	List(ctx context.Context, input *UserListInput) (*domain.UserPage, error)
+	Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error
+	Unsuspend(ctx context.Context, id uint32) error
+	Unlock(ctx context.Context, id uint32) error
+	UpdateLastOnline(ctx context.Context, id uint32) error
+}
+
+type RBAC interface {
+	Can(ctx context.Context, subject uint32, permission string, resource string) (bool, error)
+	GetUserRoles(ctx context.Context, userId uint32) ([]string, error)
+	AssignRole(ctx context.Context, userId uint32, role string) error
+	RevokeRole(ctx context.Context, userId uint32, role string) error
+}
+
+type Sessions interface {
+	Create(ctx context.Context, token *domain.Token) (string, error)
+	Get(ctx context.Context, id string) (*domain.Session, *domain.Token, error)
+	UpdateTokens(ctx context.Context, id string, token *domain.Token) error
+	Delete(ctx context.Context, id string) error
+	DeleteBySid(ctx context.Context, sid string) error
+	ListBySubject(ctx context.Context, subject string) ([]domain.Session, error)
+	DeleteBySubject(ctx context.Context, subject string, exceptId string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type Flow interface {
+	GetLoginFlow(ctx context.Context, challenge string) (*domain.LoginFlow, error)
+	SubmitLogin(ctx context.Context, challenge string, input *LoginSubmitInput) (string, error)
+	SubmitLoginCode(ctx context.Context, challenge string, code string) (string, error)
+	StartUpstreamLogin(ctx context.Context, challenge string, input *UpstreamLoginStartInput) (string, string, error)
+	SubmitUpstreamLogin(ctx context.Context, input *UpstreamLoginSubmitInput) (*domain.LoginFlow, error)
+	SubmitIdentityLink(ctx context.Context, input *IdentityLinkSubmitInput) (*domain.LoginFlow, error)
+	GetConsentFlow(ctx context.Context, challenge string) (*domain.ConsentFlow, error)
+	SubmitConsent(ctx context.Context, challenge string, input *ConsentSubmitInput) (string, error)
+	GetLogoutFlow(ctx context.Context, challenge string) (*domain.LogoutFlow, error)
+	SubmitLogout(ctx context.Context, challenge string, accept bool) (string, error)
+}
+
+type Account interface {
+	SignUp(ctx context.Context, input *UserSignUpInput) error
+	ChangePassword(ctx context.Context, userId uint32, input *ChangePasswordInput) error
+	RequestEmailChange(ctx context.Context, userId uint32, input *EmailChangeInput) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	RevertEmailChange(ctx context.Context, revertToken string) error
+	DeleteAccount(ctx context.Context, userId uint32, input *DeleteAccountInput) (time.Time, error)
+	RestoreAccount(ctx context.Context, restoreToken string) error
+	Export(ctx context.Context, userId uint32) (*domain.AccountExport, error)
+	SendVerificationCode(ctx context.Context, userId uint32) error
+}
+
+type Audit interface {
+	Record(ctx context.Context, event *domain.AuditEvent) error
+	List(ctx context.Context, input *AuditListInput) (*domain.AuditPage, error)
+}
+
+type Admin interface {
+	SuspendUser(ctx context.Context, actorId uint32, userId uint32, reason string, until *time.Time) error
+	UnsuspendUser(ctx context.Context, actorId uint32, userId uint32, reason string) error
+	UnlockUser(ctx context.Context, actorId uint32, userId uint32, reason string) error
+	LogoutUser(ctx context.Context, actorId uint32, userId uint32, reason string) error
+	AssignRole(ctx context.Context, actorId uint32, userId uint32, role string) error
+	RevokeRole(ctx context.Context, actorId uint32, userId uint32, role string) error
+}
+
+type Devices interface {
+	Identify(cookie string) (string, string, error)
+	Describe(ctx context.Context, deviceId string) map[string]string
+	Recognize(ctx context.Context, user *domain.User, deviceId string, details map[string]string) (bool, error)
+	LoginHistory(ctx context.Context, userId uint32) ([]domain.LoginRecord, error)
+	ListDevices(ctx context.Context, userId uint32) ([]domain.UserDevice, error)
+}
+
+type Risk interface {
+	Assess(ctx context.Context, user *domain.User, deviceId string) (*domain.RiskAssessment, error)
+	StartStepUp(ctx context.Context, challenge string, user *domain.User, remember bool, deviceId string) error
+	VerifyStepUp(ctx context.Context, challenge string, code string) (*domain.StepUp, error)
+	SendVerificationCode(ctx context.Context, user *domain.User) error
+	CheckVerificationCode(ctx context.Context, userId uint32, code string) error
+}
+
+type Identity interface {
+	Providers() []domain.IdentityProvider
+	StartLogin(ctx context.Context, providerId string, challenge string, remember bool, deviceId string) (string, string, error)
+	FinishLogin(ctx context.Context, state string, code string, upstreamErr string) (*UpstreamLoginResult, error)
+	StartLink(ctx context.Context, userId uint32, providerId string) (string, string, error)
+	GetLink(ctx context.Context, token string) (*domain.IdentityLink, error)
+	ConfirmLink(ctx context.Context, token string, userId uint32) (*domain.IdentityLink, error)
+	LoginMethods(ctx context.Context, userId uint32) (*domain.LoginMethods, error)
+	Unlink(ctx context.Context, userId uint32, identityId uint32) error
+	RemovePassword(ctx context.Context, userId uint32) error
+}
+
+type SCIM interface {
+	ListUsers(ctx context.Context, input *SCIMUserListInput) (*SCIMUserPage, error)
+	GetUser(ctx context.Context, id uint32) (*SCIMUser, error)
+	CreateUser(ctx context.Context, clientId string, input *SCIMUserInput) (*SCIMUser, error)
+	ReplaceUser(ctx context.Context, clientId string, id uint32, version uint32, input *SCIMUserInput) (*SCIMUser, error)
+	DeleteUser(ctx context.Context, clientId string, id uint32) error
+	ListGroups(ctx context.Context, input *SCIMGroupListInput) (*SCIMGroupPage, error)
+	GetGroup(ctx context.Context, id uint32) (*domain.Group, error)
+	CreateGroup(ctx context.Context, clientId string, input *SCIMGroupInput) (*domain.Group, error)
+	ReplaceGroup(ctx context.Context, clientId string, id uint32, version uint32, input *SCIMGroupInput) (*domain.Group, error)
+	DeleteGroup(ctx context.Context, clientId string, id uint32) error
+}
+
+// Groups manages the organizations' groups and their members.
+type Groups interface {
+	CreateOrganization(ctx context.Context, actorId uint32, name string) (*domain.Organization, error)
+	ListOrganizations(ctx context.Context) ([]domain.Organization, error)
+	CreateGroup(ctx context.Context, actorId uint32, organizationId uint32, displayName string) (*domain.Group, error)
+	ListGroups(ctx context.Context, organizationId uint32) ([]domain.Group, error)
+	GetGroup(ctx context.Context, actorId uint32, groupId uint32) (*domain.Group, error)
+	DeleteGroup(ctx context.Context, actorId uint32, groupId uint32) error
+	SetMember(ctx context.Context, actorId uint32, groupId uint32, userId uint32, role string) error
+	RemoveMember(ctx context.Context, actorId uint32, groupId uint32, userId uint32) error
+	ClaimGroups(ctx context.Context, userId uint32) ([]string, error)
+	ListUserGroups(ctx context.Context, userId uint32) ([]domain.Group, error)
+}
+
+// ServiceAccounts manages the non-human accounts and authenticates their API keys.
+type ServiceAccounts interface {
+	Create(ctx context.Context, actorId uint32, input *ServiceAccountInput) (*domain.ServiceAccount, string, error)
+	List(ctx context.Context) ([]domain.ServiceAccount, error)
+	Get(ctx context.Context, actorId uint32, id uint32) (*domain.ServiceAccount, error)
+	Update(ctx context.Context, actorId uint32, id uint32, input *ServiceAccountInput) (*domain.ServiceAccount, error)
+	Delete(ctx context.Context, actorId uint32, id uint32) error
+	CreateKey(ctx context.Context, actorId uint32, id uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error)
+	ListKeys(ctx context.Context, actorId uint32, id uint32) ([]domain.ServiceAccountKey, error)
+	RotateKey(ctx context.Context, actorId uint32, id uint32, keyId uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error)
+	RevokeKey(ctx context.Context, actorId uint32, id uint32, keyId uint32) error
+	Authenticate(ctx context.Context, apiKey string) (*domain.OA2TokenIntrospection, error)
+	ClientAccount(ctx context.Context, clientId string) (*domain.ServiceAccount, error)
+}
+
+// Tenants resolves the tenants of the requests and the Hydra clients.
+type Tenants interface {
+	Resolve(host string, tenantId string) (*domain.Tenant, bool)
+	Get(tenantId string) (*domain.Tenant, bool)
+	ClientTenant(clientId string) *domain.Tenant
+	List() []*domain.Tenant
+}
+
+type Activity interface {
+	Touch(ctx context.Context, userId uint32)
+	Flush(ctx context.Context) error
 }
 
 type Services struct {
-	Config *config.Config
-	OAuth2 OAuth2 // AuthZ
-	User   User
+	Config          *config.Config
+	OAuth2          OAuth2 // AuthZ
+	User            User
+	RBAC            RBAC
+	Sessions        Sessions
+	Flow            Flow            // Login, consent and logout flows.
+	Account         Account         // Account's security changes.
+	Audit           Audit           // Security audit log.
+	Admin           Admin           // Administrators' actions on the accounts.
+	Activity        Activity        // Users' last online dates.
+	Devices         Devices         // Users' devices and login history.
+	Risk            Risk            // Risk-based sign-in.
+	Identity        Identity        // Sign-in with the upstream identity providers.
+	SCIM            SCIM            // Users and groups provisioning by the HR system.
+	Tenants         Tenants         // Products served by the service.
+	Groups          Groups          // Organizations' groups and their members.
+	ServiceAccounts ServiceAccounts // Non-human accounts calling the API with the API keys.
 	// TODO: AuthN  *authn.AuthNHandler   // AuthN
 }
 
@@ -57,11 +357,37 @@ func NewService(
 	depends *Depends,
 	oa2 OAuth2,
 	userService User,
+	rbacService RBAC,
+	sessionService Sessions,
+	notifier Notifier,
+	auditService Audit,
+	activityService Activity,
+	deviceService Devices,
+	riskService Risk,
+	identityService Identity,
+	scimService SCIM,
+	tenantService Tenants,
+	groupService Groups,
+	serviceAccountService ServiceAccounts,
 ) *Services {
 	return &Services{
-		Config: config,
-		OAuth2: oa2,
-		User:   userService,
+		Config:          config,
+		OAuth2:          oa2,
+		User:            userService,
+		RBAC:            rbacService,
+		Sessions:        sessionService,
+		Flow:            NewFlowService(config, oa2, userService, rbacService, auditService, deviceService, riskService, identityService, tenantService, groupService),
+		Account:         NewAccountService(config, oa2, userService, rbacService, sessionService, notifier, auditService, deviceService, identityService, groupService, riskService),
+		Audit:           auditService,
+		Admin:           NewAdminService(oa2, userService, rbacService, sessionService, auditService),
+		Activity:        activityService,
+		Devices:         deviceService,
+		Risk:            riskService,
+		Identity:        identityService,
+		SCIM:            scimService,
+		Tenants:         tenantService,
+		Groups:          groupService,
+		ServiceAccounts: serviceAccountService,
 		// TODO: AuthN
 	}
 }
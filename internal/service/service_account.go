@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/pkg/convert_to"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// The longest service account's name and scope.
+	serviceAccountNameMaxLength  = 255
+	serviceAccountScopeMaxLength = 1024
+	// Length of the random part of the service accounts' client ids in bytes.
+	serviceAccountClientIdLength = 8
+	// Length of the API key's random part kept in its prefix.
+	serviceAccountKeyPrefixLength = 8
+)
+
+var (
+	ErrServiceAccountNotFound     = errors.New("Service account not found")
+	ErrServiceAccountAlreadyExist = errors.New("Service account already exist")
+	ErrServiceAccountNameInvalid  = errors.New("Service account name must be 1 to 255 characters long")
+	ErrServiceAccountScopeInvalid = errors.New("Service account scope must be 1 to 1024 characters long without quotes and backslashes")
+	// ErrServiceAccountAccessDenied the user neither administers the owner team nor manages the service accounts.
+	ErrServiceAccountAccessDenied = errors.New("Only the owner team's admins manage the service account's keys")
+	ErrServiceAccountKeyNotFound  = errors.New("API key not found")
+	ErrServiceAccountKeyExpiry    = errors.New("API key must expire in the future within the max lifetime")
+	// ErrServiceAccountKeyInvalid the API key is unknown, revoked or expired.
+	ErrServiceAccountKeyInvalid = errors.New("API key is not valid")
+)
+
+// ServiceAccountInput is the service account created or updated by the administrator.
+type ServiceAccountInput struct {
+	Name         string
+	Description  string
+	OwnerGroupId uint32
+	Scope        []string
+	// HydraClient creates the Hydra client of the account, it's ignored by the update.
+	HydraClient bool
+}
+
+// ServiceAccountService manages the non-human accounts and their API keys, every change is audited.
+// The users with the "service_accounts:manage" permission manage all the accounts,
+// the admins of the account's owner team manage its keys.
+type ServiceAccountService struct {
+	accounts ServiceAccountRepository
+	groups   GroupRepository
+	oa2      OAuth2
+	rbac     RBAC
+	audit    Audit
+	config   *config.ServiceAccountsConfig
+}
+
+func NewServiceAccountService(accounts ServiceAccountRepository, groups GroupRepository, oa2 OAuth2, rbacService RBAC, auditService Audit, config *config.ServiceAccountsConfig) *ServiceAccountService {
+	return &ServiceAccountService{
+		accounts: accounts,
+		groups:   groups,
+		oa2:      oa2,
+		rbac:     rbacService,
+		audit:    auditService,
+		config:   config,
+	}
+}
+
+// Create creates the service account of the context's tenant owned by the team.
+// The secret of the account's Hydra client is returned if it's created, the secret isn't stored.
+func (s *ServiceAccountService) Create(ctx context.Context, actorId uint32, input *ServiceAccountInput) (*domain.ServiceAccount, string, error) {
+	account := &domain.ServiceAccount{HydraClient: input.HydraClient, CreatedBy: &actorId}
+	if err := s.apply(ctx, account, input); err != nil {
+		return nil, "", err
+	}
+
+	clientId, err := newServiceAccountClientId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	account.ClientId = clientId
+	account.DateCreated = account.DateUpdated
+	if err = s.accounts.CreateServiceAccount(ctx, account); err != nil {
+		return nil, "", serviceAccountError(err)
+	}
+
+	var clientSecret string
+	if account.HydraClient {
+		clientSecret, err = s.oa2.CreateServiceClient(ctx, account.ClientId, account.Name, account.Scope)
+		if err != nil {
+			// The account without its Hydra client is dropped, so it can be created again.
+			if deleteErr := s.accounts.DeleteServiceAccount(ctx, account.Id); deleteErr != nil {
+				return nil, "", deleteErr
+			}
+
+			return nil, "", err
+		}
+	}
+
+	event := s.accountEvent(domain.AuditActionServiceAccountCreated, actorId, account.Id)
+	event.Data["name"] = account.Name
+	event.Data["scope"] = account.Scope
+	if err = recordAudit(ctx, s.audit, event); err != nil {
+		return nil, "", err
+	}
+
+	return account, clientSecret, nil
+}
+
+func (s *ServiceAccountService) List(ctx context.Context) ([]domain.ServiceAccount, error) {
+	return s.accounts.ListServiceAccounts(ctx)
+}
+
+// Get returns the service account to the owner team's admins and the service accounts' managers.
+func (s *ServiceAccountService) Get(ctx context.Context, actorId uint32, id uint32) (*domain.ServiceAccount, error) {
+	return s.authorize(ctx, actorId, id)
+}
+
+// Update changes the account's name, description, owner team and scope, the Hydra client's ones too.
+// The scope of the issued Hydra tokens is kept until they expire.
+func (s *ServiceAccountService) Update(ctx context.Context, actorId uint32, id uint32, input *ServiceAccountInput) (*domain.ServiceAccount, error) {
+	account, err := s.getAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.apply(ctx, account, input); err != nil {
+		return nil, err
+	}
+
+	if err = s.accounts.UpdateServiceAccount(ctx, account); err != nil {
+		return nil, serviceAccountError(err)
+	}
+
+	if account.HydraClient {
+		if err = s.oa2.UpdateServiceClient(ctx, account.ClientId, account.Name, account.Scope); err != nil {
+			return nil, err
+		}
+	}
+
+	event := s.accountEvent(domain.AuditActionServiceAccountUpdated, actorId, account.Id)
+	event.Data["name"] = account.Name
+	event.Data["scope"] = account.Scope
+	if err = recordAudit(ctx, s.audit, event); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Delete deletes the service account with its keys and its Hydra client.
+func (s *ServiceAccountService) Delete(ctx context.Context, actorId uint32, id uint32) error {
+	account, err := s.getAccount(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if account.HydraClient {
+		if err = s.oa2.DeleteServiceClient(ctx, account.ClientId); err != nil {
+			return err
+		}
+	}
+
+	if err = s.accounts.DeleteServiceAccount(ctx, id); err != nil {
+		return serviceAccountError(err)
+	}
+
+	return recordAudit(ctx, s.audit, s.accountEvent(domain.AuditActionServiceAccountDeleted, actorId, id))
+}
+
+// CreateKey issues the account's API key expiring at dateExpires, or after the max lifetime if it's nil.
+// The key is returned only once, only its hash is stored.
+func (s *ServiceAccountService) CreateKey(ctx context.Context, actorId uint32, id uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error) {
+	if _, err := s.authorize(ctx, actorId, id); err != nil {
+		return nil, "", err
+	}
+
+	key, apiKey, err := s.newKey(id, dateExpires)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = s.accounts.CreateServiceAccountKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	event := s.accountEvent(domain.AuditActionServiceAccountKeyCreated, actorId, id)
+	event.Data["key_id"] = convert_to.ToString(key.Id)
+	if err = recordAudit(ctx, s.audit, event); err != nil {
+		return nil, "", err
+	}
+
+	return key, apiKey, nil
+}
+
+// ListKeys returns the account's keys, the revoked and expired ones too.
+func (s *ServiceAccountService) ListKeys(ctx context.Context, actorId uint32, id uint32) ([]domain.ServiceAccountKey, error) {
+	if _, err := s.authorize(ctx, actorId, id); err != nil {
+		return nil, err
+	}
+
+	return s.accounts.ListServiceAccountKeys(ctx, id)
+}
+
+// RotateKey issues the new key replacing the account's active key, the replaced key stays valid
+// for the rotation grace period, so the services switch to the new one.
+func (s *ServiceAccountService) RotateKey(ctx context.Context, actorId uint32, id uint32, keyId uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error) {
+	if _, err := s.authorize(ctx, actorId, id); err != nil {
+		return nil, "", err
+	}
+
+	key, apiKey, err := s.newKey(id, dateExpires)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = s.accounts.RotateServiceAccountKey(ctx, keyId, key, key.DateCreated.Add(s.config.KeyRotationGrace)); err != nil {
+		return nil, "", keyError(err)
+	}
+
+	event := s.accountEvent(domain.AuditActionServiceAccountKeyRotated, actorId, id)
+	event.Data["key_id"] = convert_to.ToString(key.Id)
+	event.Data["rotated_key_id"] = convert_to.ToString(keyId)
+	if err = recordAudit(ctx, s.audit, event); err != nil {
+		return nil, "", err
+	}
+
+	return key, apiKey, nil
+}
+
+// RevokeKey revokes the account's key at once.
+func (s *ServiceAccountService) RevokeKey(ctx context.Context, actorId uint32, id uint32, keyId uint32) error {
+	if _, err := s.authorize(ctx, actorId, id); err != nil {
+		return err
+	}
+
+	if err := s.accounts.RevokeServiceAccountKey(ctx, id, keyId, time.Now()); err != nil {
+		return keyError(err)
+	}
+
+	event := s.accountEvent(domain.AuditActionServiceAccountKeyRevoked, actorId, id)
+	event.Data["key_id"] = convert_to.ToString(keyId)
+
+	return recordAudit(ctx, s.audit, event)
+}
+
+// Authenticate returns the introspection of the API key of the context's tenant, its subject and client id
+// are the account's client id. The key's last used date is updated at most once per the throttle.
+func (s *ServiceAccountService) Authenticate(ctx context.Context, apiKey string) (*domain.OA2TokenIntrospection, error) {
+	key, err := s.accounts.GetServiceAccountKey(ctx, hashLinkToken(apiKey))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrServiceAccountKeyInvalid
+		}
+
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrServiceAccountKeyInvalid
+	}
+
+	account, err := s.accounts.GetServiceAccount(ctx, key.ServiceAccountId)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrServiceAccountKeyInvalid
+		}
+
+		return nil, err
+	}
+
+	if key.DateLastUsed == nil || now.Sub(*key.DateLastUsed) >= s.config.KeyLastUsedThrottle {
+		if err = s.accounts.TouchServiceAccountKey(ctx, key.Id, now); err != nil {
+			return nil, err
+		}
+	}
+
+	tokenIntrospection := &domain.OA2TokenIntrospection{
+		Active:   true,
+		Sub:      &account.ClientId,
+		Scope:    account.Scope,
+		ClientId: &account.ClientId,
+	}
+	if key.DateExpires != nil {
+		exp := key.DateExpires.Unix()
+		tokenIntrospection.Exp = &exp
+	}
+
+	return tokenIntrospection, nil
+}
+
+// ClientAccount returns the service account of the context's tenant by its Hydra client's id,
+// it's ErrServiceAccountNotFound if the client isn't the tenant's service account.
+func (s *ServiceAccountService) ClientAccount(ctx context.Context, clientId string) (*domain.ServiceAccount, error) {
+	account, err := s.accounts.GetServiceAccountByClientId(ctx, clientId)
+	if err != nil {
+		return nil, serviceAccountError(err)
+	}
+
+	return account, nil
+}
+
+// apply validates the input and sets it to the account.
+func (s *ServiceAccountService) apply(ctx context.Context, account *domain.ServiceAccount, input *ServiceAccountInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > serviceAccountNameMaxLength {
+		return ErrServiceAccountNameInvalid
+	}
+
+	scope, ok := serviceAccountScope(input.Scope)
+	if !ok {
+		return ErrServiceAccountScopeInvalid
+	}
+
+	if _, err := s.groups.GetGroup(ctx, input.OwnerGroupId); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrGroupNotFound
+		}
+
+		return err
+	}
+
+	account.Name = name
+	account.Description = strings.TrimSpace(input.Description)
+	account.OwnerGroupId = &input.OwnerGroupId
+	account.Scope = scope
+	account.DateUpdated = time.Now()
+
+	return nil
+}
+
+// authorize returns the service account if the actor is the owner team's admin or manages the service accounts.
+func (s *ServiceAccountService) authorize(ctx context.Context, actorId uint32, id uint32) (*domain.ServiceAccount, error) {
+	account, err := s.getAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.OwnerGroupId != nil {
+		group, err := s.groups.GetGroup(ctx, *account.OwnerGroupId)
+		if err != nil && !errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		if group != nil {
+			for _, member := range group.Members {
+				if member.UserId == actorId && member.Role == domain.GroupRoleAdmin {
+					return account, nil
+				}
+			}
+		}
+	}
+
+	allowed, err := s.rbac.Can(ctx, actorId, domain.PermissionServiceAccountsManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		return nil, ErrServiceAccountAccessDenied
+	}
+
+	return account, nil
+}
+
+func (s *ServiceAccountService) getAccount(ctx context.Context, id uint32) (*domain.ServiceAccount, error) {
+	account, err := s.accounts.GetServiceAccount(ctx, id)
+	if err != nil {
+		return nil, serviceAccountError(err)
+	}
+
+	return account, nil
+}
+
+// newKey returns the account's new key and the API key of its hash, the key expires after the max lifetime at the latest.
+func (s *ServiceAccountService) newKey(id uint32, dateExpires *time.Time) (*domain.ServiceAccountKey, string, error) {
+	now := time.Now()
+	if dateExpires == nil && s.config.KeyMaxLifetime > 0 {
+		maxExpires := now.Add(s.config.KeyMaxLifetime)
+		dateExpires = &maxExpires
+	}
+
+	if dateExpires != nil && (!dateExpires.After(now) || (s.config.KeyMaxLifetime > 0 && dateExpires.After(now.Add(s.config.KeyMaxLifetime)))) {
+		return nil, "", ErrServiceAccountKeyExpiry
+	}
+
+	token, _, err := newLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := domain.ServiceAccountKeyPrefix + token
+
+	return &domain.ServiceAccountKey{
+		ServiceAccountId: id,
+		Prefix:           apiKey[:len(domain.ServiceAccountKeyPrefix)+serviceAccountKeyPrefixLength],
+		KeyHash:          hashLinkToken(apiKey),
+		DateCreated:      now,
+		DateExpires:      dateExpires,
+	}, apiKey, nil
+}
+
+func (s *ServiceAccountService) accountEvent(action string, actorId uint32, id uint32) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		Action:  action,
+		ActorId: &actorId,
+		Data:    map[string]string{"service_account_id": convert_to.ToString(id)},
+	}
+}
+
+// newServiceAccountClientId returns the random client id of the service account.
+func newServiceAccountClientId() (string, error) {
+	rawId := make([]byte, serviceAccountClientIdLength)
+	if _, err := rand.Read(rawId); err != nil {
+		return "", err
+	}
+
+	return domain.ServiceAccountClientPrefix + hex.EncodeToString(rawId), nil
+}
+
+// serviceAccountScope returns the space-separated scope, the scopes are the RFC 6749 scope tokens.
+func serviceAccountScope(scopes []string) (string, bool) {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, "\"\\") {
+			return "", false
+		}
+
+		for _, r := range scope {
+			if r <= ' ' || r > '~' {
+				return "", false
+			}
+		}
+
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	scope := strings.Join(unique, " ")
+	if scope == "" || len(scope) > serviceAccountScopeMaxLength {
+		return "", false
+	}
+
+	return scope, true
+}
+
+// serviceAccountError maps the missing account or owner team and the taken name.
+func serviceAccountError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		return ErrServiceAccountNotFound
+	case errors.Is(err, repository.ErrRecordAlreadyExist):
+		return ErrServiceAccountAlreadyExist
+	}
+
+	return err
+}
+
+// keyError maps the missing, revoked or expired key.
+func keyError(err error) error {
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return ErrServiceAccountKeyNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,326 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TestTableServiceAccount struct {
+	name                           string
+	actorId                        uint32
+	input                          *service.ServiceAccountInput
+	mockBehaviorServiceAccountRepo func(mockRepo *mock_service.MockServiceAccountRepository)
+	mockBehaviorGroupRepo          func(mockGroupRepo *mock_service.MockGroupRepository)
+	mockBehaviorOAuth2             func(mockOAuth2 *mock_service.MockOAuth2)
+	mockBehaviorRBAC               func(mockRBAC *mock_service.MockRBAC)
+	mockBehaviorAudit              func(mockAudit *mock_service.MockAudit)
+	expectedErr                    error
+}
+
+var serviceAccountsConfig = &config.ServiceAccountsConfig{
+	KeyMaxLifetime:      365 * 24 * time.Hour,
+	KeyRotationGrace:    24 * time.Hour,
+	KeyLastUsedThrottle: 5 * time.Minute,
+}
+
+func newServiceAccountService(ctrl *gomock.Controller, testCase *TestTableServiceAccount) *service.ServiceAccountService {
+	mockRepo := mock_service.NewMockServiceAccountRepository(ctrl)
+	testCase.mockBehaviorServiceAccountRepo(mockRepo)
+	mockGroupRepo := mock_service.NewMockGroupRepository(ctrl)
+	testCase.mockBehaviorGroupRepo(mockGroupRepo)
+	mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+	testCase.mockBehaviorOAuth2(mockOAuth2)
+	mockRBAC := mock_service.NewMockRBAC(ctrl)
+	testCase.mockBehaviorRBAC(mockRBAC)
+	mockAudit := mock_service.NewMockAudit(ctrl)
+	testCase.mockBehaviorAudit(mockAudit)
+
+	return service.NewServiceAccountService(mockRepo, mockGroupRepo, mockOAuth2, mockRBAC, mockAudit, serviceAccountsConfig)
+}
+
+// billingAccount is the service account 3 owned by the team 7, see teamGroup.
+func billingAccount() *domain.ServiceAccount {
+	groupId := uint32(7)
+	return &domain.ServiceAccount{Id: 3, ClientId: "sa-1f2e3d4c5b6a7980", Name: "Billing", OwnerGroupId: &groupId, Scope: "scim"}
+}
+
+func TestServiceAccountService_Create(t *testing.T) {
+	testTable := []TestTableServiceAccount{
+		{
+			name:    "OK, account with Hydra client",
+			actorId: 1,
+			input:   &service.ServiceAccountInput{Name: " Billing ", OwnerGroupId: 7, Scope: []string{"scim", "users:read", "scim"}, HydraClient: true},
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().CreateServiceAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, account *domain.ServiceAccount) error {
+					assert.Equal(t, account.Name, "Billing")
+					assert.Equal(t, account.Scope, "scim users:read")
+					assert.Equal(t, *account.OwnerGroupId, uint32(7))
+					assert.Equal(t, strings.HasPrefix(account.ClientId, domain.ServiceAccountClientPrefix), true)
+					account.Id = 3
+					return nil
+				})
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().CreateServiceClient(gomock.Any(), gomock.Any(), "Billing", "scim users:read").Return("secret", nil)
+			},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "BAD, Hydra client not created",
+			actorId: 1,
+			input:   &service.ServiceAccountInput{Name: "Billing", OwnerGroupId: 7, Scope: []string{"scim"}, HydraClient: true},
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().CreateServiceAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, account *domain.ServiceAccount) error {
+					account.Id = 3
+					return nil
+				})
+				mockRepo.EXPECT().DeleteServiceAccount(gomock.Any(), uint32(3)).Return(nil)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {
+				mockOAuth2.EXPECT().CreateServiceClient(gomock.Any(), gomock.Any(), "Billing", "scim").Return("", errTest)
+			},
+			mockBehaviorRBAC:  func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       errTest,
+		},
+		{
+			name:                           "BAD, scope is invalid",
+			actorId:                        1,
+			input:                          &service.ServiceAccountInput{Name: "Billing", OwnerGroupId: 7, Scope: []string{"users read"}},
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {},
+			mockBehaviorGroupRepo:          func(mockGroupRepo *mock_service.MockGroupRepository) {},
+			mockBehaviorOAuth2:             func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC:               func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit:              func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:                    service.ErrServiceAccountScopeInvalid,
+		},
+		{
+			name:                           "BAD, owner team not found",
+			actorId:                        1,
+			input:                          &service.ServiceAccountInput{Name: "Billing", OwnerGroupId: 8, Scope: []string{"scim"}},
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(8)).Return(nil, repository.ErrRecordNotFound)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC:   func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit:  func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:        service.ErrGroupNotFound,
+		},
+		{
+			name:    "BAD, name is taken",
+			actorId: 1,
+			input:   &service.ServiceAccountInput{Name: "Billing", OwnerGroupId: 7, Scope: []string{"scim"}},
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().CreateServiceAccount(gomock.Any(), gomock.Any()).Return(repository.ErrRecordAlreadyExist)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC:   func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit:  func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:        service.ErrServiceAccountAlreadyExist,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			serviceAccountService := newServiceAccountService(ctrl, &testCase)
+
+			//// Act
+			_, clientSecret, err := serviceAccountService.Create(context.Background(), testCase.actorId, testCase.input)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedErr == nil && testCase.input.HydraClient {
+				assert.Equal(t, clientSecret, "secret")
+			}
+		})
+	}
+}
+
+func TestServiceAccountService_RotateKey(t *testing.T) {
+	testTable := []TestTableServiceAccount{
+		{
+			name:    "OK, owner team's admin rotates key",
+			actorId: 1,
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().GetServiceAccount(gomock.Any(), uint32(3)).Return(billingAccount(), nil)
+				mockRepo.EXPECT().RotateServiceAccountKey(gomock.Any(), uint32(5), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, keyId uint32, key *domain.ServiceAccountKey, dateExpires time.Time) error {
+						assert.Equal(t, key.ServiceAccountId, uint32(3))
+						assert.Equal(t, dateExpires, key.DateCreated.Add(serviceAccountsConfig.KeyRotationGrace))
+						assert.Equal(t, *key.DateExpires, key.DateCreated.Add(serviceAccountsConfig.KeyMaxLifetime))
+						key.Id = 6
+						return nil
+					})
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC:   func(mockRBAC *mock_service.MockRBAC) {},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {
+				actorId := uint32(1)
+				mockAudit.EXPECT().Record(gomock.Any(), &domain.AuditEvent{
+					Action:  domain.AuditActionServiceAccountKeyRotated,
+					ActorId: &actorId,
+					Data:    map[string]string{"service_account_id": "3", "key_id": "6", "rotated_key_id": "5"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:    "BAD, owner team's member doesn't rotate key",
+			actorId: 2,
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().GetServiceAccount(gomock.Any(), uint32(3)).Return(billingAccount(), nil)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {
+				mockRBAC.EXPECT().Can(gomock.Any(), uint32(2), domain.PermissionServiceAccountsManage, "").Return(false, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrServiceAccountAccessDenied,
+		},
+		{
+			name:    "BAD, revoked key isn't rotated",
+			actorId: 9,
+			mockBehaviorServiceAccountRepo: func(mockRepo *mock_service.MockServiceAccountRepository) {
+				mockRepo.EXPECT().GetServiceAccount(gomock.Any(), uint32(3)).Return(billingAccount(), nil)
+				mockRepo.EXPECT().RotateServiceAccountKey(gomock.Any(), uint32(5), gomock.Any(), gomock.Any()).Return(repository.ErrRecordNotFound)
+			},
+			mockBehaviorGroupRepo: func(mockGroupRepo *mock_service.MockGroupRepository) {
+				mockGroupRepo.EXPECT().GetGroup(gomock.Any(), uint32(7)).Return(teamGroup(), nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth2 *mock_service.MockOAuth2) {},
+			mockBehaviorRBAC: func(mockRBAC *mock_service.MockRBAC) {
+				mockRBAC.EXPECT().Can(gomock.Any(), uint32(9), domain.PermissionServiceAccountsManage, "").Return(true, nil)
+			},
+			mockBehaviorAudit: func(mockAudit *mock_service.MockAudit) {},
+			expectedErr:       service.ErrServiceAccountKeyNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			serviceAccountService := newServiceAccountService(ctrl, &testCase)
+
+			//// Act
+			key, apiKey, err := serviceAccountService.RotateKey(context.Background(), testCase.actorId, 3, 5, nil)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedErr == nil {
+				assert.Equal(t, strings.HasPrefix(apiKey, domain.ServiceAccountKeyPrefix), true)
+				assert.Equal(t, strings.HasPrefix(apiKey, key.Prefix), true)
+				assert.NotEqual(t, key.KeyHash, "")
+			}
+		})
+	}
+}
+
+func TestServiceAccountService_Authenticate(t *testing.T) {
+	now := time.Now()
+	recently := now.Add(-time.Minute)
+	expired := now.Add(-time.Hour)
+
+	testTable := []struct {
+		name          string
+		key           *domain.ServiceAccountKey
+		keyErr        error
+		expectedTouch bool
+		expectedErr   error
+	}{
+		{
+			name:          "OK, key's last used date updated",
+			key:           &domain.ServiceAccountKey{Id: 5, ServiceAccountId: 3},
+			expectedTouch: true,
+		},
+		{
+			name: "OK, recently used key",
+			key:  &domain.ServiceAccountKey{Id: 5, ServiceAccountId: 3, DateLastUsed: &recently},
+		},
+		{
+			name:        "BAD, key is revoked",
+			key:         &domain.ServiceAccountKey{Id: 5, ServiceAccountId: 3, DateRevoked: &recently},
+			expectedErr: service.ErrServiceAccountKeyInvalid,
+		},
+		{
+			name:        "BAD, key is expired",
+			key:         &domain.ServiceAccountKey{Id: 5, ServiceAccountId: 3, DateExpires: &expired},
+			expectedErr: service.ErrServiceAccountKeyInvalid,
+		},
+		{
+			name:        "BAD, key of another tenant",
+			keyErr:      repository.ErrRecordNotFound,
+			expectedErr: service.ErrServiceAccountKeyInvalid,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			apiKey := "sa_live_key"
+			mockRepo := mock_service.NewMockServiceAccountRepository(ctrl)
+			mockRepo.EXPECT().GetServiceAccountKey(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, keyHash string) (*domain.ServiceAccountKey, error) {
+				assert.NotEqual(t, keyHash, apiKey)
+				return testCase.key, testCase.keyErr
+			})
+			if testCase.expectedErr == nil {
+				mockRepo.EXPECT().GetServiceAccount(gomock.Any(), uint32(3)).Return(billingAccount(), nil)
+			}
+			if testCase.expectedTouch {
+				mockRepo.EXPECT().TouchServiceAccountKey(gomock.Any(), uint32(5), gomock.Any()).Return(nil)
+			}
+			serviceAccountService := service.NewServiceAccountService(mockRepo, nil, nil, nil, nil, serviceAccountsConfig)
+
+			//// Act
+			tokenIntrospection, err := serviceAccountService.Authenticate(context.Background(), apiKey)
+
+			//// Assert
+			assert.Equal(t, err, testCase.expectedErr)
+			if testCase.expectedErr == nil {
+				assert.Equal(t, tokenIntrospection.Active, true)
+				assert.Equal(t, *tokenIntrospection.Sub, "sa-1f2e3d4c5b6a7980")
+				assert.Equal(t, *tokenIntrospection.ClientId, "sa-1f2e3d4c5b6a7980")
+				assert.Equal(t, tokenIntrospection.HasScopes(domain.ScopeSCIM), true)
+			}
+		})
+	}
+}
+
+var errTest = errors.New("Test error")
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"time"
+)
+
+const (
+	// Length of the random session ID in bytes.
+	sessionIdLength = 32
+	// The last seen date is updated no more often, so every request doesn't write to the store.
+	sessionTouchInterval = time.Minute
+)
+
+var (
+	ErrSessionNotFound       = errors.New("Session not found")
+	ErrSessionEncryptionKey  = errors.New("Session encryption key must be the base64 encoded 32 bytes")
+	ErrSessionDataCorrupted  = errors.New("Session data is corrupted")
+	ErrSessionAlreadyExpired = errors.New("Session is already expired")
+)
+
+type SessionService struct {
+	repo            SessionRepository
+	aead            cipher.AEAD // nil if the encryption at rest is disabled.
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+func NewSessionService(sessionRepo SessionRepository, config *config.SessionConfig) (*SessionService, error) {
+	s := &SessionService{
+		repo:            sessionRepo,
+		idleTimeout:     config.IdleTimeout,
+		absoluteTimeout: config.AbsoluteTimeout,
+	}
+
+	if config.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(config.EncryptionKey)
+		if err != nil || len(key) != 32 {
+			return nil, ErrSessionEncryptionKey
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Create starts the session keeping the tokens, returns the session ID for the cookie.
+func (s *SessionService) Create(ctx context.Context, token *domain.Token) (string, error) {
+	rawId := make([]byte, sessionIdLength)
+	if _, err := rand.Read(rawId); err != nil {
+		return "", err
+	}
+
+	data, err := s.seal(token)
+	if err != nil {
+		return "", err
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(rawId)
+	now := time.Now()
+	session := &domain.Session{
+		Id:           hashSessionId(id),
+		Sid:          token.Sid,
+		Subject:      token.Subject,
+		Data:         data,
+		DateCreated:  now,
+		DateLastSeen: now,
+	}
+	session.DateExpires = s.expiry(session)
+
+	if err = s.repo.Create(ctx, session); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Get returns the session and its tokens, the expired sessions aren't found.
+func (s *SessionService) Get(ctx context.Context, id string) (*domain.Session, *domain.Token, error) {
+	session, err := s.repo.Get(ctx, hashSessionId(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, nil, ErrSessionNotFound
+		}
+
+		return nil, nil, err
+	}
+
+	token, err := s.open(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if time.Since(session.DateLastSeen) > sessionTouchInterval {
+		// Prolong the idle timeout.
+		session.DateLastSeen = time.Now()
+		session.DateExpires = s.expiry(session)
+		if err = s.update(ctx, session); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return session, token, nil
+}
+
+// UpdateTokens replaces the tokens of the session, e.g. after the refresh.
+func (s *SessionService) UpdateTokens(ctx context.Context, id string, token *domain.Token) error {
+	session, err := s.repo.Get(ctx, hashSessionId(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+
+		return err
+	}
+
+	if session.Data, err = s.seal(token); err != nil {
+		return err
+	}
+
+	if token.Sid != "" {
+		session.Sid = token.Sid
+	}
+
+	session.DateLastSeen = time.Now()
+	session.DateExpires = s.expiry(session)
+
+	return s.update(ctx, session)
+}
+
+func (s *SessionService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, hashSessionId(id))
+}
+
+// DeleteBySid ends all the sessions of the Hydra login session.
+func (s *SessionService) DeleteBySid(ctx context.Context, sid string) error {
+	return s.repo.DeleteBySid(ctx, sid)
+}
+
+// ListBySubject returns the subject's active sessions.
+func (s *SessionService) ListBySubject(ctx context.Context, subject string) ([]domain.Session, error) {
+	return s.repo.ListBySubject(ctx, subject)
+}
+
+// DeleteBySubject ends all the subject's sessions except the session exceptId, e.g. the current one.
+func (s *SessionService) DeleteBySubject(ctx context.Context, subject string, exceptId string) error {
+	exceptHash := ""
+	if exceptId != "" {
+		exceptHash = hashSessionId(exceptId)
+	}
+
+	return s.repo.DeleteBySubject(ctx, subject, exceptHash)
+}
+
+func (s *SessionService) DeleteExpired(ctx context.Context) error {
+	return s.repo.DeleteExpired(ctx)
+}
+
+func (s *SessionService) update(ctx context.Context, session *domain.Session) error {
+	if !session.DateExpires.After(time.Now()) {
+		return ErrSessionAlreadyExpired
+	}
+
+	if err := s.repo.Update(ctx, session); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// expiry is the nearest of the idle and absolute timeouts.
+func (s *SessionService) expiry(session *domain.Session) time.Time {
+	idleExpiry := session.DateLastSeen.Add(s.idleTimeout)
+	absoluteExpiry := session.DateCreated.Add(s.absoluteTimeout)
+	if idleExpiry.Before(absoluteExpiry) {
+		return idleExpiry
+	}
+
+	return absoluteExpiry
+}
+
+func (s *SessionService) seal(token *domain.Token) ([]byte, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.aead == nil {
+		return data, nil
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return s.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (s *SessionService) open(session *domain.Session) (*domain.Token, error) {
+	data := session.Data
+	if s.aead != nil {
+		nonceSize := s.aead.NonceSize()
+		if len(data) < nonceSize {
+			return nil, ErrSessionDataCorrupted
+		}
+
+		var err error
+		if data, err = s.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil); err != nil {
+			return nil, ErrSessionDataCorrupted
+		}
+	}
+
+	token := new(domain.Token)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, ErrSessionDataCorrupted
+	}
+
+	token.Subject = session.Subject
+	token.Sid = session.Sid
+
+	return token, nil
+}
+
+// hashSessionId hashes the session ID, so the store keeps no usable session IDs.
+func hashSessionId(id string) string {
+	hash := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/repository"
+	"service-account/internal/service"
+	"testing"
+	"time"
+)
+
+type TestTableSession struct {
+	name          string
+	encryptionKey string
+	expectedErr   error
+}
+
+func TestSessionService(t *testing.T) {
+	testTable := []TestTableSession{
+		{
+			name: "OK, not encrypted",
+		},
+		{
+			name:          "OK, encrypted",
+			encryptionKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		},
+		{
+			name:          "BAD, encryption key is too short",
+			encryptionKey: "MDEyMzQ1Njc4OWFiY2RlZg==",
+			expectedErr:   service.ErrSessionEncryptionKey,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Arrange
+			ctx := context.Background()
+			repo := repository.NewSessionsRepoMemory()
+			sessions, err := service.NewSessionService(repo, &config.SessionConfig{
+				EncryptionKey:   testCase.encryptionKey,
+				IdleTimeout:     30 * time.Minute,
+				AbsoluteTimeout: 24 * time.Hour,
+			})
+			assert.Equal(t, err, testCase.expectedErr)
+			if err != nil {
+				return
+			}
+
+			token := &domain.Token{
+				AccessToken:  "access",
+				RefreshToken: "refresh",
+				IdToken:      "id",
+				Subject:      "1",
+				Sid:          "sid",
+			}
+
+			//// Act
+			sessionId, err := sessions.Create(ctx, token)
+			assert.Equal(t, err, nil)
+			session, gotToken, err := sessions.Get(ctx, sessionId)
+
+			//// Assert
+			assert.Equal(t, err, nil)
+			assert.Equal(t, gotToken, token)
+			assert.Equal(t, session.Sid, "sid")
+			assert.NotEqual(t, session.Id, sessionId)
+			assert.Equal(t, bytes.Contains(session.Data, []byte("refresh")), testCase.encryptionKey == "")
+			assert.Equal(t, session.DateExpires, session.DateLastSeen.Add(30*time.Minute))
+
+			// Ending the other sessions of the subject keeps the current one.
+			assert.Equal(t, sessions.DeleteBySubject(ctx, "1", sessionId), nil)
+			_, _, err = sessions.Get(ctx, sessionId)
+			assert.Equal(t, err, nil)
+
+			// The back-channel logout ends the session.
+			assert.Equal(t, sessions.DeleteBySid(ctx, "sid"), nil)
+			_, _, err = sessions.Get(ctx, sessionId)
+			assert.Equal(t, err, service.ErrSessionNotFound)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"net"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"strings"
+)
+
+// TenantService resolves the tenants of the requests by the host or the path and the tenants of the Hydra clients.
+// The tenants are configured, the requests and clients of no tenant are the default tenant's ones.
+type TenantService struct {
+	tenants  []*domain.Tenant
+	byId     map[string]*domain.Tenant
+	byHost   map[string]*domain.Tenant
+	byClient map[string]*domain.Tenant
+}
+
+func NewTenantService(config *config.Config) *TenantService {
+	s := &TenantService{
+		byId:     make(map[string]*domain.Tenant),
+		byHost:   make(map[string]*domain.Tenant),
+		byClient: make(map[string]*domain.Tenant),
+	}
+
+	s.add(&domain.Tenant{
+		Id:                domain.DefaultTenantId,
+		Name:              config.Branding.ProductName,
+		URL:               config.OAuth2.RedirectURL,
+		Branding:          branding(&config.Branding, config.Branding.ProductName),
+		PasswordMinLength: config.Password.MinLength,
+		RolesClaim:        config.OAuth2.RolesClaim,
+	}, nil, nil)
+
+	for i := range config.Tenants {
+		tenantConfig := &config.Tenants[i]
+		tenant := &domain.Tenant{
+			Id:                tenantConfig.Id,
+			Name:              tenantConfig.Name,
+			URL:               tenantConfig.URL,
+			Branding:          branding(&tenantConfig.Branding, tenantConfig.Name),
+			PasswordMinLength: config.Password.MinLength,
+			RolesClaim:        config.OAuth2.RolesClaim,
+		}
+		if tenantConfig.PasswordMinLength > 0 {
+			tenant.PasswordMinLength = tenantConfig.PasswordMinLength
+		}
+		if tenantConfig.RolesClaim != nil {
+			tenant.RolesClaim = *tenantConfig.RolesClaim
+		}
+
+		s.add(tenant, tenantConfig.Hosts, tenantConfig.Clients)
+	}
+
+	return s
+}
+
+func (s *TenantService) add(tenant *domain.Tenant, hosts []string, clients []string) {
+	s.tenants = append(s.tenants, tenant)
+	s.byId[tenant.Id] = tenant
+	for _, host := range hosts {
+		s.byHost[strings.ToLower(host)] = tenant
+	}
+	for _, client := range clients {
+		s.byClient[client] = tenant
+	}
+}
+
+// Resolve returns the tenant of the request, the tenant's id of the "/t/<id>" path prefix takes precedence over the host.
+// It's false if the path's tenant is unknown.
+func (s *TenantService) Resolve(host string, tenantId string) (*domain.Tenant, bool) {
+	if tenantId != "" {
+		return s.Get(tenantId)
+	}
+
+	host = strings.ToLower(host)
+	if tenant, ok := s.byHost[host]; ok {
+		return tenant, true
+	}
+
+	// The host is configured without the port.
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if tenant, ok := s.byHost[hostname]; ok {
+			return tenant, true
+		}
+	}
+
+	return s.byId[domain.DefaultTenantId], true
+}
+
+// Get returns the tenant by its id, it's false if the tenant is unknown.
+func (s *TenantService) Get(tenantId string) (*domain.Tenant, bool) {
+	tenant, ok := s.byId[tenantId]
+	return tenant, ok
+}
+
+// ClientTenant returns the tenant of the Hydra client, the clients of no tenant are the default tenant's ones.
+func (s *TenantService) ClientTenant(clientId string) *domain.Tenant {
+	if tenant, ok := s.byClient[clientId]; ok {
+		return tenant
+	}
+
+	return s.byId[domain.DefaultTenantId]
+}
+
+// List returns the tenants, the default one first.
+func (s *TenantService) List() []*domain.Tenant {
+	return s.tenants
+}
+
+// branding returns the tenant's look, the product is named after the tenant unless it's set.
+func branding(config *config.BrandingConfig, name string) domain.Branding {
+	productName := config.ProductName
+	if productName == "" {
+		productName = name
+	}
+
+	return domain.Branding{
+		ProductName:  productName,
+		LogoURL:      config.LogoURL,
+		PrimaryColor: config.PrimaryColor,
+	}
+}
+
+// passwordPolicy returns the password policy of the context's tenant.
+func passwordPolicy(ctx context.Context, policy *config.PasswordConfig) *config.PasswordConfig {
+	if tenant := domain.TenantFromContext(ctx); tenant != nil && tenant.PasswordMinLength > 0 {
+		return &config.PasswordConfig{MinLength: tenant.PasswordMinLength}
+	}
+
+	return policy
+}
+
+// rolesClaim reports whether the tokens of the context's tenant have the roles claim.
+func rolesClaim(ctx context.Context, config *config.OAuth2Config) bool {
+	if tenant := domain.TenantFromContext(ctx); tenant != nil {
+		return tenant.RolesClaim
+	}
+
+	return config.RolesClaim
+}
+
+// tenantURL returns the URL of the account pages of the context's tenant.
+func tenantURL(ctx context.Context, config *config.OAuth2Config) string {
+	if tenant := domain.TenantFromContext(ctx); tenant != nil && tenant.URL != "" {
+		return tenant.URL
+	}
+
+	return config.RedirectURL
+}
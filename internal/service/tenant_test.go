@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"github.com/go-playground/assert/v2"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"testing"
+)
+
+func TestTenantService_Resolve(t *testing.T) {
+	tenants := service.NewTenantService(&config.Config{
+		OAuth2: config.OAuth2Config{RedirectURL: "https://account.localhost"},
+		Tenants: []config.TenantConfig{{
+			Id:      "acme",
+			Name:    "Acme",
+			URL:     "https://account.acme.com",
+			Hosts:   []string{"account.acme.com"},
+			Clients: []string{"acme-web"},
+		}},
+	})
+
+	testTable := []struct {
+		name             string
+		host             string
+		tenantId         string
+		expectedTenantId string
+		expectedOk       bool
+	}{
+		{
+			name:             "OK, default tenant",
+			host:             "account.localhost",
+			expectedTenantId: domain.DefaultTenantId,
+			expectedOk:       true,
+		},
+		{
+			name:             "OK, tenant by the host with the port",
+			host:             "Account.Acme.com:8080",
+			expectedTenantId: "acme",
+			expectedOk:       true,
+		},
+		{
+			name:             "OK, tenant by the path takes precedence",
+			host:             "account.localhost",
+			tenantId:         "acme",
+			expectedTenantId: "acme",
+			expectedOk:       true,
+		},
+		{
+			name:     "BAD, unknown tenant of the path",
+			host:     "account.acme.com",
+			tenantId: "globex",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Act
+			tenant, ok := tenants.Resolve(testCase.host, testCase.tenantId)
+
+			//// Assert
+			assert.Equal(t, ok, testCase.expectedOk)
+			if ok {
+				assert.Equal(t, tenant.Id, testCase.expectedTenantId)
+			}
+		})
+	}
+}
+
+func TestTenantService_ClientTenant(t *testing.T) {
+	tenants := service.NewTenantService(&config.Config{
+		Branding: config.BrandingConfig{ProductName: "Account"},
+		Tenants: []config.TenantConfig{{
+			Id:       "acme",
+			Name:     "Acme",
+			Clients:  []string{"acme-web"},
+			Branding: config.BrandingConfig{PrimaryColor: "#ff0000"},
+		}},
+	})
+
+	//// Act
+	acme := tenants.ClientTenant("acme-web")
+	other := tenants.ClientTenant("other")
+
+	//// Assert
+	assert.Equal(t, acme.Id, "acme")
+	assert.Equal(t, acme.Branding, domain.Branding{ProductName: "Acme", PrimaryColor: "#ff0000"})
+	assert.Equal(t, other.Id, domain.DefaultTenantId)
+	assert.Equal(t, other.Branding.ProductName, "Account")
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Length of the random link tokens in bytes.
+const linkTokenLength = 32
+
+// newLinkToken returns the random token of the link sent to the user and its hash to store.
+func newLinkToken() (string, string, error) {
+	rawToken := make([]byte, linkTokenLength)
+	if _, err := rand.Read(rawToken); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(rawToken)
+	return token, hashLinkToken(token), nil
+}
+
+// hashLinkToken hashes the link's token, so the store keeps no usable tokens.
+func hashLinkToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
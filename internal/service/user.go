@@ -7,13 +7,39 @@ import (
 	"service-account/internal/config"
 	"service-account/internal/domain"
 	"service-account/internal/repository"
+	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+const (
+	// The longest accepted password, it limits the hashing cost.
+	passwordMaxLength = 128
+	// How long the new email can be confirmed.
+	emailChangeConfirmFor = 24 * time.Hour
+	// How long the old email can revert the confirmed change.
+	emailChangeRevertFor = 3 * 24 * time.Hour
 )
 
 var (
 	ErrUserNotFound      = errors.New("UserRepositoryGorm not found")
 	ErrPasswordIncorrect = errors.New("Password is incorrect")
 	ErrUserAlreadyExist  = errors.New("UserRepositoryGorm already exist")
+	ErrUsernameTaken     = errors.New("Username is already taken")
+	// ErrUserVersionConflict the user was changed since the client read it.
+	ErrUserVersionConflict = errors.New("User was changed by another request")
+	// ErrPasswordPolicy is wrapped with the violated rule.
+	ErrPasswordPolicy      = errors.New("Password doesn't meet the policy")
+	ErrEmailTaken          = errors.New("Email is already taken")
+	ErrEmailChangeNotFound = errors.New("Email change link is invalid or expired")
+	ErrRestoreNotFound     = errors.New("Restore link is invalid or expired")
+	ErrUserSuspended       = errors.New("Account is suspended")
+	ErrUserLocked          = errors.New("Account is locked after too many failed sign-in attempts, try again later")
+	// ErrDirectoryEmailTaken the directory's entry has the email of the account not linked to the directory,
+	// the directory doesn't prove the ownership of the account.
+	ErrDirectoryEmailTaken = errors.New("Account with your email already exists and isn't linked to the directory, contact the administrator")
+	// ErrSuspensionExpired the suspension's end is in the past.
+	ErrSuspensionExpired = errors.New("Suspension must end in the future")
 )
 
 type UserSignUpInput struct {
@@ -23,58 +49,121 @@ type UserSignUpInput struct {
 }
 
 type UserSignInInput struct {
-	Email    string
+	// Login is the user's username or email.
+	Login    string
+	Password string
+}
+
+// UserUpdateInput the nil fields aren't changed.
+type UserUpdateInput struct {
+	Username    *string
+	DisplayName *string
+	Locale      *string
+}
+
+type UserChangePasswordInput struct {
+	CurrentPassword string
+	NewPassword     string
+	// Verified the user confirmed the change with the one-time code sent to the email.
+	Verified bool
+}
+
+type UserDeleteInput struct {
 	Password string
+	// Verified the user confirmed the deletion with the one-time code sent to the email.
+	Verified bool
+}
+
+type UserEmailChangeInput struct {
+	// Password is the current password, it's re-verified.
+	Password string
+	NewEmail string
+	// Verified the user confirmed the change with the one-time code sent to the email.
+	Verified bool
 }
 
 type UserService struct {
 	repo   UserRepository
+	roles  RoleRepository
 	hasher Hasher
-	config *config.Config
+	// backend is the directory the staff signs in with, the local passwords are used if it's nil.
+	backend CredentialBackend
+	config  *config.Config
 }
 
-func NewUserSerices(userRepo UserRepository, hasher Hasher, config *config.Config) *UserService {
+func NewUserSerices(userRepo UserRepository, roleRepo RoleRepository, hasher Hasher, backend CredentialBackend, config *config.Config) *UserService {
 	return &UserService{
-		repo:   userRepo,
-		hasher: hasher,
-		config: config,
+		repo:    userRepo,
+		roles:   roleRepo,
+		hasher:  hasher,
+		backend: backend,
+		config:  config,
 	}
 }
 
-func (s *UserService) SignUp(ctx context.Context, inputUserData *UserSignUpInput) error {
+// SignUp registers the user, the username and email are normalized.
+func (s *UserService) SignUp(ctx context.Context, inputUserData *UserSignUpInput) (*domain.User, error) {
+	username, err := NormalizeUsername(inputUserData.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := NormalizeEmail(inputUserData.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPasswordPolicy(passwordPolicy(ctx, &s.config.Password), inputUserData.Password, username, email); err != nil {
+		return nil, err
+	}
+
 	// Hashing password.
 	// TODO: get password salt from config file and .env.
 	passwordHash := s.hasher.Hash(inputUserData.Password, []byte(s.config.DB.Salt))
 
 	// Prepare user data.
 	user := &domain.User{
-		Username:         inputUserData.Username,
-		Email:            inputUserData.Email,
+		Username:         username,
+		Email:            email,
 		PasswordHash:     passwordHash,
 		DateRegistration: time.Now(),
 		DateLastOnline:   time.Now(),
+		Version:          1,
 	}
 
 	// Create record in database.
 	if err := s.repo.Create(ctx, user); err != nil {
 		if errors.Is(err, repository.ErrRecordAlreadyExist) {
-			return ErrUserAlreadyExist
+			return nil, ErrUserAlreadyExist
 		}
 
-		return err
+		return nil, err
 	}
 
-	return nil
+	return user, nil
 }
 
+// SignIn checks the user's credentials. The user is returned with ErrUserLocked, ErrPasswordIncorrect
+// and ErrUserSuspended too, so the failure is attributed to the user.
+// The directory's users sign in with the directory passwords, the logins unknown to the directory with the local ones.
 func (s *UserService) SignIn(ctx context.Context, inputUserData *UserSignInInput) (*domain.User, error) {
+	if s.backend != nil {
+		entry, err := s.backend.Find(ctx, strings.TrimSpace(inputUserData.Login))
+		if err != nil {
+			return nil, err
+		}
+
+		if entry != nil {
+			return s.signInDirectory(ctx, entry, inputUserData.Password)
+		}
+	}
+
 	// Hashing password.
 	// TODO: get password salt from config file and .env.
 	passwordHash := s.hasher.Hash(inputUserData.Password, []byte(s.config.DB.Salt))
 
-	// Get user record from database.
-	user := &domain.User{}
-	user, err := s.repo.GetUserByEmail(ctx, inputUserData.Email)
+	// Get user record from database by the username or email, the unusable login is an unknown one.
+	user, err := s.getUserByLogin(ctx, inputUserData.Login)
 	if err != nil {
 		if errors.Is(err, repository.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -83,12 +172,230 @@ func (s *UserService) SignIn(ctx context.Context, inputUserData *UserSignInInput
 		return nil, err
 	}
 
+	// The locked out user isn't checked, so guessing the password goes no further.
+	now := time.Now()
+	if user.IsLocked(now) {
+		return user, ErrUserLocked
+	}
+
 	// Check password hash.
 	if bytes.Compare(user.PasswordHash, passwordHash) != 0 {
 		// Not equal!
-		return nil, ErrPasswordIncorrect
+		return s.failSignIn(ctx, user, now)
+	}
+
+	return s.completeSignIn(ctx, user, now)
+}
+
+// signInDirectory checks the password of the directory's entry. The user of the entry is provisioned at the first sign-in,
+// the roles of the entry's groups are synced at every one.
+func (s *UserService) signInDirectory(ctx context.Context, entry *domain.DirectoryEntry, password string) (*domain.User, error) {
+	now := time.Now()
+	user, err := s.getDirectoryUser(ctx, entry, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// The locked out user isn't checked, so guessing the password goes no further.
+	if user != nil && user.IsLocked(now) {
+		return user, ErrUserLocked
+	}
+
+	ok, err := s.backend.Verify(ctx, entry, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if user == nil {
+			return nil, ErrPasswordIncorrect
+		}
+
+		return s.failSignIn(ctx, user, now)
+	}
+
+	if user == nil {
+		if user, err = s.provision(ctx, entry, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.syncRoles(ctx, user.Id, entry.Groups, now); err != nil {
+		return nil, err
 	}
 
+	return s.completeSignIn(ctx, user, now)
+}
+
+// getDirectoryUser returns the user linked to the entry, it's nil if the user isn't provisioned yet.
+func (s *UserService) getDirectoryUser(ctx context.Context, entry *domain.DirectoryEntry, now time.Time) (*domain.User, error) {
+	identity, err := s.repo.GetIdentity(ctx, domain.IdentityProviderDirectory, entry.Id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserById(ctx, identity.UserId)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	if err = s.repo.TouchIdentity(ctx, identity.Id, entry.Email, now); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// provision registers the user of the entry without the password and links the entry to the user.
+// The existing account with the entry's email isn't linked, the directory's password doesn't prove its ownership.
+func (s *UserService) provision(ctx context.Context, entry *domain.DirectoryEntry, now time.Time) (*domain.User, error) {
+	email, err := NormalizeEmail(entry.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &domain.Identity{
+		Provider:     domain.IdentityProviderDirectory,
+		Subject:      entry.Id,
+		Email:        email,
+		DateCreated:  now,
+		DateLastUsed: now,
+	}
+
+	_, err = s.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		return nil, ErrDirectoryEmailTaken
+	}
+
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username, err := NormalizeUsername(entry.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Username:         username,
+		Email:            email,
+		PasswordHash:     []byte{},
+		DateRegistration: now,
+		DateLastOnline:   now,
+		DisplayName:      entry.DisplayName,
+		Version:          1,
+	}
+	if err = s.repo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		return nil, provisionError(err)
+	}
+
+	return user, nil
+}
+
+// syncRoles assigns the mapped roles of the entry's groups and revokes the other mapped ones,
+// the roles assigned by the administrators aren't touched.
+func (s *UserService) syncRoles(ctx context.Context, userId uint32, groups []string, now time.Time) error {
+	if len(s.config.LDAP.GroupRoles) == 0 {
+		return nil
+	}
+
+	granted := map[string]bool{}
+	for _, groupRole := range s.config.LDAP.GroupRoles {
+		granted[groupRole.Role] = granted[groupRole.Role] || containsFold(groups, groupRole.Group)
+	}
+
+	roles, err := s.roles.GetUserRoles(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	assigned := map[string]bool{}
+	for _, role := range roles {
+		assigned[role] = true
+	}
+
+	for _, groupRole := range s.config.LDAP.GroupRoles {
+		role := groupRole.Role
+		switch {
+		case granted[role] && !assigned[role]:
+			err = s.roles.AssignRole(ctx, &domain.UserRole{UserId: userId, Role: role, DateAssigned: now})
+		case !granted[role] && assigned[role]:
+			err = s.roles.RevokeRole(ctx, userId, role)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "sync the role %q of the directory groups", role)
+		}
+
+		assigned[role] = granted[role]
+	}
+
+	return nil
+}
+
+// failSignIn counts the user's failed sign-in, the user is locked out after too many ones.
+func (s *UserService) failSignIn(ctx context.Context, user *domain.User, now time.Time) (*domain.User, error) {
+	if threshold := s.config.Account.LockoutThreshold; threshold > 0 {
+		if err := s.repo.RecordFailedSignin(ctx, user.Id, threshold, now.Add(s.config.Account.LockoutDuration)); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, ErrPasswordIncorrect
+}
+
+// checkCurrentPassword re-verifies the signed in user's password, the failed checks are counted as the failed sign-ins,
+// so the session's holder can't guess the password either. The user registered by the identity provider
+// or the directory proves the ownership of the email with the one-time code instead.
+func (s *UserService) checkCurrentPassword(ctx context.Context, user *domain.User, password string, verified bool) error {
+	if !user.HasPassword() {
+		if !verified {
+			return ErrVerificationRequired
+		}
+
+		return nil
+	}
+
+	now := time.Now()
+	if user.IsLocked(now) {
+		return ErrUserLocked
+	}
+
+	if !bytes.Equal(user.PasswordHash, s.hasher.Hash(password, []byte(s.config.DB.Salt))) {
+		_, err := s.failSignIn(ctx, user, now)
+		return err
+	}
+
+	return nil
+}
+
+// completeSignIn checks the suspension of the user who knows the password and resets the failed sign-ins.
+func (s *UserService) completeSignIn(ctx context.Context, user *domain.User, now time.Time) (*domain.User, error) {
+	// The suspension is revealed only to the user who knows the password.
+	if user.IsSuspended(now) {
+		return user, ErrUserSuspended
+	}
+
+	if user.FailedSignins > 0 {
+		if err := s.repo.Unlock(ctx, user.Id); err != nil {
+			return nil, err
+		}
+
+		user.FailedSignins = 0
+	}
+
+	if err := s.repo.UpdateLastOnline(ctx, map[uint32]time.Time{user.Id: now}); err != nil {
+		return nil, err
+	}
+
+	user.DateLastOnline = now
+
 	// UserRepositoryGorm sign in.
 	return user, nil
 }
@@ -108,3 +415,321 @@ func (s *UserService) GetUserById(ctx context.Context, id uint32) (*domain.User,
 
 	return user, nil
 }
+
+// Update changes the user's profile. The update is rejected if the version isn't zero and the user's version differs.
+func (s *UserService) Update(ctx context.Context, id uint32, version uint32, inputUserData *UserUpdateInput) (*domain.User, error) {
+	user, err := s.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && user.Version != version {
+		return nil, ErrUserVersionConflict
+	}
+
+	if inputUserData.Username != nil {
+		if user.Username, err = NormalizeUsername(*inputUserData.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	if inputUserData.DisplayName != nil {
+		user.DisplayName = *inputUserData.DisplayName
+	}
+
+	if inputUserData.Locale != nil {
+		user.Locale = *inputUserData.Locale
+	}
+
+	if err = s.repo.Update(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, repository.ErrUsernameAlreadyExist):
+			return nil, ErrUsernameTaken
+		case errors.Is(err, repository.ErrEmailAlreadyExist):
+			return nil, ErrEmailTaken
+		case errors.Is(err, repository.ErrExternalIdAlreadyExist), errors.Is(err, repository.ErrRecordAlreadyExist):
+			return nil, ErrUserAlreadyExist
+		case errors.Is(err, repository.ErrRecordVersionConflict):
+			return nil, ErrUserVersionConflict
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the user's password after re-verifying the current one, the user without the password sets one
+// once the change is verified with the one-time code.
+func (s *UserService) ChangePassword(ctx context.Context, id uint32, inputUserData *UserChangePasswordInput) (*domain.User, error) {
+	user, err := s.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkCurrentPassword(ctx, user, inputUserData.CurrentPassword, inputUserData.Verified); err != nil {
+		return nil, err
+	}
+
+	if user.HasPassword() && inputUserData.NewPassword == inputUserData.CurrentPassword {
+		return nil, errors.Wrap(ErrPasswordPolicy, "New password must differ from the current one")
+	}
+
+	if err = checkPasswordPolicy(passwordPolicy(ctx, &s.config.Password), inputUserData.NewPassword, user.Username, user.Email); err != nil {
+		return nil, err
+	}
+
+	passwordHash := s.hasher.Hash(inputUserData.NewPassword, []byte(s.config.DB.Salt))
+	if err = s.repo.UpdatePasswordHash(ctx, id, passwordHash); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	user.PasswordHash = passwordHash
+	user.Version++
+
+	// The new password starts with no failed sign-ins.
+	if user.FailedSignins > 0 {
+		if err = s.repo.Unlock(ctx, id); err != nil {
+			return nil, err
+		}
+
+		user.FailedSignins = 0
+	}
+
+	return user, nil
+}
+
+// RequestEmailChange stores the pending email change after re-verifying the password,
+// returns the token of the new email's confirmation link.
+func (s *UserService) RequestEmailChange(ctx context.Context, id uint32, inputUserData *UserEmailChangeInput) (*domain.EmailChange, string, error) {
+	user, err := s.GetUserById(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = s.checkCurrentPassword(ctx, user, inputUserData.Password, inputUserData.Verified); err != nil {
+		return nil, "", err
+	}
+
+	newEmail, err := NormalizeEmail(inputUserData.NewEmail)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The uniqueness is enforced again on the confirmation.
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil, "", ErrEmailTaken
+	}
+
+	if _, err = s.repo.GetUserByEmail(ctx, newEmail); err == nil {
+		return nil, "", ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	token, tokenHash, err := newLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	emailChange := &domain.EmailChange{
+		UserId:      user.Id,
+		OldEmail:    user.Email,
+		NewEmail:    newEmail,
+		TokenHash:   tokenHash,
+		DateCreated: now,
+		DateExpires: now.Add(emailChangeConfirmFor),
+	}
+	if err = s.repo.CreateEmailChange(ctx, emailChange); err != nil {
+		return nil, "", err
+	}
+
+	return emailChange, token, nil
+}
+
+// ConfirmEmailChange swaps the user's email for the confirmed one, returns the token of the old email's revert link.
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, string, error) {
+	revertToken, revertTokenHash, err := newLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	emailChange, err := s.repo.ConfirmEmailChange(ctx, hashLinkToken(token), revertTokenHash, time.Now().Add(emailChangeRevertFor))
+	if err != nil {
+		return nil, "", emailChangeError(err)
+	}
+
+	return emailChange, revertToken, nil
+}
+
+// RevertEmailChange swaps the user's email back for the old one.
+func (s *UserService) RevertEmailChange(ctx context.Context, revertToken string) (*domain.EmailChange, error) {
+	emailChange, err := s.repo.RevertEmailChange(ctx, hashLinkToken(revertToken))
+	if err != nil {
+		return nil, emailChangeError(err)
+	}
+
+	return emailChange, nil
+}
+
+// ListEmailChanges returns the user's email changes.
+func (s *UserService) ListEmailChanges(ctx context.Context, id uint32) ([]domain.EmailChange, error) {
+	return s.repo.ListEmailChanges(ctx, id)
+}
+
+// Delete marks the user deleted after re-verifying the password, returns the token of the restore link.
+// The user without the password verifies the deletion with the one-time code.
+// The user is erased after the grace period unless restored.
+func (s *UserService) Delete(ctx context.Context, id uint32, inputUserData *UserDeleteInput) (*domain.User, string, error) {
+	user, err := s.GetUserById(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = s.checkCurrentPassword(ctx, user, inputUserData.Password, inputUserData.Verified); err != nil {
+		return nil, "", err
+	}
+
+	restoreToken, restoreTokenHash, err := newLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = s.repo.SoftDelete(ctx, id, restoreTokenHash); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, "", ErrUserNotFound
+		}
+
+		return nil, "", err
+	}
+
+	return user, restoreToken, nil
+}
+
+// Restore unmarks the user deleted within the grace period.
+func (s *UserService) Restore(ctx context.Context, restoreToken string) (*domain.User, error) {
+	user, err := s.repo.Restore(ctx, hashLinkToken(restoreToken), time.Now().Add(-s.config.Account.DeletionGracePeriod))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrRestoreNotFound
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// PurgeDeleted erases the users deleted longer than the grace period ago, returns the number of the erased users.
+func (s *UserService) PurgeDeleted(ctx context.Context) (int64, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-s.config.Account.DeletionGracePeriod))
+}
+
+// Suspend suspends the user until the date or indefinitely if it's nil, the suspended user can't sign in.
+func (s *UserService) Suspend(ctx context.Context, id uint32, reason string, until *time.Time) error {
+	if until != nil && !until.After(time.Now()) {
+		return ErrSuspensionExpired
+	}
+
+	return userError(s.repo.Suspend(ctx, id, reason, until))
+}
+
+// Unsuspend lifts the user's suspension.
+func (s *UserService) Unsuspend(ctx context.Context, id uint32) error {
+	return userError(s.repo.Unsuspend(ctx, id))
+}
+
+// Unlock lifts the user's lockout after the failed sign-ins.
+func (s *UserService) Unlock(ctx context.Context, id uint32) error {
+	return userError(s.repo.Unlock(ctx, id))
+}
+
+// UpdateLastOnline sets the user's last online date to now.
+func (s *UserService) UpdateLastOnline(ctx context.Context, id uint32) error {
+	return s.repo.UpdateLastOnline(ctx, map[uint32]time.Time{id: time.Now()})
+}
+
+// checkPasswordPolicy checks the password's length and that it doesn't contain the user's name or email.
+func checkPasswordPolicy(policy *config.PasswordConfig, password string, username string, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		return errors.Wrapf(ErrPasswordPolicy, "Password must be at least %d characters long", policy.MinLength)
+	}
+
+	if length > passwordMaxLength {
+		return errors.Wrapf(ErrPasswordPolicy, "Password must be at most %d characters long", passwordMaxLength)
+	}
+
+	lowerPassword := strings.ToLower(password)
+	if username != "" && strings.Contains(lowerPassword, strings.ToLower(username)) {
+		return errors.Wrap(ErrPasswordPolicy, "Password must not contain the username")
+	}
+
+	if emailName, _, _ := strings.Cut(email, "@"); emailName != "" && strings.Contains(lowerPassword, strings.ToLower(emailName)) {
+		return errors.Wrap(ErrPasswordPolicy, "Password must not contain the email")
+	}
+
+	return nil
+}
+
+// getUserByLogin finds the user by the email if the login has "@", by the username otherwise.
+func (s *UserService) getUserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	login, err := NormalizeLogin(login)
+	if err != nil {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	if IsEmailLogin(login) {
+		return s.repo.GetUserByEmail(ctx, login)
+	}
+
+	return s.repo.GetUserByUsername(ctx, login)
+}
+
+// provisionError maps the taken username or email of the directory's user.
+func provisionError(err error) error {
+	if errors.Is(err, repository.ErrRecordAlreadyExist) {
+		return ErrUserAlreadyExist
+	}
+
+	return err
+}
+
+// containsFold reports whether the DNs contain the DN, the DNs are case-insensitive.
+func containsFold(dns []string, dn string) bool {
+	for _, value := range dns {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(dn)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func userError(err error) error {
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+
+	return err
+}
+
+func emailChangeError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		return ErrEmailChangeNotFound
+	case errors.Is(err, repository.ErrRecordAlreadyExist):
+		return ErrEmailTaken
+	case errors.Is(err, repository.ErrRecordVersionConflict):
+		return ErrUserVersionConflict
+	}
+
+	return err
+}
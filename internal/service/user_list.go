@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"service-account/internal/domain"
+	"strings"
+	"time"
+)
+
+const (
+	// The users listing's page size if the limit isn't set.
+	userListDefaultLimit = 50
+	// The largest users listing's page.
+	userListMaxLimit = 200
+)
+
+// ErrInvalidCursor the cursor is malformed or was issued for another sort.
+var ErrInvalidCursor = errors.New("Cursor is invalid")
+
+// UserListInput the empty filters don't filter.
+type UserListInput struct {
+	EmailPrefix    string
+	UsernamePrefix string
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time
+	// LastOnlineBefore finds the inactive users.
+	LastOnlineBefore *time.Time
+	Status           string
+	// Sort is the sort field, "-" prefix sorts in the descending order.
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+// userListCursor is the key of the page's last user, only the sort field's key is set.
+type userListCursor struct {
+	Sort             string     `json:"s"`
+	Id               uint32     `json:"i"`
+	Username         string     `json:"u,omitempty"`
+	Email            string     `json:"e,omitempty"`
+	DateRegistration *time.Time `json:"r,omitempty"`
+	DateLastOnline   *time.Time `json:"o,omitempty"`
+}
+
+// List returns the page of the users, the next page is read by the returned cursor.
+func (s *UserService) List(ctx context.Context, input *UserListInput) (*domain.UserPage, error) {
+	query := &domain.UserListQuery{
+		EmailPrefix:      input.EmailPrefix,
+		UsernamePrefix:   input.UsernamePrefix,
+		RegisteredFrom:   input.RegisteredFrom,
+		RegisteredTo:     input.RegisteredTo,
+		LastOnlineBefore: input.LastOnlineBefore,
+		Status:           input.Status,
+		Sort:             strings.TrimPrefix(input.Sort, "-"),
+		Desc:             strings.HasPrefix(input.Sort, "-"),
+		Limit:            input.Limit,
+	}
+
+	if query.Sort == "" {
+		query.Sort = domain.UserSortId
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = userListDefaultLimit
+	} else if query.Limit > userListMaxLimit {
+		query.Limit = userListMaxLimit
+	}
+
+	if input.Cursor != "" {
+		after, err := decodeUserListCursor(input.Cursor, input.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		query.After = after
+	}
+
+	// One more user tells that the next page exists.
+	limit := query.Limit
+	query.Limit++
+
+	users, total, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.UserPage{
+		Users: users,
+		Total: total,
+	}
+
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor, err = encodeUserListCursor(&page.Users[limit-1], input.Sort, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+func encodeUserListCursor(user *domain.User, sort string, field string) (string, error) {
+	cursor := userListCursor{
+		Sort: sort,
+		Id:   user.Id,
+	}
+
+	switch field {
+	case domain.UserSortUsername:
+		cursor.Username = user.Username
+	case domain.UserSortEmail:
+		cursor.Email = user.Email
+	case domain.UserSortDateRegistration:
+		cursor.DateRegistration = &user.DateRegistration
+	case domain.UserSortDateLastOnline:
+		cursor.DateLastOnline = &user.DateLastOnline
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeUserListCursor(encoded string, sort string) (*domain.User, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor userListCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	after := &domain.User{
+		Id:       cursor.Id,
+		Username: cursor.Username,
+		Email:    cursor.Email,
+	}
+
+	if cursor.DateRegistration != nil {
+		after.DateRegistration = *cursor.DateRegistration
+	}
+
+	if cursor.DateLastOnline != nil {
+		after.DateLastOnline = *cursor.DateLastOnline
+	}
+
+	return after, nil
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+func TestUserService_List(t *testing.T) {
+	users := []domain.User{
+		{Id: 3, Email: "a@mail.com"},
+		{Id: 1, Email: "b@mail.com"},
+		{Id: 2, Email: "c@mail.com"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//// Arrange
+	mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+	userService := service.NewUserSerices(mockUserRepo, nil, nil, nil, &config.Config{})
+
+	mockUserRepo.EXPECT().List(gomock.Any(), &domain.UserListQuery{
+		EmailPrefix: "a",
+		Sort:        domain.UserSortEmail,
+		Desc:        true,
+		Limit:       3,
+	}).Return(users, int64(5), nil)
+
+	mockUserRepo.EXPECT().List(gomock.Any(), &domain.UserListQuery{
+		EmailPrefix: "a",
+		Sort:        domain.UserSortEmail,
+		Desc:        true,
+		Limit:       3,
+		After:       &domain.User{Id: 1, Email: "b@mail.com"},
+	}).Return(users[2:], int64(5), nil)
+
+	//// Act
+	input := &service.UserListInput{EmailPrefix: "a", Sort: "-email", Limit: 2}
+	firstPage, firstErr := userService.List(context.Background(), input)
+
+	input.Cursor = firstPage.NextCursor
+	lastPage, lastErr := userService.List(context.Background(), input)
+
+	//// Assert
+	assert.Equal(t, firstErr, nil)
+	assert.Equal(t, firstPage.Users, users[:2])
+	assert.Equal(t, firstPage.Total, int64(5))
+	assert.NotEqual(t, firstPage.NextCursor, "")
+
+	assert.Equal(t, lastErr, nil)
+	assert.Equal(t, lastPage.Users, users[2:])
+	assert.Equal(t, lastPage.NextCursor, "")
+}
+
+func TestUserService_List_InvalidCursor(t *testing.T) {
+	testTable := []struct {
+		name   string
+		sort   string
+		cursor string
+	}{
+		{
+			name:   "BAD, cursor is not base64",
+			cursor: "!",
+		},
+		{
+			name:   "BAD, cursor is not JSON",
+			cursor: "bm90IGpzb24",
+		},
+		{
+			name:   "BAD, cursor of another sort",
+			sort:   "-id",
+			cursor: "eyJzIjoiaWQiLCJpIjoxfQ", // {"s":"id","i":1}
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockUserRepo := mock_service.NewMockUserRepository(ctrl)
+			userService := service.NewUserSerices(mockUserRepo, nil, nil, nil, &config.Config{})
+
+			//// Act
+			_, err := userService.List(context.Background(), &service.UserListInput{
+				Sort:   testCase.sort,
+				Cursor: testCase.cursor,
+			})
+
+			//// Assert
+			assert.Equal(t, err, service.ErrInvalidCursor)
+		})
+	}
+}
@@ -5,6 +5,17 @@ import (
 	"time"
 )
 
+const (
+	// Session is the name of the cookie storing the session ID, the tokens are kept in the session store.
+	Session = "session_id"
+	// CSRFToken is the name of the cookie storing the forms' CSRF token.
+	CSRFToken = "csrf_token"
+	// Device is the name of the long-lived cookie storing the signed device ID, the users' devices are recognized by it.
+	Device = "device_id"
+	// UpstreamState is the name of the cookie storing the state of the sign-in with the upstream identity provider.
+	UpstreamState = "idp_state"
+)
+
 func GetValue(request *http.Request, name string) (string, error) {
 	if coockie, err := request.Cookie(name); err != nil {
 		return "", err
@@ -18,8 +29,61 @@ func Remove(responseWriter http.ResponseWriter, cookieName string) {
 	expire := time.Now().Add(-7 * 24 * time.Hour)
 	cookie := http.Cookie{
 		Name:    cookieName,
+		Path:    "/",
 		Expires: expire,
 		MaxAge:  -1,
 	}
 	http.SetCookie(responseWriter, &cookie)
 }
+
+// SetSession saves the session ID in the cookie living no longer than the session.
+func SetSession(responseWriter http.ResponseWriter, sessionId string, maxAge time.Duration) {
+	http.SetCookie(responseWriter, &http.Cookie{
+		Name:     Session,
+		Value:    sessionId,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetDevice saves the signed device ID in the cookie outliving the sessions.
+func SetDevice(responseWriter http.ResponseWriter, device string, maxAge time.Duration) {
+	http.SetCookie(responseWriter, &http.Cookie{
+		Name:     Device,
+		Value:    device,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetCSRFToken saves the CSRF token in the browser session cookie.
+func SetCSRFToken(responseWriter http.ResponseWriter, token string) {
+	http.SetCookie(responseWriter, &http.Cookie{
+		Name:     CSRFToken,
+		Value:    token,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetUpstreamState saves the state of the sign-in with the identity provider until the provider's callback.
+// The callback is the top-level navigation from the provider, the lax cookie is sent with it.
+func SetUpstreamState(responseWriter http.ResponseWriter, state string, maxAge time.Duration) {
+	http.SetCookie(responseWriter, &http.Cookie{
+		Name:     UpstreamState,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
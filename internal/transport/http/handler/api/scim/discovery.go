@@ -0,0 +1,286 @@
+package scim
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupported struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SpecUri     string `json:"specUri"`
+	Primary     bool   `json:"primary"`
+}
+
+// serviceProviderConfig is the SCIM features supported by the service.
+// SRC: https://www.rfc-editor.org/rfc/rfc7643#section-5
+type serviceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupported          `json:"bulk"`
+	Filter                filterSupported        `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	Etag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	Meta                  *meta                  `json:"meta"`
+}
+
+// attribute is the attribute's definition of the resource's schema.
+// SRC: https://www.rfc-editor.org/rfc/rfc7643#section-7
+type attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	Required      bool        `json:"required"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	Uniqueness    string      `json:"uniqueness"`
+	SubAttributes []attribute `json:"subAttributes,omitempty"`
+}
+
+type schemaResource struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []attribute `json:"attributes"`
+	Meta        *meta       `json:"meta"`
+}
+
+type resourceType struct {
+	Schemas     []string `json:"schemas"`
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+	Meta        *meta    `json:"meta"`
+}
+
+// stringAttribute returns the definition of the string attribute returned by default.
+func stringAttribute(name string, required bool, mutability string, uniqueness string) attribute {
+	return attribute{
+		Name:       name,
+		Type:       "string",
+		Required:   required,
+		Mutability: mutability,
+		Returned:   "default",
+		Uniqueness: uniqueness,
+	}
+}
+
+// referenceAttribute returns the definition of the multi-valued reference, e.g. the group's members.
+func referenceAttribute(name string, mutability string) attribute {
+	return attribute{
+		Name:        name,
+		Type:        "complex",
+		MultiValued: true,
+		Mutability:  mutability,
+		Returned:    "default",
+		Uniqueness:  "none",
+		SubAttributes: []attribute{
+			stringAttribute("value", false, mutability, "none"),
+			{Name: "$ref", Type: "reference", Mutability: mutability, Returned: "default", Uniqueness: "none"},
+			stringAttribute("display", false, "readOnly", "none"),
+		},
+	}
+}
+
+func (h *HandlerSCIM) schemas() []schemaResource {
+	return []schemaResource{
+		{
+			Schemas:     []string{schemaSchema},
+			Id:          schemaUser,
+			Name:        resourceTypeUser,
+			Description: "User Account",
+			Attributes: []attribute{
+				stringAttribute("userName", true, "readWrite", "server"),
+				{
+					Name:       "name",
+					Type:       "complex",
+					Mutability: "readWrite",
+					Returned:   "default",
+					Uniqueness: "none",
+					SubAttributes: []attribute{
+						stringAttribute("formatted", false, "readWrite", "none"),
+						stringAttribute("familyName", false, "readWrite", "none"),
+						stringAttribute("givenName", false, "readWrite", "none"),
+					},
+				},
+				stringAttribute("displayName", false, "readWrite", "none"),
+				{
+					Name:        "emails",
+					Type:        "complex",
+					MultiValued: true,
+					Required:    true,
+					Mutability:  "readWrite",
+					Returned:    "default",
+					Uniqueness:  "server",
+					SubAttributes: []attribute{
+						stringAttribute("value", true, "readWrite", "server"),
+						stringAttribute("type", false, "readWrite", "none"),
+						{Name: "primary", Type: "boolean", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+					},
+				},
+				stringAttribute("locale", false, "readWrite", "none"),
+				{Name: "active", Type: "boolean", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+				{Name: "password", Type: "string", Mutability: "writeOnly", Returned: "never", Uniqueness: "none"},
+				referenceAttribute("groups", "readOnly"),
+			},
+			Meta: &meta{ResourceType: "Schema", Location: h.baseURL() + pathSchemas + "/" + schemaUser},
+		},
+		{
+			Schemas:     []string{schemaSchema},
+			Id:          schemaGroup,
+			Name:        resourceTypeGroup,
+			Description: "Group",
+			Attributes: []attribute{
+				stringAttribute("displayName", true, "readWrite", "server"),
+				referenceAttribute("members", "readWrite"),
+			},
+			Meta: &meta{ResourceType: "Schema", Location: h.baseURL() + pathSchemas + "/" + schemaGroup},
+		},
+	}
+}
+
+func (h *HandlerSCIM) resourceTypes() []resourceType {
+	return []resourceType{
+		{
+			Schemas:     []string{schemaResourceType},
+			Id:          resourceTypeUser,
+			Name:        resourceTypeUser,
+			Endpoint:    pathUsers,
+			Description: "User Account",
+			Schema:      schemaUser,
+			Meta:        &meta{ResourceType: "ResourceType", Location: h.baseURL() + pathResourceTypes + "/" + resourceTypeUser},
+		},
+		{
+			Schemas:     []string{schemaResourceType},
+			Id:          resourceTypeGroup,
+			Name:        resourceTypeGroup,
+			Endpoint:    pathGroups,
+			Description: "Group",
+			Schema:      schemaGroup,
+			Meta:        &meta{ResourceType: "ResourceType", Location: h.baseURL() + pathResourceTypes + "/" + resourceTypeGroup},
+		},
+	}
+}
+
+// serviceProviderConfigGet godoc
+// @Summary     Get service provider config
+// @Description get the SCIM features supported by the service: patch, filter and ETags, but no bulk operations and sorting.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} serviceProviderConfig
+// @Router      /scim/v2/ServiceProviderConfig [get]
+func (h *HandlerSCIM) serviceProviderConfigGet(context *gin.Context) {
+	respond(context, http.StatusOK, "", &serviceProviderConfig{
+		Schemas:        []string{schemaServiceProviderConfig},
+		Patch:          supported{Supported: true},
+		Filter:         filterSupported{Supported: true, MaxResults: 200},
+		ChangePassword: supported{Supported: true},
+		Etag:           supported{Supported: true},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: `Authentication with the OAuth 2.0 access token granted the "scim" scope.`,
+			SpecUri:     "https://www.rfc-editor.org/rfc/rfc6750",
+			Primary:     true,
+		}},
+		Meta: &meta{ResourceType: "ServiceProviderConfig", Location: h.baseURL() + pathServiceProviderConfig},
+	})
+}
+
+// schemasGet godoc
+// @Summary     List schemas
+// @Description list the schemas of the User and Group resources.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} listResponse{Resources=[]schemaResource}
+// @Router      /scim/v2/Schemas [get]
+func (h *HandlerSCIM) schemasGet(context *gin.Context) {
+	schemas := h.schemas()
+	respond(context, http.StatusOK, "", &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: int64(len(schemas)),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+// schemaGet godoc
+// @Summary     Get schema
+// @Description get the schema by its URN.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} schemaResource
+// @Failure     404 {object} errorResponse
+// @Param id    path string true "Schema URN"
+// @Router      /scim/v2/Schemas/{id} [get]
+func (h *HandlerSCIM) schemaGet(context *gin.Context) {
+	for _, schema := range h.schemas() {
+		if schema.Id == context.Param("id") {
+			respond(context, http.StatusOK, "", &schema)
+			return
+		}
+	}
+
+	abortError(context, "schemaGet()", &scimError{status: http.StatusNotFound, detail: "Schema not found"})
+}
+
+// resourceTypesGet godoc
+// @Summary     List resource types
+// @Description list the User and Group resource types.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} listResponse{Resources=[]resourceType}
+// @Router      /scim/v2/ResourceTypes [get]
+func (h *HandlerSCIM) resourceTypesGet(context *gin.Context) {
+	resourceTypes := h.resourceTypes()
+	respond(context, http.StatusOK, "", &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: int64(len(resourceTypes)),
+		StartIndex:   1,
+		ItemsPerPage: len(resourceTypes),
+		Resources:    resourceTypes,
+	})
+}
+
+// resourceTypeGet godoc
+// @Summary     Get resource type
+// @Description get the resource type by its name.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} resourceType
+// @Failure     404 {object} errorResponse
+// @Param id    path string true "Resource type"
+// @Router      /scim/v2/ResourceTypes/{id} [get]
+func (h *HandlerSCIM) resourceTypeGet(context *gin.Context) {
+	for _, resourceType := range h.resourceTypes() {
+		if resourceType.Id == context.Param("id") {
+			respond(context, http.StatusOK, "", &resourceType)
+			return
+		}
+	}
+
+	abortError(context, "resourceTypeGet()", &scimError{status: http.StatusNotFound, detail: "Resource type not found"})
+}
@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Filter operators.
+// SRC: https://www.rfc-editor.org/rfc/rfc7644#section-3.4.2.2
+const (
+	operatorEqual      = "eq"
+	operatorStartsWith = "sw"
+)
+
+// filter is the comparison of the attribute with the string, e.g. `userName eq "alice"`.
+// The logical operators and the other comparisons aren't supported, the provisioning clients look the resources up one by one.
+type filter struct {
+	// Attribute is lowercased without the schema, the attributes are case-insensitive.
+	Attribute string
+	Operator  string
+	Value     string
+}
+
+// parseFilter parses the filter expression, it's nil if the expression is empty.
+func parseFilter(expression string) (*filter, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, nil
+	}
+
+	attribute, rest, found := strings.Cut(expression, " ")
+	if !found {
+		return nil, errInvalidFilter("The filter must compare the attribute with the value.")
+	}
+
+	operator, value, found := strings.Cut(strings.TrimSpace(rest), " ")
+	if !found {
+		return nil, errInvalidFilter("The filter must compare the attribute with the value.")
+	}
+
+	operator = strings.ToLower(operator)
+	if operator != operatorEqual && operator != operatorStartsWith {
+		return nil, errInvalidFilter("The filter supports the eq and sw operators only.")
+	}
+
+	// The value is the JSON string.
+	var decoded string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &decoded); err != nil {
+		return nil, errInvalidFilter("The filter's value must be the quoted string.")
+	}
+
+	return &filter{
+		Attribute: attributePath(attribute),
+		Operator:  operator,
+		Value:     decoded,
+	}, nil
+}
+
+// attributePath returns the lowercased attribute path without the schema's URN.
+func attributePath(path string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	for _, schema := range []string{schemaUser, schemaGroup} {
+		path = strings.TrimPrefix(path, strings.ToLower(schema)+":")
+	}
+
+	return path
+}
+
+func errInvalidFilter(detail string) *scimError {
+	return &scimError{status: http.StatusBadRequest, scimType: scimTypeInvalidFilter, detail: detail}
+}
@@ -0,0 +1,67 @@
+package scim
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	testTable := []struct {
+		name           string
+		expression     string
+		expectedFilter *filter
+		expectedError  bool
+	}{
+		{
+			name:           "OK, empty filter",
+			expression:     " ",
+			expectedFilter: nil,
+		},
+		{
+			name:           "OK, equal",
+			expression:     `userName eq "Alice"`,
+			expectedFilter: &filter{Attribute: "username", Operator: operatorEqual, Value: "Alice"},
+		},
+		{
+			name:           "OK, starts with by the schema's attribute",
+			expression:     `urn:ietf:params:scim:schemas:core:2.0:User:emails.value SW "alice@"`,
+			expectedFilter: &filter{Attribute: "emails.value", Operator: operatorStartsWith, Value: "alice@"},
+		},
+		{
+			name:           "OK, value with spaces and escapes",
+			expression:     `displayName eq "R&D \"Core\" team"`,
+			expectedFilter: &filter{Attribute: "displayname", Operator: operatorEqual, Value: `R&D "Core" team`},
+		},
+		{
+			name:          "BAD, unsupported operator",
+			expression:    `userName co "ali"`,
+			expectedError: true,
+		},
+		{
+			name:          "BAD, logical operator",
+			expression:    `userName eq "alice" or userName eq "bob"`,
+			expectedError: true,
+		},
+		{
+			name:          "BAD, presence",
+			expression:    `title pr`,
+			expectedError: true,
+		},
+		{
+			name:          "BAD, unquoted value",
+			expression:    `userName eq alice`,
+			expectedError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Act
+			parsed, err := parseFilter(testCase.expression)
+
+			//// Assert
+			assert.Equal(t, err != nil, testCase.expectedError)
+			assert.Equal(t, parsed, testCase.expectedFilter)
+		})
+	}
+}
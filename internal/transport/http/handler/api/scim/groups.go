@@ -0,0 +1,306 @@
+package scim
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"strings"
+)
+
+// groupsList godoc
+// @Summary     List groups
+// @Security 	ApiKeyAuth
+// @Description list the groups with their members, `excludedAttributes=members` omits the members. The filter compares the displayName
+// @Description or externalId with the "eq" operator, e.g. `displayName eq "Engineering"`. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} listResponse{Resources=[]groupResource}
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     500 {object} errorResponse
+// @Param filter             query string false "Filter"
+// @Param startIndex         query int    false "1-based index of the first group"
+// @Param count              query int    false "Page size, up to 200"
+// @Param excludedAttributes query string false "Excluded attributes"
+// @Router      /scim/v2/Groups [get]
+func (h *HandlerSCIM) groupsList(context *gin.Context) {
+	startIndex, count, ok := getPage(context, "groupsList()")
+	if !ok {
+		return
+	}
+
+	input := &service.SCIMGroupListInput{StartIndex: startIndex, Count: count}
+	expression, err := parseFilter(context.Query("filter"))
+	if err != nil {
+		abortError(context, "groupsList()", err)
+		return
+	}
+
+	if expression != nil {
+		switch {
+		case expression.Attribute == "displayname" && expression.Operator == operatorEqual:
+			input.DisplayName = expression.Value
+		case expression.Attribute == "externalid" && expression.Operator == operatorEqual:
+			input.ExternalId = expression.Value
+		default:
+			abortError(context, "groupsList()", errInvalidFilter("The groups are filtered by the displayName or externalId with the eq operator."))
+			return
+		}
+	}
+
+	page, err := h.services.SCIM.ListGroups(context, input)
+	if err != nil {
+		abortError(context, "groupsList()", err)
+		return
+	}
+
+	excludeMembers := excludesMembers(context)
+	resources := make([]*groupResource, 0, len(page.Groups))
+	for i := range page.Groups {
+		resources = append(resources, newGroupResource(&page.Groups[i], h.baseURL(), excludeMembers))
+	}
+
+	respond(context, http.StatusOK, "", &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: page.Total,
+		StartIndex:   page.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// groupGet godoc
+// @Summary     Get group
+// @Security 	ApiKeyAuth
+// @Description get the group with its members, `excludedAttributes=members` omits the members. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} groupResource
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id                 path  int    true  "Group ID"
+// @Param excludedAttributes query string false "Excluded attributes"
+// @Router      /scim/v2/Groups/{id} [get]
+func (h *HandlerSCIM) groupGet(context *gin.Context) {
+	groupId, ok := getIdParam(context, "groupGet()", service.ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	group, err := h.services.SCIM.GetGroup(context, groupId)
+	if err != nil {
+		abortError(context, "groupGet()", err)
+		return
+	}
+
+	resource := newGroupResource(group, h.baseURL(), excludesMembers(context))
+	respond(context, http.StatusOK, resource.Meta.Version, resource)
+}
+
+// groupPost godoc
+// @Summary     Create group
+// @Security 	ApiKeyAuth
+// @Description create the group with the members, the members are the users' ids. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} groupResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     409 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param input body groupResource true "Group"
+// @Router      /scim/v2/Groups [post]
+func (h *HandlerSCIM) groupPost(context *gin.Context) {
+	var resource groupResource
+	if !bindJSON(context, "groupPost()", &resource) {
+		return
+	}
+
+	input, err := resource.input()
+	if err != nil {
+		abortError(context, "groupPost()", err)
+		return
+	}
+
+	group, err := h.services.SCIM.CreateGroup(context, clientId(context), input)
+	if err != nil {
+		abortError(context, "groupPost()", err)
+		return
+	}
+
+	created := newGroupResource(group, h.baseURL(), false)
+	context.Header("Location", created.Meta.Location)
+	respond(context, http.StatusCreated, created.Meta.Version, created)
+}
+
+// groupPut godoc
+// @Summary     Replace group
+// @Security 	ApiKeyAuth
+// @Description replace the group's name and members. The If-Match header rejects the replacement of the changed group.
+// @Description The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} groupResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     409 {object} errorResponse
+// @Failure     412 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id       path   int           true  "Group ID"
+// @Param If-Match header string        false "Group's ETag"
+// @Param input    body   groupResource true  "Group"
+// @Router      /scim/v2/Groups/{id} [put]
+func (h *HandlerSCIM) groupPut(context *gin.Context) {
+	groupId, ok := getIdParam(context, "groupPut()", service.ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	version, ok := getVersion(context, "groupPut()")
+	if !ok {
+		return
+	}
+
+	var resource groupResource
+	if !bindJSON(context, "groupPut()", &resource) {
+		return
+	}
+
+	input, err := resource.input()
+	if err != nil {
+		abortError(context, "groupPut()", err)
+		return
+	}
+
+	group, err := h.services.SCIM.ReplaceGroup(context, clientId(context), groupId, version, input)
+	if err != nil {
+		abortError(context, "groupPut()", err)
+		return
+	}
+
+	replaced := newGroupResource(group, h.baseURL(), false)
+	respond(context, http.StatusOK, replaced.Meta.Version, replaced)
+}
+
+// groupPatch godoc
+// @Summary     Patch group
+// @Security 	ApiKeyAuth
+// @Description change the group by the patch operations, e.g. `{"op": "add", "path": "members", "value": [{"value": "1"}]}` adds the member.
+// @Description The If-Match header rejects the patch of the changed group. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} groupResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     409 {object} errorResponse
+// @Failure     412 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id       path   int          true  "Group ID"
+// @Param If-Match header string       false "Group's ETag"
+// @Param input    body   patchRequest true  "Patch operations"
+// @Router      /scim/v2/Groups/{id} [patch]
+func (h *HandlerSCIM) groupPatch(context *gin.Context) {
+	groupId, ok := getIdParam(context, "groupPatch()", service.ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	version, ok := getVersion(context, "groupPatch()")
+	if !ok {
+		return
+	}
+
+	var patch patchRequest
+	if !bindJSON(context, "groupPatch()", &patch) {
+		return
+	}
+
+	// The patch is applied to the current group and replaces it if the group wasn't changed meanwhile,
+	// the clients add and remove the members concurrently.
+	var group *domain.Group
+	var err error
+	for attempt := 0; attempt < patchRetries; attempt++ {
+		if group, err = h.services.SCIM.GetGroup(context, groupId); err != nil {
+			break
+		}
+
+		if version != 0 && group.Version != version {
+			err = service.ErrGroupVersionConflict
+			break
+		}
+
+		var input *service.SCIMGroupInput
+		resource := newGroupResource(group, h.baseURL(), false)
+		if err = applyGroupPatch(resource, patch.Operations); err != nil {
+			break
+		}
+
+		if input, err = resource.input(); err != nil {
+			break
+		}
+
+		group, err = h.services.SCIM.ReplaceGroup(context, clientId(context), groupId, group.Version, input)
+		if version != 0 || !errors.Is(err, service.ErrGroupVersionConflict) {
+			break
+		}
+	}
+
+	if err != nil {
+		abortError(context, "groupPatch()", err)
+		return
+	}
+
+	patched := newGroupResource(group, h.baseURL(), excludesMembers(context))
+	respond(context, http.StatusOK, patched.Meta.Version, patched)
+}
+
+// groupDelete godoc
+// @Summary     Delete group
+// @Security 	ApiKeyAuth
+// @Description delete the group, the members aren't deleted. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     204
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id    path int true "Group ID"
+// @Router      /scim/v2/Groups/{id} [delete]
+func (h *HandlerSCIM) groupDelete(context *gin.Context) {
+	groupId, ok := getIdParam(context, "groupDelete()", service.ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SCIM.DeleteGroup(context, clientId(context), groupId); err != nil {
+		abortError(context, "groupDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// excludesMembers is true if the members are excluded, the large groups are read without them.
+func excludesMembers(context *gin.Context) bool {
+	for _, attribute := range strings.Split(context.Query("excludedAttributes"), ",") {
+		if attributePath(attribute) == "members" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package scim
+
+import (
+	"github.com/gin-gonic/gin"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+)
+
+const (
+	// Paths
+	pathSCIM                  string = "/scim/v2"
+	pathUsers                 string = "/Users"
+	pathGroups                string = "/Groups"
+	pathServiceProviderConfig string = "/ServiceProviderConfig"
+	pathSchemas               string = "/Schemas"
+	pathResourceTypes         string = "/ResourceTypes"
+)
+
+// HandlerSCIM is the SCIM 2.0 provisioning API of the users and groups.
+// SRC: https://www.rfc-editor.org/rfc/rfc7644
+type HandlerSCIM struct {
+	services *service.Services
+}
+
+func NewHandlerSCIM(services *service.Services) *HandlerSCIM {
+	return &HandlerSCIM{
+		services: services,
+	}
+}
+
+func (h *HandlerSCIM) Init(router *gin.RouterGroup) {
+	scim := router.Group(pathSCIM)
+	{
+		// The discovery endpoints don't require the authentication.
+		scim.GET(pathServiceProviderConfig, h.serviceProviderConfigGet)
+		scim.GET(pathSchemas, h.schemasGet)
+		scim.GET(pathSchemas+"/:id", h.schemaGet)
+		scim.GET(pathResourceTypes, h.resourceTypesGet)
+		scim.GET(pathResourceTypes+"/:id", h.resourceTypeGet)
+	}
+
+	// The provisioning client of the configuration authenticates with its own access token granted the "scim" scope,
+	// e.g. by the client credentials grant. The users' tokens granted the scope by the consent are rejected.
+	resources := scim.Group("",
+		middleware.Authenticate(h.services.OAuth2, h.services.Tenants, h.services.ServiceAccounts),
+		middleware.RequireScopes(domain.ScopeSCIM),
+		middleware.RequireClients(h.services.Config.SCIM.Clients...),
+	)
+	{
+		resources.GET(pathUsers, h.usersList)
+		resources.POST(pathUsers, h.userPost)
+		resources.GET(pathUsers+"/:id", h.userGet)
+		resources.PUT(pathUsers+"/:id", h.userPut)
+		resources.PATCH(pathUsers+"/:id", h.userPatch)
+		resources.DELETE(pathUsers+"/:id", h.userDelete)
+		resources.GET(pathGroups, h.groupsList)
+		resources.POST(pathGroups, h.groupPost)
+		resources.GET(pathGroups+"/:id", h.groupGet)
+		resources.PUT(pathGroups+"/:id", h.groupPut)
+		resources.PATCH(pathGroups+"/:id", h.groupPatch)
+		resources.DELETE(pathGroups+"/:id", h.groupDelete)
+	}
+}
+
+// baseURL returns the public URL of the SCIM API, the resources' locations are built on it.
+func (h *HandlerSCIM) baseURL() string {
+	return h.services.Config.OAuth2.RedirectURL + pathSCIM
+}
+
+// clientId returns the provisioning client of the access token, the changes are audited with it.
+func clientId(context *gin.Context) string {
+	tokenIntrospection := middleware.GetTokenIntrospection(context)
+	if tokenIntrospection == nil || tokenIntrospection.ClientId == nil {
+		return ""
+	}
+
+	return *tokenIntrospection.ClientId
+}
@@ -0,0 +1,91 @@
+package scim
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http/httptest"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+func TestHandlerSCIM_Init(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userId := "7"
+	provisioningClient := "hr-sync"
+	webClient := "web"
+
+	testTable := []struct {
+		name               string
+		tokenIntrospection *domain.OA2TokenIntrospection
+		mockBehaviorSCIM   mockBehaviorSCIM
+		expectedStatusCode int
+	}{
+		{
+			name:               "OK, provisioning client's token",
+			tokenIntrospection: &domain.OA2TokenIntrospection{Active: true, Sub: &provisioningClient, ClientId: &provisioningClient, Scope: "scim"},
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().ListUsers(gomock.Any(), &service.SCIMUserListInput{StartIndex: 1}).Return(&service.SCIMUserPage{StartIndex: 1}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "BAD, user's token granted scim scope",
+			tokenIntrospection: &domain.OA2TokenIntrospection{Active: true, Sub: &userId, ClientId: &provisioningClient, Scope: "openid scim"},
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				// Nothing
+			},
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "BAD, token of client not allowed to provision",
+			tokenIntrospection: &domain.OA2TokenIntrospection{Active: true, Sub: &webClient, ClientId: &webClient, Scope: "scim"},
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				// Nothing
+			},
+			expectedStatusCode: 403,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			mockOAuth2.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(testCase.tokenIntrospection, nil)
+			mockSCIM := mock_service.NewMockSCIM(ctrl)
+			testCase.mockBehaviorSCIM(mockSCIM)
+			serviceConfig := &config.Config{
+				OAuth2: config.OAuth2Config{RedirectURL: "https://account.localhost"},
+				SCIM:   config.SCIMConfig{Clients: []string{provisioningClient}},
+			}
+			handler := NewHandlerSCIM(&service.Services{
+				OAuth2:  mockOAuth2,
+				Tenants: service.NewTenantService(serviceConfig),
+				SCIM:    mockSCIM,
+				Config:  serviceConfig,
+			})
+
+			// Init Endpoints with the authentication.
+			r := gin.New()
+			handler.Init(&r.RouterGroup)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", pathSCIM+pathUsers, nil)
+			req.Header.Add("Authorization", "Bearer token")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Patch operations.
+// SRC: https://www.rfc-editor.org/rfc/rfc7644#section-3.5.2
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations" binding:"required"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value" swaggertype:"object"`
+}
+
+// applyUserPatch applies the operations to the user's resource. The user has one email,
+// so adding the email replaces it.
+func applyUserPatch(resource *userResource, operations []patchOperation) error {
+	return applyPatch(operations, func(op string, path string, value json.RawMessage) error {
+		return patchUser(resource, op, path, value)
+	})
+}
+
+// applyGroupPatch applies the operations to the group's resource.
+func applyGroupPatch(resource *groupResource, operations []patchOperation) error {
+	return applyPatch(operations, func(op string, path string, value json.RawMessage) error {
+		return patchGroup(resource, op, path, value)
+	})
+}
+
+// applyPatch applies the operations by the attribute's path. The operation without the path
+// sets the attributes of the value's object.
+func applyPatch(operations []patchOperation, apply func(op string, path string, value json.RawMessage) error) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != opAdd && op != opReplace && op != opRemove {
+			return errInvalidSyntax("The patch operation must be add, replace or remove.")
+		}
+
+		if operation.Path != "" {
+			if err := apply(op, attributePath(operation.Path), operation.Value); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if op == opRemove {
+			return &scimError{status: http.StatusBadRequest, scimType: scimTypeNoTarget, detail: "The remove operation must have the path."}
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return errInvalidValue("The value of the operation without the path must be the object.")
+		}
+
+		for path, value := range values {
+			if err := apply(op, attributePath(path), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func patchUser(resource *userResource, op string, path string, value json.RawMessage) error {
+	// The email is set by its filtered value path too, e.g. `emails[type eq "work"].value`.
+	if strings.HasPrefix(path, "emails[") && strings.HasSuffix(path, "].value") {
+		path = "emails.value"
+	}
+
+	if op == opRemove {
+		switch path {
+		case "displayname":
+			resource.DisplayName = ""
+		case "name", "name.formatted", "name.givenname", "name.familyname":
+			resource.Name = nil
+		case "externalid":
+			resource.ExternalId = ""
+		case "locale":
+			resource.Locale = ""
+		default:
+			return errInvalidPath("The attribute is required or can't be removed: " + path)
+		}
+
+		return nil
+	}
+
+	switch path {
+	case "active":
+		active, err := decodeBool(value)
+		if err != nil {
+			return err
+		}
+
+		resource.Active = &active
+		return nil
+	case "emails":
+		var emails []multiValue
+		if err := json.Unmarshal(value, &emails); err != nil || len(emails) == 0 {
+			return errInvalidValue("The emails must be the array of the email values.")
+		}
+
+		resource.Emails = emails
+		return nil
+	case "name":
+		var userName name
+		if err := json.Unmarshal(value, &userName); err != nil {
+			return errInvalidValue("The name must be the object.")
+		}
+
+		resource.Name = &userName
+		return nil
+	}
+
+	var field *string
+	switch path {
+	case "username":
+		field = &resource.UserName
+	case "displayname":
+		field = &resource.DisplayName
+	case "externalid":
+		field = &resource.ExternalId
+	case "locale":
+		field = &resource.Locale
+	case "password":
+		field = &resource.Password
+	case "emails.value":
+		resource.Emails = []multiValue{{Type: "work", Primary: true}}
+		field = &resource.Emails[0].Value
+	case "name.formatted", "name.givenname", "name.familyname":
+		field = nameField(resource, path)
+	default:
+		return errInvalidPath("The attribute isn't supported: " + path)
+	}
+
+	text, err := decodeString(value)
+	if err != nil {
+		return err
+	}
+
+	*field = text
+	return nil
+}
+
+// nameField returns the name's part of the path.
+func nameField(resource *userResource, path string) *string {
+	if resource.Name == nil {
+		resource.Name = &name{}
+	}
+
+	switch path {
+	case "name.givenname":
+		return &resource.Name.GivenName
+	case "name.familyname":
+		return &resource.Name.FamilyName
+	}
+
+	// The display name is stored, the formatted name replaces it.
+	resource.DisplayName = ""
+	return &resource.Name.Formatted
+}
+
+func patchGroup(resource *groupResource, op string, path string, value json.RawMessage) error {
+	// The member is removed by its filtered path, e.g. `members[value eq "1"]`.
+	if strings.HasPrefix(path, "members[") {
+		member, err := parseFilter(strings.TrimSuffix(strings.TrimPrefix(path, "members["), "]"))
+		if err != nil || member == nil || member.Attribute != "value" || member.Operator != operatorEqual || op != opRemove {
+			return errInvalidPath("The member's path must be removed by its value.")
+		}
+
+		resource.Members = removeMembers(resource.Members, []reference{{Value: member.Value}})
+		return nil
+	}
+
+	switch path {
+	case "members":
+		var members []reference
+		if op == opRemove && len(value) == 0 {
+			resource.Members = nil
+			return nil
+		}
+
+		if err := json.Unmarshal(value, &members); err != nil {
+			return errInvalidValue("The members must be the array of the member values.")
+		}
+
+		switch op {
+		case opAdd:
+			resource.Members = append(removeMembers(resource.Members, members), members...)
+		case opReplace:
+			resource.Members = members
+		case opRemove:
+			resource.Members = removeMembers(resource.Members, members)
+		}
+
+		return nil
+	case "externalid":
+		if op == opRemove {
+			resource.ExternalId = ""
+			return nil
+		}
+
+		text, err := decodeString(value)
+		resource.ExternalId = text
+		return err
+	case "displayname":
+		if op == opRemove {
+			return errInvalidPath("The group's name is required.")
+		}
+
+		text, err := decodeString(value)
+		resource.DisplayName = text
+		return err
+	}
+
+	return errInvalidPath("The attribute isn't supported: " + path)
+}
+
+// removeMembers returns the members without the removed ones.
+func removeMembers(members []reference, removed []reference) []reference {
+	var kept []reference
+	for _, member := range members {
+		found := false
+		for _, removedMember := range removed {
+			found = found || member.Value == removedMember.Value
+		}
+
+		if !found {
+			kept = append(kept, member)
+		}
+	}
+
+	return kept
+}
+
+func decodeString(value json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return "", errInvalidValue("The value must be the string.")
+	}
+
+	return text, nil
+}
+
+// decodeBool decodes the boolean, some clients send it as the "True" or "False" string.
+func decodeBool(value json.RawMessage) (bool, error) {
+	var boolean bool
+	if err := json.Unmarshal(value, &boolean); err == nil {
+		return boolean, nil
+	}
+
+	text, err := decodeString(value)
+	if err == nil {
+		switch strings.ToLower(text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+
+	return false, errInvalidValue("The value must be the boolean.")
+}
+
+func errInvalidPath(detail string) *scimError {
+	return &scimError{status: http.StatusBadRequest, scimType: scimTypeInvalidPath, detail: detail}
+}
+
+func errInvalidValue(detail string) *scimError {
+	return &scimError{status: http.StatusBadRequest, scimType: scimTypeInvalidValue, detail: detail}
+}
+
+func errInvalidSyntax(detail string) *scimError {
+	return &scimError{status: http.StatusBadRequest, scimType: scimTypeInvalidSyntax, detail: detail}
+}
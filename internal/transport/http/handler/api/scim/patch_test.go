@@ -0,0 +1,181 @@
+package scim
+
+import (
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestApplyUserPatch(t *testing.T) {
+	active, inactive := true, false
+
+	testTable := []struct {
+		name             string
+		operations       string
+		expectedResource *userResource
+		expectedError    string
+	}{
+		{
+			name:       "OK, deactivate",
+			operations: `[{"op":"Replace","path":"active","value":false}]`,
+			expectedResource: &userResource{
+				UserName: "alice",
+				Emails:   []multiValue{{Value: "alice@mail.com", Type: "work", Primary: true}},
+				Locale:   "en",
+				Active:   &inactive,
+			},
+		},
+		{
+			name:       "OK, deactivate by the string and the operation without the path",
+			operations: `[{"op":"replace","value":{"active":"False","displayName":"Alice"}}]`,
+			expectedResource: &userResource{
+				UserName:    "alice",
+				DisplayName: "Alice",
+				Emails:      []multiValue{{Value: "alice@mail.com", Type: "work", Primary: true}},
+				Locale:      "en",
+				Active:      &inactive,
+			},
+		},
+		{
+			name:       "OK, replace email by the filtered path and remove locale",
+			operations: `[{"op":"replace","path":"emails[type eq \"work\"].value","value":"bob@mail.com"},{"op":"remove","path":"locale"}]`,
+			expectedResource: &userResource{
+				UserName: "alice",
+				Emails:   []multiValue{{Value: "bob@mail.com", Type: "work", Primary: true}},
+				Active:   &active,
+			},
+		},
+		{
+			name:       "OK, set name's part",
+			operations: `[{"op":"add","path":"name.givenName","value":"Alice"}]`,
+			expectedResource: &userResource{
+				UserName: "alice",
+				Name:     &name{GivenName: "Alice"},
+				Emails:   []multiValue{{Value: "alice@mail.com", Type: "work", Primary: true}},
+				Locale:   "en",
+				Active:   &active,
+			},
+		},
+		{
+			name:          "BAD, remove username",
+			operations:    `[{"op":"remove","path":"userName"}]`,
+			expectedError: "The attribute is required or can't be removed: username",
+		},
+		{
+			name:          "BAD, unsupported attribute",
+			operations:    `[{"op":"add","path":"title","value":"Engineer"}]`,
+			expectedError: "The attribute isn't supported: title",
+		},
+		{
+			name:          "BAD, active isn't boolean",
+			operations:    `[{"op":"replace","path":"active","value":"maybe"}]`,
+			expectedError: "The value must be the boolean.",
+		},
+		{
+			name:          "BAD, unknown operation",
+			operations:    `[{"op":"move","path":"active","value":true}]`,
+			expectedError: "The patch operation must be add, replace or remove.",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Arrange
+			var operations []patchOperation
+			if err := json.Unmarshal([]byte(testCase.operations), &operations); err != nil {
+				t.Fatal(err)
+			}
+
+			resource := &userResource{
+				UserName: "alice",
+				Emails:   []multiValue{{Value: "alice@mail.com", Type: "work", Primary: true}},
+				Locale:   "en",
+				Active:   &active,
+			}
+
+			//// Act
+			err := applyUserPatch(resource, operations)
+
+			//// Assert
+			if testCase.expectedError != "" {
+				assert.Equal(t, err.Error(), testCase.expectedError)
+				return
+			}
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, resource, testCase.expectedResource)
+		})
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	testTable := []struct {
+		name            string
+		operations      string
+		expectedMembers []reference
+		expectedError   string
+	}{
+		{
+			name:            "OK, add members",
+			operations:      `[{"op":"add","path":"members","value":[{"value":"2"},{"value":"3"}]}]`,
+			expectedMembers: []reference{{Value: "1"}, {Value: "2"}, {Value: "3"}},
+		},
+		{
+			name:            "OK, remove member by the filtered path",
+			operations:      `[{"op":"remove","path":"members[value eq \"1\"]"}]`,
+			expectedMembers: []reference{{Value: "2"}},
+		},
+		{
+			name:            "OK, remove members by the value",
+			operations:      `[{"op":"remove","path":"members","value":[{"value":"2"}]}]`,
+			expectedMembers: []reference{{Value: "1"}},
+		},
+		{
+			name:            "OK, remove all members",
+			operations:      `[{"op":"remove","path":"members"}]`,
+			expectedMembers: nil,
+		},
+		{
+			name:            "OK, replace members",
+			operations:      `[{"op":"replace","path":"members","value":[{"value":"5"}]}]`,
+			expectedMembers: []reference{{Value: "5"}},
+		},
+		{
+			name:          "BAD, remove display name",
+			operations:    `[{"op":"remove","path":"displayName"}]`,
+			expectedError: "The group's name is required.",
+		},
+		{
+			name:          "BAD, add member by the filtered path",
+			operations:    `[{"op":"add","path":"members[value eq \"1\"]","value":[{"value":"1"}]}]`,
+			expectedError: "The member's path must be removed by its value.",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Arrange
+			var operations []patchOperation
+			if err := json.Unmarshal([]byte(testCase.operations), &operations); err != nil {
+				t.Fatal(err)
+			}
+
+			resource := &groupResource{
+				DisplayName: "Engineering",
+				Members:     []reference{{Value: "1"}, {Value: "2"}},
+			}
+
+			//// Act
+			err := applyGroupPatch(resource, operations)
+
+			//// Assert
+			if testCase.expectedError != "" {
+				assert.Equal(t, err.Error(), testCase.expectedError)
+				return
+			}
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, resource.Members, testCase.expectedMembers)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package scim
+
+import (
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/pkg/convert_to"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schemas of the resources and messages.
+// SRC: https://www.rfc-editor.org/rfc/rfc7643#section-8.7
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	schemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Resource types.
+const (
+	resourceTypeUser  = "User"
+	resourceTypeGroup = "Group"
+)
+
+type meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	// Version is the weak ETag of the resource.
+	Version string `json:"version,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+// multiValue is the value of the multi-valued attribute, e.g. the email.
+type multiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// reference is the group of the user or the member of the group.
+type reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// userResource is the User resource. The user has one email, the primary one of the emails.
+// SRC: https://www.rfc-editor.org/rfc/rfc7643#section-4.1
+type userResource struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id,omitempty"`
+	ExternalId  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []multiValue `json:"emails,omitempty"`
+	Locale      string       `json:"locale,omitempty"`
+	// Active is omitted to keep the user's state, the new user is active.
+	Active *bool `json:"active,omitempty"`
+	// Password is write-only.
+	Password string      `json:"password,omitempty"`
+	Groups   []reference `json:"groups,omitempty"`
+	Meta     *meta       `json:"meta,omitempty"`
+}
+
+// groupResource is the Group resource, the members are users.
+// SRC: https://www.rfc-editor.org/rfc/rfc7643#section-4.2
+type groupResource struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id,omitempty"`
+	ExternalId  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []reference `json:"members,omitempty"`
+	Meta        *meta       `json:"meta,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// newUserResource returns the user's resource, the locations are built on the base URL of the SCIM API.
+func newUserResource(scimUser *service.SCIMUser, baseURL string) *userResource {
+	user := scimUser.User
+	active := !user.IsSuspended(time.Now())
+	resource := &userResource{
+		Schemas:     []string{schemaUser},
+		Id:          convert_to.ToString(user.Id),
+		ExternalId:  user.ExternalId,
+		UserName:    user.Username,
+		DisplayName: user.DisplayName,
+		Emails:      []multiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Locale:      user.Locale,
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: resourceTypeUser,
+			Created:      &user.DateRegistration,
+			Location:     baseURL + pathUsers + "/" + convert_to.ToString(user.Id),
+			Version:      etag(user.Version),
+		},
+	}
+
+	for _, group := range scimUser.Groups {
+		resource.Groups = append(resource.Groups, reference{
+			Value:   convert_to.ToString(group.Id),
+			Ref:     baseURL + pathGroups + "/" + convert_to.ToString(group.Id),
+			Display: group.DisplayName,
+		})
+	}
+
+	return resource
+}
+
+// input returns the user's state. The email is the primary one or the first one,
+// the display name is taken from the name if it's omitted.
+func (r *userResource) input() *service.SCIMUserInput {
+	input := &service.SCIMUserInput{
+		Username:    r.UserName,
+		DisplayName: r.DisplayName,
+		Locale:      r.Locale,
+		ExternalId:  r.ExternalId,
+		Password:    r.Password,
+		Active:      r.Active,
+	}
+
+	for i, email := range r.Emails {
+		if i == 0 || email.Primary {
+			input.Email = email.Value
+		}
+	}
+
+	if input.DisplayName == "" && r.Name != nil {
+		input.DisplayName = r.Name.Formatted
+		if input.DisplayName == "" {
+			input.DisplayName = strings.TrimSpace(r.Name.GivenName + " " + r.Name.FamilyName)
+		}
+	}
+
+	return input
+}
+
+// newGroupResource returns the group's resource, the members are omitted if they're excluded.
+func newGroupResource(group *domain.Group, baseURL string, excludeMembers bool) *groupResource {
+	resource := &groupResource{
+		Schemas:     []string{schemaGroup},
+		Id:          convert_to.ToString(group.Id),
+		ExternalId:  group.ExternalId,
+		DisplayName: group.DisplayName,
+		Meta: &meta{
+			ResourceType: resourceTypeGroup,
+			Created:      &group.DateCreated,
+			LastModified: &group.DateUpdated,
+			Location:     baseURL + pathGroups + "/" + convert_to.ToString(group.Id),
+			Version:      etag(group.Version),
+		},
+	}
+
+	if excludeMembers {
+		return resource
+	}
+
+	for _, member := range group.Members {
+		resource.Members = append(resource.Members, reference{
+			Value:   convert_to.ToString(member.UserId),
+			Ref:     baseURL + pathUsers + "/" + convert_to.ToString(member.UserId),
+			Display: member.Username,
+		})
+	}
+
+	return resource
+}
+
+// input returns the group's state, the member that isn't the user's id isn't found.
+func (r *groupResource) input() (*service.SCIMGroupInput, error) {
+	input := &service.SCIMGroupInput{
+		DisplayName: r.DisplayName,
+		ExternalId:  r.ExternalId,
+	}
+
+	for _, member := range r.Members {
+		userId, err := strconv.ParseUint(member.Value, 10, 32)
+		if err != nil {
+			return nil, service.ErrGroupMemberNotFound
+		}
+
+		input.MemberIds = append(input.MemberIds, uint32(userId))
+	}
+
+	return input, nil
+}
+
+// etag returns the weak entity tag of the resource's version.
+func etag(version uint32) string {
+	return `W/"` + convert_to.ToString(version) + `"`
+}
+
+// parseETag returns the version of the entity tag, it's zero for "*".
+func parseETag(tag string) (uint32, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "*" {
+		return 0, true
+	}
+
+	tag = strings.TrimPrefix(tag, "W/")
+	version, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 32)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+
+	return uint32(version), true
+}
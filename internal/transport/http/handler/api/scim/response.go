@@ -0,0 +1,139 @@
+package scim
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"strconv"
+)
+
+const contentType = "application/scim+json"
+
+// Error types of the bad requests and conflicts.
+// SRC: https://www.rfc-editor.org/rfc/rfc7644#section-3.12
+const (
+	scimTypeInvalidFilter = "invalidFilter"
+	scimTypeInvalidPath   = "invalidPath"
+	scimTypeInvalidValue  = "invalidValue"
+	scimTypeInvalidSyntax = "invalidSyntax"
+	scimTypeNoTarget      = "noTarget"
+	scimTypeUniqueness    = "uniqueness"
+)
+
+// scimError is the error of the request with its status and type.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+// respond writes the SCIM resource, the resource's version is the ETag.
+func respond(context *gin.Context, statusCode int, version string, resource interface{}) {
+	if version != "" {
+		context.Header("ETag", version)
+	}
+
+	// gin sets the JSON content type if it isn't set.
+	context.Header("Content-Type", contentType)
+	context.JSON(statusCode, resource)
+}
+
+// abortError responds with the SCIM error message.
+func abortError(context *gin.Context, handlerName string, err error) {
+	scimErr := new(scimError)
+	switch {
+	case errors.As(err, &scimErr):
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrGroupNotFound):
+		scimErr = &scimError{status: http.StatusNotFound, detail: err.Error()}
+	case errors.Is(err, service.ErrUserAlreadyExist), errors.Is(err, service.ErrUsernameTaken),
+		errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrGroupAlreadyExist):
+		scimErr = &scimError{status: http.StatusConflict, scimType: scimTypeUniqueness, detail: err.Error()}
+	case errors.Is(err, service.ErrUserVersionConflict), errors.Is(err, service.ErrGroupVersionConflict):
+		scimErr = &scimError{status: http.StatusPreconditionFailed, detail: err.Error()}
+	case errors.Is(err, service.ErrUsernameInvalid), errors.Is(err, service.ErrEmailInvalid),
+		errors.Is(err, service.ErrPasswordPolicy), errors.Is(err, service.ErrGroupNameInvalid),
+		errors.Is(err, service.ErrGroupMemberNotFound):
+		scimErr = &scimError{status: http.StatusBadRequest, scimType: scimTypeInvalidValue, detail: err.Error()}
+	default:
+		logger.Error(handlerName, logger.NamedError("error", err))
+		scimErr = &scimError{status: http.StatusInternalServerError, detail: "Internal server error."}
+	}
+
+	context.Header("Content-Type", contentType)
+	context.AbortWithStatusJSON(scimErr.status, &errorResponse{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(scimErr.status),
+		ScimType: scimErr.scimType,
+		Detail:   scimErr.detail,
+	})
+}
+
+// bindJSON binds the request's resource, the invalid JSON is the syntax error.
+func bindJSON(context *gin.Context, handlerName string, resource interface{}) bool {
+	if err := context.ShouldBindJSON(resource); err != nil {
+		abortError(context, handlerName, errInvalidSyntax("The request body is invalid: "+err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// getIdParam returns the resource's id from the path, the resource that isn't the number isn't found.
+func getIdParam(context *gin.Context, handlerName string, errNotFound error) (uint32, bool) {
+	id, err := strconv.ParseUint(context.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		abortError(context, handlerName, errNotFound)
+		return 0, false
+	}
+
+	return uint32(id), true
+}
+
+// getVersion returns the version of the If-Match header, it's zero if the header isn't set.
+func getVersion(context *gin.Context, handlerName string) (uint32, bool) {
+	tag := context.GetHeader("If-Match")
+	if tag == "" {
+		return 0, true
+	}
+
+	version, ok := parseETag(tag)
+	if !ok {
+		abortError(context, handlerName, &scimError{status: http.StatusPreconditionFailed, detail: "The If-Match header is invalid."})
+		return 0, false
+	}
+
+	return version, true
+}
+
+// getPage returns the 1-based start index and the page size of the query, the count is nil if it isn't set.
+func getPage(context *gin.Context, handlerName string) (int, *int, bool) {
+	startIndex := 1
+	if value := context.Query("startIndex"); value != "" {
+		index, err := strconv.Atoi(value)
+		if err != nil {
+			abortError(context, handlerName, errInvalidValue("The startIndex must be the number."))
+			return 0, nil, false
+		}
+
+		startIndex = index
+	}
+
+	var count *int
+	if value := context.Query("count"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			abortError(context, handlerName, errInvalidValue("The count must be the number."))
+			return 0, nil, false
+		}
+
+		count = &size
+	}
+
+	return startIndex, count, true
+}
@@ -0,0 +1,287 @@
+package scim
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+)
+
+// patchRetries is the number of the patch's attempts if the resource is changed by another request meanwhile.
+// The patch with the If-Match header isn't retried.
+const patchRetries = 3
+
+// usersList godoc
+// @Summary     List users
+// @Security 	ApiKeyAuth
+// @Description list the users, the deleted users aren't listed. The filter compares the userName, emails or externalId with the "eq" operator
+// @Description or the userName and emails with the "sw" operator, e.g. `userName eq "alice"`. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} listResponse{Resources=[]userResource}
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     500 {object} errorResponse
+// @Param filter     query string false "Filter"
+// @Param startIndex query int    false "1-based index of the first user"
+// @Param count      query int    false "Page size, up to 200"
+// @Router      /scim/v2/Users [get]
+func (h *HandlerSCIM) usersList(context *gin.Context) {
+	startIndex, count, ok := getPage(context, "usersList()")
+	if !ok {
+		return
+	}
+
+	input := &service.SCIMUserListInput{StartIndex: startIndex, Count: count}
+	expression, err := parseFilter(context.Query("filter"))
+	if err != nil {
+		abortError(context, "usersList()", err)
+		return
+	}
+
+	if expression != nil {
+		var field *string
+		switch {
+		case expression.Attribute == "username" && expression.Operator == operatorEqual:
+			field = &input.Username
+		case expression.Attribute == "username":
+			field = &input.UsernamePrefix
+		case (expression.Attribute == "emails" || expression.Attribute == "emails.value") && expression.Operator == operatorEqual:
+			field = &input.Email
+		case expression.Attribute == "emails" || expression.Attribute == "emails.value":
+			field = &input.EmailPrefix
+		case expression.Attribute == "externalid" && expression.Operator == operatorEqual:
+			field = &input.ExternalId
+		default:
+			abortError(context, "usersList()", errInvalidFilter("The users are filtered by the userName, emails or externalId."))
+			return
+		}
+
+		*field = expression.Value
+	}
+
+	page, err := h.services.SCIM.ListUsers(context, input)
+	if err != nil {
+		abortError(context, "usersList()", err)
+		return
+	}
+
+	resources := make([]*userResource, 0, len(page.Users))
+	for i := range page.Users {
+		resources = append(resources, newUserResource(&page.Users[i], h.baseURL()))
+	}
+
+	respond(context, http.StatusOK, "", &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: page.Total,
+		StartIndex:   page.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// userGet godoc
+// @Summary     Get user
+// @Security 	ApiKeyAuth
+// @Description get the user with the user's groups. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     200 {object} userResource
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id    path int true "User ID"
+// @Router      /scim/v2/Users/{id} [get]
+func (h *HandlerSCIM) userGet(context *gin.Context) {
+	userId, ok := getIdParam(context, "userGet()", service.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	user, err := h.services.SCIM.GetUser(context, userId)
+	if err != nil {
+		abortError(context, "userGet()", err)
+		return
+	}
+
+	resource := newUserResource(user, h.baseURL())
+	respond(context, http.StatusOK, resource.Meta.Version, resource)
+}
+
+// userPost godoc
+// @Summary     Create user
+// @Security 	ApiKeyAuth
+// @Description provision the user, the user without the password signs in with the identity provider or the directory.
+// @Description The inactive user is suspended. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} userResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     409 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param input body userResource true "User"
+// @Router      /scim/v2/Users [post]
+func (h *HandlerSCIM) userPost(context *gin.Context) {
+	var resource userResource
+	if !bindJSON(context, "userPost()", &resource) {
+		return
+	}
+
+	user, err := h.services.SCIM.CreateUser(context, clientId(context), resource.input())
+	if err != nil {
+		abortError(context, "userPost()", err)
+		return
+	}
+
+	created := newUserResource(user, h.baseURL())
+	context.Header("Location", created.Meta.Location)
+	respond(context, http.StatusCreated, created.Meta.Version, created)
+}
+
+// userPut godoc
+// @Summary     Replace user
+// @Security 	ApiKeyAuth
+// @Description replace the user's attributes, the omitted password isn't changed. The deactivated user is suspended and signed out everywhere.
+// @Description The If-Match header rejects the replacement of the changed user. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} userResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     409 {object} errorResponse
+// @Failure     412 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id       path   int          true  "User ID"
+// @Param If-Match header string       false "User's ETag"
+// @Param input    body   userResource true  "User"
+// @Router      /scim/v2/Users/{id} [put]
+func (h *HandlerSCIM) userPut(context *gin.Context) {
+	userId, ok := getIdParam(context, "userPut()", service.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	version, ok := getVersion(context, "userPut()")
+	if !ok {
+		return
+	}
+
+	var resource userResource
+	if !bindJSON(context, "userPut()", &resource) {
+		return
+	}
+
+	user, err := h.services.SCIM.ReplaceUser(context, clientId(context), userId, version, resource.input())
+	if err != nil {
+		abortError(context, "userPut()", err)
+		return
+	}
+
+	replaced := newUserResource(user, h.baseURL())
+	respond(context, http.StatusOK, replaced.Meta.Version, replaced)
+}
+
+// userPatch godoc
+// @Summary     Patch user
+// @Security 	ApiKeyAuth
+// @Description change the user's attributes by the patch operations, e.g. `{"op": "replace", "path": "active", "value": false}` deactivates the user.
+// @Description The If-Match header rejects the patch of the changed user. The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} userResource
+// @Failure     400 {object} errorResponse
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     409 {object} errorResponse
+// @Failure     412 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id       path   int          true  "User ID"
+// @Param If-Match header string       false "User's ETag"
+// @Param input    body   patchRequest true  "Patch operations"
+// @Router      /scim/v2/Users/{id} [patch]
+func (h *HandlerSCIM) userPatch(context *gin.Context) {
+	userId, ok := getIdParam(context, "userPatch()", service.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	version, ok := getVersion(context, "userPatch()")
+	if !ok {
+		return
+	}
+
+	var patch patchRequest
+	if !bindJSON(context, "userPatch()", &patch) {
+		return
+	}
+
+	// The patch is applied to the current user and replaces it if the user wasn't changed meanwhile.
+	var user *service.SCIMUser
+	var err error
+	for attempt := 0; attempt < patchRetries; attempt++ {
+		if user, err = h.services.SCIM.GetUser(context, userId); err != nil {
+			break
+		}
+
+		if version != 0 && user.User.Version != version {
+			err = service.ErrUserVersionConflict
+			break
+		}
+
+		resource := newUserResource(user, h.baseURL())
+		if err = applyUserPatch(resource, patch.Operations); err != nil {
+			break
+		}
+
+		user, err = h.services.SCIM.ReplaceUser(context, clientId(context), userId, user.User.Version, resource.input())
+		if version != 0 || !errors.Is(err, service.ErrUserVersionConflict) {
+			break
+		}
+	}
+
+	if err != nil {
+		abortError(context, "userPatch()", err)
+		return
+	}
+
+	patched := newUserResource(user, h.baseURL())
+	respond(context, http.StatusOK, patched.Meta.Version, patched)
+}
+
+// userDelete godoc
+// @Summary     Delete user
+// @Security 	ApiKeyAuth
+// @Description delete the user and end all the user's sessions, the user is erased after the grace period.
+// @Description The action is written to the audit trail. Requires the "scim" scope.
+// @Tags        scim
+// @Produce     json
+// @Success     204
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} errorResponse
+// @Failure     500 {object} errorResponse
+// @Param id    path int true "User ID"
+// @Router      /scim/v2/Users/{id} [delete]
+func (h *HandlerSCIM) userDelete(context *gin.Context) {
+	userId, ok := getIdParam(context, "userDelete()", service.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SCIM.DeleteUser(context, clientId(context), userId); err != nil {
+		abortError(context, "userDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
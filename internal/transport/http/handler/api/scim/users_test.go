@@ -0,0 +1,211 @@
+package scim
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http/httptest"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+type mockBehaviorSCIM func(mockSCIM *mock_service.MockSCIM)
+
+type TestTableSCIM struct {
+	name               string
+	method             string
+	target             string
+	header             map[string]string
+	requestBody        string
+	mockBehaviorSCIM   mockBehaviorSCIM
+	expectedStatusCode int
+	expectedHeader     map[string]string
+	expectedBody       string
+}
+
+func TestHandlerSCIM_users(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registered := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := func(version uint32) *service.SCIMUser {
+		return &service.SCIMUser{
+			User: &domain.User{
+				Id:               7,
+				Username:         "alice",
+				Email:            "alice@mail.com",
+				ExternalId:       "e-7",
+				DateRegistration: registered,
+				Version:          version,
+			},
+			Groups: []domain.Group{{Id: 3, DisplayName: "Engineering"}},
+		}
+	}
+
+	aliceBody := func(version string, active bool) string {
+		state := "true"
+		if !active {
+			state = "false"
+		}
+
+		return `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"id":"7","externalId":"e-7","userName":"alice",` +
+			`"emails":[{"value":"alice@mail.com","type":"work","primary":true}],"active":` + state + `,` +
+			`"groups":[{"value":"3","$ref":"https://account.localhost/scim/v2/Groups/3","display":"Engineering"}],` +
+			`"meta":{"resourceType":"User","created":"2023-01-02T03:04:05Z","location":"https://account.localhost/scim/v2/Users/7","version":"W/\"` + version + `\""}}`
+	}
+
+	testTable := []TestTableSCIM{
+		{
+			name:   "OK, list users by username",
+			method: "GET",
+			target: `/scim/v2/Users?filter=userName+eq+%22alice%22&startIndex=1&count=10`,
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				count := 10
+				mockSCIM.EXPECT().ListUsers(gomock.Any(), &service.SCIMUserListInput{Username: "alice", StartIndex: 1, Count: &count}).Return(&service.SCIMUserPage{
+					Users:      []service.SCIMUser{*user(1)},
+					Total:      1,
+					StartIndex: 1,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedHeader:     map[string]string{"Content-Type": contentType},
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:ListResponse"],"totalResults":1,"startIndex":1,"itemsPerPage":1,"Resources":[` +
+				aliceBody("1", true) + `]}`,
+		},
+		{
+			name:   "BAD, unsupported filter",
+			method: "GET",
+			target: `/scim/v2/Users?filter=title+eq+%22Engineer%22`,
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				// Nothing
+			},
+			expectedStatusCode: 400,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"400","scimType":"invalidFilter",` +
+				`"detail":"The users are filtered by the userName, emails or externalId."}`,
+		},
+		{
+			name:   "OK, get user",
+			method: "GET",
+			target: "/scim/v2/Users/7",
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().GetUser(gomock.Any(), uint32(7)).Return(user(2), nil)
+			},
+			expectedStatusCode: 200,
+			expectedHeader:     map[string]string{"ETag": `W/"2"`},
+			expectedBody:       aliceBody("2", true),
+		},
+		{
+			name:   "BAD, user not found",
+			method: "GET",
+			target: "/scim/v2/Users/8",
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().GetUser(gomock.Any(), uint32(8)).Return(nil, service.ErrUserNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedBody:       `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"404","detail":"UserRepositoryGorm not found"}`,
+		},
+		{
+			name:        "BAD, create taken user",
+			method:      "POST",
+			target:      "/scim/v2/Users",
+			requestBody: `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"alice","name":{"givenName":"Alice","familyName":"Smith"},"emails":[{"value":"alice@mail.com","primary":true}]}`,
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().CreateUser(gomock.Any(), "", &service.SCIMUserInput{
+					Username:    "alice",
+					Email:       "alice@mail.com",
+					DisplayName: "Alice Smith",
+				}).Return(nil, service.ErrUsernameTaken)
+			},
+			expectedStatusCode: 409,
+			expectedBody:       `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"409","scimType":"uniqueness","detail":"Username is already taken"}`,
+		},
+		{
+			name:        "OK, deactivate user",
+			method:      "PATCH",
+			target:      "/scim/v2/Users/7",
+			header:      map[string]string{"If-Match": `W/"2"`},
+			requestBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"replace","path":"active","value":false}]}`,
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				suspended := user(3)
+				suspended.User.DateSuspended = &registered
+				inactive := false
+				mockSCIM.EXPECT().GetUser(gomock.Any(), uint32(7)).Return(user(2), nil)
+				mockSCIM.EXPECT().ReplaceUser(gomock.Any(), "", uint32(7), uint32(2), &service.SCIMUserInput{
+					Username:   "alice",
+					Email:      "alice@mail.com",
+					ExternalId: "e-7",
+					Active:     &inactive,
+				}).Return(suspended, nil)
+			},
+			expectedStatusCode: 200,
+			expectedHeader:     map[string]string{"ETag": `W/"3"`},
+			expectedBody:       aliceBody("3", false),
+		},
+		{
+			name:        "BAD, patch changed user",
+			method:      "PATCH",
+			target:      "/scim/v2/Users/7",
+			header:      map[string]string{"If-Match": `W/"1"`},
+			requestBody: `{"Operations":[{"op":"replace","path":"active","value":false}]}`,
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().GetUser(gomock.Any(), uint32(7)).Return(user(2), nil)
+			},
+			expectedStatusCode: 412,
+			expectedBody:       `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"412","detail":"User was changed by another request"}`,
+		},
+		{
+			name:   "OK, delete user",
+			method: "DELETE",
+			target: "/scim/v2/Users/7",
+			mockBehaviorSCIM: func(mockSCIM *mock_service.MockSCIM) {
+				mockSCIM.EXPECT().DeleteUser(gomock.Any(), "", uint32(7)).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockSCIM := mock_service.NewMockSCIM(ctrl)
+			testCase.mockBehaviorSCIM(mockSCIM)
+			handler := NewHandlerSCIM(&service.Services{
+				SCIM:   mockSCIM,
+				Config: &config.Config{OAuth2: config.OAuth2Config{RedirectURL: "https://account.localhost"}},
+			})
+
+			// Init Endpoint without the authentication.
+			r := gin.New()
+			r.GET(pathSCIM+pathUsers, handler.usersList)
+			r.POST(pathSCIM+pathUsers, handler.userPost)
+			r.GET(pathSCIM+pathUsers+"/:id", handler.userGet)
+			r.PATCH(pathSCIM+pathUsers+"/:id", handler.userPatch)
+			r.DELETE(pathSCIM+pathUsers+"/:id", handler.userDelete)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, bytes.NewBufferString(testCase.requestBody))
+			req.Header.Add("Content-Type", contentType)
+			for key, value := range testCase.header {
+				req.Header.Add(key, value)
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedBody)
+			for key, value := range testCase.expectedHeader {
+				assert.Equal(t, w.Header().Get(key), value)
+			}
+		})
+	}
+}
@@ -2,13 +2,59 @@ package v1
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"service-account/internal/domain"
 	"service-account/internal/repository"
-	"service-account/internal/transport/http/coockie"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+	"service-account/pkg/logger"
 	"strconv"
+	"strings"
+	"time"
 )
 
+type userPatchInput struct {
+	// Username is normalized and validated by the service, like at the sign-up.
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name" binding:"omitempty,max=255"`
+	Locale      *string `json:"locale" binding:"omitempty,bcp47_language_tag"`
+}
+
+type userEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required_without=Code"`
+	// Code is the one-time code sent to the email of the user without the password.
+	Code string `json:"code"`
+}
+
+type userDeleteInput struct {
+	Password string `json:"password" binding:"required_without=Code"`
+	// Code is the one-time code sent to the email of the user without the password.
+	Code string `json:"code"`
+}
+
+type usersListInput struct {
+	Email          string     `form:"email" json:"email" binding:"max=255"`
+	Username       string     `form:"username" json:"username" binding:"max=32"`
+	RegisteredFrom *time.Time `form:"registered_from" json:"registered_from"`
+	RegisteredTo   *time.Time `form:"registered_to" json:"registered_to"`
+	LastOnlineTo   *time.Time `form:"last_online_to" json:"last_online_to"`
+	Status         string     `form:"status" json:"status" binding:"omitempty,oneof=active suspended deleted"`
+	Sort           string     `form:"sort" json:"sort" binding:"omitempty,oneof=id -id username -username email -email date_registration -date_registration date_last_online -date_last_online"`
+	Limit          int        `form:"limit" json:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor         string     `form:"cursor" json:"cursor"`
+}
+
+type userPasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required_without=Code"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	// Code is the one-time code sent to the email of the user without the password.
+	Code                string `json:"code"`
+	RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+}
+
 func (HandlerAccountManagementAPI) convertStringToUserId(idStr string) (uint32, error) {
 	userId64, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -21,112 +67,613 @@ func (HandlerAccountManagementAPI) convertStringToUserId(idStr string) (uint32,
 	return userId, nil
 }
 
+// getUserIdParam returns the user id from the path, responds with an error if it's not valid.
+func (h *HandlerAccountManagementAPI) getUserIdParam(context *gin.Context) (uint32, bool) {
+	// Get user id.
+	userIdStr := context.Param("id")
+	if userIdStr == "" {
+		context.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "Expected a user id to be set but received none.",
+		})
+		return 0, false
+	}
+
+	// Convert string to id.
+	userId, err := h.convertStringToUserId(userIdStr)
+	if err != nil {
+		context.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "User id bad format.",
+		})
+		return 0, false
+	}
+
+	return userId, true
+}
+
+// usersList godoc
+// @Summary     List users
+// @Security 	ApiKeyAuth
+// @Description list the users page by page. Requires the "users:admin" scope and the "users:list" permission.
+// @Description The filters are combined, "email" and "username" match the prefix. The next page is read by passing "next_cursor" with the same filters and sort, it's empty on the last page.
+// @Tags        user
+// @Produce     json
+// @Success     200 {object} object{users=[]object{id=uint32,username=string,email=string,display_name=string,locale=string,version=uint32,date_registration=time.Time,date_last_online=time.Time,status=string,suspension_reason=string,date_suspended_until=time.Time,date_locked_until=time.Time},next_cursor=string}
+// @Header      200 {integer} X-Total-Count "Number of the users matching the filters"
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     500 {object} object{error=string}
+// @Param email           query string false "Email prefix"
+// @Param username        query string false "Username prefix"
+// @Param registered_from query string false "Registered at or after, RFC 3339"
+// @Param registered_to   query string false "Registered before, RFC 3339"
+// @Param last_online_to  query string false "Last online before, RFC 3339"
+// @Param status          query string false "Status" Enums(active, suspended, deleted)
+// @Param sort            query string false "Sort field, the minus prefix sorts in the descending order" Enums(id, -id, username, -username, email, -email, date_registration, -date_registration, date_last_online, -date_last_online)
+// @Param limit           query int    false "Page size" minimum(1) maximum(200) default(50)
+// @Param cursor          query string false "Cursor of the next page"
+// @Router      /api/v1/users [get]
+func (h *HandlerAccountManagementAPI) usersList(context *gin.Context) {
+	var input usersListInput
+	if err := context.ShouldBindQuery(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	page, err := h.services.User.List(context, &service.UserListInput{
+		EmailPrefix:      input.Email,
+		UsernamePrefix:   input.Username,
+		RegisteredFrom:   input.RegisteredFrom,
+		RegisteredTo:     input.RegisteredTo,
+		LastOnlineBefore: input.LastOnlineTo,
+		Status:           input.Status,
+		Sort:             input.Sort,
+		Limit:            input.Limit,
+		Cursor:           input.Cursor,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	users := make([]gin.H, 0, len(page.Users))
+	for i := range page.Users {
+		users = append(users, adminUserResponse(&page.Users[i]))
+	}
+
+	context.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"users":       users,
+		"next_cursor": page.NextCursor,
+	})
+}
+
 // userGet godoc
 // @Summary     Get user info
 // @Security 	ApiKeyAuth
-// @Description get user by ID
+// @Description get user by ID. Requires the "users:read" scope, reading other users' records requires the "users:admin" scope and the administrator role.
 // @Tags        user
 // @Produce     json
-// @Success     200 {object} object{user=object{id=uint32,username=string,email=string,date_registration=time.Time,date_last_online=time.Time}}
+// @Success     200 {object} object{user=object{id=uint32,username=string,email=string,display_name=string,locale=string,version=uint32,date_registration=time.Time,date_last_online=time.Time}}
+// @Header      200 {string} ETag "User version"
 // @Failure     400 {object} object{error=string}
 // @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
 // @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
 // @Failure     500 {object} object{error=string}
-// @Param id   path int true "UserRepositoryGorm ID"
+// @Param id   path int true "User ID"
 // @Router      /api/v1/users/{id} [get]
 func (h *HandlerAccountManagementAPI) userGet(context *gin.Context) {
-	// Get user id.
-	userIdStr := context.Param("id")
-	if userIdStr == "" {
-		context.IndentedJSON(http.StatusBadRequest, gin.H{
-			"error": "Expected a user id to be set but received none.",
-		})
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
 		return
 	}
 
-	// Convert string to id.
-	userId, err := h.convertStringToUserId(userIdStr)
+	if !h.authorizeUserAccess(context, userId, domain.PermissionUsersRead) {
+		return
+	}
+
+	// Get user data.
+	user, err := h.services.User.GetUserById(context, userId)
 	if err != nil {
+		var errorMessage string
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			errorMessage = "UserRepositoryGorm not found."
+		} else {
+			errorMessage = err.Error()
+		}
+
 		context.IndentedJSON(http.StatusBadRequest, gin.H{
-			"error": "UserRepositoryGorm id bad format.",
+			"error": errorMessage,
 		})
+		return
 	}
 
-	// Get access_token from cookie.
-	accessToken, _ := coockie.GetValue(context.Request, "access_token")
-	if accessToken == "" {
-		context.IndentedJSON(http.StatusUnauthorized, gin.H{
-			"error": "Access Token is not present.",
+	// Send success response.
+	context.Header("ETag", userETag(user))
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"user": userResponse(user),
+	})
+}
+
+// userPatch godoc
+// @Summary     Update user profile
+// @Security 	ApiKeyAuth
+// @Description update the user's username and profile fields, the absent fields aren't changed. Requires the "users:write" scope, updating other users' records requires the "users:admin" scope and the administrator role.
+// @Description Send the ETag of the read user in the "If-Match" header to reject the update if the user was changed since.
+// @Tags        user
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object{user=object{id=uint32,username=string,email=string,display_name=string,locale=string,version=uint32,date_registration=time.Time,date_last_online=time.Time}}
+// @Header      200 {string} ETag "User version"
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     412 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id       path   int            true  "User ID"
+// @Param If-Match header string         false "ETag of the user"
+// @Param input    body   userPatchInput true  "Changed fields"
+// @Router      /api/v1/users/{id} [patch]
+func (h *HandlerAccountManagementAPI) userPatch(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeUserAccess(context, userId, domain.PermissionUsersWrite) {
+		return
+	}
+
+	version, ok := parseIfMatch(context.GetHeader("If-Match"))
+	if !ok {
+		context.IndentedJSON(http.StatusPreconditionFailed, gin.H{
+			"error": "If-Match header is malformed.",
 		})
 		return
 	}
 
-	// Get introspect token request.
-	tokenIntrospection, err := h.services.OAuth2.IntrospectOAuth2Token(context, accessToken)
+	var input userPatchInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	user, err := h.services.User.Update(context, userId, version, &service.UserUpdateInput{
+		Username:    input.Username,
+		DisplayName: input.DisplayName,
+		Locale:      input.Locale,
+	})
 	if err != nil {
-		// Error request to hydra OAuth admin API.
-		context.IndentedJSON(http.StatusInternalServerError, gin.H{
+		var statusCode int
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrUserAlreadyExist):
+			statusCode = http.StatusConflict
+		case errors.Is(err, service.ErrUsernameInvalid):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, service.ErrUserVersionConflict):
+			statusCode = http.StatusPreconditionFailed
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	// Check authorization.
-	isAuth := tokenIntrospection.Active
-	if !isAuth {
-		context.IndentedJSON(http.StatusUnauthorized, gin.H{
-			"error": "Access Token is not active.",
+	// Send success response.
+	context.Header("ETag", userETag(user))
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"user": userResponse(user),
+	})
+}
+
+// userPasswordPost godoc
+// @Summary     Change user password
+// @Security 	ApiKeyAuth
+// @Description change the user's password, the current password is required. Requires the "users:write" scope, only the user can change the own password.
+// @Description The user without the password sets one with the "code" sent by POST /api/v1/users/{id}/verification-code instead.
+// @Description The failed checks of the current password are counted as the failed sign-ins, the locked out user gets 403.
+// @Description Set "revoke_other_sessions" to sign the user out of the other sessions and revoke the tokens issued to the other clients.
+// @Tags        user
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int               true "User ID"
+// @Param input body userPasswordInput true "Passwords"
+// @Router      /api/v1/users/{id}/password [post]
+func (h *HandlerAccountManagementAPI) userPasswordPost(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	// Even the administrators can't change other users' passwords, the current password is required.
+	if !authorizeOwner(context, userId) {
+		return
+	}
+
+	var input userPasswordInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	err := h.services.Account.ChangePassword(context, userId, &service.ChangePasswordInput{
+		CurrentPassword:     input.CurrentPassword,
+		NewPassword:         input.NewPassword,
+		RevokeOtherSessions: input.RevokeOtherSessions,
+		SessionId:           middleware.GetSessionId(context),
+		Code:                input.Code,
+	})
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		var statusCode int
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrUserLocked) || isVerificationError(err):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrPasswordPolicy):
+			statusCode = http.StatusBadRequest
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	if tokenIntrospection.Sub == nil {
-		context.IndentedJSON(http.StatusUnauthorized, gin.H{
-			"error": "The token's subject user id is in the wrong format.",
+	if err != nil {
+		// The password was changed anyway.
+		logger.Error("userPasswordPost() - notification", logger.NamedError("error", err))
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// userVerificationCodePost godoc
+// @Summary     Send verification code
+// @Security 	ApiKeyAuth
+// @Description send the one-time code to the user's email, the user without the password confirms the account's changes with it. Requires the "users:write" scope, only the user can request the code.
+// @Tags        user
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int true "User ID"
+// @Router      /api/v1/users/{id}/verification-code [post]
+func (h *HandlerAccountManagementAPI) userVerificationCodePost(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !authorizeOwner(context, userId) {
+		return
+	}
+
+	if err := h.services.Account.SendVerificationCode(context, userId); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUserNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	tokenUserId, err := h.convertStringToUserId(*tokenIntrospection.Sub)
+	context.Status(http.StatusNoContent)
+}
+
+// userEmailPost godoc
+// @Summary     Change user email
+// @Security 	ApiKeyAuth
+// @Description request the user's email change, the current password is required. Requires the "users:write" scope, only the user can change the own email.
+// @Description The user without the password confirms the change with the "code" sent by POST /api/v1/users/{id}/verification-code instead.
+// @Description The failed checks of the current password are counted as the failed sign-ins, the locked out user gets 403.
+// @Description The confirmation link is sent to the new email, the email is changed when the link is followed. The old email gets the link to revert the change.
+// @Tags        user
+// @Accept      json
+// @Produce     json
+// @Success     202
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int            true "User ID"
+// @Param input body userEmailInput true "New email"
+// @Router      /api/v1/users/{id}/email [post]
+func (h *HandlerAccountManagementAPI) userEmailPost(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !authorizeOwner(context, userId) {
+		return
+	}
+
+	var input userEmailInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	err := h.services.Account.RequestEmailChange(context, userId, &service.EmailChangeInput{
+		Password: input.Password,
+		NewEmail: input.NewEmail,
+		Code:     input.Code,
+	})
 	if err != nil {
-		context.IndentedJSON(http.StatusBadRequest, gin.H{
-			"error": "The token's subject user id is in the bad format.",
+		var statusCode int
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrUserLocked) || isVerificationError(err):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrEmailTaken):
+			statusCode = http.StatusConflict
+		case errors.Is(err, service.ErrEmailInvalid):
+			statusCode = http.StatusBadRequest
+		default:
+			// The confirmation link wasn't sent either.
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
+		return
 	}
 
-	// Check if the user is the same user for whom we want to get information.
-	// TODO: Or the user has administrator privileges.
-	if userId != tokenUserId {
-		context.IndentedJSON(http.StatusForbidden, gin.H{
-			"error": "No permission.",
+	context.Status(http.StatusAccepted)
+}
+
+// userDelete godoc
+// @Summary     Delete user
+// @Security 	ApiKeyAuth
+// @Description delete the user's account, the current password is required. Requires the "users:write" scope, only the user can delete the own account.
+// @Description The user without the password confirms the deletion with the "code" sent by POST /api/v1/users/{id}/verification-code instead.
+// @Description The failed checks of the current password are counted as the failed sign-ins, the locked out user gets 403.
+// @Description All the user's sessions and consents are revoked. The account can be restored by the link sent to the user's email within the grace period, then it's erased.
+// @Tags        user
+// @Accept      json
+// @Produce     json
+// @Success     202 {object} object{date_purge=time.Time}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int             true "User ID"
+// @Param input body userDeleteInput true "Password or one-time code"
+// @Router      /api/v1/users/{id} [delete]
+func (h *HandlerAccountManagementAPI) userDelete(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !authorizeOwner(context, userId) {
+		return
+	}
+
+	var input userDeleteInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	datePurge, err := h.services.Account.DeleteAccount(context, userId, &service.DeleteAccountInput{
+		Password: input.Password,
+		Code:     input.Code,
+	})
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		var statusCode int
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrUserLocked) || isVerificationError(err):
+			statusCode = http.StatusForbidden
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Get user data.
-	user, err := h.services.User.GetUserById(context, userId)
 	if err != nil {
-		var errorMessage string
-		if errors.Is(err, repository.ErrRecordNotFound) {
-			errorMessage = "UserRepositoryGorm not found."
-		} else {
-			errorMessage = err.Error()
+		// The account was deleted anyway.
+		logger.Error("userDelete() - notification", logger.NamedError("error", err))
+	}
+
+	context.IndentedJSON(http.StatusAccepted, gin.H{
+		"date_purge": datePurge,
+	})
+}
+
+// userExportGet godoc
+// @Summary     Export user data
+// @Security 	ApiKeyAuth
+// @Description export everything the service holds about the user: the profile, roles, sessions, consents, email changes, devices, linked identities, group memberships and the user's audit events. Requires the "users:read" scope, exporting other users' data requires the "users:admin" scope and the administrator role.
+// @Tags        user
+// @Produce     json
+// @Success     200 {object} domain.AccountExport
+// @Header      200 {string} Content-Disposition "Attachment file name"
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id   path int true "User ID"
+// @Router      /api/v1/users/{id}/export [get]
+func (h *HandlerAccountManagementAPI) userExportGet(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeUserAccess(context, userId, domain.PermissionUsersRead) {
+		return
+	}
+
+	export, err := h.services.Account.Export(context, userId)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUserNotFound) {
+			statusCode = http.StatusNotFound
 		}
 
-		context.IndentedJSON(http.StatusBadRequest, gin.H{
-			"error": errorMessage,
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Send success response.
-	context.IndentedJSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":                user.Id,
-			"username":          user.Username,
-			"email":             user.Email,
-			"date_registration": user.DateRegistration,
-			"date_last_online":  user.DateLastOnline,
-		},
-	})
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d.json"`, userId))
+	context.IndentedJSON(http.StatusOK, export)
+}
+
+// isVerificationError reports whether the one-time code confirming the change is missing, incorrect or expired.
+func isVerificationError(err error) bool {
+	return errors.Is(err, service.ErrVerificationRequired) || errors.Is(err, service.ErrStepUpCodeIncorrect) ||
+		errors.Is(err, service.ErrVerificationExpired)
+}
+
+// authorizeOwner checks the token's subject is the user, the user's credentials are changed only by the user.
+func authorizeOwner(context *gin.Context, userId uint32) bool {
+	tokenUserId, ok := middleware.GetSubjectUserId(context)
+	if !ok || userId != tokenUserId {
+		context.IndentedJSON(http.StatusForbidden, gin.H{
+			"error": "Only the user can change the own credentials.",
+		})
+		return false
+	}
+
+	return true
+}
+
+// authorizeUserAccess checks the token's subject has the permission on the user's record.
+// Access to other users' records requires the administrator scope.
+func (h *HandlerAccountManagementAPI) authorizeUserAccess(context *gin.Context, userId uint32, permission string) bool {
+	// The token was introspected by the authentication middleware.
+	tokenIntrospection := middleware.GetTokenIntrospection(context)
+	tokenUserId, ok := middleware.GetSubjectUserId(context)
+	if !ok {
+		context.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "The token's subject user id is in the bad format.",
+		})
+		return false
+	}
+
+	if userId != tokenUserId && !tokenIntrospection.HasScopes(domain.ScopeUsersAdmin) {
+		middleware.AbortInsufficientScope(context, domain.ScopeUsersAdmin)
+		return false
+	}
+
+	// Check if the user is the same user for whom we want to get information or the user has administrator privileges.
+	allowed, err := h.services.RBAC.Can(context, tokenUserId, permission, domain.UserResource(userId))
+	if err != nil {
+		context.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	if !allowed {
+		context.IndentedJSON(http.StatusForbidden, gin.H{
+			"error": "No permission.",
+		})
+		return false
+	}
+
+	return true
+}
+
+func userResponse(user *domain.User) gin.H {
+	return gin.H{
+		"id":                user.Id,
+		"username":          user.Username,
+		"email":             user.Email,
+		"display_name":      user.DisplayName,
+		"locale":            user.Locale,
+		"version":           user.Version,
+		"date_registration": user.DateRegistration,
+		"date_last_online":  user.DateLastOnline,
+	}
+}
+
+// adminUserResponse is the user with the account's state seen by the administrators.
+func adminUserResponse(user *domain.User) gin.H {
+	response := userResponse(user)
+	response["status"] = user.Status()
+	response["suspension_reason"] = user.SuspensionReason
+	response["date_suspended_until"] = user.DateSuspendedUntil
+	response["date_locked_until"] = user.DateLockedUntil
+
+	return response
+}
+
+// userETag is the strong entity tag of the user's version.
+func userETag(user *domain.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// parseIfMatch returns the user's version of the "If-Match" header, zero if the header is absent or "*".
+func parseIfMatch(ifMatch string) (uint32, bool) {
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(ifMatch, `"`), 10, 32)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+
+	return uint32(version), true
 }
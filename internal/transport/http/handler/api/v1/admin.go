@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+	"service-account/pkg/logger"
+	"time"
+)
+
+type userSuspendInput struct {
+	Reason string     `json:"reason" binding:"required,max=1024"`
+	Until  *time.Time `json:"until"`
+}
+
+type adminActionInput struct {
+	Reason string `json:"reason" binding:"max=1024"`
+}
+
+// userSuspensionPost godoc
+// @Summary     Suspend user
+// @Security 	ApiKeyAuth
+// @Description suspend the user until the date or indefinitely without "until", the user can't sign in and all the user's sessions are ended. Requires the "users:admin" scope and the "users:manage" permission.
+// @Description Suspending the suspended user replaces the suspension. The action is written to the audit trail.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int              true "User ID"
+// @Param input body userSuspendInput true "Suspension"
+// @Router      /api/v1/users/{id}/suspension [post]
+func (h *HandlerAccountManagementAPI) userSuspensionPost(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	var input userSuspendInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if actorId == userId {
+		context.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "Administrators can't suspend themselves.",
+		})
+		return
+	}
+
+	err := h.services.Admin.SuspendUser(context, actorId, userId, input.Reason, input.Until)
+	abortAdminError(context, "userSuspensionPost()", err)
+}
+
+// userSuspensionDelete godoc
+// @Summary     Unsuspend user
+// @Security 	ApiKeyAuth
+// @Description lift the user's suspension. Requires the "users:admin" scope and the "users:manage" permission. The action is written to the audit trail.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int              true  "User ID"
+// @Param input body adminActionInput false "Reason"
+// @Router      /api/v1/users/{id}/suspension [delete]
+func (h *HandlerAccountManagementAPI) userSuspensionDelete(context *gin.Context) {
+	userId, input, ok := h.bindAdminAction(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	err := h.services.Admin.UnsuspendUser(context, actorId, userId, input.Reason)
+	abortAdminError(context, "userSuspensionDelete()", err)
+}
+
+// userUnlockPost godoc
+// @Summary     Unlock user
+// @Security 	ApiKeyAuth
+// @Description lift the user's lockout after too many failed sign-ins. Requires the "users:admin" scope and the "users:manage" permission. The action is written to the audit trail.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int              true  "User ID"
+// @Param input body adminActionInput false "Reason"
+// @Router      /api/v1/users/{id}/unlock [post]
+func (h *HandlerAccountManagementAPI) userUnlockPost(context *gin.Context) {
+	userId, input, ok := h.bindAdminAction(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	err := h.services.Admin.UnlockUser(context, actorId, userId, input.Reason)
+	abortAdminError(context, "userUnlockPost()", err)
+}
+
+// userLogoutPost godoc
+// @Summary     Log user out
+// @Security 	ApiKeyAuth
+// @Description end all the user's sessions: the Hydra login sessions, the consents with the issued tokens and the browser sessions. Requires the "users:admin" scope and the "users:manage" permission.
+// @Description The action is written to the audit trail.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int              true  "User ID"
+// @Param input body adminActionInput false "Reason"
+// @Router      /api/v1/users/{id}/logout [post]
+func (h *HandlerAccountManagementAPI) userLogoutPost(context *gin.Context) {
+	userId, input, ok := h.bindAdminAction(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	err := h.services.Admin.LogoutUser(context, actorId, userId, input.Reason)
+	abortAdminError(context, "userLogoutPost()", err)
+}
+
+// bindAdminAction returns the user id from the path and the optional request body.
+func (h *HandlerAccountManagementAPI) bindAdminAction(context *gin.Context) (uint32, *adminActionInput, bool) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return 0, nil, false
+	}
+
+	input := new(adminActionInput)
+	if context.Request.ContentLength != 0 {
+		if err := context.ShouldBindJSON(input); err != nil {
+			abortValidationError(context, err)
+			return 0, nil, false
+		}
+	}
+
+	return userId, input, true
+}
+
+// abortAdminError responds with the action's error or with no content if it succeeded.
+func abortAdminError(context *gin.Context, handlerName string, err error) {
+	if err == nil {
+		context.Status(http.StatusNoContent)
+		return
+	}
+
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrSuspensionExpired):
+		statusCode = http.StatusBadRequest
+	default:
+		statusCode = http.StatusInternalServerError
+		logger.Error(handlerName, logger.NamedError("error", err))
+	}
+
+	context.IndentedJSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"time"
+)
+
+type auditListInput struct {
+	UserId  *uint32    `form:"user_id" json:"user_id"`
+	ActorId *uint32    `form:"actor_id" json:"actor_id"`
+	Action  string     `form:"action" json:"action" binding:"max=64"`
+	Outcome string     `form:"outcome" json:"outcome" binding:"omitempty,oneof=success failure denied challenged"`
+	From    *time.Time `form:"from" json:"from"`
+	To      *time.Time `form:"to" json:"to"`
+	Limit   int        `form:"limit" json:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor  string     `form:"cursor" json:"cursor"`
+}
+
+// auditList godoc
+// @Summary     List audit log
+// @Security 	ApiKeyAuth
+// @Description list the audit log page by page, the newest events first. Requires the "users:admin" scope and the "audit:read" permission.
+// @Description The filters are combined. The next page is read by passing "next_cursor" with the same filters, it's empty on the last page.
+// @Tags        audit
+// @Produce     json
+// @Success     200 {object} domain.AuditPage
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     500 {object} object{error=string}
+// @Param user_id  query int    false "User the action was done to"
+// @Param actor_id query int    false "User who did the action"
+// @Param action   query string false "Action, e.g. user.signin"
+// @Param outcome  query string false "Outcome" Enums(success, failure, denied)
+// @Param from     query string false "Created at or after, RFC 3339"
+// @Param to       query string false "Created before, RFC 3339"
+// @Param limit    query int    false "Page size" minimum(1) maximum(200) default(50)
+// @Param cursor   query string false "Cursor of the next page"
+// @Router      /api/v1/audit [get]
+func (h *HandlerAccountManagementAPI) auditList(context *gin.Context) {
+	var input auditListInput
+	if err := context.ShouldBindQuery(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	page, err := h.services.Audit.List(context, &service.AuditListInput{
+		ActorId: input.ActorId,
+		UserId:  input.UserId,
+		Action:  input.Action,
+		Outcome: input.Outcome,
+		From:    input.From,
+		To:      input.To,
+		Limit:   input.Limit,
+		Cursor:  input.Cursor,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		} else {
+			logger.Error("auditList()", logger.NamedError("error", err))
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, page)
+}
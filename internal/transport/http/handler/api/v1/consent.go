@@ -1,9 +1,11 @@
 package v1
 
 import (
-	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
 	"service-account/internal/transport/http/response"
 	"service-account/pkg/logger"
 	"time"
@@ -27,65 +29,29 @@ func (h *HandlerAccountManagementAPI) consentGet(context *gin.Context) {
 	}
 
 	// Get consent request.
-	getConsentData, err := h.services.OAuth2.GetConsentRequest(context, challenge)
+	// If the user already granted the requested scopes, the consent is accepted at once.
+	consentFlow, err := h.services.Flow.GetConsentFlow(context, challenge)
 	if err != nil {
 		response.AbortError(context, http.StatusInternalServerError, err)
 		return
 	}
 
-	if getConsentData.Skip {
-		// You can apply logic here, for example grant another scope, or do whatever...
-		// ...
-
-		// Now it's time to grant the consent request. You could also deny the request if something went terribly wrong
-		/*
-			{
-			// We can grant all scopes that have been requested - hydra already checked for us that no additional scopes
-			// are requested accidentally.
-			grant_scope: body.requested_scope,
-
-			// ORY hydra checks if requested audiences are allowed by the client, so we can simply echo this.
-			grant_access_token_audience: body.requested_access_token_audience,
-
-			// The session allows us to set session data for id and access tokens
-			session: {
-			  // This data will be available when introspecting the token. Try to avoid sensitive information here,
-			  // unless you limit who can introspect tokens.
-			  // accessToken: { foo: 'bar' },
-			  // This data will be available in the ID token.
-			  // idToken: { baz: 'bar' },
-			}
-		*/
-
-		// Accept consent request.
-		redirectTo, err := h.services.OAuth2.AcceptConsentRequest(context, challenge, getConsentData.RequestedScope, getConsentData.RequestedAccessTokenAudience, true, 3600)
-		if err != nil {
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
-		}
-
-		context.Redirect(http.StatusFound, redirectTo)
+	if consentFlow.RedirectTo != "" {
+		context.Redirect(http.StatusFound, consentFlow.RedirectTo)
 		return
 	}
 
-	// Declared an empty map interface
-	var clientData map[string]interface{}
-	// Unmarshal or Decode the JSON to the interface.
-	json.Unmarshal(getConsentData.ClientData, &clientData)
-
 	// Render consent html.
-	// TODO: csrfToken for forms.
-	context.HTML(http.StatusOK, "consent.html",
+	response.HTML(context, http.StatusOK, "consent.html", withBranding(
 		gin.H{
-			"csrfToken": "",
 			"challenge": challenge,
 			// We have a bunch of data available from the response, check out the API docs to find what these values mean
 			// and what additional data you have available.
-			"requested_scope": getConsentData.RequestedScope,
-			"user":            getConsentData.Subject,
-			"client":          clientData,
+			"requested_scope": consentFlow.RequestedScope,
+			"user":            consentFlow.Subject,
+			"client":          consentFlow.Client,
 			"action":          pathConsent,
-		})
+		}, consentFlow.Tenant))
 }
 
 // consentPost godoc
@@ -105,40 +71,26 @@ func (h *HandlerAccountManagementAPI) consentPost(context *gin.Context) {
 	}
 
 	submit := context.PostForm("submit")
-	if submit == submitDenyAccess {
-		// Reject consent request.
-		redirectTo, err := h.services.OAuth2.RejectConsentRequest(context, challenge, "access_denied", "The resource owner denied the request")
-		if err != nil {
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
-		}
-
-		context.Redirect(http.StatusFound, redirectTo)
-		return
-	} else if submit != submitAllowAccess {
+	if submit != submitDenyAccess && submit != submitAllowAccess {
 		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
 		return
 	}
 
-	// Get consent request.
-	getConsentData, err := h.services.OAuth2.GetConsentRequest(context, challenge)
-	if err != nil {
-		response.AbortError(context, http.StatusInternalServerError, err)
-		return
-	}
-
-	// Remember auth consent session?
-	var remember bool
-	if context.PostForm("remember") != "" {
-		remember = true
+	// Accept or reject consent request.
+	inputConsentData := &service.ConsentSubmitInput{
+		Accept:     submit == submitAllowAccess,
+		GrantScope: context.PostFormArray("grant_scope"),
+		// Remember auth consent session?
+		Remember: context.PostForm("remember") != "",
 	}
 
-	// TODO Check grant scope.
-	grantScope := context.PostFormArray("grant_scope")
-
-	// Accept consent request.
-	redirectTo, err := h.services.OAuth2.AcceptConsentRequest(context, challenge, grantScope, getConsentData.RequestedAccessTokenAudience, remember, 3600)
+	redirectTo, err := h.services.Flow.SubmitConsent(context, challenge, inputConsentData)
 	if err != nil {
+		if errors.Is(err, service.ErrConsentScopeNotRequested) {
+			response.AbortMessage(context, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		response.AbortError(context, http.StatusInternalServerError, err)
 		return
 	}
@@ -193,12 +145,21 @@ func (h *HandlerAccountManagementAPI) callback(context *gin.Context) {
 		logger.String("ID Token", token.IdToken),
 	)
 
-	// Save tokens in cookies.
-	// SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
-	context.SetCookie("access_token", token.AccessToken, 0, "", "", true, true)
-	context.SetCookie("refresh_token", token.RefreshToken, 0, "", "", true, true)
-	context.SetCookie("access_token_expires_in", token.Expiry.Format(time.RFC3339), 0, "", "", true, true)
-	context.SetCookie("id_token", token.IdToken, 0, "", "", true, true)
+	// Keep tokens in the session store, the browser gets only the session ID.
+	sessionId, err := h.services.Sessions.Create(context, token)
+	if err != nil {
+		logger.Error("handlerCallback() - Unable to create session",
+			logger.NamedError("error", err),
+		)
+
+		// Render result html.
+		context.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"Name": err.Error(),
+		})
+		return
+	}
+
+	coockie.SetSession(context.Writer, sessionId, h.services.Config.Session.AbsoluteTimeout)
 
 	// Redirect to main page.
 	context.Redirect(http.StatusFound, pathRoot)
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http/httptest"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+func TestHandlerAccountManagementAPI_consentPost(t *testing.T) {
+	setWorkDir()
+
+	testTable := []TestTableFlowLogin{
+		{
+			name:        "OK, requested scopes granted",
+			requestBody: "challenge=challenge&submit=" + submitAllowAccess + "&grant_scope=openid&grant_scope=users%3Aread",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitConsent(gomock.Any(), "challenge", &service.ConsentSubmitInput{
+					Accept:     true,
+					GrantScope: []string{"openid", "users:read"},
+				}).Return("redirectTo", nil)
+			},
+			expectedStatusCode: 302,
+		},
+		{
+			name:        "BAD, scope not requested by client",
+			requestBody: "challenge=challenge&submit=" + submitAllowAccess + "&grant_scope=openid&grant_scope=users%3Aadmin",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitConsent(gomock.Any(), "challenge", &service.ConsentSubmitInput{
+					Accept:     true,
+					GrantScope: []string{"openid", "users:admin"},
+				}).Return("", service.ErrConsentScopeNotRequested)
+			},
+			expectedStatusCode: 400,
+			expectedBody:       "The granted scope wasn't requested by the client",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			handler := NewHandlerAccountManagementAPI(&service.Services{Flow: mockFlow})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.POST("/consent", handler.consentPost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/consent", bytes.NewBufferString(testCase.requestBody))
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedBody)
+		})
+	}
+}
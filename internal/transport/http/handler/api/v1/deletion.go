@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
+	"service-account/internal/transport/http/response"
+	"service-account/pkg/logger"
+	"time"
+)
+
+const (
+	submitDeleteAccount  = "Delete account"
+	submitRestoreAccount = "Restore"
+	titleAccountRestore  = "Account restore"
+)
+
+// deleteGet godoc
+// @Summary     Delete account
+// @Description Get the account deletion page of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Router      /account/delete [get]
+func (h *HandlerAccountManagementAPI) deleteGet(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	h.renderDelete(context, http.StatusOK, userId, gin.H{})
+}
+
+// deletePost godoc
+// @Summary     Delete account
+// @Description Delete the account of the signed in user, the account can be restored within the grace period
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/delete [post]
+func (h *HandlerAccountManagementAPI) deletePost(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	switch context.PostForm("submit") {
+	case submitDeleteAccount:
+	case submitSendCode:
+		if err := h.services.Account.SendVerificationCode(context, userId); err != nil {
+			response.AbortError(context, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.renderDelete(context, http.StatusOK, userId, gin.H{"message": "The one-time code was sent to your email."})
+		return
+	default:
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	datePurge, err := h.services.Account.DeleteAccount(context, userId, &service.DeleteAccountInput{
+		Password: context.PostForm("password"),
+		Code:     context.PostForm("code"),
+	})
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrUserLocked) || isVerificationError(err) {
+			// Render delete html with error.
+			h.renderDelete(context, http.StatusBadRequest, userId, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err != nil {
+		// The account was deleted anyway.
+		logger.Error("deletePost() - notification", logger.NamedError("error", err))
+	}
+
+	// The session was ended with all the user's sessions.
+	coockie.Remove(context.Writer, coockie.Session)
+	response.HTML(context, http.StatusOK, "delete.html",
+		gin.H{
+			"message": "Your account was deleted, it will be erased on " + datePurge.Format(time.RFC1123) +
+				". Follow the link sent to your email to restore it.",
+		})
+}
+
+// renderDelete renders the account deletion page, the user without the password confirms with the one-time code.
+func (h *HandlerAccountManagementAPI) renderDelete(context *gin.Context, statusCode int, userId uint32, data gin.H) {
+	user, err := h.services.User.GetUserById(context, userId)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	data["action"] = pathAccountDelete
+	data["gracePeriod"] = h.services.Config.Account.DeletionGracePeriod.String()
+	data["verifyCode"] = !user.HasPassword()
+	response.HTML(context, statusCode, "delete.html", data)
+}
+
+// restoreGet godoc
+// @Summary     Restore account
+// @Description Get the restore page of the link sent to the deleted account's email
+// @Tags        account
+// @Produce     html
+// @Param       token query string true "Link token"
+// @Success     200 {object} object{error=string}
+// @Router      /account/restore [get]
+func (h *HandlerAccountManagementAPI) restoreGet(context *gin.Context) {
+	renderLink(context, http.StatusOK, titleAccountRestore, service.PathAccountRestore, submitRestoreAccount, gin.H{})
+}
+
+// restorePost godoc
+// @Summary     Restore account
+// @Description Restore the deleted account within the grace period
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/restore [post]
+func (h *HandlerAccountManagementAPI) restorePost(context *gin.Context) {
+	if context.PostForm("submit") != submitRestoreAccount {
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	err := h.services.Account.RestoreAccount(context, context.PostForm("token"))
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if errors.Is(err, service.ErrRestoreNotFound) {
+			renderLink(context, http.StatusBadRequest, titleAccountRestore, service.PathAccountRestore, "", gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err != nil {
+		// The account was restored anyway.
+		logger.Error("restorePost() - notification", logger.NamedError("error", err))
+	}
+
+	renderLink(context, http.StatusOK, titleAccountRestore, service.PathAccountRestore, "", gin.H{
+		"message": "Your account was restored, you can sign in again.",
+	})
+}
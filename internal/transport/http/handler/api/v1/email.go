@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/response"
+	"service-account/pkg/logger"
+)
+
+const (
+	submitChangeEmail  = "Change email"
+	submitConfirmEmail = "Confirm"
+	submitRevertEmail  = "Revert"
+	titleEmailChange   = "Email change"
+)
+
+// emailGet godoc
+// @Summary     Change email
+// @Description Get the email change page of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Router      /account/email [get]
+func (h *HandlerAccountManagementAPI) emailGet(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	h.renderEmail(context, http.StatusOK, userId, gin.H{})
+}
+
+// emailPost godoc
+// @Summary     Change email
+// @Description Send the confirmation link to the new email of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/email [post]
+func (h *HandlerAccountManagementAPI) emailPost(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	switch context.PostForm("submit") {
+	case submitChangeEmail:
+	case submitSendCode:
+		if err := h.services.Account.SendVerificationCode(context, userId); err != nil {
+			response.AbortError(context, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.renderEmail(context, http.StatusOK, userId, gin.H{"message": "The one-time code was sent to your email."})
+		return
+	default:
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	newEmail := context.PostForm("new_email")
+	err := h.services.Account.RequestEmailChange(context, userId, &service.EmailChangeInput{
+		Password: context.PostForm("password"),
+		NewEmail: newEmail,
+		Code:     context.PostForm("code"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrEmailTaken) ||
+			errors.Is(err, service.ErrEmailInvalid) || errors.Is(err, service.ErrUserLocked) || isVerificationError(err) {
+			// Render email html with error.
+			h.renderEmail(context, http.StatusBadRequest, userId, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderEmail(context, http.StatusOK, userId, gin.H{"message": "The confirmation link was sent to " + newEmail + "."})
+}
+
+// renderEmail renders the email change page, the user without the password confirms with the one-time code.
+func (h *HandlerAccountManagementAPI) renderEmail(context *gin.Context, statusCode int, userId uint32, data gin.H) {
+	user, err := h.services.User.GetUserById(context, userId)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	data["action"] = pathAccountEmail
+	data["verifyCode"] = !user.HasPassword()
+	response.HTML(context, statusCode, "email.html", data)
+}
+
+// emailConfirmGet godoc
+// @Summary     Confirm email change
+// @Description Get the confirmation page of the link sent to the new email
+// @Tags        account
+// @Produce     html
+// @Param       token query string true "Link token"
+// @Success     200 {object} object{error=string}
+// @Router      /account/email/confirm [get]
+func (h *HandlerAccountManagementAPI) emailConfirmGet(context *gin.Context) {
+	// The link is confirmed by the form, so the mail scanners following the links don't confirm it.
+	renderLink(context, http.StatusOK, titleEmailChange, service.PathEmailChangeConfirm, submitConfirmEmail, gin.H{})
+}
+
+// emailConfirmPost godoc
+// @Summary     Confirm email change
+// @Description Change the user's email to the confirmed one
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/email/confirm [post]
+func (h *HandlerAccountManagementAPI) emailConfirmPost(context *gin.Context) {
+	if context.PostForm("submit") != submitConfirmEmail {
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	err := h.services.Account.ConfirmEmailChange(context, context.PostForm("token"))
+	if !h.handleEmailLinkError(context, err, service.PathEmailChangeConfirm) {
+		return
+	}
+
+	renderLink(context, http.StatusOK, titleEmailChange, service.PathEmailChangeConfirm, "", gin.H{
+		"message": "Your email was changed.",
+	})
+}
+
+// emailRevertGet godoc
+// @Summary     Revert email change
+// @Description Get the revert page of the link sent to the old email
+// @Tags        account
+// @Produce     html
+// @Param       token query string true "Link token"
+// @Success     200 {object} object{error=string}
+// @Router      /account/email/revert [get]
+func (h *HandlerAccountManagementAPI) emailRevertGet(context *gin.Context) {
+	renderLink(context, http.StatusOK, titleEmailChange, service.PathEmailChangeRevert, submitRevertEmail, gin.H{})
+}
+
+// emailRevertPost godoc
+// @Summary     Revert email change
+// @Description Change the user's email back to the old one and end all the user's sessions
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/email/revert [post]
+func (h *HandlerAccountManagementAPI) emailRevertPost(context *gin.Context) {
+	if context.PostForm("submit") != submitRevertEmail {
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	err := h.services.Account.RevertEmailChange(context, context.PostForm("token"))
+	if !h.handleEmailLinkError(context, err, service.PathEmailChangeRevert) {
+		return
+	}
+
+	renderLink(context, http.StatusOK, titleEmailChange, service.PathEmailChangeRevert, "", gin.H{
+		"message": "Your email was changed back and all your sessions were ended. Change your password if you didn't change the email.",
+	})
+}
+
+// handleEmailLinkError renders the link's error, returns true if the link was followed.
+func (h *HandlerAccountManagementAPI) handleEmailLinkError(context *gin.Context, err error, action string) bool {
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, service.ErrNotificationFailed) {
+		// The email was changed anyway.
+		logger.Error("email link - notification", logger.NamedError("error", err))
+		return true
+	}
+
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrEmailChangeNotFound):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrUserVersionConflict):
+		statusCode = http.StatusConflict
+	default:
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return false
+	}
+
+	renderLink(context, statusCode, titleEmailChange, action, "", gin.H{
+		"error": err.Error(),
+	})
+	return false
+}
+
+// renderLink renders the page of the link sent to the user's email, the form is shown if submit isn't empty.
+func renderLink(context *gin.Context, statusCode int, title string, action string, submit string, data gin.H) {
+	data["title"] = title
+	data["action"] = action
+	data["submit"] = submit
+	data["token"] = context.Query("token")
+	response.HTML(context, statusCode, "link.html", data)
+}
@@ -0,0 +1,281 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+)
+
+type flowLoginInput struct {
+	Accept bool `json:"accept"`
+	// Login is the username or email.
+	Login string `json:"login"`
+	// Email is the former name of the login, it's used if the login is empty.
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Remember bool   `json:"remember"`
+}
+
+// login returns the username or email, the former "email" field is still accepted.
+func (i *flowLoginInput) login() string {
+	if i.Login != "" {
+		return i.Login
+	}
+
+	return i.Email
+}
+
+type flowLoginCodeInput struct {
+	Code string `json:"code"`
+}
+
+type flowConsentInput struct {
+	Accept     bool     `json:"accept"`
+	GrantScope []string `json:"grant_scope"`
+	Remember   bool     `json:"remember"`
+}
+
+type flowLogoutInput struct {
+	Accept bool `json:"accept"`
+}
+
+// flowLoginGet godoc
+// @Summary     Login flow
+// @Description Get the login flow state. The login is completed at once if the user is already authenticated, follow "redirect_to".
+// @Tags        flows
+// @Produce     json
+// @Param       challenge path     string true "Login challenge"
+// @Success     200       {object} domain.LoginFlow
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/login/{challenge} [get]
+func (h *HandlerAccountManagementAPI) flowLoginGet(context *gin.Context) {
+	loginFlow, err := h.services.Flow.GetLoginFlow(context, context.Param("challenge"))
+	if err != nil {
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, loginFlow)
+}
+
+// flowLoginPost godoc
+// @Summary     Login flow
+// @Description Sign in the user or deny the login. The risky sign-in requires the one-time code sent to the user's email,
+// @Description it's 202 and the code is submitted to "/api/v1/flows/login/{challenge}/code".
+// @Tags        flows
+// @Accept      json
+// @Produce     json
+// @Param       challenge path     string         true "Login challenge"
+// @Param       input     body     flowLoginInput true "Login submission"
+// @Success     200       {object} object{redirect_to=string}
+// @Success     202       {object} object{error=string,step_up=string}
+// @Failure     400       {object} object{error=string}
+// @Failure     415       {object} object{error=string}
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/login/{challenge} [post]
+func (h *HandlerAccountManagementAPI) flowLoginPost(context *gin.Context) {
+	var input flowLoginInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortFlowError(context, http.StatusBadRequest, err)
+		return
+	}
+
+	// Recognize the device by its cookie or issue the new one like the sign-in form does.
+	deviceId, err := h.identifyDevice(context)
+	if err != nil {
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	redirectTo, err := h.services.Flow.SubmitLogin(context, context.Param("challenge"), &service.LoginSubmitInput{
+		Accept:   input.Accept,
+		Login:    input.login(),
+		Password: input.Password,
+		Remember: input.Remember,
+		DeviceId: deviceId,
+	})
+	if errors.Is(err, service.ErrStepUpRequired) {
+		context.IndentedJSON(http.StatusAccepted, gin.H{
+			"error":   err.Error(),
+			"step_up": "email_code",
+		})
+		return
+	}
+
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if input.Accept && isCredentialsError(err) {
+			abortFlowError(context, http.StatusBadRequest, err)
+			return
+		}
+
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"redirect_to": redirectTo,
+	})
+}
+
+// flowLoginCodePost godoc
+// @Summary     Login flow
+// @Description Complete the risky sign-in with the one-time code sent to the user's email.
+// @Description The expired code requires the sign-in again.
+// @Tags        flows
+// @Accept      json
+// @Produce     json
+// @Param       challenge path     string             true "Login challenge"
+// @Param       input     body     flowLoginCodeInput true "One-time code"
+// @Success     200       {object} object{redirect_to=string}
+// @Failure     400       {object} object{error=string}
+// @Failure     415       {object} object{error=string}
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/login/{challenge}/code [post]
+func (h *HandlerAccountManagementAPI) flowLoginCodePost(context *gin.Context) {
+	var input flowLoginCodeInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortFlowError(context, http.StatusBadRequest, err)
+		return
+	}
+
+	redirectTo, err := h.services.Flow.SubmitLoginCode(context, context.Param("challenge"), input.Code)
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if errors.Is(err, service.ErrStepUpCodeIncorrect) || errors.Is(err, service.ErrStepUpExpired) || isCredentialsError(err) {
+			abortFlowError(context, http.StatusBadRequest, err)
+			return
+		}
+
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"redirect_to": redirectTo,
+	})
+}
+
+// flowConsentGet godoc
+// @Summary     Consent flow
+// @Description Get the consent flow state. The consent is completed at once if the user already granted it, follow "redirect_to".
+// @Tags        flows
+// @Produce     json
+// @Param       challenge path     string true "Consent challenge"
+// @Success     200       {object} domain.ConsentFlow
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/consent/{challenge} [get]
+func (h *HandlerAccountManagementAPI) flowConsentGet(context *gin.Context) {
+	consentFlow, err := h.services.Flow.GetConsentFlow(context, context.Param("challenge"))
+	if err != nil {
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, consentFlow)
+}
+
+// flowConsentPost godoc
+// @Summary     Consent flow
+// @Description Grant the scopes or deny the access.
+// @Tags        flows
+// @Accept      json
+// @Produce     json
+// @Param       challenge path     string           true "Consent challenge"
+// @Param       input     body     flowConsentInput true "Consent submission"
+// @Success     200       {object} object{redirect_to=string}
+// @Failure     400       {object} object{error=string}
+// @Failure     415       {object} object{error=string}
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/consent/{challenge} [post]
+func (h *HandlerAccountManagementAPI) flowConsentPost(context *gin.Context) {
+	var input flowConsentInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortFlowError(context, http.StatusBadRequest, err)
+		return
+	}
+
+	redirectTo, err := h.services.Flow.SubmitConsent(context, context.Param("challenge"), &service.ConsentSubmitInput{
+		Accept:     input.Accept,
+		GrantScope: input.GrantScope,
+		Remember:   input.Remember,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrConsentScopeNotRequested) {
+			abortFlowError(context, http.StatusBadRequest, err)
+			return
+		}
+
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"redirect_to": redirectTo,
+	})
+}
+
+// flowLogoutGet godoc
+// @Summary     Logout flow
+// @Description Get the logout flow state.
+// @Tags        flows
+// @Produce     json
+// @Param       challenge path     string true "Logout challenge"
+// @Success     200       {object} domain.LogoutFlow
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/logout/{challenge} [get]
+func (h *HandlerAccountManagementAPI) flowLogoutGet(context *gin.Context) {
+	logoutFlow, err := h.services.Flow.GetLogoutFlow(context, context.Param("challenge"))
+	if err != nil {
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, logoutFlow)
+}
+
+// flowLogoutPost godoc
+// @Summary     Logout flow
+// @Description Accept or deny the logout.
+// @Tags        flows
+// @Accept      json
+// @Produce     json
+// @Param       challenge path     string          true "Logout challenge"
+// @Param       input     body     flowLogoutInput true "Logout submission"
+// @Success     200       {object} object{redirect_to=string}
+// @Failure     400       {object} object{error=string}
+// @Failure     415       {object} object{error=string}
+// @Failure     500       {object} object{error=string}
+// @Router      /api/v1/flows/logout/{challenge} [post]
+func (h *HandlerAccountManagementAPI) flowLogoutPost(context *gin.Context) {
+	var input flowLogoutInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortFlowError(context, http.StatusBadRequest, err)
+		return
+	}
+
+	redirectTo, err := h.services.Flow.SubmitLogout(context, context.Param("challenge"), input.Accept)
+	if err != nil {
+		abortFlowError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	if redirectTo == "" {
+		// The logout was rejected, back to main page.
+		redirectTo = pathRoot
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"redirect_to": redirectTo,
+	})
+}
+
+func abortFlowError(context *gin.Context, statusCode int, err error) {
+	if statusCode >= http.StatusInternalServerError {
+		logger.Error("flow", logger.NamedError("error", err))
+	}
+
+	context.AbortWithStatusJSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
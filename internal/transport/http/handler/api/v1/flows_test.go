@@ -0,0 +1,271 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http/httptest"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+	"time"
+)
+
+type mockBehaviorFlow func(mockFlow *mock_service.MockFlow)
+
+type TestTableFlowLogin struct {
+	name               string
+	method             string
+	requestBody        string
+	mockBehaviorFlow   mockBehaviorFlow
+	expectedStatusCode int
+	expectedBody       string
+}
+
+func TestHandlerAccountManagementAPI_flowLogin(t *testing.T) {
+	setWorkDir()
+
+	testTable := []TestTableFlowLogin{
+		{
+			name:   "OK, get login flow",
+			method: "GET",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().GetLoginFlow(gomock.Any(), "challenge").Return(&domain.LoginFlow{
+					Challenge: "challenge",
+					Hint:      "foo@bar.com",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "{\n    \"challenge\": \"challenge\",\n    \"hint\": \"foo@bar.com\"\n}",
+		},
+		{
+			name:   "BAD, get login flow error",
+			method: "GET",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().GetLoginFlow(gomock.Any(), "challenge").Return(nil, errors.New("Test error"))
+			},
+			expectedStatusCode: 500,
+			expectedBody:       `{"error":"Test error"}`,
+		},
+		{
+			name:        "OK, accept login",
+			method:      "POST",
+			requestBody: `{"accept":true,"email":"foo@bar.com","password":"foobar"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLogin(gomock.Any(), "challenge", &service.LoginSubmitInput{
+					Accept:   true,
+					Login:    "foo@bar.com",
+					Password: "foobar",
+					DeviceId: "device",
+				}).Return("redirectTo", nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "{\n    \"redirect_to\": \"redirectTo\"\n}",
+		},
+		{
+			name:        "OK, accept login by username",
+			method:      "POST",
+			requestBody: `{"accept":true,"login":"alice","password":"foobar"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLogin(gomock.Any(), "challenge", &service.LoginSubmitInput{
+					Accept:   true,
+					Login:    "alice",
+					Password: "foobar",
+					DeviceId: "device",
+				}).Return("redirectTo", nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "{\n    \"redirect_to\": \"redirectTo\"\n}",
+		},
+		{
+			name:        "BAD, password is incorrect",
+			method:      "POST",
+			requestBody: `{"accept":true,"email":"foo@bar.com","password":"foo"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLogin(gomock.Any(), "challenge", gomock.Any()).Return("", service.ErrPasswordIncorrect)
+			},
+			expectedStatusCode: 400,
+			expectedBody:       `{"error":"Password is incorrect"}`,
+		},
+		{
+			name:        "OK, risky login requires code",
+			method:      "POST",
+			requestBody: `{"accept":true,"email":"foo@bar.com","password":"foobar"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLogin(gomock.Any(), "challenge", gomock.Any()).Return("", service.ErrStepUpRequired)
+			},
+			expectedStatusCode: 202,
+			expectedBody:       "{\n    \"error\": \"Sign-in requires the one-time code sent to your email\",\n    \"step_up\": \"email_code\"\n}",
+		},
+		{
+			name:        "BAD, body is malformed",
+			method:      "POST",
+			requestBody: `{"accept":`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				// Nothing
+			},
+			expectedStatusCode: 400,
+			expectedBody:       `{"error":"unexpected EOF"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			mockDevices := mock_service.NewMockDevices(ctrl)
+			mockDevices.EXPECT().Identify("").Return("device", "device.signature", nil).AnyTimes()
+			handler := NewHandlerAccountManagementAPI(&service.Services{
+				Flow:    mockFlow,
+				Devices: mockDevices,
+				Config:  &config.Config{Device: config.DeviceConfig{CookieMaxAge: time.Hour}},
+			})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.GET("/flows/login/:challenge", handler.flowLoginGet)
+			r.POST("/flows/login/:challenge", handler.flowLoginPost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/flows/login/challenge", bytes.NewBufferString(testCase.requestBody))
+			req.Header.Add("Content-Type", "application/json")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedBody)
+		})
+	}
+}
+
+func TestHandlerAccountManagementAPI_flowLoginCode(t *testing.T) {
+	setWorkDir()
+
+	testTable := []TestTableFlowLogin{
+		{
+			name:        "OK, code accepted",
+			requestBody: `{"code":"123456"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLoginCode(gomock.Any(), "challenge", "123456").Return("redirectTo", nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "{\n    \"redirect_to\": \"redirectTo\"\n}",
+		},
+		{
+			name:        "BAD, code is incorrect",
+			requestBody: `{"code":"000000"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLoginCode(gomock.Any(), "challenge", "000000").Return("", service.ErrStepUpCodeIncorrect)
+			},
+			expectedStatusCode: 400,
+			expectedBody:       `{"error":"One-time code is incorrect"}`,
+		},
+		{
+			name:        "BAD, code is expired",
+			requestBody: `{"code":"123456"}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitLoginCode(gomock.Any(), "challenge", "123456").Return("", service.ErrStepUpExpired)
+			},
+			expectedStatusCode: 400,
+			expectedBody:       `{"error":"One-time code is expired, sign in again"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			handler := NewHandlerAccountManagementAPI(&service.Services{Flow: mockFlow})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.POST("/flows/login/:challenge/code", handler.flowLoginCodePost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/flows/login/challenge/code", bytes.NewBufferString(testCase.requestBody))
+			req.Header.Add("Content-Type", "application/json")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedBody)
+		})
+	}
+}
+
+func TestHandlerAccountManagementAPI_flowConsentPost(t *testing.T) {
+	setWorkDir()
+
+	testTable := []TestTableFlowLogin{
+		{
+			name:        "OK, requested scopes granted",
+			requestBody: `{"accept":true,"grant_scope":["openid","users:read"],"remember":true}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitConsent(gomock.Any(), "challenge", &service.ConsentSubmitInput{
+					Accept:     true,
+					GrantScope: []string{"openid", "users:read"},
+					Remember:   true,
+				}).Return("redirectTo", nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "{\n    \"redirect_to\": \"redirectTo\"\n}",
+		},
+		{
+			name:        "BAD, scope not requested by client",
+			requestBody: `{"accept":true,"grant_scope":["openid","users:admin"]}`,
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitConsent(gomock.Any(), "challenge", gomock.Any()).Return("", service.ErrConsentScopeNotRequested)
+			},
+			expectedStatusCode: 400,
+			expectedBody:       `{"error":"The granted scope wasn't requested by the client"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			handler := NewHandlerAccountManagementAPI(&service.Services{Flow: mockFlow})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.POST("/flows/consent/:challenge", handler.flowConsentPost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/flows/consent/challenge", bytes.NewBufferString(testCase.requestBody))
+			req.Header.Add("Content-Type", "application/json")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+	"service-account/pkg/logger"
+	"strconv"
+)
+
+type organizationInput struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type groupInput struct {
+	DisplayName string `json:"display_name" binding:"required,max=255"`
+}
+
+type groupMemberInput struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// organizationsList godoc
+// @Summary     List organizations
+// @Security 	ApiKeyAuth
+// @Description list the tenant's organizations ordered by the name. Requires the "users:admin" scope and the "groups:manage" permission.
+// @Tags        groups
+// @Produce     json
+// @Success     200 {object} object{organizations=[]object{id=uint32,name=string,date_created=time.Time}}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /api/v1/organizations [get]
+func (h *HandlerAccountManagementAPI) organizationsList(context *gin.Context) {
+	organizations, err := h.services.Groups.ListOrganizations(context)
+	if err != nil {
+		abortGroupError(context, "organizationsList()", err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(organizations))
+	for i := range organizations {
+		response = append(response, organizationResponse(&organizations[i]))
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"organizations": response,
+	})
+}
+
+// organizationPost godoc
+// @Summary     Create organization
+// @Security 	ApiKeyAuth
+// @Description create the organization, its name is unique in the tenant. Requires the "users:admin" scope and the "groups:manage" permission. The action is written to the audit trail.
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} object{organization=object{id=uint32,name=string,date_created=time.Time}}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param input body organizationInput true "Organization"
+// @Router      /api/v1/organizations [post]
+func (h *HandlerAccountManagementAPI) organizationPost(context *gin.Context) {
+	var input organizationInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	organization, err := h.services.Groups.CreateOrganization(context, actorId, input.Name)
+	if err != nil {
+		abortGroupError(context, "organizationPost()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusCreated, gin.H{
+		"organization": organizationResponse(organization),
+	})
+}
+
+// organizationGroupsList godoc
+// @Summary     List organization's groups
+// @Security 	ApiKeyAuth
+// @Description list the organization's groups with their members. Requires the "users:admin" scope and the "groups:manage" permission.
+// @Tags        groups
+// @Produce     json
+// @Success     200 {object} object{groups=[]object{id=uint32,organization_id=uint32,display_name=string,version=uint32,date_created=time.Time,date_updated=time.Time,members=[]object{user_id=uint32,username=string,role=string}}}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Organization ID"
+// @Router      /api/v1/organizations/{id}/groups [get]
+func (h *HandlerAccountManagementAPI) organizationGroupsList(context *gin.Context) {
+	organizationId, ok := getIdParam(context, "id", "organization")
+	if !ok {
+		return
+	}
+
+	groups, err := h.services.Groups.ListGroups(context, organizationId)
+	if err != nil {
+		abortGroupError(context, "organizationGroupsList()", err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(groups))
+	for i := range groups {
+		response = append(response, groupResponse(&groups[i]))
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"groups": response,
+	})
+}
+
+// organizationGroupPost godoc
+// @Summary     Create organization's group
+// @Security 	ApiKeyAuth
+// @Description create the organization's group without members, its name is unique in the organization. Requires the "users:admin" scope and the "groups:manage" permission. The action is written to the audit trail.
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} object{group=object{id=uint32,organization_id=uint32,display_name=string,version=uint32,date_created=time.Time,date_updated=time.Time,members=[]object{user_id=uint32,username=string,role=string}}}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int        true "Organization ID"
+// @Param input body groupInput true "Group"
+// @Router      /api/v1/organizations/{id}/groups [post]
+func (h *HandlerAccountManagementAPI) organizationGroupPost(context *gin.Context) {
+	organizationId, ok := getIdParam(context, "id", "organization")
+	if !ok {
+		return
+	}
+
+	var input groupInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	group, err := h.services.Groups.CreateGroup(context, actorId, organizationId, input.DisplayName)
+	if err != nil {
+		abortGroupError(context, "organizationGroupPost()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusCreated, gin.H{
+		"group": groupResponse(group),
+	})
+}
+
+// groupGet godoc
+// @Summary     Get group
+// @Security 	ApiKeyAuth
+// @Description get the group with its members. Requires the "users:read" scope, the group is read by its members and the users with the "groups:manage" permission.
+// @Tags        groups
+// @Produce     json
+// @Success     200 {object} object{group=object{id=uint32,organization_id=uint32,display_name=string,version=uint32,date_created=time.Time,date_updated=time.Time,members=[]object{user_id=uint32,username=string,role=string}}}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Group ID"
+// @Router      /api/v1/groups/{id} [get]
+func (h *HandlerAccountManagementAPI) groupGet(context *gin.Context) {
+	groupId, ok := getIdParam(context, "id", "group")
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	group, err := h.services.Groups.GetGroup(context, actorId, groupId)
+	if err != nil {
+		abortGroupError(context, "groupGet()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"group": groupResponse(group),
+	})
+}
+
+// groupDelete godoc
+// @Summary     Delete group
+// @Security 	ApiKeyAuth
+// @Description delete the group, its members aren't deleted. Requires the "users:admin" scope and the "groups:manage" permission. The action is written to the audit trail.
+// @Tags        groups
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Group ID"
+// @Router      /api/v1/groups/{id} [delete]
+func (h *HandlerAccountManagementAPI) groupDelete(context *gin.Context) {
+	groupId, ok := getIdParam(context, "id", "group")
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.Groups.DeleteGroup(context, actorId, groupId); err != nil {
+		abortGroupError(context, "groupDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// groupMemberPut godoc
+// @Summary     Set group member
+// @Security 	ApiKeyAuth
+// @Description add the user to the group or change the member's role. Requires the "users:write" scope, the members are managed by the group's admins and the users with the "groups:manage" permission.
+// @Description The group's only admin can't become its member. The action is written to the audit trail.
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id      path int              true "Group ID"
+// @Param user_id path int              true "User ID"
+// @Param input   body groupMemberInput true "Member's role"
+// @Router      /api/v1/groups/{id}/members/{user_id} [put]
+func (h *HandlerAccountManagementAPI) groupMemberPut(context *gin.Context) {
+	groupId, userId, ok := getGroupMemberParams(context)
+	if !ok {
+		return
+	}
+
+	var input groupMemberInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.Groups.SetMember(context, actorId, groupId, userId, input.Role); err != nil {
+		abortGroupError(context, "groupMemberPut()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// groupMemberDelete godoc
+// @Summary     Remove group member
+// @Security 	ApiKeyAuth
+// @Description remove the user from the group. Requires the "users:write" scope, the members are removed by the group's admins and the users with the "groups:manage" permission, the members leave the group themselves.
+// @Description The group's only admin can't leave it. The action is written to the audit trail.
+// @Tags        groups
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id      path int true "Group ID"
+// @Param user_id path int true "User ID"
+// @Router      /api/v1/groups/{id}/members/{user_id} [delete]
+func (h *HandlerAccountManagementAPI) groupMemberDelete(context *gin.Context) {
+	groupId, userId, ok := getGroupMemberParams(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.Groups.RemoveMember(context, actorId, groupId, userId); err != nil {
+		abortGroupError(context, "groupMemberDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// getIdParam returns the record's id from the path, responds with an error if it's not valid.
+func getIdParam(context *gin.Context, param string, record string) (uint32, bool) {
+	id, err := strconv.ParseUint(context.Param(param), 10, 32)
+	if err != nil {
+		context.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "The " + record + " id is in the bad format.",
+		})
+		return 0, false
+	}
+
+	return uint32(id), true
+}
+
+func getGroupMemberParams(context *gin.Context) (uint32, uint32, bool) {
+	groupId, ok := getIdParam(context, "id", "group")
+	if !ok {
+		return 0, 0, false
+	}
+
+	userId, ok := getIdParam(context, "user_id", "user")
+	if !ok {
+		return 0, 0, false
+	}
+
+	return groupId, userId, true
+}
+
+// abortGroupError responds with the error of the groups' management.
+func abortGroupError(context *gin.Context, handlerName string, err error) {
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrOrganizationNameInvalid), errors.Is(err, service.ErrGroupNameInvalid),
+		errors.Is(err, service.ErrGroupRoleInvalid):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, service.ErrGroupAccessDenied):
+		statusCode = http.StatusForbidden
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrGroupNotFound),
+		errors.Is(err, service.ErrGroupMemberNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrOrganizationAlreadyExist), errors.Is(err, service.ErrGroupAlreadyExist),
+		errors.Is(err, service.ErrLastGroupAdmin):
+		statusCode = http.StatusConflict
+	default:
+		statusCode = http.StatusInternalServerError
+		logger.Error(handlerName, logger.NamedError("error", err))
+	}
+
+	context.IndentedJSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
+
+func organizationResponse(organization *domain.Organization) gin.H {
+	return gin.H{
+		"id":           organization.Id,
+		"name":         organization.Name,
+		"date_created": organization.DateCreated,
+	}
+}
+
+func groupResponse(group *domain.Group) gin.H {
+	members := make([]gin.H, 0, len(group.Members))
+	for _, member := range group.Members {
+		members = append(members, gin.H{
+			"user_id":  member.UserId,
+			"username": member.Username,
+			"role":     member.Role,
+		})
+	}
+
+	return gin.H{
+		"id":              group.Id,
+		"organization_id": group.OrganizationId,
+		"display_name":    group.DisplayName,
+		"version":         group.Version,
+		"date_created":    group.DateCreated,
+		"date_updated":    group.DateUpdated,
+		"members":         members,
+	}
+}
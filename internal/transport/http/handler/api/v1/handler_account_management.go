@@ -2,7 +2,9 @@ package v1
 
 import (
 	"github.com/gin-gonic/gin"
+	"service-account/internal/domain"
 	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
 )
 
 const (
@@ -16,13 +18,28 @@ const (
 	pathRoot                      = "/"
 	PathSignup             string = "/signup"
 	pathSignin             string = "/signin"
+	pathSigninCode         string = "/signin/code"
+	pathSigninIdP          string = "/signin/idp"
+	pathSigninIdPCallback  string = "/signin/idp/callback"
+	pathSigninIdPLink      string = "/signin/idp/link"
 	pathConsent            string = "/consent"
 	pathCallback           string = "/callback"
 	pathLogout             string = "/logout"
 	pathLogoutBackchannel  string = "/backchannel-logout"
 	pathLogoutFrontchannel string = "/frontchannel-logout"
+	pathAccountPassword    string = "/account/password"
+	pathAccountEmail       string = "/account/email"
+	pathAccountDelete      string = "/account/delete"
+	pathAccountLogins      string = "/account/logins"
+	pathAccountLink        string = "/account/identities/link"
+	pathAccountUnlink      string = "/account/identities/unlink"
 	// Paths v1
-	pathUser string = "/users"
+	pathUser            string = "/users"
+	pathFlows           string = "/flows"
+	pathAudit           string = "/audit"
+	pathOrganizations   string = "/organizations"
+	pathGroups          string = "/groups"
+	pathServiceAccounts string = "/service-accounts"
 )
 
 type HandlerAccountManagementAPI struct {
@@ -40,32 +57,196 @@ func (h *HandlerAccountManagementAPI) Init(router *gin.RouterGroup) {
 	v1 := router.Group("/api/v1")
 	{
 		h.initHandlersAccountManagement(v1)
+		h.initHandlersFlows(v1)
+		h.initHandlersAudit(v1)
+		h.initHandlersGroups(v1)
+		h.initHandlersServiceAccounts(v1)
 	}
 }
 
 func (h *HandlerAccountManagementAPI) initHandlersAccountManagement(router *gin.RouterGroup) {
-	user := router.Group(pathUser)
+	// All the users API requires an active access token granted the "users:read" scope.
+	user := router.Group(pathUser,
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+		middleware.Authenticate(h.services.OAuth2, h.services.Tenants, h.services.ServiceAccounts),
+		middleware.TrackActivity(h.services.Activity),
+		middleware.RequireScopes(domain.ScopeUsersRead),
+	)
 	{
+		user.GET("", middleware.RequireScopes(domain.ScopeUsersAdmin), middleware.RequirePermission(h.services.RBAC, domain.PermissionUsersList), h.usersList)
 		user.GET(":id", h.userGet)
+		user.PATCH(":id", middleware.RequireScopes(domain.ScopeUsersWrite), h.userPatch)
+		user.POST(":id/password", middleware.RequireScopes(domain.ScopeUsersWrite), h.userPasswordPost)
+		user.POST(":id/verification-code", middleware.RequireScopes(domain.ScopeUsersWrite), h.userVerificationCodePost)
+		user.POST(":id/email", middleware.RequireScopes(domain.ScopeUsersWrite), h.userEmailPost)
+		user.DELETE(":id", middleware.RequireScopes(domain.ScopeUsersWrite), h.userDelete)
+		user.GET(":id/export", h.userExportGet)
+		user.GET(":id/logins", h.userLoginsGet)
+	}
+
+	// Suspension, unlock and forced logout require the administrator scope and permission.
+	admin := user.Group(":id",
+		middleware.RequireScopes(domain.ScopeUsersAdmin),
+		middleware.RequirePermission(h.services.RBAC, domain.PermissionUsersManage),
+	)
+	{
+		admin.POST("suspension", h.userSuspensionPost)
+		admin.DELETE("suspension", h.userSuspensionDelete)
+		admin.POST("unlock", h.userUnlockPost)
+		admin.POST("logout", h.userLogoutPost)
+	}
+
+	// Role management requires the administrator scope and permission.
+	roles := user.Group(":id/roles",
+		middleware.RequireScopes(domain.ScopeUsersAdmin),
+		middleware.RequirePermission(h.services.RBAC, domain.PermissionRolesManage),
+	)
+	{
+		roles.GET("", h.userRolesGet)
+		roles.PUT(":role", h.userRolePut)
+		roles.DELETE(":role", h.userRoleDelete)
+	}
+}
+
+// initHandlersAudit inits the audit log API, it requires the administrator scope and permission.
+func (h *HandlerAccountManagementAPI) initHandlersAudit(router *gin.RouterGroup) {
+	audit := router.Group(pathAudit,
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+		middleware.Authenticate(h.services.OAuth2, h.services.Tenants, h.services.ServiceAccounts),
+		middleware.TrackActivity(h.services.Activity),
+		middleware.RequireScopes(domain.ScopeUsersAdmin),
+		middleware.RequirePermission(h.services.RBAC, domain.PermissionAuditRead),
+	)
+	{
+		audit.GET("", h.auditList)
+	}
+}
+
+// initHandlersGroups inits the organizations' and groups' API, the organizations and their groups are managed
+// with the administrator scope and permission, the members are managed by the group's admins.
+func (h *HandlerAccountManagementAPI) initHandlersGroups(router *gin.RouterGroup) {
+	authenticate := []gin.HandlerFunc{
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+		middleware.Authenticate(h.services.OAuth2, h.services.Tenants, h.services.ServiceAccounts),
+		middleware.TrackActivity(h.services.Activity),
+	}
+
+	organizations := router.Group(pathOrganizations, authenticate...)
+	organizations.Use(
+		middleware.RequireScopes(domain.ScopeUsersAdmin),
+		middleware.RequirePermission(h.services.RBAC, domain.PermissionGroupsManage),
+	)
+	{
+		organizations.GET("", h.organizationsList)
+		organizations.POST("", h.organizationPost)
+		organizations.GET(":id/groups", h.organizationGroupsList)
+		organizations.POST(":id/groups", h.organizationGroupPost)
+	}
+
+	groups := router.Group(pathGroups, authenticate...)
+	groups.Use(middleware.RequireScopes(domain.ScopeUsersRead))
+	{
+		groups.GET(":id", h.groupGet)
+		groups.DELETE(":id", middleware.RequireScopes(domain.ScopeUsersAdmin), middleware.RequirePermission(h.services.RBAC, domain.PermissionGroupsManage), h.groupDelete)
+		groups.PUT(":id/members/:user_id", middleware.RequireScopes(domain.ScopeUsersWrite), h.groupMemberPut)
+		groups.DELETE(":id/members/:user_id", middleware.RequireScopes(domain.ScopeUsersWrite), h.groupMemberDelete)
+	}
+}
+
+// initHandlersServiceAccounts inits the service accounts' API, the accounts are managed with the administrator scope
+// and permission, their keys are managed by the owner team's admins too.
+func (h *HandlerAccountManagementAPI) initHandlersServiceAccounts(router *gin.RouterGroup) {
+	serviceAccounts := router.Group(pathServiceAccounts,
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+		middleware.Authenticate(h.services.OAuth2, h.services.Tenants, h.services.ServiceAccounts),
+		middleware.TrackActivity(h.services.Activity),
+		middleware.RequireScopes(domain.ScopeUsersRead),
+	)
+	{
+		serviceAccounts.GET(":id", h.serviceAccountGet)
+		serviceAccounts.GET(":id/keys", h.serviceAccountKeysList)
+		serviceAccounts.POST(":id/keys", middleware.RequireScopes(domain.ScopeUsersWrite), h.serviceAccountKeyPost)
+		serviceAccounts.POST(":id/keys/:key_id/rotate", middleware.RequireScopes(domain.ScopeUsersWrite), h.serviceAccountKeyRotatePost)
+		serviceAccounts.DELETE(":id/keys/:key_id", middleware.RequireScopes(domain.ScopeUsersWrite), h.serviceAccountKeyDelete)
+	}
+
+	admin := serviceAccounts.Group("",
+		middleware.RequireScopes(domain.ScopeUsersAdmin),
+		middleware.RequirePermission(h.services.RBAC, domain.PermissionServiceAccountsManage),
+	)
+	{
+		admin.GET("", h.serviceAccountsList)
+		admin.POST("", h.serviceAccountPost)
+		admin.PUT(":id", h.serviceAccountPut)
+		admin.DELETE(":id", h.serviceAccountDelete)
+	}
+}
+
+// initHandlersFlows inits the JSON equivalents of the login, consent and logout pages for the custom frontends.
+// The submissions must be JSON, so the other sites can't post them without the CORS preflight.
+func (h *HandlerAccountManagementAPI) initHandlersFlows(router *gin.RouterGroup) {
+	flows := router.Group(pathFlows, middleware.RequireJSON())
+	{
+		flows.GET("login/:challenge", h.flowLoginGet)
+		flows.POST("login/:challenge", h.flowLoginPost)
+		flows.POST("login/:challenge/code", h.flowLoginCodePost)
+		flows.GET("consent/:challenge", h.flowConsentGet)
+		flows.POST("consent/:challenge", h.flowConsentPost)
+		flows.GET("logout/:challenge", h.flowLogoutGet)
+		flows.POST("logout/:challenge", h.flowLogoutPost)
 	}
 }
 
 func (h *HandlerAccountManagementAPI) initHandlersAuthentication(router *gin.RouterGroup) {
 	// Init router.
+	// The forms are protected from CSRF.
+	forms := router.Group("", middleware.CSRF())
 	// Sign in
-	router.GET(pathSignin, h.signinGet)
-	router.POST(pathSignin, h.signinPost)
+	forms.GET(pathSignin, h.signinGet)
+	forms.POST(pathSignin, h.signinPost)
+	forms.POST(pathSigninCode, h.signinCodePost)
+	// The identity provider redirects the browser to the callback, the state cookie binds it to the browser.
+	forms.POST(pathSigninIdP, h.signinIdPPost)
+	forms.GET(pathSigninIdPCallback, h.signinIdPCallback)
+	forms.POST(pathSigninIdPLink, h.signinIdPLinkPost)
 	// Sign up
-	router.GET(PathSignup, h.signupGet)
-	router.POST(PathSignup, h.signupPost)
+	forms.GET(PathSignup, h.signupGet)
+	forms.POST(PathSignup, h.signupPost)
 	// Consent
-	router.GET(pathConsent, h.consentGet)
-	router.POST(pathConsent, h.consentPost)
+	forms.GET(pathConsent, h.consentGet)
+	forms.POST(pathConsent, h.consentPost)
 	// Callback
 	router.GET(pathCallback, h.callback)
 	// Logout
-	router.GET(pathLogout, h.logoutGet)
-	router.POST(pathLogout, h.logoutPost)
-	router.Any(pathLogoutBackchannel, h.logoutBackchannel)
+	forms.GET(pathLogout, h.logoutGet)
+	forms.POST(pathLogout, h.logoutPost)
+	// Hydra calls the back channel directly, not the browser.
+	router.POST(pathLogoutBackchannel, h.logoutBackchannel)
 	router.GET(pathLogoutFrontchannel, h.logoutFrontchannel)
+	// Account pages of the signed in user.
+	account := forms.Group("",
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+	)
+	account.GET(pathAccountPassword, h.passwordGet)
+	account.POST(pathAccountPassword, h.passwordPost)
+	account.GET(pathAccountEmail, h.emailGet)
+	account.POST(pathAccountEmail, h.emailPost)
+	account.GET(pathAccountLogins, h.loginsGet)
+	account.GET(service.PathAccountIdentities, h.identitiesGet)
+	account.POST(pathAccountLink, h.identitiesLinkPost)
+	account.POST(pathAccountUnlink, h.identitiesUnlinkPost)
+	account.GET(pathAccountDelete, h.deleteGet)
+	account.POST(pathAccountDelete, h.deletePost)
+	// The links sent to the user's emails work without the session.
+	forms.GET(service.PathEmailChangeConfirm, h.emailConfirmGet)
+	forms.POST(service.PathEmailChangeConfirm, h.emailConfirmPost)
+	forms.GET(service.PathEmailChangeRevert, h.emailRevertGet)
+	forms.POST(service.PathEmailChangeRevert, h.emailRevertPost)
+	forms.GET(service.PathAccountRestore, h.restoreGet)
+	forms.POST(service.PathAccountRestore, h.restorePost)
 }
@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
+	"service-account/internal/transport/http/response"
+	"strconv"
+)
+
+// identityPassword is the unlinked identity standing for the user's password.
+const identityPassword = "password"
+
+// identitiesGet godoc
+// @Summary     Sign-in methods
+// @Description Get the page of the signed in user's password and linked identity providers
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/identities [get]
+func (h *HandlerAccountManagementAPI) identitiesGet(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	h.renderIdentities(context, http.StatusOK, userId, gin.H{})
+}
+
+// identitiesLinkPost godoc
+// @Summary     Link identity provider
+// @Description Link the identity provider to the signed in user's account, the user is redirected to the provider
+// @Tags        account
+// @Produce     html
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/identities/link [post]
+func (h *HandlerAccountManagementAPI) identitiesLinkPost(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	authCodeUrl, state, err := h.services.Identity.StartLink(context, userId, context.PostForm("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrIdentityProviderNotFound) {
+			response.AbortMessage(context, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The provider's callback links the identity like it signs the user in.
+	coockie.SetUpstreamState(context.Writer, state, h.services.Config.IdP.LoginTTL)
+	context.Redirect(http.StatusFound, authCodeUrl)
+}
+
+// identitiesUnlinkPost godoc
+// @Summary     Unlink identity provider
+// @Description Unlink the identity provider from the signed in user's account or remove the password, the last sign-in method is kept
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/identities/unlink [post]
+func (h *HandlerAccountManagementAPI) identitiesUnlinkPost(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	var err error
+	message := "Password removed."
+	if identity := context.PostForm("identity"); identity == identityPassword {
+		err = h.services.Identity.RemovePassword(context, userId)
+	} else {
+		identityId, parseErr := strconv.ParseUint(identity, 10, 32)
+		if parseErr != nil {
+			response.AbortMessage(context, http.StatusBadRequest, "identitiesUnlinkPost(): Expected an identity to be set but received none.")
+			return
+		}
+
+		message = "Identity provider unlinked."
+		err = h.services.Identity.Unlink(context, userId, uint32(identityId))
+	}
+
+	if err != nil {
+		if errors.Is(err, service.ErrLastLoginMethod) || errors.Is(err, service.ErrIdentityNotFound) {
+			h.renderIdentities(context, http.StatusBadRequest, userId, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderIdentities(context, http.StatusOK, userId, gin.H{"message": message})
+}
+
+// renderIdentities renders the page of the user's sign-in methods.
+func (h *HandlerAccountManagementAPI) renderIdentities(context *gin.Context, statusCode int, userId uint32, data gin.H) {
+	methods, err := h.services.Identity.LoginMethods(context, userId)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	data["methods"] = methods
+	data["linkAction"] = pathAccountLink
+	data["unlinkAction"] = pathAccountUnlink
+	response.HTML(context, statusCode, "identities.html", data)
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/transport/http/response"
+	"service-account/pkg/logger"
+)
+
+// loginsGet godoc
+// @Summary     Login history
+// @Description Get the login history page of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/logins [get]
+func (h *HandlerAccountManagementAPI) loginsGet(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	logins, err := h.services.Devices.LoginHistory(context, userId)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.HTML(context, http.StatusOK, "logins.html",
+		gin.H{
+			"logins": logins,
+		})
+}
+
+// userLoginsGet godoc
+// @Summary     Get user login history
+// @Security 	ApiKeyAuth
+// @Description get the user's recent sign-ins, the newest first. Requires the "users:read" scope, reading other users' history requires the "users:admin" scope and the administrator role.
+// @Tags        user
+// @Produce     json
+// @Success     200 {object} object{logins=[]domain.LoginRecord}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Header      401 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     403 {object} object{error=string}
+// @Header      403 {string} WWW-Authenticate "Bearer token error (RFC 6750)"
+// @Failure     500 {object} object{error=string}
+// @Param id   path int true "User ID"
+// @Router      /api/v1/users/{id}/logins [get]
+func (h *HandlerAccountManagementAPI) userLoginsGet(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeUserAccess(context, userId, domain.PermissionUsersRead) {
+		return
+	}
+
+	logins, err := h.services.Devices.LoginHistory(context, userId)
+	if err != nil {
+		logger.Error("userLoginsGet()", logger.NamedError("error", err))
+		context.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"logins": logins,
+	})
+}
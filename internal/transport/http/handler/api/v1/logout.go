@@ -1,10 +1,14 @@
 package v1
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"service-account/internal/service"
 	"service-account/internal/transport/http/coockie"
 	"service-account/internal/transport/http/response"
+	"service-account/pkg/logger"
+	"strings"
 )
 
 // logoutGet godoc
@@ -22,10 +26,8 @@ func (h *HandlerAccountManagementAPI) logoutGet(context *gin.Context) {
 		return
 	}
 
-	// TODO: csrfToken for forms.
 	// Render home html with auth url.
-	context.HTML(http.StatusOK, "logout.html", gin.H{
-		"csrfToken": "",
+	response.HTML(context, http.StatusOK, "logout.html", gin.H{
 		"challenge": challenge,
 		"action":    pathLogout,
 	})
@@ -48,56 +50,119 @@ func (h *HandlerAccountManagementAPI) logoutPost(context *gin.Context) {
 	}
 
 	submit := context.PostForm("submit")
-	if submit == submitNo {
-		// Reject logout request.
-		err := h.services.OAuth2.RejectLogoutRequest(context, challenge)
-		if err != nil {
-			// Error request to hydra OAuth admin API.
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
-		}
-
-		// Redirect to main page.
-		context.Redirect(http.StatusFound, pathRoot)
-		return
-	} else if submit != submitYes {
+	if submit != submitNo && submit != submitYes {
 		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
 		return
 	}
 
-	// Accept logout request.
-	redirectTo, err := h.services.OAuth2.AcceptLogoutRequest(context, challenge)
+	// Accept or reject logout request.
+	redirectTo, err := h.services.Flow.SubmitLogout(context, challenge, submit == submitYes)
 	if err != nil {
+		// Error request to hydra OAuth admin API.
 		response.AbortError(context, http.StatusInternalServerError, err)
 		return
 	}
 
+	if redirectTo == "" {
+		// The logout was rejected, redirect to main page.
+		redirectTo = pathRoot
+	}
+
 	context.Redirect(http.StatusFound, redirectTo)
 }
 
 // logoutBackchannel godoc
 // @Summary     Logout user back channel
-// @Description Logout user back channel delete tokens from storage.
+// @Description Logout user back channel ends the sessions of the logout token's login session.
 // @Tags        auth
-// @Router      /backchannel-logout [get]
+// @Accept      x-www-form-urlencoded
+// @Param       logout_token formData string true "OpenID Connect Back-Channel Logout token"
+// @Success     200
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /backchannel-logout [post]
 func (h *HandlerAccountManagementAPI) logoutBackchannel(context *gin.Context) {
+	// SRC: https://openid.net/specs/openid-connect-backchannel-1_0.html#BCResponse
+	context.Header("Cache-Control", "no-store")
+
+	logoutToken, err := h.services.OAuth2.VerifyLogoutToken(context, context.PostForm("logout_token"))
+	if err != nil {
+		logger.Warn("handlerLogoutBackchannel() - VerifyLogoutToken",
+			logger.NamedError("error", err),
+		)
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "invalid_request",
+		})
+		return
+	}
 
+	// Delete tokens from storage.
+	if err = h.services.Sessions.DeleteBySid(context, logoutToken.Sid); err != nil {
+		logger.Error("handlerLogoutBackchannel() - DeleteBySid",
+			logger.NamedError("error", err),
+		)
+		context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.Status(http.StatusOK)
 }
 
 // logoutFrontchannel godoc
 // @Summary     Logout user front channel
-// @Description Logout user front channel delete tokens from storage.
+// @Description Logout user front channel ends the browser's session if it belongs to Hydra's logged out login session.
+// @Description The sessions of the other browsers are ended by the back channel.
 // @Tags        auth
+// @Param       iss query string true "Issuer, Hydra's public URL"
+// @Param       sid query string true "Login session ID"
+// @Success     200
+// @Failure     400 {object} object{error=string}
 // @Router      /frontchannel-logout [get]
 func (h *HandlerAccountManagementAPI) logoutFrontchannel(context *gin.Context) {
-	//var accessToken, _ = GetCookieValue(context.Request, "access_token")
-	//var accessTokenExpiresIn, _ = GetCookieValue(context.Request, "access_token_expires_in")
-	//var refreshToken, _ = GetCookieValue(context.Request, "refresh_token")
-	//var idToken, _ = GetCookieValue(context.Request, "id_token")
+	// SRC: https://openid.net/specs/openid-connect-frontchannel-1_0.html#RPLogout
+	context.Header("Cache-Control", "no-store")
+
+	// The request isn't signed, anyone can send any "sid", so only the caller's own session is ended.
+	iss, sid := context.Query("iss"), context.Query("sid")
+	if strings.TrimSuffix(iss, "/") != strings.TrimSuffix(h.services.Config.OAuth2.HydraPublicURL, "/") || sid == "" {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "invalid_request",
+		})
+		return
+	}
+
+	sessionId, err := coockie.GetValue(context.Request, coockie.Session)
+	if err != nil {
+		// The session cookie isn't sent to the cross-site iframe, the back channel ends the session.
+		return
+	}
+
+	session, _, err := h.services.Sessions.Get(context, sessionId)
+	if err != nil {
+		if !errors.Is(err, service.ErrSessionNotFound) {
+			logger.Error("handlerLogoutFrontchannel() - Get",
+				logger.NamedError("error", err),
+			)
+			return
+		}
+
+		coockie.Remove(context.Writer, coockie.Session)
+		return
+	}
+
+	if session.Sid != sid {
+		// The browser signed in to another login session.
+		return
+	}
 
 	// Delete tokens from storage.
-	coockie.Remove(context.Writer, "access_token")
-	coockie.Remove(context.Writer, "access_token_expires_in")
-	coockie.Remove(context.Writer, "refresh_token")
-	coockie.Remove(context.Writer, "id_token")
+	if err = h.services.Sessions.Delete(context, sessionId); err != nil {
+		logger.Error("handlerLogoutFrontchannel() - Delete",
+			logger.NamedError("error", err),
+		)
+	}
+
+	coockie.Remove(context.Writer, coockie.Session)
 }
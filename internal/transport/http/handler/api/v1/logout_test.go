@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"service-account/internal/transport/http/coockie"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAccountManagementAPI_logoutFrontchannel(t *testing.T) {
+	setWorkDir()
+
+	testTable := []struct {
+		name                 string
+		query                string
+		sessionId            string
+		mockBehaviorSessions func(mockSessions *mock_service.MockSessions)
+		expectedStatusCode   int
+		expectedRemoved      bool
+	}{
+		{
+			name:      "OK, own session of the login session is ended",
+			query:     "?iss=https%3A%2F%2Fhydra.localhost%2F&sid=sid",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{Sid: "sid"}, &domain.Token{}, nil)
+				mockSessions.EXPECT().Delete(gomock.Any(), "session").Return(nil)
+			},
+			expectedStatusCode: 200,
+			expectedRemoved:    true,
+		},
+		{
+			name:      "OK, own session of another login session is kept",
+			query:     "?iss=https%3A%2F%2Fhydra.localhost&sid=other",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{Sid: "sid"}, &domain.Token{}, nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:                 "OK, other browsers' sessions aren't ended by the sid",
+			query:                "?iss=https%3A%2F%2Fhydra.localhost&sid=sid",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			expectedStatusCode:   200,
+		},
+		{
+			name:                 "BAD, another issuer",
+			query:                "?iss=https%3A%2F%2Fevil.localhost&sid=sid",
+			sessionId:            "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			expectedStatusCode:   400,
+		},
+		{
+			name:                 "BAD, issuer is missing",
+			query:                "?sid=sid",
+			sessionId:            "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {},
+			expectedStatusCode:   400,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockSessions := mock_service.NewMockSessions(ctrl)
+			testCase.mockBehaviorSessions(mockSessions)
+			handler := NewHandlerAccountManagementAPI(&service.Services{
+				Sessions: mockSessions,
+				Config:   &config.Config{OAuth2: config.OAuth2Config{HydraPublicURL: "https://hydra.localhost"}},
+			})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.GET(pathLogoutFrontchannel, handler.logoutFrontchannel)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", pathLogoutFrontchannel+testCase.query, nil)
+			if testCase.sessionId != "" {
+				req.AddCookie(&http.Cookie{Name: coockie.Session, Value: testCase.sessionId})
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, strings.Contains(w.Header().Get("Set-Cookie"), coockie.Session+"="), testCase.expectedRemoved)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+	"service-account/internal/transport/http/response"
+	"service-account/pkg/logger"
+	"strconv"
+)
+
+const (
+	submitChangePassword = "Change password"
+	// The user without the password requests the one-time code confirming the new one.
+	submitSendCode = "Send code"
+)
+
+// passwordGet godoc
+// @Summary     Change password
+// @Description Get the password change page of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Router      /account/password [get]
+func (h *HandlerAccountManagementAPI) passwordGet(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	h.renderPassword(context, http.StatusOK, userId, gin.H{})
+}
+
+// passwordPost godoc
+// @Summary     Change password
+// @Description Change the password of the signed in user
+// @Tags        account
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /account/password [post]
+func (h *HandlerAccountManagementAPI) passwordPost(context *gin.Context) {
+	userId, ok := getSessionUserId(context)
+	if !ok {
+		// Not signed in, back to main page.
+		context.Redirect(http.StatusFound, pathRoot)
+		return
+	}
+
+	switch context.PostForm("submit") {
+	case submitChangePassword:
+	case submitSendCode:
+		if err := h.services.Account.SendVerificationCode(context, userId); err != nil {
+			response.AbortError(context, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.renderPassword(context, http.StatusOK, userId, gin.H{"message": "The one-time code was sent to your email."})
+		return
+	default:
+		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
+		return
+	}
+
+	err := h.services.Account.ChangePassword(context, userId, &service.ChangePasswordInput{
+		CurrentPassword:     context.PostForm("current_password"),
+		NewPassword:         context.PostForm("new_password"),
+		RevokeOtherSessions: context.PostForm("revoke_other_sessions") != "",
+		SessionId:           middleware.GetSessionId(context),
+		Code:                context.PostForm("code"),
+	})
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if isPasswordChangeError(err) {
+			// Render password html with error.
+			h.renderPassword(context, http.StatusBadRequest, userId, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err != nil {
+		// The password was changed anyway.
+		logger.Error("passwordPost() - notification", logger.NamedError("error", err))
+	}
+
+	h.renderPassword(context, http.StatusOK, userId, gin.H{"message": "Password changed."})
+}
+
+// renderPassword renders the password change page, the user without the password sets one with the one-time code.
+func (h *HandlerAccountManagementAPI) renderPassword(context *gin.Context, statusCode int, userId uint32, data gin.H) {
+	user, err := h.services.User.GetUserById(context, userId)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	data["action"] = pathAccountPassword
+	data["setPassword"] = !user.HasPassword()
+	response.HTML(context, statusCode, "password.html", data)
+}
+
+// getSessionUserId returns the user id of the browser session's subject.
+func getSessionUserId(context *gin.Context) (uint32, bool) {
+	token := middleware.GetSessionToken(context)
+	if token == nil || token.Subject == "" {
+		return 0, false
+	}
+
+	userId, err := strconv.ParseUint(token.Subject, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(userId), true
+}
+
+// isPasswordChangeError reports whether the password wasn't changed because of the user's input.
+func isPasswordChangeError(err error) bool {
+	return errors.Is(err, service.ErrPasswordIncorrect) || errors.Is(err, service.ErrPasswordPolicy) ||
+		errors.Is(err, service.ErrUserLocked) || isVerificationError(err)
+}
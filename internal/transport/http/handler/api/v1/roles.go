@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+)
+
+// userRolesGet godoc
+// @Summary     Get user roles
+// @Security 	ApiKeyAuth
+// @Description get the roles assigned to the user. Requires the "users:admin" scope and the "roles:manage" permission.
+// @Tags        roles
+// @Produce     json
+// @Success     200 {object} object{roles=[]string}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id   path int true "User ID"
+// @Router      /api/v1/users/{id}/roles [get]
+func (h *HandlerAccountManagementAPI) userRolesGet(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	roles, err := h.services.RBAC.GetUserRoles(context, userId)
+	if err != nil {
+		context.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"roles": roles,
+	})
+}
+
+// userRolePut godoc
+// @Summary     Assign role
+// @Security 	ApiKeyAuth
+// @Description assign the role to the user. Requires the "users:admin" scope and the "roles:manage" permission. The action is written to the audit trail.
+// @Tags        roles
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id   path int true "User ID"
+// @Param role path string true "Role name"
+// @Router      /api/v1/users/{id}/roles/{role} [put]
+func (h *HandlerAccountManagementAPI) userRolePut(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.Admin.AssignRole(context, actorId, userId, context.Param("role")); err != nil {
+		var statusCode int
+		if errors.Is(err, service.ErrRoleNotFound) {
+			statusCode = http.StatusNotFound
+		} else {
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// userRoleDelete godoc
+// @Summary     Revoke role
+// @Security 	ApiKeyAuth
+// @Description revoke the role from the user. Requires the "users:admin" scope and the "roles:manage" permission. The action is written to the audit trail.
+// @Tags        roles
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id   path int true "User ID"
+// @Param role path string true "Role name"
+// @Router      /api/v1/users/{id}/roles/{role} [delete]
+func (h *HandlerAccountManagementAPI) userRoleDelete(context *gin.Context) {
+	userId, ok := h.getUserIdParam(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.Admin.RevokeRole(context, actorId, userId, context.Param("role")); err != nil {
+		var statusCode int
+		if errors.Is(err, service.ErrRoleNotAssigned) {
+			statusCode = http.StatusNotFound
+		} else {
+			statusCode = http.StatusInternalServerError
+		}
+
+		context.IndentedJSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
@@ -0,0 +1,438 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/middleware"
+	"service-account/pkg/logger"
+	"time"
+)
+
+type serviceAccountInput struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	Description  string   `json:"description" binding:"max=1024"`
+	OwnerGroupId uint32   `json:"owner_group_id" binding:"required"`
+	Scope        []string `json:"scope" binding:"required,min=1"`
+	// HydraClient creates the Hydra client of the account getting the tokens by the client credentials grant.
+	HydraClient bool `json:"hydra_client"`
+}
+
+type serviceAccountKeyInput struct {
+	// ExpiresAt is the key's expiry, the key expires after the max lifetime if it's not set.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// serviceAccountsList godoc
+// @Summary     List service accounts
+// @Security 	ApiKeyAuth
+// @Description list the tenant's service accounts ordered by the name. Requires the "users:admin" scope and the "service_accounts:manage" permission.
+// @Tags        service-accounts
+// @Produce     json
+// @Success     200 {object} object{service_accounts=[]object{id=uint32,client_id=string,hydra_client=bool,name=string,description=string,owner_group_id=uint32,scope=[]string,created_by=uint32,date_created=time.Time,date_updated=time.Time}}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /api/v1/service-accounts [get]
+func (h *HandlerAccountManagementAPI) serviceAccountsList(context *gin.Context) {
+	accounts, err := h.services.ServiceAccounts.List(context)
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountsList()", err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(accounts))
+	for i := range accounts {
+		response = append(response, serviceAccountResponse(&accounts[i]))
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"service_accounts": response,
+	})
+}
+
+// serviceAccountPost godoc
+// @Summary     Create service account
+// @Security 	ApiKeyAuth
+// @Description create the service account owned by the team, its name is unique in the tenant. Requires the "users:admin" scope and the "service_accounts:manage" permission.
+// @Description The account with "hydra_client" gets the tokens by the client credentials grant of its Hydra client too, the client's secret is returned only once. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} object{service_account=object{id=uint32,client_id=string,hydra_client=bool,name=string,description=string,owner_group_id=uint32,scope=[]string,created_by=uint32,date_created=time.Time,date_updated=time.Time},client_secret=string}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param input body serviceAccountInput true "Service account"
+// @Router      /api/v1/service-accounts [post]
+func (h *HandlerAccountManagementAPI) serviceAccountPost(context *gin.Context) {
+	var input serviceAccountInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	account, clientSecret, err := h.services.ServiceAccounts.Create(context, actorId, &service.ServiceAccountInput{
+		Name:         input.Name,
+		Description:  input.Description,
+		OwnerGroupId: input.OwnerGroupId,
+		Scope:        input.Scope,
+		HydraClient:  input.HydraClient,
+	})
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountPost()", err)
+		return
+	}
+
+	response := gin.H{
+		"service_account": serviceAccountResponse(account),
+	}
+	if clientSecret != "" {
+		response["client_secret"] = clientSecret
+	}
+
+	context.IndentedJSON(http.StatusCreated, response)
+}
+
+// serviceAccountGet godoc
+// @Summary     Get service account
+// @Security 	ApiKeyAuth
+// @Description get the service account. Requires the "users:read" scope, the account is read by the owner team's admins and the users with the "service_accounts:manage" permission.
+// @Tags        service-accounts
+// @Produce     json
+// @Success     200 {object} object{service_account=object{id=uint32,client_id=string,hydra_client=bool,name=string,description=string,owner_group_id=uint32,scope=[]string,created_by=uint32,date_created=time.Time,date_updated=time.Time}}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Service account ID"
+// @Router      /api/v1/service-accounts/{id} [get]
+func (h *HandlerAccountManagementAPI) serviceAccountGet(context *gin.Context) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	account, err := h.services.ServiceAccounts.Get(context, actorId, id)
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountGet()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"service_account": serviceAccountResponse(account),
+	})
+}
+
+// serviceAccountPut godoc
+// @Summary     Update service account
+// @Security 	ApiKeyAuth
+// @Description change the service account's name, description, owner team and scope, the Hydra client's ones too. Requires the "users:admin" scope and the "service_accounts:manage" permission.
+// @Description The "hydra_client" is ignored. The issued Hydra tokens keep their scope until they expire. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object{service_account=object{id=uint32,client_id=string,hydra_client=bool,name=string,description=string,owner_group_id=uint32,scope=[]string,created_by=uint32,date_created=time.Time,date_updated=time.Time}}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     409 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int                 true "Service account ID"
+// @Param input body serviceAccountInput true "Service account"
+// @Router      /api/v1/service-accounts/{id} [put]
+func (h *HandlerAccountManagementAPI) serviceAccountPut(context *gin.Context) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return
+	}
+
+	var input serviceAccountInput
+	if err := context.ShouldBindJSON(&input); err != nil {
+		abortValidationError(context, err)
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	account, err := h.services.ServiceAccounts.Update(context, actorId, id, &service.ServiceAccountInput{
+		Name:         input.Name,
+		Description:  input.Description,
+		OwnerGroupId: input.OwnerGroupId,
+		Scope:        input.Scope,
+	})
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountPut()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"service_account": serviceAccountResponse(account),
+	})
+}
+
+// serviceAccountDelete godoc
+// @Summary     Delete service account
+// @Security 	ApiKeyAuth
+// @Description delete the service account with its API keys and its Hydra client. Requires the "users:admin" scope and the "service_accounts:manage" permission. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Service account ID"
+// @Router      /api/v1/service-accounts/{id} [delete]
+func (h *HandlerAccountManagementAPI) serviceAccountDelete(context *gin.Context) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.ServiceAccounts.Delete(context, actorId, id); err != nil {
+		abortServiceAccountError(context, "serviceAccountDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// serviceAccountKeysList godoc
+// @Summary     List service account's API keys
+// @Security 	ApiKeyAuth
+// @Description list the service account's API keys, the newest first, the revoked and expired ones too. Requires the "users:read" scope, the keys are read by the owner team's admins and the users with the "service_accounts:manage" permission.
+// @Tags        service-accounts
+// @Produce     json
+// @Success     200 {object} object{keys=[]object{id=uint32,prefix=string,active=bool,date_created=time.Time,date_expires=time.Time,date_last_used=time.Time,date_revoked=time.Time}}
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id path int true "Service account ID"
+// @Router      /api/v1/service-accounts/{id}/keys [get]
+func (h *HandlerAccountManagementAPI) serviceAccountKeysList(context *gin.Context) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	keys, err := h.services.ServiceAccounts.ListKeys(context, actorId, id)
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountKeysList()", err)
+		return
+	}
+
+	now := time.Now()
+	response := make([]gin.H, 0, len(keys))
+	for i := range keys {
+		response = append(response, serviceAccountKeyResponse(&keys[i], now))
+	}
+
+	context.IndentedJSON(http.StatusOK, gin.H{
+		"keys": response,
+	})
+}
+
+// serviceAccountKeyPost godoc
+// @Summary     Create service account's API key
+// @Security 	ApiKeyAuth
+// @Description issue the service account's API key expiring at "expires_at" or after the max lifetime, the key is returned only once. Requires the "users:write" scope,
+// @Description the keys are managed by the owner team's admins and the users with the "service_accounts:manage" permission. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} object{key=object{id=uint32,prefix=string,active=bool,date_created=time.Time,date_expires=time.Time,date_last_used=time.Time,date_revoked=time.Time},api_key=string}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id    path int                    true  "Service account ID"
+// @Param input body serviceAccountKeyInput false "Key's expiry"
+// @Router      /api/v1/service-accounts/{id}/keys [post]
+func (h *HandlerAccountManagementAPI) serviceAccountKeyPost(context *gin.Context) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return
+	}
+
+	input, ok := bindServiceAccountKeyInput(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	key, apiKey, err := h.services.ServiceAccounts.CreateKey(context, actorId, id, input.ExpiresAt)
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountKeyPost()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusCreated, gin.H{
+		"key":     serviceAccountKeyResponse(key, time.Now()),
+		"api_key": apiKey,
+	})
+}
+
+// serviceAccountKeyRotatePost godoc
+// @Summary     Rotate service account's API key
+// @Security 	ApiKeyAuth
+// @Description issue the new API key replacing the active key, the replaced key stays valid for the rotation grace period. The new key is returned only once. Requires the "users:write" scope,
+// @Description the keys are managed by the owner team's admins and the users with the "service_accounts:manage" permission. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} object{key=object{id=uint32,prefix=string,active=bool,date_created=time.Time,date_expires=time.Time,date_last_used=time.Time,date_revoked=time.Time},api_key=string}
+// @Failure     400 {object} object{error=string,fields=map[string]string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id     path int                    true  "Service account ID"
+// @Param key_id path int                    true  "Key ID"
+// @Param input  body serviceAccountKeyInput false "New key's expiry"
+// @Router      /api/v1/service-accounts/{id}/keys/{key_id}/rotate [post]
+func (h *HandlerAccountManagementAPI) serviceAccountKeyRotatePost(context *gin.Context) {
+	id, keyId, ok := getServiceAccountKeyParams(context)
+	if !ok {
+		return
+	}
+
+	input, ok := bindServiceAccountKeyInput(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	key, apiKey, err := h.services.ServiceAccounts.RotateKey(context, actorId, id, keyId, input.ExpiresAt)
+	if err != nil {
+		abortServiceAccountError(context, "serviceAccountKeyRotatePost()", err)
+		return
+	}
+
+	context.IndentedJSON(http.StatusCreated, gin.H{
+		"key":     serviceAccountKeyResponse(key, time.Now()),
+		"api_key": apiKey,
+	})
+}
+
+// serviceAccountKeyDelete godoc
+// @Summary     Revoke service account's API key
+// @Security 	ApiKeyAuth
+// @Description revoke the service account's API key at once. Requires the "users:write" scope,
+// @Description the keys are managed by the owner team's admins and the users with the "service_accounts:manage" permission. The action is written to the audit trail.
+// @Tags        service-accounts
+// @Produce     json
+// @Success     204
+// @Failure     400 {object} object{error=string}
+// @Failure     401 {object} object{error=string}
+// @Failure     403 {object} object{error=string}
+// @Failure     404 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Param id     path int true "Service account ID"
+// @Param key_id path int true "Key ID"
+// @Router      /api/v1/service-accounts/{id}/keys/{key_id} [delete]
+func (h *HandlerAccountManagementAPI) serviceAccountKeyDelete(context *gin.Context) {
+	id, keyId, ok := getServiceAccountKeyParams(context)
+	if !ok {
+		return
+	}
+
+	actorId, _ := middleware.GetSubjectUserId(context)
+	if err := h.services.ServiceAccounts.RevokeKey(context, actorId, id, keyId); err != nil {
+		abortServiceAccountError(context, "serviceAccountKeyDelete()", err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+func getServiceAccountKeyParams(context *gin.Context) (uint32, uint32, bool) {
+	id, ok := getIdParam(context, "id", "service account")
+	if !ok {
+		return 0, 0, false
+	}
+
+	keyId, ok := getIdParam(context, "key_id", "key")
+	if !ok {
+		return 0, 0, false
+	}
+
+	return id, keyId, true
+}
+
+// bindServiceAccountKeyInput binds the optional body of the key's request.
+func bindServiceAccountKeyInput(context *gin.Context) (*serviceAccountKeyInput, bool) {
+	input := new(serviceAccountKeyInput)
+	if err := context.ShouldBindJSON(input); err != nil && !errors.Is(err, io.EOF) {
+		abortValidationError(context, err)
+		return nil, false
+	}
+
+	return input, true
+}
+
+// abortServiceAccountError responds with the error of the service accounts' management.
+func abortServiceAccountError(context *gin.Context, handlerName string, err error) {
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrServiceAccountNameInvalid), errors.Is(err, service.ErrServiceAccountScopeInvalid),
+		errors.Is(err, service.ErrServiceAccountKeyExpiry):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, service.ErrServiceAccountAccessDenied):
+		statusCode = http.StatusForbidden
+	case errors.Is(err, service.ErrServiceAccountNotFound), errors.Is(err, service.ErrServiceAccountKeyNotFound),
+		errors.Is(err, service.ErrGroupNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrServiceAccountAlreadyExist):
+		statusCode = http.StatusConflict
+	default:
+		statusCode = http.StatusInternalServerError
+		logger.Error(handlerName, logger.NamedError("error", err))
+	}
+
+	context.IndentedJSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
+
+func serviceAccountResponse(account *domain.ServiceAccount) gin.H {
+	return gin.H{
+		"id":             account.Id,
+		"client_id":      account.ClientId,
+		"hydra_client":   account.HydraClient,
+		"name":           account.Name,
+		"description":    account.Description,
+		"owner_group_id": account.OwnerGroupId,
+		"scope":          account.Scopes(),
+		"created_by":     account.CreatedBy,
+		"date_created":   account.DateCreated,
+		"date_updated":   account.DateUpdated,
+	}
+}
+
+func serviceAccountKeyResponse(key *domain.ServiceAccountKey, now time.Time) gin.H {
+	return gin.H{
+		"id":             key.Id,
+		"prefix":         key.Prefix,
+		"active":         key.Active(now),
+		"date_created":   key.DateCreated,
+		"date_expires":   key.DateExpires,
+		"date_last_used": key.DateLastUsed,
+		"date_revoked":   key.DateRevoked,
+	}
+}
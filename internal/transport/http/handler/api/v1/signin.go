@@ -6,9 +6,10 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"service-account/internal/domain"
 	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
 	"service-account/internal/transport/http/response"
-	"service-account/pkg/convert_to"
 )
 
 // signinGet godoc
@@ -36,41 +37,28 @@ func (h *HandlerAccountManagementAPI) signinGet(context *gin.Context) {
 	}
 
 	// Get signin request.
-	signinRequestData, err := h.services.OAuth2.GetLoginRequest(context, challenge)
+	// If hydra was already able to authenticate the user, the signin is accepted at once.
+	loginFlow, err := h.services.Flow.GetLoginFlow(context, challenge)
 	if err != nil {
 		response.AbortError(context, http.StatusInternalServerError, err)
 		return
 	}
 
-	// If hydra was already able to authenticate the user, skip will be true, and we do not need to re-authenticate
-	// the user.
-	if signinRequestData.Skip {
-		// You can apply logic here, for example update the number of times the user logged in.
-		// ...
-
-		// Now it's time to grant the signin request. You could also deny the request if something went terribly wrong
-		// (e.g. your arch-enemy logging in...)
-
-		// Accept signin.
-		RedirectTo, err := h.services.OAuth2.AcceptLoginRequest(context, challenge, signinRequestData.Subject, true, 3600)
-		if err != nil {
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
-		}
-
-		context.Redirect(http.StatusFound, RedirectTo)
+	if loginFlow.RedirectTo != "" {
+		context.Redirect(http.StatusFound, loginFlow.RedirectTo)
 		return
 	}
 
 	// Render signin html.
-	// TODO: csrfToken for forms.
-	context.HTML(http.StatusOK, "signin.html",
+	// The remembered user signs in again if the client's prompt, max_age or acr_values require it.
+	// The page has the branding of the client's tenant.
+	h.renderSignin(context, http.StatusOK, withBranding(
 		gin.H{
-			"csrfToken": "",
-			"challenge": challenge,
-			"action":    pathSignin,
-			"hint":      signinRequestData.Hint,
-		})
+			"challenge":      challenge,
+			"hint":           loginFlow.Hint,
+			"reauthenticate": loginFlow.Reauthenticate,
+			"locales":        loginFlow.UiLocales,
+		}, loginFlow.Tenant))
 }
 
 // signinPost godoc
@@ -93,74 +81,148 @@ func (h *HandlerAccountManagementAPI) signinPost(context *gin.Context) {
 	}
 
 	submit := context.PostForm("submit")
-	if submit == submitDenyAccess {
-		// Reject signin request.
-		redirectTo, err := h.services.OAuth2.RejectLoginRequest(context, challenge, "access_denied", "The resource owner denied the request")
-		if err != nil {
-			// Error request to hydra OAuth admin API.
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
-		}
-
-		context.Redirect(http.StatusFound, redirectTo)
-		return
-	} else if submit != submitLogIn {
+	if submit != submitDenyAccess && submit != submitLogIn {
 		response.AbortMessage(context, http.StatusBadRequest, "Unexpected submit!")
 		return
 	}
 
-	// Check the user's credentials.
-	var userEmail = context.PostForm("email")
-	var userPassword = context.PostForm("password")
-
-	// Check authentication.
-	inputUserData := &service.UserSignInInput{
-		Email:    userEmail,
-		Password: userPassword,
+	// Recognize the device by its cookie or issue the new one, the failed attempts are linked to the device too.
+	deviceId, err := h.identifyDevice(context)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
 	}
 
-	user, err := h.services.User.SignIn(context, inputUserData)
-	if err != nil {
-		var statusCode int
-		switch {
-		case errors.Is(err, service.ErrUserNotFound),
-			errors.Is(err, service.ErrPasswordIncorrect):
-			statusCode = http.StatusBadRequest
-		default:
-			statusCode = http.StatusInternalServerError
-		}
+	// Check the user's credentials and accept or reject signin request.
+	inputLoginData := &service.LoginSubmitInput{
+		Accept:   submit == submitLogIn,
+		Login:    signinLogin(context),
+		Password: context.PostForm("password"),
+		// Remember auth signin session?
+		Remember: context.PostForm("remember") != "",
+		DeviceId: deviceId,
+	}
 
-		// Render signin html with error.
-		// TODO: csrfToken for forms.
-		context.HTML(statusCode, "signin.html",
+	redirectTo, err := h.services.Flow.SubmitLogin(context, challenge, inputLoginData)
+	if errors.Is(err, service.ErrStepUpRequired) {
+		// The risky signin waits for the one-time code sent to the user's email.
+		response.HTML(context, http.StatusOK, "signin_code.html",
 			gin.H{
-				"csrfToken": "",
 				"challenge": challenge,
-				"action":    pathSignin,
-				"error":     err.Error(),
+				"action":    pathSigninCode,
 			},
 		)
 		return
 	}
 
-	// Get signin request.
-	if _, err = h.services.OAuth2.GetLoginRequest(context, challenge); err != nil {
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		if inputLoginData.Accept && isCredentialsError(err) {
+			// Render signin html with error.
+			h.renderSignin(context, http.StatusBadRequest,
+				gin.H{
+					"challenge": challenge,
+					"error":     err.Error(),
+				},
+			)
+			return
+		}
+
+		// Error request to hydra OAuth admin API.
 		response.AbortError(context, http.StatusInternalServerError, err)
 		return
 	}
 
-	// Remember auth signin session?
-	var remember bool
-	if context.PostForm("remember") != "" {
-		remember = true
+	context.Redirect(http.StatusFound, redirectTo)
+}
+
+// signinCodePost godoc
+// @Summary     Signin user
+// @Description Confirm the risky signin with the one-time code sent to the user's email
+// @Tags        auth
+// @Produce     html
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /signin/code [post]
+func (h *HandlerAccountManagementAPI) signinCodePost(context *gin.Context) {
+	challenge := context.PostForm("challenge")
+	if challenge == "" {
+		response.AbortMessage(context, http.StatusBadRequest, "signinCodePost(): Expected a signin challenge to be set but received none.")
+		return
 	}
 
-	// Accept signin request.
-	redirectTo, err := h.services.OAuth2.AcceptLoginRequest(context, challenge, convert_to.ToString(user.Id), remember, 3600)
-	if err != nil {
-		response.AbortError(context, http.StatusInternalServerError, err)
+	redirectTo, err := h.services.Flow.SubmitLoginCode(context, challenge, context.PostForm("code"))
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		switch {
+		case errors.Is(err, service.ErrStepUpCodeIncorrect):
+			response.HTML(context, http.StatusBadRequest, "signin_code.html",
+				gin.H{
+					"challenge": challenge,
+					"action":    pathSigninCode,
+					"error":     err.Error(),
+				},
+			)
+		case errors.Is(err, service.ErrStepUpExpired) || isCredentialsError(err):
+			// The user signs in again with the same challenge.
+			h.renderSignin(context, http.StatusBadRequest,
+				gin.H{
+					"challenge": challenge,
+					"error":     err.Error(),
+				},
+			)
+		default:
+			response.AbortError(context, http.StatusInternalServerError, err)
+		}
 		return
 	}
 
 	context.Redirect(http.StatusFound, redirectTo)
 }
+
+// renderSignin renders the signin page with the identity providers' buttons.
+func (h *HandlerAccountManagementAPI) renderSignin(context *gin.Context, statusCode int, data gin.H) {
+	data["action"] = pathSignin
+	data["idpAction"] = pathSigninIdP
+	data["providers"] = h.services.Identity.Providers()
+	response.HTML(context, statusCode, "signin.html", data)
+}
+
+// withBranding sets the branding of the flow client's tenant, the page has the request tenant's one if it's unknown.
+func withBranding(data gin.H, tenant *domain.Tenant) gin.H {
+	if tenant != nil {
+		data["branding"] = tenant.Branding
+	}
+
+	return data
+}
+
+// identifyDevice recognizes the device by its cookie or issues the new one, returns the device's ID.
+func (h *HandlerAccountManagementAPI) identifyDevice(context *gin.Context) (string, error) {
+	deviceCookie, _ := coockie.GetValue(context.Request, coockie.Device)
+	deviceId, deviceCookie, err := h.services.Devices.Identify(deviceCookie)
+	if err != nil {
+		return "", err
+	}
+
+	if deviceCookie != "" {
+		coockie.SetDevice(context.Writer, deviceCookie, h.services.Config.Device.CookieMaxAge)
+	}
+
+	return deviceId, nil
+}
+
+// isCredentialsError reports whether the signin failed because of the user's credentials or the account's state.
+func isCredentialsError(err error) bool {
+	return errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrPasswordIncorrect) ||
+		errors.Is(err, service.ErrUserSuspended) || errors.Is(err, service.ErrUserLocked) ||
+		errors.Is(err, service.ErrLoginSubjectMismatch) || errors.Is(err, service.ErrDirectoryEmailTaken)
+}
+
+// signinLogin returns the username or email of the signin form, the former "email" field is still accepted.
+func signinLogin(context *gin.Context) string {
+	if login := context.PostForm("login"); login != "" {
+		return login
+	}
+
+	return context.PostForm("email")
+}
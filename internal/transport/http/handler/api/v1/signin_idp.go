@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
+	"service-account/internal/transport/http/response"
+)
+
+// signinIdPPost godoc
+// @Summary     Signin user
+// @Description Sign in with the upstream identity provider, the user is redirected to the provider
+// @Tags        auth
+// @Produce     html
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /signin/idp [post]
+func (h *HandlerAccountManagementAPI) signinIdPPost(context *gin.Context) {
+	challenge := context.PostForm("challenge")
+	if challenge == "" {
+		response.AbortMessage(context, http.StatusBadRequest, "signinIdPPost(): Expected a signin challenge to be set but received none.")
+		return
+	}
+
+	deviceId, err := h.identifyDevice(context)
+	if err != nil {
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	authCodeUrl, state, err := h.services.Flow.StartUpstreamLogin(context, challenge, &service.UpstreamLoginStartInput{
+		ProviderId: context.PostForm("provider"),
+		Remember:   context.PostForm("remember") != "",
+		DeviceId:   deviceId,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrIdentityProviderNotFound) {
+			response.AbortMessage(context, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		response.AbortError(context, http.StatusInternalServerError, err)
+		return
+	}
+
+	coockie.SetUpstreamState(context.Writer, state, h.services.Config.IdP.LoginTTL)
+	context.Redirect(http.StatusFound, authCodeUrl)
+}
+
+// signinIdPCallback godoc
+// @Summary     Signin user
+// @Description The identity provider's callback, the signin is completed with the user mapped to the provider's subject
+// @Tags        auth
+// @Produce     html
+// @Param       state query string true  "State"
+// @Param       code  query string false "Authorization code"
+// @Param       error query string false "Provider's error"
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /signin/idp/callback [get]
+func (h *HandlerAccountManagementAPI) signinIdPCallback(context *gin.Context) {
+	// The callback must come to the browser that started the signin.
+	state := context.Query("state")
+	cookieState, _ := coockie.GetValue(context.Request, coockie.UpstreamState)
+	coockie.Remove(context.Writer, coockie.UpstreamState)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		response.AbortMessage(context, http.StatusBadRequest, "signinIdPCallback(): The signin state doesn't match the browser's one.")
+		return
+	}
+
+	loginFlow, err := h.services.Flow.SubmitUpstreamLogin(context, &service.UpstreamLoginSubmitInput{
+		State: state,
+		Code:  context.Query("code"),
+		Error: context.Query("error"),
+	})
+	if errors.Is(err, service.ErrIdentityLinkRequired) {
+		// The account with the provider's email is linked once its owner enters the password.
+		h.renderSigninLink(context, http.StatusOK, loginFlow, "")
+		return
+	}
+
+	h.redirectLogin(context, loginFlow, err)
+}
+
+// signinIdPLinkPost godoc
+// @Summary     Signin user
+// @Description Link the identity provider to the account with the provider's email by the account's password and sign in
+// @Tags        auth
+// @Produce     html
+// @Success     200 {object} object{error=string}
+// @Success     302 {object} object{error=string}
+// @Failure     400 {object} object{error=string}
+// @Failure     500 {object} object{error=string}
+// @Router      /signin/idp/link [post]
+func (h *HandlerAccountManagementAPI) signinIdPLinkPost(context *gin.Context) {
+	loginFlow, err := h.services.Flow.SubmitIdentityLink(context, &service.IdentityLinkSubmitInput{
+		Token:    context.PostForm("token"),
+		Password: context.PostForm("password"),
+	})
+	if loginFlow != nil && loginFlow.Link != nil && isCredentialsError(err) {
+		// The user enters the password again.
+		h.renderSigninLink(context, http.StatusBadRequest, loginFlow, err.Error())
+		return
+	}
+
+	h.redirectLogin(context, loginFlow, err)
+}
+
+// redirectLogin redirects the user signed in with the identity provider or renders the error.
+func (h *HandlerAccountManagementAPI) redirectLogin(context *gin.Context, loginFlow *domain.LoginFlow, err error) {
+	if errors.Is(err, service.ErrStepUpRequired) {
+		// The risky signin waits for the one-time code sent to the user's email.
+		response.HTML(context, http.StatusOK, "signin_code.html",
+			gin.H{
+				"challenge": loginFlow.Challenge,
+				"action":    pathSigninCode,
+			},
+		)
+		return
+	}
+
+	if err != nil && !errors.Is(err, service.ErrNotificationFailed) {
+		switch {
+		case loginFlow != nil && loginFlow.Challenge != "" && (isUpstreamLoginError(err) || isCredentialsError(err)):
+			// The user signs in again with the same challenge.
+			h.renderSignin(context, http.StatusBadRequest,
+				gin.H{
+					"challenge": loginFlow.Challenge,
+					"error":     err.Error(),
+				},
+			)
+		case isUpstreamLoginError(err) || isIdentityLinkError(err):
+			response.AbortMessage(context, http.StatusBadRequest, err.Error())
+		default:
+			response.AbortError(context, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	context.Redirect(http.StatusFound, loginFlow.RedirectTo)
+}
+
+// renderSigninLink renders the page of the identity waiting for the account's password.
+func (h *HandlerAccountManagementAPI) renderSigninLink(context *gin.Context, statusCode int, loginFlow *domain.LoginFlow, errMessage string) {
+	response.HTML(context, statusCode, "signin_link.html", withBranding(
+		gin.H{
+			"action":   pathSigninIdPLink,
+			"token":    loginFlow.Link.Token,
+			"provider": h.providerName(loginFlow.Link.Provider),
+			"email":    loginFlow.Link.Email,
+			"error":    errMessage,
+		}, loginFlow.Tenant),
+	)
+}
+
+// providerName returns the display name of the configured provider.
+func (h *HandlerAccountManagementAPI) providerName(providerId string) string {
+	for _, provider := range h.services.Identity.Providers() {
+		if provider.Id == providerId {
+			return provider.Name
+		}
+	}
+
+	return providerId
+}
+
+// isUpstreamLoginError reports whether the signin with the identity provider failed because of the provider's response
+// or the user can't be registered with it.
+func isUpstreamLoginError(err error) bool {
+	return errors.Is(err, service.ErrUpstreamLoginExpired) || errors.Is(err, service.ErrUpstreamLoginFailed) ||
+		errors.Is(err, service.ErrIdentityEmailUnverified) || errors.Is(err, service.ErrIdentityEmailTaken) ||
+		errors.Is(err, service.ErrEmailInvalid) || errors.Is(err, service.ErrUserAlreadyExist) ||
+		errors.Is(err, service.ErrUsernameTaken)
+}
+
+// isIdentityLinkError reports whether the identity wasn't linked to the account.
+func isIdentityLinkError(err error) bool {
+	return errors.Is(err, service.ErrIdentityLinked) || errors.Is(err, service.ErrIdentityLinkExpired)
+}
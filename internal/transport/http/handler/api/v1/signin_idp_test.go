@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"service-account/internal/transport/http/coockie"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAccountManagementAPI_signinIdPCallback(t *testing.T) {
+	setWorkDir()
+
+	testTable := []struct {
+		name                 string
+		query                string
+		cookieState          string
+		mockBehaviorFlow     mockBehaviorFlow
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedBodyContains string
+	}{
+		{
+			name:        "OK, signed in",
+			query:       "?state=state&code=code",
+			cookieState: "state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitUpstreamLogin(gomock.Any(), &service.UpstreamLoginSubmitInput{State: "state", Code: "code"}).
+					Return(&domain.LoginFlow{Challenge: "challenge", RedirectTo: "http://127.0.0.1:4444/oauth2/auth?login_verifier=verifier"}, nil)
+			},
+			expectedStatusCode: 302,
+			expectedLocation:   "http://127.0.0.1:4444/oauth2/auth?login_verifier=verifier",
+		},
+		{
+			name:        "BAD, email of local account",
+			query:       "?state=state&code=code",
+			cookieState: "state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitUpstreamLogin(gomock.Any(), gomock.Any()).
+					Return(&domain.LoginFlow{Challenge: "challenge"}, service.ErrIdentityEmailTaken)
+			},
+			expectedStatusCode:   400,
+			expectedBodyContains: service.ErrIdentityEmailTaken.Error(),
+		},
+		{
+			name:        "OK, email of local account waits for password",
+			query:       "?state=state&code=code",
+			cookieState: "state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitUpstreamLogin(gomock.Any(), gomock.Any()).
+					Return(&domain.LoginFlow{Challenge: "challenge", Link: &domain.PendingLink{Token: "link-token", Provider: "keycloak", Email: "alice@mail.com"}},
+						service.ErrIdentityLinkRequired)
+			},
+			expectedStatusCode:   200,
+			expectedBodyContains: `name="token" value="link-token"`,
+		},
+		{
+			name:        "OK, identity linked to signed in user",
+			query:       "?state=state&code=code",
+			cookieState: "state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitUpstreamLogin(gomock.Any(), gomock.Any()).
+					Return(&domain.LoginFlow{RedirectTo: service.PathAccountIdentities}, nil)
+			},
+			expectedStatusCode: 302,
+			expectedLocation:   service.PathAccountIdentities,
+		},
+		{
+			name:        "BAD, identity of another account",
+			query:       "?state=state&code=code",
+			cookieState: "state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitUpstreamLogin(gomock.Any(), gomock.Any()).Return(nil, service.ErrIdentityLinked)
+			},
+			expectedStatusCode:   400,
+			expectedBodyContains: service.ErrIdentityLinked.Error(),
+		},
+		{
+			name:        "BAD, state of another browser",
+			query:       "?state=state&code=code",
+			cookieState: "another state",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				// Nothing
+			},
+			expectedStatusCode:   400,
+			expectedBodyContains: "state doesn't match",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			mockIdentity := mock_service.NewMockIdentity(ctrl)
+			mockIdentity.EXPECT().Providers().Return(nil).AnyTimes()
+			handler := NewHandlerAccountManagementAPI(&service.Services{Flow: mockFlow, Identity: mockIdentity})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.GET(pathSigninIdPCallback, handler.signinIdPCallback)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", pathSigninIdPCallback+testCase.query, nil)
+			req.AddCookie(&http.Cookie{Name: coockie.UpstreamState, Value: testCase.cookieState})
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), testCase.expectedLocation)
+			assert.Equal(t, strings.Contains(w.Body.String(), testCase.expectedBodyContains), true)
+		})
+	}
+}
+
+func TestHandlerAccountManagementAPI_signinIdPLinkPost(t *testing.T) {
+	setWorkDir()
+	pendingLink := &domain.PendingLink{Token: "link-token", Provider: "keycloak", Email: "alice@mail.com"}
+
+	testTable := []struct {
+		name                 string
+		mockBehaviorFlow     mockBehaviorFlow
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedBodyContains string
+	}{
+		{
+			name: "OK",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitIdentityLink(gomock.Any(), &service.IdentityLinkSubmitInput{Token: "link-token", Password: "password"}).
+					Return(&domain.LoginFlow{Challenge: "challenge", RedirectTo: "http://127.0.0.1:4444/oauth2/auth?login_verifier=verifier"}, nil)
+			},
+			expectedStatusCode: 302,
+			expectedLocation:   "http://127.0.0.1:4444/oauth2/auth?login_verifier=verifier",
+		},
+		{
+			name: "BAD, wrong password",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitIdentityLink(gomock.Any(), gomock.Any()).
+					Return(&domain.LoginFlow{Challenge: "challenge", Link: pendingLink}, service.ErrPasswordIncorrect)
+			},
+			expectedStatusCode:   400,
+			expectedBodyContains: `name="token" value="link-token"`,
+		},
+		{
+			name: "BAD, expired link",
+			mockBehaviorFlow: func(mockFlow *mock_service.MockFlow) {
+				mockFlow.EXPECT().SubmitIdentityLink(gomock.Any(), gomock.Any()).Return(nil, service.ErrIdentityLinkExpired)
+			},
+			expectedStatusCode:   400,
+			expectedBodyContains: service.ErrIdentityLinkExpired.Error(),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockFlow := mock_service.NewMockFlow(ctrl)
+			testCase.mockBehaviorFlow(mockFlow)
+			mockIdentity := mock_service.NewMockIdentity(ctrl)
+			mockIdentity.EXPECT().Providers().Return([]domain.IdentityProvider{{Id: "keycloak", Name: "Corporate account"}}).AnyTimes()
+			handler := NewHandlerAccountManagementAPI(&service.Services{Flow: mockFlow, Identity: mockIdentity})
+
+			// Init Endpoint
+			r := initEndpoint()
+			r.POST(pathSigninIdPLink, handler.signinIdPLinkPost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", pathSigninIdPLink, strings.NewReader("token=link-token&password=password"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), testCase.expectedLocation)
+			assert.Equal(t, strings.Contains(w.Body.String(), testCase.expectedBodyContains), true)
+		})
+	}
+}
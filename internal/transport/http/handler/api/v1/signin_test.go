@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"service-account/internal/config"
 	"service-account/internal/domain"
 	"service-account/internal/service"
 	mock_service "service-account/internal/service/mocks"
@@ -74,11 +75,35 @@ func initArrange(ctrl *gomock.Controller, testCase *TestTable) *HandlerAccountMa
 	mockUser := mock_service.NewMockUser(ctrl)
 	testCase.GetmockBehaviorUser()(mockUser)
 
+	// The flows' audit events and devices are checked by the service's tests.
+	mockAudit := mock_service.NewMockAudit(ctrl)
+	mockAudit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDevices := mock_service.NewMockDevices(ctrl)
+	mockDevices.EXPECT().Identify(gomock.Any()).Return("", "", nil).AnyTimes()
+	mockDevices.EXPECT().Describe(gomock.Any(), gomock.Any()).Return(map[string]string{}).AnyTimes()
+	mockDevices.EXPECT().Recognize(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	mockRisk := mock_service.NewMockRisk(ctrl)
+	mockRisk.EXPECT().Assess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockIdentity := mock_service.NewMockIdentity(ctrl)
+	mockIdentity.EXPECT().Providers().Return(nil).AnyTimes()
+
 	services := service.NewService(
 		nil,
 		nil,
 		mockOAuth2,
 		mockUser,
+		nil,
+		nil,
+		nil,
+		mockAudit,
+		nil,
+		mockDevices,
+		mockRisk,
+		mockIdentity,
+		nil,
+		service.NewTenantService(&config.Config{}),
+		nil,
+		nil,
 	)
 
 	return NewHandlerAccountManagementAPI(services)
@@ -119,7 +144,7 @@ func TestHandlerAccountManagementAPI_loginGet(t *testing.T) {
 				name:      "OK, authorized already",
 				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
-					subject := "foo@bar.com"
+					subject := "1"
 
 					mockOAuth.EXPECT().GetLoginRequest(gomock.Any(), challenge).Return(&domain.OA2LoginRequest{
 						Skip:    true,
@@ -127,10 +152,11 @@ func TestHandlerAccountManagementAPI_loginGet(t *testing.T) {
 						Hint:    "",
 					}, nil)
 
-					mockOAuth.EXPECT().AcceptLoginRequest(gomock.Any(), challenge, subject, true, int64(3600)).Return("redirectToURL", nil)
+					mockOAuth.EXPECT().AcceptLoginRequest(gomock.Any(), challenge, subject, true, int64(3600), domain.AcrRemembered).Return("redirectToURL", nil)
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
-					// Nothing
+					mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1}, nil)
+					mockUser.EXPECT().UpdateLastOnline(gomock.Any(), uint32(1)).Return(nil)
 				},
 				expectedStatusCode: 302,
 			},
@@ -169,7 +195,7 @@ func TestHandlerAccountManagementAPI_loginGet(t *testing.T) {
 				name:      "BAD, authorized already but AcceptLoginRequest error",
 				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
-					subject := "foo@bar.com"
+					subject := "1"
 
 					mockOAuth.EXPECT().GetLoginRequest(gomock.Any(), challenge).Return(&domain.OA2LoginRequest{
 						Skip:    true,
@@ -177,15 +203,58 @@ func TestHandlerAccountManagementAPI_loginGet(t *testing.T) {
 						Hint:    "",
 					}, nil)
 
-					mockOAuth.EXPECT().AcceptLoginRequest(gomock.Any(), challenge, subject, true, int64(3600)).Return("", errors.New("Test error"))
+					mockOAuth.EXPECT().AcceptLoginRequest(gomock.Any(), challenge, subject, true, int64(3600), domain.AcrRemembered).Return("", errors.New("Test error"))
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
-					// Nothing
+					mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1}, nil)
 				},
 				expectedStatusCode: 500,
 			},
 			inputBody: "login_challenge=2f5d20b9e8f0404aafe01978a8d92a45",
 		},
+		{
+			TestTable: TestTable{
+				name:      "OK, authorized already but suspended",
+				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
+				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
+					mockOAuth.EXPECT().GetLoginRequest(gomock.Any(), challenge).Return(&domain.OA2LoginRequest{
+						Skip:    true,
+						Subject: "1",
+					}, nil)
+
+					mockOAuth.EXPECT().
+						RejectLoginRequest(gomock.Any(), challenge, "access_denied", service.ErrUserSuspended.Error()).
+						Return("redirectToURL", nil)
+				},
+				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+					dateSuspended := time.Now()
+					mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(&domain.User{Id: 1, DateSuspended: &dateSuspended}, nil)
+				},
+				expectedStatusCode: 302,
+			},
+			inputBody: "login_challenge=2f5d20b9e8f0404aafe01978a8d92a45",
+		},
+		{
+			TestTable: TestTable{
+				name:      "OK, authorized already but deleted",
+				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
+				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
+					mockOAuth.EXPECT().GetLoginRequest(gomock.Any(), challenge).Return(&domain.OA2LoginRequest{
+						Skip:    true,
+						Subject: "1",
+					}, nil)
+
+					mockOAuth.EXPECT().
+						RejectLoginRequest(gomock.Any(), challenge, "access_denied", service.ErrUserNotFound.Error()).
+						Return("redirectToURL", nil)
+				},
+				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+					mockUser.EXPECT().GetUserById(gomock.Any(), uint32(1)).Return(nil, service.ErrUserNotFound)
+				},
+				expectedStatusCode: 302,
+			},
+			inputBody: "login_challenge=2f5d20b9e8f0404aafe01978a8d92a45",
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -261,6 +330,9 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				name:      "BAD, submit is Deny Access",
 				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
+					mockOAuth.EXPECT().
+						GetLoginRequest(gomock.Any(), challenge).
+						Return(&domain.OA2LoginRequest{}, nil)
 					mockOAuth.EXPECT().
 						RejectLoginRequest(gomock.Any(), challenge, "access_denied", "The resource owner denied the request").
 						Return("redirectTo", nil)
@@ -277,6 +349,9 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				name:      "OK, reject login request",
 				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
+					mockOAuth.EXPECT().
+						GetLoginRequest(gomock.Any(), challenge).
+						Return(&domain.OA2LoginRequest{}, nil)
 					mockOAuth.EXPECT().
 						RejectLoginRequest(gomock.Any(), challenge, "access_denied", "The resource owner denied the request").
 						Return("", service.ErrUserNotFound)
@@ -293,7 +368,9 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				name:      "OK, AuthN is bad",
 				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
-					// Nothing
+					mockOAuth.EXPECT().
+						GetLoginRequest(gomock.Any(), challenge).
+						Return(&domain.OA2LoginRequest{}, nil)
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
 					mockUser.EXPECT().
@@ -304,6 +381,24 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 			},
 			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&submit=" + submitLogIn,
 		},
+		{
+			TestTable: TestTable{
+				name:      "OK, AuthN is bad, user is suspended",
+				challenge: "2f5d20b9e8f0404aafe01978a8d92a45",
+				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
+					mockOAuth.EXPECT().
+						GetLoginRequest(gomock.Any(), challenge).
+						Return(&domain.OA2LoginRequest{}, nil)
+				},
+				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
+					mockUser.EXPECT().
+						SignIn(gomock.Any(), &service.UserSignInInput{Login: "foo@bar.com", Password: "foobar"}).
+						Return(nil, service.ErrUserSuspended)
+				},
+				expectedStatusCode: 400,
+			},
+			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&login=foo%40bar.com&password=foobar&submit=" + submitLogIn,
+		},
 		{
 			TestTable: TestTable{
 				name:      "BAD, get login request",
@@ -314,13 +409,11 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 						Return(nil, errors.New("Test error"))
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
-					mockUser.EXPECT().
-						SignIn(gomock.Any(), &service.UserSignInInput{"foo@bar.com", "foobar"}).
-						Return(testUser, nil)
+					// Nothing
 				},
 				expectedStatusCode: 500,
 			},
-			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&email=foo%40bar.com&password=foobar&submit=" + submitLogIn,
+			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&login=foo%40bar.com&password=foobar&submit=" + submitLogIn,
 		},
 		{
 			TestTable: TestTable{
@@ -329,19 +422,19 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
 					mockOAuth.EXPECT().
 						GetLoginRequest(gomock.Any(), challenge).
-						Return(nil, nil)
+						Return(&domain.OA2LoginRequest{}, nil)
 					mockOAuth.EXPECT().
-						AcceptLoginRequest(gomock.Any(), challenge, "1", false, int64(3600)).
+						AcceptLoginRequest(gomock.Any(), challenge, "1", false, int64(3600), domain.AcrPassword).
 						Return("", errors.New("Test error"))
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
 					mockUser.EXPECT().
-						SignIn(gomock.Any(), &service.UserSignInInput{"foo@bar.com", "foobar"}).
+						SignIn(gomock.Any(), &service.UserSignInInput{Login: "foo@bar.com", Password: "foobar"}).
 						Return(testUser, nil)
 				},
 				expectedStatusCode: 500,
 			},
-			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&email=foo%40bar.com&password=foobar&submit=" + submitLogIn,
+			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&login=foo%40bar.com&password=foobar&submit=" + submitLogIn,
 		},
 		{
 			TestTable: TestTable{
@@ -350,19 +443,19 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
 					mockOAuth.EXPECT().
 						GetLoginRequest(gomock.Any(), challenge).
-						Return(nil, nil)
+						Return(&domain.OA2LoginRequest{}, nil)
 					mockOAuth.EXPECT().
-						AcceptLoginRequest(gomock.Any(), challenge, "1", false, int64(3600)).
+						AcceptLoginRequest(gomock.Any(), challenge, "1", false, int64(3600), domain.AcrPassword).
 						Return("redirectTo", nil)
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
 					mockUser.EXPECT().
-						SignIn(gomock.Any(), &service.UserSignInInput{"foo@bar.com", "foobar"}).
+						SignIn(gomock.Any(), &service.UserSignInInput{Login: "foo@bar.com", Password: "foobar"}).
 						Return(testUser, nil)
 				},
 				expectedStatusCode: 302,
 			},
-			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&email=foo%40bar.com&password=foobar&submit=" + submitLogIn,
+			requestBody: "challenge=2f5d20b9e8f0404aafe01978a8d92a45&login=foo%40bar.com&password=foobar&submit=" + submitLogIn,
 		},
 		{
 			TestTable: TestTable{
@@ -371,14 +464,14 @@ func TestHandlerAccountManagementAPI_loginPost(t *testing.T) {
 				mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2, challenge string) {
 					mockOAuth.EXPECT().
 						GetLoginRequest(gomock.Any(), challenge).
-						Return(nil, nil)
+						Return(&domain.OA2LoginRequest{}, nil)
 					mockOAuth.EXPECT().
-						AcceptLoginRequest(gomock.Any(), challenge, "1", true, int64(3600)).
+						AcceptLoginRequest(gomock.Any(), challenge, "1", true, int64(3600), domain.AcrPassword).
 						Return("redirectTo", nil)
 				},
 				mockBehaviorUser: func(mockUser *mock_service.MockUser) {
 					mockUser.EXPECT().
-						SignIn(gomock.Any(), &service.UserSignInInput{"foo@bar.com", "foobar"}).
+						SignIn(gomock.Any(), &service.UserSignInInput{Login: "foo@bar.com", Password: "foobar"}).
 						Return(testUser, nil)
 				},
 				expectedStatusCode: 302,
@@ -20,11 +20,9 @@ import (
 // @Router      /signup [get]
 func (h *HandlerAccountManagementAPI) signupGet(context *gin.Context) {
 	// Render login html.
-	// TODO: csrfToken for forms.
-	context.HTML(http.StatusOK, "signup.html",
+	response.HTML(context, http.StatusOK, "signup.html",
 		gin.H{
-			"csrfToken": "",
-			"action":    PathSignup,
+			"action": PathSignup,
 		})
 }
 
@@ -55,9 +53,10 @@ func (h *HandlerAccountManagementAPI) signupPost(context *gin.Context) {
 		Email:    userEmail,
 		Password: userPassword,
 	}
-	if err := h.services.User.SignUp(context, inputUserData); err != nil {
+	if err := h.services.Account.SignUp(context, inputUserData); err != nil {
 		var statusCode int
-		if errors.Is(err, service.ErrUserAlreadyExist) {
+		if errors.Is(err, service.ErrUserAlreadyExist) || errors.Is(err, service.ErrPasswordPolicy) ||
+			errors.Is(err, service.ErrEmailInvalid) || errors.Is(err, service.ErrUsernameInvalid) {
 			statusCode = http.StatusBadRequest
 		} else {
 			statusCode = http.StatusInternalServerError
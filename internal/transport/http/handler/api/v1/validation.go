@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+func init() {
+	// Name the invalid fields by their JSON names.
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+
+			return name
+		})
+	}
+}
+
+// abortValidationError responds with the invalid fields of the request body.
+func abortValidationError(context *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Request body is malformed.",
+		})
+		return
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields[fieldError.Field()] = validationMessage(fieldError)
+	}
+
+	context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error":  "Validation failed.",
+		"fields": fields,
+	})
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "Is required."
+	case "min":
+		if isNumber(fieldError.Kind()) {
+			return fmt.Sprintf("Must be at least %s.", fieldError.Param())
+		}
+
+		return fmt.Sprintf("Must be at least %s characters long.", fieldError.Param())
+	case "max":
+		if isNumber(fieldError.Kind()) {
+			return fmt.Sprintf("Must be at most %s.", fieldError.Param())
+		}
+
+		return fmt.Sprintf("Must be at most %s characters long.", fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("Must be one of: %s.", strings.ReplaceAll(fieldError.Param(), " ", ", "))
+	case "alphanum":
+		return "Must contain only letters and digits."
+	case "email":
+		return "Must be an email address."
+	case "bcp47_language_tag":
+		return "Must be a BCP 47 language tag, e.g. \"en-US\"."
+	default:
+		return "Is invalid."
+	}
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
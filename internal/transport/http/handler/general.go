@@ -3,15 +3,19 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"service-account/internal/transport/http/coockie"
 	v1 "service-account/internal/transport/http/handler/api/v1"
+	"service-account/internal/transport/http/middleware"
 	"service-account/internal/transport/http/response"
 	"service-account/pkg/logger"
 )
 
 func (h *Handler) initGeneralRoutes(router *gin.Engine) {
 	// Main/Home page
-	router.GET(pathRoot, h.rootGet)
+	router.GET(pathRoot,
+		middleware.LoadSession(h.services.Sessions),
+		middleware.RefreshTokens(h.services.OAuth2, h.services.Sessions),
+		h.rootGet,
+	)
 	// 404
 	router.NoRoute(h.notFound)
 }
@@ -21,24 +25,28 @@ func (h *Handler) rootGet(context *gin.Context) {
 	// https://<hydra-public>:4444/oauth2/auth?prompt=login&max_age=60&id_token_hint=...'
 	// SRC: https://www.ory.sh/docs/hydra/concepts/login#login-sessions-prompt-max_age-id_token_hint
 	// We can get login_challenge by sent id_token_hint for re-auth automaticly.
-	accessToken, _ := coockie.GetValue(context.Request, "access_token")
+	// The access token was refreshed and introspected by the middleware if necessary.
+	token := middleware.GetSessionToken(context)
 	var isAuth bool
 
-	if accessToken != "" {
-		// Get introspect token request.
-		tokenIntrospection, err := h.services.OAuth2.IntrospectOAuth2Token(context, accessToken)
-		if err != nil {
-			// Error request to hydra OAuth admin API.
-			response.AbortError(context, http.StatusInternalServerError, err)
-			return
+	if token != nil && token.AccessToken != "" {
+		tokenIntrospection := middleware.GetTokenIntrospection(context)
+		if tokenIntrospection == nil {
+			// Get introspect token request.
+			var err error
+			tokenIntrospection, err = h.services.OAuth2.IntrospectOAuth2Token(context, token.AccessToken)
+			if err != nil {
+				// Error request to hydra OAuth admin API.
+				response.AbortError(context, http.StatusInternalServerError, err)
+				return
+			}
 		}
 
 		isAuth = tokenIntrospection.Active
 		if isAuth {
 			// Get OpenID Token.
-			var idToken, err = coockie.GetValue(context.Request, "id_token")
-			if err == nil {
-				logoutUrl := h.services.OAuth2.GenerateLogoutURL(idToken, "", "")
+			if token.IdToken != "" {
+				logoutUrl := h.services.OAuth2.GenerateLogoutURL(token.IdToken, "", "")
 
 				// Token is valid.
 				// Render home html with auth info.
@@ -50,9 +58,7 @@ func (h *Handler) rootGet(context *gin.Context) {
 			}
 
 			// Can't get OpenID.
-			logger.Error("handlerRootGet() - GetCookieValue(id_token)\n",
-				logger.NamedError("error", err),
-			)
+			logger.Error("handlerRootGet() - session has no id_token")
 		}
 	}
 
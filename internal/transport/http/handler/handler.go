@@ -6,12 +6,16 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 	_ "service-account/api"
 	"service-account/internal/service"
+	"service-account/internal/transport/http/handler/api/scim"
 	"service-account/internal/transport/http/handler/api/v1"
+	"service-account/internal/transport/http/middleware"
 )
 
 const (
 	// Paths
 	pathRoot = "/"
+	// The tenant's routes are prefixed with its id when the tenant isn't resolved by the host.
+	pathTenant = "/t/:" + middleware.ParamTenant
 )
 
 type Handler struct {
@@ -26,6 +30,12 @@ func NewHandler(services *service.Services) *Handler {
 
 func (h *Handler) Init() *gin.Engine {
 	router := gin.Default()
+	// The services get gin's context, the request's context values (the tenant, the request info) are read through it.
+	router.ContextWithFallback = true
+	// The audit events are annotated with the client's IP and user agent.
+	router.Use(middleware.RequestInfo())
+	// The handlers read and change the users of the request's tenant.
+	router.Use(middleware.ResolveTenant(h.services.Tenants))
 	// Init HTML Glob
 	h.initHTMLGlob(router)
 	// Init general routes.
@@ -45,5 +55,11 @@ func (h *Handler) initAPI(router *gin.Engine) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	// Init API v1 h.
 	handlersV1 := v1.NewHandlerAccountManagementAPI(h.services)
-	handlersV1.Init(&router.RouterGroup)
+	// Init SCIM API.
+	handlersSCIM := scim.NewHandlerSCIM(h.services)
+	// The routes of the tenant resolved by the host and the ones of the tenant of the path.
+	for _, group := range []*gin.RouterGroup{&router.RouterGroup, router.Group(pathTenant)} {
+		handlersV1.Init(group)
+		handlersSCIM.Init(group)
+	}
 }
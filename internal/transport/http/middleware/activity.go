@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"service-account/internal/service"
+)
+
+// TrackActivity collects the last online date of the authenticated token's subject,
+// it's used after the Authenticate middleware.
+func TrackActivity(activity service.Activity) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if userId, ok := GetSubjectUserId(context); ok {
+			activity.Touch(context, userId)
+		}
+
+		context.Next()
+	}
+}
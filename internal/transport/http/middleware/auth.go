@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"strings"
+)
+
+const (
+	// Realm of the protected resources.
+	realm = "service-account"
+	// Context keys.
+	contextKeyTokenIntrospection = "token_introspection"
+	// Bearer token error codes.
+	// SRC: https://www.rfc-editor.org/rfc/rfc6750#section-3.1
+	errInvalidRequest    = "invalid_request"
+	errInvalidToken      = "invalid_token"
+	errInsufficientScope = "insufficient_scope"
+)
+
+// Authenticate introspects the access token of the request and stores the result in the context.
+// The token is taken from the "Authorization: Bearer" header or from the browser session loaded by LoadSession,
+// the session token's introspection made by RefreshTokens is reused. The token issued to the client of another tenant
+// than the request's one is rejected, its subject is another tenant's user.
+// The service accounts' API keys ("sa_live_...") are accepted as the bearer tokens too, they're checked by the service
+// instead of Hydra and find the request's tenant's accounts only.
+func Authenticate(oa2 service.OAuth2, tenants service.Tenants, serviceAccounts service.ServiceAccounts) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if tokenIntrospection := GetTokenIntrospection(context); tokenIntrospection != nil && context.GetHeader("Authorization") == "" {
+			checkTokenIntrospection(context, tenants, serviceAccounts, tokenIntrospection)
+			return
+		}
+
+		accessToken, ok := getAccessToken(context)
+		if !ok {
+			abortBearerError(context, http.StatusBadRequest, errInvalidRequest, "The Authorization header is malformed.", "")
+			return
+		}
+
+		if accessToken == "" {
+			// The request lacks any authentication information, so don't include an error code.
+			// SRC: https://www.rfc-editor.org/rfc/rfc6750#section-3.1
+			context.Header("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s"`, realm))
+			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Access Token is not present.",
+			})
+			return
+		}
+
+		if strings.HasPrefix(accessToken, domain.ServiceAccountKeyPrefix) {
+			authenticateServiceAccount(context, serviceAccounts, accessToken)
+			return
+		}
+
+		// Get introspect token request.
+		tokenIntrospection, err := oa2.IntrospectOAuth2Token(context, accessToken)
+		if err != nil {
+			// Error request to hydra OAuth admin API.
+			logger.Error("Authenticate() - IntrospectOAuth2Token",
+				logger.NamedError("error", err),
+			)
+			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		checkTokenIntrospection(context, tenants, serviceAccounts, tokenIntrospection)
+	}
+}
+
+// authenticateServiceAccount stores the introspection of the service account's API key in the context.
+func authenticateServiceAccount(context *gin.Context, serviceAccounts service.ServiceAccounts, apiKey string) {
+	tokenIntrospection, err := serviceAccounts.Authenticate(context, apiKey)
+	if err != nil {
+		if errors.Is(err, service.ErrServiceAccountKeyInvalid) {
+			abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "The API key is not valid.", "")
+			return
+		}
+
+		logger.Error("Authenticate() - ServiceAccounts.Authenticate",
+			logger.NamedError("error", err),
+		)
+		context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	context.Set(contextKeyTokenIntrospection, tokenIntrospection)
+	context.Next()
+}
+
+func checkTokenIntrospection(context *gin.Context, tenants service.Tenants, serviceAccounts service.ServiceAccounts, tokenIntrospection *domain.OA2TokenIntrospection) {
+	if !tokenIntrospection.Active {
+		abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "Access Token is not active.", "")
+		return
+	}
+
+	if tokenIntrospection.Sub == nil {
+		abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "The token's subject is not present.", "")
+		return
+	}
+
+	var clientId string
+	if tokenIntrospection.ClientId != nil {
+		clientId = *tokenIntrospection.ClientId
+	}
+
+	if strings.HasPrefix(clientId, domain.ServiceAccountClientPrefix) {
+		// The service account's Hydra client belongs to the account's tenant.
+		if _, err := serviceAccounts.ClientAccount(context, clientId); err != nil {
+			if errors.Is(err, service.ErrServiceAccountNotFound) {
+				abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "The token was issued to another tenant's client.", "")
+				return
+			}
+
+			logger.Error("Authenticate() - ServiceAccounts.ClientAccount",
+				logger.NamedError("error", err),
+			)
+			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	} else if tenants.ClientTenant(clientId).Id != domain.TenantId(context) {
+		abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "The token was issued to another tenant's client.", "")
+		return
+	}
+
+	context.Set(contextKeyTokenIntrospection, tokenIntrospection)
+	context.Next()
+}
+
+// RequireScopes aborts the request if the introspected token wasn't granted all the given scopes.
+// Must be used after Authenticate.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		tokenIntrospection := GetTokenIntrospection(context)
+		if tokenIntrospection == nil {
+			abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "Access Token is not present.", "")
+			return
+		}
+
+		if !tokenIntrospection.HasScopes(scopes...) {
+			AbortInsufficientScope(context, scopes...)
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// RequireClients aborts the request unless the introspected token is the client's own one (the subject is the client,
+// e.g. granted by the client credentials grant) and the client is one of the given clients.
+// The users' tokens are rejected whatever their scopes are. Must be used after Authenticate.
+func RequireClients(clients ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		tokenIntrospection := GetTokenIntrospection(context)
+		if tokenIntrospection == nil {
+			abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "Access Token is not present.", "")
+			return
+		}
+
+		if !isClientToken(tokenIntrospection, clients) {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "The token wasn't issued to the allowed client.",
+			})
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// isClientToken reports whether the token's subject is the client it's issued to and the client is one of the clients.
+func isClientToken(tokenIntrospection *domain.OA2TokenIntrospection, clients []string) bool {
+	if tokenIntrospection.Sub == nil || tokenIntrospection.ClientId == nil || *tokenIntrospection.Sub != *tokenIntrospection.ClientId {
+		return false
+	}
+
+	for _, client := range clients {
+		if client == *tokenIntrospection.ClientId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetTokenIntrospection returns the token introspection stored by Authenticate.
+func GetTokenIntrospection(context *gin.Context) *domain.OA2TokenIntrospection {
+	value, exists := context.Get(contextKeyTokenIntrospection)
+	if !exists {
+		return nil
+	}
+
+	tokenIntrospection, _ := value.(*domain.OA2TokenIntrospection)
+	return tokenIntrospection
+}
+
+// AbortInsufficientScope aborts the request with the "insufficient_scope" error.
+func AbortInsufficientScope(context *gin.Context, scopes ...string) {
+	abortBearerError(context, http.StatusForbidden, errInsufficientScope, "No permission.", strings.Join(scopes, " "))
+}
+
+func getAccessToken(context *gin.Context) (string, bool) {
+	authorization := context.GetHeader("Authorization")
+	if authorization == "" {
+		// Fallback to the browser session.
+		if token := GetSessionToken(context); token != nil {
+			return token.AccessToken, true
+		}
+
+		return "", true
+	}
+
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+func abortBearerError(context *gin.Context, statusCode int, errCode string, description string, scope string) {
+	challenge := fmt.Sprintf(`Bearer realm="%s", error="%s", error_description="%s"`, realm, errCode, description)
+	if scope != "" {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+
+	context.Header("WWW-Authenticate", challenge)
+	context.AbortWithStatusJSON(statusCode, gin.H{
+		"error": description,
+	})
+}
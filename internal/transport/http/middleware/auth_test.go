@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"service-account/internal/config"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	mock_service "service-account/internal/service/mocks"
+	"testing"
+)
+
+type mockBehaviorOAuth2 func(mockOAuth *mock_service.MockOAuth2)
+
+type TestTableAuth struct {
+	name                        string
+	host                        string
+	authorization               string
+	requiredScopes              []string
+	mockBehaviorOAuth2          mockBehaviorOAuth2
+	mockBehaviorServiceAccounts func(mockServiceAccounts *mock_service.MockServiceAccounts)
+	expectedStatusCode          int
+	expectedWWWAuthenticate     string
+}
+
+func TestAuthenticate(t *testing.T) {
+	subject := "1"
+	acmeClient := "acme-web"
+	serviceAccountClient := "sa-1f2e3d4c5b6a7980"
+	tenants := service.NewTenantService(&config.Config{Tenants: []config.TenantConfig{{
+		Id:      "acme",
+		Name:    "Acme",
+		Hosts:   []string{"account.acme.com"},
+		Clients: []string{acmeClient},
+	}}})
+
+	testTable := []TestTableAuth{
+		{
+			name:           "OK, token has the required scopes",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active: true,
+					Sub:    &subject,
+					Scope:  "openid offline users:read",
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "OK, token of the tenant's client",
+			host:           "account.acme.com",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active:   true,
+					Sub:      &subject,
+					ClientId: &acmeClient,
+					Scope:    "openid users:read",
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "BAD, token of another tenant's client",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active:   true,
+					Sub:      &subject,
+					ClientId: &acmeClient,
+					Scope:    "openid users:read",
+				}, nil)
+			},
+			expectedStatusCode:      http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="invalid_token", error_description="The token was issued to another tenant's client."`,
+		},
+		{
+			name:           "OK, API key of service account",
+			authorization:  "Bearer sa_live_key",
+			requiredScopes: []string{domain.ScopeSCIM},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			mockBehaviorServiceAccounts: func(mockServiceAccounts *mock_service.MockServiceAccounts) {
+				mockServiceAccounts.EXPECT().Authenticate(gomock.Any(), "sa_live_key").Return(&domain.OA2TokenIntrospection{
+					Active:   true,
+					Sub:      &serviceAccountClient,
+					ClientId: &serviceAccountClient,
+					Scope:    "scim",
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "BAD, API key is revoked",
+			authorization:  "Bearer sa_live_key",
+			requiredScopes: []string{domain.ScopeSCIM},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			mockBehaviorServiceAccounts: func(mockServiceAccounts *mock_service.MockServiceAccounts) {
+				mockServiceAccounts.EXPECT().Authenticate(gomock.Any(), "sa_live_key").Return(nil, service.ErrServiceAccountKeyInvalid)
+			},
+			expectedStatusCode:      http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="invalid_token", error_description="The API key is not valid."`,
+		},
+		{
+			name:           "OK, token of the tenant's service account client",
+			host:           "account.acme.com",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeSCIM},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active:   true,
+					Sub:      &serviceAccountClient,
+					ClientId: &serviceAccountClient,
+					Scope:    "scim",
+				}, nil)
+			},
+			mockBehaviorServiceAccounts: func(mockServiceAccounts *mock_service.MockServiceAccounts) {
+				mockServiceAccounts.EXPECT().ClientAccount(gomock.Any(), serviceAccountClient).Return(&domain.ServiceAccount{Id: 3, TenantId: "acme"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "BAD, token of another tenant's service account client",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeSCIM},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active:   true,
+					Sub:      &serviceAccountClient,
+					ClientId: &serviceAccountClient,
+					Scope:    "scim",
+				}, nil)
+			},
+			mockBehaviorServiceAccounts: func(mockServiceAccounts *mock_service.MockServiceAccounts) {
+				mockServiceAccounts.EXPECT().ClientAccount(gomock.Any(), serviceAccountClient).Return(nil, service.ErrServiceAccountNotFound)
+			},
+			expectedStatusCode:      http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="invalid_token", error_description="The token was issued to another tenant's client."`,
+		},
+		{
+			name:           "BAD, token is not present",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedStatusCode:      http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer realm="service-account"`,
+		},
+		{
+			name:           "BAD, authorization header is malformed",
+			authorization:  "Basic dXNlcjpwYXNz",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedStatusCode:      http.StatusBadRequest,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="invalid_request", error_description="The Authorization header is malformed."`,
+		},
+		{
+			name:           "BAD, token is not active",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active: false,
+				}, nil)
+			},
+			expectedStatusCode:      http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="invalid_token", error_description="Access Token is not active."`,
+		},
+		{
+			name:           "BAD, token lacks the required scopes",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead, domain.ScopeUsersAdmin},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(&domain.OA2TokenIntrospection{
+					Active: true,
+					Sub:    &subject,
+					Scope:  "openid users:read",
+				}, nil)
+			},
+			expectedStatusCode:      http.StatusForbidden,
+			expectedWWWAuthenticate: `Bearer realm="service-account", error="insufficient_scope", error_description="No permission.", scope="users:read users:admin"`,
+		},
+		{
+			name:           "BAD, IntrospectOAuth2Token error",
+			authorization:  "Bearer token",
+			requiredScopes: []string{domain.ScopeUsersRead},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "token").Return(nil, errors.New("Test error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockServiceAccounts := mock_service.NewMockServiceAccounts(ctrl)
+			if testCase.mockBehaviorServiceAccounts != nil {
+				testCase.mockBehaviorServiceAccounts(mockServiceAccounts)
+			}
+
+			// Init Endpoint
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			// The tenant is read from the request's context like in the service's router.
+			r.ContextWithFallback = true
+			r.GET("/protected", ResolveTenant(tenants), Authenticate(mockOAuth2, tenants, mockServiceAccounts), RequireScopes(testCase.requiredScopes...), func(context *gin.Context) {
+				context.Status(http.StatusOK)
+			})
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/protected", nil)
+			if testCase.host != "" {
+				req.Host = testCase.host
+			}
+			if testCase.authorization != "" {
+				req.Header.Set("Authorization", testCase.authorization)
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("WWW-Authenticate"), testCase.expectedWWWAuthenticate)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/transport/http/coockie"
+	"service-account/pkg/logger"
+)
+
+const (
+	// Length of the random CSRF token in bytes.
+	csrfTokenLength = 32
+	// The form field and the header of the submitted CSRF token.
+	CSRFFormField = "_csrf"
+	CSRFHeader    = "X-CSRF-Token"
+	// Context keys.
+	contextKeyCSRFToken = "csrf_token"
+)
+
+// CSRF protects the forms with the double-submit cookie: the token is issued in the cookie
+// and the unsafe requests must submit the same token in the "_csrf" form field or the "X-CSRF-Token" header.
+// The token is available to the templates by GetCSRFToken.
+func CSRF() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		cookieToken, _ := coockie.GetValue(context.Request, coockie.CSRFToken)
+
+		if !isSafeMethod(context.Request.Method) {
+			submittedToken := context.GetHeader(CSRFHeader)
+			if submittedToken == "" {
+				submittedToken = context.PostForm(CSRFFormField)
+			}
+
+			if cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(submittedToken)) != 1 {
+				logger.Warn("CSRF() - CSRF token mismatch",
+					logger.String("path", context.Request.URL.Path),
+				)
+				context.HTML(http.StatusForbidden, "error.html", gin.H{
+					"Name":        "The form has expired",
+					"Description": "The form was open for too long or was sent from another site.",
+					"Hint":        "Please go back, reload the page and try again.",
+				})
+				context.Abort()
+				return
+			}
+		}
+
+		if cookieToken == "" {
+			var err error
+			if cookieToken, err = generateCSRFToken(); err != nil {
+				logger.Error("CSRF() - generateCSRFToken",
+					logger.NamedError("error", err),
+				)
+				context.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+
+			coockie.SetCSRFToken(context.Writer, cookieToken)
+		}
+
+		context.Set(contextKeyCSRFToken, cookieToken)
+		context.Next()
+	}
+}
+
+// GetCSRFToken returns the CSRF token issued by CSRF to render in the forms.
+func GetCSRFToken(context *gin.Context) string {
+	return context.GetString(contextKeyCSRFToken)
+}
+
+func generateCSRFToken() (string, error) {
+	token := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// SRC: https://www.rfc-editor.org/rfc/rfc9110#section-9.2.1
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"service-account/internal/transport/http/coockie"
+	"strings"
+	"testing"
+)
+
+type TestTableCSRF struct {
+	name               string
+	method             string
+	cookieToken        string
+	formToken          string
+	headerToken        string
+	expectedStatusCode int
+	expectedNewCookie  bool
+}
+
+func TestCSRF(t *testing.T) {
+	testTable := []TestTableCSRF{
+		{
+			name:               "OK, token is issued",
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+			expectedNewCookie:  true,
+		},
+		{
+			name:               "OK, token is submitted in the form",
+			method:             http.MethodPost,
+			cookieToken:        "token",
+			formToken:          "token",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "OK, token is submitted in the header",
+			method:             http.MethodPost,
+			cookieToken:        "token",
+			headerToken:        "token",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "BAD, token is not submitted",
+			method:             http.MethodPost,
+			cookieToken:        "token",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "BAD, token mismatch",
+			method:             http.MethodPost,
+			cookieToken:        "token",
+			formToken:          "forged",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "BAD, cookie is not present",
+			method:             http.MethodPost,
+			formToken:          "token",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Arrange
+			// Init Endpoint
+			var csrfToken string
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.SetHTMLTemplate(template.Must(template.New("error.html").Parse("{{ .Name }}")))
+			r.Any("/form", CSRF(), func(context *gin.Context) {
+				csrfToken = GetCSRFToken(context)
+				context.Status(http.StatusOK)
+			})
+
+			// Create Request
+			w := httptest.NewRecorder()
+			form := url.Values{}
+			if testCase.formToken != "" {
+				form.Set(CSRFFormField, testCase.formToken)
+			}
+			req := httptest.NewRequest(testCase.method, "/form", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if testCase.headerToken != "" {
+				req.Header.Set(CSRFHeader, testCase.headerToken)
+			}
+			if testCase.cookieToken != "" {
+				req.AddCookie(&http.Cookie{Name: coockie.CSRFToken, Value: testCase.cookieToken})
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			cookies := w.Result().Cookies()
+			assert.Equal(t, len(cookies) == 1, testCase.expectedNewCookie)
+			if testCase.expectedNewCookie {
+				assert.Equal(t, cookies[0].Value, csrfToken)
+			} else if w.Code == http.StatusOK {
+				assert.Equal(t, csrfToken, testCase.cookieToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireJSON aborts the unsafe requests whose body isn't JSON. The other sites can't send JSON from the browser
+// without the CORS preflight, while the "text/plain" form with the JSON body is sent as a simple request,
+// so the JSON endpoints of the browser's session aren't reachable by CSRF.
+func RequireJSON() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !isSafeMethod(context.Request.Method) && context.ContentType() != gin.MIMEJSON {
+			context.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error": "Content-Type must be application/json.",
+			})
+			return
+		}
+
+		context.Next()
+	}
+}
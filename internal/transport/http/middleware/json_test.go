@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	testTable := []struct {
+		name               string
+		method             string
+		contentType        string
+		expectedStatusCode int
+	}{
+		{
+			name:               "OK, JSON body",
+			method:             http.MethodPost,
+			contentType:        "application/json; charset=utf-8",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "OK, safe method",
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "BAD, text/plain form with JSON body",
+			method:             http.MethodPost,
+			contentType:        "text/plain",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:               "BAD, Content-Type is not present",
+			method:             http.MethodPost,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			//// Arrange
+			// Init Endpoint
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.Any("/flow", RequireJSON(), func(context *gin.Context) {
+				context.Status(http.StatusOK)
+			})
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/flow", strings.NewReader(`{"accept":true}`))
+			if testCase.contentType != "" {
+				req.Header.Set("Content-Type", testCase.contentType)
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/service"
+	"service-account/pkg/logger"
+	"strconv"
+)
+
+// RequirePermission aborts the request if the token's subject isn't granted the permission by its roles.
+// Must be used after Authenticate.
+func RequirePermission(rbac service.RBAC, permission string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		subject, ok := GetSubjectUserId(context)
+		if !ok {
+			abortBearerError(context, http.StatusUnauthorized, errInvalidToken, "The token's subject user id is in the bad format.", "")
+			return
+		}
+
+		allowed, err := rbac.Can(context, subject, permission, "")
+		if err != nil {
+			logger.Error("RequirePermission() - Can",
+				logger.NamedError("error", err),
+			)
+			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if !allowed {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "No permission.",
+			})
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// GetSubjectUserId returns the user id of the introspected token's subject.
+func GetSubjectUserId(context *gin.Context) (uint32, bool) {
+	tokenIntrospection := GetTokenIntrospection(context)
+	if tokenIntrospection == nil || tokenIntrospection.Sub == nil {
+		return 0, false
+	}
+
+	userId, err := strconv.ParseUint(*tokenIntrospection.Sub, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(userId), true
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"service-account/internal/service"
+	"service-account/internal/service/authz/oauth2"
+	"service-account/internal/transport/http/coockie"
+	"service-account/pkg/logger"
+	"time"
+)
+
+// The access token is refreshed in advance, so it doesn't expire during the request.
+const refreshBeforeExpiry = time.Minute
+
+// RefreshTokens transparently refreshes the browser session's access token using its refresh token
+// when the access token is near expiry or introspects as inactive. Must be used after LoadSession.
+// The session is ended if the refresh token was rejected (e.g. revoked or reused after rotation).
+func RefreshTokens(oa2 service.OAuth2, sessions service.Sessions) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		token := GetSessionToken(context)
+		if token == nil || token.RefreshToken == "" {
+			// Nothing to refresh with.
+			context.Next()
+			return
+		}
+
+		if token.AccessToken != "" && time.Until(token.Expiry) > refreshBeforeExpiry {
+			tokenIntrospection, err := oa2.IntrospectOAuth2Token(context, token.AccessToken)
+			if err != nil {
+				// Let the handlers deal with the unavailable authorization service.
+				logger.Error("RefreshTokens() - IntrospectOAuth2Token",
+					logger.NamedError("error", err),
+				)
+				context.Next()
+				return
+			}
+
+			if tokenIntrospection.Active {
+				// Reuse the introspection in the handlers.
+				context.Set(contextKeyTokenIntrospection, tokenIntrospection)
+				context.Next()
+				return
+			}
+		}
+
+		sessionId := GetSessionId(context)
+		refreshedToken, err := oa2.RefreshToken(context, token)
+		if err != nil {
+			if errors.Is(err, oauth2.ErrInvalidGrant) {
+				logger.Warn("RefreshTokens() - refresh token was rejected, ending the session",
+					logger.NamedError("error", err),
+				)
+				if err = sessions.Delete(context, sessionId); err != nil {
+					logger.Error("RefreshTokens() - Delete",
+						logger.NamedError("error", err),
+					)
+				}
+				coockie.Remove(context.Writer, coockie.Session)
+				clearSession(context)
+			} else {
+				logger.Error("RefreshTokens() - RefreshToken",
+					logger.NamedError("error", err),
+				)
+			}
+
+			context.Next()
+			return
+		}
+
+		// Keep the rotated tokens in the session.
+		if err = sessions.UpdateTokens(context, sessionId, refreshedToken); err != nil {
+			logger.Error("RefreshTokens() - UpdateTokens",
+				logger.NamedError("error", err),
+			)
+		}
+
+		setSession(context, sessionId, refreshedToken)
+		context.Next()
+	}
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/service/authz/oauth2"
+	mock_service "service-account/internal/service/mocks"
+	"service-account/internal/transport/http/coockie"
+	"testing"
+	"time"
+)
+
+type mockBehaviorSessions func(mockSessions *mock_service.MockSessions)
+
+type TestTableRefresh struct {
+	name                 string
+	sessionId            string
+	mockBehaviorSessions mockBehaviorSessions
+	mockBehaviorOAuth2   mockBehaviorOAuth2
+	expectedAccessToken  string
+	expectedCookies      map[string]string
+}
+
+func TestRefreshTokens(t *testing.T) {
+	subject := "1"
+	expiresSoon := time.Now().Add(10 * time.Second)
+	expiresLater := time.Now().Add(time.Hour)
+	refreshedToken := &domain.Token{
+		AccessToken:  "access2",
+		RefreshToken: "refresh2",
+		Expiry:       time.Now().Add(time.Hour),
+		IdToken:      "id",
+	}
+
+	testTable := []TestTableRefresh{
+		{
+			name:      "OK, no session",
+			sessionId: "",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				// Nothing
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedAccessToken: "",
+			expectedCookies:     map[string]string{},
+		},
+		{
+			name:      "OK, no refresh token",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken: "access",
+				}, nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedAccessToken: "access",
+			expectedCookies:     map[string]string{},
+		},
+		{
+			name:      "OK, access token is active",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken:  "access",
+					RefreshToken: "refresh",
+					Expiry:       expiresLater,
+				}, nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "access").Return(&domain.OA2TokenIntrospection{
+					Active: true,
+					Sub:    &subject,
+				}, nil)
+			},
+			expectedAccessToken: "access",
+			expectedCookies:     map[string]string{},
+		},
+		{
+			name:      "OK, access token is near expiry",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken:  "access",
+					RefreshToken: "refresh",
+					Expiry:       expiresSoon,
+					IdToken:      "id",
+				}, nil)
+				mockSessions.EXPECT().UpdateTokens(gomock.Any(), "session", refreshedToken).Return(nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).Return(refreshedToken, nil)
+			},
+			expectedAccessToken: "access2",
+			expectedCookies:     map[string]string{},
+		},
+		{
+			name:      "OK, access token is inactive",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken:  "access",
+					RefreshToken: "refresh",
+					Expiry:       expiresLater,
+					IdToken:      "id",
+				}, nil)
+				mockSessions.EXPECT().UpdateTokens(gomock.Any(), "session", refreshedToken).Return(nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().IntrospectOAuth2Token(gomock.Any(), "access").Return(&domain.OA2TokenIntrospection{
+					Active: false,
+				}, nil)
+				mockOAuth.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).Return(refreshedToken, nil)
+			},
+			expectedAccessToken: "access2",
+			expectedCookies:     map[string]string{},
+		},
+		{
+			name:      "BAD, session is ended",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(nil, nil, service.ErrSessionNotFound)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				// Nothing
+			},
+			expectedAccessToken: "",
+			expectedCookies: map[string]string{
+				coockie.Session: "",
+			},
+		},
+		{
+			name:      "BAD, refresh token was reused",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken:  "access",
+					RefreshToken: "refresh",
+					Expiry:       expiresSoon,
+					IdToken:      "id",
+				}, nil)
+				mockSessions.EXPECT().Delete(gomock.Any(), "session").Return(nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).Return(nil, oauth2.ErrInvalidGrant)
+			},
+			expectedAccessToken: "",
+			expectedCookies: map[string]string{
+				coockie.Session: "",
+			},
+		},
+		{
+			name:      "BAD, authorization service is unavailable",
+			sessionId: "session",
+			mockBehaviorSessions: func(mockSessions *mock_service.MockSessions) {
+				mockSessions.EXPECT().Get(gomock.Any(), "session").Return(&domain.Session{}, &domain.Token{
+					AccessToken:  "access",
+					RefreshToken: "refresh",
+					Expiry:       expiresSoon,
+				}, nil)
+			},
+			mockBehaviorOAuth2: func(mockOAuth *mock_service.MockOAuth2) {
+				mockOAuth.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).Return(nil, errors.New("Test error"))
+			},
+			expectedAccessToken: "access",
+			expectedCookies:     map[string]string{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			//// Arrange
+			mockOAuth2 := mock_service.NewMockOAuth2(ctrl)
+			testCase.mockBehaviorOAuth2(mockOAuth2)
+			mockSessions := mock_service.NewMockSessions(ctrl)
+			testCase.mockBehaviorSessions(mockSessions)
+
+			// Init Endpoint
+			var accessToken string
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.GET("/", LoadSession(mockSessions), RefreshTokens(mockOAuth2, mockSessions), func(context *gin.Context) {
+				if token := GetSessionToken(context); token != nil {
+					accessToken = token.AccessToken
+				}
+				context.Status(http.StatusOK)
+			})
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if testCase.sessionId != "" {
+				req.AddCookie(&http.Cookie{Name: coockie.Session, Value: testCase.sessionId})
+			}
+
+			//// Act
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			//// Assert
+			assert.Equal(t, accessToken, testCase.expectedAccessToken)
+			cookies := map[string]string{}
+			for _, cookie := range w.Result().Cookies() {
+				cookies[cookie.Name] = cookie.Value
+			}
+			assert.Equal(t, cookies, testCase.expectedCookies)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"service-account/internal/domain"
+)
+
+// RequestInfo stores the client's IP and user agent in the request's context, the audit events are annotated with them.
+func RequestInfo() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Request = context.Request.WithContext(domain.WithRequestInfo(context.Request.Context(), &domain.RequestInfo{
+			Ip:        context.ClientIP(),
+			UserAgent: context.Request.UserAgent(),
+		}))
+
+		context.Next()
+	}
+}
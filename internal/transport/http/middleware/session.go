@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+	"service-account/internal/transport/http/coockie"
+	"service-account/pkg/logger"
+)
+
+// Context keys.
+const (
+	contextKeySessionId    = "session_id"
+	contextKeySessionToken = "session_token"
+)
+
+// LoadSession loads the browser session by the session ID cookie and stores its ID and tokens in the context.
+// The cookie of the ended session is removed.
+func LoadSession(sessions service.Sessions) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		sessionId, err := coockie.GetValue(context.Request, coockie.Session)
+		if err != nil || sessionId == "" {
+			context.Next()
+			return
+		}
+
+		_, token, err := sessions.Get(context, sessionId)
+		if err != nil {
+			if errors.Is(err, service.ErrSessionNotFound) {
+				coockie.Remove(context.Writer, coockie.Session)
+			} else {
+				// Let the handlers deal with the absent session.
+				logger.Error("LoadSession() - Get",
+					logger.NamedError("error", err),
+				)
+			}
+
+			context.Next()
+			return
+		}
+
+		setSession(context, sessionId, token)
+		context.Next()
+	}
+}
+
+// GetSessionId returns the browser session's ID stored by LoadSession.
+func GetSessionId(context *gin.Context) string {
+	return context.GetString(contextKeySessionId)
+}
+
+// GetSessionToken returns the browser session's tokens stored by LoadSession.
+func GetSessionToken(context *gin.Context) *domain.Token {
+	value, exists := context.Get(contextKeySessionToken)
+	if !exists {
+		return nil
+	}
+
+	token, _ := value.(*domain.Token)
+	return token
+}
+
+func setSession(context *gin.Context, sessionId string, token *domain.Token) {
+	context.Set(contextKeySessionId, sessionId)
+	context.Set(contextKeySessionToken, token)
+}
+
+func clearSession(context *gin.Context) {
+	context.Set(contextKeySessionId, "")
+	context.Set(contextKeySessionToken, nil)
+}
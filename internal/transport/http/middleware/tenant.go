@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service-account/internal/domain"
+	"service-account/internal/service"
+)
+
+// ParamTenant is the tenant's id of the "/t/:tenant" path prefix.
+const ParamTenant = "tenant"
+
+// ResolveTenant stores the tenant of the request resolved by the "/t/:tenant" path prefix or the host in the request's
+// context, the handlers read and change the users of the tenant only. The request of the unknown tenant is not found.
+func ResolveTenant(tenants service.Tenants) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		tenant, ok := tenants.Resolve(context.Request.Host, context.Param(ParamTenant))
+		if !ok {
+			context.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error": "Tenant not found",
+			})
+			return
+		}
+
+		context.Request = context.Request.WithContext(domain.WithTenant(context.Request.Context(), tenant))
+		context.Next()
+	}
+}
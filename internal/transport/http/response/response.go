@@ -2,6 +2,8 @@ package response
 
 import (
 	"github.com/gin-gonic/gin"
+	"service-account/internal/domain"
+	"service-account/internal/transport/http/middleware"
 	"service-account/pkg/logger"
 )
 
@@ -14,3 +16,15 @@ func AbortMessage(context *gin.Context, statusCode int, msg string) {
 	logger.Error(msg)
 	context.String(statusCode, msg)
 }
+
+// HTML renders the template with the CSRF token of the request's forms.
+// The page has the branding of the request's tenant unless the handler set the one of the flow's client.
+func HTML(context *gin.Context, statusCode int, name string, data gin.H) {
+	data["csrfToken"] = middleware.GetCSRFToken(context)
+	if _, ok := data["branding"]; !ok {
+		if tenant := domain.TenantFromContext(context); tenant != nil {
+			data["branding"] = tenant.Branding
+		}
+	}
+	context.HTML(statusCode, name, data)
+}
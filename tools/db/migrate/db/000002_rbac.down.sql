@@ -0,0 +1,3 @@
+DROP TABLE tb_user_roles;
+DROP TABLE tb_role_permissions;
+DROP TABLE tb_roles;
\ No newline at end of file
@@ -0,0 +1,31 @@
+CREATE TABLE public.tb_roles (
+    name varchar(64) NOT NULL,
+    description varchar(255) NOT NULL DEFAULT '',
+    CONSTRAINT tb_roles_pk PRIMARY KEY (name)
+);
+
+CREATE TABLE public.tb_role_permissions (
+    role varchar(64) NOT NULL,
+    permission varchar(128) NOT NULL,
+    CONSTRAINT tb_role_permissions_pk PRIMARY KEY (role, permission),
+    CONSTRAINT tb_role_permissions_role_fk FOREIGN KEY (role) REFERENCES public.tb_roles (name) ON DELETE CASCADE
+);
+
+CREATE TABLE public.tb_user_roles (
+    user_id integer NOT NULL,
+    role varchar(64) NOT NULL,
+    date_assigned timestamptz NOT NULL,
+    CONSTRAINT tb_user_roles_pk PRIMARY KEY (user_id, role),
+    CONSTRAINT tb_user_roles_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE,
+    CONSTRAINT tb_user_roles_role_fk FOREIGN KEY (role) REFERENCES public.tb_roles (name) ON DELETE CASCADE
+);
+
+INSERT INTO public.tb_roles (name, description)
+VALUES
+    ('admin', 'Administrator of the user accounts.');
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:write'),
+    ('admin', 'roles:manage');
@@ -0,0 +1 @@
+DROP TABLE tb_sessions;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE public.tb_sessions (
+    id char(64) NOT NULL,
+    sid varchar(64) NOT NULL DEFAULT '',
+    subject varchar(64) NOT NULL DEFAULT '',
+    data bytea NOT NULL,
+    date_created timestamptz NOT NULL,
+    date_last_seen timestamptz NOT NULL,
+    date_expires timestamptz NOT NULL,
+    CONSTRAINT tb_sessions_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX tb_sessions_sid_idx ON public.tb_sessions (sid);
+CREATE INDEX tb_sessions_subject_idx ON public.tb_sessions (subject);
+CREATE INDEX tb_sessions_date_expires_idx ON public.tb_sessions (date_expires);
@@ -0,0 +1,4 @@
+ALTER TABLE public.tb_users
+    DROP COLUMN version,
+    DROP COLUMN locale,
+    DROP COLUMN display_name;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE public.tb_users
+    ADD COLUMN display_name varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN locale varchar(35) NOT NULL DEFAULT '',
+    ADD COLUMN version integer NOT NULL DEFAULT 1;
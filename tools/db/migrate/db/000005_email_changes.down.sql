@@ -0,0 +1 @@
+DROP TABLE tb_email_changes;
\ No newline at end of file
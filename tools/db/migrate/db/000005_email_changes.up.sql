@@ -0,0 +1,19 @@
+CREATE TABLE public.tb_email_changes (
+    id serial NOT NULL,
+    user_id integer NOT NULL,
+    old_email varchar(255) NOT NULL,
+    new_email varchar(255) NOT NULL,
+    token_hash char(64) NOT NULL,
+    revert_token_hash char(64),
+    date_created timestamptz NOT NULL,
+    date_expires timestamptz NOT NULL,
+    date_confirmed timestamptz,
+    date_revert_expires timestamptz,
+    date_reverted timestamptz,
+    CONSTRAINT tb_email_changes_pk PRIMARY KEY (id),
+    CONSTRAINT tb_email_changes_token_hash UNIQUE (token_hash),
+    CONSTRAINT tb_email_changes_revert_token_hash UNIQUE (revert_token_hash),
+    CONSTRAINT tb_email_changes_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tb_email_changes_user_id_idx ON public.tb_email_changes (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE public.tb_users
+    DROP COLUMN restore_token_hash,
+    DROP COLUMN date_deleted;
\ No newline at end of file
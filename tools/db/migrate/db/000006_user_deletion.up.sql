@@ -0,0 +1,6 @@
+ALTER TABLE public.tb_users
+    ADD COLUMN date_deleted timestamptz,
+    ADD COLUMN restore_token_hash char(64);
+
+CREATE UNIQUE INDEX tb_users_restore_token_hash_idx ON public.tb_users (restore_token_hash);
+CREATE INDEX tb_users_date_deleted_idx ON public.tb_users (date_deleted);
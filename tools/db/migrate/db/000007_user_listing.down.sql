@@ -0,0 +1,5 @@
+DELETE FROM public.tb_role_permissions WHERE role = 'admin' AND permission = 'users:list';
+
+DROP INDEX tb_users_date_registration_idx;
+DROP INDEX tb_users_username_pattern_idx;
+DROP INDEX tb_users_email_pattern_idx;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- The prefix filters of the users listing.
+CREATE INDEX tb_users_email_pattern_idx ON public.tb_users (email varchar_pattern_ops);
+CREATE INDEX tb_users_username_pattern_idx ON public.tb_users (username varchar_pattern_ops);
+-- The keyset pagination of the users listing sorted by the registration date.
+CREATE INDEX tb_users_date_registration_idx ON public.tb_users (date_registration, id);
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'users:list');
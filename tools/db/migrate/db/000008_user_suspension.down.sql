@@ -0,0 +1,10 @@
+DELETE FROM public.tb_role_permissions WHERE role = 'admin' AND permission = 'users:manage';
+
+DROP TABLE public.tb_audit_log;
+
+ALTER TABLE public.tb_users
+    DROP COLUMN date_locked_until,
+    DROP COLUMN failed_signins,
+    DROP COLUMN suspension_reason,
+    DROP COLUMN date_suspended_until,
+    DROP COLUMN date_suspended;
\ No newline at end of file
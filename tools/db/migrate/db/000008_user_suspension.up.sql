@@ -0,0 +1,25 @@
+ALTER TABLE public.tb_users
+    ADD COLUMN date_suspended timestamptz,
+    ADD COLUMN date_suspended_until timestamptz,
+    ADD COLUMN suspension_reason text NOT NULL DEFAULT '',
+    ADD COLUMN failed_signins integer NOT NULL DEFAULT 0,
+    ADD COLUMN date_locked_until timestamptz;
+
+-- The audit trail outlives the users, so it has no foreign keys.
+CREATE TABLE public.tb_audit_log (
+    id bigserial NOT NULL,
+    action varchar(64) NOT NULL,
+    actor_id integer,
+    user_id integer,
+    reason text NOT NULL DEFAULT '',
+    data jsonb,
+    date_created timestamptz NOT NULL,
+    CONSTRAINT tb_audit_log_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX tb_audit_log_user_id_idx ON public.tb_audit_log (user_id, date_created);
+CREATE INDEX tb_audit_log_date_created_idx ON public.tb_audit_log (date_created);
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'users:manage');
@@ -0,0 +1 @@
+DROP INDEX tb_users_date_last_online_idx;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- The inactive users report of the users listing.
+CREATE INDEX tb_users_date_last_online_idx ON public.tb_users (date_last_online, id);
@@ -0,0 +1,14 @@
+DELETE FROM public.tb_role_permissions WHERE role = 'admin' AND permission = 'audit:read';
+
+DROP TRIGGER tb_audit_log_append_only ON public.tb_audit_log;
+DROP FUNCTION public.tb_audit_log_append_only();
+
+DROP INDEX tb_audit_log_action_idx;
+DROP INDEX tb_audit_log_actor_id_idx;
+
+ALTER TABLE public.tb_audit_log
+    DROP COLUMN challenge,
+    DROP COLUMN client_id,
+    DROP COLUMN user_agent,
+    DROP COLUMN ip,
+    DROP COLUMN outcome;
\ No newline at end of file
@@ -0,0 +1,24 @@
+ALTER TABLE public.tb_audit_log
+    ADD COLUMN outcome varchar(16) NOT NULL DEFAULT 'success',
+    ADD COLUMN ip varchar(45) NOT NULL DEFAULT '',
+    ADD COLUMN user_agent text NOT NULL DEFAULT '',
+    ADD COLUMN client_id varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN challenge varchar(255) NOT NULL DEFAULT '';
+
+CREATE INDEX tb_audit_log_actor_id_idx ON public.tb_audit_log (actor_id, date_created);
+CREATE INDEX tb_audit_log_action_idx ON public.tb_audit_log (action, date_created);
+
+-- The audit log is append-only, the records are neither changed nor deleted.
+CREATE FUNCTION public.tb_audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'tb_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tb_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON public.tb_audit_log
+    FOR EACH ROW EXECUTE FUNCTION public.tb_audit_log_append_only();
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'audit:read');
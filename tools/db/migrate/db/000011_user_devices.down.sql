@@ -0,0 +1,3 @@
+DROP INDEX tb_audit_log_signin_idx;
+
+DROP TABLE public.tb_user_devices;
\ No newline at end of file
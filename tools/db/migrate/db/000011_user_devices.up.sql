@@ -0,0 +1,13 @@
+CREATE TABLE public.tb_user_devices (
+    user_id integer NOT NULL,
+    device_id varchar(64) NOT NULL,
+    user_agent text NOT NULL DEFAULT '',
+    ip varchar(45) NOT NULL DEFAULT '',
+    date_first_seen timestamptz NOT NULL,
+    date_last_seen timestamptz NOT NULL,
+    CONSTRAINT tb_user_devices_pk PRIMARY KEY (user_id, device_id),
+    CONSTRAINT tb_user_devices_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+-- The users' login history is read from the audit log.
+CREATE INDEX tb_audit_log_signin_idx ON public.tb_audit_log (user_id, id) WHERE action = 'user.signin';
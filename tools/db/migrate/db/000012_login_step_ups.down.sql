@@ -0,0 +1,3 @@
+DROP INDEX tb_audit_log_signin_outcome_idx;
+
+DROP TABLE public.tb_login_step_ups;
\ No newline at end of file
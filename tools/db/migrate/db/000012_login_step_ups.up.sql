@@ -0,0 +1,17 @@
+CREATE TABLE public.tb_login_step_ups (
+    challenge varchar(255) NOT NULL,
+    user_id integer NOT NULL,
+    code_hash char(64) NOT NULL,
+    remember boolean NOT NULL DEFAULT false,
+    device_id varchar(64) NOT NULL DEFAULT '',
+    attempts integer NOT NULL DEFAULT 0,
+    date_created timestamptz NOT NULL,
+    date_expires timestamptz NOT NULL,
+    CONSTRAINT tb_login_step_ups_pk PRIMARY KEY (challenge),
+    CONSTRAINT tb_login_step_ups_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tb_login_step_ups_date_expires_idx ON public.tb_login_step_ups (date_expires);
+
+-- The recent failed sign-ins of the user are counted by the risk engine.
+CREATE INDEX tb_audit_log_signin_outcome_idx ON public.tb_audit_log (user_id, outcome, date_created) WHERE action = 'user.signin';
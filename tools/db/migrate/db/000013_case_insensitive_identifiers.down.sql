@@ -0,0 +1,6 @@
+DROP INDEX public.tb_users_email;
+DROP INDEX public.tb_users_username;
+
+ALTER TABLE public.tb_users
+    ADD CONSTRAINT tb_users_username UNIQUE (username),
+    ADD CONSTRAINT tb_users_email UNIQUE (email);
\ No newline at end of file
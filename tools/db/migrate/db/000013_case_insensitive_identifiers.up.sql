@@ -0,0 +1,13 @@
+-- The emails' domains are case-insensitive, they are stored lowercased.
+UPDATE public.tb_users
+SET email = substring(email FROM '^(.*)@') || '@' || lower(substring(email FROM '[^@]*$'))
+WHERE email LIKE '%@%';
+
+-- The usernames and emails differing in case only are the same user.
+-- The migration fails if such users already exist, they have to be merged or renamed first.
+ALTER TABLE public.tb_users
+    DROP CONSTRAINT tb_users_username,
+    DROP CONSTRAINT tb_users_email;
+
+CREATE UNIQUE INDEX tb_users_username ON public.tb_users (lower(username));
+CREATE UNIQUE INDEX tb_users_email ON public.tb_users (lower(email));
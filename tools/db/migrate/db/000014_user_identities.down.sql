@@ -0,0 +1,3 @@
+DROP TABLE public.tb_upstream_logins;
+
+DROP TABLE public.tb_user_identities;
\ No newline at end of file
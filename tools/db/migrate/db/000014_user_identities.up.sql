@@ -0,0 +1,30 @@
+CREATE TABLE public.tb_user_identities (
+    id serial NOT NULL,
+    user_id integer NOT NULL,
+    provider varchar(64) NOT NULL,
+    subject varchar(255) NOT NULL,
+    email varchar(255) NOT NULL DEFAULT '',
+    date_created timestamptz NOT NULL,
+    date_last_used timestamptz NOT NULL,
+    CONSTRAINT tb_user_identities_pk PRIMARY KEY (id),
+    CONSTRAINT tb_user_identities_subject UNIQUE (provider, subject),
+    CONSTRAINT tb_user_identities_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tb_user_identities_user_id_idx ON public.tb_user_identities (user_id);
+
+-- The sign-ins waiting for the upstream provider's callback.
+CREATE TABLE public.tb_upstream_logins (
+    state_hash char(64) NOT NULL,
+    provider varchar(64) NOT NULL,
+    challenge varchar(255) NOT NULL,
+    nonce varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    remember boolean NOT NULL DEFAULT false,
+    device_id varchar(64) NOT NULL DEFAULT '',
+    date_created timestamptz NOT NULL,
+    date_expires timestamptz NOT NULL,
+    CONSTRAINT tb_upstream_logins_pk PRIMARY KEY (state_hash)
+);
+
+CREATE INDEX tb_upstream_logins_date_expires_idx ON public.tb_upstream_logins (date_expires);
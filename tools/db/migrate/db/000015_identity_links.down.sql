@@ -0,0 +1,4 @@
+DROP TABLE public.tb_identity_links;
+
+ALTER TABLE public.tb_upstream_logins DROP COLUMN user_id;
+ALTER TABLE public.tb_upstream_logins ALTER COLUMN challenge DROP DEFAULT;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- The signed in user linking the identity provider to the account.
+ALTER TABLE public.tb_upstream_logins ALTER COLUMN challenge SET DEFAULT '';
+ALTER TABLE public.tb_upstream_logins ADD COLUMN user_id integer REFERENCES public.tb_users (id) ON DELETE CASCADE;
+
+-- The identities waiting for the password of the local account with the provider's email.
+CREATE TABLE public.tb_identity_links (
+    token_hash char(64) NOT NULL,
+    challenge varchar(255) NOT NULL,
+    user_id integer NOT NULL,
+    provider varchar(64) NOT NULL,
+    subject varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    remember boolean NOT NULL DEFAULT false,
+    device_id varchar(64) NOT NULL DEFAULT '',
+    date_created timestamptz NOT NULL,
+    date_expires timestamptz NOT NULL,
+    CONSTRAINT tb_identity_links_pk PRIMARY KEY (token_hash),
+    CONSTRAINT tb_identity_links_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tb_identity_links_date_expires_idx ON public.tb_identity_links (date_expires);
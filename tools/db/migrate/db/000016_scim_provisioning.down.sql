@@ -0,0 +1,7 @@
+DROP TABLE public.tb_group_members;
+
+DROP TABLE public.tb_groups;
+
+DROP INDEX public.tb_users_external_id;
+
+ALTER TABLE public.tb_users DROP COLUMN external_id;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- The user's id in the provisioning client, e.g. the HR system's employee id.
+ALTER TABLE public.tb_users ADD COLUMN external_id varchar(255) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX tb_users_external_id ON public.tb_users (external_id) WHERE external_id <> '';
+
+-- The teams of the users provisioned by the HR system.
+CREATE TABLE public.tb_groups (
+    id serial NOT NULL,
+    display_name varchar(255) NOT NULL,
+    external_id varchar(255) NOT NULL DEFAULT '',
+    date_created timestamptz NOT NULL,
+    date_updated timestamptz NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT tb_groups_pk PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX tb_groups_display_name ON public.tb_groups (lower(display_name));
+CREATE UNIQUE INDEX tb_groups_external_id ON public.tb_groups (external_id) WHERE external_id <> '';
+
+CREATE TABLE public.tb_group_members (
+    group_id integer NOT NULL,
+    user_id integer NOT NULL,
+    CONSTRAINT tb_group_members_pk PRIMARY KEY (group_id, user_id),
+    CONSTRAINT tb_group_members_group_fk FOREIGN KEY (group_id) REFERENCES public.tb_groups (id) ON DELETE CASCADE,
+    CONSTRAINT tb_group_members_user_fk FOREIGN KEY (user_id) REFERENCES public.tb_users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tb_group_members_user_id_idx ON public.tb_group_members (user_id);
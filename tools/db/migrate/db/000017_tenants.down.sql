@@ -0,0 +1,25 @@
+DROP INDEX public.tb_groups_display_name;
+DROP INDEX public.tb_groups_external_id;
+
+CREATE UNIQUE INDEX tb_groups_display_name ON public.tb_groups (lower(display_name));
+CREATE UNIQUE INDEX tb_groups_external_id ON public.tb_groups (external_id) WHERE external_id <> '';
+
+ALTER TABLE public.tb_groups DROP COLUMN tenant_id;
+
+ALTER TABLE public.tb_upstream_logins DROP COLUMN tenant_id;
+
+ALTER TABLE public.tb_user_identities
+    DROP CONSTRAINT tb_user_identities_subject,
+    ADD CONSTRAINT tb_user_identities_subject UNIQUE (provider, subject);
+
+ALTER TABLE public.tb_user_identities DROP COLUMN tenant_id;
+
+DROP INDEX public.tb_users_username;
+DROP INDEX public.tb_users_email;
+DROP INDEX public.tb_users_external_id;
+
+CREATE UNIQUE INDEX tb_users_username ON public.tb_users (lower(username));
+CREATE UNIQUE INDEX tb_users_email ON public.tb_users (lower(email));
+CREATE UNIQUE INDEX tb_users_external_id ON public.tb_users (external_id) WHERE external_id <> '';
+
+ALTER TABLE public.tb_users DROP COLUMN tenant_id;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- The products served by the service are the tenants, their users are isolated from each other.
+-- The existing users belong to the default tenant.
+ALTER TABLE public.tb_users ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+
+-- The usernames, emails and external ids are unique within the tenant.
+DROP INDEX public.tb_users_username;
+DROP INDEX public.tb_users_email;
+DROP INDEX public.tb_users_external_id;
+
+CREATE UNIQUE INDEX tb_users_username ON public.tb_users (tenant_id, lower(username));
+CREATE UNIQUE INDEX tb_users_email ON public.tb_users (tenant_id, lower(email));
+CREATE UNIQUE INDEX tb_users_external_id ON public.tb_users (tenant_id, external_id) WHERE external_id <> '';
+
+-- The provider's subject is linked to one user of every tenant.
+ALTER TABLE public.tb_user_identities ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+
+ALTER TABLE public.tb_user_identities
+    DROP CONSTRAINT tb_user_identities_subject,
+    ADD CONSTRAINT tb_user_identities_subject UNIQUE (tenant_id, provider, subject);
+
+-- The provider's callback signs in to the tenant the sign-in was started in.
+ALTER TABLE public.tb_upstream_logins ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+
+ALTER TABLE public.tb_groups ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+
+DROP INDEX public.tb_groups_display_name;
+DROP INDEX public.tb_groups_external_id;
+
+CREATE UNIQUE INDEX tb_groups_display_name ON public.tb_groups (tenant_id, lower(display_name));
+CREATE UNIQUE INDEX tb_groups_external_id ON public.tb_groups (tenant_id, external_id) WHERE external_id <> '';
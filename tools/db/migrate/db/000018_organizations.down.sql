@@ -0,0 +1,14 @@
+DELETE FROM public.tb_role_permissions WHERE role = 'admin' AND permission = 'groups:manage';
+
+ALTER TABLE public.tb_group_members DROP COLUMN role;
+
+-- The organizations' groups would break the tenant-wide uniqueness of the names.
+DELETE FROM public.tb_groups WHERE organization_id IS NOT NULL;
+
+DROP INDEX public.tb_groups_display_name;
+
+CREATE UNIQUE INDEX tb_groups_display_name ON public.tb_groups (tenant_id, lower(display_name));
+
+ALTER TABLE public.tb_groups DROP COLUMN organization_id;
+
+DROP TABLE public.tb_organizations;
\ No newline at end of file
@@ -0,0 +1,29 @@
+-- The organizations of the tenant, e.g. the customers' companies, own the teams of their users.
+CREATE TABLE public.tb_organizations (
+    id serial NOT NULL,
+    tenant_id varchar(64) NOT NULL DEFAULT 'default',
+    name varchar(255) NOT NULL,
+    date_created timestamptz NOT NULL,
+    CONSTRAINT tb_organizations_pk PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX tb_organizations_name ON public.tb_organizations (tenant_id, lower(name));
+
+-- The groups of no organization are the tenant's top-level groups, e.g. the provisioned ones.
+ALTER TABLE public.tb_groups
+    ADD COLUMN organization_id integer,
+    ADD CONSTRAINT tb_groups_organization_fk FOREIGN KEY (organization_id) REFERENCES public.tb_organizations (id) ON DELETE CASCADE;
+
+-- The group's name is unique within the organization.
+DROP INDEX public.tb_groups_display_name;
+
+CREATE UNIQUE INDEX tb_groups_display_name ON public.tb_groups (tenant_id, coalesce(organization_id, 0), lower(display_name));
+
+-- The group's admins manage its members.
+ALTER TABLE public.tb_group_members
+    ADD COLUMN role varchar(16) NOT NULL DEFAULT 'member',
+    ADD CONSTRAINT tb_group_members_role CHECK (role IN ('admin', 'member'));
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'groups:manage');
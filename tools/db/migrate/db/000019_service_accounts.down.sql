@@ -0,0 +1,5 @@
+DELETE FROM public.tb_role_permissions WHERE role = 'admin' AND permission = 'service_accounts:manage';
+
+DROP TABLE public.tb_service_account_keys;
+
+DROP TABLE public.tb_service_accounts;
\ No newline at end of file
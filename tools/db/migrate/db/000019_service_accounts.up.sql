@@ -0,0 +1,43 @@
+-- The non-human accounts of the services calling the API, owned by the team responsible for them.
+CREATE TABLE public.tb_service_accounts (
+    id serial NOT NULL,
+    tenant_id varchar(64) NOT NULL DEFAULT 'default',
+    -- The subject and the client id of the account's tokens, the id of its Hydra client if it's backed by one.
+    client_id varchar(64) NOT NULL,
+    hydra_client boolean NOT NULL DEFAULT false,
+    name varchar(255) NOT NULL,
+    description varchar(1024) NOT NULL DEFAULT '',
+    owner_group_id integer,
+    scope varchar(1024) NOT NULL DEFAULT '',
+    created_by integer,
+    date_created timestamptz NOT NULL,
+    date_updated timestamptz NOT NULL,
+    CONSTRAINT tb_service_accounts_pk PRIMARY KEY (id),
+    CONSTRAINT tb_service_accounts_owner_group_fk FOREIGN KEY (owner_group_id) REFERENCES public.tb_groups (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX tb_service_accounts_client_id ON public.tb_service_accounts (client_id);
+CREATE UNIQUE INDEX tb_service_accounts_name ON public.tb_service_accounts (tenant_id, lower(name));
+
+-- The API keys of the service accounts, only the keys' hashes are stored.
+CREATE TABLE public.tb_service_account_keys (
+    id serial NOT NULL,
+    tenant_id varchar(64) NOT NULL DEFAULT 'default',
+    service_account_id integer NOT NULL,
+    -- The key's first characters telling the keys apart.
+    prefix varchar(32) NOT NULL,
+    key_hash varchar(64) NOT NULL,
+    date_created timestamptz NOT NULL,
+    date_expires timestamptz,
+    date_last_used timestamptz,
+    date_revoked timestamptz,
+    CONSTRAINT tb_service_account_keys_pk PRIMARY KEY (id),
+    CONSTRAINT tb_service_account_keys_account_fk FOREIGN KEY (service_account_id) REFERENCES public.tb_service_accounts (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX tb_service_account_keys_key_hash ON public.tb_service_account_keys (key_hash);
+CREATE INDEX tb_service_account_keys_account_id_idx ON public.tb_service_account_keys (service_account_id);
+
+INSERT INTO public.tb_role_permissions (role, permission)
+VALUES
+    ('admin', 'service_accounts:manage');
@@ -0,0 +1,3 @@
+DROP INDEX tb_audit_log_tenant_id_idx;
+
+ALTER TABLE public.tb_audit_log DROP COLUMN tenant_id;
\ No newline at end of file
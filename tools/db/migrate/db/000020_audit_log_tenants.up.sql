@@ -0,0 +1,14 @@
+-- The tenant's admins only read the events of their tenant.
+ALTER TABLE public.tb_audit_log ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+
+-- The events recorded since the tenants were added belong to the tenant of their user or actor.
+ALTER TABLE public.tb_audit_log DISABLE TRIGGER tb_audit_log_append_only;
+
+UPDATE public.tb_audit_log AS a
+SET tenant_id = u.tenant_id
+FROM public.tb_users AS u
+WHERE u.id = COALESCE(a.user_id, a.actor_id) AND u.tenant_id <> 'default';
+
+ALTER TABLE public.tb_audit_log ENABLE TRIGGER tb_audit_log_append_only;
+
+CREATE INDEX tb_audit_log_tenant_id_idx ON public.tb_audit_log (tenant_id, id);